@@ -515,6 +515,36 @@ func CustomCommand(hostname string, cmd string) (output string, err error) {
 	return output, err
 }
 
+// MySQLRunning asks an agent whether MySQL is running on its host. Unlike agent commands, this is not audited.
+func MySQLRunning(hostname string) (running bool, err error) {
+	agent, token, err := readAgentBasicInfo(hostname)
+	if err != nil {
+		return false, err
+	}
+	mySQLRunningUri := fmt.Sprintf("%s/mysql-status?token=%s", baseAgentUri(agent.Hostname, agent.Port), token)
+	body, err := readResponse(httpGet(mySQLRunningUri))
+	if err != nil {
+		return false, err
+	}
+	err = json.Unmarshal(body, &running)
+	return running, err
+}
+
+// MySQLDatadirDiskFree asks an agent for the free space, in bytes, on the MySQL datadir's filesystem. Unlike agent commands, this is not audited.
+func MySQLDatadirDiskFree(hostname string) (diskFree int64, err error) {
+	agent, token, err := readAgentBasicInfo(hostname)
+	if err != nil {
+		return 0, err
+	}
+	mySQLDatadirDiskFreeUri := fmt.Sprintf("%s/mysql-datadir-available-space?token=%s", baseAgentUri(agent.Hostname, agent.Port), token)
+	body, err := readResponse(httpGet(mySQLDatadirDiskFreeUri))
+	if err != nil {
+		return 0, err
+	}
+	err = json.Unmarshal(body, &diskFree)
+	return diskFree, err
+}
+
 // seedCommandCompleted checks an agent to see if it thinks a seed was completed.
 func seedCommandCompleted(hostname string, seedId int64) (Agent, bool, error) {
 	result := false
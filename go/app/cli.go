@@ -120,6 +120,32 @@ func assignThisInstanceKey() *inst.InstanceKey {
 	return thisInstanceKey
 }
 
+// getTargetInstanceKeys returns the instances an operation applies to: those matching the --tag expression when
+// given without an explicit instance, or else the single given (or deduced) instance
+func getTargetInstanceKeys(instanceKey *inst.InstanceKey) (instanceKeys []inst.InstanceKey) {
+	if instanceKey == nil && *config.RuntimeCLIFlags.Tag != "" {
+		tagged, err := inst.GetInstanceKeysByTags(*config.RuntimeCLIFlags.Tag)
+		if err != nil {
+			log.Fatale(err)
+		}
+		return tagged.GetInstanceKeys()
+	}
+	instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+	if instanceKey == nil {
+		log.Fatalf("Unresolved instance")
+	}
+	return []inst.InstanceKey{*instanceKey}
+}
+
+// printErrantGTIDRemediationPlan prints the statements of an errant GTID remediation, as comments-annotated SQL
+func printErrantGTIDRemediationPlan(plan *inst.ErrantGTIDRemediationPlan) {
+	fmt.Printf("-- gtid-errant-%s: errant GTID on %s: %s\n", plan.Method, plan.InstanceKey.DisplayString(), plan.GtidErrant)
+	fmt.Printf("-- to be issued on %s:\n", plan.TargetKey.DisplayString())
+	for _, statement := range plan.Statements {
+		fmt.Printf("%s;\n", statement)
+	}
+}
+
 func validateInstanceIsFound(instanceKey *inst.InstanceKey) (instance *inst.Instance) {
 	instance, _, err := inst.ReadInstance(instanceKey)
 	if err != nil {
@@ -160,13 +186,19 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	}
 
 	skipDatabaseCommands := false
+	skipContinuousRegistration := false
 	switch command {
 	case "redeploy-internal-db":
 		skipDatabaseCommands = true
 	case "help":
 		skipDatabaseCommands = true
-	case "dump-config":
+	case "dump-config", "config-check":
 		skipDatabaseCommands = true
+	case "complete-commands", "bash-completion", "zsh-completion":
+		skipDatabaseCommands = true
+	case "complete-instances", "complete-clusters":
+		// invoked upon each <TAB>; keep these light
+		skipContinuousRegistration = true
 	}
 
 	instanceKey, err := inst.ParseResolveInstanceKey(instance)
@@ -204,7 +236,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	}
 	inst.SetMaintenanceOwner(owner)
 
-	if !skipDatabaseCommands && !*config.RuntimeCLIFlags.SkipContinuousRegistration {
+	if !skipDatabaseCommands && !skipContinuousRegistration && !*config.RuntimeCLIFlags.SkipContinuousRegistration {
 		process.ContinuousRegistration(string(process.OrchestratorExecutionCliMode), command)
 	}
 	kv.InitKVStores()
@@ -230,7 +262,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey == nil {
 				log.Fatal("Cannot deduce destination:", destination)
 			}
-			replicas, _, err, errs := inst.RelocateReplicas(instanceKey, destinationKey, pattern)
+			replicas, _, err, errs := inst.RelocateReplicasByTags(instanceKey, destinationKey, pattern, *config.RuntimeCLIFlags.Tag)
 			if err != nil {
 				log.Fatale(err)
 			} else {
@@ -586,11 +618,37 @@ func Cli(command string, strict bool, instance string, destination string, owner
 	case registerCliCommand("gtid-errant-reset-master", "Replication, general", `Reset master on instance, remove GTID errant transactions`):
 		{
 			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
-			_, err := inst.ErrantGTIDResetMaster(instanceKey)
-			if err != nil {
-				log.Fatale(err)
+			if *config.RuntimeCLIFlags.Noop {
+				plan, err := inst.PlanErrantGTIDResetMaster(instanceKey)
+				if err != nil {
+					log.Fatale(err)
+				}
+				printErrantGTIDRemediationPlan(plan)
+			} else {
+				_, err := inst.ErrantGTIDResetMaster(instanceKey)
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(instanceKey.DisplayString())
+			}
+		}
+	case registerCliCommand("gtid-errant-inject-empty", "Replication, general", `Inject empty transactions on the cluster's master for the errant GTID set of given instance`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if *config.RuntimeCLIFlags.Noop {
+				plan, err := inst.PlanErrantGTIDInjectEmpty(instanceKey)
+				if err != nil {
+					log.Fatale(err)
+				}
+				printErrantGTIDRemediationPlan(plan)
+			} else {
+				_, clusterMaster, countInjectedTransactions, err := inst.ErrantGTIDInjectEmpty(instanceKey)
+				if err != nil {
+					log.Fatale(err)
+				}
+				log.Infof("Injected %d empty transactions on %+v", countInjectedTransactions, clusterMaster.Key)
+				fmt.Println(clusterMaster.Key.DisplayString())
 			}
-			fmt.Println(instanceKey.DisplayString())
 		}
 	case registerCliCommand("skip-query", "Replication, general", `Skip a single statement on a replica; either when running with GTID or without`):
 		{
@@ -1078,6 +1136,19 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(output)
 		}
+	case registerCliCommand("topology-at", "Information", `Show an ascii-graph of a replication topology as persisted by its latest snapshot at or before --at 'YYYY-MM-DD hh:mm:ss', given the cluster alias or a member of that topology`):
+		{
+			clusterNameOrAlias := clusterAlias
+			if clusterNameOrAlias == "" {
+				clusterNameOrAlias = getClusterName(clusterAlias, instanceKey)
+			}
+			snapshot, err := inst.ReadClusterTopologySnapshotAt(clusterNameOrAlias, *config.RuntimeCLIFlags.ScheduleAt)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(fmt.Sprintf("%s snapshot at %s; master: %+v", snapshot.ClusterName, snapshot.SnapshotTimestamp, snapshot.MasterKey.DisplayString()))
+			fmt.Println(snapshot.ASCII())
+		}
 	case registerCliCommand("topology-tabulated", "Information", `Show an ascii-graph of a replication topology, given a member of that topology`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
@@ -1289,7 +1360,7 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(tag.TagValue)
 			}
 		}
-	case registerCliCommand("tagged", "tags", `List instances tagged by tag-string. Format: "tagname" or "tagname=tagvalue" or comma separated "tag0,tag1=val1,tag2" for intersection of all.`):
+	case registerCliCommand("tagged", "tags", `List instances tagged by tag-string. Format: "tagname" or "tagname=tagvalue" or comma/"and" separated "tag0,tag1=val1 and tag2" for intersection of all, "or" separated for union, e.g. "env=prod and role=reporting or role=backup".`):
 		{
 			tagsString := *config.RuntimeCLIFlags.Tag
 			instanceKeyMap, err := inst.GetInstanceKeysByTags(tagsString)
@@ -1418,9 +1489,9 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				fmt.Println(instanceKey.DisplayString())
 			}
 		}
-	case registerCliCommand("begin-downtime", "Instance management", `Mark an instance as downtimed`):
+	case registerCliCommand("begin-downtime", "Instance management", `Mark an instance, or all instances matching --tag, as downtimed`):
 		{
-			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			instanceKeys := getTargetInstanceKeys(instanceKey)
 			if reason == "" {
 				log.Fatal("--reason option required")
 			}
@@ -1435,13 +1506,16 @@ func Cli(command string, strict bool, instance string, destination string, owner
 				}
 			}
 			duration := time.Duration(durationSeconds) * time.Second
-			err := inst.BeginDowntime(inst.NewDowntime(instanceKey, inst.GetMaintenanceOwner(), reason, duration))
-			if err == nil {
-				log.Infof("Downtime duration: %d seconds", durationSeconds)
-			} else {
-				log.Fatale(err)
+			for _, instanceKey := range instanceKeys {
+				instanceKey := instanceKey
+				err := inst.BeginDowntime(inst.NewDowntime(&instanceKey, inst.GetMaintenanceOwner(), reason, duration))
+				if err == nil {
+					log.Infof("Downtime duration: %d seconds", durationSeconds)
+				} else {
+					log.Fatale(err)
+				}
+				fmt.Println(instanceKey.DisplayString())
 			}
-			fmt.Println(instanceKey.DisplayString())
 		}
 	case registerCliCommand("end-downtime", "Instance management", `Indicate an instance is no longer downtimed`):
 		{
@@ -1452,6 +1526,44 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("begin-cluster-maintenance", "Recovery", `Put a cluster in maintenance mode, suppressing automated recoveries on it`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if reason == "" {
+				log.Fatal("--reason option required")
+			}
+			var durationSeconds int = 0
+			if duration != "" {
+				durationSeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+				if durationSeconds < 0 {
+					log.Fatalf("Duration value must be non-negative. Given value: %d", durationSeconds)
+				}
+			}
+			clusterInfo, err := inst.ReadClusterInfo(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			maintenance := inst.NewClusterMaintenance(clusterInfo.ClusterAlias, inst.GetMaintenanceOwner(), reason, time.Duration(durationSeconds)*time.Second)
+			if err := inst.BeginClusterMaintenance(maintenance); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterInfo.ClusterAlias)
+		}
+	case registerCliCommand("end-cluster-maintenance", "Recovery", `Take a cluster out of maintenance mode`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			clusterInfo, err := inst.ReadClusterInfo(clusterName)
+			if err != nil {
+				log.Fatale(err)
+			}
+			if _, err := inst.EndClusterMaintenance(clusterInfo.ClusterAlias); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(clusterInfo.ClusterAlias)
+		}
 		// Recovery & analysis
 	case registerCliCommand("recover", "Recovery", `Do auto-recovery given a dead instance`), registerCliCommand("recover-lite", "Recovery", `Do auto-recovery given a dead instance. Orchestrator chooses the best course of actionwithout executing external processes`):
 		{
@@ -1499,7 +1611,11 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			if destinationKey != nil {
 				validateInstanceIsFound(destinationKey)
 			}
-			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeover(clusterName, destinationKey)
+			// Progress goes to stderr, keeping stdout for the result
+			onProgress := func(progress *inst.ReplicationCatchupProgress) {
+				fmt.Fprintln(os.Stderr, progress.String())
+			}
+			topologyRecovery, promotedMasterCoordinates, err := logic.GracefulMasterTakeoverWithProgress(clusterName, destinationKey, onProgress)
 			if err != nil {
 				log.Fatale(err)
 			}
@@ -1507,6 +1623,87 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			fmt.Println(*promotedMasterCoordinates)
 			log.Debugf("Promoted %+v as new master. Binlog coordinates at time of promotion: %+v", topologyRecovery.SuccessorKey, *promotedMasterCoordinates)
 		}
+	case registerCliCommand("schedule-graceful-master-takeover", "Recovery", `Schedule a graceful master takeover within a future time window. Requires --at 'YYYY-MM-DD hh:mm:ss'; window length by --duration (default 1h). Optionally designate the new master via '-d designated.instance.com'`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if destinationKey != nil {
+				validateInstanceIsFound(destinationKey)
+			}
+			windowStart := *config.RuntimeCLIFlags.ScheduleAt
+			start, err := time.ParseInLocation(logic.ScheduledTakeoverTimeFormat, windowStart, time.Local)
+			if err != nil {
+				log.Fatalf("--at option required, format: %s", logic.ScheduledTakeoverTimeFormat)
+			}
+			windowSeconds := 3600
+			if duration != "" {
+				if windowSeconds, err = util.SimpleTimeToSeconds(duration); err != nil {
+					log.Fatale(err)
+				}
+			}
+			windowEnd := start.Add(time.Duration(windowSeconds) * time.Second).Format(logic.ScheduledTakeoverTimeFormat)
+			takeover, err := logic.ScheduleMasterTakeover(clusterName, destinationKey, windowStart, windowEnd, inst.GetMaintenanceOwner(), reason)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(takeover.UID)
+		}
+	case registerCliCommand("rolling-restart", "Recovery", `Restart all servers in a cluster, replicas first, then the master following a graceful takeover. Optionally designate the new master via '-d designated.instance.com'`):
+		{
+			clusterName := getClusterName(clusterAlias, instanceKey)
+			if destinationKey != nil {
+				validateInstanceIsFound(destinationKey)
+			}
+			rollingRestart, err := logic.BeginRollingRestart(clusterName, destinationKey, inst.GetMaintenanceOwner())
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.RunRollingRestart(rollingRestart); err != nil {
+				log.Fatale(err)
+			}
+			for _, rollingRestart := range logic.ReadRollingRestarts(clusterName) {
+				for _, instance := range rollingRestart.Instances {
+					fmt.Printf("%s\t%s\t%s\n", instance.Key.DisplayString(), instance.Status, instance.Message)
+				}
+			}
+		}
+	case registerCliCommand("cancel-scheduled-master-takeovers", "Recovery", `Cancel scheduled graceful master takeovers of a cluster which have not started executing`):
+		{
+			clusterInfo, err := inst.ReadClusterInfo(getClusterName(clusterAlias, instanceKey))
+			if err != nil {
+				log.Fatale(err)
+			}
+			takeovers, err := logic.ReadScheduledMasterTakeovers(clusterInfo.ClusterAlias)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, takeover := range takeovers {
+				if takeover.Status != logic.ScheduledTakeoverScheduled {
+					continue
+				}
+				if _, err := logic.CancelScheduledMasterTakeover(takeover.UID, inst.GetMaintenanceOwner()); err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(takeover.UID)
+			}
+		}
+	case registerCliCommand("scheduled-master-takeovers", "Recovery", `List scheduled graceful master takeovers, optionally of a given cluster`):
+		{
+			takeoversClusterAlias := ""
+			if clusterAlias != "" || instanceKey != nil {
+				clusterInfo, err := inst.ReadClusterInfo(getClusterName(clusterAlias, instanceKey))
+				if err != nil {
+					log.Fatale(err)
+				}
+				takeoversClusterAlias = clusterInfo.ClusterAlias
+			}
+			takeovers, err := logic.ReadScheduledMasterTakeovers(takeoversClusterAlias)
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, takeover := range takeovers {
+				fmt.Println(fmt.Sprintf("%s\t%s\t%s\t%s\t%s\t%s\t%s", takeover.UID, takeover.ClusterAlias, takeover.DesignatedKey.DisplayString(), takeover.WindowStart, takeover.WindowEnd, takeover.Status, takeover.StatusMessage))
+			}
+		}
 	case registerCliCommand("replication-analysis", "Recovery", `Request an analysis of potential crash incidents in all known topologies`):
 		{
 			analysis, err := inst.GetReplicationAnalysis("", &inst.ReplicationAnalysisHints{})
@@ -1553,16 +1750,47 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(fmt.Sprintf("%d recoveries acknowldged", countRecoveries))
 		}
+	case registerCliCommand("recovery-report", "Recovery", `Generate a post-mortem report of a recovery, given by --uid: timeline, hook outputs, topology before and after, and estimated data loss`):
+		{
+			recoveryUID := *config.RuntimeCLIFlags.RecoveryUID
+			if recoveryUID == "" {
+				log.Fatal("--uid option required")
+			}
+			report, err := logic.GenerateRecoveryReport(recoveryUID)
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(report.Text())
+		}
 	// Instance meta
-	case registerCliCommand("register-candidate", "Instance, meta", `Indicate that a specific instance is a preferred candidate for master promotion`):
+	case registerCliCommand("register-candidate", "Instance, meta", `Indicate that a specific instance, or all instances matching --tag, is a preferred candidate for master promotion`):
 		{
-			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			instanceKeys := getTargetInstanceKeys(instanceKey)
 			promotionRule, err := inst.ParseCandidatePromotionRule(*config.RuntimeCLIFlags.PromotionRule)
 			if err != nil {
 				log.Fatale(err)
 			}
-			err = inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(instanceKey, promotionRule).WithCurrentTime())
-			if err != nil {
+			var expirySeconds int = 0
+			if duration != "" {
+				expirySeconds, err = util.SimpleTimeToSeconds(duration)
+				if err != nil {
+					log.Fatale(err)
+				}
+			}
+			expiry := time.Duration(expirySeconds) * time.Second
+			for _, instanceKey := range instanceKeys {
+				instanceKey := instanceKey
+				err = inst.RegisterCandidateInstance(inst.NewCandidateDatabaseInstance(&instanceKey, promotionRule).WithCurrentTime().WithExpiry(expiry))
+				if err != nil {
+					log.Fatale(err)
+				}
+				fmt.Println(instanceKey.DisplayString())
+			}
+		}
+	case registerCliCommand("unregister-candidate", "Instance, meta", `Remove the promotion rule of a specific instance`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if _, err := inst.UnregisterCandidateInstance(instanceKey); err != nil {
 				log.Fatale(err)
 			}
 			fmt.Println(instanceKey.DisplayString())
@@ -1585,6 +1813,36 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println(instanceKey.DisplayString())
 		}
+	case registerCliCommand("register-reported-address", "Instance, meta", `Registers the address an instance is known by within its topology, e.g. behind NAT`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if destinationKey == nil {
+				log.Fatal("Cannot deduce reported address:", destination)
+			}
+			address := &inst.ReportedAddress{Key: *instanceKey, ReportedKey: *destinationKey}
+			if err := inst.WriteReportedAddress(address); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("deregister-reported-address", "Instance, meta", `Removes the reported address of an instance`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if err := inst.DeleteReportedAddress(instanceKey); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("reported-addresses", "Instance, meta", `List registered reported addresses of instances`):
+		{
+			addresses, err := inst.ReadReportedAddresses()
+			if err != nil {
+				log.Fatale(err)
+			}
+			for _, address := range addresses {
+				fmt.Println(fmt.Sprintf("%s\t%s", address.Key.DisplayString(), address.ReportedKey.DisplayString()))
+			}
+		}
 	case registerCliCommand("set-heuristic-domain-instance", "Instance, meta", `Associate domain name of given cluster with what seems to be the writer master for that cluster`):
 		{
 			clusterName := getClusterName(clusterAlias, instanceKey)
@@ -1657,6 +1915,16 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			jsonString := config.Config.ToJSONString()
 			fmt.Println(jsonString)
 		}
+	case registerCliCommand("config-check", "Meta", `Check configuration files for unknown settings, invalid values, conflicts and regular expressions`):
+		{
+			problems := config.ConfigurationProblems()
+			for _, problem := range problems {
+				fmt.Println(problem.String())
+			}
+			if config.HasConfigurationErrors(problems) {
+				os.Exit(1)
+			}
+		}
 	case registerCliCommand("show-resolve-hosts", "Meta", `Show the content of the hostname_resolve table. Generally used for debugging`):
 		{
 			resolves, err := inst.ReadAllHostnameResolves()
@@ -1686,6 +1954,25 @@ func Cli(command string, strict bool, instance string, destination string, owner
 			}
 			fmt.Println("Redeployed internal db")
 		}
+	case registerCliCommand("export-backend-data", "Meta", `Write orchestrator's backend state to standard output, as gzipped JSON, for import into another backend`):
+		{
+			if _, err := logic.ExportBackendData(os.Stdout); err != nil {
+				log.Fatale(err)
+			}
+		}
+	case registerCliCommand("import-backend-data", "Meta", `Import backend state written by export-backend-data, read from standard input, and verify it`):
+		{
+			verification, err := logic.ImportBackendData(os.Stdin)
+			if verification != nil {
+				for _, table := range verification.Tables {
+					fmt.Println(fmt.Sprintf("%s\t%d\t%d", table.TableName, table.ExportedRows, table.BackendRows))
+				}
+				fmt.Println(fmt.Sprintf("instances\t%d\t%d", verification.ExportedInstances, verification.ExportedInstances-len(verification.MissingInstances)))
+			}
+			if err != nil {
+				log.Fatale(err)
+			}
+		}
 	case registerCliCommand("internal-suggest-promoted-replacement", "Internal", `Internal only, used to test promotion logic in CI`):
 		{
 			destination := validateInstanceIsFound(destinationKey)
@@ -1704,6 +1991,36 @@ func Cli(command string, strict bool, instance string, destination string, owner
 
 			fmt.Printf("%v\n", output)
 		}
+	case registerCliCommand("rebuild-replica", "Agent", `Rebuild a diverged or lost replica from the data of a healthy replica, optionally given via -d`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatal("Cannot deduce instance:", instance)
+			}
+			rebuild, err := logic.BeginReplicaRebuild(instanceKey, destinationKey, inst.GetMaintenanceOwner())
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.RunReplicaRebuild(rebuild); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
+	case registerCliCommand("reseed-instance", "Instance management", `Reseed an instance from a healthy replica, optionally given via -d, using the MySQL 8 CLONE plugin, and re-attach it as a replica`):
+		{
+			instanceKey, _ = inst.FigureInstanceKey(instanceKey, thisInstanceKey)
+			if instanceKey == nil {
+				log.Fatal("Cannot deduce instance:", instance)
+			}
+			rebuild, err := logic.BeginReseedInstance(instanceKey, destinationKey, inst.GetMaintenanceOwner())
+			if err != nil {
+				log.Fatale(err)
+			}
+			if err := logic.RunReplicaRebuild(rebuild); err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(instanceKey.DisplayString())
+		}
 	case registerCliCommand("disable-global-recoveries", "", `Disallow orchestrator from performing recoveries globally`):
 		{
 			if err := logic.DisableRecovery(); err != nil {
@@ -1754,6 +2071,35 @@ func Cli(command string, strict bool, instance string, destination string, owner
 
 			fmt.Printf("%s\n", strings.Join(asciiPromotionRules, "\n"))
 		}
+		// Shell completion
+	case registerCliCommand("bash-completion", "Completion", `Print a bash completion script. Load with: source <(orchestrator -c bash-completion)`):
+		{
+			fmt.Print(bashCompletionScript)
+		}
+	case registerCliCommand("zsh-completion", "Completion", `Print a zsh completion script. Load with: source <(orchestrator -c zsh-completion)`):
+		{
+			fmt.Print(zshCompletionScript)
+		}
+	case registerCliCommand("complete-commands", "Completion", `List known commands, for shell completion`):
+		{
+			fmt.Println(strings.Join(completionCommands(), "\n"))
+		}
+	case registerCliCommand("complete-instances", "Completion", `List known instances, for shell completion. Cached for CompletionCacheSeconds`):
+		{
+			completionData, err := getCompletionData()
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(strings.Join(completionData.Instances, "\n"))
+		}
+	case registerCliCommand("complete-clusters", "Completion", `List known cluster names and aliases, for shell completion. Cached for CompletionCacheSeconds`):
+		{
+			completionData, err := getCompletionData()
+			if err != nil {
+				log.Fatale(err)
+			}
+			fmt.Println(strings.Join(completionData.Clusters, "\n"))
+		}
 		// Help
 	case "help":
 		{
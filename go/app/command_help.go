@@ -818,6 +818,18 @@ func init() {
 
   orchestrator -c register-candidate
       -i not given, implicitly assumed local hostname
+
+  orchestrator -c register-candidate -i candidate.instance.com --promotion-rule must_not --duration 4h
+      registration expires after 4 hours, rather than after CandidateInstanceExpireMinutes
+
+  orchestrator -c register-candidate --tag "role=candidate" --promotion-rule prefer
+      -i not given, registers all instances matching the tag expression
+	`
+	CommandHelp["unregister-candidate"] = `
+  Remove the promotion rule of a specific instance, such that it is considered neutral until registered again.
+  Example:
+
+  orchestrator -c unregister-candidate -i candidate.instance.com
 	`
 	CommandHelp["register-hostname-unresolve"] = `
   Assigns the given instance a virtual (aka "unresolved") name. When moving replicas under an instance with assigned
@@ -838,6 +850,29 @@ func init() {
   Example:
 
   orchestrator -c deregister-hostname-unresolve -i instance.fqdn.com
+	`
+	CommandHelp["register-reported-address"] = `
+  Registers the address an instance is known by within its topology, as opposed to the address orchestrator connects
+  to it by. This is the case behind NAT or port forwarding, or when orchestrator reaches hosts by public IPs while
+  replication runs over private IPs. Replicas report the instance's reported address via SHOW SLAVE HOSTS, and
+  replicate from it; orchestrator maps it back onto the instance. When moving replicas under the instance, orchestrator
+  issues CHANGE MASTER TO MASTER_HOST='<reported hostname>', MASTER_PORT=<reported port>.
+  A reported address registered for another instance is taken over. Registration does not expire.
+  Example:
+
+  orchestrator -c register-reported-address -i db1.public.example.com:13306 -d 10.0.0.11:3306
+	`
+	CommandHelp["deregister-reported-address"] = `
+  Removes the reported address of an instance. Replicas are not touched.
+  Example:
+
+  orchestrator -c deregister-reported-address -i db1.public.example.com:13306
+	`
+	CommandHelp["reported-addresses"] = `
+  List registered reported addresses: each instance's connect address, followed by its reported address.
+  Example:
+
+  orchestrator -c reported-addresses
 	`
 	CommandHelp["set-heuristic-domain-instance"] = `
 	This is a temporary (sync your watches, watch for next ice age) command which registers the cluster domain name of a given cluster
@@ -896,6 +931,31 @@ func init() {
 	use and at most it wastes some cycles.
 	`
 
+	CommandHelp["export-backend-data"] = `
+  Write orchestrator's backend state to standard output, as gzipped JSON: instances, cluster aliases, candidates,
+  downtimes, recoveries and the like. The format does not depend on the backend type, and is that of raft snapshots.
+  Use along with import-backend-data to migrate between backends, e.g. from a shared MySQL backend to raft+SQLite.
+  Example:
+
+  orchestrator -c export-backend-data --config=/etc/orchestrator-mysql.conf.json > orchestrator-state.json.gz
+	`
+	CommandHelp["import-backend-data"] = `
+  Import backend state written by export-backend-data, read from standard input, then verify that all exported
+  rows and instances are found in the backend. Prints, per table, the exported and found row counts, and exits
+  with an error when verification fails. Instances not in the exported state are forgotten. Import into a backend
+  not in use by a running orchestrator service. Example:
+
+  orchestrator -c import-backend-data --config=/etc/orchestrator-sqlite.conf.json --ignore-raft-setup < orchestrator-state.json.gz
+	`
+	CommandHelp["config-check"] = `
+  Check configuration files, as read on startup, without reading them into orchestrator: JSON syntax, unknown
+  settings (suggesting the setting likely meant), values of the wrong type, conflicting settings and regular
+  expressions which do not compile. Each problem is printed with its file and line. Exits with an error when any
+  problem is an error; deprecated settings are warnings. orchestrator refuses to start on the same errors. Example:
+
+  orchestrator -c config-check --config=/etc/orchestrator.conf.json
+	`
+
 	for key := range CommandHelp {
 		CommandHelp[key] = strings.Trim(CommandHelp[key], "\n")
 	}
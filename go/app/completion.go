@@ -0,0 +1,180 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// bashCompletionScript completes commands (-c), instances (-i, -d, -s) and cluster aliases (-alias).
+// Instances and clusters are read via the completion commands, which cache them.
+const bashCompletionScript = `# orchestrator bash completion. Load with:
+#   source <(orchestrator -c bash-completion)
+_orchestrator_complete() {
+	local cur prev words cword candidates
+	if declare -F _get_comp_words_by_ref >/dev/null; then
+		_get_comp_words_by_ref -n : cur prev words cword
+	else
+		cur="${COMP_WORDS[COMP_CWORD]}"
+		prev="${COMP_WORDS[COMP_CWORD-1]}"
+	fi
+	case "$prev" in
+		-c|--c)
+			candidates="$(orchestrator -c complete-commands --quiet 2>/dev/null)" ;;
+		-i|--i|-d|--d|-s|--s)
+			candidates="$(orchestrator -c complete-instances --quiet 2>/dev/null)" ;;
+		-alias|--alias)
+			candidates="$(orchestrator -c complete-clusters --quiet 2>/dev/null)" ;;
+		*)
+			candidates="cli http tui help" ;;
+	esac
+	COMPREPLY=($(compgen -W "$candidates" -- "$cur"))
+	if declare -F __ltrim_colon_completions >/dev/null; then
+		__ltrim_colon_completions "$cur"
+	fi
+}
+complete -F _orchestrator_complete orchestrator
+`
+
+// zshCompletionScript reuses the bash completion via zsh's bashcompinit
+const zshCompletionScript = `# orchestrator zsh completion. Load with:
+#   source <(orchestrator -c zsh-completion)
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletionScript
+
+// CompletionData is what shell completion suggests beyond command names
+type CompletionData struct {
+	Timestamp time.Time
+	Instances []string
+	Clusters  []string // cluster names and aliases
+}
+
+// completionCacheFile returns the path of the completion cache. The file name is unique per backend database,
+// so that different configurations do not share completion data.
+func completionCacheFile() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		cacheDir = os.TempDir()
+	}
+	backendHash := fnv.New32a()
+	fmt.Fprintf(backendHash, "%s|%s|%s|%s|%d",
+		config.Config.BackendDB, config.Config.SQLite3DataFile,
+		config.Config.MySQLOrchestratorHost, config.Config.MySQLOrchestratorDatabase, config.Config.MySQLOrchestratorPort,
+	)
+	return filepath.Join(cacheDir, "orchestrator", fmt.Sprintf("completion-%08x.json", backendHash.Sum32()))
+}
+
+// readCompletionCache reads completion data from given cache file, as long as it is younger than given ttl
+func readCompletionCache(cacheFile string, ttl time.Duration) (*CompletionData, bool) {
+	content, err := ioutil.ReadFile(cacheFile)
+	if err != nil {
+		return nil, false
+	}
+	completionData := &CompletionData{}
+	if err := json.Unmarshal(content, completionData); err != nil {
+		return nil, false
+	}
+	if time.Since(completionData.Timestamp) > ttl {
+		return nil, false
+	}
+	return completionData, true
+}
+
+// writeCompletionCache writes completion data into given cache file
+func writeCompletionCache(cacheFile string, completionData *CompletionData) error {
+	content, err := json.Marshal(completionData)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cacheFile), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cacheFile, content, 0600)
+}
+
+// readBackendCompletionData reads known instances and clusters from the backend database
+func readBackendCompletionData() (*CompletionData, error) {
+	completionData := &CompletionData{Timestamp: time.Now(), Instances: []string{}, Clusters: []string{}}
+	instanceKeys, err := inst.BulkReadInstance()
+	if err != nil {
+		return nil, err
+	}
+	for _, instanceKey := range instanceKeys {
+		completionData.Instances = append(completionData.Instances, instanceKey.StringCode())
+	}
+	clusters, err := inst.ReadClustersInfo("")
+	if err != nil {
+		return nil, err
+	}
+	clusterNames := map[string]bool{}
+	for _, cluster := range clusters {
+		clusterNames[cluster.ClusterName] = true
+		if cluster.ClusterAlias != "" {
+			clusterNames[cluster.ClusterAlias] = true
+		}
+	}
+	for clusterName := range clusterNames {
+		completionData.Clusters = append(completionData.Clusters, clusterName)
+	}
+	sort.Strings(completionData.Clusters)
+	return completionData, nil
+}
+
+// getCompletionData returns completion data from the cache if fresh, or else from the backend database, refreshing the cache.
+// Failing to write the cache is not an error: completion is merely slower.
+func getCompletionData() (*CompletionData, error) {
+	cacheFile := completionCacheFile()
+	ttl := time.Duration(config.Config.CompletionCacheSeconds) * time.Second
+	if completionData, ok := readCompletionCache(cacheFile, ttl); ok {
+		return completionData, nil
+	}
+	completionData, err := readBackendCompletionData()
+	if err != nil {
+		return nil, err
+	}
+	if ttl > 0 {
+		writeCompletionCache(cacheFile, completionData)
+	}
+	return completionData, nil
+}
+
+// completionCommands lists known commands and their synonyms, sorted
+func completionCommands() []string {
+	commands := map[string]bool{}
+	for _, cliCommand := range knownCommands {
+		commands[cliCommand.Command] = true
+	}
+	for synonym := range commandSynonyms {
+		commands[synonym] = true
+	}
+	result := []string{}
+	for command := range commands {
+		result = append(result, command)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestCompletionCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "orchestrator-completion-test")
+	test.S(t).ExpectNil(err)
+	defer os.RemoveAll(dir)
+	cacheFile := filepath.Join(dir, "cache", "completion.json")
+
+	_, ok := readCompletionCache(cacheFile, time.Minute)
+	test.S(t).ExpectFalse(ok)
+
+	err = writeCompletionCache(cacheFile, &CompletionData{Timestamp: time.Now(), Instances: []string{"db1:3306", "db2:3306"}, Clusters: []string{"main"}})
+	test.S(t).ExpectNil(err)
+	completionData, ok := readCompletionCache(cacheFile, time.Minute)
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(len(completionData.Instances), 2)
+	test.S(t).ExpectEquals(completionData.Clusters[0], "main")
+
+	err = writeCompletionCache(cacheFile, &CompletionData{Timestamp: time.Now().Add(-2 * time.Minute)})
+	test.S(t).ExpectNil(err)
+	_, ok = readCompletionCache(cacheFile, time.Minute)
+	test.S(t).ExpectFalse(ok)
+}
+
+func TestCompletionCommands(t *testing.T) {
+	Cli("help", false, "localhost:9999", "localhost:9999", "orc", "no-reason", "1m", ".", "no-alias", "no-pool", "")
+
+	commands := map[string]bool{}
+	for _, command := range completionCommands() {
+		commands[command] = true
+	}
+	test.S(t).ExpectTrue(commands["relocate"])
+	test.S(t).ExpectTrue(commands["relocate-slaves"])
+	test.S(t).ExpectTrue(commands["complete-instances"])
+	test.S(t).ExpectFalse(commands["help"])
+}
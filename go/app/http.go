@@ -17,6 +17,7 @@
 package app
 
 import (
+	"crypto/tls"
 	"net"
 	nethttp "net/http"
 	"strings"
@@ -25,6 +26,7 @@ import (
 	"github.com/github/orchestrator/go/agent"
 	"github.com/github/orchestrator/go/collection"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/grpcapi"
 	"github.com/github/orchestrator/go/http"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/logic"
@@ -82,7 +84,7 @@ func standardHttp(continuousDiscovery bool) {
 				// Still allowed; may be disallowed in future versions
 				log.Warning("AuthenticationMethod is configured as 'basic' but HTTPAuthUser undefined. Running without authentication.")
 			}
-			m.Use(auth.Basic(config.Config.HTTPAuthUser, config.Config.HTTPAuthPassword))
+			m.Use(http.ExemptChatOps(auth.Basic(config.Config.HTTPAuthUser, config.Config.HTTPAuthPassword)))
 		}
 	case "multi":
 		{
@@ -91,13 +93,17 @@ func standardHttp(continuousDiscovery bool) {
 				log.Fatal("AuthenticationMethod is configured as 'multi' but HTTPAuthUser undefined")
 			}
 
-			m.Use(auth.BasicFunc(func(username, password string) bool {
+			m.Use(http.ExemptChatOps(auth.BasicFunc(func(username, password string) bool {
 				if username == "readonly" {
 					// Will be treated as "read-only"
 					return true
 				}
 				return auth.SecureCompare(username, config.Config.HTTPAuthUser) && auth.SecureCompare(password, config.Config.HTTPAuthPassword)
-			}))
+			})))
+		}
+	case "oidc":
+		{
+			m.Use(http.OIDCAuth)
 		}
 	default:
 		{
@@ -106,7 +112,19 @@ func standardHttp(continuousDiscovery bool) {
 		}
 	}
 
+	m.Use(http.StripForwardingHeaders)
+	if config.Config.APIRateLimitRequestsPerSecond > 0 {
+		m.Use(http.RateLimit())
+	}
+	if config.Config.AuditAPIRequests {
+		m.Use(http.AuditRequests)
+	}
+	if config.Config.APIShedLowPriorityDuringRecovery {
+		m.Use(http.PrioritizeRequests)
+	}
+
 	m.Use(gzip.All())
+	m.Use(http.APIVersioning)
 	// Render html templates from templates directory
 	m.Use(render.Renderer(render.Options{
 		Directory:       "resources",
@@ -115,7 +133,7 @@ func standardHttp(continuousDiscovery bool) {
 	}))
 	m.Use(martini.Static("resources/public", martini.StaticOptions{Prefix: config.Config.URLPrefix}))
 	if config.Config.UseMutualTLS {
-		m.Use(ssl.VerifyOUs(config.Config.SSLValidOUs))
+		m.Use(ssl.VerifyOUsOrSANs(config.Config.SSLValidOUs, config.Config.SSLValidSANs))
 	}
 
 	inst.SetMaintenanceOwner(process.ThisHostname)
@@ -135,6 +153,10 @@ func standardHttp(continuousDiscovery bool) {
 	http.API.RegisterRequests(m)
 	http.Web.RegisterRequests(m)
 
+	if config.Config.GRPCListenAddress != "" {
+		go grpcServe()
+	}
+
 	// Serve
 	if config.Config.ListenSocket != "" {
 		log.Infof("Starting HTTP listener on unix socket %v", config.Config.ListenSocket)
@@ -153,9 +175,16 @@ func standardHttp(continuousDiscovery bool) {
 			log.Fatale(err)
 		}
 		tlsConfig.InsecureSkipVerify = config.Config.SSLSkipVerify
+		if config.Config.UseMutualTLS && config.Config.SSLRequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
 		if err = ssl.AppendKeyPairWithPassword(tlsConfig, config.Config.SSLCertFile, config.Config.SSLPrivateKeyFile, sslPEMPassword); err != nil {
 			log.Fatale(err)
 		}
+		if config.Config.UseMutualTLS {
+			// Present our own certificate when forwarding requests to the raft leader
+			http.SetRaftReverseProxyTLSConfig(ssl.NewClientTLSConfig(tlsConfig))
+		}
 		if err = ssl.ListenAndServeTLS(config.Config.ListenAddress, m, tlsConfig); err != nil {
 			log.Fatale(err)
 		}
@@ -168,6 +197,27 @@ func standardHttp(continuousDiscovery bool) {
 	log.Info("Web server started")
 }
 
+// grpcServe serves the read-only gRPC API, over TLS when UseSSL is configured
+func grpcServe() {
+	var tlsConfig *tls.Config
+	if config.Config.UseSSL {
+		var err error
+		if tlsConfig, err = ssl.NewTLSConfig(config.Config.SSLCAFile, config.Config.UseMutualTLS); err != nil {
+			log.Fatale(err)
+		}
+		if config.Config.UseMutualTLS && config.Config.SSLRequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+		if err = ssl.AppendKeyPairWithPassword(tlsConfig, config.Config.SSLCertFile, config.Config.SSLPrivateKeyFile, sslPEMPassword); err != nil {
+			log.Fatale(err)
+		}
+	}
+	log.Infof("Starting gRPC listener on %+v", config.Config.GRPCListenAddress)
+	if err := grpcapi.Serve(config.Config.GRPCListenAddress, tlsConfig); err != nil {
+		log.Fatale(err)
+	}
+}
+
 // agentsHttp startes serving agents HTTP or HTTPS API requests
 func agentsHttp() {
 	m := martini.Classic()
@@ -0,0 +1,738 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/user"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+// remoteRequestTimeout caps the time given to a single API request. It is generous, as some commands
+// (e.g. a graceful master takeover) take a while to complete.
+const remoteRequestTimeout = 5 * time.Minute
+
+// remoteRetryIntervals are the pauses between attempts to reach the API, when it cannot be reached at all
+var remoteRetryIntervals = []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 500 * time.Millisecond, time.Second, 2 * time.Second}
+
+// remoteClient executes commands over the HTTP API of a remote orchestrator service
+type remoteClient struct {
+	endpoints  []string
+	leaderAPI  string
+	basicAuth  string // user:password
+	token      string
+	httpClient *http.Client
+}
+
+// remoteArgs are the command line arguments of a remote command
+type remoteArgs struct {
+	instance      string // host/port, as in API paths
+	destination   string // host/port, as in API paths
+	clusterHint   string // cluster alias, or else the instance
+	owner         string
+	reason        string
+	duration      string
+	promotionRule string
+	tag           string
+}
+
+// remoteAPIResponse is the API's common response format
+type remoteAPIResponse struct {
+	Code    string
+	Message string
+	Details json.RawMessage
+}
+
+// remoteInstance holds the instance fields remote commands read
+type remoteInstance struct {
+	Key                       inst.InstanceKey
+	MasterKey                 inst.InstanceKey
+	ReplicationSQLThreadState int
+	ReplicationIOThreadState  int
+	GtidErrant                string
+}
+
+// remoteCommand executes a command via given client, returning lines of output
+type remoteCommand func(client *remoteClient, args *remoteArgs) ([]string, error)
+
+// normalizeRemoteAPI makes an endpoint point to the API root, e.g. "http://orchestrator:3000" => "http://orchestrator:3000/api"
+func normalizeRemoteAPI(endpoint string) string {
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/api") {
+		endpoint = endpoint + "/api"
+	}
+	return endpoint
+}
+
+// toRemoteHostPort converts host[:port] into the API's host/port path format
+func toRemoteHostPort(instance string) string {
+	if instance == "" {
+		return ""
+	}
+	if strings.Contains(instance, ":") {
+		return strings.Replace(instance, ":", "/", 1)
+	}
+	return fmt.Sprintf("%s/%d", instance, config.Config.DefaultInstancePort)
+}
+
+func newRemoteClient(endpoints string, basicAuth string, token string) *remoteClient {
+	client := &remoteClient{basicAuth: basicAuth, token: token, httpClient: &http.Client{Timeout: remoteRequestTimeout}}
+	for _, endpoint := range strings.Fields(endpoints) {
+		client.endpoints = append(client.endpoints, normalizeRemoteAPI(endpoint))
+	}
+	return client
+}
+
+func (this *remoteClient) newRequest(uri string) (*http.Request, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if this.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", this.token))
+	} else if this.basicAuth != "" {
+		tokens := strings.SplitN(this.basicAuth, ":", 2)
+		if len(tokens) == 2 {
+			req.SetBasicAuth(tokens[0], tokens[1])
+		}
+	}
+	return req, nil
+}
+
+// detectLeader picks the endpoint to talk to. With a single endpoint, it is used as is: orchestrator
+// forwards requests to the raft leader by itself. With multiple endpoints, the one passing leader-check is chosen.
+func (this *remoteClient) detectLeader() error {
+	if len(this.endpoints) == 0 {
+		return fmt.Errorf("No API endpoint given")
+	}
+	if len(this.endpoints) == 1 {
+		this.leaderAPI = this.endpoints[0]
+		return nil
+	}
+	leaderCheckClient := &http.Client{Timeout: time.Second}
+	for _, endpoint := range this.endpoints {
+		req, err := this.newRequest(fmt.Sprintf("%s/leader-check", endpoint))
+		if err != nil {
+			return err
+		}
+		resp, err := leaderCheckClient.Do(req)
+		if err != nil {
+			log.Debugf("leader-check on %s: %+v", endpoint, err)
+			continue
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			this.leaderAPI = endpoint
+			return nil
+		}
+	}
+	return fmt.Errorf("Cannot determine leader from %s", strings.Join(this.endpoints, " "))
+}
+
+// isRemoteConnectionError checks whether a request failed to connect to the API at all, in which case
+// the request was never received, and is safe to retry
+func isRemoteConnectionError(err error) bool {
+	if urlErr, ok := err.(*url.Error); ok {
+		err = urlErr.Err
+	}
+	opErr, ok := err.(*net.OpError)
+	return ok && opErr.Op == "dial"
+}
+
+// getRaw invokes an API path and returns the raw response body. It retries while the API cannot be reached at all;
+// a request which may have reached the API, e.g. one timing out on its response, is not retried, as it may have taken effect.
+func (this *remoteClient) getRaw(path string) ([]byte, error) {
+	uri := fmt.Sprintf("%s/%s", this.leaderAPI, path)
+	var lastErr error
+	for attempt := 0; attempt <= len(remoteRetryIntervals); attempt++ {
+		if attempt > 0 {
+			time.Sleep(remoteRetryIntervals[attempt-1])
+		}
+		req, err := this.newRequest(uri)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := this.httpClient.Do(req)
+		if err != nil {
+			if !isRemoteConnectionError(err) {
+				return nil, fmt.Errorf("%s: %+v", uri, err)
+			}
+			lastErr = err
+			continue
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %+v", uri, err)
+		}
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return nil, fmt.Errorf("%s: %s. Check --api-auth or --api-token", uri, resp.Status)
+		}
+		return body, nil
+	}
+	return nil, fmt.Errorf("Cannot access orchestrator at %s: %+v", this.leaderAPI, lastErr)
+}
+
+// get invokes an API path, and returns the details of its response (see parseRemoteResponse)
+func (this *remoteClient) get(path string) (json.RawMessage, error) {
+	response, err := this.getResponse(path)
+	if err != nil {
+		return nil, err
+	}
+	return response.Details, nil
+}
+
+func (this *remoteClient) getResponse(path string) (*remoteAPIResponse, error) {
+	body, err := this.getRaw(path)
+	if err != nil {
+		return nil, err
+	}
+	return parseRemoteResponse(body)
+}
+
+// parseRemoteResponse unwraps an API response. A response in the API's common format is returned as is,
+// or as an error when its code is ERROR. Any other response, such as a list of instances, is returned as details.
+func parseRemoteResponse(body []byte) (*remoteAPIResponse, error) {
+	if !json.Valid(body) {
+		return nil, fmt.Errorf("Unexpected response: %s", strings.TrimSpace(string(body)))
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		// not an object: an array or a plain value
+		return &remoteAPIResponse{Details: json.RawMessage(body)}, nil
+	}
+	if _, ok := fields["Code"]; !ok {
+		return &remoteAPIResponse{Details: json.RawMessage(body)}, nil
+	}
+	response := &remoteAPIResponse{}
+	if err := json.Unmarshal(body, response); err != nil {
+		return nil, err
+	}
+	if response.Code == "ERROR" {
+		return nil, fmt.Errorf("%s", response.Message)
+	}
+	return response, nil
+}
+
+// decodeRemoteKey reads an instance key out of details which are either an instance or an instance key
+func decodeRemoteKey(details json.RawMessage) (*inst.InstanceKey, error) {
+	instance := remoteInstance{}
+	if err := json.Unmarshal(details, &instance); err != nil {
+		return nil, err
+	}
+	if instance.Key.Hostname != "" {
+		return &instance.Key, nil
+	}
+	instanceKey := &inst.InstanceKey{}
+	if err := json.Unmarshal(details, instanceKey); err != nil {
+		return nil, err
+	}
+	return instanceKey, nil
+}
+
+func remoteKeys(instances []remoteInstance) []string {
+	lines := []string{}
+	for _, instance := range instances {
+		lines = append(lines, instance.Key.DisplayString())
+	}
+	return lines
+}
+
+func requireRemoteArg(name string, value string) error {
+	if value == "" {
+		return fmt.Errorf("%s must be provided", name)
+	}
+	return nil
+}
+
+// remoteKeyCommand invokes an API path resulting in an instance or an instance key, and outputs the key
+func remoteKeyCommand(pathFunc func(args *remoteArgs) (string, error)) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := pathFunc(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(path)
+		if err != nil {
+			return nil, err
+		}
+		instanceKey, err := decodeRemoteKey(details)
+		if err != nil {
+			return nil, err
+		}
+		return []string{instanceKey.DisplayString()}, nil
+	}
+}
+
+// remoteInstanceCommand operates on an instance, and outputs its key
+func remoteInstanceCommand(path string) remoteCommand {
+	return remoteKeyCommand(instancePath(path))
+}
+
+// remoteRelocateCommand relocates an instance, optionally below a destination, and outputs "instance<master"
+func remoteRelocateCommand(path string, requireDestination bool) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		if err := requireRemoteArg("instance", args.instance); err != nil {
+			return nil, err
+		}
+		apiPath := fmt.Sprintf("%s/%s", path, args.instance)
+		if requireDestination {
+			if err := requireRemoteArg("destination", args.destination); err != nil {
+				return nil, err
+			}
+			apiPath = fmt.Sprintf("%s/%s", apiPath, args.destination)
+		}
+		details, err := client.get(apiPath)
+		if err != nil {
+			return nil, err
+		}
+		instance := remoteInstance{}
+		if err := json.Unmarshal(details, &instance); err != nil {
+			return nil, err
+		}
+		return []string{fmt.Sprintf("%s<%s", instance.Key.DisplayString(), instance.MasterKey.DisplayString())}, nil
+	}
+}
+
+// remoteInstancesCommand invokes an API path resulting in a list of instances, and outputs their keys
+func remoteInstancesCommand(pathFunc func(args *remoteArgs) (string, error), filter func(instance remoteInstance) bool) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := pathFunc(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(path)
+		if err != nil {
+			return nil, err
+		}
+		instances := []remoteInstance{}
+		if err := json.Unmarshal(details, &instances); err != nil {
+			return nil, err
+		}
+		filtered := []remoteInstance{}
+		for _, instance := range instances {
+			if filter == nil || filter(instance) {
+				filtered = append(filtered, instance)
+			}
+		}
+		return remoteKeys(filtered), nil
+	}
+}
+
+// remoteTextCommand invokes an API path and outputs its details: text as is, anything else as JSON.
+// Without details, it outputs the response message.
+func remoteTextCommand(pathFunc func(args *remoteArgs) (string, error)) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := pathFunc(args)
+		if err != nil {
+			return nil, err
+		}
+		response, err := client.getResponse(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(response.Details) == 0 || string(response.Details) == "null" {
+			return []string{response.Message}, nil
+		}
+		var text string
+		if err := json.Unmarshal(response.Details, &text); err != nil {
+			return []string{string(response.Details)}, nil
+		}
+		return []string{strings.TrimSuffix(text, "\n")}, nil
+	}
+}
+
+// remoteRecoveryCommand invokes a recovery API path, and outputs the successor
+func remoteRecoveryCommand(pathFunc func(args *remoteArgs) (string, error)) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := pathFunc(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(path)
+		if err != nil {
+			return nil, err
+		}
+		recovery := struct{ SuccessorKey *inst.InstanceKey }{}
+		if err := json.Unmarshal(details, &recovery); err != nil {
+			return nil, err
+		}
+		if recovery.SuccessorKey == nil {
+			return []string{}, nil
+		}
+		return []string{recovery.SuccessorKey.DisplayString()}, nil
+	}
+}
+
+func instancePath(path string) func(args *remoteArgs) (string, error) {
+	return func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("instance", args.instance); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/%s", path, args.instance), nil
+	}
+}
+
+func clusterPath(path string) func(args *remoteArgs) (string, error) {
+	return func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("instance|alias", args.clusterHint); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s/%s", path, url.PathEscape(args.clusterHint)), nil
+	}
+}
+
+func fixedPath(path string) func(args *remoteArgs) (string, error) {
+	return func(args *remoteArgs) (string, error) {
+		return path, nil
+	}
+}
+
+// remoteInstanceFieldCommand reads an instance and outputs what given function extracts of it
+func remoteInstanceFieldCommand(extract func(instance remoteInstance) []string) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := instancePath("instance")(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(path)
+		if err != nil {
+			return nil, err
+		}
+		instance := remoteInstance{}
+		if err := json.Unmarshal(details, &instance); err != nil {
+			return nil, err
+		}
+		return extract(instance), nil
+	}
+}
+
+// remoteClusterInfoCommand reads clusters info and outputs what given function extracts of each cluster
+func remoteClusterInfoCommand(pathFunc func(args *remoteArgs) (string, error), single bool, extract func(clusterInfo inst.ClusterInfo) string) remoteCommand {
+	return func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		path, err := pathFunc(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(path)
+		if err != nil {
+			return nil, err
+		}
+		clusters := []inst.ClusterInfo{}
+		if single {
+			clusterInfo := inst.ClusterInfo{}
+			err = json.Unmarshal(details, &clusterInfo)
+			clusters = append(clusters, clusterInfo)
+		} else {
+			err = json.Unmarshal(details, &clusters)
+		}
+		if err != nil {
+			return nil, err
+		}
+		lines := []string{}
+		for _, clusterInfo := range clusters {
+			lines = append(lines, extract(clusterInfo))
+		}
+		return lines, nil
+	}
+}
+
+// remoteCommands are the commands supported in remote mode, by the same names as in the command line interface.
+// Replica commands are named with "replica"; their "slave" synonyms are translated by commandSynonyms.
+var remoteCommands = map[string]remoteCommand{
+	"which-api": func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		return []string{client.leaderAPI}, nil
+	},
+
+	"discover":                     remoteInstanceCommand("discover"),
+	"forget":                       remoteInstanceCommand("forget"),
+	"end-downtime":                 remoteInstanceCommand("end-downtime"),
+	"end-maintenance":              remoteInstanceCommand("end-maintenance"),
+	"stop-replica":                 remoteInstanceCommand("stop-replica"),
+	"stop-replica-nice":            remoteInstanceCommand("stop-replica-nice"),
+	"start-replica":                remoteInstanceCommand("start-replica"),
+	"restart-replica":              remoteInstanceCommand("restart-replica"),
+	"reset-replica":                remoteInstanceCommand("reset-replica"),
+	"detach-replica-master-host":   remoteInstanceCommand("detach-replica-master-host"),
+	"reattach-replica-master-host": remoteInstanceCommand("reattach-replica-master-host"),
+	"skip-query":                   remoteInstanceCommand("skip-query"),
+	"gtid-errant-reset-master":     remoteInstanceCommand("gtid-errant-reset-master"),
+	"gtid-errant-inject-empty":     remoteInstanceCommand("gtid-errant-inject-empty"),
+	"enable-semi-sync-master":      remoteInstanceCommand("enable-semi-sync-master"),
+	"disable-semi-sync-master":     remoteInstanceCommand("disable-semi-sync-master"),
+	"enable-semi-sync-replica":     remoteInstanceCommand("enable-semi-sync-replica"),
+	"disable-semi-sync-replica":    remoteInstanceCommand("disable-semi-sync-replica"),
+	"set-read-only":                remoteInstanceCommand("set-read-only"),
+	"set-writeable":                remoteInstanceCommand("set-writeable"),
+	"flush-binary-logs":            remoteInstanceCommand("flush-binary-logs"),
+
+	"relocate":        remoteRelocateCommand("relocate", true),
+	"match":           remoteRelocateCommand("match", true),
+	"move-below":      remoteRelocateCommand("move-below", true),
+	"move-equivalent": remoteRelocateCommand("move-equivalent", true),
+	"move-gtid":       remoteRelocateCommand("move-below-gtid", true),
+	"repoint":         remoteRelocateCommand("repoint", true),
+	"move-up":         remoteRelocateCommand("move-up", false),
+	"match-up":        remoteRelocateCommand("match-up", false),
+	"make-co-master":  remoteRelocateCommand("make-co-master", false),
+	"take-master":     remoteRelocateCommand("take-master", false),
+	"take-siblings":   remoteRelocateCommand("take-siblings", false),
+
+	"relocate-replicas": remoteInstancesCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("destination", args.destination); err != nil {
+			return "", err
+		}
+		path, err := instancePath("relocate-replicas")(args)
+		return fmt.Sprintf("%s/%s", path, args.destination), err
+	}, nil),
+	"move-up-replicas":  remoteInstancesCommand(instancePath("move-up-replicas"), nil),
+	"match-up-replicas": remoteInstancesCommand(instancePath("match-up-replicas"), nil),
+	"repoint-replicas":  remoteInstancesCommand(instancePath("repoint-replicas"), nil),
+
+	"instance": remoteInstanceFieldCommand(func(instance remoteInstance) []string {
+		return []string{instance.Key.DisplayString()}
+	}),
+	"which-master": remoteInstanceFieldCommand(func(instance remoteInstance) []string {
+		return []string{instance.MasterKey.DisplayString()}
+	}),
+	"which-gtid-errant": remoteInstanceFieldCommand(func(instance remoteInstance) []string {
+		return []string{instance.GtidErrant}
+	}),
+	"is-replicating": remoteInstanceFieldCommand(func(instance remoteInstance) []string {
+		if instance.ReplicationSQLThreadState == 1 && instance.ReplicationIOThreadState == 1 {
+			return []string{instance.Key.DisplayString()}
+		}
+		return []string{}
+	}),
+	"is-replication-stopped": remoteInstanceFieldCommand(func(instance remoteInstance) []string {
+		if instance.ReplicationSQLThreadState == 0 && instance.ReplicationIOThreadState == 0 {
+			return []string{instance.Key.DisplayString()}
+		}
+		return []string{}
+	}),
+	"which-replicas":          remoteInstancesCommand(instancePath("instance-replicas"), nil),
+	"which-cluster-instances": remoteInstancesCommand(clusterPath("cluster"), nil),
+	"all-clusters-masters":    remoteInstancesCommand(fixedPath("masters"), nil),
+	"all-instances":           remoteInstancesCommand(fixedPath("all-instances"), nil),
+	"downtimed":               remoteInstancesCommand(fixedPath("downtimed"), nil),
+	"search": remoteInstancesCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("instance", args.clusterHint); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("search?s=%s", url.QueryEscape(args.clusterHint)), nil
+	}, nil),
+	"tagged": remoteInstancesCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("tag", args.tag); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("tagged?tag=%s", url.QueryEscape(args.tag)), nil
+	}, nil),
+	"which-cluster-master": remoteKeyCommand(clusterPath("master")),
+
+	"clusters": remoteClusterInfoCommand(fixedPath("clusters-info"), false, func(clusterInfo inst.ClusterInfo) string {
+		return clusterInfo.ClusterName
+	}),
+	"clusters-alias": remoteClusterInfoCommand(fixedPath("clusters-info"), false, func(clusterInfo inst.ClusterInfo) string {
+		return fmt.Sprintf("%s,%s", clusterInfo.ClusterName, clusterInfo.ClusterAlias)
+	}),
+	"which-cluster": remoteClusterInfoCommand(clusterPath("cluster-info"), true, func(clusterInfo inst.ClusterInfo) string {
+		return clusterInfo.ClusterName
+	}),
+	"which-cluster-alias": remoteClusterInfoCommand(clusterPath("cluster-info"), true, func(clusterInfo inst.ClusterInfo) string {
+		return clusterInfo.ClusterAlias
+	}),
+
+	"topology":           remoteTextCommand(clusterPath("topology")),
+	"topology-tabulated": remoteTextCommand(clusterPath("topology-tabulated")),
+	"forget-cluster":     remoteTextCommand(clusterPath("forget-cluster")),
+
+	"begin-downtime": remoteKeyCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("reason", args.reason); err != nil {
+			return "", err
+		}
+		path, err := instancePath("begin-downtime")(args)
+		path = fmt.Sprintf("%s/%s/%s", path, url.PathEscape(args.owner), url.PathEscape(args.reason))
+		if args.duration != "" {
+			path = fmt.Sprintf("%s/%s", path, args.duration)
+		}
+		return path, err
+	}),
+	"begin-maintenance": remoteKeyCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("reason", args.reason); err != nil {
+			return "", err
+		}
+		path, err := instancePath("begin-maintenance")(args)
+		return fmt.Sprintf("%s/%s/%s", path, url.PathEscape(args.owner), url.PathEscape(args.reason)), err
+	}),
+	"register-candidate": remoteKeyCommand(func(args *remoteArgs) (string, error) {
+		path, err := instancePath("register-candidate")(args)
+		return fmt.Sprintf("%s/%s", path, args.promotionRule), err
+	}),
+	"tag": remoteKeyCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("tag", args.tag); err != nil {
+			return "", err
+		}
+		path, err := instancePath("tag")(args)
+		return fmt.Sprintf("%s?tag=%s", path, url.QueryEscape(args.tag)), err
+	}),
+	"untag": func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		if err := requireRemoteArg("tag", args.tag); err != nil {
+			return nil, err
+		}
+		path, err := instancePath("untag")(args)
+		if err != nil {
+			return nil, err
+		}
+		details, err := client.get(fmt.Sprintf("%s?tag=%s", path, url.QueryEscape(args.tag)))
+		if err != nil {
+			return nil, err
+		}
+		instanceKeys := []inst.InstanceKey{}
+		if err := json.Unmarshal(details, &instanceKeys); err != nil {
+			return nil, err
+		}
+		lines := []string{}
+		for _, instanceKey := range instanceKeys {
+			lines = append(lines, instanceKey.DisplayString())
+		}
+		return lines, nil
+	},
+
+	"recover": remoteKeyCommand(instancePath("recover")),
+	"graceful-master-takeover": remoteRecoveryCommand(func(args *remoteArgs) (string, error) {
+		path, err := clusterPath("graceful-master-takeover")(args)
+		if args.destination != "" {
+			path = fmt.Sprintf("%s/%s", path, args.destination)
+		}
+		return path, err
+	}),
+	"force-master-failover": remoteRecoveryCommand(clusterPath("force-master-failover")),
+	"force-master-takeover": remoteRecoveryCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("destination", args.destination); err != nil {
+			return "", err
+		}
+		path, err := clusterPath("force-master-takeover")(args)
+		return fmt.Sprintf("%s/%s", path, args.destination), err
+	}),
+	"ack-cluster-recoveries": remoteTextCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("reason", args.reason); err != nil {
+			return "", err
+		}
+		path, err := clusterPath("ack-recovery/cluster")(args)
+		return fmt.Sprintf("%s?comment=%s", path, url.QueryEscape(args.reason)), err
+	}),
+	"ack-all-recoveries": remoteTextCommand(func(args *remoteArgs) (string, error) {
+		if err := requireRemoteArg("reason", args.reason); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("ack-all-recoveries?comment=%s", url.QueryEscape(args.reason)), nil
+	}),
+	"disable-global-recoveries": remoteTextCommand(fixedPath("disable-global-recoveries")),
+	"enable-global-recoveries":  remoteTextCommand(fixedPath("enable-global-recoveries")),
+	"check-global-recoveries":   remoteTextCommand(fixedPath("check-global-recoveries")),
+
+	"replication-analysis": func(client *remoteClient, args *remoteArgs) ([]string, error) {
+		details, err := client.get("replication-analysis")
+		if err != nil {
+			return nil, err
+		}
+		analysis := []inst.ReplicationAnalysis{}
+		if err := json.Unmarshal(details, &analysis); err != nil {
+			return nil, err
+		}
+		lines := []string{}
+		for _, analysisEntry := range analysis {
+			problem := string(analysisEntry.Analysis)
+			if analysisEntry.Analysis == inst.NoProblem && len(analysisEntry.StructureAnalysis) > 0 {
+				problem = string(analysisEntry.StructureAnalysis[0])
+			}
+			lines = append(lines, fmt.Sprintf("%s (cluster %s): %s", analysisEntry.AnalyzedInstanceKey.DisplayString(), analysisEntry.ClusterDetails.ClusterName, problem))
+		}
+		return lines, nil
+	},
+	"raft-leader": remoteTextCommand(fixedPath("raft-leader")),
+	"raft-health": remoteTextCommand(fixedPath("raft-health")),
+}
+
+// remoteCommandNames lists the commands supported in remote mode, sorted
+func remoteCommandNames() []string {
+	names := []string{}
+	for name := range remoteCommands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RemoteCli executes a command over the HTTP API of a remote orchestrator service. It requires neither
+// configuration nor backend database access. apiEndpoints is a space delimited list of API endpoints; given
+// multiple endpoints, the raft leader among them is detected.
+func RemoteCli(apiEndpoints string, basicAuth string, token string, command string, instance string, destination string, owner string, reason string, duration string, clusterAlias string) {
+	if synonym, ok := commandSynonyms[command]; ok {
+		command = synonym
+	}
+	if command == "help" || command == "" {
+		fmt.Fprintf(os.Stderr, "Commands supported with --api:\n\t%s\n", strings.Join(remoteCommandNames(), "\n\t"))
+		return
+	}
+	remoteCommand, ok := remoteCommands[command]
+	if !ok {
+		log.Fatalf("Command not supported with --api: %s. Run 'orchestrator --api ... -c help' for the list of supported commands", command)
+	}
+	if owner == "" {
+		if usr, err := user.Current(); err == nil {
+			owner = usr.Username
+		}
+	}
+	args := &remoteArgs{
+		instance:      toRemoteHostPort(instance),
+		destination:   toRemoteHostPort(destination),
+		clusterHint:   clusterAlias,
+		owner:         owner,
+		reason:        reason,
+		duration:      duration,
+		promotionRule: *config.RuntimeCLIFlags.PromotionRule,
+		tag:           *config.RuntimeCLIFlags.Tag,
+	}
+	if args.clusterHint == "" {
+		args.clusterHint = instance
+	}
+
+	client := newRemoteClient(apiEndpoints, basicAuth, token)
+	if err := client.detectLeader(); err != nil {
+		log.Fatale(err)
+	}
+	lines, err := remoteCommand(client, args)
+	if err != nil {
+		log.Fatale(err)
+	}
+	for _, line := range lines {
+		fmt.Println(line)
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newTestRemoteAPI(isLeader bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/leader-check", func(w http.ResponseWriter, req *http.Request) {
+		if !isLeader {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprint(w, `"OK"`)
+	})
+	mux.HandleFunc("/api/relocate/db3/3306/db1/3306", func(w http.ResponseWriter, req *http.Request) {
+		if user, password, ok := req.BasicAuth(); !ok || user != "ops" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"Code": "OK", "Message": "db3 relocated", "Details": {"Key": {"Hostname": "db3", "Port": 3306}, "MasterKey": {"Hostname": "db1", "Port": 3306}}}`)
+	})
+	mux.HandleFunc("/api/end-downtime/db3/3306", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `{"Code": "OK", "Message": "Downtime ended", "Details": {"Hostname": "db3", "Port": 3306}}`)
+	})
+	mux.HandleFunc("/api/cluster/main", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, `[{"Key": {"Hostname": "db1", "Port": 3306}}, {"Key": {"Hostname": "db3", "Port": 3306}}]`)
+	})
+	mux.HandleFunc("/api/forget-cluster/main", func(w http.ResponseWriter, req *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprint(w, `{"Code": "ERROR", "Message": "Cannot forget master"}`)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestNormalizeRemoteAPI(t *testing.T) {
+	test.S(t).ExpectEquals(normalizeRemoteAPI("http://orchestrator:3000"), "http://orchestrator:3000/api")
+	test.S(t).ExpectEquals(normalizeRemoteAPI("http://orchestrator:3000/api/"), "http://orchestrator:3000/api")
+	test.S(t).ExpectEquals(toRemoteHostPort("db1:3307"), "db1/3307")
+	test.S(t).ExpectEquals(toRemoteHostPort("db1"), "db1/3306")
+	test.S(t).ExpectEquals(toRemoteHostPort(""), "")
+}
+
+func TestRemoteDetectLeader(t *testing.T) {
+	follower := newTestRemoteAPI(false)
+	defer follower.Close()
+	leader := newTestRemoteAPI(true)
+	defer leader.Close()
+
+	client := newRemoteClient(fmt.Sprintf("%s %s", follower.URL, leader.URL), "", "")
+	test.S(t).ExpectNil(client.detectLeader())
+	test.S(t).ExpectEquals(client.leaderAPI, leader.URL+"/api")
+
+	client = newRemoteClient(follower.URL, "", "")
+	test.S(t).ExpectNil(client.detectLeader())
+	test.S(t).ExpectEquals(client.leaderAPI, follower.URL+"/api")
+
+	client = newRemoteClient(fmt.Sprintf("%s %s", follower.URL, follower.URL), "", "")
+	test.S(t).ExpectNotNil(client.detectLeader())
+}
+
+func TestIsRemoteConnectionError(t *testing.T) {
+	server := newTestRemoteAPI(true)
+	closedURL := server.URL
+	server.Close()
+
+	_, err := http.Get(closedURL + "/api/leader-check")
+	test.S(t).ExpectNotNil(err)
+	test.S(t).ExpectTrue(isRemoteConnectionError(err))
+
+	timeoutErr := &url.Error{Op: "Get", URL: closedURL, Err: fmt.Errorf("net/http: request canceled (Client.Timeout exceeded while awaiting headers)")}
+	test.S(t).ExpectFalse(isRemoteConnectionError(timeoutErr))
+}
+
+func TestRemoteCommands(t *testing.T) {
+	server := newTestRemoteAPI(true)
+	defer server.Close()
+	client := newRemoteClient(server.URL, "ops:secret", "")
+	test.S(t).ExpectNil(client.detectLeader())
+	args := &remoteArgs{instance: "db3/3306", destination: "db1/3306", clusterHint: "main"}
+	{
+		lines, err := remoteCommands["relocate"](client, args)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(lines[0], "db3:3306<db1:3306")
+	}
+	{
+		lines, err := remoteCommands["end-downtime"](client, args)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(lines[0], "db3:3306")
+	}
+	{
+		lines, err := remoteCommands["which-cluster-instances"](client, args)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(lines), 2)
+		test.S(t).ExpectEquals(lines[1], "db3:3306")
+	}
+	{
+		_, err := remoteCommands["forget-cluster"](client, args)
+		test.S(t).ExpectNotNil(err)
+		test.S(t).ExpectEquals(err.Error(), "Cannot forget master")
+	}
+	{
+		_, err := remoteCommands["relocate"](client, &remoteArgs{instance: "db3/3306"})
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		unauthorized := newRemoteClient(server.URL, "", "")
+		test.S(t).ExpectNil(unauthorized.detectLeader())
+		_, err := remoteCommands["relocate"](unauthorized, args)
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -0,0 +1,350 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package app
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/kv"
+	"github.com/github/orchestrator/go/logic"
+	"github.com/github/orchestrator/go/process"
+	"github.com/openark/golib/log"
+)
+
+const tuiClearScreen = "\033[H\033[2J"
+
+// tuiScreen is what the terminal UI presents at a given time
+type tuiScreen int
+
+const (
+	tuiClustersScreen tuiScreen = iota
+	tuiProblemsScreen
+	tuiClusterScreen
+)
+
+// terminalUI is an interactive, line oriented browser of clusters, replication trees and problems,
+// for operators working over ssh without the web interface. It reads the backend database directly,
+// as the command line interface does.
+type terminalUI struct {
+	in          *bufio.Reader
+	out         io.Writer
+	screen      tuiScreen
+	clusters    []inst.ClusterInfo
+	problems    []inst.ReplicationAnalysis
+	clusterName string
+	message     string
+}
+
+// TUI runs the interactive terminal UI until the operator quits
+func TUI() {
+	if config.Config.RaftEnabled && !*config.RuntimeCLIFlags.IgnoreRaftSetup {
+		log.Fatalf(`Orchestrator configured to run raft ("RaftEnabled": true). The terminal UI operates on the backend database directly, and is not supported on raft setups. You may override this with --ignore-raft-setup`)
+	}
+	if usr, err := user.Current(); err == nil {
+		inst.SetMaintenanceOwner(usr.Username)
+	}
+	if !*config.RuntimeCLIFlags.SkipContinuousRegistration {
+		process.ContinuousRegistration(string(process.OrchestratorExecutionCliMode), "tui")
+	}
+	kv.InitKVStores()
+
+	ui := &terminalUI{in: bufio.NewReader(os.Stdin), out: os.Stdout, screen: tuiClustersScreen}
+	ui.run()
+}
+
+// parseTUICommand splits an input line into a command and its arguments
+func parseTUICommand(line string) (command string, args []string) {
+	tokens := strings.Fields(line)
+	if len(tokens) == 0 {
+		return "", nil
+	}
+	return strings.ToLower(tokens[0]), tokens[1:]
+}
+
+// formatTUIClusters lists clusters, numbered for selection
+func formatTUIClusters(clusters []inst.ClusterInfo) string {
+	if len(clusters) == 0 {
+		return "No clusters found\n"
+	}
+	lines := []string{}
+	for i, cluster := range clusters {
+		line := fmt.Sprintf("%3d  %s", i+1, cluster.ClusterName)
+		if cluster.ClusterAlias != "" && cluster.ClusterAlias != cluster.ClusterName {
+			line = fmt.Sprintf("%s (%s)", line, cluster.ClusterAlias)
+		}
+		line = fmt.Sprintf("%s  instances: %d", line, cluster.CountInstances)
+		if cluster.IsInMaintenance {
+			line = fmt.Sprintf("%s  [maintenance]", line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// formatTUIProblems lists problems, numbered for selection. withCluster indicates whether to show the cluster of each problem.
+func formatTUIProblems(problems []inst.ReplicationAnalysis, withCluster bool) string {
+	if len(problems) == 0 {
+		return "No problems\n"
+	}
+	lines := []string{}
+	for i, problem := range problems {
+		line := fmt.Sprintf("%3d  %s  %s", i+1, problem.AnalyzedInstanceKey.DisplayString(), problem.Analysis)
+		if len(problem.StructureAnalysis) > 0 {
+			codes := []string{}
+			for _, code := range problem.StructureAnalysis {
+				codes = append(codes, string(code))
+			}
+			line = fmt.Sprintf("%s  %s", line, strings.Join(codes, ","))
+		}
+		if withCluster {
+			line = fmt.Sprintf("%s  (%s)", line, problem.ClusterDetails.ClusterName)
+		}
+		if problem.IsDowntimed {
+			line = fmt.Sprintf("%s  [downtimed]", line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// filterTUIProblems returns the analysis entries which indicate a problem, optionally only those of given cluster
+func filterTUIProblems(analysis []inst.ReplicationAnalysis, clusterName string) []inst.ReplicationAnalysis {
+	problems := []inst.ReplicationAnalysis{}
+	for _, analysisEntry := range analysis {
+		if analysisEntry.Analysis == inst.NoProblem && len(analysisEntry.StructureAnalysis) == 0 {
+			continue
+		}
+		if clusterName != "" && analysisEntry.ClusterDetails.ClusterName != clusterName {
+			continue
+		}
+		problems = append(problems, analysisEntry)
+	}
+	return problems
+}
+
+// tuiSelection parses a 1-based list selection
+func tuiSelection(command string, count int) (index int, ok bool) {
+	selected, err := strconv.Atoi(command)
+	if err != nil || selected < 1 || selected > count {
+		return 0, false
+	}
+	return selected - 1, true
+}
+
+// parseTUIInstanceKey resolves an instance given as host[:port]
+func parseTUIInstanceKey(hostPort string) (*inst.InstanceKey, error) {
+	if !strings.Contains(hostPort, ":") {
+		hostPort = fmt.Sprintf("%s:%d", hostPort, config.Config.DefaultInstancePort)
+	}
+	instanceKey, err := inst.ParseResolveInstanceKey(hostPort)
+	if err != nil {
+		return nil, err
+	}
+	return inst.ReadFuzzyInstanceKeyIfPossible(instanceKey), nil
+}
+
+func (this *terminalUI) printf(format string, args ...interface{}) {
+	fmt.Fprintf(this.out, format, args...)
+}
+
+// readLine prompts and reads a line of input. ok is false on end of input.
+func (this *terminalUI) readLine(prompt string) (line string, ok bool) {
+	this.printf("%s", prompt)
+	line, err := this.in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// confirm asks the operator to approve an action
+func (this *terminalUI) confirm(question string) bool {
+	answer, ok := this.readLine(fmt.Sprintf("%s [y/N] ", question))
+	return ok && (answer == "y" || answer == "yes")
+}
+
+func (this *terminalUI) run() {
+	for {
+		this.render()
+		line, ok := this.readLine("> ")
+		if !ok {
+			this.printf("\n")
+			return
+		}
+		this.message = ""
+		command, args := parseTUICommand(line)
+		switch command {
+		case "":
+		case "q", "quit", "exit":
+			return
+		case "c", "clusters":
+			this.screen = tuiClustersScreen
+		case "p", "problems":
+			this.screen = tuiProblemsScreen
+		case "b", "back":
+			if this.screen == tuiClusterScreen {
+				this.screen = tuiClustersScreen
+			}
+		case "r", "refresh":
+		case "relocate":
+			this.relocate(args)
+		case "takeover":
+			this.takeover(args)
+		default:
+			this.selectEntry(command)
+		}
+	}
+}
+
+// selectEntry opens the cluster of the numbered entry on the current screen
+func (this *terminalUI) selectEntry(command string) {
+	switch this.screen {
+	case tuiClustersScreen:
+		if index, ok := tuiSelection(command, len(this.clusters)); ok {
+			this.clusterName = this.clusters[index].ClusterName
+			this.screen = tuiClusterScreen
+			return
+		}
+	case tuiProblemsScreen:
+		if index, ok := tuiSelection(command, len(this.problems)); ok {
+			this.clusterName = this.problems[index].ClusterDetails.ClusterName
+			this.screen = tuiClusterScreen
+			return
+		}
+	}
+	this.message = fmt.Sprintf("Unknown command: %s", command)
+}
+
+// relocate moves an instance below another, upon confirmation
+func (this *terminalUI) relocate(args []string) {
+	if len(args) != 2 {
+		this.message = "Usage: relocate <instance> <destination>"
+		return
+	}
+	instanceKey, err := parseTUIInstanceKey(args[0])
+	if err != nil {
+		this.message = err.Error()
+		return
+	}
+	destinationKey, err := parseTUIInstanceKey(args[1])
+	if err != nil {
+		this.message = err.Error()
+		return
+	}
+	if !this.confirm(fmt.Sprintf("Relocate %s below %s?", instanceKey.DisplayString(), destinationKey.DisplayString())) {
+		this.message = "Relocation cancelled"
+		return
+	}
+	if _, err := inst.RelocateBelow(instanceKey, destinationKey); err != nil {
+		this.message = fmt.Sprintf("Relocation failed: %+v", err)
+		return
+	}
+	this.message = fmt.Sprintf("%s<%s", instanceKey.DisplayString(), destinationKey.DisplayString())
+}
+
+// takeover gracefully promotes a new master of the current cluster, upon confirmation
+func (this *terminalUI) takeover(args []string) {
+	if this.screen != tuiClusterScreen {
+		this.message = "Select a cluster first"
+		return
+	}
+	var designatedKey *inst.InstanceKey
+	if len(args) > 0 {
+		var err error
+		if designatedKey, err = parseTUIInstanceKey(args[0]); err != nil {
+			this.message = err.Error()
+			return
+		}
+	}
+	question := fmt.Sprintf("Graceful master takeover on %s?", this.clusterName)
+	if designatedKey != nil {
+		question = fmt.Sprintf("Graceful master takeover on %s, promoting %s?", this.clusterName, designatedKey.DisplayString())
+	}
+	if !this.confirm(question) {
+		this.message = "Takeover cancelled"
+		return
+	}
+	onProgress := func(progress *inst.ReplicationCatchupProgress) {
+		this.printf("%s\n", progress.String())
+	}
+	topologyRecovery, _, err := logic.GracefulMasterTakeoverWithProgress(this.clusterName, designatedKey, onProgress)
+	if err != nil {
+		this.message = fmt.Sprintf("Takeover failed: %+v", err)
+		return
+	}
+	this.message = fmt.Sprintf("Promoted %s", topologyRecovery.SuccessorKey.DisplayString())
+}
+
+func (this *terminalUI) render() {
+	this.printf("%s", tuiClearScreen)
+	switch this.screen {
+	case tuiClustersScreen:
+		this.renderClusters()
+	case tuiProblemsScreen:
+		this.renderProblems()
+	case tuiClusterScreen:
+		this.renderCluster()
+	}
+	if this.message != "" {
+		this.printf("\n%s\n", this.message)
+	}
+}
+
+func (this *terminalUI) renderClusters() {
+	this.printf("Clusters\n\n")
+	clusters, err := inst.ReadClustersInfo("")
+	if err != nil {
+		this.printf("Cannot read clusters: %+v\n", err)
+	}
+	this.clusters = clusters
+	this.printf("%s", formatTUIClusters(this.clusters))
+	this.printf("\n<number> open cluster | p problems | r refresh | q quit\n")
+}
+
+func (this *terminalUI) renderProblems() {
+	this.printf("Problems\n\n")
+	analysis, err := inst.GetReplicationAnalysis("", &inst.ReplicationAnalysisHints{IncludeDowntimed: true})
+	if err != nil {
+		this.printf("Cannot get analysis: %+v\n", err)
+	}
+	this.problems = filterTUIProblems(analysis, "")
+	this.printf("%s", formatTUIProblems(this.problems, true))
+	this.printf("\n<number> open cluster | c clusters | r refresh | q quit\n")
+}
+
+func (this *terminalUI) renderCluster() {
+	this.printf("Cluster %s\n\n", this.clusterName)
+	topology, err := inst.ASCIITopology(this.clusterName, "", false)
+	if err != nil {
+		this.printf("Cannot read topology: %+v\n", err)
+	}
+	this.printf("%s\n", topology)
+	analysis, err := inst.GetReplicationAnalysis(this.clusterName, &inst.ReplicationAnalysisHints{IncludeDowntimed: true})
+	if err != nil {
+		this.printf("Cannot get analysis: %+v\n", err)
+	}
+	this.printf("\nProblems:\n%s", formatTUIProblems(filterTUIProblems(analysis, this.clusterName), false))
+	this.printf("\nrelocate <instance> <destination> | takeover [<new master>] | b back | p problems | r refresh | q quit\n")
+}
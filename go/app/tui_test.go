@@ -0,0 +1,62 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseTUICommand(t *testing.T) {
+	{
+		command, args := parseTUICommand("  ")
+		test.S(t).ExpectEquals(command, "")
+		test.S(t).ExpectEquals(len(args), 0)
+	}
+	{
+		command, args := parseTUICommand(" Relocate db1:3306   db2 ")
+		test.S(t).ExpectEquals(command, "relocate")
+		test.S(t).ExpectEquals(len(args), 2)
+		test.S(t).ExpectEquals(args[0], "db1:3306")
+		test.S(t).ExpectEquals(args[1], "db2")
+	}
+}
+
+func TestTUISelection(t *testing.T) {
+	index, ok := tuiSelection("2", 3)
+	test.S(t).ExpectTrue(ok)
+	test.S(t).ExpectEquals(index, 1)
+	for _, command := range []string{"0", "4", "x", "-1"} {
+		_, ok := tuiSelection(command, 3)
+		test.S(t).ExpectFalse(ok)
+	}
+}
+
+func TestFormatTUIClusters(t *testing.T) {
+	test.S(t).ExpectEquals(formatTUIClusters(nil), "No clusters found\n")
+	clusters := []inst.ClusterInfo{
+		{ClusterName: "db1:3306", ClusterAlias: "main", CountInstances: 3},
+		{ClusterName: "db9:3306", ClusterAlias: "db9:3306", CountInstances: 1, IsInMaintenance: true},
+	}
+	lines := strings.Split(strings.TrimSuffix(formatTUIClusters(clusters), "\n"), "\n")
+	test.S(t).ExpectEquals(len(lines), 2)
+	test.S(t).ExpectEquals(lines[0], "  1  db1:3306 (main)  instances: 3")
+	test.S(t).ExpectEquals(lines[1], "  2  db9:3306  instances: 1  [maintenance]")
+}
+
+func TestTUIProblems(t *testing.T) {
+	analysis := []inst.ReplicationAnalysis{
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db1", Port: 3306}, Analysis: inst.NoProblem, ClusterDetails: inst.ClusterInfo{ClusterName: "c1"}},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db2", Port: 3306}, Analysis: inst.DeadMaster, ClusterDetails: inst.ClusterInfo{ClusterName: "c1"}, IsDowntimed: true},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db3", Port: 3306}, Analysis: inst.NoProblem, ClusterDetails: inst.ClusterInfo{ClusterName: "c2"}, StructureAnalysis: []inst.StructureAnalysisCode{inst.NoLoggingReplicasStructureWarning}},
+	}
+	problems := filterTUIProblems(analysis, "")
+	test.S(t).ExpectEquals(len(problems), 2)
+	test.S(t).ExpectEquals(len(filterTUIProblems(analysis, "c2")), 1)
+
+	lines := strings.Split(strings.TrimSuffix(formatTUIProblems(problems, true), "\n"), "\n")
+	test.S(t).ExpectEquals(lines[0], "  1  db2:3306  DeadMaster  (c1)  [downtimed]")
+	test.S(t).ExpectEquals(lines[1], "  2  db3:3306  NoProblem  NoLoggingReplicasStructureWarning  (c2)")
+	test.S(t).ExpectEquals(formatTUIProblems(nil, false), "No problems\n")
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package chatops parses the small command grammar by which orchestrator is operated from chat,
+// authorizes chat users to run commands, and speaks the Slack slash command protocol.
+package chatops
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/util"
+)
+
+// Action is what a chat command asks orchestrator to do
+type Action string
+
+const (
+	HelpAction             Action = "help"
+	ShowClusterAction      Action = "show cluster"
+	AckRecoveryAction      Action = "ack recovery"
+	BeginDowntimeAction    Action = "begin downtime"
+	GracefulTakeoverAction Action = "graceful takeover"
+)
+
+// Usage describes the command grammar
+const Usage = "show cluster <cluster>\n" +
+	"ack recovery <cluster | recovery id | recovery uid> <comment>\n" +
+	"begin downtime <host[:port]> <duration, e.g. 30m, 2h> <reason>\n" +
+	"graceful takeover <cluster> [<designated host[:port]>]\n" +
+	"help"
+
+// Command is a parsed chat command
+type Command struct {
+	Action      Action
+	ClusterHint string        // show cluster, graceful takeover
+	Recovery    string        // ack recovery: a cluster hint, recovery id or recovery uid
+	Instance    string        // begin downtime: host[:port]; graceful takeover: optional designated replica
+	Duration    time.Duration // begin downtime
+	Comment     string        // ack recovery: acknowledgement comment; begin downtime: reason
+}
+
+// IsMutating tells whether the command changes anything, as opposed to only reading
+func (this *Command) IsMutating() bool {
+	switch this.Action {
+	case AckRecoveryAction, BeginDowntimeAction, GracefulTakeoverAction:
+		return true
+	}
+	return false
+}
+
+// ParseCommand parses the text of a chat command, e.g. "begin downtime db-1:3306 2h reimaging"
+func ParseCommand(text string) (*Command, error) {
+	tokens := strings.Fields(text)
+	if len(tokens) == 0 {
+		return &Command{Action: HelpAction}, nil
+	}
+	if strings.ToLower(tokens[0]) == string(HelpAction) {
+		return &Command{Action: HelpAction}, nil
+	}
+	if len(tokens) < 2 {
+		return nil, fmt.Errorf("Unknown command: %s", text)
+	}
+	action := Action(strings.ToLower(tokens[0] + " " + tokens[1]))
+	args := tokens[2:]
+	command := &Command{Action: action}
+	switch action {
+	case ShowClusterAction:
+		if len(args) != 1 {
+			return nil, fmt.Errorf("Usage: show cluster <cluster>")
+		}
+		command.ClusterHint = args[0]
+	case AckRecoveryAction:
+		if len(args) < 2 {
+			return nil, fmt.Errorf("Usage: ack recovery <cluster | recovery id | recovery uid> <comment>")
+		}
+		command.Recovery = args[0]
+		command.Comment = strings.Join(args[1:], " ")
+	case BeginDowntimeAction:
+		if len(args) < 3 {
+			return nil, fmt.Errorf("Usage: begin downtime <host[:port]> <duration> <reason>")
+		}
+		command.Instance = args[0]
+		seconds, err := util.SimpleTimeToSeconds(args[1])
+		if err != nil || seconds <= 0 {
+			return nil, fmt.Errorf("Invalid downtime duration: %s; expecting e.g. 30m, 2h, 1d", args[1])
+		}
+		command.Duration = time.Duration(seconds) * time.Second
+		command.Comment = strings.Join(args[2:], " ")
+	case GracefulTakeoverAction:
+		if len(args) < 1 || len(args) > 2 {
+			return nil, fmt.Errorf("Usage: graceful takeover <cluster> [<designated host[:port]>]")
+		}
+		command.ClusterHint = args[0]
+		if len(args) == 2 {
+			command.Instance = args[1]
+		}
+	default:
+		return nil, fmt.Errorf("Unknown command: %s", text)
+	}
+	return command, nil
+}
+
+// IsPowerUser tells whether a chat user, identified by user id or user name, may run mutating commands
+func IsPowerUser(userId string, userName string) bool {
+	for _, powerUser := range config.Config.SlackPowerUsers {
+		if powerUser == "*" || (powerUser != "" && (powerUser == userId || powerUser == userName)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package chatops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func init() {
+	config.Config.SlackSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+	config.Config.SlackPowerUsers = []string{"U0DBA", "shlomi"}
+}
+
+func TestParseCommand(t *testing.T) {
+	command, err := ParseCommand("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(command.Action, HelpAction)
+
+	command, err = ParseCommand("Show Cluster  mycluster")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(command.Action, ShowClusterAction)
+	test.S(t).ExpectEquals(command.ClusterHint, "mycluster")
+	test.S(t).ExpectFalse(command.IsMutating())
+
+	command, err = ParseCommand("ack recovery mycluster failover looks good")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(command.Recovery, "mycluster")
+	test.S(t).ExpectEquals(command.Comment, "failover looks good")
+	test.S(t).ExpectTrue(command.IsMutating())
+
+	command, err = ParseCommand("begin downtime db-1:3306 2h reimaging host")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(command.Instance, "db-1:3306")
+	test.S(t).ExpectEquals(command.Duration, 2*time.Hour)
+	test.S(t).ExpectEquals(command.Comment, "reimaging host")
+
+	command, err = ParseCommand("graceful takeover mycluster db-2:3306")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(command.ClusterHint, "mycluster")
+	test.S(t).ExpectEquals(command.Instance, "db-2:3306")
+
+	_, err = ParseCommand("ack recovery mycluster")
+	test.S(t).ExpectNotNil(err)
+	_, err = ParseCommand("begin downtime db-1:3306 soon reimaging")
+	test.S(t).ExpectNotNil(err)
+	_, err = ParseCommand("drop database")
+	test.S(t).ExpectNotNil(err)
+}
+
+func TestIsPowerUser(t *testing.T) {
+	test.S(t).ExpectTrue(IsPowerUser("U0DBA", "someone"))
+	test.S(t).ExpectTrue(IsPowerUser("U0OTHER", "shlomi"))
+	test.S(t).ExpectFalse(IsPowerUser("U0OTHER", "someone"))
+	test.S(t).ExpectFalse(IsPowerUser("", ""))
+}
+
+func TestVerifySlackRequest(t *testing.T) {
+	now := time.Now()
+	timestamp := fmt.Sprintf("%d", now.Unix())
+	body := []byte("command=%2Forchestrator&text=show+cluster+mycluster")
+	signature := slackSignature(config.Config.SlackSigningSecret, timestamp, body)
+
+	test.S(t).ExpectNil(VerifySlackRequest(timestamp, signature, body, now))
+	test.S(t).ExpectNotNil(VerifySlackRequest(timestamp, signature, []byte("text=graceful+takeover+mycluster"), now))
+	test.S(t).ExpectNotNil(VerifySlackRequest(timestamp, "v0=00", body, now))
+	test.S(t).ExpectNotNil(VerifySlackRequest(timestamp, signature, body, now.Add(10*time.Minute)))
+	test.S(t).ExpectNotNil(VerifySlackRequest("", signature, body, now))
+}
+
+func TestPostSlackResponse(t *testing.T) {
+	var posted SlackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&posted)
+	}))
+	defer server.Close()
+
+	message := NewSlackMessage(SlackInChannel, "Graceful takeover of mycluster complete").AddSection("", "Promoted", "db-2:3306")
+	test.S(t).ExpectNil(PostSlackResponse(server.URL, message))
+	test.S(t).ExpectEquals(posted.ResponseType, SlackInChannel)
+	test.S(t).ExpectEquals(len(posted.Blocks), 2)
+	test.S(t).ExpectEquals(posted.Blocks[0].Text.Text, "Graceful takeover of mycluster complete")
+	test.S(t).ExpectEquals(posted.Blocks[1].Fields[0].Text, "*Promoted*\ndb-2:3306")
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package chatops
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+const (
+	// SlackSignatureHeader and SlackTimestampHeader authenticate a request Slack sends
+	SlackSignatureHeader = "X-Slack-Signature"
+	SlackTimestampHeader = "X-Slack-Request-Timestamp"
+
+	// slackMaxRequestAge bounds the age of a signed request, against replays
+	slackMaxRequestAge = 5 * time.Minute
+	// slackResponseTimeout caps the time given to posting a delayed response
+	slackResponseTimeout = 10 * time.Second
+)
+
+// Slack response visibility
+const (
+	SlackEphemeral = "ephemeral"  // visible only to the user issuing the command
+	SlackInChannel = "in_channel" // visible to the whole channel
+)
+
+// IsSlackEnabled tells whether Slack slash commands are accepted
+func IsSlackEnabled() bool {
+	return config.Config.SlackSigningSecret != ""
+}
+
+// slackSignature computes the signature of a request body sent at given timestamp
+func slackSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "v0:%s:", timestamp)
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySlackRequest checks that a request body was signed by Slack with SlackSigningSecret, recently enough
+func VerifySlackRequest(timestamp string, signature string, body []byte, now time.Time) error {
+	if !IsSlackEnabled() {
+		return fmt.Errorf("SlackSigningSecret is not configured; Slack chatops is disabled")
+	}
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("Invalid %s: %s", SlackTimestampHeader, timestamp)
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > slackMaxRequestAge || age < -slackMaxRequestAge {
+		return fmt.Errorf("Stale Slack request, sent at %s", time.Unix(seconds, 0))
+	}
+	expected := slackSignature(config.Config.SlackSigningSecret, timestamp, body)
+	if !hmac.Equal([]byte(expected), []byte(signature)) {
+		return fmt.Errorf("Invalid Slack request signature")
+	}
+	return nil
+}
+
+// SlackText is a Slack text object
+type SlackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// SlackBlock is a Slack layout block: a header, a section, or a divider
+type SlackBlock struct {
+	Type   string      `json:"type"`
+	Text   *SlackText  `json:"text,omitempty"`
+	Fields []SlackText `json:"fields,omitempty"`
+}
+
+// SlackMessage is a response to a slash command
+type SlackMessage struct {
+	ResponseType string       `json:"response_type"`
+	Text         string       `json:"text"` // fallback, for notifications
+	Blocks       []SlackBlock `json:"blocks,omitempty"`
+}
+
+// NewSlackMessage returns a message whose first block is a header with given text
+func NewSlackMessage(responseType string, header string) *SlackMessage {
+	message := &SlackMessage{ResponseType: responseType, Text: header}
+	message.Blocks = append(message.Blocks, SlackBlock{Type: "header", Text: &SlackText{Type: "plain_text", Text: header}})
+	return message
+}
+
+// AddSection appends a section with given markdown text and "title: value" fields. Fields are given as
+// alternating titles and values.
+func (this *SlackMessage) AddSection(text string, fields ...string) *SlackMessage {
+	block := SlackBlock{Type: "section"}
+	if text != "" {
+		block.Text = &SlackText{Type: "mrkdwn", Text: text}
+	}
+	for i := 0; i+1 < len(fields); i += 2 {
+		block.Fields = append(block.Fields, SlackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", fields[i], fields[i+1])})
+	}
+	this.Blocks = append(this.Blocks, block)
+	return this
+}
+
+// AddCode appends a section with given text as a code block
+func (this *SlackMessage) AddCode(code string) *SlackMessage {
+	return this.AddSection(fmt.Sprintf("```%s```", code))
+}
+
+// NewSlackErrorMessage returns a message, visible only to the issuing user, reporting an error
+func NewSlackErrorMessage(err error) *SlackMessage {
+	return NewSlackMessage(SlackEphemeral, "Error").AddSection(err.Error())
+}
+
+// PostSlackResponse posts a delayed response to a slash command's response_url, for commands running longer
+// than Slack waits for an immediate response
+func PostSlackResponse(responseURL string, message *SlackMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: slackResponseTimeout}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Posting Slack response: %s", resp.Status)
+	}
+	return nil
+}
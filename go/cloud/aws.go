@@ -0,0 +1,190 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+
+Package cloud queries cloud provider APIs directly over HTTP, without vendoring provider SDKs.
+
+AWS query APIs are signed with Signature Version 4. Credentials are taken from the
+AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY/AWS_SESSION_TOKEN environment variables, or else from
+the EC2 instance metadata service (the IAM role of the instance orchestrator runs on).
+
+*/
+
+package cloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const requestTimeout = 10 * time.Second
+const awsInstanceMetadataURL = "http://169.254.169.254/latest"
+
+var httpClient = &http.Client{Timeout: requestTimeout}
+
+type awsCredentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	Token           string
+}
+
+func getAWSInstanceMetadata(path string, token string) ([]byte, error) {
+	request, err := http.NewRequest("GET", awsInstanceMetadataURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token", token)
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("instance metadata %s: unexpected status %d", path, response.StatusCode)
+	}
+	return body, nil
+}
+
+func getAWSCredentials() (credentials awsCredentials, err error) {
+	if accessKeyId := os.Getenv("AWS_ACCESS_KEY_ID"); accessKeyId != "" {
+		credentials.AccessKeyId = accessKeyId
+		credentials.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		credentials.Token = os.Getenv("AWS_SESSION_TOKEN")
+		return credentials, nil
+	}
+	// IMDSv2: get a session token first
+	request, err := http.NewRequest("PUT", awsInstanceMetadataURL+"/api/token", nil)
+	if err != nil {
+		return credentials, err
+	}
+	request.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "60")
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return credentials, fmt.Errorf("No AWS credentials in environment, and cannot reach instance metadata: %+v", err)
+	}
+	defer response.Body.Close()
+	token, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return credentials, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return credentials, fmt.Errorf("instance metadata token: unexpected status %d", response.StatusCode)
+	}
+	role, err := getAWSInstanceMetadata("/meta-data/iam/security-credentials/", string(token))
+	if err != nil {
+		return credentials, err
+	}
+	roleCredentials, err := getAWSInstanceMetadata("/meta-data/iam/security-credentials/"+strings.TrimSpace(string(role)), string(token))
+	if err != nil {
+		return credentials, err
+	}
+	err = json.Unmarshal(roleCredentials, &credentials)
+	return credentials, err
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	h := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(h[:])
+}
+
+// signAWSRequest signs a GET request to an AWS query API with Signature Version 4
+func signAWSRequest(request *http.Request, credentials awsCredentials, region string, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	request.Header.Set("X-Amz-Date", amzDate)
+	if credentials.Token != "" {
+		request.Header.Set("X-Amz-Security-Token", credentials.Token)
+	}
+
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-date:%s\n", request.URL.Host, amzDate)
+	signedHeaders := "host;x-amz-date"
+	if credentials.Token != "" {
+		canonicalHeaders = fmt.Sprintf("%sx-amz-security-token:%s\n", canonicalHeaders, credentials.Token)
+		signedHeaders = signedHeaders + ";x-amz-security-token"
+	}
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		"/",
+		request.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		sha256Hex(""),
+	}, "\n")
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex(canonicalRequest)}, "\n")
+
+	signingKey := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	signingKey = hmacSHA256(signingKey, region)
+	signingKey = hmacSHA256(signingKey, service)
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	request.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", credentials.AccessKeyId, scope, signedHeaders, signature))
+}
+
+// AWSQuery issues a signed GET request to an AWS query API (e.g. EC2, RDS), and decodes its XML response into result
+func AWSQuery(region string, service string, params url.Values, result interface{}) error {
+	credentials, err := getAWSCredentials()
+	if err != nil {
+		return err
+	}
+	requestURL := &url.URL{
+		Scheme: "https",
+		Host:   fmt.Sprintf("%s.%s.amazonaws.com", service, region),
+		Path:   "/",
+		// SigV4 requires spaces encoded as %20
+		RawQuery: strings.Replace(params.Encode(), "+", "%20", -1),
+	}
+	request, err := http.NewRequest("GET", requestURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	signAWSRequest(request, credentials, region, service, time.Now())
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: unexpected status %d: %s", service, params.Get("Action"), response.StatusCode, string(body))
+	}
+	return xml.Unmarshal(body, result)
+}
@@ -0,0 +1,39 @@
+package cloud
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+// Credentials and expected signatures are those of the AWS Signature Version 4 test suite
+var testAWSCredentials = awsCredentials{AccessKeyId: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+var testAWSSigningTime = time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+func TestSignAWSRequest(t *testing.T) {
+	{
+		request, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+		test.S(t).ExpectNil(err)
+		signAWSRequest(request, testAWSCredentials, "us-east-1", "service", testAWSSigningTime)
+		test.S(t).ExpectEquals(request.Header.Get("X-Amz-Date"), "20150830T123600Z")
+		test.S(t).ExpectEquals(request.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31")
+	}
+	{
+		request, err := http.NewRequest("GET", "https://example.amazonaws.com/?Param1=value1&Param2=value2", nil)
+		test.S(t).ExpectNil(err)
+		signAWSRequest(request, testAWSCredentials, "us-east-1", "service", testAWSSigningTime)
+		test.S(t).ExpectTrue(strings.HasSuffix(request.Header.Get("Authorization"), "Signature=b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500"))
+	}
+	{
+		credentials := testAWSCredentials
+		credentials.Token = "session-token"
+		request, err := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+		test.S(t).ExpectNil(err)
+		signAWSRequest(request, credentials, "us-east-1", "service", testAWSSigningTime)
+		test.S(t).ExpectEquals(request.Header.Get("X-Amz-Security-Token"), "session-token")
+		test.S(t).ExpectTrue(strings.Contains(request.Header.Get("Authorization"), "SignedHeaders=host;x-amz-date;x-amz-security-token,"))
+	}
+}
@@ -0,0 +1,182 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const cloudSQLAdminURL = "https://sqladmin.googleapis.com/sql/v1beta4"
+
+// CloudSQLPort is the port all CloudSQL MySQL instances listen on
+const CloudSQLPort = 3306
+
+// CloudSQLIPAddress is an address of a CloudSQL instance; Type is e.g. "PRIVATE" or "PRIMARY"
+type CloudSQLIPAddress struct {
+	Type      string `json:"type"`
+	IPAddress string `json:"ipAddress"`
+}
+
+// CloudSQLInstance is the subset of a CloudSQL instance description orchestrator cares about
+type CloudSQLInstance struct {
+	Name               string              `json:"name"`
+	DatabaseVersion    string              `json:"databaseVersion"`
+	State              string              `json:"state"`
+	MasterInstanceName string              `json:"masterInstanceName"`
+	ReplicaNames       []string            `json:"replicaNames"`
+	IPAddresses        []CloudSQLIPAddress `json:"ipAddresses"`
+}
+
+// IsMySQL returns true for CloudSQL MySQL instances
+func (this *CloudSQLInstance) IsMySQL() bool {
+	return strings.HasPrefix(this.DatabaseVersion, "MYSQL")
+}
+
+// IPAddress returns the instance's address of given type, or empty string if it has none
+func (this *CloudSQLInstance) IPAddress(ipType string) string {
+	for _, address := range this.IPAddresses {
+		if address.Type == ipType {
+			return address.IPAddress
+		}
+	}
+	return ""
+}
+
+// IPAddressType returns the type of given address of the instance, or empty string if the address is not the instance's
+func (this *CloudSQLInstance) IPAddressType(ipAddress string) string {
+	for _, address := range this.IPAddresses {
+		if address.IPAddress == ipAddress {
+			return address.Type
+		}
+	}
+	return ""
+}
+
+// IsReplicaOf returns true when this instance replicates from the given instance of given project.
+// CloudSQL reports the master as "project:instance".
+func (this *CloudSQLInstance) IsReplicaOf(project string, masterName string) bool {
+	return this.MasterInstanceName == masterName || this.MasterInstanceName == fmt.Sprintf("%s:%s", project, masterName)
+}
+
+// CloudSQLOperation is a long running CloudSQL Admin API operation
+type CloudSQLOperation struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Error  *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// IsDone returns true once the operation has completed, successfully or not
+func (this *CloudSQLOperation) IsDone() bool {
+	return this.Status == "DONE"
+}
+
+// Err returns the operation's error, if any
+func (this *CloudSQLOperation) Err() error {
+	if this.Error == nil || len(this.Error.Errors) == 0 {
+		return nil
+	}
+	messages := []string{}
+	for _, operationError := range this.Error.Errors {
+		messages = append(messages, fmt.Sprintf("%s: %s", operationError.Code, operationError.Message))
+	}
+	return fmt.Errorf("CloudSQL operation %s failed: %s", this.Name, strings.Join(messages, "; "))
+}
+
+func cloudSQLHeaders() (map[string]string, error) {
+	accessToken, err := GCEAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"Authorization": "Bearer " + accessToken}, nil
+}
+
+// ListCloudSQLInstances lists all CloudSQL instances of given project
+func ListCloudSQLInstances(project string) (instances []CloudSQLInstance, err error) {
+	headers, err := cloudSQLHeaders()
+	if err != nil {
+		return instances, err
+	}
+	pageToken := ""
+	for {
+		requestURL := fmt.Sprintf("%s/projects/%s/instances", cloudSQLAdminURL, url.PathEscape(project))
+		if pageToken != "" {
+			requestURL = fmt.Sprintf("%s?pageToken=%s", requestURL, url.QueryEscape(pageToken))
+		}
+		response := struct {
+			Items         []CloudSQLInstance `json:"items"`
+			NextPageToken string             `json:"nextPageToken"`
+		}{}
+		if err := GetJSON(requestURL, headers, &response); err != nil {
+			return instances, err
+		}
+		instances = append(instances, response.Items...)
+		if response.NextPageToken == "" {
+			return instances, nil
+		}
+		pageToken = response.NextPageToken
+	}
+}
+
+// GetCloudSQLInstance describes a single CloudSQL instance
+func GetCloudSQLInstance(project string, name string) (*CloudSQLInstance, error) {
+	headers, err := cloudSQLHeaders()
+	if err != nil {
+		return nil, err
+	}
+	instance := &CloudSQLInstance{}
+	requestURL := fmt.Sprintf("%s/projects/%s/instances/%s", cloudSQLAdminURL, url.PathEscape(project), url.PathEscape(name))
+	if err := GetJSON(requestURL, headers, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// PromoteCloudSQLReplica promotes a CloudSQL read replica into a standalone instance. The promotion is asynchronous;
+// the returned operation is to be polled via GetCloudSQLOperation.
+func PromoteCloudSQLReplica(project string, name string) (*CloudSQLOperation, error) {
+	headers, err := cloudSQLHeaders()
+	if err != nil {
+		return nil, err
+	}
+	operation := &CloudSQLOperation{}
+	requestURL := fmt.Sprintf("%s/projects/%s/instances/%s/promoteReplica", cloudSQLAdminURL, url.PathEscape(project), url.PathEscape(name))
+	if err := DoJSON("POST", requestURL, headers, struct{}{}, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// GetCloudSQLOperation reads the status of a CloudSQL operation
+func GetCloudSQLOperation(project string, operationName string) (*CloudSQLOperation, error) {
+	headers, err := cloudSQLHeaders()
+	if err != nil {
+		return nil, err
+	}
+	operation := &CloudSQLOperation{}
+	requestURL := fmt.Sprintf("%s/projects/%s/operations/%s", cloudSQLAdminURL, url.PathEscape(project), url.PathEscape(operationName))
+	if err := GetJSON(requestURL, headers, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
@@ -0,0 +1,38 @@
+package cloud
+
+import (
+	"encoding/json"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestCloudSQLInstance(t *testing.T) {
+	instance := CloudSQLInstance{}
+	err := json.Unmarshal([]byte(`{
+		"name": "replica-1",
+		"databaseVersion": "MYSQL_5_7",
+		"masterInstanceName": "my-project:master-1",
+		"ipAddresses": [{"type": "PRIMARY", "ipAddress": "35.1.2.3"}, {"type": "PRIVATE", "ipAddress": "10.1.2.3"}]
+	}`), &instance)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(instance.IsMySQL())
+	test.S(t).ExpectEquals(instance.IPAddress("PRIVATE"), "10.1.2.3")
+	test.S(t).ExpectEquals(instance.IPAddress("OUTGOING"), "")
+	test.S(t).ExpectEquals(instance.IPAddressType("35.1.2.3"), "PRIMARY")
+	test.S(t).ExpectEquals(instance.IPAddressType("10.9.9.9"), "")
+	test.S(t).ExpectTrue(instance.IsReplicaOf("my-project", "master-1"))
+	test.S(t).ExpectFalse(instance.IsReplicaOf("my-project", "master-2"))
+}
+
+func TestCloudSQLOperationErr(t *testing.T) {
+	operation := CloudSQLOperation{}
+	err := json.Unmarshal([]byte(`{"name": "op-1", "status": "DONE", "error": {"errors": [{"code": "INTERNAL_ERROR", "message": "failed"}]}}`), &operation)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectTrue(operation.IsDone())
+	test.S(t).ExpectNotNil(operation.Err())
+
+	operation = CloudSQLOperation{Name: "op-2", Status: "RUNNING"}
+	test.S(t).ExpectFalse(operation.IsDone())
+	test.S(t).ExpectNil(operation.Err())
+}
@@ -0,0 +1,108 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cloud
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+)
+
+const ec2APIVersion = "2016-11-15"
+
+// EC2Instance is the subset of an EC2 instance description orchestrator cares about
+type EC2Instance struct {
+	InstanceId       string `xml:"instanceId"`
+	PrivateDnsName   string `xml:"privateDnsName"`
+	PrivateIpAddress string `xml:"privateIpAddress"`
+	State            struct {
+		Name string `xml:"name"`
+	} `xml:"instanceState"`
+}
+
+// IsStopped returns true when the instance is powered off, or on its way to termination
+func (this *EC2Instance) IsStopped() bool {
+	switch this.State.Name {
+	case "stopped", "shutting-down", "terminated":
+		return true
+	}
+	return false
+}
+
+type ec2DescribeInstancesResponse struct {
+	Reservations []struct {
+		Instances []EC2Instance `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+}
+
+func (this *ec2DescribeInstancesResponse) firstInstance() *EC2Instance {
+	for _, reservation := range this.Reservations {
+		if len(reservation.Instances) > 0 {
+			return &reservation.Instances[0]
+		}
+	}
+	return nil
+}
+
+// FindEC2Instance finds the EC2 instance of given private DNS name or private IP address
+func FindEC2Instance(region string, address string) (*EC2Instance, error) {
+	params := url.Values{}
+	params.Set("Action", "DescribeInstances")
+	params.Set("Version", ec2APIVersion)
+	if net.ParseIP(address) != nil {
+		params.Set("Filter.1.Name", "private-ip-address")
+	} else {
+		params.Set("Filter.1.Name", "private-dns-name")
+	}
+	params.Set("Filter.1.Value.1", address)
+	response := ec2DescribeInstancesResponse{}
+	if err := AWSQuery(region, "ec2", params, &response); err != nil {
+		return nil, err
+	}
+	if instance := response.firstInstance(); instance != nil {
+		return instance, nil
+	}
+	return nil, fmt.Errorf("No EC2 instance found for %s in %s", address, region)
+}
+
+// DescribeEC2Instance describes a single EC2 instance
+func DescribeEC2Instance(region string, instanceId string) (*EC2Instance, error) {
+	params := url.Values{}
+	params.Set("Action", "DescribeInstances")
+	params.Set("Version", ec2APIVersion)
+	params.Set("InstanceId.1", instanceId)
+	response := ec2DescribeInstancesResponse{}
+	if err := AWSQuery(region, "ec2", params, &response); err != nil {
+		return nil, err
+	}
+	if instance := response.firstInstance(); instance != nil {
+		return instance, nil
+	}
+	return nil, fmt.Errorf("EC2 instance %s not found in %s", instanceId, region)
+}
+
+// StopEC2Instance force-stops an EC2 instance: the instance is powered off without a graceful OS shutdown.
+// The stop is asynchronous; poll DescribeEC2Instance to verify.
+func StopEC2Instance(region string, instanceId string) error {
+	params := url.Values{}
+	params.Set("Action", "StopInstances")
+	params.Set("Version", ec2APIVersion)
+	params.Set("InstanceId.1", instanceId)
+	params.Set("Force", "true")
+	response := struct{}{}
+	return AWSQuery(region, "ec2", params, &response)
+}
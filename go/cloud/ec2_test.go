@@ -0,0 +1,43 @@
+package cloud
+
+import (
+	"encoding/xml"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseEC2DescribeInstancesResponse(t *testing.T) {
+	body := `<?xml version="1.0" encoding="UTF-8"?>
+<DescribeInstancesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+	<requestId>8f7724cf-496f-496e-8fe3-example</requestId>
+	<reservationSet>
+		<item>
+			<reservationId>r-1234567890abcdef0</reservationId>
+			<instancesSet>
+				<item>
+					<instanceId>i-1234567890abcdef0</instanceId>
+					<instanceState>
+						<code>80</code>
+						<name>stopped</name>
+					</instanceState>
+					<privateDnsName>ip-10-0-0-12.ec2.internal</privateDnsName>
+					<privateIpAddress>10.0.0.12</privateIpAddress>
+				</item>
+			</instancesSet>
+		</item>
+	</reservationSet>
+</DescribeInstancesResponse>`
+	response := ec2DescribeInstancesResponse{}
+	test.S(t).ExpectNil(xml.Unmarshal([]byte(body), &response))
+	instance := response.firstInstance()
+	test.S(t).ExpectNotNil(instance)
+	test.S(t).ExpectEquals(instance.InstanceId, "i-1234567890abcdef0")
+	test.S(t).ExpectEquals(instance.PrivateDnsName, "ip-10-0-0-12.ec2.internal")
+	test.S(t).ExpectEquals(instance.PrivateIpAddress, "10.0.0.12")
+	test.S(t).ExpectTrue(instance.IsStopped())
+
+	empty := ec2DescribeInstancesResponse{}
+	test.S(t).ExpectNil(xml.Unmarshal([]byte(`<DescribeInstancesResponse><reservationSet/></DescribeInstancesResponse>`), &empty))
+	test.S(t).ExpectTrue(empty.firstInstance() == nil)
+}
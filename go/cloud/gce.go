@@ -0,0 +1,86 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const gceComputeURL = "https://compute.googleapis.com/compute/v1"
+
+const gceInternalDNSSuffix = ".internal"
+
+// GCEInstance is the subset of a GCE instance description orchestrator cares about
+type GCEInstance struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// IsStopped returns true when the instance is powered off
+func (this *GCEInstance) IsStopped() bool {
+	return this.Status == "TERMINATED" || this.Status == "STOPPED"
+}
+
+// ParseGCEHostname extracts the instance name, zone and project out of a GCE zonal internal DNS name,
+// e.g. "db-1.us-central1-a.c.my-project.internal". ok is false for any other hostname.
+func ParseGCEHostname(hostname string) (name string, zone string, project string, ok bool) {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if !strings.HasSuffix(hostname, gceInternalDNSSuffix) {
+		return "", "", "", false
+	}
+	tokens := strings.Split(strings.TrimSuffix(hostname, gceInternalDNSSuffix), ".")
+	if len(tokens) != 4 || tokens[2] != "c" {
+		return "", "", "", false
+	}
+	return tokens[0], tokens[1], tokens[3], true
+}
+
+func gceInstanceURL(project string, zone string, name string) string {
+	return fmt.Sprintf("%s/projects/%s/zones/%s/instances/%s", gceComputeURL, url.PathEscape(project), url.PathEscape(zone), url.PathEscape(name))
+}
+
+func gceHeaders() (map[string]string, error) {
+	accessToken, err := GCEAccessToken()
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{"Authorization": "Bearer " + accessToken}, nil
+}
+
+// GetGCEInstance describes a single GCE instance
+func GetGCEInstance(project string, zone string, name string) (*GCEInstance, error) {
+	headers, err := gceHeaders()
+	if err != nil {
+		return nil, err
+	}
+	instance := &GCEInstance{}
+	if err := GetJSON(gceInstanceURL(project, zone, name), headers, instance); err != nil {
+		return nil, err
+	}
+	return instance, nil
+}
+
+// StopGCEInstance powers off a GCE instance. The stop is asynchronous; poll GetGCEInstance to verify.
+func StopGCEInstance(project string, zone string, name string) error {
+	headers, err := gceHeaders()
+	if err != nil {
+		return err
+	}
+	return DoJSON("POST", gceInstanceURL(project, zone, name)+"/stop", headers, struct{}{}, nil)
+}
@@ -0,0 +1,36 @@
+package cloud
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseGCEHostname(t *testing.T) {
+	{
+		name, zone, project, ok := ParseGCEHostname("db-1.us-central1-a.c.my-project.internal")
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(name, "db-1")
+		test.S(t).ExpectEquals(zone, "us-central1-a")
+		test.S(t).ExpectEquals(project, "my-project")
+	}
+	{
+		name, _, _, ok := ParseGCEHostname("DB-2.europe-west1-b.c.my-project.internal.")
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(name, "db-2")
+	}
+	{
+		_, _, _, ok := ParseGCEHostname("db-1.c.my-project.internal")
+		test.S(t).ExpectFalse(ok)
+	}
+	{
+		_, _, _, ok := ParseGCEHostname("db-1.example.com")
+		test.S(t).ExpectFalse(ok)
+	}
+}
+
+func TestGCEInstanceIsStopped(t *testing.T) {
+	test.S(t).ExpectTrue((&GCEInstance{Status: "TERMINATED"}).IsStopped())
+	test.S(t).ExpectFalse((&GCEInstance{Status: "STOPPING"}).IsStopped())
+	test.S(t).ExpectFalse((&GCEInstance{Status: "RUNNING"}).IsStopped())
+}
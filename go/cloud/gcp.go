@@ -0,0 +1,83 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cloud
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+)
+
+// GCP APIs are authenticated with the default service account of the GCE instance orchestrator
+// runs on, as provided by the GCE metadata server.
+
+const gceServiceAccountTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// DoJSON issues an HTTP request with an optional JSON body, and decodes the JSON response into result
+func DoJSON(method string, requestURL string, headers map[string]string, body interface{}, result interface{}) error {
+	var requestBody []byte
+	if body != nil {
+		var err error
+		if requestBody, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	request, err := http.NewRequest(method, requestURL, bytes.NewReader(requestBody))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		request.Header.Set("Content-Type", "application/json")
+	}
+	for header, value := range headers {
+		request.Header.Set(header, value)
+	}
+	response, err := httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	responseBody, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return err
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s: unexpected status %d: %s", method, requestURL, response.StatusCode, string(responseBody))
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(responseBody, result)
+}
+
+// GetJSON issues a GET request and decodes the JSON response into result
+func GetJSON(requestURL string, headers map[string]string, result interface{}) error {
+	return DoJSON("GET", requestURL, headers, nil, result)
+}
+
+// GCEAccessToken returns an OAuth access token of the GCE instance's default service account
+func GCEAccessToken() (string, error) {
+	token := struct {
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := GetJSON(gceServiceAccountTokenURL, map[string]string{"Metadata-Flavor": "Google"}, &token); err != nil {
+		return "", err
+	}
+	return token.AccessToken, nil
+}
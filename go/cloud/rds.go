@@ -0,0 +1,150 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package cloud
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const rdsAPIVersion = "2014-10-31"
+const rdsEndpointSuffix = ".rds.amazonaws.com"
+
+// RDSDBInstance is the subset of an RDS DBInstance description orchestrator cares about
+type RDSDBInstance struct {
+	DBInstanceIdentifier                  string   `xml:"DBInstanceIdentifier"`
+	DBClusterIdentifier                   string   `xml:"DBClusterIdentifier"`
+	DBInstanceStatus                      string   `xml:"DBInstanceStatus"`
+	Engine                                string   `xml:"Engine"`
+	ReadReplicaSourceDBInstanceIdentifier string   `xml:"ReadReplicaSourceDBInstanceIdentifier"`
+	ReadReplicaDBInstanceIdentifiers      []string `xml:"ReadReplicaDBInstanceIdentifiers>ReadReplicaDBInstanceIdentifier"`
+	Endpoint                              struct {
+		Address string `xml:"Address"`
+		Port    int    `xml:"Port"`
+	} `xml:"Endpoint"`
+}
+
+// IsAvailable returns true when the DB instance is available for use
+func (this *RDSDBInstance) IsAvailable() bool {
+	return this.DBInstanceStatus == "available"
+}
+
+// RDSDBClusterMember is a member of an Aurora DB cluster
+type RDSDBClusterMember struct {
+	DBInstanceIdentifier string `xml:"DBInstanceIdentifier"`
+	IsClusterWriter      bool   `xml:"IsClusterWriter"`
+}
+
+// RDSDBCluster is the subset of an Aurora DBCluster description orchestrator cares about
+type RDSDBCluster struct {
+	DBClusterIdentifier string               `xml:"DBClusterIdentifier"`
+	Status              string               `xml:"Status"`
+	DBClusterMembers    []RDSDBClusterMember `xml:"DBClusterMembers>DBClusterMember"`
+}
+
+// Writer returns the identifier of the cluster's writer instance, or empty string if there is none
+func (this *RDSDBCluster) Writer() string {
+	for _, member := range this.DBClusterMembers {
+		if member.IsClusterWriter {
+			return member.DBInstanceIdentifier
+		}
+	}
+	return ""
+}
+
+// ParseRDSEndpoint extracts the DB instance identifier and region out of an RDS instance endpoint,
+// e.g. "mydb.abcdefghijkl.us-east-1.rds.amazonaws.com". ok is false for non-RDS hostnames and for Aurora cluster endpoints.
+func ParseRDSEndpoint(hostname string) (instanceIdentifier string, region string, ok bool) {
+	hostname = strings.ToLower(strings.TrimSuffix(hostname, "."))
+	if !strings.HasSuffix(hostname, rdsEndpointSuffix) {
+		return "", "", false
+	}
+	tokens := strings.Split(strings.TrimSuffix(hostname, rdsEndpointSuffix), ".")
+	if len(tokens) != 3 {
+		return "", "", false
+	}
+	if strings.HasPrefix(tokens[1], "cluster-") {
+		// An Aurora cluster (or reader) endpoint, not an instance endpoint
+		return "", "", false
+	}
+	return tokens[0], tokens[2], true
+}
+
+type rdsDescribeDBInstancesResponse struct {
+	DBInstances []RDSDBInstance `xml:"DescribeDBInstancesResult>DBInstances>DBInstance"`
+}
+
+type rdsDescribeDBClustersResponse struct {
+	DBClusters []RDSDBCluster `xml:"DescribeDBClustersResult>DBClusters>DBCluster"`
+}
+
+// DescribeRDSDBInstance describes a single RDS DB instance
+func DescribeRDSDBInstance(region string, instanceIdentifier string) (*RDSDBInstance, error) {
+	params := url.Values{}
+	params.Set("Action", "DescribeDBInstances")
+	params.Set("Version", rdsAPIVersion)
+	params.Set("DBInstanceIdentifier", instanceIdentifier)
+	response := rdsDescribeDBInstancesResponse{}
+	if err := AWSQuery(region, "rds", params, &response); err != nil {
+		return nil, err
+	}
+	if len(response.DBInstances) != 1 {
+		return nil, fmt.Errorf("DescribeDBInstances %s: expected a single DB instance, got %d", instanceIdentifier, len(response.DBInstances))
+	}
+	return &response.DBInstances[0], nil
+}
+
+// DescribeRDSDBCluster describes a single Aurora DB cluster
+func DescribeRDSDBCluster(region string, clusterIdentifier string) (*RDSDBCluster, error) {
+	params := url.Values{}
+	params.Set("Action", "DescribeDBClusters")
+	params.Set("Version", rdsAPIVersion)
+	params.Set("DBClusterIdentifier", clusterIdentifier)
+	response := rdsDescribeDBClustersResponse{}
+	if err := AWSQuery(region, "rds", params, &response); err != nil {
+		return nil, err
+	}
+	if len(response.DBClusters) != 1 {
+		return nil, fmt.Errorf("DescribeDBClusters %s: expected a single DB cluster, got %d", clusterIdentifier, len(response.DBClusters))
+	}
+	return &response.DBClusters[0], nil
+}
+
+// FailoverRDSDBCluster forces a failover of an Aurora DB cluster. When targetInstanceIdentifier is non-empty,
+// Aurora promotes that reader; otherwise Aurora picks the reader to promote.
+func FailoverRDSDBCluster(region string, clusterIdentifier string, targetInstanceIdentifier string) error {
+	params := url.Values{}
+	params.Set("Action", "FailoverDBCluster")
+	params.Set("Version", rdsAPIVersion)
+	params.Set("DBClusterIdentifier", clusterIdentifier)
+	if targetInstanceIdentifier != "" {
+		params.Set("TargetDBInstanceIdentifier", targetInstanceIdentifier)
+	}
+	response := struct{}{}
+	return AWSQuery(region, "rds", params, &response)
+}
+
+// PromoteRDSReadReplica promotes an RDS read replica into a standalone, writable DB instance
+func PromoteRDSReadReplica(region string, instanceIdentifier string) error {
+	params := url.Values{}
+	params.Set("Action", "PromoteReadReplica")
+	params.Set("Version", rdsAPIVersion)
+	params.Set("DBInstanceIdentifier", instanceIdentifier)
+	response := struct{}{}
+	return AWSQuery(region, "rds", params, &response)
+}
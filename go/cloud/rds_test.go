@@ -0,0 +1,108 @@
+package cloud
+
+import (
+	"encoding/xml"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseRDSEndpoint(t *testing.T) {
+	{
+		instanceIdentifier, region, ok := ParseRDSEndpoint("mydb.abcdefghijkl.us-east-1.rds.amazonaws.com")
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(instanceIdentifier, "mydb")
+		test.S(t).ExpectEquals(region, "us-east-1")
+	}
+	{
+		instanceIdentifier, region, ok := ParseRDSEndpoint("MyDB-2.abcdefghijkl.eu-west-1.rds.amazonaws.com.")
+		test.S(t).ExpectTrue(ok)
+		test.S(t).ExpectEquals(instanceIdentifier, "mydb-2")
+		test.S(t).ExpectEquals(region, "eu-west-1")
+	}
+	{
+		_, _, ok := ParseRDSEndpoint("mydb.cluster-abcdefghijkl.us-east-1.rds.amazonaws.com.example.com")
+		test.S(t).ExpectFalse(ok)
+	}
+	{
+		_, _, ok := ParseRDSEndpoint("mydb.cluster-ro-abcdefghijkl.us-east-1.rds.amazonaws.com")
+		test.S(t).ExpectFalse(ok)
+	}
+	{
+		_, _, ok := ParseRDSEndpoint("db-1.example.com")
+		test.S(t).ExpectFalse(ok)
+	}
+}
+
+func TestRDSDBClusterWriter(t *testing.T) {
+	dbCluster := &RDSDBCluster{
+		DBClusterMembers: []RDSDBClusterMember{
+			{DBInstanceIdentifier: "reader-1"},
+			{DBInstanceIdentifier: "writer", IsClusterWriter: true},
+		},
+	}
+	test.S(t).ExpectEquals(dbCluster.Writer(), "writer")
+	dbCluster.DBClusterMembers[1].IsClusterWriter = false
+	test.S(t).ExpectEquals(dbCluster.Writer(), "")
+}
+
+func TestParseRDSDescribeDBInstancesResponse(t *testing.T) {
+	body := `<DescribeDBInstancesResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+	<DescribeDBInstancesResult>
+		<DBInstances>
+			<DBInstance>
+				<DBInstanceIdentifier>mydb-replica</DBInstanceIdentifier>
+				<DBInstanceStatus>available</DBInstanceStatus>
+				<Engine>mysql</Engine>
+				<ReadReplicaSourceDBInstanceIdentifier>mydb</ReadReplicaSourceDBInstanceIdentifier>
+				<ReadReplicaDBInstanceIdentifiers>
+					<ReadReplicaDBInstanceIdentifier>mydb-replica-2</ReadReplicaDBInstanceIdentifier>
+				</ReadReplicaDBInstanceIdentifiers>
+				<Endpoint>
+					<Address>mydb-replica.abcdefghijkl.us-east-1.rds.amazonaws.com</Address>
+					<Port>3306</Port>
+				</Endpoint>
+			</DBInstance>
+		</DBInstances>
+	</DescribeDBInstancesResult>
+</DescribeDBInstancesResponse>`
+	response := rdsDescribeDBInstancesResponse{}
+	test.S(t).ExpectNil(xml.Unmarshal([]byte(body), &response))
+	test.S(t).ExpectEquals(len(response.DBInstances), 1)
+	dbInstance := response.DBInstances[0]
+	test.S(t).ExpectEquals(dbInstance.DBInstanceIdentifier, "mydb-replica")
+	test.S(t).ExpectTrue(dbInstance.IsAvailable())
+	test.S(t).ExpectEquals(dbInstance.ReadReplicaSourceDBInstanceIdentifier, "mydb")
+	test.S(t).ExpectEquals(len(dbInstance.ReadReplicaDBInstanceIdentifiers), 1)
+	test.S(t).ExpectEquals(dbInstance.Endpoint.Address, "mydb-replica.abcdefghijkl.us-east-1.rds.amazonaws.com")
+	test.S(t).ExpectEquals(dbInstance.Endpoint.Port, 3306)
+}
+
+func TestParseRDSDescribeDBClustersResponse(t *testing.T) {
+	body := `<DescribeDBClustersResponse xmlns="http://rds.amazonaws.com/doc/2014-10-31/">
+	<DescribeDBClustersResult>
+		<DBClusters>
+			<DBCluster>
+				<DBClusterIdentifier>aurora-1</DBClusterIdentifier>
+				<Status>available</Status>
+				<DBClusterMembers>
+					<DBClusterMember>
+						<DBInstanceIdentifier>aurora-1-a</DBInstanceIdentifier>
+						<IsClusterWriter>false</IsClusterWriter>
+					</DBClusterMember>
+					<DBClusterMember>
+						<DBInstanceIdentifier>aurora-1-b</DBInstanceIdentifier>
+						<IsClusterWriter>true</IsClusterWriter>
+					</DBClusterMember>
+				</DBClusterMembers>
+			</DBCluster>
+		</DBClusters>
+	</DescribeDBClustersResult>
+</DescribeDBClustersResponse>`
+	response := rdsDescribeDBClustersResponse{}
+	test.S(t).ExpectNil(xml.Unmarshal([]byte(body), &response))
+	test.S(t).ExpectEquals(len(response.DBClusters), 1)
+	test.S(t).ExpectEquals(response.DBClusters[0].DBClusterIdentifier, "aurora-1")
+	test.S(t).ExpectEquals(len(response.DBClusters[0].DBClusterMembers), 2)
+	test.S(t).ExpectEquals(response.DBClusters[0].Writer(), "aurora-1-b")
+}
@@ -62,6 +62,12 @@ func main() {
 	config.RuntimeCLIFlags.EnableDatabaseUpdate = flag.Bool("enable-database-update", false, "Enable database update, overrides SkipOrchestratorDatabaseUpdate")
 	config.RuntimeCLIFlags.IgnoreRaftSetup = flag.Bool("ignore-raft-setup", false, "Override RaftEnabled for CLI invocation (CLI by default not allowed for raft setups). NOTE: operations by CLI invocation may not reflect in all raft nodes.")
 	config.RuntimeCLIFlags.Tag = flag.String("tag", "", "tag to add ('tagname' or 'tagname=tagvalue') or to search ('tagname' or 'tagname=tagvalue' or comma separated 'tag0,tag1=val1,tag2' for intersection of all)")
+	config.RuntimeCLIFlags.ScheduleAt = flag.String("at", "", "schedule time, 'YYYY-MM-DD hh:mm:ss' in orchestrator backend time (applies for scheduled takeover and topology-at)")
+	config.RuntimeCLIFlags.RecoveryUID = flag.String("uid", "", "recovery UID (applies for recovery-report)")
+	config.RuntimeCLIFlags.Profile = flag.String("profile", "", "Comma separated configuration profiles to overlay, overriding ActiveProfiles")
+	api := flag.String("api", "", "orchestrator HTTP API endpoint(s), space delimited, e.g. http://orchestrator.service:3000/api. When given, the command executes over the remote API, with no configuration nor backend database required")
+	apiAuth := flag.String("api-auth", "", "user:password for HTTP basic authentication with --api")
+	apiToken := flag.String("api-token", "", "bearer token for authentication with --api")
 	flag.Parse()
 
 	if *destination != "" && *sibling != "" {
@@ -97,6 +103,12 @@ func main() {
 		return
 	}
 
+	if *api != "" {
+		// Remote mode: no configuration nor backend access; everything goes through the API
+		app.RemoteCli(*api, *apiAuth, *apiToken, *command, *instance, *destination, *owner, *reason, *duration, *clusterAlias)
+		return
+	}
+
 	startText := "starting orchestrator"
 	if AppVersion != "" {
 		startText += ", version: " + AppVersion
@@ -106,6 +118,8 @@ func main() {
 	}
 	log.Info(startText)
 
+	// config-check reports configuration errors, rather than bail out on them
+	config.RuntimeCLIFlags.ConfigCheck = (*command == "config-check")
 	if len(*configFile) > 0 {
 		config.ForceRead(*configFile)
 	} else {
@@ -156,12 +170,15 @@ func main() {
 	case helpTopic != "":
 		app.HelpCommand(helpTopic)
 	case len(flag.Args()) == 0 || flag.Arg(0) == "cli":
+		config.RuntimeCLIFlags.CLIMode = true
 		app.CliWrapper(*command, *strict, *instance, *destination, *owner, *reason, *duration, *pattern, *clusterAlias, *pool, *hostnameFlag)
 	case flag.Arg(0) == "http":
 		app.Http(*discovery)
+	case flag.Arg(0) == "tui":
+		app.TUI()
 	default:
 		fmt.Fprintln(os.Stderr, `Usage:
-  orchestrator --options... [cli|http]
+  orchestrator --options... [cli|http|tui]
 See complete list of commands:
   orchestrator -c help
 Full blown documentation:
@@ -0,0 +1,47 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// AnalysisHookSet routes analyses that have no recovery of their own (e.g. UnreachableMaster, or custom
+// analyses) to hooks of their own, such as a diagnostics script.
+type AnalysisHookSet struct {
+	Analysis       []string // analysis codes the hooks run on
+	ClusterFilters []string // clusters the hook set applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Processes      []string // processes to execute. Same placeholders as OnFailureDetectionProcesses
+}
+
+// AppliesToAnalysis checks whether the hook set covers given analysis code
+func (this *AnalysisHookSet) AppliesToAnalysis(analysis string) bool {
+	return len(this.Analysis) > 0 && analysisListed(this.Analysis, analysis)
+}
+
+// validateAnalysisHookSets checks hook sets name both analyses and processes
+func (this *Configuration) validateAnalysisHookSets() error {
+	for i, hookSet := range this.AnalysisHookSets {
+		if len(hookSet.Analysis) == 0 {
+			return fmt.Errorf("AnalysisHookSets: hook set #%d has no Analysis", i)
+		}
+		if len(hookSet.Processes) == 0 {
+			return fmt.Errorf("AnalysisHookSets: hook set #%d has no Processes", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// CustomAnalysisCodePrefix starts the codes of operator defined analyses, keeping them apart from built-in codes
+const CustomAnalysisCodePrefix = "Custom"
+
+var customAnalysisCodeRegexp = regexp.MustCompile("^" + CustomAnalysisCodePrefix + "[A-Za-z0-9]+$")
+
+// AnalysisRuleFields are the replication analysis fields analysis rule conditions may test. Booleans test as 0 or 1.
+var AnalysisRuleFields = map[string]bool{
+	"IsMaster":                      true,
+	"IsCoMaster":                    true,
+	"IsReadOnly":                    true,
+	"IsBinlogServer":                true,
+	"IsDowntimed":                   true,
+	"IsFailingToConnectToMaster":    true,
+	"CountReplicas":                 true,
+	"CountValidReplicas":            true,
+	"CountValidReplicatingReplicas": true,
+	"CountLaggingReplicas":          true,
+	"CountDelayedReplicas":          true,
+	"CountDowntimedReplicas":        true,
+	"ReplicationDepth":              true,
+	"ReplicationLagSeconds":         true,
+	"SecondsSinceLastChecked":       true,
+}
+
+// AnalysisRuleCondition compares a replication analysis field with a value
+type AnalysisRuleCondition struct {
+	Field    string  // any of AnalysisRuleFields
+	Operator string  // any of "=", "!=", "<", "<=", ">", ">="
+	Value    float64 // booleans compare as 0 or 1
+}
+
+// AnalysisRule is an operator defined analysis. An instance with no built-in problem, satisfying all of a rule's
+// conditions, is analyzed with the rule's code. Such codes run OnFailureDetectionProcesses and are subject to
+// notification policies, but never run an automated recovery.
+type AnalysisRule struct {
+	Code           string                  // analysis code, starting with "Custom", e.g. "CustomMasterWithFewReplicas"
+	Description    string                  // description of the analysis
+	ClusterFilters []string                // clusters the rule applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Conditions     []AnalysisRuleCondition // all must hold
+}
+
+// Validate checks the rule is well defined
+func (this *AnalysisRule) Validate() error {
+	if !customAnalysisCodeRegexp.MatchString(this.Code) {
+		return fmt.Errorf("Invalid analysis rule code %q: expecting alphanumeric code starting with %q", this.Code, CustomAnalysisCodePrefix)
+	}
+	if len(this.Conditions) == 0 {
+		return fmt.Errorf("Analysis rule %s has no conditions", this.Code)
+	}
+	for i, condition := range this.Conditions {
+		if !AnalysisRuleFields[condition.Field] {
+			return fmt.Errorf("Analysis rule %s: condition #%d has unknown field %q", this.Code, i, condition.Field)
+		}
+		switch condition.Operator {
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			return fmt.Errorf("Analysis rule %s: condition #%d has unknown operator %q", this.Code, i, condition.Operator)
+		}
+	}
+	return nil
+}
+
+// validateAnalysisRules checks rules are well defined and their codes unique
+func (this *Configuration) validateAnalysisRules() error {
+	codes := map[string]bool{}
+	for i := range this.AnalysisRules {
+		rule := &this.AnalysisRules[i]
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("AnalysisRules: %+v", err)
+		}
+		if codes[rule.Code] {
+			return fmt.Errorf("AnalysisRules: duplicate code %s", rule.Code)
+		}
+		codes[rule.Code] = true
+	}
+	return nil
+}
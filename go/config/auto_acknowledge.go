@@ -0,0 +1,31 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+// AutoAckPolicy acknowledges completed recoveries on matching clusters once they are old enough,
+// so that routine recoveries, e.g. of intermediate masters, need no manual acknowledgement.
+type AutoAckPolicy struct {
+	ClusterFilters []string // clusters the policy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Analysis       []string // analysis codes the policy applies to, e.g. "DeadIntermediateMaster". Empty applies to all
+	SuccessfulOnly bool     // when true, failed recoveries are left for a human to acknowledge
+	AfterMinutes   uint     // time since the recovery ended, after which it is acknowledged
+}
+
+// AppliesToAnalysis checks whether the policy covers recoveries of given analysis code
+func (this *AutoAckPolicy) AppliesToAnalysis(analysis string) bool {
+	return analysisListed(this.Analysis, analysis)
+}
@@ -32,6 +32,11 @@ type CLIFlags struct {
 	EnableDatabaseUpdate       *bool
 	IgnoreRaftSetup            *bool
 	Tag                        *string
+	ScheduleAt                 *string
+	RecoveryUID                *string
+	ConfigCheck                bool
+	CLIMode                    bool
+	Profile                    *string
 }
 
 var RuntimeCLIFlags CLIFlags
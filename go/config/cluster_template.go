@@ -0,0 +1,87 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ClusterTemplate is a named bundle of per-cluster settings. A template is assigned to a newly
+// discovered cluster whose master matches MasterPattern, and from then on applies to that cluster
+// in addition to the global configuration.
+type ClusterTemplate struct {
+	Name                                 string
+	MasterPattern                        string            // regexp matched against the master's hostname:port
+	AutoMasterRecovery                   bool              // when true, the cluster is auto-recovered on master failure, as if listed in RecoverMasterClusterFilters
+	AutoIntermediateMasterRecovery       bool              // when true, the cluster is auto-recovered on intermediate master failure, as if listed in RecoverIntermediateMasterClusterFilters
+	PreventCrossDataCenterMasterFailover bool              // same as the global PreventCrossDataCenterMasterFailover, for this cluster
+	PreventCrossRegionMasterFailover     bool              // same as the global PreventCrossRegionMasterFailover, for this cluster
+	KVClusterMasterPrefix                string            // overrides the global KVClusterMasterPrefix for this cluster
+	PreFailoverProcesses                 []string          // executed after the global PreFailoverProcesses
+	PostFailoverProcesses                []string          // executed after the global PostFailoverProcesses
+	Owner                                string            // free text: team or person owning the cluster
+	Tags                                 map[string]string // tags applied to the cluster's master upon template assignment
+}
+
+// MatchesMaster checks whether the template applies to a cluster with the given master
+func (this *ClusterTemplate) MatchesMaster(masterKey string) bool {
+	if this.MasterPattern == "" {
+		return false
+	}
+	matched, _ := regexp.MatchString(this.MasterPattern, masterKey)
+	return matched
+}
+
+// GetClusterTemplate returns the template of the given name, or nil if no such template is configured
+func (this *Configuration) GetClusterTemplate(name string) *ClusterTemplate {
+	if name == "" {
+		return nil
+	}
+	for i := range this.ClusterTemplates {
+		if this.ClusterTemplates[i].Name == name {
+			return &this.ClusterTemplates[i]
+		}
+	}
+	return nil
+}
+
+// validateClusterTemplates checks templates are named uniquely and have valid patterns
+func (this *Configuration) validateClusterTemplates() error {
+	names := map[string]bool{}
+	for i := range this.ClusterTemplates {
+		template := &this.ClusterTemplates[i]
+		if template.Name == "" {
+			return fmt.Errorf("ClusterTemplates: template #%d has no Name", i)
+		}
+		if names[template.Name] {
+			return fmt.Errorf("ClusterTemplates: duplicate template name %s", template.Name)
+		}
+		names[template.Name] = true
+		if template.MasterPattern == "" {
+			return fmt.Errorf("ClusterTemplates: template %s has no MasterPattern", template.Name)
+		}
+		if _, err := regexp.Compile(template.MasterPattern); err != nil {
+			return fmt.Errorf("ClusterTemplates: template %s has invalid MasterPattern: %+v", template.Name, err)
+		}
+		if template.KVClusterMasterPrefix != "" && template.KVClusterMasterPrefix != "/" {
+			template.KVClusterMasterPrefix = fmt.Sprintf("%s/", strings.TrimRight(template.KVClusterMasterPrefix, "/"))
+		}
+	}
+	return nil
+}
@@ -37,6 +37,38 @@ const (
 	LostInRecoveryDowntimeSeconds int = 60 * 60 * 24 * 365
 )
 
+// Address families connections to MySQL try first, see ConnectIPPreference
+const (
+	IPPreferenceIPv4 = "ipv4"
+	IPPreferenceIPv6 = "ipv6"
+)
+
+// Methods by which rebuild-replica copies data
+const (
+	ReplicaRebuildMethodAgentSeed = "agent-seed"
+	ReplicaRebuildMethodCommand   = "command"
+	ReplicaRebuildMethodClone     = "clone"
+)
+
+// Handling of a GTID move below an instance which has purged transactions the moved replica has yet to apply
+const (
+	GTIDPurgedCheckRefuse = "refuse"
+	GTIDPurgedCheckWarn   = "warn"
+)
+
+// Action taken when a promotion delayed by DelayMasterPromotionIfSQLThreadNotUpToDate exceeds its max wait
+const (
+	DelayMasterPromotionTimeoutFail          = "fail"
+	DelayMasterPromotionTimeoutNextCandidate = "next-candidate"
+	DelayMasterPromotionTimeoutProceed       = "proceed"
+)
+
+// Handling of masters found failing BinlogRetentionSeconds or BinlogMinDiskHeadroomMB
+const (
+	BinlogWatchdogMonitor = "monitor"
+	BinlogWatchdogEnforce = "enforce"
+)
+
 var configurationLoaded chan bool = make(chan bool)
 
 const (
@@ -80,6 +112,7 @@ var deprecatedConfigurationVariables = []string{
 	"PseudoGTIDCoordinatesHistoryHeuristicMinutes",
 	"PseudoGTIDPreferIndependentMultiMatch",
 	"MaxOutdatedKeysToShow",
+	"StatusSimpleHealth",
 }
 
 // Configuration makes for orchestrator configuration input, which can be provided by user via JSON formatted file.
@@ -90,6 +123,8 @@ type Configuration struct {
 	EnableSyslog                               bool   // Should logs be directed (in addition) to syslog daemon?
 	ListenAddress                              string // Where orchestrator HTTP should listen for TCP
 	ListenSocket                               string // Where orchestrator HTTP should listen for unix socket (default: empty; when given, TCP is disabled)
+	GRPCListenAddress                          string // Where orchestrator gRPC API should listen for TCP (default: empty; gRPC API disabled)
+	GRPCWatchPollSeconds                       uint   // Interval at which gRPC watch streams poll for analysis and recovery changes
 	HTTPAdvertise                              string // optional, for raft setups, what is the HTTP address this node will advertise to its peers (potentially use where behind NAT or when rerouting ports; example: "http://11.22.33.44:3030")
 	AgentsServerPort                           string // port orchestrator agents talk back to
 	MySQLTopologyUser                          string
@@ -102,8 +137,9 @@ type Configuration struct {
 	MySQLTopologyUseMutualTLS                  bool   // Turn on TLS authentication with the Topology MySQL instances
 	MySQLTopologyUseMixedTLS                   bool   // Mixed TLS and non-TLS authentication with the Topology MySQL instances
 	TLSCacheTTLFactor                          uint   // Factor of InstancePollSeconds that we set as TLS info cache expiry
-	BackendDB                                  string // EXPERIMENTAL: type of backend db; either "mysql" or "sqlite3"
+	BackendDB                                  string // EXPERIMENTAL: type of backend db; either "mysql", "sqlite3" or "postgres"
 	SQLite3DataFile                            string // when BackendDB == "sqlite3", full path to sqlite3 datafile
+	SQLite3ReadPoolConnections                 int    // when BackendDB == "sqlite3", number of connections serving reads, concurrently with the single writing connection. 0 to read via the writing connection
 	SkipOrchestratorDatabaseUpdate             bool   // When true, do not check backend database schema nor attempt to update it. Useful when you may be running multiple versions of orchestrator, and you only wish certain boxes to dictate the db structure (or else any time a different orchestrator version runs it will rebuild database schema)
 	PanicIfDifferentDatabaseDeploy             bool   // When true, and this process finds the orchestrator backend DB was provisioned by a different version, panic
 	RaftEnabled                                bool   // When true, setup orchestrator in a raft consensus layout. When false (default) all Raft* variables are ignored
@@ -112,6 +148,8 @@ type Configuration struct {
 	RaftDataDir                                string
 	DefaultRaftPort                            int      // if a RaftNodes entry does not specify port, use this one
 	RaftNodes                                  []string // Raft nodes to make initial connection with
+	RaftReadConsistency                        string   // Consistency of API reads on raft followers, unless requested otherwise: "leader" (default; forwarded to the leader), "lease" (served locally while the leader is in recent contact) or "stale" (served locally)
+	RaftLeaseReadSeconds                       uint     // With "lease" read consistency, a follower serves reads locally if it heard from the leader within this many seconds
 	ExpectFailureAnalysisConcensus             bool
 	MySQLOrchestratorHost                      string
 	MySQLOrchestratorMaxPoolConnections        int // The maximum size of the connection pool to the Orchestrator backend.
@@ -126,55 +164,101 @@ type Configuration struct {
 	MySQLOrchestratorSSLSkipVerify             bool     // If true, do not strictly validate mutual TLS certs for the Orchestrator mysql instances
 	MySQLOrchestratorUseMutualTLS              bool     // Turn on TLS authentication with the Orchestrator MySQL instance
 	MySQLConnectTimeoutSeconds                 int      // Number of seconds before connection is aborted (driver-side)
+	ConnectIPPreference                        string   // Address family to try first when connecting to MySQL hosts with both IPv4 and IPv6 addresses: "ipv4" or "ipv6". Empty (default) leaves the order to the system resolver
 	MySQLOrchestratorReadTimeoutSeconds        int      // Number of seconds before backend mysql read operation is aborted (driver-side)
 	MySQLDiscoveryReadTimeoutSeconds           int      // Number of seconds before topology mysql read operation is aborted (driver-side). Used for discovery queries.
 	MySQLTopologyReadTimeoutSeconds            int      // Number of seconds before topology mysql read operation is aborted (driver-side). Used for all but discovery queries.
+	PostgreSQLOrchestratorHost                 string   // when BackendDB == "postgres", hostname of the PostgreSQL backend
+	PostgreSQLOrchestratorPort                 uint     // when BackendDB == "postgres", port of the PostgreSQL backend
+	PostgreSQLOrchestratorDatabase             string   // when BackendDB == "postgres", name of the backend database
+	PostgreSQLOrchestratorUser                 string   // when BackendDB == "postgres", user connecting to the backend database
+	PostgreSQLOrchestratorPassword             string   // when BackendDB == "postgres", password of PostgreSQLOrchestratorUser
+	PostgreSQLOrchestratorSSLMode              string   // when BackendDB == "postgres", one of "disable", "require", "verify-ca", "verify-full"
 	MySQLConnectionLifetimeSeconds             int      // Number of seconds the mysql driver will keep database connection alive before recycling it
+	MySQLOrchestratorMaxIdleConnections        int      // The maximum number of idle connections kept in the pool to the Orchestrator backend. 0 means 25% of MySQLOrchestratorMaxPoolConnections, and at least 10
+	MySQLOrchestratorConnectionLifetimeSeconds int      // Number of seconds a connection to the Orchestrator backend is kept alive before recycling it. 0 means using MySQLConnectionLifetimeSeconds
+	MySQLOrchestratorRecoveryPoolConnections   int      // Size of a connection pool to the Orchestrator backend reserved for recovery-critical writes (registration, steps, resolution). 0 (default) disables. Not applicable to SQLite
 	DefaultInstancePort                        int      // In case port was not specified on command line
 	SlaveLagQuery                              string   // Synonym to ReplicationLagQuery
 	ReplicationLagQuery                        string   // custom query to check on replica lg (e.g. heartbeat table). Must return a single row with a single numeric column, which is the lag.
+	HeartbeatIntervalMilliseconds              uint     // When non-zero, orchestrator writes a heartbeat into writeable masters at this interval, and reads it on replicas for sub-second lag measurement. 0 disables
+	HeartbeatTable                             string   // Schema qualified table orchestrator writes heartbeats into. The schema and table are created if missing
 	ReplicationCredentialsQuery                string   // custom query to get replication credentials. Must return a single row, with two text columns: 1st is username, 2nd is password. This is optional, and can be used by orchestrator to configure replication after master takeover or setup of co-masters. You need to ensure the orchestrator user has the privileges to run this query
 	DiscoverByShowSlaveHosts                   bool     // Attempt SHOW SLAVE HOSTS before PROCESSLIST
 	UseSuperReadOnly                           bool     // Should orchestrator super_read_only any time it sets read_only
 	InstancePollSeconds                        uint     // Number of seconds between instance reads
+	AdaptiveInstancePollMaxSeconds             uint     // When greater than InstancePollSeconds, enables adaptive polling: stable instances are polled gradually less often, up to once per this number of seconds. 0 disables
+	AdaptiveInstancePollMinSeconds             uint     // With adaptive polling, instances with recent state changes (replication errors, lag spikes, recent recoveries) are polled once per this number of seconds
 	InstanceWriteBufferSize                    int      // Instance write buffer size (max number of instances to flush in one INSERT ODKU)
 	BufferInstanceWrites                       bool     // Set to 'true' for write-optimization on backend table (compromise: writes can be stale and overwrite non stale data)
 	InstanceFlushIntervalMilliseconds          int      // Max interval between instance write buffer flushes
 	SkipMaxScaleCheck                          bool     // If you don't ever have MaxScale BinlogServer in your topology (and most people don't), set this to 'true' to save some pointless queries
+	DetectRippleBinlogServers                  bool     // When true, discovery checks whether an instance is a Ripple binlog server (via @@version_comment). Costs an extra query per instance probe
 	UnseenInstanceForgetHours                  uint     // Number of hours after which an unseen instance is forgotten
 	SnapshotTopologiesIntervalHours            uint     // Interval in hour between snapshot-topologies invocation. Default: 0 (disabled)
+	ClusterTopologySnapshotIntervalMinutes     uint     // Interval in minutes between persisted snapshots of each cluster's topology, for time-travel queries. Default: 0 (disabled)
 	DiscoveryMaxConcurrency                    uint     // Number of goroutines doing hosts discovery
 	DiscoveryQueueCapacity                     uint     // Buffer size of the discovery queue. Should be greater than the number of DB instances being discovered
 	DiscoveryQueueMaxStatisticsSize            int      // The maximum number of individual secondly statistics taken of the discovery queue
 	DiscoveryCollectionRetentionSeconds        uint     // Number of seconds to retain the discovery collection information
 	InstanceBulkOperationsWaitTimeoutSeconds   uint     // Time to wait on a single instance when doing bulk (many instances) operation
+	CompletionCacheSeconds                     uint     // Time for which shell completion data (instances, clusters) is cached on the client side. 0 to always read the backend
 	HostnameResolveMethod                      string   // Method by which to "normalize" hostname ("none"/"default"/"cname")
 	MySQLHostnameResolveMethod                 string   // Method by which to "normalize" hostname via MySQL server. ("none"/"@@hostname"/"@@report_host"; default "@@hostname")
 	SkipBinlogServerUnresolveCheck             bool     // Skip the double-check that an unresolved hostname resolves back to same hostname for binlog servers
 	ExpiryHostnameResolvesMinutes              int      // Number of minutes after which to expire hostname-resolves
 	RejectHostnameResolvePattern               string   // Regexp pattern for resolved hostname that will not be accepted (not cached, not written to db). This is done to avoid storing wrong resolves due to network glitches.
 	ReasonableReplicationLagSeconds            int      // Above this value is considered a problem
+	ReasonableReplicaHeartbeatSeconds          int      // A replica that received a heartbeat from its master within this many seconds observes the master as alive (MySQL 5.7 and above)
+	ReasonableLockedSemiSyncMasterSeconds      uint     // A semi-sync master short of semi-sync replicas, whose rpl_semi_sync_master_timeout exceeds this many seconds, is considered locked
 	ProblemIgnoreHostnameFilters               []string // Will minimize problem visualization for hostnames matching given regexp filters
 	VerifyReplicationFilters                   bool     // Include replication filters check before approving topology refactoring
 	ReasonableMaintenanceReplicationLagSeconds int      // Above this value move-up and move-below are blocked
+	ReplicaLagSLOSeconds                       uint     // When > 0, replicas' lag is sampled once a minute into the backend, and a replica complies with the lag SLO while its lag is below this value
+	ReplicaLagSLOTargetPercent                 float64  // Percent of samples in which replicas must comply for a cluster or replica to meet the lag SLO
+	ReplicaLagSLOWindowHours                   uint     // Window of lag SLO reports and metrics, unless otherwise requested
+	ReplicaLagHistoryRetentionDays             uint     // Days to keep replica lag samples
 	CandidateInstanceExpireMinutes             uint     // Minutes after which a suggestion to use an instance as a candidate replica (to be preferably promoted on master failover) is expired.
 	AuditLogFile                               string   // Name of log file for audit operations. Disabled when empty.
 	AuditToSyslog                              bool     // If true, audit messages are written to syslog
 	AuditToBackendDB                           bool     // If true, audit messages are written to the backend DB's `audit` table (default: true)
+	AuditRetentionDays                         uint     // Days to keep rows of the `audit` table
+	RecoveryRetentionDays                      uint     // Days to keep recoveries, their steps, and failure detections
+	AnalysisChangelogRetentionHours            uint     // Hours to keep replication analysis changes. Default: 0, meaning UnseenInstanceForgetHours
+	RetentionArchiveDirectory                  string   // When non-empty, rows past retention are archived as gzipped JSON files in this directory before being purged
+	RetentionArchiveUploadCommand              string   // Optional command uploading an archive file, e.g. to an S3 bucket. Rows are only purged once it succeeds. Placeholders: {archiveFile}, {tableName}
+	RetentionArchiveIntervalMinutes            uint     // Interval between archival runs, when RetentionArchiveDirectory is set
+	FleetReportIntervalMinutes                 uint     // When > 0, the leader generates a fleet capacity & topology report every this number of minutes. Reports may also be generated via API
+	FleetReportRetentionDays                   uint     // Number of days fleet reports are kept for, to track fleet posture trends
 	RemoveTextFromHostnameDisplay              string   // Text to strip off the hostname on cluster/clusters pages
 	ReadOnly                                   bool
-	AuthenticationMethod                       string // Type of autherntication to use, if any. "" for none, "basic" for BasicAuth, "multi" for advanced BasicAuth, "proxy" for forwarded credentials via reverse proxy, "token" for token based access
+	AuthenticationMethod                       string // Type of autherntication to use, if any. "" for none, "basic" for BasicAuth, "multi" for advanced BasicAuth, "proxy" for forwarded credentials via reverse proxy, "token" for token based access, "oidc" for OpenID Connect, "mtls" for client certificates
 	OAuthClientId                              string
 	OAuthClientSecret                          string
 	OAuthScopes                                []string
+	OIDCIssuerURL                              string            // OpenID Connect provider issuer, when AuthenticationMethod is "oidc". Provider endpoints are discovered via <issuer>/.well-known/openid-configuration
+	OIDCRedirectURL                            string            // Externally visible URL of orchestrator's OIDC callback, e.g. https://orchestrator.example.com/oidc/callback
+	OIDCUserClaim                              string            // ID token claim identifying the user (default: "email"). Matched against PowerAuthUsers
+	OIDCGroupsClaim                            string            // ID token claim listing the user's groups (default: "groups"). Matched against PowerAuthGroups
 	HTTPAuthUser                               string            // Username for HTTP Basic authentication (blank disables authentication)
 	HTTPAuthPassword                           string            // Password for HTTP Basic authentication
 	AuthUserHeader                             string            // HTTP header indicating auth user, when AuthenticationMethod is "proxy"
 	PowerAuthUsers                             []string          // On AuthenticationMethod == "proxy", list of users that can make changes. All others are read-only.
 	PowerAuthGroups                            []string          // list of unix groups the authenticated user must be a member of to make changes.
+	SlackSigningSecret                         string            // Signing secret of the Slack app whose slash command posts to /api/chatops/slack. Empty (default) disables chatops
+	SlackPowerUsers                            []string          // Slack user ids or user names allowed to run changing chatops commands: ack, downtime, takeover. "*" allows all. All others are read-only
 	AccessTokenUseExpirySeconds                uint              // Time by which an issued token must be used
+	APIRateLimitRequestsPerSecond              float64           // When > 0, limits the rate of web/API requests per client (authenticated user, or else client IP). Excess requests get HTTP 429
+	APIRateLimitBurst                          int               // Number of requests a client may issue in a burst, beyond APIRateLimitRequestsPerSecond
+	APIShedLowPriorityDuringRecovery           bool              // When true, low priority API requests (see APILowPriorityPaths) get HTTP 429 while a master recovery is in progress
+	APILowPriorityPaths                        []string          // API paths, e.g. "/api/audit", considered low priority. A path covers its sub-paths
+	AuditAPIRequests                           bool              // When true, API requests requiring write privileges are audited: user, client, path and resulting HTTP status
+	APIVersionCompatibilityMode                bool              // When true, API responses in version 2 carry renamed fields (e.g. ReplicaHosts) along with their version 1 names (e.g. SlaveHosts)
 	AccessTokenExpiryMinutes                   uint              // Time after which HTTP access token expires
 	ClusterNameToAlias                         map[string]string // map between regex matching cluster name to a human friendly alias
+	AnalysisSeverities                         map[string]string // map between analysis (or structure analysis) code to severity: "critical", "warning" or "info". Overrides built-in severities
+	AnalysisRules                              []AnalysisRule    // Operator defined analyses, producing custom analysis codes. See AnalysisRule
+	ClusterTemplates                           []ClusterTemplate // Settings bundles applied to newly discovered clusters whose master matches the template's MasterPattern. See ClusterTemplate
 	DetectClusterAliasQuery                    string            // Optional query (executed on topology instance) that returns the alias of a cluster. Query will only be executed on cluster master (though until the topology's master is resovled it may execute on other/all replicas). If provided, must return one row, one column
 	DetectClusterDomainQuery                   string            // Optional query (executed on topology instance) that returns the VIP/CNAME/Alias/whatever domain name for the master of this cluster. Query will only be executed on cluster master (though until the topology's master is resovled it may execute on other/all replicas). If provided, must return one row, one column
 	DetectInstanceAliasQuery                   string            // Optional query (executed on topology instance) that returns the alias of an instance. If provided, must return one row, one column
@@ -204,6 +288,11 @@ type Configuration struct {
 	SSLCertFile                                string            // Name of SSL certification file, applies only when UseSSL = true
 	SSLCAFile                                  string            // Name of the Certificate Authority file, applies only when UseSSL = true
 	SSLValidOUs                                []string          // Valid organizational units when using mutual TLS
+	SSLValidSANs                               []string          // Valid client certificate subject alternative names (DNS names, emails, IPs, URIs) when using mutual TLS. Accepted in addition to SSLValidOUs
+	SSLRequireClientCert                       bool              // When "true" (and UseMutualTLS), the TLS handshake fails for clients not presenting a valid certificate
+	SSLPowerOUs                                []string          // On AuthenticationMethod == "mtls", client certificate OUs that can make changes. All others are read-only.
+	SSLPowerSANs                               []string          // On AuthenticationMethod == "mtls", client certificate SANs that can make changes. All others are read-only.
+	SSLRaftPeerSANs                            []string          // SANs of orchestrator nodes' own certificates. Requests these nodes forward to the raft leader are authorized by the original client's certificate
 	StatusEndpoint                             string            // Override the status endpoint.  Defaults to '/api/status'
 	StatusOUVerify                             bool              // If true, try to verify OUs when Mutual TLS is on.  Defaults to false
 	AgentPollMinutes                           uint              // Minutes between agent polling
@@ -218,14 +307,56 @@ type Configuration struct {
 	DetectPseudoGTIDQuery                      string            // Optional query which is used to authoritatively decide whether pseudo gtid is enabled on instance
 	BinlogEventsChunkSize                      int               // Chunk size (X) for SHOW BINLOG|RELAYLOG EVENTS LIMIT ?,X statements. Smaller means less locking and mroe work to be done
 	SkipBinlogEventsContaining                 []string          // When scanning/comparing binlogs for Pseudo-GTID, skip entries containing given texts. These are NOT regular expressions (would consume too much CPU while scanning binlogs), just substrings to find.
+	GTIDPurgedCheck                            string            // When moving a replica via GTID below an instance which has purged GTID entries the replica has yet to apply: "refuse" (default) the move, or "warn" and proceed
 	ReduceReplicationAnalysisCount             bool              // When true, replication analysis will only report instances where possibility of handled problems is possible in the first place (e.g. will not report most leaf nodes, that are mostly uninteresting). When false, provides an entry for every known instance
 	FailureDetectionPeriodBlockMinutes         int               // The time for which an instance's failure discovery is kept "active", so as to avoid concurrent "discoveries" of the instance's failure; this preceeds any recovery process, if any.
 	RecoveryPeriodBlockMinutes                 int               // (supported for backwards compatibility but please use newer `RecoveryPeriodBlockSeconds` instead) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
 	RecoveryPeriodBlockSeconds                 int               // (overrides `RecoveryPeriodBlockMinutes`) The time for which an instance's recovery is kept "active", so as to avoid concurrent recoveries on smae instance as well as flapping
+	MaxConcurrentRecoveries                    uint              // Max number of automated recoveries executing at once on this node. Excess recoveries are queued: masters first, then co-masters, then intermediate masters. 0 for unlimited
+	MaxConcurrentRecoveriesPerDataCenter       uint              // Max number of automated recoveries executing at once on failed servers of any single data center. 0 for unlimited
+	MasterFlapDetectionPeriodSeconds           uint              // When > 0, a cluster whose master has failed over MasterFlapThreshold times within this period is flapping: further automated master failovers are blocked until a human acknowledges the flapping
+	MasterFlapThreshold                        uint              // Number of master failovers within MasterFlapDetectionPeriodSeconds that make a cluster flapping
+	MasterHealthProbes                         []HealthProbe     // Auxiliary probes (TCP port, HTTP endpoint, orchestrator-agent) of a master orchestrator finds dead. A probe finding the master healthy turns a dead master analysis into an unreachable master. See HealthProbe
+	AutoAcknowledgePolicies                    []AutoAckPolicy   // Acknowledge completed recoveries on matching clusters once they are old enough. See AutoAckPolicy
+	MasterDeathConfirmationQuorum              uint              // When > 0, an automated master recovery requires this many vantage points (other than this node) to independently confirm they cannot reach the dead master. Guards against failing over when only this node's network path is broken. 0 disables
+	MasterDeathConfirmationRaftPeers           bool              // When true, raft-healthy peers are vantage points for MasterDeathConfirmationQuorum
+	MasterDeathConfirmationVantagePoints       []string          // API base URLs (e.g. "http://orchestrator-dc2:3000/api") of further vantage points for MasterDeathConfirmationQuorum, serving check-instance-reachability
+	MasterDeathConfirmationTimeoutSeconds      uint              // Time to wait for a vantage point's answer; no answer is no confirmation
+	DatacenterFailureMinDeadMasters            uint              // When at least this many masters of a single data center are dead at once, orchestrator declares a data center failure and recovers them in one coordinated bulk recovery. 0 disables. Must be at least 2 otherwise
+	DatacenterFailureRecoveryIntervalSeconds   uint              // Pause between consecutive recoveries of a bulk data center failure recovery
 	RecoveryIgnoreHostnameFilters              []string          // Recovery analysis will completely ignore hosts matching given patterns
 	RecoverMasterClusterFilters                []string          // Only do master recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
 	RecoverIntermediateMasterClusterFilters    []string          // Only do IM recovery on clusters matching these regexp patterns (of course the ".*" pattern matches everything)
+	ReplicationTLSRequiredClusterFilters       []string          // Clusters matching these patterns must replicate over TLS: relocated, recovered and demoted servers get MASTER_SSL=1, and replicas not using TLS are reported in analysis
+	ReplicationTLSVerifyServerCert             bool              // When requiring TLS replication, also require MASTER_SSL_VERIFY_SERVER_CERT=1
+	CanaryClusterFilters                       []string          // When non-empty, this deployment is a canary: it only takes automated recovery action on clusters matching these patterns, and shadow-evaluates (logs intended actions without executing them) on all other clusters
+	CanaryIncumbentURL                         string            // Optional base URL (e.g. "http://orchestrator.example.com:3000") of the incumbent deployment, whose recoveries the canary report compares against. When empty, the canary report compares against recoveries found in this deployment's backend
+	PriorityTierP1ClusterFilters               []string          // Clusters matching these patterns are in recovery priority tier P1: when multiple clusters fail at once, P1 clusters are recovered first. Overridden per cluster via the API
+	PriorityTierP2ClusterFilters               []string          // Clusters matching these patterns are in recovery priority tier P2, recovered after P1 clusters
+	PriorityTierP3ClusterFilters               []string          // Clusters matching these patterns are in recovery priority tier P3, recovered after P2 clusters. Clusters in no tier are recovered last
 	ProcessesShellCommand                      string            // Shell that executes command scripts
+	NotifyPolicies                             []NotifyPolicy    // Deduplication, silencing and escalation of notifications on matching clusters and analyses. See NotifyPolicy
+	PagerDutyRoutingKey                        string            // Integration key of a PagerDuty Events API v2 service. When set, failure detection triggers an incident, acknowledging the recovery acknowledges it, and a successful recovery resolves it
+	PagerDutyEventsURL                         string            // PagerDuty Events API v2 endpoint
+	PagerDutyDedupKey                          string            // "analysis" (default): an incident per cluster and analysis code, triggered on detection. "recovery": an incident per recovery UID, triggered when recovery begins
+	PagerDutySeverities                        map[string]string // map between regex matching cluster name or alias to PagerDuty severity of the cluster's incidents: critical, error, warning or info
+	PagerDutyDefaultSeverity                   string            // PagerDuty severity of incidents on clusters not matching PagerDutySeverities
+	SMTPServer                                 string            // host:port of the SMTP server by which recovery summaries are emailed. Empty (default) disables email
+	SMTPUser                                   string            // SMTP username, if the server requires authentication
+	SMTPPassword                               string            // SMTP password
+	RecoveryEmailFrom                          string            // Sender address of recovery emails
+	RecoveryEmailTo                            []string          // Recipient addresses of recovery emails
+	RecoveryEmailSubjectTemplate               string            // Go text/template of the recovery email subject, executed on the recovery report (as in /api/recovery-report)
+	RecoveryEmailBodyTemplateFile              string            // File holding a Go text/template of the recovery email body, executed on the recovery report. Empty uses a built-in template
+	AnalysisHookSets                           []AnalysisHookSet // Hooks run on analyses that have no recovery of their own, per analysis code. See AnalysisHookSet
+	ReplicaRemedies                            []ReplicaRemedy   // Opt-in automated remediation of replicas with stopped replication or errant GTID, on matching clusters. See ReplicaRemedy
+	SemiSyncRemedies                           []SemiSyncRemedy  // Opt-in remediation of masters locked on semi-sync ACK waits, on matching clusters. See SemiSyncRemedy
+	DetectUnusableMasters                      bool              // When true, writeable masters are probed for conditions that leave them reachable but unusable: exhausted max_connections, read-only filesystem, full disk
+	UnusableMasterMinDiskFreeMB                uint              // With DetectUnusableMasters, a master whose orchestrator-agent reports less free space than this in the MySQL datadir is considered out of disk
+	UnusableMasterPolicies                     []UnusablePolicy  // Opt-in failover of masters found reachable but unusable, on matching clusters. See UnusablePolicy
+	DetectRunningActivities                    bool              // When true, discovery looks for running backups and long-running DDL on instances, and analysis does not report lag or stalled replication they explain as actionable problems
+	DetectRunningActivityQuery                 string            // Optional query (executed on topology instance) describing a running backup or blocking activity, overriding built-in performance_schema detection. If provided, must return one row, one column; empty when nothing runs
+	ReasonableLongRunningDDLSeconds            uint              // With DetectRunningActivities, DDL running at least this many seconds counts as a blocking activity
 	OnFailureDetectionProcesses                []string          // Processes to execute when detecting a failover scenario (before making a decision whether to failover or not). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {successorHost}, {successorPort}, {successorAlias}, {countReplicas}, {replicaHosts}, {isDowntimed}, {autoMasterRecovery}, {autoIntermediateMasterRecovery}
 	PreGracefulTakeoverProcesses               []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
 	PreFailoverProcesses                       []string          // Processes to execute before doing a failover (aborting operation should any once of them exits with non-zero code; order of execution undefined). May and should use some of these placeholders: {failureType}, {failureDescription}, {command}, {failedHost}, {failureCluster}, {failureClusterAlias}, {failureClusterDomain}, {failedPort}, {countReplicas}, {replicaHosts}, {isDowntimed}
@@ -234,7 +365,19 @@ type Configuration struct {
 	PostMasterFailoverProcesses                []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostIntermediateMasterFailoverProcesses    []string          // Processes to execute after doing a master failover (order of execution undefined). Uses same placeholders as PostFailoverProcesses
 	PostGracefulTakeoverProcesses              []string          // Processes to execute after runnign a graceful master takeover. Uses same placeholders as PostFailoverProcesses
+	ScheduledTakeoverProcesses                 []string          // Processes to execute on scheduled graceful master takeover events: when it starts, succeeds, fails or expires unexecuted. Failures do not affect the takeover. May use placeholders: {takeoverEvent}, {takeoverUID}, {takeoverCluster}, {designatedHost}, {designatedPort}, {takeoverMessage}, {orchestratorHost}
 	PostTakeMasterProcesses                    []string          // Processes to execute after a successful Take-Master event has taken place
+	ReplicaRebuildMethod                       string            // How rebuild-replica copies data onto the rebuilt replica: "agent-seed" (orchestrator-agent seed), "command" (ReplicaRebuildCommand, e.g. streaming xtrabackup) or "clone" (MySQL 8 CLONE plugin)
+	ReplicaRebuildCommand                      string            // With ReplicaRebuildMethod "command", copies a source replica's data onto a target, leaving MySQL running on the target. May use placeholders: {sourceHost}, {sourcePort}, {targetHost}, {targetPort}
+	ReplicaRebuildTimeoutSeconds               uint              // Time a replica rebuild has for copying data, and again for the rebuilt replica to catch up, before it is considered failed
+	RollingRestartCommand                      string            // Command restarting (or upgrading) a single MySQL server in a rolling restart. May use placeholders: {host}, {port}, {cluster}. When empty, orchestrator-agent stops and starts MySQL
+	RollingRestartConcurrency                  uint              // Number of replicas restarted concurrently by a rolling restart
+	RollingRestartInstanceTimeoutSeconds       uint              // Time a restarted server has to come back replicating with reasonable lag before a rolling restart fails
+	OnDatacenterFailureProcesses               []string          // Processes to execute once on detecting a data center failure, before its bulk recovery (aborting the bulk recovery should any of them exit with non-zero code). May use placeholders: {failedDataCenter}, {failedClusters}, {countFailedClusters}, {orchestratorHost}
+	PostDatacenterFailureProcesses             []string          // Processes to execute once a data center failure bulk recovery completes; a single summary in place of the per-cluster failover hooks. May use placeholders as OnDatacenterFailureProcesses, as well as {recoveredClusters}, {countRecoveredClusters}, {unrecoveredClusters}
+	ReplicaProvisioningMinReplicas             uint              // When non-zero, a master recovery leaving its cluster with fewer reachable replicas than this (e.g. having detached lost replicas) requests provisioning of new replicas via ReplicaProvisioningProcesses
+	ReplicaProvisioningProcesses               []string          // Processes to execute to request provisioning of new replicas. May use recovery placeholders, as well as {provisionUID}, {remainingReplicas}, {minReplicas}, {missingReplicas}
+	ReplicaProvisioningExpiryMinutes           uint              // A provisioning request not fulfilled within this time expires, and a further recovery may request provisioning again. Until then, repeated requests for the same cluster are deduplicated
 	CoMasterRecoveryMustPromoteOtherCoMaster   bool              // When 'false', anything can get promoted (and candidates are prefered over others). When 'true', orchestrator will promote the other co-master or else fail
 	DetachLostSlavesAfterMasterFailover        bool              // synonym to DetachLostReplicasAfterMasterFailover
 	DetachLostReplicasAfterMasterFailover      bool              // Should replicas that are not to be lost in master recovery (i.e. were more up-to-date than promoted replica) be forcibly detached
@@ -242,10 +385,36 @@ type Configuration struct {
 	PreventCrossDataCenterMasterFailover       bool              // When true (default: false), cross-DC master failover are not allowed, orchestrator will do all it can to only fail over within same DC, or else not fail over at all.
 	PreventCrossRegionMasterFailover           bool              // When true (default: false), cross-region master failover are not allowed, orchestrator will do all it can to only fail over within same region, or else not fail over at all.
 	MasterFailoverLostInstancesDowntimeMinutes uint              // Number of minutes to downtime any server that was lost after a master failover (including failed master & lost replicas). 0 to disable
+	DowntimeAutoExtensionMinutes               uint              // Number of minutes by which a downtime is extended when about to end while its extension condition (e.g. "backup-lock") holds
 	MasterFailoverDetachSlaveMasterHost        bool              // synonym to MasterFailoverDetachReplicaMasterHost
 	MasterFailoverDetachReplicaMasterHost      bool              // Should orchestrator issue a detach-replica-master-host on newly promoted master (this makes sure the new master will not attempt to replicate old master if that comes back to life). Defaults 'false'. Meaningless if ApplyMySQLPromotionAfterMasterFailover is 'true'.
+	MasterRecoveryMaxDataStalenessSeconds      uint              // When > 0, an automated master recovery is refused if the master's backend data was last updated more than this number of seconds ago. Guards against acting on stale analysis when orchestrator's backend is degraded
+	MasterRecoveryMaxRaftApplyLag              uint64            // When > 0 and raft is enabled, an automated master recovery is refused if this node has more than this number of raft log entries yet to be applied
 	FailMasterPromotionIfSQLThreadNotUpToDate  bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, promotion is aborted with error
+	FailMasterPromotionIfNotWritable           bool              // when true, and a master failover takes place, if read_only=0 cannot be verified on the promoted master, the recovery fails and KV, cluster alias and post master failover hooks are not applied
+	ReadOnlyFlipRetries                        uint              // Number of times to retry setting read_only on promoted and demoted masters, when re-reading the variable shows the change did not take effect
+	ReadOnlyFlipRetryIntervalSeconds           uint              // Seconds to wait between read_only flip retries
+	PromotionChecks                            []PromotionCheck  // SQL assertions run on a promoted master, on matching clusters, before its promotion is declared successful. See PromotionCheck
+	ApplyEventSchedulerAfterMasterFailover     bool              // When true, and ApplyMySQLPromotionAfterMasterFailover applies, a master failover turns event_scheduler on in the promoted master and off in the demoted master
+	VerifyGTIDConsistencyAfterMasterFailover   bool              // When true, a master failover compares gtid_mode and enforce_gtid_consistency of the promoted master's replicas with the promoted master, and audits discrepancies as warnings
+	DownstreamHeadsPolicy                      string            // What a master failover does with downstream heads: instances, typically masters of other clusters, replicating from the failed master via a named replication channel. "alert" (default) audits them; "repoint" points their channel at the promoted master
 	DelayMasterPromotionIfSQLThreadNotUpToDate bool              // when true, and a master failover takes place, if candidate master has not consumed all relay logs, delay promotion until the sql thread has caught up
+	DelayMasterPromotionMaxWaitSeconds         uint              // Max time to delay promotion waiting for the sql thread to catch up. 0 waits indefinitely
+	DelayMasterPromotionTimeoutAction          string            // When DelayMasterPromotionMaxWaitSeconds is exceeded: "fail" (default) the promotion, promote the "next-candidate" replica whose sql thread is up to date, or "proceed" with the promotion and reattach lost replicas later
+	MasterFailoverBinlogArchiveFetchCommand    string            // Minimal data loss mode: command run before promotion, which fetches the dead master's binary log events the promoted replica is missing (from a binlog backup/streaming location, or a surviving binlog server) and applies them onto it. Supports recovery hook placeholders plus {candidateHost}, {candidatePort}, {candidateExecutedGtidSet}, {candidateMasterLogFile}, {candidateMasterLogPos}. Empty disables
+	CloseMTSGapsBeforeMasterPromotion          bool              // when true, and a master failover takes place, a multi-threaded candidate master whose sql thread is stopped first runs START SLAVE UNTIL SQL_AFTER_MTS_GAPS, so that its applied transactions are consistent; promotion fails otherwise
+	CloseMTSGapsTimeoutSeconds                 uint              // Max time to wait for the sql thread to close MTS gaps on the candidate master
+	MasterFencingMethods                       []string          // Methods by which to fence a dead master before promoting a replacement, tried in order until one is verified: "sql" (read_only + kill connections), "webhook", "ec2" (force stop), "gce" (stop). Empty disables fencing
+	MasterFencingWebhookURL                    string            // URL to POST the dead master's details to for the "webhook" fencing method (e.g. disabling a switch port). A 200 response means the master is fenced
+	MasterFencingEC2Region                     string            // AWS region of the EC2 instances fenced by the "ec2" fencing method
+	MasterFencingTimeoutSeconds                uint              // Time to wait for a fencing method to be verified (e.g. for a cloud instance to stop)
+	MasterFencingRequired                      bool              // When true, a master recovery is aborted if the dead master could not be fenced. When false, promotion proceeds regardless. Requires a fencing method other than "sql"
+	RDSFailoverClusterFilters                  []string          // Clusters matching these patterns are AWS RDS/Aurora clusters: their masters are failed over via the RDS API (FailoverDBCluster, PromoteReadReplica) rather than by SQL-level promotion
+	RDSFailoverRegion                          string            // AWS region of RDS failovers, when it cannot be inferred from the instance endpoint. Defaults to DiscoverySeedAWSRegion
+	RDSFailoverTimeoutSeconds                  uint              // Time to wait for RDS to complete a failover or read replica promotion
+	CloudSQLFailoverClusterFilters             []string          // Clusters matching these patterns are GCP CloudSQL clusters: their masters are failed over by promoting a replica via the CloudSQL Admin API
+	CloudSQLFailoverProject                    string            // GCP project of CloudSQL failovers. Defaults to DiscoverySeedCloudSQLProject
+	CloudSQLFailoverTimeoutSeconds             uint              // Time to wait for CloudSQL to complete a replica promotion
 	PostponeSlaveRecoveryOnLagMinutes          uint              // Synonym to PostponeReplicaRecoveryOnLagMinutes
 	PostponeReplicaRecoveryOnLagMinutes        uint              // On crash recovery, replicas that are lagging more than given minutes are only resurrected late in the recovery process, after master/IM has been elected and processes executed. Value of 0 disables this feature
 	OSCIgnoreHostnameFilters                   []string          // OSC replicas recommendation will ignore replica hostnames matching given patterns
@@ -254,13 +423,55 @@ type Configuration struct {
 	GraphiteConvertHostnameDotsToUnderscores   bool              // If true, then hostname's dots are converted to underscores before being used in graphite path
 	GraphitePollSeconds                        int               // Graphite writes interval. 0 disables.
 	URLPrefix                                  string            // URL prefix to run orchestrator on non-root web path, e.g. /orchestrator to put it behind nginx.
+	GraphvizDotCommand                         string            // Path of graphviz "dot" executable, by which the topology-dot API renders SVG diagrams. Empty disables SVG rendering; DOT output is always available
 	DiscoveryIgnoreReplicaHostnameFilters      []string          // Regexp filters to apply to prevent auto-discovering new replicas. Usage: unreachable servers due to firewalls, applications which trigger binlog dumps
+	DiscoveryPartitionBy                       string            // Partition discoveries by "datacenter" or by "cluster", limiting the concurrency of each partition. Default: "" (no partitioning)
+	DiscoveryPartitionMaxConcurrency           uint              // When partitioning discoveries, max number of concurrent discoveries per partition. 0 for half of DiscoveryMaxConcurrency
+	DiscoveryPartitionConcurrencyLimits        map[string]uint   // Per-partition overrides of DiscoveryPartitionMaxConcurrency, keyed by datacenter or cluster name
+	DiscoveryPartitionRequeueJitterMillis      uint              // Discoveries deferred due to their partition's concurrency limit are requeued after a random delay of up to this many milliseconds
+	DiscoverySeedIntervalSeconds               uint              // Interval at which discovery is seeded off inventory sources (below). 0 disables
+	DiscoverySeedHTTPURLs                      []string          // Inventory endpoints, each returning a JSON array of "host:port" strings, or of {"Hostname": ..., "Port": ...} objects
+	DiscoverySeedAWSRegion                     string            // AWS region in which to look up RDS and EC2 instances
+	DiscoverySeedAWSTags                       []string          // "key=value" tags RDS and EC2 instances must all carry to seed discovery. Empty to seed all MySQL RDS instances/all running EC2 instances
+	DiscoverySeedRDS                           bool              // Seed discovery off AWS RDS (and Aurora) MySQL instances
+	DiscoverySeedEC2                           bool              // Seed discovery off running AWS EC2 instances, assumed to run MySQL on DefaultInstancePort
+	DiscoverySeedCloudSQLProject               string            // When non empty, seed discovery off GCP CloudSQL MySQL instances in this project
+	DiscoverySeedCloudSQLIPType                string            // CloudSQL address type to discover by: "PRIVATE" (default) or "PRIMARY" (public)
 	ConsulAddress                              string            // Address where Consul HTTP api is found. Example: 127.0.0.1:8500
 	ConsulAclToken                             string            // ACL token used to write to Consul KV
 	ConsulCrossDataCenterDistribution          bool              // should orchestrator automatically auto-deduce all consul DCs and write KVs in all DCs
 	ZkAddress                                  string            // UNSUPPERTED YET. Address where (single or multiple) ZooKeeper servers are found, in `srv1[:port1][,srv2[:port2]...]` format. Default port is 2181. Example: srv-a,srv-b:12181,srv-c
 	KVClusterMasterPrefix                      string            // Prefix to use for clusters' masters entries in KV stores (internal, consul, ZK), default: "mysql/master"
+	ReadPoolClusterFilters                     []string          // Clusters, as in RecoverMasterClusterFilters, for which orchestrator maintains a read pool: healthy, low-lag replicas, weighted for load balancing. Empty (default) disables read pools
+	ReadPoolMaxLagSeconds                      uint              // Replicas lagging more than this are left out of read pools
+	ReadPoolCapacityTag                        string            // Name of the instance tag whose value is the replica's relative read capacity (default 1) in read pool weights. A capacity of 0 leaves the replica out
+	ReadPoolPublishIntervalSeconds             uint              // Interval at which the leader publishes changed read pools to KV stores and ProxySQL
+	ReadPoolKVPrefix                           string            // Prefix to use for clusters' read pool entries in KV stores. Empty disables publishing read pools to KV stores
+	ReadPoolProxySQLHostgroups                 map[string]uint   // map between cluster alias and the ProxySQL hostgroup to which its read pool is published
+	ProxySQLAdminAddress                       string            // host:port of the ProxySQL admin interface, e.g. 127.0.0.1:6032
+	ProxySQLAdminUser                          string            // ProxySQL admin user
+	ProxySQLAdminPassword                      string            // ProxySQL admin password
+	FederationName                             string            // Name of this orchestrator cluster within a federation of independent orchestrator clusters (e.g. one per region). Empty (default) disables federation
+	FederationMembers                          map[string]string // Other federation members: name to API base URL, e.g. "https://orchestrator.us-east.example.com:3000". Basic authentication credentials may be embedded in the URL
+	FederationClusterOwners                    map[string]string // map between regex matching cluster name or alias to the federation member allowed to recover the cluster
+	FederationPollSeconds                      uint              // Interval at which federation members are polled for topology metadata and recovery events
 	WebMessage                                 string            // If provided, will be shown on all web pages below the title bar
+	Include                                    []string          // Configuration files read before this file, whose settings this file overrides. Relative paths are relative to this file's directory
+	Profiles                                   ProfileOverlays   // Named overlays of settings, e.g. per data center or environment: {"production": {"RecoverMasterClusterFilters": ["*"]}}
+	ActiveProfiles                             []string          // Profiles overlaid, in order, onto settings read from files. --profile overrides
+	HostnameRules                              []HostnameRule    // Rules normalizing instance keys of matching hostnames before they are resolved, followed by rules managed via API. See HostnameRule
+	MembershipRules                            []MembershipRule  // Expected membership of matching clusters: replicas and promotion candidates per data center. See MembershipRule
+	OnMembershipViolationProcesses             []string          // Processes to execute when a membership rule is found violated, once per violation. May use placeholders: {failureCluster}, {failureClusterAlias}, {membershipRule}, {dataCenter}, {violation}, {orchestratorHost}
+	BinlogRetentionSeconds                     uint              // Minimum binary log retention on masters (binlog_expire_logs_seconds, or expire_logs_days on servers lacking it). 0 (default) does not check retention
+	BinlogMinDiskHeadroomMB                    uint              // Minimum free space on a master's MySQL datadir, as reported by orchestrator-agent. 0 (default) does not check disk headroom
+	BinlogWatchdogMode                         string            // Handling of masters failing BinlogRetentionSeconds or BinlogMinDiskHeadroomMB: "monitor" (default) audits, "enforce" also remediates: raises retention, and purges binary logs all replicas have applied
+	DemotedMasterKillPolicies                  []SQLKillPolicy   // Sessions to kill on a demoted master, per cluster: open write transactions and long running queries. See SQLKillPolicy
+	GracefulTakeoverDrainMethods               []string          // Proxies to drain the master at before a graceful takeover sets it read-only, all applied in order: "proxysql" (OFFLINE_SOFT via ProxySQLAdminAddress), "webhook". Empty disables draining
+	GracefulTakeoverDrainWebhookURL            string            // URL to POST drain and undrain requests to for the "webhook" drain method (e.g. a HAProxy runtime API bridge). A 2xx response means the request is applied
+	GracefulTakeoverDrainMaxConnections        uint              // A drained master is considered drained once it has at most this many client connections
+	GracefulTakeoverDrainTimeoutSeconds        uint              // Time to wait for a drained master's client connections to fall to GracefulTakeoverDrainMaxConnections
+	GracefulTakeoverDrainProceedOnTimeout      bool              // When true, a takeover proceeds though its master did not drain in time. Otherwise (default) the takeover is aborted and the master undrained
+	PromotionCanaries                          []PromotionCanary // Canary writes through the production access path, on matching clusters, verified on a promoted master and its replicas before the promotion is declared successful. See PromotionCanary
 }
 
 // ToJSONString will marshal this configuration as JSON
@@ -279,12 +490,15 @@ func newConfiguration() *Configuration {
 		EnableSyslog:                               false,
 		ListenAddress:                              ":3000",
 		ListenSocket:                               "",
+		GRPCListenAddress:                          "",
+		GRPCWatchPollSeconds:                       5,
 		HTTPAdvertise:                              "",
 		AgentsServerPort:                           ":3001",
 		StatusEndpoint:                             "/api/status",
 		StatusOUVerify:                             false,
 		BackendDB:                                  "mysql",
 		SQLite3DataFile:                            "",
+		SQLite3ReadPoolConnections:                 4,
 		SkipOrchestratorDatabaseUpdate:             false,
 		PanicIfDifferentDatabaseDeploy:             false,
 		RaftBind:                                   "127.0.0.1:10008",
@@ -292,6 +506,8 @@ func newConfiguration() *Configuration {
 		RaftDataDir:                                "",
 		DefaultRaftPort:                            10008,
 		RaftNodes:                                  []string{},
+		RaftReadConsistency:                        "leader",
+		RaftLeaseReadSeconds:                       2,
 		ExpectFailureAnalysisConcensus:             true,
 		MySQLOrchestratorMaxPoolConnections:        128, // limit concurrent conns to backend DB
 		MySQLOrchestratorPort:                      3306,
@@ -299,19 +515,29 @@ func newConfiguration() *Configuration {
 		MySQLTopologyUseMixedTLS:                   true,
 		MySQLOrchestratorUseMutualTLS:              false,
 		MySQLConnectTimeoutSeconds:                 2,
+		ConnectIPPreference:                        "",
 		MySQLOrchestratorReadTimeoutSeconds:        30,
 		MySQLDiscoveryReadTimeoutSeconds:           10,
 		MySQLTopologyReadTimeoutSeconds:            600,
+		PostgreSQLOrchestratorPort:                 5432,
+		PostgreSQLOrchestratorSSLMode:              "require",
 		MySQLConnectionLifetimeSeconds:             0,
+		MySQLOrchestratorMaxIdleConnections:        0,
+		MySQLOrchestratorConnectionLifetimeSeconds: 0,
+		MySQLOrchestratorRecoveryPoolConnections:   0,
 		DefaultInstancePort:                        3306,
 		TLSCacheTTLFactor:                          100,
 		InstancePollSeconds:                        5,
+		AdaptiveInstancePollMaxSeconds:             0,
+		AdaptiveInstancePollMinSeconds:             1,
 		InstanceWriteBufferSize:                    100,
 		BufferInstanceWrites:                       false,
 		InstanceFlushIntervalMilliseconds:          100,
 		SkipMaxScaleCheck:                          false,
+		DetectRippleBinlogServers:                  false,
 		UnseenInstanceForgetHours:                  240,
 		SnapshotTopologiesIntervalHours:            0,
+		ClusterTopologySnapshotIntervalMinutes:     0,
 		DiscoverByShowSlaveHosts:                   false,
 		UseSuperReadOnly:                           false,
 		DiscoveryMaxConcurrency:                    300,
@@ -319,30 +545,59 @@ func newConfiguration() *Configuration {
 		DiscoveryQueueMaxStatisticsSize:            120,
 		DiscoveryCollectionRetentionSeconds:        120,
 		InstanceBulkOperationsWaitTimeoutSeconds:   10,
+		CompletionCacheSeconds:                     60,
 		HostnameResolveMethod:                      "default",
 		MySQLHostnameResolveMethod:                 "@@hostname",
 		SkipBinlogServerUnresolveCheck:             true,
 		ExpiryHostnameResolvesMinutes:              60,
 		RejectHostnameResolvePattern:               "",
 		ReasonableReplicationLagSeconds:            10,
+		ReasonableReplicaHeartbeatSeconds:          10,
+		ReasonableLockedSemiSyncMasterSeconds:      10,
+		HeartbeatIntervalMilliseconds:              0,
+		HeartbeatTable:                             "meta.orchestrator_heartbeat",
 		ProblemIgnoreHostnameFilters:               []string{},
 		VerifyReplicationFilters:                   false,
 		ReasonableMaintenanceReplicationLagSeconds: 20,
+		ReplicaLagSLOSeconds:                       0,
+		ReplicaLagSLOTargetPercent:                 99,
+		ReplicaLagSLOWindowHours:                   24,
+		ReplicaLagHistoryRetentionDays:             7,
 		CandidateInstanceExpireMinutes:             60,
 		AuditLogFile:                               "",
+		AuditRetentionDays:                         AuditPurgeDays,
+		RecoveryRetentionDays:                      AuditPurgeDays,
+		AnalysisChangelogRetentionHours:            0,
+		RetentionArchiveDirectory:                  "",
+		RetentionArchiveUploadCommand:              "",
+		RetentionArchiveIntervalMinutes:            60,
 		AuditToSyslog:                              false,
 		AuditToBackendDB:                           false,
+		FleetReportIntervalMinutes:                 0,
+		FleetReportRetentionDays:                   365,
 		RemoveTextFromHostnameDisplay:              "",
 		ReadOnly:                                   false,
 		AuthenticationMethod:                       "",
 		HTTPAuthUser:                               "",
 		HTTPAuthPassword:                           "",
+		OIDCUserClaim:                              "email",
+		OIDCGroupsClaim:                            "groups",
 		AuthUserHeader:                             "X-Forwarded-User",
 		PowerAuthUsers:                             []string{"*"},
 		PowerAuthGroups:                            []string{},
+		SlackSigningSecret:                         "",
+		SlackPowerUsers:                            []string{},
 		AccessTokenUseExpirySeconds:                60,
+		APIRateLimitRequestsPerSecond:              0,
+		APIRateLimitBurst:                          20,
+		APIShedLowPriorityDuringRecovery:           false,
+		APILowPriorityPaths:                        []string{"/api/audit", "/api/all-instances", "/api/search", "/api/clusters-info", "/api/instance-changes", "/api/replication-analysis-changelog", "/api/backend-query-metrics-raw", "/api/discovery-metrics-raw", "/api/discovery-queue-metrics-raw"},
+		AuditAPIRequests:                           false,
+		APIVersionCompatibilityMode:                false,
 		AccessTokenExpiryMinutes:                   1440,
 		ClusterNameToAlias:                         make(map[string]string),
+		AnalysisSeverities:                         make(map[string]string),
+		ClusterTemplates:                           []ClusterTemplate{},
 		DetectClusterAliasQuery:                    "",
 		DetectClusterDomainQuery:                   "",
 		DetectInstanceAliasQuery:                   "",
@@ -366,6 +621,11 @@ func newConfiguration() *Configuration {
 		UseSSL:                                     false,
 		UseMutualTLS:                               false,
 		SSLValidOUs:                                []string{},
+		SSLValidSANs:                               []string{},
+		SSLRequireClientCert:                       false,
+		SSLPowerOUs:                                []string{},
+		SSLPowerSANs:                               []string{},
+		SSLRaftPeerSANs:                            []string{},
 		SSLSkipVerify:                              false,
 		SSLPrivateKeyFile:                          "",
 		SSLCertFile:                                "",
@@ -382,14 +642,57 @@ func newConfiguration() *Configuration {
 		DetectPseudoGTIDQuery:                      "",
 		BinlogEventsChunkSize:                      10000,
 		SkipBinlogEventsContaining:                 []string{},
+		GTIDPurgedCheck:                            GTIDPurgedCheckRefuse,
 		ReduceReplicationAnalysisCount:             true,
 		FailureDetectionPeriodBlockMinutes:         60,
 		RecoveryPeriodBlockMinutes:                 60,
 		RecoveryPeriodBlockSeconds:                 3600,
+		MaxConcurrentRecoveries:                    0,
+		MaxConcurrentRecoveriesPerDataCenter:       0,
+		MasterFlapDetectionPeriodSeconds:           0,
+		MasterFlapThreshold:                        2,
+		MasterHealthProbes:                         []HealthProbe{},
+		AutoAcknowledgePolicies:                    []AutoAckPolicy{},
+		MasterDeathConfirmationQuorum:              0,
+		MasterDeathConfirmationRaftPeers:           false,
+		MasterDeathConfirmationVantagePoints:       []string{},
+		MasterDeathConfirmationTimeoutSeconds:      5,
+		DatacenterFailureMinDeadMasters:            0,
+		DatacenterFailureRecoveryIntervalSeconds:   5,
 		RecoveryIgnoreHostnameFilters:              []string{},
 		RecoverMasterClusterFilters:                []string{},
 		RecoverIntermediateMasterClusterFilters:    []string{},
+		ReplicationTLSRequiredClusterFilters:       []string{},
+		ReplicationTLSVerifyServerCert:             false,
+		CanaryClusterFilters:                       []string{},
+		CanaryIncumbentURL:                         "",
+		PriorityTierP1ClusterFilters:               []string{},
+		PriorityTierP2ClusterFilters:               []string{},
+		PriorityTierP3ClusterFilters:               []string{},
 		ProcessesShellCommand:                      "bash",
+		NotifyPolicies:                             []NotifyPolicy{},
+		PagerDutyRoutingKey:                        "",
+		PagerDutyEventsURL:                         "https://events.pagerduty.com/v2/enqueue",
+		PagerDutyDedupKey:                          PagerDutyDedupKeyAnalysis,
+		PagerDutySeverities:                        make(map[string]string),
+		PagerDutyDefaultSeverity:                   "error",
+		SMTPServer:                                 "",
+		SMTPUser:                                   "",
+		SMTPPassword:                               "",
+		RecoveryEmailFrom:                          "",
+		RecoveryEmailTo:                            []string{},
+		RecoveryEmailSubjectTemplate:               DefaultRecoveryEmailSubjectTemplate,
+		RecoveryEmailBodyTemplateFile:              "",
+		AnalysisRules:                              []AnalysisRule{},
+		AnalysisHookSets:                           []AnalysisHookSet{},
+		ReplicaRemedies:                            []ReplicaRemedy{},
+		SemiSyncRemedies:                           []SemiSyncRemedy{},
+		DetectUnusableMasters:                      false,
+		UnusableMasterMinDiskFreeMB:                16,
+		UnusableMasterPolicies:                     []UnusablePolicy{},
+		DetectRunningActivities:                    false,
+		DetectRunningActivityQuery:                 "",
+		ReasonableLongRunningDDLSeconds:            60,
 		OnFailureDetectionProcesses:                []string{},
 		PreGracefulTakeoverProcesses:               []string{},
 		PreFailoverProcesses:                       []string{},
@@ -398,16 +701,53 @@ func newConfiguration() *Configuration {
 		PostFailoverProcesses:                      []string{},
 		PostUnsuccessfulFailoverProcesses:          []string{},
 		PostGracefulTakeoverProcesses:              []string{},
+		ScheduledTakeoverProcesses:                 []string{},
 		PostTakeMasterProcesses:                    []string{},
+		ReplicaRebuildMethod:                       ReplicaRebuildMethodAgentSeed,
+		ReplicaRebuildCommand:                      "",
+		ReplicaRebuildTimeoutSeconds:               86400,
+		RollingRestartCommand:                      "",
+		RollingRestartConcurrency:                  1,
+		RollingRestartInstanceTimeoutSeconds:       600,
+		OnDatacenterFailureProcesses:               []string{},
+		PostDatacenterFailureProcesses:             []string{},
+		ReplicaProvisioningMinReplicas:             0,
+		ReplicaProvisioningProcesses:               []string{},
+		ReplicaProvisioningExpiryMinutes:           60,
 		CoMasterRecoveryMustPromoteOtherCoMaster:   true,
 		DetachLostSlavesAfterMasterFailover:        true,
 		ApplyMySQLPromotionAfterMasterFailover:     true,
 		PreventCrossDataCenterMasterFailover:       false,
 		PreventCrossRegionMasterFailover:           false,
 		MasterFailoverLostInstancesDowntimeMinutes: 0,
+		DowntimeAutoExtensionMinutes:               10,
 		MasterFailoverDetachSlaveMasterHost:        false,
+		MasterRecoveryMaxDataStalenessSeconds:      0,
+		MasterRecoveryMaxRaftApplyLag:              0,
 		FailMasterPromotionIfSQLThreadNotUpToDate:  false,
+		FailMasterPromotionIfNotWritable:           false,
+		ReadOnlyFlipRetries:                        3,
+		ReadOnlyFlipRetryIntervalSeconds:           1,
+		PromotionChecks:                            []PromotionCheck{},
+		ApplyEventSchedulerAfterMasterFailover:     false,
+		VerifyGTIDConsistencyAfterMasterFailover:   false,
+		DownstreamHeadsPolicy:                      DownstreamHeadsAlert,
 		DelayMasterPromotionIfSQLThreadNotUpToDate: false,
+		DelayMasterPromotionMaxWaitSeconds:         0,
+		DelayMasterPromotionTimeoutAction:          DelayMasterPromotionTimeoutFail,
+		CloseMTSGapsBeforeMasterPromotion:          false,
+		CloseMTSGapsTimeoutSeconds:                 60,
+		MasterFencingMethods:                       []string{},
+		MasterFencingWebhookURL:                    "",
+		MasterFencingEC2Region:                     "",
+		MasterFencingTimeoutSeconds:                120,
+		MasterFencingRequired:                      false,
+		RDSFailoverClusterFilters:                  []string{},
+		RDSFailoverRegion:                          "",
+		RDSFailoverTimeoutSeconds:                  600,
+		CloudSQLFailoverClusterFilters:             []string{},
+		CloudSQLFailoverProject:                    "",
+		CloudSQLFailoverTimeoutSeconds:             600,
 		PostponeSlaveRecoveryOnLagMinutes:          0,
 		OSCIgnoreHostnameFilters:                   []string{},
 		GraphiteAddr:                               "",
@@ -415,17 +755,62 @@ func newConfiguration() *Configuration {
 		GraphiteConvertHostnameDotsToUnderscores:   true,
 		GraphitePollSeconds:                        60,
 		URLPrefix:                                  "",
+		GraphvizDotCommand:                         "",
 		DiscoveryIgnoreReplicaHostnameFilters:      []string{},
+		DiscoveryPartitionBy:                       "",
+		DiscoveryPartitionMaxConcurrency:           0,
+		DiscoveryPartitionConcurrencyLimits:        make(map[string]uint),
+		DiscoveryPartitionRequeueJitterMillis:      1000,
+		DiscoverySeedIntervalSeconds:               0,
+		DiscoverySeedHTTPURLs:                      []string{},
+		DiscoverySeedAWSRegion:                     "",
+		DiscoverySeedAWSTags:                       []string{},
+		DiscoverySeedRDS:                           false,
+		DiscoverySeedEC2:                           false,
+		DiscoverySeedCloudSQLProject:               "",
+		DiscoverySeedCloudSQLIPType:                "PRIVATE",
 		ConsulAddress:                              "",
 		ConsulAclToken:                             "",
 		ConsulCrossDataCenterDistribution:          false,
 		ZkAddress:                                  "",
 		KVClusterMasterPrefix:                      "mysql/master",
+		ReadPoolClusterFilters:                     []string{},
+		ReadPoolMaxLagSeconds:                      10,
+		ReadPoolCapacityTag:                        "read-pool-capacity",
+		ReadPoolPublishIntervalSeconds:             10,
+		ReadPoolKVPrefix:                           "mysql/read-pool",
+		ReadPoolProxySQLHostgroups:                 make(map[string]uint),
+		ProxySQLAdminAddress:                       "",
+		ProxySQLAdminUser:                          "",
+		ProxySQLAdminPassword:                      "",
+		FederationName:                             "",
+		FederationMembers:                          make(map[string]string),
+		FederationClusterOwners:                    make(map[string]string),
+		FederationPollSeconds:                      30,
 		WebMessage:                                 "",
+		Include:                                    []string{},
+		Profiles:                                   make(ProfileOverlays),
+		ActiveProfiles:                             []string{},
+		HostnameRules:                              []HostnameRule{},
+		MembershipRules:                            []MembershipRule{},
+		OnMembershipViolationProcesses:             []string{},
+		BinlogRetentionSeconds:                     0,
+		BinlogMinDiskHeadroomMB:                    0,
+		BinlogWatchdogMode:                         BinlogWatchdogMonitor,
+		DemotedMasterKillPolicies:                  []SQLKillPolicy{},
+		GracefulTakeoverDrainMethods:               []string{},
+		GracefulTakeoverDrainWebhookURL:            "",
+		GracefulTakeoverDrainMaxConnections:        0,
+		GracefulTakeoverDrainTimeoutSeconds:        60,
+		GracefulTakeoverDrainProceedOnTimeout:      false,
+		PromotionCanaries:                          []PromotionCanary{},
 	}
 }
 
 func (this *Configuration) postReadAdjustments() error {
+	if err := this.applyProfiles(); err != nil {
+		return err
+	}
 	if this.MySQLOrchestratorCredentialsConfigFile != "" {
 		mySQLConfig := struct {
 			Client struct {
@@ -435,21 +820,13 @@ func (this *Configuration) postReadAdjustments() error {
 		}{}
 		err := gcfg.ReadFileInto(&mySQLConfig, this.MySQLOrchestratorCredentialsConfigFile)
 		if err != nil {
-			log.Fatalf("Failed to parse gcfg data from file: %+v", err)
+			return fmt.Errorf("Failed to parse gcfg data from file: %+v", err)
 		} else {
 			log.Debugf("Parsed orchestrator credentials from %s", this.MySQLOrchestratorCredentialsConfigFile)
 			this.MySQLOrchestratorUser = mySQLConfig.Client.User
 			this.MySQLOrchestratorPassword = mySQLConfig.Client.Password
 		}
 	}
-	{
-		// We accept password in the form "${SOME_ENV_VARIABLE}" in which case we pull
-		// the given variable from os env
-		submatch := envVariableRegexp.FindStringSubmatch(this.MySQLOrchestratorPassword)
-		if len(submatch) > 1 {
-			this.MySQLOrchestratorPassword = os.Getenv(submatch[1])
-		}
-	}
 	if this.MySQLTopologyCredentialsConfigFile != "" {
 		mySQLConfig := struct {
 			Client struct {
@@ -459,20 +836,16 @@ func (this *Configuration) postReadAdjustments() error {
 		}{}
 		err := gcfg.ReadFileInto(&mySQLConfig, this.MySQLTopologyCredentialsConfigFile)
 		if err != nil {
-			log.Fatalf("Failed to parse gcfg data from file: %+v", err)
+			return fmt.Errorf("Failed to parse gcfg data from file: %+v", err)
 		} else {
 			log.Debugf("Parsed topology credentials from %s", this.MySQLTopologyCredentialsConfigFile)
 			this.MySQLTopologyUser = mySQLConfig.Client.User
 			this.MySQLTopologyPassword = mySQLConfig.Client.Password
 		}
 	}
-	{
-		// We accept password in the form "${SOME_ENV_VARIABLE}" in which case we pull
-		// the given variable from os env
-		submatch := envVariableRegexp.FindStringSubmatch(this.MySQLTopologyPassword)
-		if len(submatch) > 1 {
-			this.MySQLTopologyPassword = os.Getenv(submatch[1])
-		}
+	// Credentials may reference secrets: "${SOME_ENV_VARIABLE}", "env:", "file:" or "exec:"
+	if err := this.resolveSecretReferences(); err != nil {
+		return err
 	}
 
 	if this.RecoveryPeriodBlockSeconds == 0 && this.RecoveryPeriodBlockMinutes > 0 {
@@ -493,6 +866,41 @@ func (this *Configuration) postReadAdjustments() error {
 			this.SlaveLagQuery = this.ReplicationLagQuery
 		}
 	}
+	if this.HeartbeatIntervalMilliseconds > 0 {
+		if this.HeartbeatIntervalMilliseconds < 100 {
+			return fmt.Errorf("HeartbeatIntervalMilliseconds must be at least 100, got %d", this.HeartbeatIntervalMilliseconds)
+		}
+		if tokens := strings.Split(this.HeartbeatTable, "."); len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" || strings.Contains(this.HeartbeatTable, "`") {
+			return fmt.Errorf("HeartbeatTable must be of the form schema.table, got %q", this.HeartbeatTable)
+		}
+	}
+
+	if this.ReplicaProvisioningMinReplicas > 0 && this.ReplicaProvisioningExpiryMinutes < 1 {
+		return fmt.Errorf("ReplicaProvisioningExpiryMinutes must be at least 1 when ReplicaProvisioningMinReplicas is set")
+	}
+	switch this.ReplicaRebuildMethod {
+	case ReplicaRebuildMethodAgentSeed, ReplicaRebuildMethodClone:
+	case ReplicaRebuildMethodCommand:
+		if this.ReplicaRebuildCommand == "" {
+			return fmt.Errorf("ReplicaRebuildMethod is %q, but ReplicaRebuildCommand is empty", this.ReplicaRebuildMethod)
+		}
+	default:
+		return fmt.Errorf("ReplicaRebuildMethod must be one of %q, %q, %q; got %q", ReplicaRebuildMethodAgentSeed, ReplicaRebuildMethodCommand, ReplicaRebuildMethodClone, this.ReplicaRebuildMethod)
+	}
+	switch this.GTIDPurgedCheck {
+	case GTIDPurgedCheckRefuse, GTIDPurgedCheckWarn:
+	default:
+		return fmt.Errorf("GTIDPurgedCheck must be one of %q, %q; got %q", GTIDPurgedCheckRefuse, GTIDPurgedCheckWarn, this.GTIDPurgedCheck)
+	}
+	if this.DowntimeAutoExtensionMinutes < 1 {
+		return fmt.Errorf("DowntimeAutoExtensionMinutes must be at least 1")
+	}
+	if this.RollingRestartConcurrency < 1 {
+		return fmt.Errorf("RollingRestartConcurrency must be at least 1")
+	}
+	if this.RollingRestartInstanceTimeoutSeconds < 1 {
+		return fmt.Errorf("RollingRestartInstanceTimeoutSeconds must be at least 1")
+	}
 
 	{
 		if this.DetachLostSlavesAfterMasterFailover {
@@ -508,6 +916,11 @@ func (this *Configuration) postReadAdjustments() error {
 	if this.FailMasterPromotionIfSQLThreadNotUpToDate && this.DelayMasterPromotionIfSQLThreadNotUpToDate {
 		return fmt.Errorf("Cannot have both FailMasterPromotionIfSQLThreadNotUpToDate and DelayMasterPromotionIfSQLThreadNotUpToDate enabled")
 	}
+	switch this.DelayMasterPromotionTimeoutAction {
+	case DelayMasterPromotionTimeoutFail, DelayMasterPromotionTimeoutNextCandidate, DelayMasterPromotionTimeoutProceed:
+	default:
+		return fmt.Errorf("DelayMasterPromotionTimeoutAction must be one of %q, %q, %q; got %q", DelayMasterPromotionTimeoutFail, DelayMasterPromotionTimeoutNextCandidate, DelayMasterPromotionTimeoutProceed, this.DelayMasterPromotionTimeoutAction)
+	}
 	{
 		if this.PostponeReplicaRecoveryOnLagMinutes != 0 && this.PostponeSlaveRecoveryOnLagMinutes != 0 &&
 			this.PostponeReplicaRecoveryOnLagMinutes != this.PostponeSlaveRecoveryOnLagMinutes {
@@ -525,12 +938,220 @@ func (this *Configuration) postReadAdjustments() error {
 		this.URLPrefix = "/" + this.URLPrefix
 	}
 
+	if strings.ToLower(this.AuthenticationMethod) == "oidc" {
+		if this.OIDCIssuerURL == "" || this.OAuthClientId == "" || this.OIDCRedirectURL == "" {
+			return fmt.Errorf("OIDCIssuerURL, OAuthClientId and OIDCRedirectURL must be defined when AuthenticationMethod is oidc")
+		}
+	}
+
+	if strings.ToLower(this.AuthenticationMethod) == "mtls" && !(this.UseSSL && this.UseMutualTLS) {
+		return fmt.Errorf("AuthenticationMethod mtls requires both UseSSL and UseMutualTLS")
+	}
+
+	if err := this.validateClusterTemplates(); err != nil {
+		return err
+	}
+	if err := this.validateHealthProbes(); err != nil {
+		return err
+	}
+	if err := this.validateNotifyPolicies(); err != nil {
+		return err
+	}
+	if err := this.validatePagerDuty(); err != nil {
+		return err
+	}
+	if err := this.validateRecoveryEmail(); err != nil {
+		return err
+	}
+	if err := this.validateAnalysisRules(); err != nil {
+		return err
+	}
+	if err := this.validateHostnameRules(); err != nil {
+		return err
+	}
+	if err := this.validateMembershipRules(); err != nil {
+		return err
+	}
+	if err := this.validateAnalysisHookSets(); err != nil {
+		return err
+	}
+	if err := this.validateReplicaRemedies(); err != nil {
+		return err
+	}
+	if err := this.validateSemiSyncRemedies(); err != nil {
+		return err
+	}
+	if err := this.validateUnusableMasterPolicies(); err != nil {
+		return err
+	}
+	if err := this.validateDemotedMasterKillPolicies(); err != nil {
+		return err
+	}
+	if err := this.validatePromotionChecks(); err != nil {
+		return err
+	}
+	if err := this.validatePromotionCanaries(); err != nil {
+		return err
+	}
+	if err := this.validateDownstreamHeadsPolicy(); err != nil {
+		return err
+	}
+	switch this.ConnectIPPreference {
+	case "", IPPreferenceIPv4, IPPreferenceIPv6:
+	default:
+		return fmt.Errorf("ConnectIPPreference must be empty, %s or %s; got %s", IPPreferenceIPv4, IPPreferenceIPv6, this.ConnectIPPreference)
+	}
+	switch this.BinlogWatchdogMode {
+	case BinlogWatchdogMonitor, BinlogWatchdogEnforce:
+	default:
+		return fmt.Errorf("BinlogWatchdogMode must be one of %q, %q; got %q", BinlogWatchdogMonitor, BinlogWatchdogEnforce, this.BinlogWatchdogMode)
+	}
+	if err := this.validateReadPools(); err != nil {
+		return err
+	}
+	if this.RetentionArchiveUploadCommand != "" && this.RetentionArchiveDirectory == "" {
+		return fmt.Errorf("RetentionArchiveUploadCommand requires RetentionArchiveDirectory")
+	}
+	if this.RetentionArchiveDirectory != "" && this.RetentionArchiveIntervalMinutes == 0 {
+		return fmt.Errorf("RetentionArchiveIntervalMinutes must be positive when RetentionArchiveDirectory is set")
+	}
+	if this.ReplicaLagSLOSeconds > 0 {
+		if this.ReplicaLagSLOTargetPercent <= 0 || this.ReplicaLagSLOTargetPercent > 100 {
+			return fmt.Errorf("ReplicaLagSLOTargetPercent must be in (0, 100]; got %v", this.ReplicaLagSLOTargetPercent)
+		}
+		if this.ReplicaLagSLOWindowHours == 0 || this.ReplicaLagSLOWindowHours > 24*this.ReplicaLagHistoryRetentionDays {
+			return fmt.Errorf("ReplicaLagSLOWindowHours must be > 0 and within ReplicaLagHistoryRetentionDays")
+		}
+	}
+
 	if this.IsSQLite() && this.SQLite3DataFile == "" {
 		return fmt.Errorf("SQLite3DataFile must be set when BackendDB is sqlite3")
 	}
 	if this.IsSQLite() {
 		//		this.HostnameResolveMethod = "none"
 	}
+	if this.IsPostgreSQL() && this.PostgreSQLOrchestratorHost == "" {
+		return fmt.Errorf("PostgreSQLOrchestratorHost must be set when BackendDB is postgres")
+	}
+	if this.IsPostgreSQL() && this.PostgreSQLOrchestratorDatabase == "" {
+		return fmt.Errorf("PostgreSQLOrchestratorDatabase must be set when BackendDB is postgres")
+	}
+	if this.IsPostgreSQL() && this.RaftEnabled {
+		return fmt.Errorf("BackendDB postgres is only supported in a shared backend setup; it cannot be used with RaftEnabled")
+	}
+	if this.MySQLOrchestratorMaxIdleConnections < 0 {
+		return fmt.Errorf("MySQLOrchestratorMaxIdleConnections must not be negative")
+	}
+	if this.MySQLOrchestratorConnectionLifetimeSeconds < 0 {
+		return fmt.Errorf("MySQLOrchestratorConnectionLifetimeSeconds must not be negative")
+	}
+	if this.MySQLOrchestratorRecoveryPoolConnections < 0 {
+		return fmt.Errorf("MySQLOrchestratorRecoveryPoolConnections must not be negative")
+	}
+	if this.AdaptiveInstancePollMaxSeconds > 0 {
+		if this.AdaptiveInstancePollMaxSeconds < this.InstancePollSeconds {
+			return fmt.Errorf("AdaptiveInstancePollMaxSeconds must not be lower than InstancePollSeconds")
+		}
+		if this.AdaptiveInstancePollMinSeconds == 0 || this.AdaptiveInstancePollMinSeconds > this.InstancePollSeconds {
+			return fmt.Errorf("AdaptiveInstancePollMinSeconds must be between 1 and InstancePollSeconds")
+		}
+	}
+	switch this.DiscoveryPartitionBy {
+	case "", "datacenter", "cluster":
+	default:
+		return fmt.Errorf("DiscoveryPartitionBy must be one of: \"\", \"datacenter\", \"cluster\"; got %q", this.DiscoveryPartitionBy)
+	}
+	if (this.DiscoverySeedRDS || this.DiscoverySeedEC2) && this.DiscoverySeedAWSRegion == "" {
+		return fmt.Errorf("DiscoverySeedAWSRegion must be set when DiscoverySeedRDS or DiscoverySeedEC2 are enabled")
+	}
+	for _, tag := range this.DiscoverySeedAWSTags {
+		if !strings.Contains(tag, "=") {
+			return fmt.Errorf("DiscoverySeedAWSTags entries must be in key=value format; got %q", tag)
+		}
+	}
+	switch this.DiscoverySeedCloudSQLIPType {
+	case "PRIVATE", "PRIMARY":
+	default:
+		return fmt.Errorf("DiscoverySeedCloudSQLIPType must be one of: \"PRIVATE\", \"PRIMARY\"; got %q", this.DiscoverySeedCloudSQLIPType)
+	}
+	for _, method := range this.MasterFencingMethods {
+		switch method {
+		case "sql", "gce":
+		case "webhook":
+			if this.MasterFencingWebhookURL == "" {
+				return fmt.Errorf("MasterFencingWebhookURL must be set for the \"webhook\" fencing method")
+			}
+		case "ec2":
+			if this.MasterFencingEC2Region == "" {
+				return fmt.Errorf("MasterFencingEC2Region must be set for the \"ec2\" fencing method")
+			}
+		default:
+			return fmt.Errorf("MasterFencingMethods must be any of: \"sql\", \"webhook\", \"ec2\", \"gce\"; got %q", method)
+		}
+	}
+	if len(this.MasterFencingMethods) > 0 && this.MasterFencingTimeoutSeconds == 0 {
+		return fmt.Errorf("MasterFencingTimeoutSeconds must be positive when MasterFencingMethods are given")
+	}
+	if this.MasterFencingRequired && len(this.MasterFencingMethods) > 0 {
+		// The "sql" method needs to reach the master, which a dead master rarely is
+		hasOutOfBandMethod := false
+		for _, method := range this.MasterFencingMethods {
+			if method != "sql" {
+				hasOutOfBandMethod = true
+			}
+		}
+		if !hasOutOfBandMethod {
+			return fmt.Errorf("MasterFencingRequired needs a MasterFencingMethods method other than \"sql\", which cannot fence an unreachable master")
+		}
+	}
+	for _, method := range this.GracefulTakeoverDrainMethods {
+		switch method {
+		case "proxysql":
+			if this.ProxySQLAdminAddress == "" {
+				return fmt.Errorf("ProxySQLAdminAddress must be set for the \"proxysql\" drain method")
+			}
+		case "webhook":
+			if this.GracefulTakeoverDrainWebhookURL == "" {
+				return fmt.Errorf("GracefulTakeoverDrainWebhookURL must be set for the \"webhook\" drain method")
+			}
+		default:
+			return fmt.Errorf("GracefulTakeoverDrainMethods must be any of: \"proxysql\", \"webhook\"; got %q", method)
+		}
+	}
+	if len(this.GracefulTakeoverDrainMethods) > 0 && this.GracefulTakeoverDrainTimeoutSeconds == 0 {
+		return fmt.Errorf("GracefulTakeoverDrainTimeoutSeconds must be positive when GracefulTakeoverDrainMethods are given")
+	}
+	if len(this.RDSFailoverClusterFilters) > 0 && this.RDSFailoverTimeoutSeconds == 0 {
+		return fmt.Errorf("RDSFailoverTimeoutSeconds must be positive when RDSFailoverClusterFilters are given")
+	}
+	if len(this.CloudSQLFailoverClusterFilters) > 0 {
+		if this.CloudSQLFailoverTimeoutSeconds == 0 {
+			return fmt.Errorf("CloudSQLFailoverTimeoutSeconds must be positive when CloudSQLFailoverClusterFilters are given")
+		}
+		if this.CloudSQLFailoverProject == "" && this.DiscoverySeedCloudSQLProject == "" {
+			return fmt.Errorf("CloudSQLFailoverProject or DiscoverySeedCloudSQLProject must be set when CloudSQLFailoverClusterFilters are given")
+		}
+	}
+	if this.MasterFlapDetectionPeriodSeconds > 0 && this.MasterFlapThreshold == 0 {
+		return fmt.Errorf("MasterFlapThreshold must be positive when MasterFlapDetectionPeriodSeconds is set")
+	}
+	if this.MasterDeathConfirmationQuorum > 0 {
+		if !this.MasterDeathConfirmationRaftPeers && uint(len(this.MasterDeathConfirmationVantagePoints)) < this.MasterDeathConfirmationQuorum {
+			return fmt.Errorf("MasterDeathConfirmationQuorum is %d, but only %d MasterDeathConfirmationVantagePoints are given and MasterDeathConfirmationRaftPeers is false", this.MasterDeathConfirmationQuorum, len(this.MasterDeathConfirmationVantagePoints))
+		}
+		if this.MasterDeathConfirmationTimeoutSeconds == 0 {
+			return fmt.Errorf("MasterDeathConfirmationTimeoutSeconds must be positive when MasterDeathConfirmationQuorum is set")
+		}
+	}
+	if this.DatacenterFailureMinDeadMasters == 1 {
+		return fmt.Errorf("DatacenterFailureMinDeadMasters must be 0 (disabled) or at least 2")
+	}
+	if this.GRPCListenAddress != "" && this.GRPCWatchPollSeconds == 0 {
+		return fmt.Errorf("GRPCWatchPollSeconds must be positive when GRPCListenAddress is given")
+	}
+	if this.SQLite3ReadPoolConnections < 0 {
+		return fmt.Errorf("SQLite3ReadPoolConnections must not be negative")
+	}
 	if this.RaftEnabled && this.RaftDataDir == "" {
 		return fmt.Errorf("RaftDataDir must be defined since raft is enabled (RaftEnabled)")
 	}
@@ -540,6 +1161,18 @@ func (this *Configuration) postReadAdjustments() error {
 	if this.RaftAdvertise == "" {
 		this.RaftAdvertise = this.RaftBind
 	}
+	for analysisCode, severity := range this.AnalysisSeverities {
+		switch severity {
+		case "critical", "warning", "info":
+		default:
+			return fmt.Errorf("AnalysisSeverities: invalid severity %q for %s; expecting critical, warning or info", severity, analysisCode)
+		}
+	}
+	switch this.RaftReadConsistency {
+	case "leader", "lease", "stale":
+	default:
+		return fmt.Errorf("RaftReadConsistency must be one of: leader, lease, stale. Got %q", this.RaftReadConsistency)
+	}
 	if this.KVClusterMasterPrefix != "/" {
 		// "/" remains "/"
 		// "prefix" turns to "prefix/"
@@ -547,6 +1180,10 @@ func (this *Configuration) postReadAdjustments() error {
 		this.KVClusterMasterPrefix = strings.TrimRight(this.KVClusterMasterPrefix, "/")
 		this.KVClusterMasterPrefix = fmt.Sprintf("%s/", this.KVClusterMasterPrefix)
 	}
+	if this.ReadPoolKVPrefix != "" && this.ReadPoolKVPrefix != "/" {
+		this.ReadPoolKVPrefix = strings.TrimRight(this.ReadPoolKVPrefix, "/")
+		this.ReadPoolKVPrefix = fmt.Sprintf("%s/", this.ReadPoolKVPrefix)
+	}
 	if this.AutoPseudoGTID {
 		this.PseudoGTIDPattern = "drop view if exists `_pseudo_gtid_`"
 		this.PseudoGTIDPatternIsFixedSubstring = true
@@ -571,6 +1208,22 @@ func (this *Configuration) postReadAdjustments() error {
 			return fmt.Errorf("If specified, HTTPAdvertise must not specify a path")
 		}
 	}
+	if this.FederationName != "" {
+		if this.FederationPollSeconds == 0 {
+			return fmt.Errorf("FederationPollSeconds must be positive when FederationName is given")
+		}
+		if _, found := this.FederationMembers[this.FederationName]; found {
+			return fmt.Errorf("FederationMembers must not list this member, %s", this.FederationName)
+		}
+		for pattern, owner := range this.FederationClusterOwners {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("Invalid FederationClusterOwners pattern %s: %+v", pattern, err)
+			}
+			if _, found := this.FederationMembers[owner]; !found && owner != this.FederationName {
+				return fmt.Errorf("FederationClusterOwners: unknown federation member %s", owner)
+			}
+		}
+	}
 	return nil
 }
 
@@ -578,17 +1231,54 @@ func (this *Configuration) IsSQLite() bool {
 	return strings.Contains(this.BackendDB, "sqlite")
 }
 
+func (this *Configuration) IsPostgreSQL() bool {
+	return this.BackendDB == "postgres" || this.BackendDB == "postgresql"
+}
+
 func (this *Configuration) IsMySQL() bool {
 	return this.BackendDB == "mysql" || this.BackendDB == ""
 }
 
+// IsAdaptiveInstancePolling checks whether instances are polled at intervals adapted to their stability
+func (this *Configuration) IsAdaptiveInstancePolling() bool {
+	return this.AdaptiveInstancePollMaxSeconds > this.InstancePollSeconds
+}
+
+// IsCanary checks whether this deployment only acts on a set of canary clusters, shadow-evaluating the rest
+func (this *Configuration) IsCanary() bool {
+	return len(this.CanaryClusterFilters) > 0
+}
+
+// checkConfiguration checks configuration files before they are read, logging warnings and bailing out on errors.
+// When invoked for the config-check command, files with errors are not read, and the command reports the errors.
+func checkConfiguration(fileNames ...string) (readable bool) {
+	configurationProblems = CheckConfigurationFiles(fileNames...)
+	for _, problem := range configurationProblems {
+		if problem.Warning {
+			log.Warning(problem.String())
+		}
+	}
+	if !HasConfigurationErrors(configurationProblems) {
+		return true
+	}
+	if RuntimeCLIFlags.ConfigCheck {
+		return false
+	}
+	for _, problem := range configurationProblems {
+		if !problem.Warning {
+			log.Error(problem.String())
+		}
+	}
+	log.Fatalf("Invalid configuration in %s; see `orchestrator -c config-check`", strings.Join(fileNames, ", "))
+	return false
+}
+
 // read reads configuration from given file, or silently skips if the file does not exist.
 // If the file does exist, then it is expected to be in valid JSON format or the function bails out.
 func read(fileName string) (*Configuration, error) {
-	file, err := os.Open(fileName)
+	_, err := os.Stat(fileName)
 	if err == nil {
-		decoder := json.NewDecoder(file)
-		err := decoder.Decode(Config)
+		err := readFileInto(fileName, Config, nil)
 		if err == nil {
 			log.Infof("Read config: %s", fileName)
 		} else {
@@ -604,27 +1294,28 @@ func read(fileName string) (*Configuration, error) {
 // Read reads configuration from zero, either, some or all given files, in order of input.
 // A file can override configuration provided in previous file.
 func Read(fileNames ...string) *Configuration {
+	readFileNames = fileNames
+	if !checkConfiguration(fileNames...) {
+		return Config
+	}
 	for _, fileName := range fileNames {
 		read(fileName)
 	}
-	readFileNames = fileNames
 	return Config
 }
 
 // ForceRead reads configuration from given file name or bails out if it fails
 func ForceRead(fileName string) *Configuration {
-	_, err := read(fileName)
-	if err != nil {
+	if _, err := os.Stat(fileName); err != nil {
 		log.Fatal("Cannot read config file:", fileName, err)
 	}
 	readFileNames = []string{fileName}
-	return Config
-}
-
-// Reload re-reads configuration from last used files
-func Reload() *Configuration {
-	for _, fileName := range readFileNames {
-		read(fileName)
+	if !checkConfiguration(fileName) {
+		return Config
+	}
+	_, err := read(fileName)
+	if err != nil {
+		log.Fatal("Cannot read config file:", fileName, err)
 	}
 	return Config
 }
@@ -0,0 +1,421 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ConfigurationProblem is an issue found in configuration: an error, which stops orchestrator from starting, or
+// a warning
+type ConfigurationProblem struct {
+	FileName string
+	Line     int
+	Setting  string
+	Message  string
+	Warning  bool
+}
+
+func (this ConfigurationProblem) String() string {
+	severity := "error"
+	if this.Warning {
+		severity = "warning"
+	}
+	location := this.FileName
+	if this.Line > 0 {
+		location = fmt.Sprintf("%s:%d", this.FileName, this.Line)
+	}
+	if this.Setting == "" {
+		return fmt.Sprintf("%s: %s: %s", location, severity, this.Message)
+	}
+	return fmt.Sprintf("%s: %s: %s: %s", location, severity, this.Setting, this.Message)
+}
+
+// configurationProblems are the problems found in the configuration files last read
+var configurationProblems []ConfigurationProblem
+
+// ConfigurationProblems returns the problems found in the configuration files last read
+func ConfigurationProblems() []ConfigurationProblem {
+	return configurationProblems
+}
+
+// HasConfigurationErrors checks whether any of given problems is an error
+func HasConfigurationErrors(problems []ConfigurationProblem) bool {
+	for _, problem := range problems {
+		if !problem.Warning {
+			return true
+		}
+	}
+	return false
+}
+
+// settingLocation is where in a configuration file a setting is given
+type settingLocation struct {
+	FileName string
+	Line     int
+}
+
+// lineAt returns the line number of given offset within data
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// editDistance is the Levenshtein distance between two strings, case insensitive
+func editDistance(a string, b string) int {
+	a, b = strings.ToLower(a), strings.ToLower(b)
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = current[j-1] + 1
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if previous[j-1]+cost < current[j] {
+				current[j] = previous[j-1] + cost
+			}
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
+
+// settingNames lists the names of all settings
+func settingNames() []string {
+	names := []string{}
+	configurationType := reflect.TypeOf(Configuration{})
+	for i := 0; i < configurationType.NumField(); i++ {
+		names = append(names, configurationType.Field(i).Name)
+	}
+	return names
+}
+
+// unknownSettingProblem describes a key which is not a setting, suggesting the closest setting name
+func unknownSettingProblem(key string, names []string) (message string, warning bool) {
+	for _, deprecated := range deprecatedConfigurationVariables {
+		if key == deprecated {
+			return "deprecated setting, ignored", true
+		}
+	}
+	closest := ""
+	closestDistance := 4
+	for _, name := range names {
+		if strings.EqualFold(name, key) {
+			return fmt.Sprintf("applied as %s, whose case differs", name), true
+		}
+		if distance := editDistance(name, key); distance < closestDistance {
+			closest, closestDistance = name, distance
+		}
+	}
+	if closest != "" {
+		return fmt.Sprintf("unknown setting; did you mean %s?", closest), false
+	}
+	return "unknown setting", false
+}
+
+// checkConfigurationFile checks a single file's JSON syntax, setting names and setting types, and notes where
+// each setting is given
+func checkConfigurationFile(fileName string, data []byte, locations map[string]settingLocation) (problems []ConfigurationProblem) {
+	var syntaxCheck map[string]json.RawMessage
+	if err := json.Unmarshal(data, &syntaxCheck); err != nil {
+		problem := ConfigurationProblem{FileName: fileName, Message: err.Error()}
+		if syntaxError, ok := err.(*json.SyntaxError); ok {
+			problem.Line = lineAt(data, syntaxError.Offset)
+		}
+		return append(problems, problem)
+	}
+	return checkSettings(fileName, data, 0, "", locations)
+}
+
+// checkSettings checks names and types of the settings of a JSON object, found at given offset of a file's data.
+// prefix is that of the settings of a profile, and is empty for a file's own settings, whose locations are noted.
+func checkSettings(fileName string, data []byte, start int64, prefix string, locations map[string]settingLocation) (problems []ConfigurationProblem) {
+	names := settingNames()
+	knownNames := make(map[string]bool)
+	for _, name := range names {
+		knownNames[name] = true
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data[start:]))
+	// The opening brace; syntax is known to be valid
+	decoder.Token()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		key, _ := token.(string)
+		line := lineAt(data, start+decoder.InputOffset())
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		valueStart := start + decoder.InputOffset() - int64(len(value))
+		if strings.HasPrefix(key, "#") {
+			// A comment
+			continue
+		}
+		if !knownNames[key] {
+			message, warning := unknownSettingProblem(key, names)
+			problems = append(problems, ConfigurationProblem{FileName: fileName, Line: line, Setting: prefix + key, Message: message, Warning: warning})
+			continue
+		}
+		if prefix != "" {
+			for _, profileSetting := range profileSettings {
+				if key == profileSetting {
+					problems = append(problems, ConfigurationProblem{FileName: fileName, Line: line, Setting: prefix + key, Message: "not allowed in a profile"})
+				}
+			}
+		} else {
+			locations[key] = settingLocation{FileName: fileName, Line: line}
+		}
+		setting := map[string]json.RawMessage{key: value}
+		settingJSON, _ := json.Marshal(setting)
+		if err := json.Unmarshal(settingJSON, newConfiguration()); err != nil {
+			if typeError, ok := err.(*json.UnmarshalTypeError); ok {
+				err = fmt.Errorf("expected %s, got JSON %s", typeError.Type, typeError.Value)
+			}
+			problems = append(problems, ConfigurationProblem{FileName: fileName, Line: line, Setting: prefix + key, Message: err.Error()})
+			continue
+		}
+		if key == "Profiles" && prefix == "" {
+			problems = append(problems, checkProfiles(fileName, data, valueStart)...)
+		}
+	}
+	return problems
+}
+
+// checkProfiles checks the settings of each profile in a Profiles object, found at given offset of a file's data
+func checkProfiles(fileName string, data []byte, start int64) (problems []ConfigurationProblem) {
+	decoder := json.NewDecoder(bytes.NewReader(data[start:]))
+	decoder.Token()
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		profile, _ := token.(string)
+		line := lineAt(data, start+decoder.InputOffset())
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+		var overlay map[string]json.RawMessage
+		if err := json.Unmarshal(value, &overlay); err != nil {
+			problems = append(problems, ConfigurationProblem{FileName: fileName, Line: line, Setting: "Profiles." + profile, Message: "expected a JSON object of settings"})
+			continue
+		}
+		valueStart := start + decoder.InputOffset() - int64(len(value))
+		problems = append(problems, checkSettings(fileName, data, valueStart, "Profiles."+profile+".", nil)...)
+	}
+	return problems
+}
+
+// checkConfigurationFileWithIncludes checks a configuration file after the files it includes, and reads them onto
+// given configuration. including lists the files whose includes led to this file. Checks stop at invalid JSON.
+func checkConfigurationFileWithIncludes(fileName string, including []string, locations map[string]settingLocation, checked *Configuration) (problems []ConfigurationProblem, valid bool) {
+	for _, includingFileName := range including {
+		if includingFileName == fileName {
+			problem := ConfigurationProblem{FileName: including[len(including)-1], Setting: "Include", Message: fmt.Sprintf("%s includes itself, via %s", fileName, strings.Join(including, ", "))}
+			return append(problems, problem), false
+		}
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		problem := ConfigurationProblem{FileName: including[len(including)-1], Setting: "Include", Message: err.Error()}
+		return append(problems, problem), false
+	}
+	if !json.Valid(data) {
+		return checkConfigurationFile(fileName, data, locations), false
+	}
+	// A wrong typed Include is reported as such by checkConfigurationFile
+	includes, _ := readIncludes(fileName, data)
+	for _, include := range includes {
+		includeProblems, valid := checkConfigurationFileWithIncludes(include, append(including, fileName), locations, checked)
+		problems = append(problems, includeProblems...)
+		if !valid {
+			return problems, false
+		}
+	}
+	problems = append(problems, checkConfigurationFile(fileName, data, locations)...)
+	json.Unmarshal(data, checked)
+	return problems, true
+}
+
+var settingWordRegexp = regexp.MustCompile("[A-Za-z0-9]+")
+
+// mentionedSettingLocation returns where the last setting mentioned in a message is given, so that a validation
+// error, e.g. on conflicting settings, points at a line
+func mentionedSettingLocation(message string, locations map[string]settingLocation) (location settingLocation) {
+	for _, word := range settingWordRegexp.FindAllString(message, -1) {
+		if mentioned, found := locations[word]; found && mentioned.Line > location.Line {
+			location = mentioned
+		}
+	}
+	return location
+}
+
+// regexpSettings are single regular expression settings
+var regexpSettings = []string{"DataCenterPattern", "RegionPattern", "PhysicalEnvironmentPattern", "RejectHostnameResolvePattern"}
+
+// regexpKeySettings are maps keyed by regular expressions
+var regexpKeySettings = []string{"ClusterNameToAlias", "PagerDutySeverities"}
+
+// checkRegexp reports a pattern which does not compile
+func checkRegexp(path string, pattern string) []string {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return []string{fmt.Sprintf("%s: invalid regular expression %q: %+v", path, pattern, err)}
+	}
+	return nil
+}
+
+// checkFilterRegexps reports patterns of cluster and hostname filters which do not compile. It looks into
+// nested policies, such as NotifyPolicies' ClusterFilters.
+func checkFilterRegexps(path string, name string, value reflect.Value) (messages []string) {
+	switch value.Kind() {
+	case reflect.Ptr:
+		if !value.IsNil() {
+			messages = append(messages, checkFilterRegexps(path, name, value.Elem())...)
+		}
+	case reflect.Struct:
+		for i := 0; i < value.NumField(); i++ {
+			fieldName := value.Type().Field(i).Name
+			messages = append(messages, checkFilterRegexps(path+"."+fieldName, fieldName, value.Field(i))...)
+		}
+	case reflect.Map:
+		keys := value.MapKeys()
+		for _, key := range keys {
+			messages = append(messages, checkFilterRegexps(fmt.Sprintf("%s[%v]", path, key.Interface()), name, value.MapIndex(key))...)
+		}
+	case reflect.Slice, reflect.Array:
+		if value.Type().Elem().Kind() == reflect.String {
+			for i := 0; i < value.Len(); i++ {
+				filter := value.Index(i).String()
+				elementPath := fmt.Sprintf("%s[%d]", path, i)
+				switch {
+				case strings.HasSuffix(name, "HostnameFilters"):
+					messages = append(messages, checkRegexp(elementPath, filter)...)
+				case strings.HasSuffix(name, "ClusterFilters"):
+					if filter == "*" || strings.HasPrefix(filter, "alias=") {
+						continue
+					}
+					messages = append(messages, checkRegexp(elementPath, strings.TrimPrefix(filter, "alias~="))...)
+				}
+			}
+			return messages
+		}
+		for i := 0; i < value.Len(); i++ {
+			messages = append(messages, checkFilterRegexps(fmt.Sprintf("%s[%d]", path, i), name, value.Index(i))...)
+		}
+	}
+	return messages
+}
+
+// checkRegexps reports regular expression settings of a configuration which do not compile, by setting
+func (this *Configuration) checkRegexps() map[string][]string {
+	result := make(map[string][]string)
+	value := reflect.ValueOf(this).Elem()
+	for _, setting := range regexpSettings {
+		if pattern := value.FieldByName(setting).String(); pattern != "" {
+			result[setting] = append(result[setting], checkRegexp(setting, pattern)...)
+		}
+	}
+	if this.PseudoGTIDPattern != "" && !this.PseudoGTIDPatternIsFixedSubstring {
+		result["PseudoGTIDPattern"] = append(result["PseudoGTIDPattern"], checkRegexp("PseudoGTIDPattern", this.PseudoGTIDPattern)...)
+	}
+	for _, setting := range regexpKeySettings {
+		for _, key := range value.FieldByName(setting).MapKeys() {
+			result[setting] = append(result[setting], checkRegexp(fmt.Sprintf("%s[%s]", setting, key.String()), key.String())...)
+		}
+	}
+	for i := 0; i < value.NumField(); i++ {
+		setting := value.Type().Field(i).Name
+		result[setting] = append(result[setting], checkFilterRegexps(setting, setting, value.Field(i))...)
+	}
+	return result
+}
+
+// CheckConfigurationFiles checks configuration files, as read in order on startup along with the files they include:
+// their syntax, unknown settings, setting types, profiles, conflicting settings and regular expressions. Missing
+// files are skipped.
+func CheckConfigurationFiles(fileNames ...string) (problems []ConfigurationProblem) {
+	problems = []ConfigurationProblem{}
+	locations := make(map[string]settingLocation)
+	checked := newConfiguration()
+	lastFileName := ""
+	for _, fileName := range fileNames {
+		if _, err := os.Stat(fileName); err != nil {
+			continue
+		}
+		lastFileName = fileName
+		fileProblems, valid := checkConfigurationFileWithIncludes(fileName, nil, locations, checked)
+		problems = append(problems, fileProblems...)
+		if !valid {
+			// Further checks would report on a partially read configuration
+			return problems
+		}
+	}
+	if lastFileName == "" {
+		return problems
+	}
+	if err := checked.postReadAdjustments(); err != nil {
+		location := mentionedSettingLocation(err.Error(), locations)
+		if location.FileName == "" {
+			location.FileName = lastFileName
+		}
+		problems = append(problems, ConfigurationProblem{FileName: location.FileName, Line: location.Line, Message: err.Error()})
+	}
+	regexpMessages := checked.checkRegexps()
+	settings := []string{}
+	for setting := range regexpMessages {
+		settings = append(settings, setting)
+	}
+	sort.Slice(settings, func(i, j int) bool {
+		if locations[settings[i]].Line == locations[settings[j]].Line {
+			return settings[i] < settings[j]
+		}
+		return locations[settings[i]].Line < locations[settings[j]].Line
+	})
+	for _, setting := range settings {
+		location, found := locations[setting]
+		if !found {
+			location = settingLocation{FileName: lastFileName}
+		}
+		for _, message := range regexpMessages[setting] {
+			problems = append(problems, ConfigurationProblem{FileName: location.FileName, Line: location.Line, Message: message})
+		}
+	}
+	return problems
+}
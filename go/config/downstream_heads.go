@@ -0,0 +1,36 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// Handling of downstream heads upon master failover, see DownstreamHeadsPolicy
+const (
+	DownstreamHeadsAlert   = "alert"
+	DownstreamHeadsRepoint = "repoint"
+)
+
+// validateDownstreamHeadsPolicy checks DownstreamHeadsPolicy names a known policy
+func (this *Configuration) validateDownstreamHeadsPolicy() error {
+	switch this.DownstreamHeadsPolicy {
+	case DownstreamHeadsAlert, DownstreamHeadsRepoint:
+		return nil
+	}
+	return fmt.Errorf("DownstreamHeadsPolicy must be %s or %s; got %s", DownstreamHeadsAlert, DownstreamHeadsRepoint, this.DownstreamHeadsPolicy)
+}
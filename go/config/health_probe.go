@@ -0,0 +1,59 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+const (
+	HealthProbeTCP   = "tcp"
+	HealthProbeHTTP  = "http"
+	HealthProbeAgent = "agent"
+)
+
+const defaultHealthProbeTimeoutSeconds = 3
+
+// HealthProbe is an auxiliary check of a master's health, beyond orchestrator's own MySQL connection.
+// When orchestrator finds a master dead, a probe that finds it healthy overrules that conclusion.
+type HealthProbe struct {
+	Type           string   // "tcp" (port check), "http" (health endpoint) or "agent" (MySQL status via orchestrator-agent)
+	ClusterFilters []string // clusters the probe applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Port           int      // "tcp": port to connect to; 0 for the master's MySQL port
+	URL            string   // "http": health endpoint; {host} and {port} are replaced with the master's. A 200 response means healthy
+	TimeoutSeconds uint     // time to wait for the probe; 0 for the default of 3 seconds
+}
+
+// validateHealthProbes checks probes are well defined, and applies default timeouts
+func (this *Configuration) validateHealthProbes() error {
+	for i := range this.MasterHealthProbes {
+		probe := &this.MasterHealthProbes[i]
+		switch probe.Type {
+		case HealthProbeTCP, HealthProbeAgent:
+		case HealthProbeHTTP:
+			if probe.URL == "" {
+				return fmt.Errorf("MasterHealthProbes: probe #%d is of type http and has no URL", i)
+			}
+		default:
+			return fmt.Errorf("MasterHealthProbes: probe #%d type must be any of: %q, %q, %q; got %q", i, HealthProbeTCP, HealthProbeHTTP, HealthProbeAgent, probe.Type)
+		}
+		if probe.TimeoutSeconds == 0 {
+			probe.TimeoutSeconds = defaultHealthProbeTimeoutSeconds
+		}
+	}
+	return nil
+}
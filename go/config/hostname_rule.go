@@ -0,0 +1,73 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var hostnameRuleNameRegexp = regexp.MustCompile("^[A-Za-z0-9_.-]+$")
+
+// HostnameRule normalizes the instance keys of matching hostnames: the first rule whose pattern matches a
+// hostname applies to it, before the hostname is resolved.
+type HostnameRule struct {
+	Name          string      // unique name of the rule
+	Pattern       string      // regexp matched against the hostname. Empty matches all hostnames
+	Rewrite       string      // replacement of the hostname's match of Pattern, e.g. "${1}.db.example.com". Empty keeps the hostname
+	ResolveMethod string      // overrides HostnameResolveMethod for matching hostnames: "none", "default", "cname" or "ip". Empty keeps HostnameResolveMethod
+	NormalizeIPv6 bool        // rewrite IPv6 addresses in canonical form: lowercase, compressed, no brackets nor zone
+	MapPorts      map[int]int // ports of matching instances to the ports they are known by, e.g. {"13306": 3306}
+}
+
+// Validate checks the rule is well defined
+func (this *HostnameRule) Validate() error {
+	if !hostnameRuleNameRegexp.MatchString(this.Name) {
+		return fmt.Errorf("Invalid hostname rule name %q: expecting alphanumeric name", this.Name)
+	}
+	if _, err := regexp.Compile(this.Pattern); err != nil {
+		return fmt.Errorf("Hostname rule %s: invalid Pattern: %+v", this.Name, err)
+	}
+	switch strings.ToLower(this.ResolveMethod) {
+	case "", "none", "default", "cname", "ip":
+	default:
+		return fmt.Errorf("Hostname rule %s: unknown ResolveMethod %q", this.Name, this.ResolveMethod)
+	}
+	for fromPort, toPort := range this.MapPorts {
+		if fromPort <= 0 || fromPort > 65535 || toPort <= 0 || toPort > 65535 {
+			return fmt.Errorf("Hostname rule %s: invalid port mapping %d:%d", this.Name, fromPort, toPort)
+		}
+	}
+	return nil
+}
+
+// validateHostnameRules checks rules are well defined and their names unique
+func (this *Configuration) validateHostnameRules() error {
+	names := map[string]bool{}
+	for i := range this.HostnameRules {
+		rule := &this.HostnameRules[i]
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("HostnameRules: %+v", err)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("HostnameRules: duplicate name %s", rule.Name)
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// MembershipRule declares the expected membership of matching clusters: how many replicas each data center holds,
+// and whether each holds a promotion candidate. orchestrator evaluates rules continuously, alerting on violations,
+// and warns ahead of a master promotion that would leave the cluster in violation.
+type MembershipRule struct {
+	Name                     string   // identifies the rule in violations and audits
+	ClusterFilters           []string // clusters the rule applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	DataCenters              []string // data centers the cluster is expected to span. Empty stands for the data centers of the cluster's instances
+	MinReplicasPerDataCenter uint     // minimum number of healthy replicas in each data center
+	CandidatePerDataCenter   bool     // when true, each data center must hold a healthy replica whose promotion rule is "must" or "prefer"
+}
+
+// validateMembershipRules checks each rule is named, uniquely, and requires something
+func (this *Configuration) validateMembershipRules() error {
+	names := make(map[string]bool)
+	for i, rule := range this.MembershipRules {
+		if rule.Name == "" {
+			return fmt.Errorf("MembershipRules: rule #%d has no Name", i)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("MembershipRules: duplicate rule name %s", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.MinReplicasPerDataCenter == 0 && !rule.CandidatePerDataCenter {
+			return fmt.Errorf("MembershipRules: rule %s requires neither MinReplicasPerDataCenter nor CandidatePerDataCenter", rule.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// NotifyPolicy governs failure detection notifications (OnFailureDetectionProcesses) and the escalation of
+// unacknowledged recoveries, on matching clusters and analyses.
+type NotifyPolicy struct {
+	ClusterFilters       []string // clusters the policy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Analysis             []string // analysis codes the policy applies to, e.g. "DeadMaster". Empty applies to all
+	DedupWindowMinutes   uint     // when > 0, a detection does not notify if the same analysis was detected on the same cluster within this window
+	SilenceDowntimed     bool     // when true, detections on downtimed instances do not notify
+	EscalateAfterMinutes uint     // when > 0, a recovery unacknowledged this long after it started runs EscalationProcesses
+	EscalationProcesses  []string // processes to execute on escalation. Same placeholders as PostFailoverProcesses
+}
+
+// AppliesToAnalysis checks whether the policy covers given analysis code
+func (this *NotifyPolicy) AppliesToAnalysis(analysis string) bool {
+	return analysisListed(this.Analysis, analysis)
+}
+
+// analysisListed checks whether an analysis code is in a policy's list; an empty list covers all codes
+func analysisListed(analysisList []string, analysis string) bool {
+	if len(analysisList) == 0 {
+		return true
+	}
+	for _, listed := range analysisList {
+		if listed == analysis {
+			return true
+		}
+	}
+	return false
+}
+
+// validateNotifyPolicies checks escalation settings come in pairs
+func (this *Configuration) validateNotifyPolicies() error {
+	for i, policy := range this.NotifyPolicies {
+		if policy.EscalateAfterMinutes > 0 && len(policy.EscalationProcesses) == 0 {
+			return fmt.Errorf("NotifyPolicies: policy #%d has EscalateAfterMinutes but no EscalationProcesses", i)
+		}
+		if policy.EscalateAfterMinutes == 0 && len(policy.EscalationProcesses) > 0 {
+			return fmt.Errorf("NotifyPolicies: policy #%d has EscalationProcesses but no EscalateAfterMinutes", i)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// PagerDuty incident keying, see PagerDutyDedupKey
+const (
+	PagerDutyDedupKeyAnalysis = "analysis"
+	PagerDutyDedupKeyRecovery = "recovery"
+)
+
+// pagerDutySeverities are the severities the PagerDuty Events API v2 accepts
+var pagerDutySeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// validatePagerDuty checks PagerDuty incident keying and severities
+func (this *Configuration) validatePagerDuty() error {
+	if this.PagerDutyRoutingKey == "" {
+		return nil
+	}
+	if this.PagerDutyEventsURL == "" {
+		return fmt.Errorf("PagerDutyEventsURL must be defined when PagerDutyRoutingKey is given")
+	}
+	switch this.PagerDutyDedupKey {
+	case PagerDutyDedupKeyAnalysis, PagerDutyDedupKeyRecovery:
+	default:
+		return fmt.Errorf("PagerDutyDedupKey must be %s or %s; got %s", PagerDutyDedupKeyAnalysis, PagerDutyDedupKeyRecovery, this.PagerDutyDedupKey)
+	}
+	if !pagerDutySeverities[this.PagerDutyDefaultSeverity] {
+		return fmt.Errorf("Invalid PagerDutyDefaultSeverity: %s; expecting critical, error, warning or info", this.PagerDutyDefaultSeverity)
+	}
+	for pattern, severity := range this.PagerDutySeverities {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("Invalid PagerDutySeverities pattern %s: %+v", pattern, err)
+		}
+		if !pagerDutySeverities[severity] {
+			return fmt.Errorf("Invalid PagerDutySeverities severity for %s: %s; expecting critical, error, warning or info", pattern, severity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// ProfileOverlays maps profile names to the settings a profile overlays, as JSON objects
+type ProfileOverlays map[string]json.RawMessage
+
+// profileSettings may not be overlaid by a profile
+var profileSettings = []string{"Include", "Profiles", "ActiveProfiles"}
+
+// includedFileName returns the path of a file included by another file: relative paths are relative to the
+// including file's directory
+func includedFileName(includingFileName string, fileName string) string {
+	if filepath.IsAbs(fileName) {
+		return fileName
+	}
+	return filepath.Join(filepath.Dir(includingFileName), fileName)
+}
+
+// readIncludes returns the files given file includes
+func readIncludes(fileName string, data []byte) ([]string, error) {
+	includes := struct{ Include []string }{}
+	if err := json.Unmarshal(data, &includes); err != nil {
+		return nil, fmt.Errorf("Cannot read config file %s: %+v", fileName, err)
+	}
+	fileNames := []string{}
+	for _, include := range includes.Include {
+		fileNames = append(fileNames, includedFileName(fileName, include))
+	}
+	return fileNames, nil
+}
+
+// readFileInto reads given configuration file onto given configuration, after the files it includes, so that
+// its own settings override theirs. including lists the files whose includes led to this file.
+func readFileInto(fileName string, target *Configuration, including []string) error {
+	for _, includingFileName := range including {
+		if includingFileName == fileName {
+			return fmt.Errorf("Config file %s includes itself, via %s", fileName, strings.Join(including, ", "))
+		}
+	}
+	data, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return err
+	}
+	includes, err := readIncludes(fileName, data)
+	if err != nil {
+		return err
+	}
+	for _, include := range includes {
+		if err := readFileInto(include, target, append(including, fileName)); err != nil {
+			return err
+		}
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("Cannot read config file %s: %+v", fileName, err)
+	}
+	return nil
+}
+
+// activeProfiles returns the profiles to overlay: those given by --profile, or else ActiveProfiles
+func (this *Configuration) activeProfiles() []string {
+	if RuntimeCLIFlags.Profile != nil && *RuntimeCLIFlags.Profile != "" {
+		return strings.Split(*RuntimeCLIFlags.Profile, ",")
+	}
+	return this.ActiveProfiles
+}
+
+// applyProfiles overlays the active profiles' settings, in order, onto the settings read from files
+func (this *Configuration) applyProfiles() error {
+	profiles, activeProfiles, include := this.Profiles, this.ActiveProfiles, this.Include
+	defer func() {
+		this.Profiles, this.ActiveProfiles, this.Include = profiles, activeProfiles, include
+	}()
+	for _, profile := range this.activeProfiles() {
+		profile = strings.TrimSpace(profile)
+		overlay, found := profiles[profile]
+		if !found {
+			return fmt.Errorf("Unknown profile %s in ActiveProfiles or --profile", profile)
+		}
+		if err := json.Unmarshal(overlay, this); err != nil {
+			return fmt.Errorf("Cannot apply profile %s: %+v", profile, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultPromotionCanaryTable          = "meta.orchestrator_canary"
+	defaultPromotionCanaryTimeoutSeconds = 30
+)
+
+// PromotionCanary is a canary write made on matching clusters once a master is promoted, through the production
+// access path (e.g. a proxy or VIP) rather than directly. The promotion is declared successful once the canary is
+// found written on the promoted master, and replicated to enough of its replicas.
+type PromotionCanary struct {
+	ClusterFilters []string // clusters the canary applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	DSN            string   // go-sql-driver/mysql DSN of the production access path, e.g. "app:secret@tcp({clusterAlias}-rw.example.com:3306)/". May use {clusterAlias} and {clusterName}
+	Table          string   // schema qualified table the canary is written into, created if missing. Defaults to "meta.orchestrator_canary"
+	MinReplicas    uint     // number of the promoted master's replicas the canary must replicate to
+	TimeoutSeconds uint     // time given to the canary to replicate. Defaults to 30
+	OnFailure      string   // one of "abort" (fail the recovery), "warn" (log and audit the failure) or "continue" (only record the step). Defaults to "abort"
+}
+
+// TableName returns the canary's table, as schema and table
+func (this *PromotionCanary) TableName() (schema string, table string) {
+	tableName := this.Table
+	if tableName == "" {
+		tableName = defaultPromotionCanaryTable
+	}
+	tokens := strings.SplitN(tableName, ".", 2)
+	return tokens[0], tokens[1]
+}
+
+// Timeout returns the number of seconds given to the canary to replicate
+func (this *PromotionCanary) Timeout() uint {
+	if this.TimeoutSeconds == 0 {
+		return defaultPromotionCanaryTimeoutSeconds
+	}
+	return this.TimeoutSeconds
+}
+
+// FailurePolicy returns the canary's effective OnFailure policy
+func (this *PromotionCanary) FailurePolicy() string {
+	if this.OnFailure == "" {
+		return PromotionCheckAbort
+	}
+	return this.OnFailure
+}
+
+// ClusterDSN returns the canary's DSN for given cluster
+func (this *PromotionCanary) ClusterDSN(clusterName string, clusterAlias string) string {
+	dsn := strings.Replace(this.DSN, "{clusterAlias}", clusterAlias, -1)
+	return strings.Replace(dsn, "{clusterName}", clusterName, -1)
+}
+
+// validatePromotionCanaries checks each canary has a DSN, a well formed table and a known failure policy
+func (this *Configuration) validatePromotionCanaries() error {
+	for i, canary := range this.PromotionCanaries {
+		if canary.DSN == "" {
+			return fmt.Errorf("PromotionCanaries: canary #%d has no DSN", i)
+		}
+		if canary.Table != "" {
+			if tokens := strings.Split(canary.Table, "."); len(tokens) != 2 || tokens[0] == "" || tokens[1] == "" || strings.Contains(canary.Table, "`") {
+				return fmt.Errorf("PromotionCanaries: canary #%d: Table must be of the form schema.table, got %q", i, canary.Table)
+			}
+		}
+		switch canary.FailurePolicy() {
+		case PromotionCheckAbort, PromotionCheckContinue, PromotionCheckWarn:
+		default:
+			return fmt.Errorf("PromotionCanaries: canary #%d has unknown OnFailure %q; expecting %s, %s or %s", i, canary.OnFailure, PromotionCheckAbort, PromotionCheckWarn, PromotionCheckContinue)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// Promotion check failure policies
+const (
+	PromotionCheckAbort    = "abort"
+	PromotionCheckContinue = "continue"
+	PromotionCheckWarn     = "warn"
+)
+
+// PromotionCheck is a SQL assertion run on a newly promoted master, on matching clusters, before the promotion is
+// declared successful and KV stores, cluster alias and post master failover hooks are applied.
+type PromotionCheck struct {
+	Name           string   // identifies the check in recovery steps. Defaults to the query
+	ClusterFilters []string // clusters the check applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Query          string   // holds when the first column of its first row is neither NULL, empty nor 0; a statement returning no rows (e.g. a canary write) holds when it succeeds
+	OnFailure      string   // one of "abort" (fail the recovery), "warn" (log and audit the failure) or "continue" (only record the step). Defaults to "abort"
+}
+
+// Description names the check in recovery steps
+func (this *PromotionCheck) Description() string {
+	if this.Name != "" {
+		return this.Name
+	}
+	return this.Query
+}
+
+// FailurePolicy returns the check's effective OnFailure policy
+func (this *PromotionCheck) FailurePolicy() string {
+	if this.OnFailure == "" {
+		return PromotionCheckAbort
+	}
+	return this.OnFailure
+}
+
+// validatePromotionChecks checks each check has a query and a known failure policy
+func (this *Configuration) validatePromotionChecks() error {
+	for i, check := range this.PromotionChecks {
+		if check.Query == "" {
+			return fmt.Errorf("PromotionChecks: check #%d has no Query", i)
+		}
+		switch check.FailurePolicy() {
+		case PromotionCheckAbort, PromotionCheckContinue, PromotionCheckWarn:
+		default:
+			return fmt.Errorf("PromotionChecks: check #%d has unknown OnFailure %q; expecting %s, %s or %s", i, check.OnFailure, PromotionCheckAbort, PromotionCheckWarn, PromotionCheckContinue)
+		}
+	}
+	return nil
+}
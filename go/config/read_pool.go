@@ -0,0 +1,35 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// validateReadPools checks read pools publish at intervals, and ProxySQL hostgroups come with a ProxySQL admin
+func (this *Configuration) validateReadPools() error {
+	if len(this.ReadPoolClusterFilters) == 0 {
+		return nil
+	}
+	if this.ReadPoolPublishIntervalSeconds == 0 {
+		return fmt.Errorf("ReadPoolPublishIntervalSeconds must be > 0 when ReadPoolClusterFilters are given")
+	}
+	if len(this.ReadPoolProxySQLHostgroups) > 0 && this.ProxySQLAdminAddress == "" {
+		return fmt.Errorf("ProxySQLAdminAddress must be defined when ReadPoolProxySQLHostgroups are given")
+	}
+	return nil
+}
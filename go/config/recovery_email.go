@@ -0,0 +1,48 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net"
+	"text/template"
+)
+
+// DefaultRecoveryEmailSubjectTemplate is the default RecoveryEmailSubjectTemplate
+const DefaultRecoveryEmailSubjectTemplate = `[orchestrator] {{if .Recovery.IsSuccessful}}Recovered{{else}}Failed recovering{{end}} {{.Recovery.AnalysisEntry.Analysis}} on {{.Recovery.AnalysisEntry.ClusterDetails.ClusterAlias}}`
+
+// validateRecoveryEmail checks SMTP settings and recovery email templates
+func (this *Configuration) validateRecoveryEmail() error {
+	if this.SMTPServer == "" {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(this.SMTPServer); err != nil {
+		return fmt.Errorf("SMTPServer must be host:port; got %s", this.SMTPServer)
+	}
+	if this.RecoveryEmailFrom == "" || len(this.RecoveryEmailTo) == 0 {
+		return fmt.Errorf("RecoveryEmailFrom and RecoveryEmailTo must be defined when SMTPServer is given")
+	}
+	if _, err := template.New("subject").Parse(this.RecoveryEmailSubjectTemplate); err != nil {
+		return fmt.Errorf("Invalid RecoveryEmailSubjectTemplate: %+v", err)
+	}
+	if this.RecoveryEmailBodyTemplateFile != "" {
+		if _, err := template.ParseFiles(this.RecoveryEmailBodyTemplateFile); err != nil {
+			return fmt.Errorf("Invalid RecoveryEmailBodyTemplateFile: %+v", err)
+		}
+	}
+	return nil
+}
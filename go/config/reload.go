@@ -0,0 +1,293 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// redactedValue stands for the value of a secret setting
+const redactedValue = "********"
+
+// restartRequiredSettings are read once on startup, e.g. to open listeners and backend connections or to set up
+// tickers. Changing these upon reload has no effect until restart.
+var restartRequiredSettings = map[string]bool{
+	"Debug":                                    true,
+	"EnableSyslog":                             true,
+	"ListenAddress":                            true,
+	"ListenSocket":                             true,
+	"GRPCListenAddress":                        true,
+	"HTTPAdvertise":                            true,
+	"AgentsServerPort":                         true,
+	"BackendDB":                                true,
+	"SkipOrchestratorDatabaseUpdate":           true,
+	"PanicIfDifferentDatabaseDeploy":           true,
+	"DefaultRaftPort":                          true,
+	"ExpectFailureAnalysisConcensus":           true,
+	"MySQLConnectTimeoutSeconds":               true,
+	"MySQLConnectionLifetimeSeconds":           true,
+	"InstancePollSeconds":                      true,
+	"InstanceWriteBufferSize":                  true,
+	"BufferInstanceWrites":                     true,
+	"DiscoveryMaxConcurrency":                  true,
+	"DiscoveryQueueCapacity":                   true,
+	"DiscoveryQueueMaxStatisticsSize":          true,
+	"DiscoveryPartitionBy":                     true,
+	"DiscoveryPartitionMaxConcurrency":         true,
+	"DiscoveryPartitionConcurrencyLimits":      true,
+	"AuditToSyslog":                            true,
+	"AuthenticationMethod":                     true,
+	"HTTPAuthUser":                             true,
+	"HTTPAuthPassword":                         true,
+	"AuthUserHeader":                           true,
+	"APIRateLimitRequestsPerSecond":            true,
+	"APIRateLimitBurst":                        true,
+	"ServeAgentsHttp":                          true,
+	"UseSSL":                                   true,
+	"UseMutualTLS":                             true,
+	"StatusEndpoint":                           true,
+	"StatusOUVerify":                           true,
+	"URLPrefix":                                true,
+	"ConsulAddress":                            true,
+	"ConsulAclToken":                           true,
+	"ConsulCrossDataCenterDistribution":        true,
+	"ZkAddress":                                true,
+	"ReadPoolClusterFilters":                   true,
+	"RetentionArchiveDirectory":                true,
+	"MySQLOrchestratorRecoveryPoolConnections": true,
+}
+
+// restartRequiredPrefixes and restartRequiredSuffixes match further restart-required settings by name
+var restartRequiredPrefixes = []string{"MySQLOrchestrator", "PostgreSQLOrchestrator", "SQLite3", "Raft", "SSL", "Agent", "OAuth", "OIDC", "Graphite", "Federation"}
+var restartRequiredSuffixes = []string{"IntervalSeconds", "IntervalMinutes", "IntervalHours", "IntervalMilliseconds", "PollSeconds"}
+
+// secretSettingSuffixes match settings whose values are not to be exposed
+var secretSettingSuffixes = []string{"Password", "Secret", "Token"}
+var secretSettings = map[string]bool{
+	"PagerDutyRoutingKey": true,
+	"CanaryIncumbentURL":  true,
+	"Profiles":            true,
+}
+
+// IsRestartRequiredSetting checks whether a change to given setting only applies once orchestrator restarts
+func IsRestartRequiredSetting(setting string) bool {
+	if restartRequiredSettings[setting] {
+		return true
+	}
+	for _, prefix := range restartRequiredPrefixes {
+		if strings.HasPrefix(setting, prefix) {
+			return true
+		}
+	}
+	for _, suffix := range restartRequiredSuffixes {
+		if strings.HasSuffix(setting, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretSetting checks whether a setting's value is to be redacted when exposed
+func isSecretSetting(setting string) bool {
+	if secretSettings[setting] {
+		return true
+	}
+	for _, suffix := range secretSettingSuffixes {
+		if strings.HasSuffix(setting, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// dsnCredentialsRegexp matches the credentials of a go-sql-driver/mysql DSN, e.g. "user:password@tcp(host:3306)/"
+var dsnCredentialsRegexp = regexp.MustCompile(`^([^:@/()]*):(.+)@([a-z0-9]*\(|/)`)
+
+// redactCredentials redacts the password of a URL's userinfo or of a DSN, if any
+func redactCredentials(value string) string {
+	if strings.Contains(value, "://") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			if _, hasPassword := u.User.Password(); hasPassword {
+				username := url.User(u.User.Username()).String()
+				u.User = nil
+				prefix := u.Scheme + "://"
+				return prefix + username + ":" + redactedValue + "@" + strings.TrimPrefix(u.String(), prefix)
+			}
+			return value
+		}
+	}
+	return dsnCredentialsRegexp.ReplaceAllString(value, "${1}:"+redactedValue+"@${3}")
+}
+
+// exposedValue returns a setting's value, redacted if secret and non-empty. Structs, slices and maps are
+// redacted recursively, by field name or map key; strings have credentials in URLs and DSNs redacted.
+func exposedValue(setting string, value reflect.Value) interface{} {
+	if isSecretSetting(setting) && !reflect.DeepEqual(value.Interface(), reflect.Zero(value.Type()).Interface()) {
+		return redactedValue
+	}
+	switch value.Kind() {
+	case reflect.String:
+		return redactCredentials(value.String())
+	case reflect.Ptr:
+		if value.IsNil() {
+			return nil
+		}
+		return exposedValue(setting, value.Elem())
+	case reflect.Struct:
+		fields := make(map[string]interface{})
+		for i := 0; i < value.NumField(); i++ {
+			if field := value.Type().Field(i); field.PkgPath == "" {
+				fields[field.Name] = exposedValue(field.Name, value.Field(i))
+			}
+		}
+		return fields
+	case reflect.Slice, reflect.Array:
+		if value.Kind() == reflect.Slice && value.IsNil() {
+			return value.Interface()
+		}
+		elements := make([]interface{}, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			elements[i] = exposedValue(setting, value.Index(i))
+		}
+		return elements
+	case reflect.Map:
+		if value.IsNil() || value.Type().Key().Kind() != reflect.String {
+			return value.Interface()
+		}
+		entries := make(map[string]interface{})
+		for _, key := range value.MapKeys() {
+			entries[key.String()] = exposedValue(key.String(), value.MapIndex(key))
+		}
+		return entries
+	}
+	return value.Interface()
+}
+
+// RedactedMap returns all settings by name, with secrets redacted
+func (this *Configuration) RedactedMap() map[string]interface{} {
+	settings := make(map[string]interface{})
+	value := reflect.ValueOf(this).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		setting := value.Type().Field(i).Name
+		settings[setting] = exposedValue(setting, value.Field(i))
+	}
+	return settings
+}
+
+// SettingChange is a setting whose value changed upon configuration reload. Secrets are redacted.
+type SettingChange struct {
+	Setting  string
+	Previous interface{}
+	Current  interface{}
+}
+
+// ReloadReport lists the settings changed upon configuration reload: those applied, and those which only apply
+// once orchestrator restarts
+type ReloadReport struct {
+	FileNames      []string
+	Applied        []SettingChange
+	PendingRestart []SettingChange
+}
+
+// diffConfigurations compares two configurations, setting by setting
+func diffConfigurations(previous *Configuration, current *Configuration) (applied []SettingChange, pendingRestart []SettingChange) {
+	applied = []SettingChange{}
+	pendingRestart = []SettingChange{}
+	previousValue := reflect.ValueOf(previous).Elem()
+	currentValue := reflect.ValueOf(current).Elem()
+	for i := 0; i < previousValue.NumField(); i++ {
+		if reflect.DeepEqual(previousValue.Field(i).Interface(), currentValue.Field(i).Interface()) {
+			continue
+		}
+		setting := previousValue.Type().Field(i).Name
+		change := SettingChange{
+			Setting:  setting,
+			Previous: exposedValue(setting, previousValue.Field(i)),
+			Current:  exposedValue(setting, currentValue.Field(i)),
+		}
+		if IsRestartRequiredSetting(setting) {
+			pendingRestart = append(pendingRestart, change)
+		} else {
+			applied = append(applied, change)
+		}
+	}
+	return applied, pendingRestart
+}
+
+// readInto reads given configuration file onto given configuration. Unlike read, it does not bail out on error.
+func readInto(fileName string, target *Configuration) error {
+	if _, err := os.Stat(fileName); os.IsNotExist(err) {
+		return nil
+	}
+	return readFileInto(fileName, target, nil)
+}
+
+// applySettings copies the given settings from one configuration onto another
+func applySettings(target *Configuration, source *Configuration, changes []SettingChange) {
+	targetValue := reflect.ValueOf(target).Elem()
+	sourceValue := reflect.ValueOf(source).Elem()
+	for _, change := range changes {
+		targetValue.FieldByName(change.Setting).Set(sourceValue.FieldByName(change.Setting))
+	}
+}
+
+// Reload re-reads configuration from last used files, onto defaults, as on startup. The new configuration is
+// validated first; when invalid, nothing changes. Changed settings which only apply on restart keep their running
+// values.
+func Reload() (*ReloadReport, error) {
+	problems := CheckConfigurationFiles(readFileNames...)
+	if HasConfigurationErrors(problems) {
+		errors := []string{}
+		for _, problem := range problems {
+			if !problem.Warning {
+				errors = append(errors, problem.String())
+			}
+		}
+		return nil, fmt.Errorf("Invalid configuration: %s", strings.Join(errors, "; "))
+	}
+	reloaded := newConfiguration()
+	for _, fileName := range readFileNames {
+		if err := readInto(fileName, reloaded); err != nil {
+			return nil, err
+		}
+	}
+	if err := reloaded.postReadAdjustments(); err != nil {
+		return nil, err
+	}
+	report := &ReloadReport{FileNames: readFileNames}
+	report.Applied, report.PendingRestart = diffConfigurations(Config, reloaded)
+	applySettings(Config, reloaded, report.Applied)
+	configurationProblems = problems
+	return report, nil
+}
+
+// String summarizes the changes
+func (this *ReloadReport) String() string {
+	settings := func(changes []SettingChange) string {
+		names := []string{}
+		for _, change := range changes {
+			names = append(names, change.Setting)
+		}
+		return strings.Join(names, ", ")
+	}
+	return fmt.Sprintf("applied: [%s]; pending restart: [%s]", settings(this.Applied), settings(this.PendingRestart))
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// Replica remediation actions
+const (
+	ReplicaRemedyRestart     = "restart"
+	ReplicaRemedySkip        = "skip"
+	ReplicaRemedyReclone     = "reclone"
+	ReplicaRemedyInjectEmpty = "inject-empty"
+	ReplicaRemedyResetMaster = "reset-master"
+)
+
+// ReplicaRemedy opts clusters into automated remediation of broken replicas: replicas whose SQL thread
+// stopped on an error (ReplicationStopped analysis) and replicas with errant GTID (ErrantGTIDReplica analysis).
+// Only listed error codes are remediated.
+type ReplicaRemedy struct {
+	ClusterFilters    []string // clusters the remedy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	RestartSQLErrnos  []int    // transient SQL thread errors (e.g. 1205, 1213) on which replication is restarted
+	SkipSQLErrnos     []int    // SQL thread errors (e.g. 1062) on which the failing statement is skipped
+	SkipCount         uint     // max statements skipped in one remediation, one at a time; 0 means 1
+	RecloneSQLErrnos  []int    // SQL thread errors on which the replica is rebuilt via ReplicaRebuildMethod
+	ErrantGTIDAction  string   // one of "inject-empty", "reset-master", "reclone"; empty leaves errant GTID alone
+	MaxActionsPerHour uint     // max remediations per replica per hour; 0 means 1
+}
+
+// SQLErrnoAction returns the action configured for given SQL thread error, or an empty string if none is
+func (this *ReplicaRemedy) SQLErrnoAction(errno int) string {
+	for _, action := range []string{ReplicaRemedyRestart, ReplicaRemedySkip, ReplicaRemedyReclone} {
+		for _, listedErrno := range this.sqlErrnos(action) {
+			if listedErrno == errno {
+				return action
+			}
+		}
+	}
+	return ""
+}
+
+// StatementsToSkip returns the max number of statements skipped in one remediation
+func (this *ReplicaRemedy) StatementsToSkip() uint {
+	if this.SkipCount == 0 {
+		return 1
+	}
+	return this.SkipCount
+}
+
+// ActionsPerHour returns the max number of remediations per replica per hour
+func (this *ReplicaRemedy) ActionsPerHour() uint {
+	if this.MaxActionsPerHour == 0 {
+		return 1
+	}
+	return this.MaxActionsPerHour
+}
+
+func (this *ReplicaRemedy) sqlErrnos(action string) []int {
+	switch action {
+	case ReplicaRemedyRestart:
+		return this.RestartSQLErrnos
+	case ReplicaRemedySkip:
+		return this.SkipSQLErrnos
+	case ReplicaRemedyReclone:
+		return this.RecloneSQLErrnos
+	}
+	return nil
+}
+
+// validateReplicaRemedies checks remedies name valid actions, and no error code under two actions
+func (this *Configuration) validateReplicaRemedies() error {
+	for i, remedy := range this.ReplicaRemedies {
+		switch remedy.ErrantGTIDAction {
+		case "", ReplicaRemedyInjectEmpty, ReplicaRemedyResetMaster, ReplicaRemedyReclone:
+		default:
+			return fmt.Errorf("ReplicaRemedies: remedy #%d has unknown ErrantGTIDAction %q; expecting %s, %s or %s", i, remedy.ErrantGTIDAction, ReplicaRemedyInjectEmpty, ReplicaRemedyResetMaster, ReplicaRemedyReclone)
+		}
+		if len(remedy.RestartSQLErrnos)+len(remedy.SkipSQLErrnos)+len(remedy.RecloneSQLErrnos) == 0 && remedy.ErrantGTIDAction == "" {
+			return fmt.Errorf("ReplicaRemedies: remedy #%d has no action", i)
+		}
+		actions := map[int]string{}
+		for _, action := range []string{ReplicaRemedyRestart, ReplicaRemedySkip, ReplicaRemedyReclone} {
+			for _, errno := range remedy.sqlErrnos(action) {
+				if errno <= 0 {
+					return fmt.Errorf("ReplicaRemedies: remedy #%d has invalid error code %d", i, errno)
+				}
+				if listedAction, found := actions[errno]; found && listedAction != action {
+					return fmt.Errorf("ReplicaRemedies: remedy #%d lists error code %d under both %s and %s", i, errno, listedAction, action)
+				}
+				actions[errno] = action
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Prefixes of credential settings' values which reference a secret kept outside the configuration file
+const (
+	secretReferenceEnv  = "env:"
+	secretReferenceFile = "file:"
+	secretReferenceExec = "exec:"
+)
+
+// secretCommandTimeout caps the time given to an exec: secret reference's command
+const secretCommandTimeout = 30 * time.Second
+
+// isCredentialSetting checks whether a setting's value may reference a secret
+func isCredentialSetting(setting string) bool {
+	return isSecretSetting(setting) || strings.HasSuffix(setting, "User")
+}
+
+// resolveSecretReference returns the secret a credential value references:
+// - "env:NAME" or "${NAME}": the value of environment variable NAME
+// - "file:/path": the content of the file, less trailing newlines
+// - "exec:command": the standard output of the command, run by sh, less trailing newlines
+// Any other value is returned as is.
+func resolveSecretReference(value string) (string, error) {
+	if submatch := envVariableRegexp.FindStringSubmatch(value); len(submatch) > 1 {
+		return os.Getenv(submatch[1]), nil
+	}
+	switch {
+	case strings.HasPrefix(value, secretReferenceEnv):
+		name := strings.TrimPrefix(value, secretReferenceEnv)
+		secret, found := os.LookupEnv(name)
+		if !found {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, secretReferenceFile):
+		content, err := ioutil.ReadFile(strings.TrimPrefix(value, secretReferenceFile))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(content), "\r\n"), nil
+	case strings.HasPrefix(value, secretReferenceExec):
+		command := strings.TrimPrefix(value, secretReferenceExec)
+		ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+		defer cancel()
+		var stdout, stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, "sh", "-c", command)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("%s: %+v %s", command, err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimRight(stdout.String(), "\r\n"), nil
+	}
+	return value, nil
+}
+
+// resolveSecretReferences replaces references in credential settings with the secrets they reference
+func (this *Configuration) resolveSecretReferences() error {
+	value := reflect.ValueOf(this).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		setting := value.Type().Field(i).Name
+		field := value.Field(i)
+		if field.Kind() != reflect.String || !isCredentialSetting(setting) {
+			continue
+		}
+		secret, err := resolveSecretReference(field.String())
+		if err != nil {
+			return fmt.Errorf("Cannot resolve %s: %+v", setting, err)
+		}
+		field.SetString(secret)
+	}
+	// A canary's DSN embeds its password
+	for i := range this.PromotionCanaries {
+		dsn, err := resolveSecretReference(this.PromotionCanaries[i].DSN)
+		if err != nil {
+			return fmt.Errorf("Cannot resolve PromotionCanaries: canary #%d DSN: %+v", i, err)
+		}
+		this.PromotionCanaries[i].DSN = dsn
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// Locked semi-sync master remediation actions
+const (
+	SemiSyncRemedyDisable  = "disable-semi-sync"
+	SemiSyncRemedyFailover = "failover"
+)
+
+// SemiSyncRemedy opts clusters into remediation of a master locked on semi-sync ACK waits (LockedSemiSyncMaster analysis)
+type SemiSyncRemedy struct {
+	ClusterFilters        []string // clusters the remedy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Action                string   // "disable-semi-sync": disable semi-sync on the master until semi-sync replicas return; "failover": fail over the master
+	RestoreTimeoutMinutes uint     // with "disable-semi-sync", how long to wait for semi-sync replicas to return before leaving semi-sync disabled; 0 means 60
+}
+
+// RestoreTimeout returns the number of minutes to wait for semi-sync replicas before giving up on restoring semi-sync
+func (this *SemiSyncRemedy) RestoreTimeout() uint {
+	if this.RestoreTimeoutMinutes == 0 {
+		return 60
+	}
+	return this.RestoreTimeoutMinutes
+}
+
+// validateSemiSyncRemedies checks remedies name a known action
+func (this *Configuration) validateSemiSyncRemedies() error {
+	for i, remedy := range this.SemiSyncRemedies {
+		switch remedy.Action {
+		case SemiSyncRemedyDisable, SemiSyncRemedyFailover:
+		default:
+			return fmt.Errorf("SemiSyncRemedies: remedy #%d has unknown Action %q; expecting %s or %s", i, remedy.Action, SemiSyncRemedyDisable, SemiSyncRemedyFailover)
+		}
+	}
+	return nil
+}
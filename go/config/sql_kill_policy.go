@@ -0,0 +1,80 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Stages of a demotion at which DemotedMasterKillPolicies apply
+const (
+	SQLKillStageBeforeReadOnly = "before-read-only"
+	SQLKillStageAfterReadOnly  = "after-read-only"
+)
+
+// SQLKillPolicy kills sessions on a demoted master, during a graceful takeover and when a failed over master is
+// set read-only: connections holding open write transactions, and long running queries. On a graceful takeover,
+// sessions are given a grace period to complete before being killed; a failed over master's are killed right away.
+type SQLKillPolicy struct {
+	ClusterFilters       []string // clusters the policy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Stage                string   // "after-read-only" (default) kills sessions once read_only is set; "before-read-only" kills them first, so that setting read_only does not wait on them
+	GracePeriodSeconds   uint     // time given to matching sessions to complete before they are killed, on a graceful takeover
+	KillOpenTransactions bool     // when true, connections holding an open transaction which modified rows are killed
+	LongQuerySeconds     uint     // queries running at least this long are killed. 0 does not kill queries
+	UserPattern          string   // regexp; only sessions of matching users are killed. Empty matches all users
+	ExcludeUserPattern   string   // regexp; sessions of matching users, e.g. monitoring, are never killed
+}
+
+// IsBeforeReadOnly checks whether this policy kills sessions ahead of setting read_only
+func (this *SQLKillPolicy) IsBeforeReadOnly() bool {
+	return this.Stage == SQLKillStageBeforeReadOnly
+}
+
+// MatchesUser checks whether sessions of given user are subject to this policy
+func (this *SQLKillPolicy) MatchesUser(user string) bool {
+	if this.ExcludeUserPattern != "" {
+		if matched, _ := regexp.MatchString(this.ExcludeUserPattern, user); matched {
+			return false
+		}
+	}
+	if this.UserPattern == "" {
+		return true
+	}
+	matched, _ := regexp.MatchString(this.UserPattern, user)
+	return matched
+}
+
+// validateDemotedMasterKillPolicies checks stages and patterns, and that each policy kills something
+func (this *Configuration) validateDemotedMasterKillPolicies() error {
+	for i, policy := range this.DemotedMasterKillPolicies {
+		switch policy.Stage {
+		case "", SQLKillStageBeforeReadOnly, SQLKillStageAfterReadOnly:
+		default:
+			return fmt.Errorf("DemotedMasterKillPolicies: policy #%d has unknown Stage %q; expecting %q or %q", i, policy.Stage, SQLKillStageBeforeReadOnly, SQLKillStageAfterReadOnly)
+		}
+		if !policy.KillOpenTransactions && policy.LongQuerySeconds == 0 {
+			return fmt.Errorf("DemotedMasterKillPolicies: policy #%d kills nothing; set KillOpenTransactions or LongQuerySeconds", i)
+		}
+		for _, pattern := range []string{policy.UserPattern, policy.ExcludeUserPattern} {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return fmt.Errorf("DemotedMasterKillPolicies: policy #%d: invalid pattern %q: %+v", i, pattern, err)
+			}
+		}
+	}
+	return nil
+}
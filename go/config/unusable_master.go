@@ -0,0 +1,71 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+)
+
+// Analyses of masters that are reachable, but unusable
+var unusableMasterAnalyses = []string{"TooManyConnectionsMaster", "ReadOnlyFilesystemMaster", "DiskFullMaster"}
+
+const defaultUnusableMasterMinDurationSeconds = 60
+
+// UnusablePolicy opts clusters into failing over masters found reachable but unusable: out of connections,
+// on a read-only filesystem, or out of disk space. Such masters are not failed over otherwise.
+type UnusablePolicy struct {
+	ClusterFilters     []string // clusters the policy applies to, as in RecoverMasterClusterFilters. Empty applies to all clusters
+	Analyses           []string // any of "TooManyConnectionsMaster", "ReadOnlyFilesystemMaster", "DiskFullMaster"; empty applies to all
+	MinDurationSeconds uint     // how long the condition must persist before failing over; 0 means 60
+}
+
+// AppliesToAnalysis checks whether this policy fails over given analysis
+func (this *UnusablePolicy) AppliesToAnalysis(analysis string) bool {
+	if len(this.Analyses) == 0 {
+		return true
+	}
+	for _, policyAnalysis := range this.Analyses {
+		if policyAnalysis == analysis {
+			return true
+		}
+	}
+	return false
+}
+
+// MinDuration returns the number of seconds the condition must persist before failing over
+func (this *UnusablePolicy) MinDuration() uint {
+	if this.MinDurationSeconds == 0 {
+		return defaultUnusableMasterMinDurationSeconds
+	}
+	return this.MinDurationSeconds
+}
+
+// validateUnusableMasterPolicies checks policies name known analyses
+func (this *Configuration) validateUnusableMasterPolicies() error {
+	for i, policy := range this.UnusableMasterPolicies {
+		for _, analysis := range policy.Analyses {
+			known := false
+			for _, unusableMasterAnalysis := range unusableMasterAnalyses {
+				known = known || analysis == unusableMasterAnalysis
+			}
+			if !known {
+				return fmt.Errorf("UnusableMasterPolicies: policy #%d has unknown analysis %q; expecting any of %v", i, analysis, unusableMasterAnalyses)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/rcrowley/go-metrics"
+)
+
+var backendPoolMaxOpenGauge = metrics.NewGauge()
+var backendPoolOpenGauge = metrics.NewGauge()
+var backendPoolInUseGauge = metrics.NewGauge()
+var backendPoolIdleGauge = metrics.NewGauge()
+var backendPoolWaitCountGauge = metrics.NewGauge()
+var backendPoolWaitMillisGauge = metrics.NewGauge()
+var backendPoolMaxIdleClosedGauge = metrics.NewGauge()
+var backendPoolMaxLifetimeClosedGauge = metrics.NewGauge()
+var backendErrorsCounter = metrics.NewCounter()
+
+// backendStatementFamilies are the families by which backend statements are timed
+var backendStatementFamilies = []string{"select", "insert", "update", "delete", "ddl", "other"}
+
+var backendStatementTimers = map[string]metrics.Timer{}
+var backendStatementErrorCounters = map[string]metrics.Counter{}
+
+func init() {
+	metrics.Register("backend.pool.max_open", backendPoolMaxOpenGauge)
+	metrics.Register("backend.pool.open", backendPoolOpenGauge)
+	metrics.Register("backend.pool.in_use", backendPoolInUseGauge)
+	metrics.Register("backend.pool.idle", backendPoolIdleGauge)
+	metrics.Register("backend.pool.wait_count", backendPoolWaitCountGauge)
+	metrics.Register("backend.pool.wait_millis", backendPoolWaitMillisGauge)
+	metrics.Register("backend.pool.max_idle_closed", backendPoolMaxIdleClosedGauge)
+	metrics.Register("backend.pool.max_lifetime_closed", backendPoolMaxLifetimeClosedGauge)
+	metrics.Register("backend.errors", backendErrorsCounter)
+	for _, family := range backendStatementFamilies {
+		backendStatementTimers[family] = metrics.NewTimer()
+		backendStatementErrorCounters[family] = metrics.NewCounter()
+		metrics.Register(fmt.Sprintf("backend.query.%s", family), backendStatementTimers[family])
+		metrics.Register(fmt.Sprintf("backend.query.%s.errors", family), backendStatementErrorCounters[family])
+	}
+}
+
+var backendPoolMetricsOnce sync.Once
+
+// getBackendStatementFamily classifies a statement by its leading keyword
+func getBackendStatementFamily(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "other"
+	}
+	switch keyword := strings.ToLower(fields[0]); keyword {
+	case "select", "insert", "update", "delete":
+		return keyword
+	case "replace":
+		return "insert"
+	case "create", "alter", "drop":
+		return "ddl"
+	}
+	return "other"
+}
+
+// auditBackendQuery times a statement executed on the backend database, by statement family, and counts errors
+func auditBackendQuery(query string, startTime time.Time, err error) error {
+	family := getBackendStatementFamily(query)
+	backendStatementTimers[family].UpdateSince(startTime)
+	if err != nil {
+		backendErrorsCounter.Inc(1)
+		backendStatementErrorCounters[family].Inc(1)
+	}
+	return err
+}
+
+// getOrchestratorMaxIdleConnections returns the number of idle connections to keep in the backend pool
+func getOrchestratorMaxIdleConnections() int {
+	if config.Config.MySQLOrchestratorMaxIdleConnections > 0 {
+		return config.Config.MySQLOrchestratorMaxIdleConnections
+	}
+	// A low value here will trigger reconnects which could
+	// make the number of backend connections hit the tcp
+	// limit. That's bad. Unless configured, allow up to 25%
+	// of MySQLOrchestratorMaxPoolConnections to be idle.
+	// That should provide a good number which does not keep
+	// the maximum number of connections open but at the same
+	// time does not trigger disconnections and reconnections
+	// too frequently.
+	maxIdleConns := int(config.Config.MySQLOrchestratorMaxPoolConnections * 25 / 100)
+	if maxIdleConns < 10 {
+		maxIdleConns = 10
+	}
+	return maxIdleConns
+}
+
+// getOrchestratorConnectionLifetime returns the time a backend connection is kept alive, or 0 for no limit
+func getOrchestratorConnectionLifetime() time.Duration {
+	if config.Config.MySQLOrchestratorConnectionLifetimeSeconds > 0 {
+		return time.Duration(config.Config.MySQLOrchestratorConnectionLifetimeSeconds) * time.Second
+	}
+	return time.Duration(config.Config.MySQLConnectionLifetimeSeconds) * time.Second
+}
+
+// collectBackendPoolMetrics periodically publishes the usage of the backend connection pool
+func collectBackendPoolMetrics(db *sql.DB) {
+	for range time.Tick(time.Second) {
+		stats := db.Stats()
+		backendPoolMaxOpenGauge.Update(int64(stats.MaxOpenConnections))
+		backendPoolOpenGauge.Update(int64(stats.OpenConnections))
+		backendPoolInUseGauge.Update(int64(stats.InUse))
+		backendPoolIdleGauge.Update(int64(stats.Idle))
+		backendPoolWaitCountGauge.Update(stats.WaitCount)
+		backendPoolWaitMillisGauge.Update(int64(stats.WaitDuration / time.Millisecond))
+		backendPoolMaxIdleClosedGauge.Update(stats.MaxIdleClosed)
+		backendPoolMaxLifetimeClosedGauge.Update(stats.MaxLifetimeClosed)
+	}
+}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// Cluster names used to be written as host:port, such that a master addressed by IPv6 made for an
+// unbracketed cluster name, e.g. 2001:db8::10:3306. Cluster names are now bracketed, e.g. [2001:db8::10]:3306.
+// These tables key per-cluster state by cluster name, and have it migrated to the bracketed form.
+var clusterNameTables = []string{
+	"database_instance",
+	"cluster_alias",
+	"cluster_alias_override",
+	"cluster_domain_name",
+	"cluster_injected_pseudo_gtid",
+	"topology_recovery",
+	"blocked_topology_recovery",
+	"scheduled_master_takeover",
+	"replica_provisioning_request",
+}
+
+// bracketedIPv6ClusterName returns the bracketed form of an unbracketed IPv6 host:port cluster name.
+// The second return value is false for any other name, which is left as is.
+func bracketedIPv6ClusterName(clusterName string) (string, bool) {
+	if strings.HasPrefix(clusterName, "[") {
+		return clusterName, false
+	}
+	i := strings.LastIndex(clusterName, ":")
+	if i < 0 {
+		return clusterName, false
+	}
+	host, port := clusterName[:i], clusterName[i+1:]
+	if !strings.Contains(host, ":") {
+		return clusterName, false
+	}
+	if _, err := strconv.Atoi(port); err != nil {
+		return clusterName, false
+	}
+	address := host
+	if zoneIndex := strings.Index(address, "%"); zoneIndex >= 0 {
+		address = address[:zoneIndex]
+	}
+	if net.ParseIP(address) == nil {
+		return clusterName, false
+	}
+	return net.JoinHostPort(host, port), true
+}
+
+// migrateIPv6ClusterNames rewrites unbracketed IPv6 cluster names to their bracketed form. A name that
+// cannot be rewritten, e.g. as its bracketed form already exists, is logged and left as is; such a cluster
+// is renamed anyway once its master is rediscovered.
+func migrateIPv6ClusterNames(db *sql.DB) error {
+	for _, tableName := range clusterNameTables {
+		query, err := translateStatement(fmt.Sprintf(`select distinct cluster_name from %s`, tableName))
+		if err != nil {
+			return log.Errore(err)
+		}
+		renames := make(map[string]string)
+		err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+			clusterName := m.GetString("cluster_name")
+			if bracketedName, ok := bracketedIPv6ClusterName(clusterName); ok {
+				renames[clusterName] = bracketedName
+			}
+			return nil
+		})
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		for clusterName, bracketedName := range renames {
+			query := fmt.Sprintf(`update %s set cluster_name=? where cluster_name=?`, tableName)
+			if _, err := execInternal(db, query, bracketedName, clusterName); err != nil {
+				log.Errorf("Cannot migrate cluster name %s to %s in %s: %+v", clusterName, bracketedName, tableName, err)
+				continue
+			}
+			log.Infof("Migrated cluster name %s to %s in %s", clusterName, bracketedName, tableName)
+		}
+	}
+	return nil
+}
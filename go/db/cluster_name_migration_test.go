@@ -0,0 +1,75 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/sqlutils"
+	test "github.com/openark/golib/tests"
+)
+
+func TestBracketedIPv6ClusterName(t *testing.T) {
+	tests := []struct {
+		clusterName   string
+		expectedName  string
+		expectRenamed bool
+	}{
+		{clusterName: "2001:db8::10:3306", expectedName: "[2001:db8::10]:3306", expectRenamed: true},
+		{clusterName: "fe80::1%eth0:3307", expectedName: "[fe80::1%eth0]:3307", expectRenamed: true},
+		{clusterName: "::ffff:10.0.0.1:3306", expectedName: "[::ffff:10.0.0.1]:3306", expectRenamed: true},
+		{clusterName: "[2001:db8::10]:3306", expectedName: "[2001:db8::10]:3306"},
+		{clusterName: "db1:3306", expectedName: "db1:3306"},
+		{clusterName: "10.0.0.1:3306", expectedName: "10.0.0.1:3306"},
+		{clusterName: "orders", expectedName: "orders"},
+		{clusterName: "2001:db8::10", expectedName: "2001:db8::10"},
+		{clusterName: "", expectedName: ""},
+	}
+	for _, tt := range tests {
+		bracketedName, renamed := bracketedIPv6ClusterName(tt.clusterName)
+		test.S(t).ExpectEquals(bracketedName, tt.expectedName)
+		test.S(t).ExpectEquals(renamed, tt.expectRenamed)
+	}
+}
+
+func TestMigrateIPv6ClusterNames(t *testing.T) {
+	backendDB, dataFile := config.Config.BackendDB, config.Config.SQLite3DataFile
+	defer func() {
+		config.Config.BackendDB, config.Config.SQLite3DataFile = backendDB, dataFile
+	}()
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = ":memory:"
+	db, err := OpenOrchestrator()
+	test.S(t).ExpectNil(err)
+
+	for _, clusterName := range []string{"2001:db8::10:3306", "db1:3306"} {
+		_, err = execInternal(db, `insert into cluster_alias (cluster_name, alias, last_registered) values (?, ?, now())`, clusterName, clusterName)
+		test.S(t).ExpectNil(err)
+	}
+	test.S(t).ExpectNil(migrateIPv6ClusterNames(db))
+
+	aliases := make(map[string]string)
+	err = sqlutils.QueryRowsMap(db, `select cluster_name, alias from cluster_alias`, func(m sqlutils.RowMap) error {
+		aliases[m.GetString("cluster_name")] = m.GetString("alias")
+		return nil
+	})
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(aliases), 2)
+	test.S(t).ExpectEquals(aliases["[2001:db8::10]:3306"], "2001:db8::10:3306")
+	test.S(t).ExpectEquals(aliases["db1:3306"], "db1:3306")
+}
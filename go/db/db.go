@@ -52,11 +52,10 @@ func getMySQLURI() string {
 	if mysqlURI != "" {
 		return mysqlURI
 	}
-	mysqlURI := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?timeout=%ds&readTimeout=%ds&interpolateParams=true",
+	mysqlURI := fmt.Sprintf("%s:%s@%s/%s?timeout=%ds&readTimeout=%ds&interpolateParams=true",
 		config.Config.MySQLOrchestratorUser,
 		config.Config.MySQLOrchestratorPassword,
-		config.Config.MySQLOrchestratorHost,
-		config.Config.MySQLOrchestratorPort,
+		mysqlAddress(config.Config.MySQLOrchestratorHost, int(config.Config.MySQLOrchestratorPort)),
 		config.Config.MySQLOrchestratorDatabase,
 		config.Config.MySQLConnectTimeoutSeconds,
 		config.Config.MySQLOrchestratorReadTimeoutSeconds,
@@ -79,11 +78,26 @@ func OpenTopology(host string, port int) (*sql.DB, error) {
 	return openTopology(host, port, config.Config.MySQLTopologyReadTimeoutSeconds)
 }
 
-func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error) {
-	mysql_uri := fmt.Sprintf("%s:%s@tcp(%s:%d)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
+// OpenTopologyProbe returns a DB instance with a single, dedicated connection to a topology instance.
+// Unlike OpenTopology, it does not reuse pooled connections; the caller must close it.
+func OpenTopologyProbe(host string, port int) (db *sql.DB, err error) {
+	mysql_uri, err := topologyURI(host, port, config.Config.MySQLDiscoveryReadTimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if db, err = sql.Open("mysql", mysql_uri); err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(0)
+	return db, nil
+}
+
+func topologyURI(host string, port int, readTimeout int) (mysql_uri string, err error) {
+	mysql_uri = fmt.Sprintf("%s:%s@%s/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
 		config.Config.MySQLTopologyUser,
 		config.Config.MySQLTopologyPassword,
-		host, port,
+		mysqlAddress(host, port),
 		config.Config.MySQLConnectTimeoutSeconds,
 		readTimeout,
 	)
@@ -91,9 +105,17 @@ func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error
 	if config.Config.MySQLTopologyUseMutualTLS ||
 		(config.Config.MySQLTopologyUseMixedTLS && requiresTLS(host, port, mysql_uri)) {
 		if mysql_uri, err = SetupMySQLTopologyTLS(mysql_uri); err != nil {
-			return nil, err
+			return "", err
 		}
 	}
+	return mysql_uri, nil
+}
+
+func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error) {
+	mysql_uri, err := topologyURI(host, port, readTimeout)
+	if err != nil {
+		return nil, err
+	}
 	if db, _, err = sqlutils.GetDB(mysql_uri); err != nil {
 		return nil, err
 	}
@@ -106,11 +128,10 @@ func openTopology(host string, port int, readTimeout int) (db *sql.DB, err error
 }
 
 func openOrchestratorMySQLGeneric() (db *sql.DB, fromCache bool, err error) {
-	uri := fmt.Sprintf("%s:%s@tcp(%s:%d)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
+	uri := fmt.Sprintf("%s:%s@%s/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
 		config.Config.MySQLOrchestratorUser,
 		config.Config.MySQLOrchestratorPassword,
-		config.Config.MySQLOrchestratorHost,
-		config.Config.MySQLOrchestratorPort,
+		mysqlAddress(config.Config.MySQLOrchestratorHost, int(config.Config.MySQLOrchestratorPort)),
 		config.Config.MySQLConnectTimeoutSeconds,
 		config.Config.MySQLOrchestratorReadTimeoutSeconds,
 	)
@@ -124,6 +145,10 @@ func IsSQLite() bool {
 	return config.Config.IsSQLite()
 }
 
+func IsPostgreSQL() bool {
+	return config.Config.IsPostgreSQL()
+}
+
 func isInMemorySQLite() bool {
 	return config.Config.IsSQLite() && strings.Contains(config.Config.SQLite3DataFile, ":memory:")
 }
@@ -136,8 +161,20 @@ func OpenOrchestrator() (db *sql.DB, err error) {
 		if err == nil && !fromCache {
 			log.Debugf("Connected to orchestrator backend: sqlite on %v", config.Config.SQLite3DataFile)
 		}
+		// A single writing connection; see sqlite.go
 		db.SetMaxOpenConns(1)
 		db.SetMaxIdleConns(1)
+		if err == nil && !fromCache {
+			for _, pragma := range []string{`PRAGMA journal_mode = WAL`, `PRAGMA synchronous = NORMAL`} {
+				if _, err := db.Exec(pragma); err != nil {
+					log.Errorf("OpenOrchestrator: sqlite %s failed: %+v", pragma, err)
+				}
+			}
+		}
+	} else if IsPostgreSQL() {
+		if db, fromCache, err = openOrchestratorPostgreSQL(); err != nil {
+			return db, log.Errore(err)
+		}
 	} else {
 		if db, fromCache, err := openOrchestratorMySQLGeneric(); err != nil {
 			return db, log.Errore(err)
@@ -151,15 +188,15 @@ func OpenOrchestrator() (db *sql.DB, err error) {
 		db, fromCache, err = sqlutils.GetDB(getMySQLURI())
 		if err == nil && !fromCache {
 			// do not show the password but do show what we connect to.
-			safeMySQLURI := fmt.Sprintf("%s:?@tcp(%s:%d)/%s?timeout=%ds", config.Config.MySQLOrchestratorUser,
-				config.Config.MySQLOrchestratorHost, config.Config.MySQLOrchestratorPort, config.Config.MySQLOrchestratorDatabase, config.Config.MySQLConnectTimeoutSeconds)
+			safeMySQLURI := fmt.Sprintf("%s:?@%s/%s?timeout=%ds", config.Config.MySQLOrchestratorUser,
+				mysqlAddress(config.Config.MySQLOrchestratorHost, int(config.Config.MySQLOrchestratorPort)), config.Config.MySQLOrchestratorDatabase, config.Config.MySQLConnectTimeoutSeconds)
 			log.Debugf("Connected to orchestrator backend: %v", safeMySQLURI)
 			if config.Config.MySQLOrchestratorMaxPoolConnections > 0 {
 				log.Debugf("Orchestrator pool SetMaxOpenConns: %d", config.Config.MySQLOrchestratorMaxPoolConnections)
 				db.SetMaxOpenConns(config.Config.MySQLOrchestratorMaxPoolConnections)
 			}
-			if config.Config.MySQLConnectionLifetimeSeconds > 0 {
-				db.SetConnMaxLifetime(time.Duration(config.Config.MySQLConnectionLifetimeSeconds) * time.Second)
+			if lifetime := getOrchestratorConnectionLifetime(); lifetime > 0 {
+				db.SetConnMaxLifetime(lifetime)
 			}
 		}
 	}
@@ -167,25 +204,20 @@ func OpenOrchestrator() (db *sql.DB, err error) {
 		if !config.Config.SkipOrchestratorDatabaseUpdate {
 			initOrchestratorDB(db)
 		}
-		// A low value here will trigger reconnects which could
-		// make the number of backend connections hit the tcp
-		// limit. That's bad.  I could make this setting dynamic
-		// but then people need to know which value to use. For now
-		// allow up to 25% of MySQLOrchestratorMaxPoolConnections
-		// to be idle.  That should provide a good number which
-		// does not keep the maximum number of connections open but
-		// at the same time does not trigger disconnections and
-		// reconnections too frequently.
-		maxIdleConns := int(config.Config.MySQLOrchestratorMaxPoolConnections * 25 / 100)
-		if maxIdleConns < 10 {
-			maxIdleConns = 10
+		if !IsSQLite() {
+			// SQLite keeps its single writing connection
+			maxIdleConns := getOrchestratorMaxIdleConnections()
+			log.Infof("Connecting to backend %s:%d: maxConnections: %d, maxIdleConns: %d",
+				config.Config.MySQLOrchestratorHost,
+				config.Config.MySQLOrchestratorPort,
+				config.Config.MySQLOrchestratorMaxPoolConnections,
+				maxIdleConns)
+			db.SetMaxIdleConns(maxIdleConns)
 		}
-		log.Infof("Connecting to backend %s:%d: maxConnections: %d, maxIdleConns: %d",
-			config.Config.MySQLOrchestratorHost,
-			config.Config.MySQLOrchestratorPort,
-			config.Config.MySQLOrchestratorMaxPoolConnections,
-			maxIdleConns)
-		db.SetMaxIdleConns(maxIdleConns)
+		// Pool metrics are collected for all backends; with SQLite they describe the writing connection
+		backendPoolMetricsOnce.Do(func() {
+			go collectBackendPoolMetrics(db)
+		})
 	}
 	return db, err
 }
@@ -194,6 +226,9 @@ func translateStatement(statement string) (string, error) {
 	if IsSQLite() {
 		statement = sqlutils.ToSqlite3Dialect(statement)
 	}
+	if IsPostgreSQL() {
+		statement = toPostgresDialect(statement)
+	}
 	return statement, nil
 }
 
@@ -207,6 +242,9 @@ func versionIsDeployed(db *sql.DB) (result bool, err error) {
 		where
 			deployed_version = ?
 		`
+	if query, err = translateStatement(query); err != nil {
+		return result, err
+	}
 	err = db.QueryRow(query, config.RuntimeCLIFlags.ConfiguredVersion).Scan(&result)
 	// err means the table 'orchestrator_db_deployments' does not even exist, in which case we proceed
 	// to deploy.
@@ -264,7 +302,18 @@ func deployStatements(db *sql.DB, queries []string) error {
 		if err != nil {
 			return log.Fatalf("Cannot initiate orchestrator: %+v; query=%+v", err, query)
 		}
+		if IsPostgreSQL() {
+			// A failed statement aborts a PostgreSQL transaction, unless rolled back to a savepoint
+			if _, err := tx.Exec(`savepoint orchestrator_deploy`); err != nil {
+				log.Fatale(err)
+			}
+		}
 		if _, err := tx.Exec(query); err != nil {
+			if IsPostgreSQL() {
+				if _, err := tx.Exec(`rollback to savepoint orchestrator_deploy`); err != nil {
+					log.Fatale(err)
+				}
+			}
 			if strings.Contains(err.Error(), "syntax error") {
 				return log.Fatalf("Cannot initiate orchestrator: %+v; query=%+v", err, query)
 			}
@@ -307,30 +356,27 @@ func initOrchestratorDB(db *sql.DB) error {
 	log.Debugf("Migrating database schema")
 	deployStatements(db, generateSQLBase)
 	deployStatements(db, generateSQLPatches)
+	migrateIPv6ClusterNames(db)
 	registerOrchestratorDeployment(db)
 
-	if IsSQLite() {
-		ExecOrchestrator(`PRAGMA journal_mode = WAL`)
-		ExecOrchestrator(`PRAGMA synchronous = NORMAL`)
-	}
-
 	return nil
 }
 
 // execInternal
-func execInternal(db *sql.DB, query string, args ...interface{}) (sql.Result, error) {
-	var err error
+func execInternal(db *sql.DB, query string, args ...interface{}) (res sql.Result, err error) {
 	query, err = translateStatement(query)
 	if err != nil {
 		return nil, err
 	}
-	res, err := sqlutils.ExecNoPrepare(db, query, args...)
-	return res, err
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
+	if IsPostgreSQL() {
+		return execPostgres(db, query, args...)
+	}
+	return sqlutils.ExecNoPrepare(db, query, args...)
 }
 
 // ExecOrchestrator will execute given query on the orchestrator backend database.
-func ExecOrchestrator(query string, args ...interface{}) (sql.Result, error) {
-	var err error
+func ExecOrchestrator(query string, args ...interface{}) (res sql.Result, err error) {
 	query, err = translateStatement(query)
 	if err != nil {
 		return nil, err
@@ -339,59 +385,82 @@ func ExecOrchestrator(query string, args ...interface{}) (sql.Result, error) {
 	if err != nil {
 		return nil, err
 	}
-	res, err := sqlutils.ExecNoPrepare(db, query, args...)
-	return res, err
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
+	if IsPostgreSQL() {
+		return execPostgres(db, query, args...)
+	}
+	res, err = sqlutils.ExecNoPrepare(db, query, args...)
+	return res, auditSQLiteBusy(err)
+}
+
+// ExecOrchestratorTx will execute given query within a transaction on the orchestrator backend database.
+func ExecOrchestratorTx(tx *sql.Tx, query string, args ...interface{}) (res sql.Result, err error) {
+	query, err = translateStatement(query)
+	if err != nil {
+		return nil, err
+	}
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
+	if IsPostgreSQL() {
+		return execPostgres(tx, query, args...)
+	}
+	return tx.Exec(query, args...)
 }
 
 // QueryRowsMapOrchestrator
-func QueryOrchestratorRowsMap(query string, on_row func(sqlutils.RowMap) error) error {
-	query, err := translateStatement(query)
+func QueryOrchestratorRowsMap(query string, on_row func(sqlutils.RowMap) error) (err error) {
+	query, err = translateStatement(query)
 	if err != nil {
 		return log.Fatalf("Cannot query orchestrator: %+v; query=%+v", err, query)
 	}
-	db, err := OpenOrchestrator()
+	db, err := OpenOrchestratorReader()
 	if err != nil {
 		return err
 	}
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
 
 	return sqlutils.QueryRowsMap(db, query, on_row)
 }
 
 // QueryOrchestrator
-func QueryOrchestrator(query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) error {
-	query, err := translateStatement(query)
+func QueryOrchestrator(query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) (err error) {
+	query, err = translateStatement(query)
 	if err != nil {
 		return log.Fatalf("Cannot query orchestrator: %+v; query=%+v", err, query)
 	}
-	db, err := OpenOrchestrator()
+	db, err := OpenOrchestratorReader()
 	if err != nil {
 		return err
 	}
 
+	if IsPostgreSQL() {
+		argsArray = toPostgresArgs(argsArray)
+	}
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
 	return log.Criticale(sqlutils.QueryRowsMap(db, query, on_row, argsArray...))
 }
 
 // QueryOrchestratorRowsMapBuffered
-func QueryOrchestratorRowsMapBuffered(query string, on_row func(sqlutils.RowMap) error) error {
-	query, err := translateStatement(query)
+func QueryOrchestratorRowsMapBuffered(query string, on_row func(sqlutils.RowMap) error) (err error) {
+	query, err = translateStatement(query)
 	if err != nil {
 		return log.Fatalf("Cannot query orchestrator: %+v; query=%+v", err, query)
 	}
-	db, err := OpenOrchestrator()
+	db, err := OpenOrchestratorReader()
 	if err != nil {
 		return err
 	}
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
 
 	return sqlutils.QueryRowsMapBuffered(db, query, on_row)
 }
 
 // QueryOrchestratorBuffered
-func QueryOrchestratorBuffered(query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) error {
-	query, err := translateStatement(query)
+func QueryOrchestratorBuffered(query string, argsArray []interface{}, on_row func(sqlutils.RowMap) error) (err error) {
+	query, err = translateStatement(query)
 	if err != nil {
 		return log.Fatalf("Cannot query orchestrator: %+v; query=%+v", err, query)
 	}
-	db, err := OpenOrchestrator()
+	db, err := OpenOrchestratorReader()
 	if err != nil {
 		return err
 	}
@@ -399,6 +468,10 @@ func QueryOrchestratorBuffered(query string, argsArray []interface{}, on_row fun
 	if argsArray == nil {
 		argsArray = EmptyArgs
 	}
+	if IsPostgreSQL() {
+		argsArray = toPostgresArgs(argsArray)
+	}
+	defer func(startTime time.Time) { auditBackendQuery(query, startTime, err) }(time.Now())
 	return log.Criticale(sqlutils.QueryRowsMapBuffered(db, query, on_row, argsArray...))
 }
 
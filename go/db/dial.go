@@ -0,0 +1,88 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/go-sql-driver/mysql"
+)
+
+// preferredIPNetwork is the network of MySQL connections dialed per ConnectIPPreference
+const preferredIPNetwork = "orchestrator-tcp"
+
+func init() {
+	mysql.RegisterDial(preferredIPNetwork, dialPreferredIP)
+}
+
+// sortIPAddrsByPreference orders addresses of the preferred family first, otherwise keeping the resolver's order
+func sortIPAddrsByPreference(addrs []net.IPAddr, preference string) []net.IPAddr {
+	if preference == "" {
+		return addrs
+	}
+	preferred := []net.IPAddr{}
+	others := []net.IPAddr{}
+	for _, addr := range addrs {
+		isIPv4 := addr.IP.To4() != nil
+		if (preference == config.IPPreferenceIPv4) == isIPv4 {
+			preferred = append(preferred, addr)
+		} else {
+			others = append(others, addr)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// dialPreferredIP dials a MySQL host's addresses of the family per ConnectIPPreference first, then the others
+func dialPreferredIP(address string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(config.Config.MySQLConnectTimeoutSeconds) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("%s resolved but no IP found", host)
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	for _, addr := range sortIPAddrsByPreference(addrs, config.Config.ConnectIPPreference) {
+		var conn net.Conn
+		if conn, err = dialer.Dial("tcp", net.JoinHostPort(addr.String(), port)); err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// mysqlAddress returns the network and address of a MySQL host, as in a DSN: "tcp(host:port)", with IPv6
+// addresses bracketed
+func mysqlAddress(host string, port int) string {
+	network := "tcp"
+	if config.Config.ConnectIPPreference != "" {
+		network = preferredIPNetwork
+	}
+	return fmt.Sprintf("%s(%s)", network, net.JoinHostPort(host, fmt.Sprintf("%d", port)))
+}
@@ -0,0 +1,62 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"net"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestSortIPAddrsByPreference(t *testing.T) {
+	addrs := []net.IPAddr{
+		{IP: net.ParseIP("2001:db8::1")},
+		{IP: net.ParseIP("10.0.0.1")},
+		{IP: net.ParseIP("fe80::1"), Zone: "eth0"},
+		{IP: net.ParseIP("10.0.0.2")},
+	}
+	{
+		sorted := sortIPAddrsByPreference(addrs, "")
+		test.S(t).ExpectEquals(sorted[0].String(), "2001:db8::1")
+		test.S(t).ExpectEquals(sorted[1].String(), "10.0.0.1")
+	}
+	{
+		sorted := sortIPAddrsByPreference(addrs, config.IPPreferenceIPv4)
+		test.S(t).ExpectEquals(sorted[0].String(), "10.0.0.1")
+		test.S(t).ExpectEquals(sorted[1].String(), "10.0.0.2")
+		test.S(t).ExpectEquals(sorted[2].String(), "2001:db8::1")
+		test.S(t).ExpectEquals(sorted[3].String(), "fe80::1%eth0")
+	}
+	{
+		sorted := sortIPAddrsByPreference(addrs, config.IPPreferenceIPv6)
+		test.S(t).ExpectEquals(sorted[0].String(), "2001:db8::1")
+		test.S(t).ExpectEquals(sorted[1].String(), "fe80::1%eth0")
+		test.S(t).ExpectEquals(sorted[2].String(), "10.0.0.1")
+	}
+}
+
+func TestMySQLAddress(t *testing.T) {
+	defer func() { config.Config.ConnectIPPreference = "" }()
+
+	test.S(t).ExpectEquals(mysqlAddress("db1", 3306), "tcp(db1:3306)")
+	test.S(t).ExpectEquals(mysqlAddress("10.0.0.1", 3306), "tcp(10.0.0.1:3306)")
+	test.S(t).ExpectEquals(mysqlAddress("2001:db8::1", 3306), "tcp([2001:db8::1]:3306)")
+	config.Config.ConnectIPPreference = config.IPPreferenceIPv6
+	test.S(t).ExpectEquals(mysqlAddress("db1", 3306), "orchestrator-tcp(db1:3306)")
+}
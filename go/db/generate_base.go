@@ -838,4 +838,373 @@ var generateSQLBase = []string{
 	`
 		CREATE INDEX tag_name_idx_database_instance_tags ON database_instance_tags (tag_name)
 	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_template (
+			cluster_alias varchar(128) CHARACTER SET ascii NOT NULL,
+			template_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			assigned_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_alias)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_change (
+			change_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			change_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			field_name varchar(64) CHARACTER SET ascii NOT NULL,
+			before_value text CHARACTER SET utf8 NOT NULL,
+			after_value text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (change_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX change_timestamp_idx_database_instance_change ON database_instance_change (change_timestamp)
+	`,
+	`
+		CREATE INDEX host_port_idx_database_instance_change ON database_instance_change (hostname, port, change_timestamp)
+	`,
+	`
+		CREATE INDEX cluster_name_idx_database_instance_change ON database_instance_change (cluster_name, change_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS canary_shadow_decision (
+			decision_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			analysis varchar(128) CHARACTER SET ascii NOT NULL,
+			is_actionable tinyint unsigned NOT NULL DEFAULT '0',
+			would_recover tinyint unsigned NOT NULL DEFAULT '0',
+			app_version varchar(64) CHARACTER SET ascii NOT NULL DEFAULT '',
+			processing_node_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			decision_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (decision_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX decision_timestamp_idx_canary_shadow_decision ON canary_shadow_decision (decision_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS database_instance_no_touch (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			lock_owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			lock_reason text CHARACTER SET utf8 NOT NULL,
+			lock_begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			lock_end_timestamp timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS fleet_report (
+			report_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			generated_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			report_trigger varchar(32) CHARACTER SET ascii NOT NULL,
+			processing_node_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			count_instances int unsigned NOT NULL DEFAULT 0,
+			count_clusters int unsigned NOT NULL DEFAULT 0,
+			count_gtid_instances int unsigned NOT NULL DEFAULT 0,
+			count_semi_sync_instances int unsigned NOT NULL DEFAULT 0,
+			count_constraint_violations int unsigned NOT NULL DEFAULT 0,
+			report_details mediumtext CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (report_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX generated_timestamp_idx_fleet_report ON fleet_report (generated_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS topology_recovery_journal (
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			journal_step varchar(64) CHARACTER SET ascii NOT NULL,
+			journal_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			step_details text CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (recovery_uid, journal_step)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX journal_timestamp_idx_topology_recovery_journal ON topology_recovery_journal (journal_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS postponed_function (
+			uid varchar(128) CHARACTER SET ascii NOT NULL,
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			description text CHARACTER SET utf8 NOT NULL,
+			status varchar(32) CHARACTER SET ascii NOT NULL,
+			error_message text CHARACTER SET utf8 NOT NULL,
+			processing_node_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			processing_node_token varchar(128) NOT NULL,
+			added_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			completed_timestamp timestamp NULL DEFAULT NULL,
+			PRIMARY KEY (uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX recovery_uid_idx_postponed_function ON postponed_function (recovery_uid)
+	`,
+	`
+		CREATE INDEX status_idx_postponed_function ON postponed_function (status, added_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_priority_tier (
+			cluster_alias varchar(128) CHARACTER SET ascii NOT NULL,
+			priority_tier varchar(16) CHARACTER SET ascii NOT NULL,
+			assigned_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_alias)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_master_flapping_acknowledgement (
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			acknowledged_by varchar(128) CHARACTER SET utf8 NOT NULL,
+			acknowledged_comment text CHARACTER SET utf8 NOT NULL,
+			acknowledged_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_alias)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS scheduled_master_takeover (
+			takeover_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			designated_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			designated_port smallint unsigned NOT NULL,
+			window_start timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			window_end timestamp NOT NULL DEFAULT '1971-01-01 00:00:00',
+			scheduled_by varchar(128) CHARACTER SET utf8 NOT NULL,
+			scheduled_comment text CHARACTER SET utf8 NOT NULL,
+			scheduled_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			status varchar(32) CHARACTER SET ascii NOT NULL,
+			status_message text CHARACTER SET utf8 NOT NULL,
+			status_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (takeover_uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX status_idx_scheduled_master_takeover ON scheduled_master_takeover (status, window_start)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replica_provisioning_request (
+			request_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			remaining_replicas int unsigned NOT NULL,
+			min_replicas int unsigned NOT NULL,
+			requested_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			status varchar(32) CHARACTER SET ascii NOT NULL,
+			status_message text CHARACTER SET utf8 NOT NULL,
+			status_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (request_uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_alias_idx_replica_provisioning_request ON replica_provisioning_request (cluster_alias, status)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replica_rebuild (
+			rebuild_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			target_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			target_port smallint unsigned NOT NULL,
+			source_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			source_port smallint unsigned NOT NULL,
+			rebuild_method varchar(32) CHARACTER SET ascii NOT NULL,
+			seed_id bigint unsigned NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			start_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			status varchar(32) CHARACTER SET ascii NOT NULL,
+			status_message text CHARACTER SET utf8 NOT NULL,
+			status_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (rebuild_uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX target_idx_replica_rebuild ON replica_rebuild (target_hostname, target_port, status)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS downtime_schedule (
+			schedule_id varchar(128) CHARACTER SET ascii NOT NULL,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint unsigned NOT NULL,
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			weekdays varchar(32) CHARACTER SET ascii NOT NULL,
+			start_time varchar(8) CHARACTER SET ascii NOT NULL,
+			duration_seconds int unsigned NOT NULL,
+			extend_while varchar(32) CHARACTER SET ascii NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			created_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (schedule_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_maintenance (
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			end_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_alias)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS topology_recovery_topology (
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			snapshot_phase varchar(16) CHARACTER SET ascii NOT NULL,
+			snapshot_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			instances mediumtext CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (recovery_uid, snapshot_phase)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX snapshot_timestamp_idx_topology_recovery_topology ON topology_recovery_topology (snapshot_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS cluster_topology_snapshot (
+			snapshot_id bigint unsigned not null auto_increment,
+			cluster_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			snapshot_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			master_host varchar(128) CHARACTER SET ascii NOT NULL,
+			master_port smallint unsigned NOT NULL,
+			count_instances int unsigned NOT NULL,
+			instances mediumtext CHARACTER SET utf8 NOT NULL,
+			PRIMARY KEY (snapshot_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_name_idx_cluster_topology_snapshot ON cluster_topology_snapshot (cluster_name, snapshot_timestamp)
+	`,
+	`
+		CREATE INDEX cluster_alias_idx_cluster_topology_snapshot ON cluster_topology_snapshot (cluster_alias, snapshot_timestamp)
+	`,
+	`
+		CREATE INDEX snapshot_timestamp_idx_cluster_topology_snapshot ON cluster_topology_snapshot (snapshot_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS user_dashboard (
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			dashboard_name varchar(128) CHARACTER SET utf8 NOT NULL,
+			content mediumtext CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (owner, dashboard_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS problem_suppression (
+			suppression_id varchar(128) CHARACTER SET ascii NOT NULL,
+			cluster_pattern varchar(255) CHARACTER SET utf8 NOT NULL,
+			instance_pattern varchar(255) CHARACTER SET utf8 NOT NULL,
+			analysis varchar(128) CHARACTER SET ascii NOT NULL,
+			owner varchar(128) CHARACTER SET utf8 NOT NULL,
+			reason text CHARACTER SET utf8 NOT NULL,
+			begin_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			expires_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (suppression_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS topology_recovery_escalation (
+			recovery_uid varchar(128) CHARACTER SET ascii NOT NULL,
+			policy_index int unsigned NOT NULL,
+			escalated_at timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (recovery_uid)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX escalated_at_idx_topology_recovery_escalation ON topology_recovery_escalation (escalated_at)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS analysis_rule (
+			rule_code varchar(128) CHARACTER SET ascii NOT NULL,
+			content mediumtext CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (rule_code)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replica_remedy (
+			remedy_id bigint unsigned NOT NULL AUTO_INCREMENT,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint unsigned NOT NULL,
+			remedy_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			analysis varchar(128) CHARACTER SET ascii NOT NULL,
+			action varchar(32) CHARACTER SET ascii NOT NULL,
+			sql_errno int unsigned NOT NULL DEFAULT 0,
+			PRIMARY KEY (remedy_id)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX hostname_port_idx_replica_remedy ON replica_remedy (hostname, port, remedy_timestamp)
+	`,
+	`
+		CREATE INDEX remedy_timestamp_idx_replica_remedy ON replica_remedy (remedy_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS unusable_master_signal (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint unsigned NOT NULL,
+			unusable_condition varchar(32) CHARACTER SET ascii NOT NULL,
+			source varchar(32) CHARACTER SET ascii NOT NULL,
+			details text CHARACTER SET utf8 NOT NULL,
+			first_seen_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			last_seen_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port, unusable_condition, source)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX last_seen_timestamp_idx_unusable_master_signal ON unusable_master_signal (last_seen_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS replica_lag_history (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint unsigned NOT NULL,
+			sample_timestamp timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			lag_seconds bigint unsigned DEFAULT NULL,
+			PRIMARY KEY (hostname, port, sample_timestamp)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE INDEX cluster_alias_idx_replica_lag_history ON replica_lag_history (cluster_alias, sample_timestamp)
+	`,
+	`
+		CREATE INDEX sample_timestamp_idx_replica_lag_history ON replica_lag_history (sample_timestamp)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS hostname_rule (
+			rule_name varchar(128) CHARACTER SET ascii NOT NULL,
+			content mediumtext CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (rule_name)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS instance_reported_address (
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint unsigned NOT NULL,
+			reported_hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			reported_port smallint unsigned NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (hostname, port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
+	`
+		CREATE UNIQUE INDEX reported_hostname_port_uidx_instance_reported_address ON instance_reported_address (reported_hostname, reported_port)
+	`,
+	`
+		CREATE TABLE IF NOT EXISTS membership_violation (
+			cluster_alias varchar(128) CHARACTER SET utf8 NOT NULL,
+			rule_name varchar(128) CHARACTER SET ascii NOT NULL,
+			data_center varchar(32) CHARACTER SET ascii NOT NULL,
+			violation_kind varchar(32) CHARACTER SET ascii NOT NULL,
+			cluster_name varchar(128) CHARACTER SET ascii NOT NULL,
+			description varchar(512) CHARACTER SET utf8 NOT NULL,
+			last_updated timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (cluster_alias, rule_name, data_center, violation_kind)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`,
 }
@@ -546,4 +546,94 @@ var generateSQLPatches = []string{
 			database_instance
 			ADD COLUMN region varchar(32) CHARACTER SET ascii NOT NULL AFTER data_center
 	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN tls_verify_server_cert TINYINT UNSIGNED NOT NULL DEFAULT 0 AFTER allow_tls
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN fencing_method varchar(32) CHARACTER SET ascii NOT NULL DEFAULT ''
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN fencing_status varchar(32) CHARACTER SET ascii NOT NULL DEFAULT ''
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN last_io_errno int unsigned NOT NULL DEFAULT 0 AFTER last_io_error
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN seconds_since_last_heartbeat bigint(20) DEFAULT NULL AFTER last_io_errno
+	`,
+	`
+		ALTER TABLE
+			topology_failure_detection
+			ADD COLUMN health_probe_results text NOT NULL
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN heartbeat_lag_milliseconds bigint(20) DEFAULT NULL AFTER seconds_behind_master
+	`,
+	`
+		ALTER TABLE
+			database_instance_downtime
+			ADD COLUMN extend_while varchar(32) CHARACTER SET ascii NOT NULL DEFAULT ''
+	`,
+	`
+		ALTER TABLE
+			candidate_database_instance
+			ADD COLUMN expire_timestamp TIMESTAMP NULL DEFAULT NULL
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN data_loss_estimate text NOT NULL
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN last_sql_errno int unsigned NOT NULL DEFAULT 0 AFTER last_sql_error
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_timeout bigint unsigned NOT NULL DEFAULT 0 AFTER semi_sync_replica_enabled
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_wait_for_slave_count int unsigned NOT NULL DEFAULT 0 AFTER semi_sync_master_timeout
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_clients int unsigned NOT NULL DEFAULT 0 AFTER semi_sync_master_wait_for_slave_count
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN semi_sync_master_wait_sessions int unsigned NOT NULL DEFAULT 0 AFTER semi_sync_master_clients
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN running_activity varchar(512) CHARACTER SET utf8 NOT NULL DEFAULT '' AFTER semi_sync_master_wait_sessions
+	`,
+	`
+		ALTER TABLE
+			database_instance
+			ADD COLUMN replication_channels text CHARACTER SET utf8 NOT NULL AFTER running_activity
+	`,
+	`
+		ALTER TABLE
+			topology_recovery
+			ADD COLUMN successor_promotion_rule varchar(128) CHARACTER SET ascii NOT NULL DEFAULT ''
+	`,
 }
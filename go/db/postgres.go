@@ -0,0 +1,201 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/lib/pq"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// The PostgreSQL backend speaks to the database via lib/pq, wrapped such that values are read the
+// way orchestrator reads them off MySQL and SQLite: timestamps as "2006-01-02 15:04:05" strings,
+// and booleans as integers. See postgres_dialect.go for the conversion of statements.
+
+const postgresDriverName = "orchestrator-postgres"
+
+func init() {
+	sql.Register(postgresDriverName, &postgresDriver{})
+}
+
+type postgresDriver struct {
+	pq.Driver
+}
+
+func (this *postgresDriver) Open(name string) (driver.Conn, error) {
+	conn, err := this.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresConn{Conn: conn}, nil
+}
+
+type postgresConn struct {
+	driver.Conn
+}
+
+func (this *postgresConn) Prepare(query string) (driver.Stmt, error) {
+	stmt, err := this.Conn.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresStmt{Stmt: stmt}, nil
+}
+
+func (this *postgresConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := this.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	rows, err := queryer.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresRows{Rows: rows}, nil
+}
+
+func (this *postgresConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := this.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+type postgresStmt struct {
+	driver.Stmt
+}
+
+func (this *postgresStmt) Query(args []driver.Value) (driver.Rows, error) {
+	rows, err := this.Stmt.Query(args)
+	if err != nil {
+		return nil, err
+	}
+	return &postgresRows{Rows: rows}, nil
+}
+
+type postgresRows struct {
+	driver.Rows
+}
+
+func (this *postgresRows) Next(dest []driver.Value) error {
+	if err := this.Rows.Next(dest); err != nil {
+		return err
+	}
+	for i, value := range dest {
+		switch value := value.(type) {
+		case time.Time:
+			dest[i] = value.Format("2006-01-02 15:04:05")
+		case bool:
+			if value {
+				dest[i] = int64(1)
+			} else {
+				dest[i] = int64(0)
+			}
+		}
+	}
+	return nil
+}
+
+// postgresResult is the result of an insert statement returning the generated auto_increment value,
+// which PostgreSQL does not otherwise provide
+type postgresResult struct {
+	lastInsertId int64
+	rowsAffected int64
+}
+
+func (this postgresResult) LastInsertId() (int64, error) {
+	return this.lastInsertId, nil
+}
+
+func (this postgresResult) RowsAffected() (int64, error) {
+	return this.rowsAffected, nil
+}
+
+var postgresReturningClause = regexp.MustCompile(`(?i)[\s]returning[\s]+[\w]+[\s]*$`)
+
+// execPostgres executes a statement already converted to PostgreSQL dialect
+func execPostgres(db sqlExecQueryer, query string, args ...interface{}) (sql.Result, error) {
+	args = toPostgresArgs(args)
+	if !postgresReturningClause.MatchString(query) {
+		return db.Exec(query, args...)
+	}
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	result := postgresResult{}
+	for rows.Next() {
+		if err := rows.Scan(&result.lastInsertId); err != nil {
+			return nil, err
+		}
+		result.rowsAffected++
+	}
+	return result, rows.Err()
+}
+
+// sqlExecQueryer is implemented by both sql.DB and sql.Tx
+type sqlExecQueryer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func getPostgreSQLURI(safe bool) string {
+	uri := url.URL{
+		Scheme: "postgres",
+		Host:   net.JoinHostPort(config.Config.PostgreSQLOrchestratorHost, fmt.Sprintf("%d", config.Config.PostgreSQLOrchestratorPort)),
+		Path:   "/" + config.Config.PostgreSQLOrchestratorDatabase,
+	}
+	if safe {
+		uri.User = url.User(config.Config.PostgreSQLOrchestratorUser)
+	} else {
+		uri.User = url.UserPassword(config.Config.PostgreSQLOrchestratorUser, config.Config.PostgreSQLOrchestratorPassword)
+	}
+	query := url.Values{}
+	query.Set("sslmode", config.Config.PostgreSQLOrchestratorSSLMode)
+	query.Set("connect_timeout", fmt.Sprintf("%d", config.Config.MySQLConnectTimeoutSeconds))
+	uri.RawQuery = query.Encode()
+	return uri.String()
+}
+
+// openOrchestratorPostgreSQL returns the DB instance for a PostgreSQL backend. Unlike with MySQL, the
+// database is expected to exist.
+func openOrchestratorPostgreSQL() (db *sql.DB, fromCache bool, err error) {
+	db, fromCache, err = sqlutils.GetGenericDB(postgresDriverName, getPostgreSQLURI(false))
+	if err == nil && !fromCache {
+		log.Debugf("Connected to orchestrator backend: %v", getPostgreSQLURI(true))
+		if config.Config.MySQLOrchestratorMaxPoolConnections > 0 {
+			log.Debugf("Orchestrator pool SetMaxOpenConns: %d", config.Config.MySQLOrchestratorMaxPoolConnections)
+			db.SetMaxOpenConns(config.Config.MySQLOrchestratorMaxPoolConnections)
+		}
+		if lifetime := getOrchestratorConnectionLifetime(); lifetime > 0 {
+			db.SetConnMaxLifetime(lifetime)
+		}
+	}
+	return db, fromCache, err
+}
@@ -0,0 +1,452 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Much like the sqlite3 dialect in golib/sqlutils, this is a regular-expression based conversion
+// from the MySQL syntax orchestrator speaks, to PostgreSQL syntax. It is only expected to work on
+// queries issued by orchestrator.
+// Upserts require some knowledge of the schema: MySQL's "on duplicate key update" implicitly applies to
+// any unique key, whereas PostgreSQL's "on conflict" requires naming the conflicting columns. These are
+// read from orchestrator's own schema definitions (generateSQLBase, generateSQLPatches).
+
+package db
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+type postgresConversion struct {
+	r           *regexp.Regexp
+	replacement string
+}
+
+func pgmap(regexpExpression string, replacement string) postgresConversion {
+	return postgresConversion{
+		r:           regexp.MustCompile(strings.Replace(regexpExpression, " ", `[\s]+`, -1)),
+		replacement: replacement,
+	}
+}
+
+func applyPostgresConversions(statement string, conversions []postgresConversion) string {
+	for _, conversion := range conversions {
+		statement = conversion.r.ReplaceAllString(statement, conversion.replacement)
+	}
+	return statement
+}
+
+var postgresCreateTableConversions = []postgresConversion{
+	pgmap(`(?i) (character set|charset) [\S]+`, ``),
+	pgmap(`(?i)DEFAULT CHARSET[\s]*=[\s]*[\S]+`, ``),
+	pgmap(`(?i)engine[\s]*=[\s]*[\w]+`, ``),
+	pgmap(`(?i)comment '[^']*'`, ``),
+	pgmap("`", `"`),
+	pgmap(`(?i)[\w]*int\b([\s]*[(][0-9]+[)])?( unsigned)?( not null)? auto_increment`, `bigserial`),
+	pgmap(`(?i)\bsmallint\b([\s]*[(][0-9]+[)])?( unsigned)?`, `integer`),
+	pgmap(`(?i)\bmediumint\b([\s]*[(][0-9]+[)])?( unsigned)?`, `integer`),
+	pgmap(`(?i)\btinyint\b([\s]*[(][0-9]+[)])?( unsigned)?`, `smallint`),
+	pgmap(`(?i)\bbigint\b([\s]*[(][0-9]+[)])?( unsigned)?`, `bigint`),
+	pgmap(`(?i)\bint\b([\s]*[(][0-9]+[)])?( unsigned)?`, `bigint`),
+	pgmap(`(?i)\bdouble\b`, `double precision`),
+	pgmap(`(?i)\bdatetime\b`, `timestamp`),
+	pgmap(`(?i) on update current_timestamp`, ``),
+	pgmap(`(?i)\b(tinytext|mediumtext|longtext)\b`, `text`),
+	pgmap(`(?i)\bvarbinary[\s]*[(][0-9]+[)]`, `bytea`),
+	pgmap(`(?i)\b(tinyblob|mediumblob|longblob|blob)\b`, `bytea`),
+	pgmap(`(?i)([\S]+) enum[\s]*([(].*?[)])`, `$1 text check($1 in $2)`),
+	pgmap(`(?i) after [\S]+`, ``),
+
+	pgmap(`(?i),[\s]*unique (key|index) [\S]+[\s]*([(][^)]*[)])`, `, unique $2`),
+	pgmap(`(?i),[\s]*(key|index) [\S]+[\s]*[(][^)]*[)]`, ``),
+	pgmap(`(?i)alter table ([\S]+) add unique (index|key) ([\S]+) (.+)`, `create unique index if not exists ${3}_${1} on $1 $4`),
+	pgmap(`(?i)alter table ([\S]+) add (index|key) ([\S]+) (.+)`, `create index if not exists ${3}_${1} on $1 $4`),
+	pgmap(`(?i)alter table ([\S]+) drop primary key`, `alter table $1 drop constraint ${1}_pkey`),
+	pgmap(`(?i)\badd column ([\S]+)`, `add column if not exists $1`),
+	pgmap(`(?i)(add column if not exists .*(int|bigint|integer)) not null[\s]*$`, `$1 not null default 0`),
+	pgmap(`(?i)(add column if not exists .*(text|varchar.*)) not null[\s]*$`, `$1 not null default ''`),
+	pgmap(`(?i)\bmodify (column )?([\S]+) ([\w]+([(][0-9, ]+[)])?)[^,\n]*`, `alter column $2 type $3`),
+}
+
+var postgresIndexConversions = []postgresConversion{
+	pgmap(`(?i)create (unique )?index ([\S]+) on`, `create ${1}index if not exists $2 on`),
+	pgmap(`(?i)([\w]+)[\s]*[(][0-9]+[)]`, `$1`),
+	pgmap(`(?i)drop index ([\S]+) on ([\S]+)`, `drop index if exists $1`),
+}
+
+var postgresGeneralConversions = []postgresConversion{
+	pgmap("`", `"`),
+	pgmap(`(?i)\bifnull[(]`, `coalesce(`),
+	pgmap(`(?i)unix_timestamp[(][\s]*now[(][)][\s]*[)]`, `unix_timestamp()`),
+	pgmap(`(?i)unix_timestamp[(][)]`, `cast(extract(epoch from now()) as bigint)`),
+	pgmap(`(?i)unix_timestamp[(]([^()]+)[)]`, `cast(extract(epoch from $1) as bigint)`),
+	pgmap(`(?i)interval ([?]|[0-9.]+) (second|minute|hour|day|week|month|year)s?\b`, `(interval '1 $2' * $1)`),
+	pgmap(`(?i)cast[(]([^()]+) as (signed|unsigned)[)]`, `cast($1 as bigint)`),
+	pgmap(`(?i)substring_index[(]([^,()]+),([^,()]+),[\s]*1[\s]*[)]`, `split_part($1,$2, 1)`),
+	pgmap(`(?i) rlike `, ` ~ `),
+}
+
+var (
+	postgresIdentifyCreateTableStatement = regexp.MustCompile(`(?i)^[\s]*create[\s]+table`)
+	postgresIdentifyAlterTableStatement  = regexp.MustCompile(`(?i)^[\s]*alter[\s]+table`)
+	postgresIdentifyIndexStatement       = regexp.MustCompile(`(?i)^[\s]*(create([\s]+unique|)|drop)[\s]+index`)
+	postgresIdentifyUpsertStatement      = regexp.MustCompile(`(?is)^[\s]*(insert|replace)([\s]+ignore)?[\s]+into[\s]+([\w.]+)[\s]*[(]([^)]*)[)](.*)$`)
+	postgresOnDuplicateKeyUpdate         = regexp.MustCompile(`(?i)on[\s]+duplicate[\s]+key[\s]+update`)
+	postgresValuesFunction               = regexp.MustCompile(`(?i)\bvalues[(][\s]*([\w]+)[\s]*[)]`)
+	postgresBooleanOperators             = regexp.MustCompile(`(?i)(<|>|=|\band\b|\bor\b|\bis\b|\bin\b|\blike\b)`)
+)
+
+// postgresAggregateFunctions may be applied onto boolean expressions in MySQL, but not in PostgreSQL
+var postgresAggregateFunctions = []string{"sum", "min", "max", "avg"}
+
+// findClosingParenthesis returns the position of the parenthesis closing the one at given position, or -1
+func findClosingParenthesis(statement string, open int) int {
+	depth := 0
+	for i := open; i < len(statement); i++ {
+		switch statement[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// convertFunctionArguments rewrites the argument of all calls to given function
+func convertFunctionArguments(statement string, function string, convert func(argument string) string) string {
+	r := regexp.MustCompile(`(?i)\b` + function + `[(]`)
+	result := ""
+	for {
+		loc := r.FindStringIndex(statement)
+		if loc == nil {
+			return result + statement
+		}
+		open := loc[1] - 1
+		closing := findClosingParenthesis(statement, open)
+		if closing < 0 {
+			return result + statement
+		}
+		argument := convertFunctionArguments(statement[open+1:closing], function, convert)
+		result = result + statement[:loc[0]] + convert(argument)
+		statement = statement[closing+1:]
+	}
+}
+
+// convertBooleanAggregates converts e.g. SUM(a = b) into SUM(case when (a = b) then 1 else 0 end)
+func convertBooleanAggregates(statement string) string {
+	for _, function := range postgresAggregateFunctions {
+		function := function
+		statement = convertFunctionArguments(statement, function, func(argument string) string {
+			if postgresBooleanOperators.MatchString(argument) && !strings.Contains(strings.ToLower(argument), "case ") {
+				argument = fmt.Sprintf("case when (%s) then 1 else 0 end", argument)
+			}
+			return fmt.Sprintf("%s(%s)", function, argument)
+		})
+	}
+	return statement
+}
+
+// convertGroupConcat converts GROUP_CONCAT(x) into string_agg(cast(x as text), ',')
+func convertGroupConcat(statement string) string {
+	distinct := regexp.MustCompile(`(?i)^[\s]*distinct[\s]+`)
+	return convertFunctionArguments(statement, "group_concat", func(argument string) string {
+		if loc := distinct.FindStringIndex(argument); loc != nil {
+			return fmt.Sprintf("string_agg(distinct cast(%s as text), ',')", argument[loc[1]:])
+		}
+		return fmt.Sprintf("string_agg(cast(%s as text), ',')", argument)
+	})
+}
+
+// convertPlaceholders converts '?' placeholders into PostgreSQL's numbered '$n' placeholders
+func convertPlaceholders(statement string) string {
+	var result strings.Builder
+	inQuotes := false
+	count := 0
+	for _, c := range statement {
+		switch {
+		case c == '\'':
+			inQuotes = !inQuotes
+			result.WriteRune(c)
+		case c == '?' && !inQuotes:
+			count++
+			result.WriteString(fmt.Sprintf("$%d", count))
+		default:
+			result.WriteRune(c)
+		}
+	}
+	return result.String()
+}
+
+// postgresUniqueKeys maps table names onto their unique column sets, primary key first
+var postgresUniqueKeys map[string][][]string
+
+// postgresAutoIncrementColumns maps table names onto their auto_increment column
+var postgresAutoIncrementColumns map[string]string
+var postgresSchemaOnce sync.Once
+
+func readPostgresSchema() {
+	postgresSchemaOnce.Do(func() {
+		statements := append(append([]string{}, generateSQLBase...), generateSQLPatches...)
+		postgresUniqueKeys = readPostgresUniqueKeys(statements)
+		postgresAutoIncrementColumns = readPostgresAutoIncrementColumns(statements)
+	})
+}
+
+func parseColumnList(columnList string) (columns []string) {
+	for _, column := range strings.Split(columnList, ",") {
+		column = strings.Trim(strings.TrimSpace(column), "`\"")
+		if column != "" {
+			columns = append(columns, strings.ToLower(column))
+		}
+	}
+	return columns
+}
+
+// readPostgresUniqueKeys reads primary and unique keys from orchestrator's schema definitions
+func readPostgresUniqueKeys(statements []string) map[string][][]string {
+	uniqueKeys := map[string][][]string{}
+	createTable := regexp.MustCompile(`(?is)create[\s]+table[\s]+(if[\s]+not[\s]+exists[\s]+)?([\w]+)`)
+	alterTable := regexp.MustCompile(`(?is)alter[\s]+table[\s]+([\w]+)`)
+	primaryKey := regexp.MustCompile(`(?is)primary[\s]+key[\s]*[(]([^)]+)[)]`)
+	uniqueKey := regexp.MustCompile(`(?is)unique[\s]+(key|index)[\s]+[\S]+[\s]*[(]([^)]+)[)]`)
+	createUniqueIndex := regexp.MustCompile(`(?is)create[\s]+unique[\s]+index[\s]+[\S]+[\s]+on[\s]+([\w]+)[\s]*[(]([^)]+)[)]`)
+
+	for _, statement := range statements {
+		if submatch := createUniqueIndex.FindStringSubmatch(statement); submatch != nil {
+			table := strings.ToLower(submatch[1])
+			uniqueKeys[table] = append(uniqueKeys[table], parseColumnList(submatch[2]))
+			continue
+		}
+		table := ""
+		if submatch := createTable.FindStringSubmatch(statement); submatch != nil {
+			table = strings.ToLower(submatch[2])
+		} else if submatch := alterTable.FindStringSubmatch(statement); submatch != nil {
+			table = strings.ToLower(submatch[1])
+		} else {
+			continue
+		}
+		if submatch := primaryKey.FindStringSubmatch(statement); submatch != nil {
+			// A primary key replaces any former primary key, which is always listed first
+			keys := [][]string{parseColumnList(submatch[1])}
+			if len(uniqueKeys[table]) > 0 && !postgresIdentifyCreateTableStatement.MatchString(statement) {
+				keys = append(keys, uniqueKeys[table][1:]...)
+			}
+			uniqueKeys[table] = keys
+		}
+		for _, submatch := range uniqueKey.FindAllStringSubmatch(statement, -1) {
+			uniqueKeys[table] = append(uniqueKeys[table], parseColumnList(submatch[2]))
+		}
+	}
+	return uniqueKeys
+}
+
+// readPostgresAutoIncrementColumns reads auto_increment columns from orchestrator's schema definitions
+func readPostgresAutoIncrementColumns(statements []string) map[string]string {
+	autoIncrementColumns := map[string]string{}
+	createTable := regexp.MustCompile(`(?is)create[\s]+table[\s]+(if[\s]+not[\s]+exists[\s]+)?([\w]+)`)
+	autoIncrement := regexp.MustCompile(`(?i)([\w]+)[\s]+[\w]*int\b[^,]*auto_increment`)
+
+	for _, statement := range statements {
+		if submatch := createTable.FindStringSubmatch(statement); submatch != nil {
+			if column := autoIncrement.FindStringSubmatch(statement); column != nil {
+				autoIncrementColumns[strings.ToLower(submatch[2])] = strings.ToLower(column[1])
+			}
+		}
+	}
+	return autoIncrementColumns
+}
+
+// splitTopLevel splits given text by commas which are neither quoted nor nested in parentheses
+func splitTopLevel(text string) (tokens []string) {
+	depth := 0
+	inQuotes := false
+	start := 0
+	for i, c := range text {
+		switch {
+		case c == '\'':
+			inQuotes = !inQuotes
+		case inQuotes:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == ',' && depth == 0:
+			tokens = append(tokens, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(tokens, text[start:])
+}
+
+// convertAutoIncrementValues handles explicit values for an auto_increment column. MySQL generates
+// a value when given NULL; PostgreSQL requires omitting the column, so NULL is replaced by the
+// sequence's next value
+func convertAutoIncrementValues(table string, columns []string, values string, autoIncrementColumn string) string {
+	columnIndex := -1
+	for i, column := range columns {
+		if column == autoIncrementColumn {
+			columnIndex = i
+		}
+	}
+	valuesClause := regexp.MustCompile(`(?i)^[\s]*values[\s]*`)
+	loc := valuesClause.FindStringIndex(values)
+	if columnIndex < 0 || loc == nil {
+		return values
+	}
+	result := values[:loc[1]]
+	values = values[loc[1]:]
+	for strings.HasPrefix(values, "(") {
+		closing := findClosingParenthesis(values, 0)
+		if closing < 0 {
+			break
+		}
+		tuple := splitTopLevel(values[1:closing])
+		if columnIndex < len(tuple) {
+			tuple[columnIndex] = fmt.Sprintf("coalesce(%s, nextval(pg_get_serial_sequence('%s', '%s')))", strings.TrimSpace(tuple[columnIndex]), table, autoIncrementColumn)
+		}
+		result = result + "(" + strings.Join(tuple, ",") + ")"
+		values = values[closing+1:]
+		trimmed := strings.TrimLeft(values, " \t\r\n")
+		if !strings.HasPrefix(trimmed, ",") {
+			break
+		}
+		result = result + ", "
+		values = strings.TrimLeft(trimmed[1:], " \t\r\n")
+	}
+	return result + values
+}
+
+// getPostgresConflictTarget returns the columns of the first unique key of given table, which
+// are all provided by an insert statement
+func getPostgresConflictTarget(table string, insertColumns []string) []string {
+	readPostgresSchema()
+	provided := map[string]bool{}
+	for _, column := range insertColumns {
+		provided[column] = true
+	}
+	for _, key := range postgresUniqueKeys[strings.ToLower(table)] {
+		allProvided := true
+		for _, column := range key {
+			allProvided = allProvided && provided[column]
+		}
+		if allProvided {
+			return key
+		}
+	}
+	return nil
+}
+
+// toPostgresInsert converts MySQL's "insert ignore", "replace into" and "on duplicate key update"
+// into "on conflict" clauses. Inserts into tables with an auto_increment column return the generated
+// value, see postgresResult.
+func toPostgresInsert(statement string) string {
+	submatch := postgresIdentifyUpsertStatement.FindStringSubmatch(statement)
+	if submatch == nil {
+		return statement
+	}
+	readPostgresSchema()
+	table := submatch[3]
+	columns := parseColumnList(submatch[4])
+	autoIncrementColumn := postgresAutoIncrementColumns[strings.ToLower(table)]
+	statement = toPostgresUpsert(submatch, convertAutoIncrementValues(table, columns, submatch[5], autoIncrementColumn))
+	if autoIncrementColumn != "" {
+		statement = fmt.Sprintf("%s returning %s", strings.TrimRight(statement, " \t\r\n;"), autoIncrementColumn)
+	}
+	return statement
+}
+
+// toPostgresUpsert converts the conflict handling of an insert statement, given the statement's
+// parsed parts (see postgresIdentifyUpsertStatement) and the part following the column list
+func toPostgresUpsert(submatch []string, rest string) string {
+	isReplace := strings.ToLower(submatch[1]) == "replace"
+	isIgnore := submatch[2] != ""
+	table := submatch[3]
+	columns := parseColumnList(submatch[4])
+
+	insert := fmt.Sprintf("insert into %s (%s)", table, submatch[4])
+	conflictTarget := getPostgresConflictTarget(table, columns)
+
+	if loc := postgresOnDuplicateKeyUpdate.FindStringIndex(rest); loc != nil {
+		updates := postgresValuesFunction.ReplaceAllString(rest[loc[1]:], "excluded.$1")
+		if len(conflictTarget) == 0 {
+			return fmt.Sprintf("%s%s on conflict do nothing", insert, rest[:loc[0]])
+		}
+		return fmt.Sprintf("%s%s on conflict (%s) do update set %s", insert, rest[:loc[0]], strings.Join(conflictTarget, ", "), updates)
+	}
+	if isReplace {
+		if len(conflictTarget) == 0 {
+			return fmt.Sprintf("%s%s on conflict do nothing", insert, rest)
+		}
+		inTarget := map[string]bool{}
+		for _, column := range conflictTarget {
+			inTarget[column] = true
+		}
+		updates := []string{}
+		for _, column := range columns {
+			if !inTarget[column] {
+				updates = append(updates, fmt.Sprintf("%s=excluded.%s", column, column))
+			}
+		}
+		if len(updates) == 0 {
+			return fmt.Sprintf("%s%s on conflict do nothing", insert, rest)
+		}
+		return fmt.Sprintf("%s%s on conflict (%s) do update set %s", insert, rest, strings.Join(conflictTarget, ", "), strings.Join(updates, ", "))
+	}
+	if isIgnore {
+		return fmt.Sprintf("%s%s on conflict do nothing", insert, rest)
+	}
+	return insert + rest
+}
+
+// toPostgresDialect converts a MySQL statement issued by orchestrator into PostgreSQL syntax
+func toPostgresDialect(statement string) string {
+	if postgresIdentifyCreateTableStatement.MatchString(statement) || postgresIdentifyAlterTableStatement.MatchString(statement) {
+		statement = applyPostgresConversions(statement, postgresCreateTableConversions)
+		if postgresIdentifyIndexStatement.MatchString(statement) {
+			statement = applyPostgresConversions(statement, postgresIndexConversions)
+		}
+		return statement
+	}
+	if postgresIdentifyIndexStatement.MatchString(statement) {
+		return applyPostgresConversions(statement, postgresIndexConversions)
+	}
+	statement = applyPostgresConversions(statement, postgresGeneralConversions)
+	statement = convertGroupConcat(statement)
+	statement = convertBooleanAggregates(statement)
+	statement = toPostgresInsert(statement)
+	return convertPlaceholders(statement)
+}
+
+// toPostgresArgs converts query arguments to types PostgreSQL accepts for orchestrator's columns:
+// booleans are stored as integers
+func toPostgresArgs(args []interface{}) []interface{} {
+	converted := make([]interface{}, len(args))
+	for i, arg := range args {
+		if b, ok := arg.(bool); ok {
+			if b {
+				arg = 1
+			} else {
+				arg = 0
+			}
+		}
+		converted[i] = arg
+	}
+	return converted
+}
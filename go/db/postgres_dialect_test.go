@@ -0,0 +1,118 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+var spacesRegexp = regexp.MustCompile(`[\s]+`)
+
+func stripSpaces(statement string) string {
+	return strings.TrimSpace(spacesRegexp.ReplaceAllString(statement, " "))
+}
+
+func TestToPostgresDialectCreateTable(t *testing.T) {
+	statement := toPostgresDialect(`
+		CREATE TABLE IF NOT EXISTS some_table (
+			some_id bigint unsigned not null auto_increment,
+			hostname varchar(128) CHARACTER SET ascii NOT NULL,
+			port smallint(5) unsigned NOT NULL,
+			is_active tinyint unsigned NOT NULL DEFAULT '0',
+			PRIMARY KEY (some_id),
+			UNIQUE KEY hostname_port_uidx (hostname, port),
+			KEY port_idx (port)
+		) ENGINE=InnoDB DEFAULT CHARSET=ascii
+	`)
+	test.S(t).ExpectEquals(stripSpaces(statement), "CREATE TABLE IF NOT EXISTS some_table ( some_id bigserial, hostname varchar(128) NOT NULL, port integer NOT NULL, is_active smallint NOT NULL DEFAULT '0', PRIMARY KEY (some_id), unique (hostname, port) )")
+}
+
+func TestToPostgresDialectAlterTable(t *testing.T) {
+	{
+		statement := toPostgresDialect(`ALTER TABLE some_table ADD COLUMN hostname varchar(128) CHARACTER SET ascii NOT NULL AFTER port`)
+		test.S(t).ExpectEquals(stripSpaces(statement), "ALTER TABLE some_table add column if not exists hostname varchar(128) not null default ''")
+	}
+	{
+		statement := toPostgresDialect(`ALTER TABLE some_table ADD INDEX hostname_idx (hostname)`)
+		test.S(t).ExpectEquals(stripSpaces(statement), "create index if not exists hostname_idx_some_table on some_table (hostname)")
+	}
+	{
+		statement := toPostgresDialect(`ALTER TABLE some_table MODIFY port int unsigned NOT NULL`)
+		test.S(t).ExpectEquals(stripSpaces(statement), "ALTER TABLE some_table alter column port type bigint")
+	}
+}
+
+func TestToPostgresDialectPlaceholders(t *testing.T) {
+	statement := toPostgresDialect(`select hostname from some_table where hostname = ? and reason like '%?%' and port = ?`)
+	test.S(t).ExpectEquals(statement, `select hostname from some_table where hostname = $1 and reason like '%?%' and port = $2`)
+}
+
+func TestToPostgresDialectFunctions(t *testing.T) {
+	{
+		statement := toPostgresDialect(`select ifnull(max(id), 0) as id from some_table where ts < now() - interval ? second`)
+		test.S(t).ExpectEquals(statement, `select coalesce(max(id), 0) as id from some_table where ts < now() - (interval '1 second' * $1)`)
+	}
+	{
+		statement := toPostgresDialect(`select unix_timestamp() - unix_timestamp(last_checked) as seconds`)
+		test.S(t).ExpectEquals(statement, `select cast(extract(epoch from now()) as bigint) - cast(extract(epoch from last_checked) as bigint) as seconds`)
+	}
+	{
+		statement := toPostgresDialect(`select group_concat(distinct hostname) as hosts, GROUP_CONCAT(concat(hostname, ':', port)) as keys`)
+		test.S(t).ExpectEquals(statement, `select string_agg(distinct cast(hostname as text), ',') as hosts, string_agg(cast(concat(hostname, ':', port) as text), ',') as keys`)
+	}
+	{
+		statement := toPostgresDialect(`select SUM(slave_io_running = 1 and slave_sql_running = 1) as count_valid, MAX(port) as max_port`)
+		test.S(t).ExpectEquals(statement, `select sum(case when (slave_io_running = 1 and slave_sql_running = 1) then 1 else 0 end) as count_valid, max(port) as max_port`)
+	}
+}
+
+func TestToPostgresDialectUpsert(t *testing.T) {
+	{
+		statement := toPostgresDialect(`insert ignore into database_instance (hostname, port, last_checked) values (?, ?, now())`)
+		test.S(t).ExpectEquals(stripSpaces(statement), `insert into database_instance (hostname, port, last_checked) values ($1, $2, now()) on conflict do nothing`)
+	}
+	{
+		statement := toPostgresDialect(`
+			insert into database_instance (hostname, port, last_checked) values (?, ?, now()), (?, ?, now())
+			on duplicate key update last_checked=VALUES(last_checked)
+		`)
+		test.S(t).ExpectEquals(stripSpaces(statement), `insert into database_instance (hostname, port, last_checked) values ($1, $2, now()), ($3, $4, now()) on conflict (hostname, port) do update set last_checked=excluded.last_checked`)
+	}
+	{
+		statement := toPostgresDialect(`replace into orchestrator_db_deployments (deployed_version, deployed_timestamp) values (?, NOW())`)
+		test.S(t).ExpectEquals(stripSpaces(statement), `insert into orchestrator_db_deployments (deployed_version, deployed_timestamp) values ($1, NOW()) on conflict (deployed_version) do update set deployed_timestamp=excluded.deployed_timestamp`)
+	}
+}
+
+func TestToPostgresDialectAutoIncrement(t *testing.T) {
+	statement := toPostgresDialect(`insert ignore into topology_recovery_steps (recovery_step_id, recovery_uid, audit_at, message) values (?, ?, now(), ?)`)
+	test.S(t).ExpectEquals(stripSpaces(statement), `insert into topology_recovery_steps (recovery_step_id, recovery_uid, audit_at, message) values (coalesce($1, nextval(pg_get_serial_sequence('topology_recovery_steps', 'recovery_step_id'))), $2, now(), $3) on conflict do nothing returning recovery_step_id`)
+	test.S(t).ExpectTrue(postgresReturningClause.MatchString(statement))
+}
+
+func TestToPostgresArgs(t *testing.T) {
+	args := toPostgresArgs([]interface{}{"host", 3306, true, false})
+	test.S(t).ExpectEquals(len(args), 4)
+	test.S(t).ExpectEquals(args[0], "host")
+	test.S(t).ExpectEquals(args[1], 3306)
+	test.S(t).ExpectEquals(args[2], 1)
+	test.S(t).ExpectEquals(args[3], 0)
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+// Recovery-critical writes (registering a recovery, its steps, its resolution) go through a small pool
+// of their own, such that they do not queue up behind heavy API reads competing for the general pool.
+
+var recoveryPoolInUseGauge = metrics.NewGauge()
+var recoveryPoolWaitCountGauge = metrics.NewGauge()
+var recoveryPoolWaitMillisGauge = metrics.NewGauge()
+
+func init() {
+	metrics.Register("backend.recovery_pool.in_use", recoveryPoolInUseGauge)
+	metrics.Register("backend.recovery_pool.wait_count", recoveryPoolWaitCountGauge)
+	metrics.Register("backend.recovery_pool.wait_millis", recoveryPoolWaitMillisGauge)
+}
+
+var recoveryPool *sql.DB
+var recoveryPoolOnce sync.Once
+
+// hasRecoveryPool checks whether a reserved recovery pool is configured. SQLite writes through a
+// single connection anyhow, and so does not get a reserved pool.
+func hasRecoveryPool() bool {
+	return !IsSQLite() && config.Config.MySQLOrchestratorRecoveryPoolConnections > 0
+}
+
+func openRecoveryPool() (db *sql.DB, err error) {
+	if IsPostgreSQL() {
+		db, err = sql.Open(postgresDriverName, getPostgreSQLURI(false))
+	} else {
+		db, err = sql.Open("mysql", getMySQLURI())
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(config.Config.MySQLOrchestratorRecoveryPoolConnections)
+	db.SetMaxIdleConns(config.Config.MySQLOrchestratorRecoveryPoolConnections)
+	if lifetime := getOrchestratorConnectionLifetime(); lifetime > 0 {
+		db.SetConnMaxLifetime(lifetime)
+	}
+	log.Debugf("Connected to orchestrator backend: recovery pool, maxConnections: %d", config.Config.MySQLOrchestratorRecoveryPoolConnections)
+	go collectRecoveryPoolMetrics(db)
+	return db, nil
+}
+
+// OpenOrchestratorRecovery returns the DB instance to use for recovery-critical writes. When there is no
+// reserved recovery pool, this is the same as OpenOrchestrator.
+func OpenOrchestratorRecovery() (db *sql.DB, err error) {
+	db, err = OpenOrchestrator()
+	if err != nil || !hasRecoveryPool() {
+		return db, err
+	}
+	recoveryPoolOnce.Do(func() {
+		if recoveryPool, err = openRecoveryPool(); err != nil {
+			log.Errore(err)
+		}
+	})
+	if recoveryPool == nil {
+		// Can still write via the general pool
+		return db, nil
+	}
+	return recoveryPool, nil
+}
+
+// ExecOrchestratorRecovery will execute given recovery-critical query on the orchestrator backend database,
+// via the reserved recovery pool.
+func ExecOrchestratorRecovery(query string, args ...interface{}) (sql.Result, error) {
+	db, err := OpenOrchestratorRecovery()
+	if err != nil {
+		return nil, err
+	}
+	return execInternal(db, query, args...)
+}
+
+// collectRecoveryPoolMetrics periodically publishes the usage of the recovery pool
+func collectRecoveryPoolMetrics(db *sql.DB) {
+	for range time.Tick(time.Second) {
+		stats := db.Stats()
+		recoveryPoolInUseGauge.Update(int64(stats.InUse))
+		recoveryPoolWaitCountGauge.Update(stats.WaitCount)
+		recoveryPoolWaitMillisGauge.Update(int64(stats.WaitDuration / time.Millisecond))
+	}
+}
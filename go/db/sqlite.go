@@ -0,0 +1,119 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/rcrowley/go-metrics"
+)
+
+// The SQLite backend runs in WAL mode, where readers do not block the writer and vice versa.
+// All writes go through a single connection: concurrent writers queue up waiting for it, rather than
+// competing for the database lock. Reads are served by a separate pool of connections, such that
+// heavy API reads do not hold back writes, e.g. during recoveries.
+
+var sqliteWriteWaitCountGauge = metrics.NewGauge()
+var sqliteWriteWaitMillisGauge = metrics.NewGauge()
+var sqliteReadWaitCountGauge = metrics.NewGauge()
+var sqliteReadWaitMillisGauge = metrics.NewGauge()
+var sqliteBusyCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("backend.sqlite.write_wait_count", sqliteWriteWaitCountGauge)
+	metrics.Register("backend.sqlite.write_wait_millis", sqliteWriteWaitMillisGauge)
+	metrics.Register("backend.sqlite.read_wait_count", sqliteReadWaitCountGauge)
+	metrics.Register("backend.sqlite.read_wait_millis", sqliteReadWaitMillisGauge)
+	metrics.Register("backend.sqlite.busy", sqliteBusyCounter)
+}
+
+var sqliteMetricsOnce sync.Once
+
+// getSQLiteReadPoolDataFile returns the data source for the read pool. It differs from the writer's data
+// source, so that the two are pooled independently.
+func getSQLiteReadPoolDataFile() string {
+	separator := "?"
+	if strings.Contains(config.Config.SQLite3DataFile, "?") {
+		separator = "&"
+	}
+	return fmt.Sprintf("%s%s_busy_timeout=%d", config.Config.SQLite3DataFile, separator, 5000)
+}
+
+// hasSQLiteReadPool checks whether reads should be served by a dedicated pool. An in-memory database
+// cannot be shared between connections, and so is always read via the writing connection.
+func hasSQLiteReadPool() bool {
+	return IsSQLite() && !isInMemorySQLite() && config.Config.SQLite3ReadPoolConnections > 0
+}
+
+// openSQLiteReadPool returns the pool of connections serving reads off the SQLite backend
+func openSQLiteReadPool() (db *sql.DB, err error) {
+	db, fromCache, err := sqlutils.GetSQLiteDB(getSQLiteReadPoolDataFile())
+	if err != nil {
+		return db, log.Errore(err)
+	}
+	if !fromCache {
+		log.Debugf("Connected to orchestrator backend: sqlite read pool on %v, maxConnections: %d", config.Config.SQLite3DataFile, config.Config.SQLite3ReadPoolConnections)
+		db.SetMaxOpenConns(config.Config.SQLite3ReadPoolConnections)
+		db.SetMaxIdleConns(config.Config.SQLite3ReadPoolConnections)
+	}
+	return db, nil
+}
+
+// OpenOrchestratorReader returns the DB instance to use for reading from the orchestrator backend database.
+// With MySQL, or when there is no SQLite read pool, this is the same as OpenOrchestrator.
+func OpenOrchestratorReader() (db *sql.DB, err error) {
+	writer, err := OpenOrchestrator()
+	if err != nil || !hasSQLiteReadPool() {
+		return writer, err
+	}
+	reader, err := openSQLiteReadPool()
+	if err != nil {
+		// Can still read via the writing connection
+		return writer, nil
+	}
+	sqliteMetricsOnce.Do(func() {
+		go collectSQLiteMetrics(writer, reader)
+	})
+	return reader, nil
+}
+
+// collectSQLiteMetrics periodically publishes contention on the writing connection and on the read pool
+func collectSQLiteMetrics(writer *sql.DB, reader *sql.DB) {
+	for range time.Tick(time.Second) {
+		writerStats := writer.Stats()
+		sqliteWriteWaitCountGauge.Update(writerStats.WaitCount)
+		sqliteWriteWaitMillisGauge.Update(int64(writerStats.WaitDuration / time.Millisecond))
+		readerStats := reader.Stats()
+		sqliteReadWaitCountGauge.Update(readerStats.WaitCount)
+		sqliteReadWaitMillisGauge.Update(int64(readerStats.WaitDuration / time.Millisecond))
+	}
+}
+
+// auditSQLiteBusy counts operations which failed on the database being locked
+func auditSQLiteBusy(err error) error {
+	if err != nil && IsSQLite() && strings.Contains(err.Error(), "database is locked") {
+		sqliteBusyCounter.Inc(1)
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+
+Partitions limit the number of concurrent discoveries per datacenter (or per cluster), such
+that unresponsive instances in one partition, e.g. a network partitioned datacenter, cannot
+occupy the entire pool of discovery workers and starve the healthy partitions.
+
+*/
+
+package discovery
+
+import (
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// Partitions tracks the partition of known instances, and the active discoveries per partition
+type Partitions struct {
+	sync.Mutex
+
+	keyPartitions map[inst.InstanceKey]string
+	active        map[string]uint
+}
+
+// NewPartitions creates an empty set of partitions
+func NewPartitions() *Partitions {
+	return &Partitions{
+		keyPartitions: make(map[inst.InstanceKey]string),
+		active:        make(map[string]uint),
+	}
+}
+
+// IsPartitioningEnabled checks whether discoveries are configured to be partitioned
+func IsPartitioningEnabled() bool {
+	return config.Config.DiscoveryPartitionBy != ""
+}
+
+// InstancePartition returns the partition of given instance, per DiscoveryPartitionBy
+func InstancePartition(instance *inst.Instance) string {
+	if config.Config.DiscoveryPartitionBy == "cluster" {
+		return instance.ClusterName
+	}
+	return instance.DataCenter
+}
+
+// PartitionConcurrencyLimit returns the max number of concurrent discoveries in given partition
+func PartitionConcurrencyLimit(partition string) uint {
+	if limit, found := config.Config.DiscoveryPartitionConcurrencyLimits[partition]; found && limit > 0 {
+		return limit
+	}
+	if config.Config.DiscoveryPartitionMaxConcurrency > 0 {
+		return config.Config.DiscoveryPartitionMaxConcurrency
+	}
+	if limit := config.Config.DiscoveryMaxConcurrency / 2; limit > 0 {
+		return limit
+	}
+	return 1
+}
+
+// SetPartition assigns given key to given partition
+func (p *Partitions) SetPartition(key inst.InstanceKey, partition string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.keyPartitions[key] = partition
+}
+
+// Reset replaces all known key assignments, e.g. with those read from the backend database
+func (p *Partitions) Reset(keyPartitions map[inst.InstanceKey]string) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.keyPartitions = keyPartitions
+}
+
+// Acquire attempts to take a discovery slot in the partition of given key. A key of no known
+// partition belongs in the "" partition. Release must be called once after a successful Acquire.
+func (p *Partitions) Acquire(key inst.InstanceKey) (partition string, acquired bool) {
+	if !IsPartitioningEnabled() {
+		return "", true
+	}
+	p.Lock()
+	defer p.Unlock()
+
+	partition = p.keyPartitions[key]
+	if p.active[partition] >= PartitionConcurrencyLimit(partition) {
+		return partition, false
+	}
+	p.active[partition]++
+	return partition, true
+}
+
+// Release returns a discovery slot to given partition
+func (p *Partitions) Release(partition string) {
+	p.Lock()
+	defer p.Unlock()
+
+	if p.active[partition] > 0 {
+		p.active[partition]--
+	}
+}
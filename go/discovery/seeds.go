@@ -0,0 +1,89 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+/*
+
+Seed sources list instances from external inventories (cloud provider APIs, HTTP inventory
+endpoints), such that new servers get discovered without first being seen via their master's
+SHOW SLAVE HOSTS/processlist, or via an explicit `discover` request.
+
+*/
+
+package discovery
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+const seedRequestTimeout = 10 * time.Second
+
+var seedHTTPClient = &http.Client{Timeout: seedRequestTimeout}
+
+// SeedSource lists instances off an external inventory
+type SeedSource interface {
+	Name() string
+	ReadSeedKeys() ([]inst.InstanceKey, error)
+}
+
+// getSeedSources returns the configured seed sources. These are re-evaluated on each call,
+// such that configuration reload applies.
+func getSeedSources() (sources []SeedSource) {
+	for _, url := range config.Config.DiscoverySeedHTTPURLs {
+		sources = append(sources, NewHTTPSeedSource(url))
+	}
+	if config.Config.DiscoverySeedRDS {
+		sources = append(sources, NewRDSSeedSource(config.Config.DiscoverySeedAWSRegion, config.Config.DiscoverySeedAWSTags))
+	}
+	if config.Config.DiscoverySeedEC2 {
+		sources = append(sources, NewEC2SeedSource(config.Config.DiscoverySeedAWSRegion, config.Config.DiscoverySeedAWSTags))
+	}
+	if config.Config.DiscoverySeedCloudSQLProject != "" {
+		sources = append(sources, NewCloudSQLSeedSource(config.Config.DiscoverySeedCloudSQLProject, config.Config.DiscoverySeedCloudSQLIPType))
+	}
+	return sources
+}
+
+// IsSeedingEnabled checks whether discovery is seeded off any inventory source
+func IsSeedingEnabled() bool {
+	return config.Config.DiscoverySeedIntervalSeconds > 0 && len(getSeedSources()) > 0
+}
+
+// ReadSeedKeys lists instances off all configured seed sources. A failing source does not
+// prevent the others from being read.
+func ReadSeedKeys() (keys []inst.InstanceKey) {
+	seen := inst.NewInstanceKeyMap()
+	for _, source := range getSeedSources() {
+		sourceKeys, err := source.ReadSeedKeys()
+		if err != nil {
+			log.Errorf("ReadSeedKeys: %s: %+v", source.Name(), err)
+			continue
+		}
+		log.Debugf("ReadSeedKeys: %s: %d instances", source.Name(), len(sourceKeys))
+		for _, key := range sourceKeys {
+			if !key.IsValid() || seen.HasKey(key) {
+				continue
+			}
+			seen.AddKey(key)
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
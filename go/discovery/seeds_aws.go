@@ -0,0 +1,175 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/github/orchestrator/go/cloud"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// parseAWSTags splits "key=value" tags
+func parseAWSTags(tags []string) map[string]string {
+	res := make(map[string]string)
+	for _, tag := range tags {
+		tokens := strings.SplitN(tag, "=", 2)
+		if len(tokens) == 2 {
+			res[tokens[0]] = tokens[1]
+		}
+	}
+	return res
+}
+
+// RDSSeedSource lists AWS RDS and Aurora MySQL instances
+type RDSSeedSource struct {
+	region string
+	tags   map[string]string
+}
+
+// NewRDSSeedSource creates a seed source listing RDS instances in given region, carrying all given "key=value" tags
+func NewRDSSeedSource(region string, tags []string) *RDSSeedSource {
+	return &RDSSeedSource{region: region, tags: parseAWSTags(tags)}
+}
+
+func (this *RDSSeedSource) Name() string {
+	return fmt.Sprintf("rds %s", this.region)
+}
+
+type rdsDescribeDBInstancesResponse struct {
+	DBInstances []struct {
+		Engine   string `xml:"Engine"`
+		Endpoint struct {
+			Address string `xml:"Address"`
+			Port    int    `xml:"Port"`
+		} `xml:"Endpoint"`
+		Tags []struct {
+			Key   string `xml:"Key"`
+			Value string `xml:"Value"`
+		} `xml:"TagList>Tag"`
+	} `xml:"DescribeDBInstancesResult>DBInstances>DBInstance"`
+	Marker string `xml:"DescribeDBInstancesResult>Marker"`
+}
+
+func isRDSMySQLEngine(engine string) bool {
+	return engine == "mysql" || engine == "mariadb" || engine == "aurora" || engine == "aurora-mysql"
+}
+
+func (this *RDSSeedSource) ReadSeedKeys() (keys []inst.InstanceKey, err error) {
+	marker := ""
+	for {
+		params := url.Values{}
+		params.Set("Action", "DescribeDBInstances")
+		params.Set("Version", "2014-10-31")
+		params.Set("MaxRecords", "100")
+		if marker != "" {
+			params.Set("Marker", marker)
+		}
+		response := rdsDescribeDBInstancesResponse{}
+		if err := cloud.AWSQuery(this.region, "rds", params, &response); err != nil {
+			return keys, err
+		}
+		for _, dbInstance := range response.DBInstances {
+			if !isRDSMySQLEngine(dbInstance.Engine) || dbInstance.Endpoint.Address == "" {
+				continue
+			}
+			matchingTags := 0
+			for _, tag := range dbInstance.Tags {
+				if value, found := this.tags[tag.Key]; found && value == tag.Value {
+					matchingTags++
+				}
+			}
+			if matchingTags < len(this.tags) {
+				continue
+			}
+			keys = append(keys, inst.InstanceKey{Hostname: dbInstance.Endpoint.Address, Port: dbInstance.Endpoint.Port})
+		}
+		if response.Marker == "" {
+			return keys, nil
+		}
+		marker = response.Marker
+	}
+}
+
+// EC2SeedSource lists running AWS EC2 instances, assumed to run MySQL on DefaultInstancePort
+type EC2SeedSource struct {
+	region string
+	tags   map[string]string
+}
+
+// NewEC2SeedSource creates a seed source listing EC2 instances in given region, carrying all given "key=value" tags
+func NewEC2SeedSource(region string, tags []string) *EC2SeedSource {
+	return &EC2SeedSource{region: region, tags: parseAWSTags(tags)}
+}
+
+func (this *EC2SeedSource) Name() string {
+	return fmt.Sprintf("ec2 %s", this.region)
+}
+
+type ec2DescribeInstancesResponse struct {
+	Reservations []struct {
+		Instances []struct {
+			PrivateDnsName   string `xml:"privateDnsName"`
+			PrivateIpAddress string `xml:"privateIpAddress"`
+		} `xml:"instancesSet>item"`
+	} `xml:"reservationSet>item"`
+	NextToken string `xml:"nextToken"`
+}
+
+func (this *EC2SeedSource) ReadSeedKeys() (keys []inst.InstanceKey, err error) {
+	nextToken := ""
+	for {
+		params := url.Values{}
+		params.Set("Action", "DescribeInstances")
+		params.Set("Version", "2016-11-15")
+		params.Set("MaxResults", "500")
+		params.Set("Filter.1.Name", "instance-state-name")
+		params.Set("Filter.1.Value.1", "running")
+		filterIndex := 2
+		for key, value := range this.tags {
+			params.Set(fmt.Sprintf("Filter.%d.Name", filterIndex), "tag:"+key)
+			params.Set(fmt.Sprintf("Filter.%d.Value.1", filterIndex), value)
+			filterIndex++
+		}
+		if nextToken != "" {
+			params.Set("NextToken", nextToken)
+		}
+		response := ec2DescribeInstancesResponse{}
+		if err := cloud.AWSQuery(this.region, "ec2", params, &response); err != nil {
+			return keys, err
+		}
+		for _, reservation := range response.Reservations {
+			for _, instance := range reservation.Instances {
+				hostname := instance.PrivateDnsName
+				if hostname == "" {
+					hostname = instance.PrivateIpAddress
+				}
+				if hostname == "" {
+					continue
+				}
+				keys = append(keys, inst.InstanceKey{Hostname: hostname, Port: config.Config.DefaultInstancePort})
+			}
+		}
+		if response.NextToken == "" {
+			return keys, nil
+		}
+		nextToken = response.NextToken
+	}
+}
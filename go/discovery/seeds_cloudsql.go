@@ -0,0 +1,55 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/cloud"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// CloudSQLSeedSource lists GCP CloudSQL MySQL instances
+type CloudSQLSeedSource struct {
+	project string
+	ipType  string
+}
+
+// NewCloudSQLSeedSource creates a seed source listing CloudSQL instances of given project, by given address type
+func NewCloudSQLSeedSource(project string, ipType string) *CloudSQLSeedSource {
+	return &CloudSQLSeedSource{project: project, ipType: ipType}
+}
+
+func (this *CloudSQLSeedSource) Name() string {
+	return fmt.Sprintf("cloudsql %s", this.project)
+}
+
+func (this *CloudSQLSeedSource) ReadSeedKeys() (keys []inst.InstanceKey, err error) {
+	instances, err := cloud.ListCloudSQLInstances(this.project)
+	if err != nil {
+		return keys, err
+	}
+	for _, instance := range instances {
+		if !instance.IsMySQL() {
+			continue
+		}
+		if ipAddress := instance.IPAddress(this.ipType); ipAddress != "" {
+			keys = append(keys, inst.InstanceKey{Hostname: ipAddress, Port: cloud.CloudSQLPort})
+		}
+	}
+	return keys, nil
+}
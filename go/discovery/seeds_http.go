@@ -0,0 +1,82 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package discovery
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// HTTPSeedSource lists instances off a generic HTTP inventory endpoint
+type HTTPSeedSource struct {
+	url string
+}
+
+// NewHTTPSeedSource creates a seed source reading given URL
+func NewHTTPSeedSource(url string) *HTTPSeedSource {
+	return &HTTPSeedSource{url: url}
+}
+
+func (this *HTTPSeedSource) Name() string {
+	return fmt.Sprintf("http %s", this.url)
+}
+
+// parseHTTPSeedKeys parses a JSON array of either "host:port" strings or {"Hostname": ..., "Port": ...} objects
+func parseHTTPSeedKeys(body []byte) (keys []inst.InstanceKey, err error) {
+	entries := []json.RawMessage{}
+	if err := json.Unmarshal(body, &entries); err != nil {
+		return keys, err
+	}
+	for _, entry := range entries {
+		var hostPort string
+		if json.Unmarshal(entry, &hostPort) == nil {
+			key, err := inst.ParseRawInstanceKey(hostPort)
+			if err != nil {
+				return keys, err
+			}
+			keys = append(keys, *key)
+			continue
+		}
+		key := inst.InstanceKey{}
+		if err := json.Unmarshal(entry, &key); err != nil {
+			return keys, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (this *HTTPSeedSource) ReadSeedKeys() (keys []inst.InstanceKey, err error) {
+	response, err := seedHTTPClient.Get(this.url)
+	if err != nil {
+		return keys, err
+	}
+	defer response.Body.Close()
+
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return keys, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return keys, fmt.Errorf("%s: unexpected status %d", this.url, response.StatusCode)
+	}
+	return parseHTTPSeedKeys(body)
+}
@@ -0,0 +1,292 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package federation lets independent orchestrator clusters (e.g. one per region) exchange topology
+// metadata and recovery events, and decides which federation member may recover which cluster.
+package federation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+// maxRecoveryEvents is the number of recent recovery events kept per federation member
+const maxRecoveryEvents = 100
+
+// ClusterMetadata is what a federation member shares about a cluster it monitors
+type ClusterMetadata struct {
+	ClusterName    string
+	ClusterAlias   string
+	CountInstances uint
+	Owner          string // owning member per FederationClusterOwners; empty when no rule applies
+}
+
+// TopologyReport is a federation member's report of the clusters it monitors
+type TopologyReport struct {
+	Member   string
+	Clusters []ClusterMetadata
+}
+
+// RecoveryEvent is a recovery a federation member ran
+type RecoveryEvent struct {
+	Id                     int64
+	UID                    string
+	Member                 string
+	ClusterName            string
+	ClusterAlias           string
+	Analysis               string
+	FailedInstance         string
+	SuccessorInstance      string
+	IsSuccessful           bool
+	RecoveryStartTimestamp string
+	RecoveryEndTimestamp   string
+}
+
+// MemberState is this node's latest knowledge of another federation member
+type MemberState struct {
+	Name         string
+	LastPollTime time.Time
+	LastError    string
+	Clusters     []ClusterMetadata
+	Recoveries   []RecoveryEvent // oldest first
+
+	lastRecoveryId int64
+	initialized    bool
+}
+
+// Status is the federation as seen by this member
+type Status struct {
+	Name           string
+	ClusterOwners  map[string]string
+	LocalClusters  []ClusterMetadata
+	Members        []MemberState
+	ContestedNames []string // clusters monitored by multiple members, with no ownership rule
+}
+
+var memberStates = make(map[string]*MemberState)
+var memberStatesMutex sync.Mutex
+
+// IsEnabled returns true when this orchestrator is a federation member
+func IsEnabled() bool {
+	return config.Config.FederationName != ""
+}
+
+// ClusterOwner returns the federation member owning given cluster, by the first FederationClusterOwners
+// pattern, in lexical order, to match the cluster name or alias. Empty result means no rule applies.
+func ClusterOwner(clusterName string, clusterAlias string) string {
+	patterns := []string{}
+	for pattern := range config.Config.FederationClusterOwners {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := regexp.MatchString(pattern, clusterName); matched {
+			return config.Config.FederationClusterOwners[pattern]
+		}
+		if clusterAlias == "" {
+			continue
+		}
+		if matched, _ := regexp.MatchString(pattern, clusterAlias); matched {
+			return config.Config.FederationClusterOwners[pattern]
+		}
+	}
+	return ""
+}
+
+// monitoringMembers returns the other federation members reporting given cluster
+func monitoringMembers(clusterName string) (members []string) {
+	memberStatesMutex.Lock()
+	defer memberStatesMutex.Unlock()
+	for _, memberState := range memberStates {
+		for _, cluster := range memberState.Clusters {
+			if cluster.ClusterName == clusterName {
+				members = append(members, memberState.Name)
+				break
+			}
+		}
+	}
+	sort.Strings(members)
+	return members
+}
+
+// MayRecoverCluster checks whether this federation member may recover given cluster. With an ownership rule,
+// only the owner may. With none, a member may recover a cluster no other member reports.
+func MayRecoverCluster(clusterName string, clusterAlias string) error {
+	if !IsEnabled() {
+		return nil
+	}
+	if owner := ClusterOwner(clusterName, clusterAlias); owner != "" {
+		if owner != config.Config.FederationName {
+			return fmt.Errorf("Cluster %s is owned by federation member %s", clusterName, owner)
+		}
+		return nil
+	}
+	if members := monitoringMembers(clusterName); len(members) > 0 {
+		return fmt.Errorf("Cluster %s has no federation owner and is also monitored by %s", clusterName, strings.Join(members, ", "))
+	}
+	return nil
+}
+
+// NewClusterMetadata returns the federation metadata of a local cluster
+func NewClusterMetadata(clusterInfo *inst.ClusterInfo) ClusterMetadata {
+	return ClusterMetadata{
+		ClusterName:    clusterInfo.ClusterName,
+		ClusterAlias:   clusterInfo.ClusterAlias,
+		CountInstances: clusterInfo.CountInstances,
+		Owner:          ClusterOwner(clusterInfo.ClusterName, clusterInfo.ClusterAlias),
+	}
+}
+
+// GetStatus returns the federation as seen by this member, given the local clusters
+func GetStatus(localClusters []ClusterMetadata) *Status {
+	status := &Status{
+		Name:           config.Config.FederationName,
+		ClusterOwners:  config.Config.FederationClusterOwners,
+		LocalClusters:  localClusters,
+		Members:        []MemberState{},
+		ContestedNames: []string{},
+	}
+	monitoredBy := map[string][]ClusterMetadata{}
+	for _, cluster := range localClusters {
+		monitoredBy[cluster.ClusterName] = append(monitoredBy[cluster.ClusterName], cluster)
+	}
+	memberStatesMutex.Lock()
+	for _, memberState := range memberStates {
+		status.Members = append(status.Members, *memberState)
+		for _, cluster := range memberState.Clusters {
+			monitoredBy[cluster.ClusterName] = append(monitoredBy[cluster.ClusterName], cluster)
+		}
+	}
+	memberStatesMutex.Unlock()
+	sort.Slice(status.Members, func(i, j int) bool { return status.Members[i].Name < status.Members[j].Name })
+
+	for clusterName, clusters := range monitoredBy {
+		if len(clusters) > 1 && ClusterOwner(clusterName, clusters[0].ClusterAlias) == "" {
+			status.ContestedNames = append(status.ContestedNames, clusterName)
+		}
+	}
+	sort.Strings(status.ContestedNames)
+	return status
+}
+
+// redactURL strips the credentials off a URL, so that it may be logged and shown
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "<unparseable URL>"
+	}
+	u.User = nil
+	return u.String()
+}
+
+// readMemberAPI reads and decodes a JSON response of a federation member's API
+func readMemberAPI(baseURL string, path string, v interface{}) error {
+	client := &http.Client{Timeout: time.Duration(config.Config.FederationPollSeconds) * time.Second}
+	memberURL := fmt.Sprintf("%s/api/%s", strings.TrimRight(baseURL, "/"), path)
+	response, err := client.Get(memberURL)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			urlErr.URL = redactURL(urlErr.URL)
+			return urlErr
+		}
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", redactURL(memberURL), response.Status)
+	}
+	return json.NewDecoder(response.Body).Decode(v)
+}
+
+// pollMember refreshes the topology metadata and recovery events of a federation member. Recovery events
+// new since the previous poll are audited locally.
+func pollMember(name string, baseURL string) {
+	memberStatesMutex.Lock()
+	memberState, found := memberStates[name]
+	if !found {
+		memberState = &MemberState{Name: name, Clusters: []ClusterMetadata{}, Recoveries: []RecoveryEvent{}}
+		memberStates[name] = memberState
+	}
+	lastRecoveryId, initialized := memberState.lastRecoveryId, memberState.initialized
+	memberStatesMutex.Unlock()
+
+	topology := &TopologyReport{}
+	err := readMemberAPI(baseURL, "federation/topology", topology)
+	recoveries := []RecoveryEvent{}
+	if err == nil {
+		recoveriesPath := "federation/recoveries"
+		if initialized {
+			recoveriesPath = fmt.Sprintf("%s?since=%d", recoveriesPath, lastRecoveryId)
+		}
+		err = readMemberAPI(baseURL, recoveriesPath, &recoveries)
+	}
+	if err == nil && topology.Member != name {
+		err = fmt.Errorf("federation member %s identifies as %s", name, topology.Member)
+	}
+
+	memberStatesMutex.Lock()
+	defer memberStatesMutex.Unlock()
+	memberState.LastPollTime = time.Now()
+	if err != nil {
+		memberState.LastError = err.Error()
+		log.Errorf("federation: polling %s: %+v", name, err)
+		return
+	}
+	memberState.LastError = ""
+	memberState.Clusters = topology.Clusters
+	for _, recovery := range recoveries {
+		if recovery.Id <= memberState.lastRecoveryId {
+			continue
+		}
+		memberState.lastRecoveryId = recovery.Id
+		memberState.Recoveries = append(memberState.Recoveries, recovery)
+		if initialized {
+			inst.AuditOperation("federation-recovery", nil, fmt.Sprintf("member=%s cluster=%s analysis=%s failed=%s successor=%s successful=%t uid=%s",
+				name, recovery.ClusterName, recovery.Analysis, recovery.FailedInstance, recovery.SuccessorInstance, recovery.IsSuccessful, recovery.UID))
+		}
+	}
+	if len(memberState.Recoveries) > maxRecoveryEvents {
+		memberState.Recoveries = memberState.Recoveries[len(memberState.Recoveries)-maxRecoveryEvents:]
+	}
+	memberState.initialized = true
+}
+
+// PollMembers refreshes the state of all federation members
+func PollMembers() {
+	if !IsEnabled() {
+		return
+	}
+	var wg sync.WaitGroup
+	for name, baseURL := range config.Config.FederationMembers {
+		wg.Add(1)
+		go func(name, baseURL string) {
+			defer wg.Done()
+			pollMember(name, baseURL)
+		}(name, baseURL)
+	}
+	wg.Wait()
+}
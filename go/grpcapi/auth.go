@@ -0,0 +1,181 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/ssl"
+
+	"github.com/martini-contrib/auth"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// authenticationMethod returns the configured AuthenticationMethod, normalized
+func authenticationMethod() string {
+	return strings.ToLower(config.Config.AuthenticationMethod)
+}
+
+// requiresClientCertificates checks whether given TLS configuration only accepts verified client certificates
+func requiresClientCertificates(tlsConfig *tls.Config) bool {
+	return tlsConfig != nil && tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert
+}
+
+// validateAuthentication makes sure the gRPC API can enforce the configured AuthenticationMethod.
+// "basic" and "multi" credentials are passed as call metadata, and "mtls" identities are read off the
+// connection. Other methods rely on cookies, headers or redirects which gRPC clients do not carry; with those
+// we only serve when client certificates are required.
+func validateAuthentication(tlsConfig *tls.Config) error {
+	switch authenticationMethod() {
+	case "":
+		return nil
+	case "basic", "multi":
+		return nil
+	case "mtls":
+		if tlsConfig == nil {
+			return fmt.Errorf("gRPC API: AuthenticationMethod mtls requires UseSSL")
+		}
+		return nil
+	default:
+		if !requiresClientCertificates(tlsConfig) {
+			return fmt.Errorf("gRPC API cannot enforce AuthenticationMethod %s; set UseSSL, UseMutualTLS and SSLRequireClientCert to serve it with client certificates", config.Config.AuthenticationMethod)
+		}
+		return nil
+	}
+}
+
+// basicCredentials returns the username & password of an "authorization: Basic ..." metadata entry
+func basicCredentials(ctx context.Context) (username string, password string, ok bool) {
+	md, found := metadata.FromIncomingContext(ctx)
+	if !found {
+		return "", "", false
+	}
+	for _, value := range md.Get("authorization") {
+		if !strings.HasPrefix(value, "Basic ") {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, "Basic "))
+		if err != nil {
+			return "", "", false
+		}
+		tokens := strings.SplitN(string(decoded), ":", 2)
+		if len(tokens) != 2 {
+			return "", "", false
+		}
+		return tokens[0], tokens[1], true
+	}
+	return "", "", false
+}
+
+// clientCertificate returns the verified certificate presented by the peer of a call, or nil if there is none
+func clientCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	for _, chain := range tlsInfo.State.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[0]
+		}
+	}
+	return nil
+}
+
+// verifyClientCertificate applies SSLValidOUs & SSLValidSANs to the peer's certificate, as the web API does
+func verifyClientCertificate(ctx context.Context) error {
+	cert := clientCertificate(ctx)
+	if cert == nil {
+		return status.Error(codes.Unauthenticated, "a verified client certificate is required")
+	}
+	if !config.Config.UseMutualTLS {
+		return nil
+	}
+	for _, ou := range cert.Subject.OrganizationalUnit {
+		if ssl.HasString(ou, config.Config.SSLValidOUs) {
+			return nil
+		}
+	}
+	for _, san := range ssl.CertificateSANs(cert) {
+		if ssl.HasString(san, config.Config.SSLValidSANs) {
+			return nil
+		}
+	}
+	return status.Error(codes.PermissionDenied, "client certificate has no valid OU or SAN")
+}
+
+// authenticate checks the credentials of a call against the configured AuthenticationMethod
+func authenticate(ctx context.Context) error {
+	switch authenticationMethod() {
+	case "":
+		return nil
+	case "basic":
+		if config.Config.HTTPAuthUser == "" {
+			// As with the web API, which in this case runs without authentication
+			return nil
+		}
+		username, password, ok := basicCredentials(ctx)
+		if !ok || !auth.SecureCompare(username, config.Config.HTTPAuthUser) || !auth.SecureCompare(password, config.Config.HTTPAuthPassword) {
+			return status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		return nil
+	case "multi":
+		username, password, ok := basicCredentials(ctx)
+		if !ok {
+			return status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		if username == "readonly" {
+			// All calls are read-only
+			return nil
+		}
+		if !auth.SecureCompare(username, config.Config.HTTPAuthUser) || !auth.SecureCompare(password, config.Config.HTTPAuthPassword) {
+			return status.Error(codes.Unauthenticated, "invalid credentials")
+		}
+		return nil
+	default:
+		// "mtls", and any method only served with required client certificates
+		return verifyClientCertificate(ctx)
+	}
+}
+
+func authUnaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := authenticate(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func authStreamInterceptor(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := authenticate(stream.Context()); err != nil {
+		return err
+	}
+	return handler(srv, stream)
+}
@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+
+	test "github.com/openark/golib/tests"
+	"google.golang.org/grpc/metadata"
+)
+
+func basicAuthContext(username, password string) context.Context {
+	credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic "+credentials))
+}
+
+func TestAuthenticate(t *testing.T) {
+	defer func(method, user, password string) {
+		config.Config.AuthenticationMethod = method
+		config.Config.HTTPAuthUser = user
+		config.Config.HTTPAuthPassword = password
+	}(config.Config.AuthenticationMethod, config.Config.HTTPAuthUser, config.Config.HTTPAuthPassword)
+	config.Config.HTTPAuthUser = "orc"
+	config.Config.HTTPAuthPassword = "secret"
+
+	config.Config.AuthenticationMethod = ""
+	test.S(t).ExpectNil(authenticate(context.Background()))
+
+	config.Config.AuthenticationMethod = "basic"
+	test.S(t).ExpectNotNil(authenticate(context.Background()))
+	test.S(t).ExpectNotNil(authenticate(basicAuthContext("orc", "wrong")))
+	test.S(t).ExpectNotNil(authenticate(basicAuthContext("readonly", "any")))
+	test.S(t).ExpectNil(authenticate(basicAuthContext("orc", "secret")))
+
+	config.Config.AuthenticationMethod = "multi"
+	test.S(t).ExpectNotNil(authenticate(context.Background()))
+	test.S(t).ExpectNotNil(authenticate(basicAuthContext("orc", "wrong")))
+	test.S(t).ExpectNil(authenticate(basicAuthContext("readonly", "any")))
+	test.S(t).ExpectNil(authenticate(basicAuthContext("orc", "secret")))
+
+	config.Config.AuthenticationMethod = "mtls"
+	test.S(t).ExpectNotNil(authenticate(basicAuthContext("orc", "secret")))
+}
+
+func TestValidateAuthentication(t *testing.T) {
+	defer func(method string) { config.Config.AuthenticationMethod = method }(config.Config.AuthenticationMethod)
+
+	config.Config.AuthenticationMethod = "multi"
+	test.S(t).ExpectNil(validateAuthentication(nil))
+
+	config.Config.AuthenticationMethod = "mtls"
+	test.S(t).ExpectNotNil(validateAuthentication(nil))
+
+	config.Config.AuthenticationMethod = "oidc"
+	test.S(t).ExpectNotNil(validateAuthentication(nil))
+	test.S(t).ExpectNotNil(validateAuthentication(&tls.Config{ClientAuth: tls.VerifyClientCertIfGiven}))
+	test.S(t).ExpectNil(validateAuthentication(&tls.Config{ClientAuth: tls.RequireAndVerifyClientCert}))
+}
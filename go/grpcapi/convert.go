@@ -0,0 +1,140 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"database/sql"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+)
+
+// nullInt64 converts to -1 when the value is unknown
+func nullInt64(value sql.NullInt64) int64 {
+	if !value.Valid {
+		return -1
+	}
+	return value.Int64
+}
+
+func toInstanceKey(key *inst.InstanceKey) *InstanceKey {
+	if key == nil {
+		return nil
+	}
+	return &InstanceKey{Hostname: key.Hostname, Port: int32(key.Port)}
+}
+
+func toInstanceKeys(keyMap *inst.InstanceKeyMap) []*InstanceKey {
+	instanceKeys := keyMap.GetInstanceKeys()
+	result := []*InstanceKey{}
+	for i := range instanceKeys {
+		result = append(result, toInstanceKey(&instanceKeys[i]))
+	}
+	return result
+}
+
+func toInstance(instance *inst.Instance) *Instance {
+	return &Instance{
+		Key:                      toInstanceKey(&instance.Key),
+		InstanceAlias:            instance.InstanceAlias,
+		ServerId:                 uint32(instance.ServerID),
+		ServerUuid:               instance.ServerUUID,
+		Version:                  instance.Version,
+		ReadOnly:                 instance.ReadOnly,
+		LogBinEnabled:            instance.LogBinEnabled,
+		LogReplicaUpdatesEnabled: instance.LogSlaveUpdatesEnabled,
+		BinlogFormat:             instance.Binlog_format,
+		MasterKey:                toInstanceKey(&instance.MasterKey),
+		IsCoMaster:               instance.IsCoMaster,
+		ReplicationIoRunning:     instance.ReplicationIOThreadState.IsRunning(),
+		ReplicationSqlRunning:    instance.ReplicationSQLThreadState.IsRunning(),
+		LastIoError:              instance.LastIOError,
+		LastSqlError:             instance.LastSQLError,
+		SecondsBehindMaster:      nullInt64(instance.SecondsBehindMaster),
+		ReplicationLagSeconds:    nullInt64(instance.SlaveLagSeconds),
+		GtidMode:                 instance.GTIDMode,
+		ExecutedGtidSet:          instance.ExecutedGtidSet,
+		GtidErrant:               instance.GtidErrant,
+		ReplicaKeys:              toInstanceKeys(&instance.SlaveHosts),
+		ClusterName:              instance.ClusterName,
+		SuggestedClusterAlias:    instance.SuggestedClusterAlias,
+		DataCenter:               instance.DataCenter,
+		Region:                   instance.Region,
+		PhysicalEnvironment:      instance.PhysicalEnvironment,
+		ReplicationDepth:         uint32(instance.ReplicationDepth),
+		PromotionRule:            string(instance.PromotionRule),
+		IsLastCheckValid:         instance.IsLastCheckValid,
+		IsUpToDate:               instance.IsUpToDate,
+		IsDowntimed:              instance.IsDowntimed,
+		DowntimeReason:           instance.DowntimeReason,
+		DowntimeEndTimestamp:     instance.DowntimeEndTimestamp,
+		LastSeenTimestamp:        instance.LastSeenTimestamp,
+		Problems:                 append([]string{}, instance.Problems...),
+	}
+}
+
+func toReplicationAnalysis(analysis *inst.ReplicationAnalysis) *ReplicationAnalysis {
+	structureAnalysis := []string{}
+	for _, code := range analysis.StructureAnalysis {
+		structureAnalysis = append(structureAnalysis, string(code))
+	}
+	return &ReplicationAnalysis{
+		AnalyzedInstanceKey:           toInstanceKey(&analysis.AnalyzedInstanceKey),
+		AnalyzedInstanceMasterKey:     toInstanceKey(&analysis.AnalyzedInstanceMasterKey),
+		ClusterName:                   analysis.ClusterDetails.ClusterName,
+		ClusterAlias:                  analysis.ClusterDetails.ClusterAlias,
+		Analysis:                      string(analysis.Analysis),
+		Description:                   analysis.Description,
+		StructureAnalysis:             structureAnalysis,
+		IsMaster:                      analysis.IsMaster,
+		IsCoMaster:                    analysis.IsCoMaster,
+		LastCheckValid:                analysis.LastCheckValid,
+		CountReplicas:                 uint32(analysis.CountReplicas),
+		CountValidReplicas:            uint32(analysis.CountValidReplicas),
+		CountValidReplicatingReplicas: uint32(analysis.CountValidReplicatingReplicas),
+		ReplicationDepth:              uint32(analysis.ReplicationDepth),
+		IsFailingToConnectToMaster:    analysis.IsFailingToConnectToMaster,
+		IsDowntimed:                   analysis.IsDowntimed,
+		IsActionableRecovery:          analysis.IsActionableRecovery,
+		CommandHint:                   analysis.CommandHint,
+	}
+}
+
+func toTopologyRecovery(topologyRecovery *logic.TopologyRecovery) *TopologyRecovery {
+	return &TopologyRecovery{
+		Id:                        topologyRecovery.Id,
+		Uid:                       topologyRecovery.UID,
+		AnalysisEntry:             toReplicationAnalysis(&topologyRecovery.AnalysisEntry),
+		SuccessorKey:              toInstanceKey(topologyRecovery.SuccessorKey),
+		SuccessorAlias:            topologyRecovery.SuccessorAlias,
+		IsActive:                  topologyRecovery.IsActive,
+		IsSuccessful:              topologyRecovery.IsSuccessful,
+		LostReplicas:              toInstanceKeys(&topologyRecovery.LostReplicas),
+		ParticipatingInstanceKeys: toInstanceKeys(&topologyRecovery.ParticipatingInstanceKeys),
+		AllErrors:                 append([]string{}, topologyRecovery.AllErrors...),
+		RecoveryStartTimestamp:    topologyRecovery.RecoveryStartTimestamp,
+		RecoveryEndTimestamp:      topologyRecovery.RecoveryEndTimestamp,
+		ProcessingNodeHostname:    topologyRecovery.ProcessingNodeHostname,
+		Acknowledged:              topologyRecovery.Acknowledged,
+		AcknowledgedAt:            topologyRecovery.AcknowledgedAt,
+		AcknowledgedBy:            topologyRecovery.AcknowledgedBy,
+		AcknowledgedComment:       topologyRecovery.AcknowledgedComment,
+		LastDetectionId:           topologyRecovery.LastDetectionId,
+		RelatedRecoveryId:         topologyRecovery.RelatedRecoveryId,
+		Type:                      string(topologyRecovery.Type),
+	}
+}
@@ -0,0 +1,241 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+// Package grpcapi serves a read-only gRPC API for automation clients. The protobuf messages and service stubs
+// are generated from orchestrator.proto; regenerate with:
+//
+//	protoc --go_out=plugins=grpc,paths=source_relative:. orchestrator.proto
+package grpcapi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+
+	"github.com/openark/golib/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Orchestrator gRPC service on top of orchestrator's backend. Watch streams poll the backend
+// every pollInterval and send whatever changed since their previous poll.
+type Server struct {
+	UnimplementedOrchestratorServer
+	pollInterval time.Duration
+}
+
+// NewServer returns a server polling at the configured GRPCWatchPollSeconds
+func NewServer() *Server {
+	return &Server{pollInterval: time.Duration(config.Config.GRPCWatchPollSeconds) * time.Second}
+}
+
+// Serve listens on given address and serves the gRPC API; it only returns on error. A nil tlsConfig serves plaintext.
+// All calls are authenticated per AuthenticationMethod; Serve refuses to start when it cannot enforce that method.
+func Serve(listenAddress string, tlsConfig *tls.Config) error {
+	if err := validateAuthentication(tlsConfig); err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", listenAddress)
+	if err != nil {
+		return err
+	}
+	options := []grpc.ServerOption{
+		grpc.UnaryInterceptor(authUnaryInterceptor),
+		grpc.StreamInterceptor(authStreamInterceptor),
+	}
+	if tlsConfig != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+	server := grpc.NewServer(options...)
+	RegisterOrchestratorServer(server, NewServer())
+	return server.Serve(listener)
+}
+
+// figureClusterName resolves a cluster name from a hint: a cluster name, alias, or any instance in the cluster.
+// An empty hint stands for all clusters.
+func figureClusterName(hint string) (string, error) {
+	if hint == "" {
+		return "", nil
+	}
+	instanceKey, _ := inst.ParseRawInstanceKey(hint)
+	clusterName, err := inst.FigureClusterName(hint, instanceKey, nil)
+	if err != nil {
+		return "", status.Error(codes.NotFound, err.Error())
+	}
+	return clusterName, nil
+}
+
+func timestampNow() string {
+	return time.Now().Format("2006-01-02 15:04:05")
+}
+
+// GetInstance returns a single instance
+func (this *Server) GetInstance(ctx context.Context, request *GetInstanceRequest) (*Instance, error) {
+	if request.GetKey().GetHostname() == "" {
+		return nil, status.Error(codes.InvalidArgument, "instance key hostname is required")
+	}
+	instanceKey, err := inst.NewResolveInstanceKey(request.GetKey().GetHostname(), int(request.GetKey().GetPort()))
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	instance, found, err := inst.ReadInstance(instanceKey)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "instance %s not found", instanceKey.DisplayString())
+	}
+	return toInstance(instance), nil
+}
+
+// GetClusterInstances returns all instances of a cluster
+func (this *Server) GetClusterInstances(ctx context.Context, request *GetClusterInstancesRequest) (*InstanceList, error) {
+	if request.GetClusterHint() == "" {
+		return nil, status.Error(codes.InvalidArgument, "cluster hint is required")
+	}
+	clusterName, err := figureClusterName(request.GetClusterHint())
+	if err != nil {
+		return nil, err
+	}
+	instances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result := &InstanceList{Instances: []*Instance{}}
+	for _, instance := range instances {
+		result.Instances = append(result.Instances, toInstance(instance))
+	}
+	return result, nil
+}
+
+// GetReplicationAnalysis returns the current replication analysis of a cluster, or of all clusters
+func (this *Server) GetReplicationAnalysis(ctx context.Context, request *GetReplicationAnalysisRequest) (*ReplicationAnalysisList, error) {
+	clusterName, err := figureClusterName(request.GetClusterHint())
+	if err != nil {
+		return nil, err
+	}
+	analyses, err := inst.GetReplicationAnalysis(clusterName, &inst.ReplicationAnalysisHints{IncludeDowntimed: request.GetIncludeDowntimed()})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result := &ReplicationAnalysisList{Analyses: []*ReplicationAnalysis{}}
+	for i := range analyses {
+		result.Analyses = append(result.Analyses, toReplicationAnalysis(&analyses[i]))
+	}
+	return result, nil
+}
+
+// GetRecoveries returns a page of recent recoveries of a cluster, or of all clusters
+func (this *Server) GetRecoveries(ctx context.Context, request *GetRecoveriesRequest) (*TopologyRecoveryList, error) {
+	clusterName, err := figureClusterName(request.GetClusterHint())
+	if err != nil {
+		return nil, err
+	}
+	recoveries, err := logic.ReadRecentRecoveries(clusterName, request.GetUnacknowledgedOnly(), int(request.GetPage()))
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	result := &TopologyRecoveryList{Recoveries: []*TopologyRecovery{}}
+	for i := range recoveries {
+		result.Recoveries = append(result.Recoveries, toTopologyRecovery(&recoveries[i]))
+	}
+	return result, nil
+}
+
+// WatchAnalysis streams changes in replication analysis. The first poll reports all current problems as detected.
+func (this *Server) WatchAnalysis(request *WatchAnalysisRequest, stream Orchestrator_WatchAnalysisServer) error {
+	clusterName, err := figureClusterName(request.GetClusterHint())
+	if err != nil {
+		return err
+	}
+	hints := &inst.ReplicationAnalysisHints{IncludeDowntimed: request.GetIncludeDowntimed()}
+	previous := map[string]*ReplicationAnalysis{}
+
+	ticker := time.NewTicker(this.pollInterval)
+	defer ticker.Stop()
+	for {
+		analyses, err := inst.GetReplicationAnalysis(clusterName, hints)
+		if err != nil {
+			// Transient backend errors should not break the stream; we try again on next poll
+			log.Errore(err)
+		} else {
+			current := map[string]*ReplicationAnalysis{}
+			for i := range analyses {
+				current[analyses[i].AnalyzedInstanceKey.StringCode()] = toReplicationAnalysis(&analyses[i])
+			}
+			timestamp := timestampNow()
+			for _, event := range analysisEvents(previous, current) {
+				event.Timestamp = timestamp
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+			}
+			previous = current
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// WatchRecoveries streams recovery state changes. The first poll reports all active recoveries as started.
+func (this *Server) WatchRecoveries(request *WatchRecoveriesRequest, stream Orchestrator_WatchRecoveriesServer) error {
+	clusterName, err := figureClusterName(request.GetClusterHint())
+	if err != nil {
+		return err
+	}
+	var previous map[string]*TopologyRecovery
+
+	ticker := time.NewTicker(this.pollInterval)
+	defer ticker.Stop()
+	for {
+		recoveries, err := logic.ReadRecentRecoveries(clusterName, false, 0)
+		if err != nil {
+			log.Errore(err)
+		} else {
+			current := []*TopologyRecovery{}
+			for i := range recoveries {
+				current = append(current, toTopologyRecovery(&recoveries[i]))
+			}
+			timestamp := timestampNow()
+			for _, event := range recoveryEvents(previous, current) {
+				event.Timestamp = timestamp
+				if err := stream.Send(event); err != nil {
+					return err
+				}
+			}
+			// A recovery which falls off the first page is older than all on it, and will not show up again
+			previous = map[string]*TopologyRecovery{}
+			for _, recovery := range current {
+				previous[recovery.Uid] = recovery
+			}
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package grpcapi
+
+import (
+	"sort"
+	"strings"
+)
+
+func sortedKeys(m map[string]*ReplicationAnalysis) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// analysisEvents compares two polls of replication analysis, each mapping an analyzed instance to its analysis
+func analysisEvents(previous map[string]*ReplicationAnalysis, current map[string]*ReplicationAnalysis) []*AnalysisEvent {
+	events := []*AnalysisEvent{}
+	for _, key := range sortedKeys(current) {
+		analysis := current[key]
+		previousAnalysis, found := previous[key]
+		if !found {
+			events = append(events, &AnalysisEvent{Type: AnalysisEvent_DETECTED, Analysis: analysis})
+			continue
+		}
+		if previousAnalysis.Analysis != analysis.Analysis ||
+			strings.Join(previousAnalysis.StructureAnalysis, ",") != strings.Join(analysis.StructureAnalysis, ",") {
+			events = append(events, &AnalysisEvent{Type: AnalysisEvent_CHANGED, Analysis: analysis})
+		}
+	}
+	for _, key := range sortedKeys(previous) {
+		if _, found := current[key]; !found {
+			events = append(events, &AnalysisEvent{Type: AnalysisEvent_CLEARED, Analysis: previous[key]})
+		}
+	}
+	return events
+}
+
+// recoveryEvents compares two polls of recent recoveries. current is ordered newest first, and events are returned
+// oldest first. A nil previous stands for the first poll, which only reports active recoveries.
+func recoveryEvents(previous map[string]*TopologyRecovery, current []*TopologyRecovery) []*RecoveryEvent {
+	events := []*RecoveryEvent{}
+	for i := len(current) - 1; i >= 0; i-- {
+		recovery := current[i]
+		if previous == nil {
+			if recovery.IsActive {
+				events = append(events, &RecoveryEvent{Type: RecoveryEvent_STARTED, Recovery: recovery})
+			}
+			continue
+		}
+		previousRecovery, found := previous[recovery.Uid]
+		if !found {
+			// Possibly started and completed in between polls
+			events = append(events, &RecoveryEvent{Type: RecoveryEvent_STARTED, Recovery: recovery})
+		}
+		if !recovery.IsActive && (!found || previousRecovery.IsActive) {
+			events = append(events, &RecoveryEvent{Type: RecoveryEvent_COMPLETED, Recovery: recovery})
+		}
+		if recovery.Acknowledged && (!found || !previousRecovery.Acknowledged) {
+			events = append(events, &RecoveryEvent{Type: RecoveryEvent_ACKNOWLEDGED, Recovery: recovery})
+		}
+	}
+	return events
+}
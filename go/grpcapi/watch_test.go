@@ -0,0 +1,77 @@
+package grpcapi
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestAnalysisEvents(t *testing.T) {
+	previous := map[string]*ReplicationAnalysis{
+		"db1:3306": {Analysis: "DeadMaster"},
+		"db2:3306": {Analysis: "AllMasterReplicasNotReplicating"},
+		"db3:3306": {Analysis: "NoProblem", StructureAnalysis: []string{"NoLoggingReplicasStructureWarning"}},
+	}
+	current := map[string]*ReplicationAnalysis{
+		"db2:3306": {Analysis: "AllMasterReplicasNotReplicating"},
+		"db3:3306": {Analysis: "NoProblem", StructureAnalysis: []string{"DifferentGTIDModesStructureWarning"}},
+		"db4:3306": {Analysis: "UnreachableMaster"},
+	}
+	{
+		events := analysisEvents(map[string]*ReplicationAnalysis{}, current)
+		test.S(t).ExpectEquals(len(events), 3)
+		for _, event := range events {
+			test.S(t).ExpectEquals(event.Type, AnalysisEvent_DETECTED)
+		}
+	}
+	{
+		events := analysisEvents(previous, current)
+		test.S(t).ExpectEquals(len(events), 3)
+		test.S(t).ExpectEquals(events[0].Type, AnalysisEvent_CHANGED)
+		test.S(t).ExpectEquals(events[0].Analysis.StructureAnalysis[0], "DifferentGTIDModesStructureWarning")
+		test.S(t).ExpectEquals(events[1].Type, AnalysisEvent_DETECTED)
+		test.S(t).ExpectEquals(events[1].Analysis.Analysis, "UnreachableMaster")
+		test.S(t).ExpectEquals(events[2].Type, AnalysisEvent_CLEARED)
+		test.S(t).ExpectEquals(events[2].Analysis.Analysis, "DeadMaster")
+	}
+	{
+		events := analysisEvents(current, current)
+		test.S(t).ExpectEquals(len(events), 0)
+	}
+}
+
+func TestRecoveryEvents(t *testing.T) {
+	current := []*TopologyRecovery{
+		{Uid: "new-completed", IsActive: false},
+		{Uid: "new-active", IsActive: true},
+		{Uid: "acknowledged", IsActive: false, Acknowledged: true},
+		{Uid: "completed", IsActive: false},
+		{Uid: "unchanged", IsActive: false},
+	}
+	{
+		// first poll only reports active recoveries
+		events := recoveryEvents(nil, current)
+		test.S(t).ExpectEquals(len(events), 1)
+		test.S(t).ExpectEquals(events[0].Type, RecoveryEvent_STARTED)
+		test.S(t).ExpectEquals(events[0].Recovery.Uid, "new-active")
+	}
+	{
+		previous := map[string]*TopologyRecovery{
+			"acknowledged": {Uid: "acknowledged", IsActive: false},
+			"completed":    {Uid: "completed", IsActive: true},
+			"unchanged":    {Uid: "unchanged", IsActive: false},
+		}
+		events := recoveryEvents(previous, current)
+		test.S(t).ExpectEquals(len(events), 5)
+		test.S(t).ExpectEquals(events[0].Type, RecoveryEvent_COMPLETED)
+		test.S(t).ExpectEquals(events[0].Recovery.Uid, "completed")
+		test.S(t).ExpectEquals(events[1].Type, RecoveryEvent_ACKNOWLEDGED)
+		test.S(t).ExpectEquals(events[1].Recovery.Uid, "acknowledged")
+		test.S(t).ExpectEquals(events[2].Type, RecoveryEvent_STARTED)
+		test.S(t).ExpectEquals(events[2].Recovery.Uid, "new-active")
+		test.S(t).ExpectEquals(events[3].Type, RecoveryEvent_STARTED)
+		test.S(t).ExpectEquals(events[3].Recovery.Uid, "new-completed")
+		test.S(t).ExpectEquals(events[4].Type, RecoveryEvent_COMPLETED)
+		test.S(t).ExpectEquals(events[4].Recovery.Uid, "new-completed")
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
@@ -188,6 +189,42 @@ func (this *HttpAPI) InstanceReplicas(params martini.Params, r render.Render, re
 	r.JSON(http.StatusOK, replicas)
 }
 
+// ReplicationCompatibility returns, for each replica of given master as promotion candidate, the sibling replicas
+// it would strand: those which cannot replicate from it, and those which are ahead of it.
+func (this *HttpAPI) ReplicationCompatibility(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	matrix, err := inst.ReadReplicationCompatibilityMatrix(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, matrix)
+}
+
+// DownstreamHeads returns the instances replicating from given master via a named replication channel
+func (this *HttpAPI) DownstreamHeads(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	instance, found, err := inst.ReadInstance(&instanceKey)
+	if !found || err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read instance: %+v", instanceKey)})
+		return
+	}
+	heads, err := inst.ReadDownstreamHeads(instance)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, heads)
+}
+
 // Instance reads and returns an instance's details.
 func (this *HttpAPI) Instance(params martini.Params, r render.Render, req *http.Request) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
@@ -204,6 +241,22 @@ func (this *HttpAPI) Instance(params martini.Params, r render.Render, req *http.
 	r.JSON(http.StatusOK, instance)
 }
 
+// InstancePollInterval returns the effective interval at which an instance is polled
+func (this *HttpAPI) InstancePollInterval(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, inst.ReadInstancePollInterval(&instanceKey))
+}
+
+// InstancePollIntervals returns the effective intervals at which instances are polled by this node
+func (this *HttpAPI) InstancePollIntervals(params martini.Params, r render.Render, req *http.Request) {
+	r.JSON(http.StatusOK, inst.ReadInstancePollIntervals())
+}
+
 // AsyncDiscover issues an asynchronous read on an instance. This is
 // useful for bulk loads of a new set of instances and will not block
 // if the instance is slow to respond or not reachable.
@@ -452,6 +505,12 @@ func (this *HttpAPI) BeginDowntime(params martini.Params, r render.Render, req *
 	}
 	duration := time.Duration(durationSeconds) * time.Second
 	downtime := inst.NewDowntime(&instanceKey, params["owner"], params["reason"], duration)
+	switch downtime.ExtendWhile = params["condition"]; downtime.ExtendWhile {
+	case "", inst.DowntimeExtendWhileBackupLock:
+	default:
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Unknown downtime extension condition: %s", downtime.ExtendWhile)})
+		return
+	}
 	if orcraft.IsRaftEnabled() {
 		_, err = orcraft.PublishCommand("begin-downtime", downtime)
 	} else {
@@ -466,6 +525,47 @@ func (this *HttpAPI) BeginDowntime(params martini.Params, r render.Render, req *
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime begun: %+v", instanceKey), Details: instanceKey})
 }
 
+// BeginDowntimeTagged sets a downtime flag with default duration on all instances matching the tag expression
+// given via ?tag=, e.g. ?tag=env%3Dprod+and+role%3Dreporting
+func (this *HttpAPI) BeginDowntimeTagged(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	tagged, err := inst.GetInstanceKeysByTags(req.URL.Query().Get("tag"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	duration := time.Duration(durationSeconds) * time.Second
+	instanceKeys := tagged.GetInstanceKeys()
+	for _, instanceKey := range instanceKeys {
+		downtime := inst.NewDowntime(&instanceKey, params["owner"], params["reason"], duration)
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("begin-downtime", downtime)
+		} else {
+			err = inst.BeginDowntime(downtime)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+			return
+		}
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime begun on %d instances", len(instanceKeys)), Details: instanceKeys})
+}
+
 // EndDowntime terminates downtime (removes downtime flag) for an instance
 func (this *HttpAPI) EndDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -491,6 +591,172 @@ func (this *HttpAPI) EndDowntime(params martini.Params, r render.Render, req *ht
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime ended: %+v", instanceKey), Details: instanceKey})
 }
 
+// scheduleDowntime creates a recurring downtime schedule, of either an instance or a cluster
+func (this *HttpAPI) scheduleDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User, schedule *inst.DowntimeSchedule) {
+	durationSeconds, err := util.SimpleTimeToSeconds(params["duration"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	schedule.StartTime = params["startTime"]
+	schedule.Duration = time.Duration(durationSeconds) * time.Second
+	schedule.Weekdays = req.URL.Query().Get("weekdays")
+	schedule.ExtendWhile = req.URL.Query().Get("extendWhile")
+	schedule.Owner = params["owner"]
+	schedule.Reason = params["reason"]
+	if err := schedule.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-downtime-schedule", schedule)
+	} else {
+		err = inst.WriteDowntimeSchedule(schedule)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime scheduled: %s", schedule.Id), Details: schedule})
+}
+
+// ScheduleDowntime creates a recurring downtime schedule for an instance, e.g. a nightly backup window
+func (this *HttpAPI) ScheduleDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	schedule := inst.NewDowntimeSchedule()
+	schedule.Key = instanceKey
+	this.scheduleDowntime(params, r, req, user, schedule)
+}
+
+// ScheduleClusterDowntime creates a recurring downtime schedule for all instances of a cluster
+func (this *HttpAPI) ScheduleClusterDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	schedule := inst.NewDowntimeSchedule()
+	schedule.ClusterAlias = clusterAlias
+	this.scheduleDowntime(params, r, req, user, schedule)
+}
+
+// UnscheduleDowntime removes a downtime schedule
+func (this *HttpAPI) UnscheduleDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-downtime-schedule", params["scheduleId"])
+	} else {
+		err = inst.DeleteDowntimeSchedule(params["scheduleId"])
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Downtime schedule removed: %s", params["scheduleId"])})
+}
+
+// DowntimeSchedules lists downtime schedules
+func (this *HttpAPI) DowntimeSchedules(params martini.Params, r render.Render, req *http.Request) {
+	schedules, err := inst.ReadDowntimeSchedules()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, schedules)
+}
+
+// BeginNoTouch sets a no-touch lock on an instance: no automation touches it until the lock ends or is released
+func (this *HttpAPI) BeginNoTouch(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	lock := inst.NewNoTouchLock(&instanceKey, params["owner"], params["reason"], uint(durationSeconds))
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("begin-no-touch", lock)
+	} else {
+		err = inst.BeginNoTouch(lock)
+	}
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("No-touch lock begun: %+v", instanceKey), Details: instanceKey})
+}
+
+// EndNoTouch releases the no-touch lock of an instance
+func (this *HttpAPI) EndNoTouch(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("end-no-touch", instanceKey)
+	} else {
+		_, err = inst.EndNoTouch(&instanceKey)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("No-touch lock ended: %+v", instanceKey), Details: instanceKey})
+}
+
+// NoTouchLocks lists the currently held no-touch locks
+func (this *HttpAPI) NoTouchLocks(params martini.Params, r render.Render, req *http.Request) {
+	locks, err := inst.ReadNoTouchLocks()
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, locks)
+}
+
 // MoveUp attempts to move an instance up the topology
 func (this *HttpAPI) MoveUp(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -767,6 +1033,35 @@ func (this *HttpAPI) ErrantGTIDInjectEmpty(params martini.Params, r render.Rende
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Have injected %+v transactions on cluster master %+v", countInjectedTransactions, clusterMaster.Key), Details: instance})
 }
 
+// ErrantGTIDRemediationDryRun lists the statements which gtid-errant-reset-master or gtid-errant-inject-empty would issue
+// for given instance, without issuing them
+func (this *HttpAPI) ErrantGTIDRemediationDryRun(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var plan *inst.ErrantGTIDRemediationPlan
+	switch params["method"] {
+	case "reset-master":
+		plan, err = inst.PlanErrantGTIDResetMaster(&instanceKey)
+	case "inject-empty":
+		plan, err = inst.PlanErrantGTIDInjectEmpty(&instanceKey)
+	default:
+		err = fmt.Errorf("Unknown errant GTID remediation method: %s. Expected reset-master or inject-empty", params["method"])
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dry run: %d statements to be issued on %+v", len(plan.Statements), plan.TargetKey), Details: plan})
+}
+
 // MoveBelow attempts to move an instance below its supposed sibling
 func (this *HttpAPI) MoveBelow(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -811,6 +1106,10 @@ func (this *HttpAPI) MoveBelowGTID(params martini.Params, r render.Render, req *
 	}
 
 	instance, err := inst.MoveBelowGTID(&instanceKey, &belowKey)
+	if purgedErr, ok := err.(*inst.GTIDPurgedError); ok {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: purgedErr})
+		return
+	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -931,7 +1230,7 @@ func (this *HttpAPI) RelocateReplicas(params martini.Params, r render.Render, re
 		return
 	}
 
-	replicas, _, err, errs := inst.RelocateReplicas(&instanceKey, &belowKey, req.URL.Query().Get("pattern"))
+	replicas, _, err, errs := inst.RelocateReplicasByTags(&instanceKey, &belowKey, req.URL.Query().Get("pattern"), req.URL.Query().Get("tag"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1510,6 +1809,10 @@ func (this *HttpAPI) CanReplicateFromGTID(params martini.Params, r render.Render
 		return
 	}
 	err = inst.CheckMoveViaGTID(instance, belowInstance)
+	if purgedErr, ok := err.(*inst.GTIDPurgedError); ok {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: purgedErr})
+		return
+	}
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
@@ -1672,78 +1975,255 @@ func (this *HttpAPI) SnapshotTopologies(params martini.Params, r render.Render,
 	Respond(r, &APIResponse{Code: OK, Message: "Topology Snapshot completed", Details: fmt.Sprintf("Took %v", time.Since(start))})
 }
 
-// AsciiTopology returns an ascii graph of cluster's instances
-func (this *HttpAPI) AsciiTopology(params martini.Params, r render.Render, req *http.Request) {
-	this.asciiTopology(params, r, req, false)
-}
-
-// AsciiTopology returns an ascii graph of cluster's instances
-func (this *HttpAPI) AsciiTopologyTabulated(params martini.Params, r render.Render, req *http.Request) {
-	this.asciiTopology(params, r, req, true)
+// figureSnapshotCluster returns the alias of the cluster given by hint, by which the cluster's topology snapshots
+// are found across master failovers. Failing that, the hint itself is returned, as it may name a cluster no longer known.
+func figureSnapshotCluster(hint string) string {
+	if clusterAlias, err := figureClusterAlias(hint); err == nil {
+		return clusterAlias
+	}
+	return hint
 }
 
-// Cluster provides list of instances in given cluster
-func (this *HttpAPI) Cluster(params martini.Params, r render.Render, req *http.Request) {
-	clusterName, err := figureClusterName(getClusterHint(params))
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
-		return
+// TopologySnapshot returns the persisted topology of a cluster as of a given time ("at", formatted 'YYYY-MM-DD hh:mm:ss'),
+// or the latest; as an ascii graph given "format=text", or as a graphviz digraph given "format=dot"
+func (this *HttpAPI) TopologySnapshot(params martini.Params, r render.Render, req *http.Request) {
+	at := req.URL.Query().Get("at")
+	if at != "" {
+		if _, err := time.Parse("2006-01-02 15:04:05", at); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid time %q; expected 'YYYY-MM-DD hh:mm:ss'", at)})
+			return
+		}
 	}
-
-	instances, err := inst.ReadClusterInstances(clusterName)
-
+	snapshot, err := inst.ReadClusterTopologySnapshotAt(figureSnapshotCluster(getClusterHint(params)), at)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-
-	r.JSON(http.StatusOK, instances)
+	switch req.URL.Query().Get("format") {
+	case "text":
+		r.Text(http.StatusOK, snapshot.ASCII())
+	case "dot":
+		r.Text(http.StatusOK, snapshot.DOT())
+	default:
+		r.JSON(http.StatusOK, snapshot)
+	}
 }
 
-// ClusterByAlias provides list of instances in given cluster
-func (this *HttpAPI) ClusterByAlias(params martini.Params, r render.Render, req *http.Request) {
-	clusterName, err := inst.GetClusterByAlias(params["clusterAlias"])
+// TopologySnapshots lists the persisted topology snapshots of a cluster, optionally limited by "from" and "to" times,
+// each with the cluster's master at the time
+func (this *HttpAPI) TopologySnapshots(params martini.Params, r render.Render, req *http.Request) {
+	snapshots, err := inst.ReadClusterTopologySnapshotTimeline(figureSnapshotCluster(getClusterHint(params)), req.URL.Query().Get("from"), req.URL.Query().Get("to"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	params["clusterName"] = clusterName
-	this.Cluster(params, r, req)
+	r.JSON(http.StatusOK, snapshots)
 }
 
-// ClusterByInstance provides list of instances in cluster an instance belongs to
-func (this *HttpAPI) ClusterByInstance(params martini.Params, r render.Render, req *http.Request) {
-	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+// TopologyDiff returns the difference between the persisted topology snapshots of a cluster at two times, "from" and "to",
+// formatted 'YYYY-MM-DD hh:mm:ss'. An empty "to" diffs against the latest snapshot.
+func (this *HttpAPI) TopologyDiff(params martini.Params, r render.Render, req *http.Request) {
+	clusterNameOrAlias := figureSnapshotCluster(getClusterHint(params))
+	from := req.URL.Query().Get("from")
+	if from == "" {
+		Respond(r, &APIResponse{Code: ERROR, Message: "from time required"})
 		return
 	}
-	instance, found, err := inst.ReadInstance(&instanceKey)
-	if (!found) || (err != nil) {
-		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read instance: %+v", instanceKey)})
+	before, err := inst.ReadClusterTopologySnapshotAt(clusterNameOrAlias, from)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-
-	params["clusterName"] = instance.ClusterName
-	this.Cluster(params, r, req)
-}
-
-// ClusterInfo provides details of a given cluster
-func (this *HttpAPI) ClusterInfo(params martini.Params, r render.Render, req *http.Request) {
-	clusterName, err := figureClusterName(getClusterHint(params))
+	after, err := inst.ReadClusterTopologySnapshotAt(clusterNameOrAlias, req.URL.Query().Get("to"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
-	clusterInfo, err := inst.ReadClusterInfo(clusterName)
 
+	r.JSON(http.StatusOK, inst.NewTopologyDiff(before, after))
+}
+
+// RecoveryTopologyDiff returns the difference between the topology before and after a given recovery
+func (this *HttpAPI) RecoveryTopologyDiff(params martini.Params, r render.Render, req *http.Request) {
+	diff, err := logic.ReadRecoveryTopologyDiff(params["uid"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
 
-	r.JSON(http.StatusOK, clusterInfo)
+	r.JSON(http.StatusOK, diff)
+}
+
+// AsciiTopology returns an ascii graph of cluster's instances
+func (this *HttpAPI) AsciiTopology(params martini.Params, r render.Render, req *http.Request) {
+	this.asciiTopology(params, r, req, false)
+}
+
+// AsciiTopology returns an ascii graph of cluster's instances
+func (this *HttpAPI) AsciiTopologyTabulated(params martini.Params, r render.Render, req *http.Request) {
+	this.asciiTopology(params, r, req, true)
+}
+
+// TopologyDOT returns the current replication tree of a cluster as a graphviz digraph, annotated with lag, version,
+// data center and promotion rules; or, given "format=svg", rendered as SVG via GraphvizDotCommand
+func (this *HttpAPI) TopologyDOT(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	dot, err := inst.ClusterTopologyDOT(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if req.URL.Query().Get("format") != "svg" {
+		r.Text(http.StatusOK, dot)
+		return
+	}
+	svg, err := inst.RenderDOTAsSVG(dot)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.Header().Set("Content-Type", "image/svg+xml")
+	r.Data(http.StatusOK, svg)
+}
+
+// Cluster provides list of instances in given cluster
+func (this *HttpAPI) Cluster(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	instances, err := inst.ReadClusterInstances(clusterName)
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, instances)
+}
+
+// ClusterByAlias provides list of instances in given cluster
+func (this *HttpAPI) ClusterByAlias(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := inst.GetClusterByAlias(params["clusterAlias"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	params["clusterName"] = clusterName
+	this.Cluster(params, r, req)
+}
+
+// ClusterByInstance provides list of instances in cluster an instance belongs to
+func (this *HttpAPI) ClusterByInstance(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	instance, found, err := inst.ReadInstance(&instanceKey)
+	if (!found) || (err != nil) {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot read instance: %+v", instanceKey)})
+		return
+	}
+
+	params["clusterName"] = instance.ClusterName
+	this.Cluster(params, r, req)
+}
+
+// ClusterInfo provides details of a given cluster
+func (this *HttpAPI) ClusterInfo(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, clusterInfo)
+}
+
+// ReadPool returns the read pool of given cluster: its healthy, low-lag replicas, weighted for load balancing
+func (this *HttpAPI) ReadPool(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	pool, err := logic.GetReadPool(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, pool)
+}
+
+// LagSLO reports the replica lag SLO compliance of given cluster, over the past `hours` hours (query parameter;
+// defaults to ReplicaLagSLOWindowHours)
+func (this *HttpAPI) LagSLO(params martini.Params, r render.Render, req *http.Request) {
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	hours := 0
+	if hoursParam := req.URL.Query().Get("hours"); hoursParam != "" {
+		if hours, err = strconv.Atoi(hoursParam); err != nil || hours <= 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid hours: %s", hoursParam)})
+			return
+		}
+	}
+	report, err := logic.GetLagSLOReport(clusterAlias, uint(hours))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, report)
+}
+
+// MembershipViolations lists the violations of MembershipRules, in all clusters or in given cluster
+func (this *HttpAPI) MembershipViolations(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if params["clusterHint"] != "" {
+		var err error
+		if clusterName, err = figureClusterName(getClusterHint(params)); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+	}
+	violations, err := logic.GetMembershipViolations(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, violations)
+}
+
+// MembershipCheckPromotion forecasts the violations of MembershipRules once given replica is promoted
+func (this *HttpAPI) MembershipCheckPromotion(params martini.Params, r render.Render, req *http.Request) {
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	violations, err := logic.GetMembershipViolationsAfterPromotion(&instanceKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, violations)
 }
 
 // Cluster provides list of instances in given cluster
@@ -1798,6 +2278,109 @@ func (this *HttpAPI) SetClusterAliasManualOverride(params martini.Params, r rend
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster %s now has alias '%s'", clusterName, alias)})
 }
 
+// ClusterTemplates lists configured cluster templates
+func (this *HttpAPI) ClusterTemplates(params martini.Params, r render.Render, req *http.Request) {
+	r.JSON(http.StatusOK, config.Config.ClusterTemplates)
+}
+
+// ClusterTemplate returns the template assigned to a given cluster
+func (this *HttpAPI) ClusterTemplate(params martini.Params, r render.Render, req *http.Request) {
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	template, err := inst.ReadClusterTemplate(clusterAlias)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if template == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No template assigned to cluster %s", clusterAlias)})
+		return
+	}
+	r.JSON(http.StatusOK, template)
+}
+
+// SetClusterTemplate assigns a template to a cluster, overriding any existing assignment
+func (this *HttpAPI) SetClusterTemplate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	templateName := params["templateName"]
+	if config.Config.GetClusterTemplate(templateName) == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Unknown cluster template: %s", templateName)})
+		return
+	}
+	if err := logic.SetClusterTemplate(clusterName, templateName); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster %s now has template %s", clusterName, templateName), Details: templateName})
+}
+
+// ClusterPriorityTier returns the recovery priority tier of a given cluster
+func (this *HttpAPI) ClusterPriorityTier(params martini.Params, r render.Render, req *http.Request) {
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, clusterInfo.PriorityTier)
+}
+
+// SetClusterPriorityTier assigns a recovery priority tier to a cluster, overriding configured tiers
+func (this *HttpAPI) SetClusterPriorityTier(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	tier := params["tier"]
+	if !inst.IsValidClusterPriorityTier(tier) {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Unknown cluster priority tier: %s", tier)})
+		return
+	}
+	if err := logic.SetClusterPriorityTier(clusterAlias, tier); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster %s now has priority tier %s", clusterAlias, tier), Details: tier})
+}
+
+// ResetClusterPriorityTier removes the priority tier assigned to a cluster via API, reverting to configured tiers
+func (this *HttpAPI) ResetClusterPriorityTier(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if err := logic.SetClusterPriorityTier(clusterAlias, ""); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster %s priority tier reset to configuration", clusterAlias), Details: clusterAlias})
+}
+
 // Clusters provides list of known clusters
 func (this *HttpAPI) Clusters(params martini.Params, r render.Render, req *http.Request) {
 	clusterNames, err := inst.ReadClusters()
@@ -2046,12 +2629,95 @@ func (this *HttpAPI) Problems(params martini.Params, r render.Render, req *http.
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
 		return
 	}
+	if req.URL.Query().Get("includeSuppressed") != "true" {
+		suppressions, err := inst.ReadActiveProblemSuppressions()
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		unsuppressed := [](*inst.Instance){}
+		for _, instance := range instances {
+			isSuppressed := false
+			for i := range suppressions {
+				isSuppressed = isSuppressed || suppressions[i].MatchesInstance(instance)
+			}
+			if !isSuppressed {
+				unsuppressed = append(unsuppressed, instance)
+			}
+		}
+		instances = unsuppressed
+	}
 
 	r.JSON(http.StatusOK, instances)
 }
 
-// Audit provides list of audit entries by given page number
-func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Request) {
+// SuppressProblems hides problems matching the "clusterPattern", "instancePattern" and/or "analysis" query
+// parameters from the problems API, for given duration
+func (this *HttpAPI) SuppressProblems(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	durationSeconds, err := util.SimpleTimeToSeconds(params["duration"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	suppression := inst.NewProblemSuppression()
+	suppression.ClusterPattern = req.URL.Query().Get("clusterPattern")
+	suppression.InstancePattern = req.URL.Query().Get("instancePattern")
+	suppression.Analysis = req.URL.Query().Get("analysis")
+	suppression.Owner = params["owner"]
+	suppression.Reason = params["reason"]
+	suppression.Duration = time.Duration(durationSeconds) * time.Second
+	if err := suppression.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-problem-suppression", suppression)
+	} else {
+		err = inst.WriteProblemSuppression(suppression)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Problems suppressed: %s", suppression.Id), Details: suppression})
+}
+
+// UnsuppressProblems removes a problem suppression
+func (this *HttpAPI) UnsuppressProblems(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-problem-suppression", params["suppressionId"])
+	} else {
+		err = inst.DeleteProblemSuppression(params["suppressionId"])
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Problem suppression removed: %s", params["suppressionId"])})
+}
+
+// ProblemSuppressions lists active problem suppressions
+func (this *HttpAPI) ProblemSuppressions(params martini.Params, r render.Render, req *http.Request) {
+	suppressions, err := inst.ReadActiveProblemSuppressions()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, suppressions)
+}
+
+// Audit provides list of audit entries by given page number, or by listing filter query parameters
+// (see getListingFilter) and an optional "type" of audit
+func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
 	page, err := strconv.Atoi(params["page"])
 	if err != nil || page < 0 {
 		page = 0
@@ -2061,7 +2727,19 @@ func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Req
 		auditedInstanceKey = &instanceKey
 	}
 
-	audits, err := inst.ReadRecentAudit(auditedInstanceKey, page)
+	var audits []inst.Audit
+	if auditType := req.URL.Query().Get("type"); hasListingQueryParams(req) || auditType != "" {
+		filter, ferr := getListingFilter(req)
+		if ferr != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", ferr)})
+			return
+		}
+		if audits, err = inst.ReadAudits(filter, auditedInstanceKey, auditType); err == nil && len(audits) > 0 {
+			setListingNextCursor(w, filter, len(audits), audits[len(audits)-1].AuditId)
+		}
+	} else {
+		audits, err = inst.ReadRecentAudit(auditedInstanceKey, page)
+	}
 
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
@@ -2071,6 +2749,81 @@ func (this *HttpAPI) Audit(params martini.Params, r render.Render, req *http.Req
 	r.JSON(http.StatusOK, audits)
 }
 
+// InstanceChanges returns material instance state changes, as detected by discovery, optionally
+// filtered by instance, by cluster, and by changed field (the "field" query parameter)
+func (this *HttpAPI) InstanceChanges(params martini.Params, r render.Render, req *http.Request) {
+	page, err := strconv.Atoi(params["page"])
+	if err != nil || page < 0 {
+		page = 0
+	}
+	filter := &inst.InstanceChangeFilter{FieldName: req.URL.Query().Get("field")}
+	if instanceKey, err := this.getInstanceKey(params["host"], params["port"]); err == nil {
+		filter.InstanceKey = &instanceKey
+	}
+	if clusterHint := params["clusterHint"]; clusterHint != "" {
+		clusterName, err := figureClusterName(clusterHint)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		filter.ClusterName = clusterName
+	}
+
+	changes, err := inst.ReadInstanceChanges(filter, page)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, changes)
+}
+
+// GenerateFleetReport generates and persists a fleet capacity & topology report
+func (this *HttpAPI) GenerateFleetReport(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	report, err := inst.GenerateFleetReport(inst.FleetReportTriggerAPI)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Generated fleet report %d", report.ReportId), Details: report})
+}
+
+// FleetReports returns the history of fleet reports, latest first
+func (this *HttpAPI) FleetReports(params martini.Params, r render.Render, req *http.Request) {
+	page, err := strconv.Atoi(params["page"])
+	if err != nil || page < 0 {
+		page = 0
+	}
+	reports, err := inst.ReadFleetReports(page)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, reports)
+}
+
+// FleetReport returns a single fleet report
+func (this *HttpAPI) FleetReport(params martini.Params, r render.Render, req *http.Request) {
+	reportId, err := strconv.ParseInt(params["reportId"], 10, 0)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid report id: %+v", params["reportId"])})
+		return
+	}
+	report, err := inst.ReadFleetReport(reportId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, report)
+}
+
 // HostnameResolveCache shows content of in-memory hostname cache
 func (this *HttpAPI) HostnameResolveCache(params martini.Params, r render.Render, req *http.Request) {
 	content, err := inst.HostnameResolveCache()
@@ -2570,29 +3323,101 @@ func (this *HttpAPI) AgentCustomCommand(params martini.Params, r render.Render,
 		return
 	}
 
-	r.JSON(http.StatusOK, output)
+	r.JSON(http.StatusOK, output)
+}
+
+// AgentSeed completely seeds a host with another host's snapshots. This is a complex operation
+// governed by orchestrator and executed by the two agents involved.
+func (this *HttpAPI) AgentSeed(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	if !config.Config.ServeAgentsHttp {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Agents not served"})
+		return
+	}
+
+	output, err := agent.Seed(params["targetHost"], params["sourceHost"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, output)
+}
+
+// beginReplicaRebuild begins a replica rebuild of the instance in params, from an optional source, via given function,
+// and runs it in the background
+func (this *HttpAPI) beginReplicaRebuild(params martini.Params, r render.Render, req *http.Request, user auth.User, sourceHostParam string, sourcePortParam string,
+	beginFunc func(*inst.InstanceKey, *inst.InstanceKey, string) (*logic.ReplicaRebuild, error),
+) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	sourceKey, _ := this.getInstanceKey(params[sourceHostParam], params[sourcePortParam])
+	// sourceKey may be empty/invalid
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	rebuild, err := beginFunc(&instanceKey, &sourceKey, userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: instanceKey})
+		return
+	}
+	details := *rebuild
+	go logic.RunReplicaRebuild(rebuild)
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Rebuilding %+v from %+v via %s: %s", instanceKey, details.SourceKey, details.Method, details.UID), Details: details})
+}
+
+// RebuildReplica begins rebuilding a diverged or lost replica from the data of a healthy replica, optionally
+// given. Runs in the background.
+func (this *HttpAPI) RebuildReplica(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.beginReplicaRebuild(params, r, req, user, "sourceHost", "sourcePort", logic.BeginReplicaRebuild)
 }
 
-// AgentSeed completely seeds a host with another host's snapshots. This is a complex operation
-// governed by orchestrator and executed by the two agents involved.
-func (this *HttpAPI) AgentSeed(params martini.Params, r render.Render, req *http.Request, user auth.User) {
-	if !isAuthorizedForAction(req, user) {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
-		return
+// ReseedInstance begins reseeding an instance from a donor replica, optionally given, via the MySQL 8 CLONE plugin.
+// Runs in the background, and is tracked as a replica rebuild.
+func (this *HttpAPI) ReseedInstance(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.beginReplicaRebuild(params, r, req, user, "donorHost", "donorPort", logic.BeginReseedInstance)
+}
+
+// ReplicaRebuilds lists replica rebuilds, optionally of a given target instance
+func (this *HttpAPI) ReplicaRebuilds(params martini.Params, r render.Render, req *http.Request) {
+	var targetKey *inst.InstanceKey
+	if params["host"] != "" {
+		instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		targetKey = &instanceKey
 	}
-	if !config.Config.ServeAgentsHttp {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Agents not served"})
+	rebuilds, err := logic.ReadReplicaRebuilds(targetKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
+	r.JSON(http.StatusOK, rebuilds)
+}
 
-	output, err := agent.Seed(params["targetHost"], params["sourceHost"])
-
+// ReplicaRebuild returns a single replica rebuild
+func (this *HttpAPI) ReplicaRebuild(params martini.Params, r render.Render, req *http.Request) {
+	rebuild, err := logic.ReadReplicaRebuild(params["uid"])
 	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-
-	r.JSON(http.StatusOK, output)
+	r.JSON(http.StatusOK, rebuild)
 }
 
 // AgentActiveSeeds lists active seeds and their state
@@ -2918,14 +3743,23 @@ func (this *HttpAPI) ReloadConfiguration(params martini.Params, r render.Render,
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	config.Reload()
-	inst.AuditOperation("reload-configuration", nil, "Triggered via API")
+	report, err := logic.ReloadConfiguration("API")
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Config not reloaded: %+v", err)})
+		return
+	}
 
-	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Config reloaded")})
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Config reloaded; %s", report.String()), Details: report})
 }
 
-// ReplicationAnalysis retuens list of issues
-func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.InstanceKey, params martini.Params, r render.Render, req *http.Request) {
+// EffectiveConfiguration returns the running configuration, with secrets redacted. Settings changed by a reload
+// yet requiring a restart show their running values.
+func (this *HttpAPI) EffectiveConfiguration(params martini.Params, r render.Render, req *http.Request) {
+	r.JSON(http.StatusOK, config.Config.RedactedMap())
+}
+
+// ReplicationAnalysis retuens list of issues, optionally filtered, sorted and paginated (see filterReplicationAnalysis)
+func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.InstanceKey, params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
 	analysis, err := inst.GetReplicationAnalysis(clusterName, &inst.ReplicationAnalysisHints{IncludeDowntimed: true})
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
@@ -2941,17 +3775,32 @@ func (this *HttpAPI) replicationAnalysis(clusterName string, instanceKey *inst.I
 		}
 		analysis = filtered
 	}
+	includeSuppressed, minSeverity, err := getProblemFilters(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
+		return
+	}
+	suppressions, err := inst.ReadActiveProblemSuppressions()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
+		return
+	}
+	analysis = classifyReplicationAnalysis(analysis, suppressions, includeSuppressed, minSeverity)
+	if analysis, err = filterReplicationAnalysis(analysis, w, req); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
+		return
+	}
 
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Analysis"), Details: analysis})
 }
 
 // ReplicationAnalysis retuens list of issues
-func (this *HttpAPI) ReplicationAnalysis(params martini.Params, r render.Render, req *http.Request) {
-	this.replicationAnalysis("", nil, params, r, req)
+func (this *HttpAPI) ReplicationAnalysis(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
+	this.replicationAnalysis("", nil, params, r, req, w)
 }
 
 // ReplicationAnalysis retuens list of issues
-func (this *HttpAPI) ReplicationAnalysisForCluster(params martini.Params, r render.Render, req *http.Request) {
+func (this *HttpAPI) ReplicationAnalysisForCluster(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
 	clusterName := params["clusterName"]
 
 	var err error
@@ -2963,11 +3812,11 @@ func (this *HttpAPI) ReplicationAnalysisForCluster(params martini.Params, r rend
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get cluster name: %+v", params["clusterName"])})
 		return
 	}
-	this.replicationAnalysis(clusterName, nil, params, r, req)
+	this.replicationAnalysis(clusterName, nil, params, r, req, w)
 }
 
 // ReplicationAnalysis retuens list of issues
-func (this *HttpAPI) ReplicationAnalysisForKey(params martini.Params, r render.Render, req *http.Request) {
+func (this *HttpAPI) ReplicationAnalysisForKey(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
 	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: %+v", err)})
@@ -2977,7 +3826,7 @@ func (this *HttpAPI) ReplicationAnalysisForKey(params martini.Params, r render.R
 		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot get analysis: invalid key %+v", instanceKey)})
 		return
 	}
-	this.replicationAnalysis("", &instanceKey, params, r, req)
+	this.replicationAnalysis("", &instanceKey, params, r, req, w)
 }
 
 // RecoverLite attempts recovery on a given instance, without executing external processes
@@ -3042,34 +3891,344 @@ func (this *HttpAPI) GracefulMasterTakeover(params martini.Params, r render.Rend
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: "graceful-master-takeover: successor promoted", Details: topologyRecovery})
+	Respond(r, &APIResponse{Code: OK, Message: "graceful-master-takeover: successor promoted", Details: topologyRecovery})
+}
+
+// GracefulMasterTakeoverProgress reports in-flight graceful master takeovers on this node: how far the
+// designated replica is from catching up with the demoted master
+func (this *HttpAPI) GracefulMasterTakeoverProgress(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		var err error
+		if clusterName, err = figureClusterName(clusterHint); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	r.JSON(http.StatusOK, logic.ReadGracefulTakeoverProgress(clusterName))
+}
+
+// ScheduleGracefulMasterTakeover schedules a graceful master takeover for a future time window, given
+// by "start" and optional "end" (default: an hour later) query params, formatted 'YYYY-MM-DD hh:mm:ss'.
+func (this *HttpAPI) ScheduleGracefulMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
+	// designatedKey may be empty/invalid
+	windowStart := strings.TrimSpace(req.URL.Query().Get("start"))
+	windowEnd := strings.TrimSpace(req.URL.Query().Get("end"))
+	if windowEnd == "" {
+		start, err := time.ParseInLocation(logic.ScheduledTakeoverTimeFormat, windowStart, time.Local)
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot parse start %q; expected format: %s", windowStart, logic.ScheduledTakeoverTimeFormat)})
+			return
+		}
+		windowEnd = start.Add(time.Hour).Format(logic.ScheduledTakeoverTimeFormat)
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	comment := strings.TrimSpace(req.URL.Query().Get("comment"))
+	takeover, err := logic.ScheduleMasterTakeover(clusterName, &designatedKey, windowStart, windowEnd, userId, comment)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Scheduled master takeover %s", takeover.UID), Details: takeover})
+}
+
+// CancelScheduledMasterTakeover cancels a scheduled master takeover which has not started executing
+func (this *HttpAPI) CancelScheduledMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	takeover, err := logic.CancelScheduledMasterTakeover(params["uid"], userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: takeover})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cancelled scheduled master takeover %s", takeover.UID), Details: takeover})
+}
+
+// ScheduledMasterTakeovers lists scheduled master takeovers, optionally by cluster
+func (this *HttpAPI) ScheduledMasterTakeovers(params martini.Params, r render.Render, req *http.Request) {
+	clusterAlias := ""
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		var err error
+		if clusterAlias, err = figureClusterAlias(clusterHint); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	takeovers, err := logic.ReadScheduledMasterTakeovers(clusterAlias)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, takeovers)
+}
+
+// RollingRestart begins a rolling restart of a cluster: replicas are restarted one batch at a time, then
+// the master, following a graceful takeover onto the optional designated instance. Runs in the background.
+func (this *HttpAPI) RollingRestart(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
+	// designatedKey may be empty/invalid
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	rollingRestart, err := logic.BeginRollingRestart(clusterName, &designatedKey, userId)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	details := logic.ReadRollingRestarts(clusterName)
+	go logic.RunRollingRestart(rollingRestart)
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Rolling restart of %s begun", clusterName), Details: details})
+}
+
+// controlRollingRestart applies a pause/resume/abort control on the rolling restart of a cluster
+func (this *HttpAPI) controlRollingRestart(params martini.Params, r render.Render, req *http.Request, user auth.User, control func(clusterName string, owner string) error, description string) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	if err := control(clusterName, userId); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: logic.ReadRollingRestarts(clusterName)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Rolling restart of %s %s", clusterName, description), Details: logic.ReadRollingRestarts(clusterName)})
+}
+
+// RollingRestartPause pauses a running rolling restart once its current batch completes
+func (this *HttpAPI) RollingRestartPause(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.controlRollingRestart(params, r, req, user, logic.PauseRollingRestart, "paused")
+}
+
+// RollingRestartResume resumes a paused rolling restart
+func (this *HttpAPI) RollingRestartResume(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.controlRollingRestart(params, r, req, user, logic.ResumeRollingRestart, "resumed")
+}
+
+// RollingRestartAbort aborts a rolling restart once its current batch completes
+func (this *HttpAPI) RollingRestartAbort(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	this.controlRollingRestart(params, r, req, user, logic.AbortRollingRestart, "aborted")
+}
+
+// RollingRestarts lists rolling restarts run by this node, optionally by cluster
+func (this *HttpAPI) RollingRestarts(params martini.Params, r render.Render, req *http.Request) {
+	clusterName := ""
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		var err error
+		if clusterName, err = figureClusterName(clusterHint); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	r.JSON(http.StatusOK, logic.ReadRollingRestarts(clusterName))
+}
+
+// ReplicaProvisioningRequests lists requests for provisioning new replicas, optionally by cluster
+func (this *HttpAPI) ReplicaProvisioningRequests(params martini.Params, r render.Render, req *http.Request) {
+	clusterAlias := ""
+	if clusterHint := getClusterHint(params); clusterHint != "" {
+		var err error
+		if clusterAlias, err = figureClusterAlias(clusterHint); err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	requests, err := logic.ReadReplicaProvisioningRequests(clusterAlias)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, requests)
+}
+
+// ForceMasterFailover fails over a master (even if there's no particular problem with the master)
+func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	topologyRecovery, err := logic.ForceMasterFailover(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if topologyRecovery.SuccessorKey != nil {
+		Respond(r, &APIResponse{Code: OK, Message: "Master failed over", Details: topologyRecovery})
+	} else {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Master not failed over", Details: topologyRecovery})
+	}
+}
+
+// ForceMasterTakeover fails over a master (even if there's no particular problem with the master)
+func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterName, err := figureClusterName(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
+	designatedInstance, _, err := inst.ReadInstance(&designatedKey)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if designatedInstance == nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Instance not found"})
+		return
+	}
+
+	topologyRecovery, err := logic.ForceMasterTakeover(clusterName, designatedInstance)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if topologyRecovery.SuccessorKey != nil {
+		Respond(r, &APIResponse{Code: OK, Message: "Master failed over", Details: topologyRecovery})
+	} else {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Master not failed over", Details: topologyRecovery})
+	}
+}
+
+// getCandidateExpiry reads the optional ?expiry= duration of a promotion rule registration, e.g. ?expiry=4h
+func getCandidateExpiry(req *http.Request) (time.Duration, error) {
+	expiry := req.URL.Query().Get("expiry")
+	if expiry == "" {
+		return 0, nil
+	}
+	expirySeconds, err := util.SimpleTimeToSeconds(expiry)
+	if err != nil {
+		return 0, err
+	}
+	if expirySeconds <= 0 {
+		return 0, fmt.Errorf("Expiry value must be positive. Given value: %d", expirySeconds)
+	}
+	return time.Duration(expirySeconds) * time.Second, nil
+}
+
+// registerCandidates registers given promotion rule on all given instances
+func registerCandidates(instanceKeys []inst.InstanceKey, promotionRule inst.CandidatePromotionRule, expiry time.Duration) (err error) {
+	for _, instanceKey := range instanceKeys {
+		candidate := inst.NewCandidateDatabaseInstance(&instanceKey, promotionRule).WithCurrentTime().WithExpiry(expiry)
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("register-candidate", candidate)
+		} else {
+			err = inst.RegisterCandidateInstance(candidate)
+		}
+		if err != nil {
+			return fmt.Errorf("%+v: %+v", instanceKey, err)
+		}
+	}
+	return nil
+}
+
+// Registers promotion preference for given instance
+func (this *HttpAPI) RegisterCandidate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	promotionRule, err := inst.ParseCandidatePromotionRule(params["promotionRule"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	expiry, err := getCandidateExpiry(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	if err := registerCandidates([]inst.InstanceKey{instanceKey}, promotionRule, expiry); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: "Registered candidate", Details: instanceKey})
 }
 
-// ForceMasterFailover fails over a master (even if there's no particular problem with the master)
-func (this *HttpAPI) ForceMasterFailover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// RegisterCandidateTagged registers all instances matching the tag expression given via ?tag= as candidates, with given promotion rule
+func (this *HttpAPI) RegisterCandidateTagged(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
 	}
-	clusterName, err := figureClusterName(getClusterHint(params))
+	tagged, err := inst.GetInstanceKeysByTags(req.URL.Query().Get("tag"))
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	topologyRecovery, err := logic.ForceMasterFailover(clusterName)
+	promotionRule, err := inst.ParseCandidatePromotionRule(params["promotionRule"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	if topologyRecovery.SuccessorKey != nil {
-		Respond(r, &APIResponse{Code: OK, Message: "Master failed over", Details: topologyRecovery})
-	} else {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Master not failed over", Details: topologyRecovery})
+	expiry, err := getCandidateExpiry(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	instanceKeys := tagged.GetInstanceKeys()
+	if err := registerCandidates(instanceKeys, promotionRule, expiry); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
 	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Registered %d candidates", len(instanceKeys)), Details: instanceKeys})
 }
 
-// ForceMasterTakeover fails over a master (even if there's no particular problem with the master)
-func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// RegisterClusterCandidates registers all instances of a given cluster as candidates, with given promotion rule
+func (this *HttpAPI) RegisterClusterCandidates(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -3079,31 +4238,35 @@ func (this *HttpAPI) ForceMasterTakeover(params martini.Params, r render.Render,
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	designatedKey, _ := this.getInstanceKey(params["designatedHost"], params["designatedPort"])
-	designatedInstance, _, err := inst.ReadInstance(&designatedKey)
+	promotionRule, err := inst.ParseCandidatePromotionRule(params["promotionRule"])
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	if designatedInstance == nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Instance not found"})
+	expiry, err := getCandidateExpiry(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-
-	topologyRecovery, err := logic.ForceMasterTakeover(clusterName, designatedInstance)
+	instances, err := inst.ReadClusterInstances(clusterName)
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	if topologyRecovery.SuccessorKey != nil {
-		Respond(r, &APIResponse{Code: OK, Message: "Master failed over", Details: topologyRecovery})
-	} else {
-		Respond(r, &APIResponse{Code: ERROR, Message: "Master not failed over", Details: topologyRecovery})
+	instanceKeys := []inst.InstanceKey{}
+	for _, instance := range instances {
+		instanceKeys = append(instanceKeys, instance.Key)
+	}
+	if err := registerCandidates(instanceKeys, promotionRule, expiry); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
 	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Registered %d candidates in %s", len(instanceKeys), clusterName), Details: instanceKeys})
 }
 
-// Registers promotion preference for given instance
-func (this *HttpAPI) RegisterCandidate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+// UnregisterCandidate removes the promotion rule of given instance
+func (this *HttpAPI) UnregisterCandidate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
 		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
 		return
@@ -3113,26 +4276,17 @@ func (this *HttpAPI) RegisterCandidate(params martini.Params, r render.Render, r
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
-	promotionRule, err := inst.ParseCandidatePromotionRule(params["promotionRule"])
-	if err != nil {
-		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
-		return
-	}
-
-	candidate := inst.NewCandidateDatabaseInstance(&instanceKey, promotionRule).WithCurrentTime()
-
 	if orcraft.IsRaftEnabled() {
-		_, err = orcraft.PublishCommand("register-candidate", candidate)
+		_, err = orcraft.PublishCommand("unregister-candidate", instanceKey)
 	} else {
-		err = inst.RegisterCandidateInstance(candidate)
+		_, err = inst.UnregisterCandidateInstance(&instanceKey)
 	}
-
 	if err != nil {
 		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
 		return
 	}
 
-	Respond(r, &APIResponse{Code: OK, Message: "Registered candidate", Details: instanceKey})
+	Respond(r, &APIResponse{Code: OK, Message: "Unregistered candidate", Details: instanceKey})
 }
 
 // AutomatedRecoveryFilters retuens list of clusters which are configured with automated recovery
@@ -3184,6 +4338,152 @@ func (this *HttpAPI) AuditRecoverySteps(params martini.Params, r render.Render,
 	r.JSON(http.StatusOK, audits)
 }
 
+// RecoveryJournal returns the journaled steps of a given recovery
+func (this *HttpAPI) RecoveryJournal(params martini.Params, r render.Render, req *http.Request) {
+	journal, err := logic.ReadRecoveryJournal(params["uid"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, journal)
+}
+
+// RecoveryReport returns the post-mortem report of a given recovery; as markdown text given "format=text"
+func (this *HttpAPI) RecoveryReport(params martini.Params, r render.Render, req *http.Request) {
+	report, err := logic.GenerateRecoveryReport(params["uid"])
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	switch req.URL.Query().Get("format") {
+	case "text":
+		r.Text(http.StatusOK, report.Text())
+		return
+	case "email":
+		subject, body, err := logic.RenderRecoveryEmail(params["uid"])
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+			return
+		}
+		r.Text(http.StatusOK, fmt.Sprintf("Subject: %s\n\n%s", subject, body))
+		return
+	}
+
+	r.JSON(http.StatusOK, report)
+}
+
+// PostponedFunctions returns pending postponed functions, or the postponed functions of a given recovery
+func (this *HttpAPI) PostponedFunctions(params martini.Params, r render.Render, req *http.Request) {
+	var postponedFunctions []logic.PostponedFunction
+	var err error
+	if recoveryUID := params["uid"]; recoveryUID != "" {
+		postponedFunctions, err = logic.ReadRecoveryPostponedFunctions(recoveryUID)
+	} else {
+		postponedFunctions, err = logic.ReadPendingPostponedFunctions()
+	}
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, postponedFunctions)
+}
+
+// RecoveryQueue lists recoveries waiting for a slot, as capped by MaxConcurrentRecoveries and MaxConcurrentRecoveriesPerDataCenter
+func (this *HttpAPI) RecoveryQueue(params martini.Params, r render.Render, req *http.Request) {
+	r.JSON(http.StatusOK, logic.ReadQueuedRecoveries())
+}
+
+// isVantagePointRequest checks whether a request comes from the address of one of the
+// MasterDeathConfirmationVantagePoints
+func isVantagePointRequest(req *http.Request) bool {
+	clientIP := net.ParseIP(getClientAddress(req))
+	if clientIP == nil {
+		return false
+	}
+	for _, apiURL := range config.Config.MasterDeathConfirmationVantagePoints {
+		vantagePointURL, err := url.Parse(apiURL)
+		if err != nil {
+			continue
+		}
+		addresses, err := net.LookupHost(vantagePointURL.Hostname())
+		if err != nil {
+			continue
+		}
+		for _, address := range addresses {
+			if clientIP.Equal(net.ParseIP(address)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckInstanceReachability attempts to connect to an instance from this node, irrespective of discovery. Other
+// orchestrator nodes use it to confirm a master's death before failing over. Since it connects with the
+// topology credentials, it only serves raft peers, MasterDeathConfirmationVantagePoints and authorized users,
+// and only on instances known to this node.
+func (this *HttpAPI) CheckInstanceReachability(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isRaftPeerRequest(req) && !isVantagePointRequest(req) && !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if _, found, err := inst.ReadInstance(&instanceKey); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	} else if !found {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Unknown instance: %+v", instanceKey)})
+		return
+	}
+	r.JSON(http.StatusOK, inst.CheckInstanceReachability(&instanceKey))
+}
+
+// DatacenterFailures lists data center failures being recovered in bulk, or recently recovered
+func (this *HttpAPI) DatacenterFailures(params martini.Params, r render.Render, req *http.Request) {
+	r.JSON(http.StatusOK, logic.ReadDatacenterFailures())
+}
+
+// getCanaryReportSeconds returns the time frame, in seconds, requested for canary shadow decisions; default one day
+func getCanaryReportSeconds(params martini.Params) uint {
+	if seconds, err := strconv.ParseUint(params["seconds"], 10, 0); err == nil && seconds > 0 {
+		return uint(seconds)
+	}
+	return 86400
+}
+
+// CanaryShadowDecisions lists actions this canary deployment would have taken on non-canary clusters
+func (this *HttpAPI) CanaryShadowDecisions(params martini.Params, r render.Render, req *http.Request) {
+	decisions, err := logic.ReadCanaryShadowDecisions(getCanaryReportSeconds(params))
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, decisions)
+}
+
+// CanaryReport compares this canary deployment's shadow decisions with the incumbent deployment's recoveries
+func (this *HttpAPI) CanaryReport(params martini.Params, r render.Render, req *http.Request) {
+	report, err := logic.GetCanaryReport(getCanaryReportSeconds(params))
+
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	r.JSON(http.StatusOK, report)
+}
+
 // ReadReplicationAnalysisChangelog lists instances and their analysis changelog
 func (this *HttpAPI) ReadReplicationAnalysisChangelog(params martini.Params, r render.Render, req *http.Request) {
 	changelogs, err := inst.ReadReplicationAnalysisChangelog()
@@ -3196,8 +4496,9 @@ func (this *HttpAPI) ReadReplicationAnalysisChangelog(params martini.Params, r r
 	r.JSON(http.StatusOK, changelogs)
 }
 
-// AuditRecovery provides list of topology-recovery entries
-func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *http.Request) {
+// AuditRecovery provides list of topology-recovery entries. Unless a specific recovery is requested, these are listed
+// by page number, or by listing filter query parameters (see getListingFilter) and comma separated "analysis" codes
+func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *http.Request, w http.ResponseWriter) {
 	var audits []logic.TopologyRecovery
 	var err error
 
@@ -3207,6 +4508,19 @@ func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *
 		audits, err = logic.ReadRecovery(recoveryId)
 	} else if clusterAlias := params["clusterAlias"]; clusterAlias != "" {
 		audits, err = logic.ReadRecoveriesForClusterAlias(clusterAlias)
+	} else if analysisCodes := getAnalysisCodes(req); hasListingQueryParams(req) || len(analysisCodes) > 0 {
+		filter, ferr := getListingFilter(req)
+		if ferr != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", ferr)})
+			return
+		}
+		if clusterName := params["clusterName"]; clusterName != "" {
+			filter.ClusterName = clusterName
+		}
+		unacknowledgedOnly := (req.URL.Query().Get("unacknowledged") == "true")
+		if audits, err = logic.ReadRecoveriesByFilter(filter, analysisCodes, unacknowledgedOnly); err == nil && len(audits) > 0 {
+			setListingNextCursor(w, filter, len(audits), audits[len(audits)-1].Id)
+		}
 	} else {
 		page, derr := strconv.Atoi(params["page"])
 		if derr != nil || page < 0 {
@@ -3224,6 +4538,25 @@ func (this *HttpAPI) AuditRecovery(params martini.Params, r render.Render, req *
 	r.JSON(http.StatusOK, audits)
 }
 
+// RecoveryAnalytics aggregates the recoveries of all clusters, or of a cluster given by alias, over the past `days`
+// days (query parameter; default 365): monthly counts, time to recovery, analysis codes and promotion rules
+func (this *HttpAPI) RecoveryAnalytics(params martini.Params, r render.Render, req *http.Request) {
+	days := 365
+	if daysParam := req.URL.Query().Get("days"); daysParam != "" {
+		var err error
+		if days, err = strconv.Atoi(daysParam); err != nil || days <= 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid days: %s", daysParam)})
+			return
+		}
+	}
+	analytics, err := logic.ReadRecoveryAnalytics(params["clusterAlias"], uint(days))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, analytics)
+}
+
 // ActiveClusterRecovery returns recoveries in-progress for a given cluster
 func (this *HttpAPI) ActiveClusterRecovery(params martini.Params, r render.Render, req *http.Request) {
 	recoveries, err := logic.ReadActiveClusterRecovery(params["clusterName"])
@@ -3310,6 +4643,147 @@ func (this *HttpAPI) AcknowledgeClusterRecoveries(params martini.Params, r rende
 	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Acknowledged cluster recoveries"), Details: clusterName})
 }
 
+// AcknowledgeAnalysisRecoveries acknowledges recoveries of given analysis code. Optional query parameters
+// "cluster", "from" and "to" limit acknowledgement to a cluster and to recoveries started within a time range.
+func (this *HttpAPI) AcknowledgeAnalysisRecoveries(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	filter, err := getListingFilter(req)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	analysis := params["analysis"]
+	comment := strings.TrimSpace(req.URL.Query().Get("comment"))
+	if comment == "" {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No acknowledge comment given")})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	var countAcknowledgedRecoveries int64
+	if orcraft.IsRaftEnabled() {
+		ack := logic.NewRecoveryAcknowledgement(userId, comment)
+		ack.Analysis = analysis
+		ack.ClusterName = filter.ClusterName
+		ack.FromTimestamp = filter.FromTimestamp
+		ack.ToTimestamp = filter.ToTimestamp
+		_, err = orcraft.PublishCommand("ack-recovery", ack)
+	} else {
+		countAcknowledgedRecoveries, err = logic.AcknowledgeAnalysisRecoveries(analysis, filter.ClusterName, filter.FromTimestamp, filter.ToTimestamp, userId, comment)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Acknowledged %s recoveries", analysis), Details: countAcknowledgedRecoveries})
+}
+
+// BeginClusterMaintenance puts a cluster in maintenance mode, suppressing automated recoveries on it
+func (this *HttpAPI) BeginClusterMaintenance(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var durationSeconds int = 0
+	if params["duration"] != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(params["duration"])
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	maintenance := inst.NewClusterMaintenance(clusterAlias, params["owner"], params["reason"], time.Duration(durationSeconds)*time.Second)
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("begin-cluster-maintenance", maintenance)
+	} else {
+		err = inst.BeginClusterMaintenance(maintenance)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error(), Details: clusterAlias})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster maintenance begun: %s", clusterAlias), Details: clusterAlias})
+}
+
+// EndClusterMaintenance takes a cluster out of maintenance mode
+func (this *HttpAPI) EndClusterMaintenance(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("end-cluster-maintenance", clusterAlias)
+	} else {
+		_, err = inst.EndClusterMaintenance(clusterAlias)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Cluster maintenance ended: %s", clusterAlias), Details: clusterAlias})
+}
+
+// ClusterMaintenance lists clusters in maintenance mode
+func (this *HttpAPI) ClusterMaintenance(params martini.Params, r render.Render, req *http.Request) {
+	maintenance, err := inst.ReadActiveClusterMaintenance()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, maintenance)
+}
+
+// AcknowledgeMasterFlapping re-enables automated master failovers on a cluster whose master has been flapping
+func (this *HttpAPI) AcknowledgeMasterFlapping(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	clusterAlias, err := figureClusterAlias(getClusterHint(params))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	comment := strings.TrimSpace(req.URL.Query().Get("comment"))
+	if comment == "" {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("No acknowledge comment given")})
+		return
+	}
+	userId := getUserId(req, user)
+	if userId == "" {
+		userId = inst.GetMaintenanceOwner()
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("ack-master-flapping", []string{clusterAlias, userId, comment})
+	} else {
+		err = inst.AcknowledgeMasterFlapping(clusterAlias, userId, comment)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Acknowledged master flapping"), Details: clusterAlias})
+}
+
 // ClusterInfo provides details of a given cluster
 func (this *HttpAPI) AcknowledgeInstanceRecoveries(params martini.Params, r render.Render, req *http.Request, user auth.User) {
 	if !isAuthorizedForAction(req, user) {
@@ -3532,6 +5006,18 @@ func (this *HttpAPI) registerAPIRequest(m *martini.ClassicMartini, path string,
 	this.registerAPIRequestInternal(m, path, handler, true)
 }
 
+// registerAPIPostRequest registers a POST request, for calls taking a JSON body
+func (this *HttpAPI) registerAPIPostRequest(m *martini.ClassicMartini, path string, handler martini.Handler) {
+	registeredPaths = append(registeredPaths, path)
+	fullPath := fmt.Sprintf("%s/api/%s", this.URLPrefix, path)
+
+	if config.Config.RaftEnabled {
+		m.Post(fullPath, raftReverseProxy, handler)
+	} else {
+		m.Post(fullPath, handler)
+	}
+}
+
 func (this *HttpAPI) registerAPIRequestNoProxy(m *martini.ClassicMartini, path string, handler martini.Handler) {
 	this.registerAPIRequestInternal(m, path, handler, false)
 }
@@ -3581,6 +5067,7 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "locate-gtid-errant/:host/:port", this.LocateErrantGTID)
 	this.registerAPIRequest(m, "gtid-errant-reset-master/:host/:port", this.ErrantGTIDResetMaster)
 	this.registerAPIRequest(m, "gtid-errant-inject-empty/:host/:port", this.ErrantGTIDInjectEmpty)
+	this.registerAPIRequest(m, "gtid-errant-dry-run/:host/:port/:method", this.ErrantGTIDRemediationDryRun)
 	this.registerAPIRequest(m, "skip-query/:host/:port", this.SkipQuery)
 	this.registerAPIRequest(m, "start-slave/:host/:port", this.StartSlave)
 	this.registerAPIRequest(m, "restart-slave/:host/:port", this.RestartSlave)
@@ -3629,15 +5116,28 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "cluster/alias/:clusterAlias", this.ClusterByAlias)
 	this.registerAPIRequest(m, "cluster/instance/:host/:port", this.ClusterByInstance)
 	this.registerAPIRequest(m, "cluster-info/:clusterHint", this.ClusterInfo)
+	this.registerAPIRequest(m, "read-pool/:clusterHint", this.ReadPool)
+	this.registerAPIRequest(m, "lag-slo/:clusterHint", this.LagSLO)
+	this.registerAPIRequest(m, "membership-violations", this.MembershipViolations)
+	this.registerAPIRequest(m, "membership-violations/:clusterHint", this.MembershipViolations)
+	this.registerAPIRequest(m, "membership-check-promotion/:host/:port", this.MembershipCheckPromotion)
 	this.registerAPIRequest(m, "cluster-info/alias/:clusterAlias", this.ClusterInfoByAlias)
 	this.registerAPIRequest(m, "cluster-osc-slaves/:clusterHint", this.ClusterOSCReplicas)
 	this.registerAPIRequest(m, "set-cluster-alias/:clusterName", this.SetClusterAliasManualOverride)
+	this.registerAPIRequest(m, "cluster-templates", this.ClusterTemplates)
+	this.registerAPIRequest(m, "cluster-template/:clusterHint", this.ClusterTemplate)
+	this.registerAPIRequest(m, "set-cluster-template/:clusterHint/:templateName", this.SetClusterTemplate)
+	this.registerAPIRequest(m, "cluster-priority-tier/:clusterHint", this.ClusterPriorityTier)
+	this.registerAPIRequest(m, "set-cluster-priority-tier/:clusterHint/:tier", this.SetClusterPriorityTier)
+	this.registerAPIRequest(m, "reset-cluster-priority-tier/:clusterHint", this.ResetClusterPriorityTier)
 	this.registerAPIRequest(m, "clusters", this.Clusters)
 	this.registerAPIRequest(m, "clusters-info", this.ClustersInfo)
 
 	this.registerAPIRequest(m, "masters", this.Masters)
 	this.registerAPIRequest(m, "master/:clusterHint", this.ClusterMaster)
 	this.registerAPIRequest(m, "instance-replicas/:host/:port", this.InstanceReplicas)
+	this.registerAPIRequest(m, "replication-compatibility/:host/:port", this.ReplicationCompatibility)
+	this.registerAPIRequest(m, "downstream-heads/:host/:port", this.DownstreamHeads)
 	this.registerAPIRequest(m, "all-instances", this.AllInstances)
 	this.registerAPIRequest(m, "downtimed", this.Downtimed)
 	this.registerAPIRequest(m, "downtimed/:clusterHint", this.Downtimed)
@@ -3645,7 +5145,12 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "topology/:host/:port", this.AsciiTopology)
 	this.registerAPIRequest(m, "topology-tabulated/:clusterHint", this.AsciiTopologyTabulated)
 	this.registerAPIRequest(m, "topology-tabulated/:host/:port", this.AsciiTopologyTabulated)
+	this.registerAPIRequest(m, "topology-dot/:clusterHint", this.TopologyDOT)
+	this.registerAPIRequest(m, "topology-dot/:host/:port", this.TopologyDOT)
 	this.registerAPIRequest(m, "snapshot-topologies", this.SnapshotTopologies)
+	this.registerAPIRequest(m, "topology-snapshot/:clusterHint", this.TopologySnapshot)
+	this.registerAPIRequest(m, "topology-snapshots/:clusterHint", this.TopologySnapshots)
+	this.registerAPIRequest(m, "topology-diff/:clusterHint", this.TopologyDiff)
 
 	// Key-value:
 	this.registerAPIRequest(m, "submit-masters-to-kv-stores", this.SubmitMastersToKvStores)
@@ -3665,6 +5170,8 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 
 	// Instance management:
 	this.registerAPIRequest(m, "instance/:host/:port", this.Instance)
+	this.registerAPIRequest(m, "instance-poll-interval/:host/:port", this.InstancePollInterval)
+	this.registerAPIRequest(m, "instance-poll-intervals", this.InstancePollIntervals)
 	this.registerAPIRequest(m, "discover/:host/:port", this.Discover)
 	this.registerAPIRequest(m, "async-discover/:host/:port", this.AsyncDiscover)
 	this.registerAPIRequest(m, "refresh/:host/:port", this.Refresh)
@@ -3677,7 +5184,24 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "maintenance", this.Maintenance)
 	this.registerAPIRequest(m, "begin-downtime/:host/:port/:owner/:reason", this.BeginDowntime)
 	this.registerAPIRequest(m, "begin-downtime/:host/:port/:owner/:reason/:duration", this.BeginDowntime)
+	this.registerAPIRequest(m, "begin-downtime-while/:host/:port/:owner/:reason/:duration/:condition", this.BeginDowntime)
+	this.registerAPIRequest(m, "begin-downtime-tagged/:owner/:reason", this.BeginDowntimeTagged)
+	this.registerAPIRequest(m, "begin-downtime-tagged/:owner/:reason/:duration", this.BeginDowntimeTagged)
 	this.registerAPIRequest(m, "end-downtime/:host/:port", this.EndDowntime)
+	this.registerAPIRequest(m, "schedule-downtime/:host/:port/:owner/:reason/:startTime/:duration", this.ScheduleDowntime)
+	this.registerAPIRequest(m, "schedule-cluster-downtime/:clusterHint/:owner/:reason/:startTime/:duration", this.ScheduleClusterDowntime)
+	this.registerAPIRequest(m, "unschedule-downtime/:scheduleId", this.UnscheduleDowntime)
+	this.registerAPIRequest(m, "downtime-schedules", this.DowntimeSchedules)
+	this.registerAPIRequest(m, "begin-no-touch/:host/:port/:owner/:reason", this.BeginNoTouch)
+	this.registerAPIRequest(m, "begin-no-touch/:host/:port/:owner/:reason/:duration", this.BeginNoTouch)
+	this.registerAPIRequest(m, "end-no-touch/:host/:port", this.EndNoTouch)
+	this.registerAPIRequest(m, "no-touch-locks", this.NoTouchLocks)
+
+	// Bulk operations:
+	this.registerAPIPostRequest(m, "bulk-begin-downtime", this.BulkBeginDowntime)
+	this.registerAPIPostRequest(m, "bulk-end-downtime", this.BulkEndDowntime)
+	this.registerAPIPostRequest(m, "bulk-register-candidate", this.BulkRegisterCandidate)
+	this.registerAPIPostRequest(m, "bulk-forget", this.BulkForget)
 
 	// Recovery:
 	this.registerAPIRequest(m, "replication-analysis", this.ReplicationAnalysis)
@@ -3691,11 +5215,30 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "graceful-master-takeover/:host/:port/:designatedHost/:designatedPort", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:clusterHint", this.GracefulMasterTakeover)
 	this.registerAPIRequest(m, "graceful-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.GracefulMasterTakeover)
+	this.registerAPIRequest(m, "graceful-master-takeover-progress", this.GracefulMasterTakeoverProgress)
+	this.registerAPIRequest(m, "graceful-master-takeover-progress/:clusterHint", this.GracefulMasterTakeoverProgress)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover/:clusterHint", this.ScheduleGracefulMasterTakeover)
+	this.registerAPIRequest(m, "schedule-graceful-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.ScheduleGracefulMasterTakeover)
+	this.registerAPIRequest(m, "cancel-scheduled-master-takeover/:uid", this.CancelScheduledMasterTakeover)
+	this.registerAPIRequest(m, "scheduled-master-takeovers", this.ScheduledMasterTakeovers)
+	this.registerAPIRequest(m, "scheduled-master-takeovers/:clusterHint", this.ScheduledMasterTakeovers)
+	this.registerAPIRequest(m, "rolling-restart/:clusterHint", this.RollingRestart)
+	this.registerAPIRequest(m, "rolling-restart/:clusterHint/:designatedHost/:designatedPort", this.RollingRestart)
+	this.registerAPIRequest(m, "rolling-restart-pause/:clusterHint", this.RollingRestartPause)
+	this.registerAPIRequest(m, "rolling-restart-resume/:clusterHint", this.RollingRestartResume)
+	this.registerAPIRequest(m, "rolling-restart-abort/:clusterHint", this.RollingRestartAbort)
+	this.registerAPIRequest(m, "rolling-restarts", this.RollingRestarts)
+	this.registerAPIRequest(m, "rolling-restarts/:clusterHint", this.RollingRestarts)
+	this.registerAPIRequest(m, "replica-provisioning-requests", this.ReplicaProvisioningRequests)
+	this.registerAPIRequest(m, "replica-provisioning-requests/:clusterHint", this.ReplicaProvisioningRequests)
 	this.registerAPIRequest(m, "force-master-failover/:host/:port", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-failover/:clusterHint", this.ForceMasterFailover)
 	this.registerAPIRequest(m, "force-master-takeover/:clusterHint/:designatedHost/:designatedPort", this.ForceMasterTakeover)
 	this.registerAPIRequest(m, "force-master-takeover/:host/:port/:designatedHost/:designatedPort", this.ForceMasterTakeover)
 	this.registerAPIRequest(m, "register-candidate/:host/:port/:promotionRule", this.RegisterCandidate)
+	this.registerAPIRequest(m, "register-candidate-tagged/:promotionRule", this.RegisterCandidateTagged)
+	this.registerAPIRequest(m, "register-cluster-candidates/:clusterHint/:promotionRule", this.RegisterClusterCandidates)
+	this.registerAPIRequest(m, "unregister-candidate/:host/:port", this.UnregisterCandidate)
 	this.registerAPIRequest(m, "automated-recovery-filters", this.AutomatedRecoveryFilters)
 	this.registerAPIRequest(m, "audit-failure-detection", this.AuditFailureDetection)
 	this.registerAPIRequest(m, "audit-failure-detection/:page", this.AuditFailureDetection)
@@ -3710,14 +5253,46 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "audit-recovery/cluster/:clusterName/:page", this.AuditRecovery)
 	this.registerAPIRequest(m, "audit-recovery/alias/:clusterAlias", this.AuditRecovery)
 	this.registerAPIRequest(m, "audit-recovery-steps/:uid", this.AuditRecoverySteps)
+	this.registerAPIRequest(m, "recovery-analytics", this.RecoveryAnalytics)
+	this.registerAPIRequest(m, "recovery-analytics/alias/:clusterAlias", this.RecoveryAnalytics)
+	this.registerAPIRequest(m, "recovery-journal/:uid", this.RecoveryJournal)
+	this.registerAPIRequest(m, "recovery-report/:uid", this.RecoveryReport)
+	this.registerAPIRequest(m, "graphql", this.GraphQL)
+	this.registerAPIPostRequest(m, "graphql", this.GraphQL)
+	this.registerAPIRequest(m, "recovery-topology-diff/:uid", this.RecoveryTopologyDiff)
+	this.registerAPIRequest(m, "postponed-functions", this.PostponedFunctions)
+	this.registerAPIRequest(m, "postponed-functions/:uid", this.PostponedFunctions)
+	this.registerAPIRequest(m, "recovery-queue", this.RecoveryQueue)
+	this.registerAPIRequest(m, "datacenter-failures", this.DatacenterFailures)
+	// Each raft peer answers from its own vantage point, rather than forwarding to the leader
+	this.registerAPIRequestNoProxy(m, "check-instance-reachability/:host/:port", this.CheckInstanceReachability)
+	this.registerAPIRequest(m, "canary-shadow-decisions", this.CanaryShadowDecisions)
+	this.registerAPIRequest(m, "canary-shadow-decisions/:seconds", this.CanaryShadowDecisions)
+	this.registerAPIRequest(m, "canary-report", this.CanaryReport)
+	this.registerAPIRequest(m, "canary-report/:seconds", this.CanaryReport)
 	this.registerAPIRequest(m, "active-cluster-recovery/:clusterName", this.ActiveClusterRecovery)
+	this.registerAPIRequest(m, "dashboards", this.Dashboards)
+	this.registerAPIRequest(m, "dashboard/:name", this.Dashboard)
+	this.registerAPIPostRequest(m, "dashboard/:name", this.SaveDashboard)
+	this.registerAPIRequest(m, "delete-dashboard/:name", this.DeleteDashboard)
+	this.registerAPIRequest(m, "federation/topology", this.FederationTopology)
+	this.registerAPIRequest(m, "federation/recoveries", this.FederationRecoveries)
+	this.registerAPIRequest(m, "federation/status", this.FederationStatus)
+	this.registerAPIRequest(m, "federation/cluster-owner/:clusterHint", this.FederationClusterOwner)
+	this.registerAPIPostRequest(m, "chatops/slack", this.SlackCommand)
 	this.registerAPIRequest(m, "recently-active-cluster-recovery/:clusterName", this.RecentlyActiveClusterRecovery)
 	this.registerAPIRequest(m, "recently-active-instance-recovery/:host/:port", this.RecentlyActiveInstanceRecovery)
 	this.registerAPIRequest(m, "ack-recovery/cluster/:clusterHint", this.AcknowledgeClusterRecoveries)
 	this.registerAPIRequest(m, "ack-recovery/cluster/alias/:clusterAlias", this.AcknowledgeClusterRecoveries)
 	this.registerAPIRequest(m, "ack-recovery/instance/:host/:port", this.AcknowledgeInstanceRecoveries)
+	this.registerAPIRequest(m, "ack-recovery/analysis/:analysis", this.AcknowledgeAnalysisRecoveries)
 	this.registerAPIRequest(m, "ack-recovery/:recoveryId", this.AcknowledgeRecovery)
 	this.registerAPIRequest(m, "ack-recovery/uid/:uid", this.AcknowledgeRecovery)
+	this.registerAPIRequest(m, "ack-master-flapping/:clusterHint", this.AcknowledgeMasterFlapping)
+	this.registerAPIRequest(m, "begin-cluster-maintenance/:clusterHint/:owner/:reason", this.BeginClusterMaintenance)
+	this.registerAPIRequest(m, "begin-cluster-maintenance/:clusterHint/:owner/:reason/:duration", this.BeginClusterMaintenance)
+	this.registerAPIRequest(m, "end-cluster-maintenance/:clusterHint", this.EndClusterMaintenance)
+	this.registerAPIRequest(m, "cluster-maintenance", this.ClusterMaintenance)
 	this.registerAPIRequest(m, "ack-all-recoveries", this.AcknowledgeAllRecoveries)
 	this.registerAPIRequest(m, "blocked-recoveries", this.BlockedRecoveries)
 	this.registerAPIRequest(m, "blocked-recoveries/cluster/:clusterName", this.BlockedRecoveries)
@@ -3728,10 +5303,27 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	// General
 	this.registerAPIRequest(m, "problems", this.Problems)
 	this.registerAPIRequest(m, "problems/:clusterName", this.Problems)
+	this.registerAPIRequest(m, "suppress-problems/:owner/:reason/:duration", this.SuppressProblems)
+	this.registerAPIRequest(m, "unsuppress-problems/:suppressionId", this.UnsuppressProblems)
+	this.registerAPIRequest(m, "problem-suppressions", this.ProblemSuppressions)
+	this.registerAPIRequest(m, "analysis-rules", this.AnalysisRules)
+	this.registerAPIPostRequest(m, "analysis-rule", this.WriteAnalysisRule)
+	this.registerAPIRequest(m, "delete-analysis-rule/:code", this.DeleteAnalysisRule)
 	this.registerAPIRequest(m, "audit", this.Audit)
 	this.registerAPIRequest(m, "audit/:page", this.Audit)
 	this.registerAPIRequest(m, "audit/instance/:host/:port", this.Audit)
 	this.registerAPIRequest(m, "audit/instance/:host/:port/:page", this.Audit)
+	this.registerAPIRequest(m, "schema/:type/:version", this.Schema)
+	this.registerAPIRequest(m, "instance-changes", this.InstanceChanges)
+	this.registerAPIRequest(m, "instance-changes/:page", this.InstanceChanges)
+	this.registerAPIRequest(m, "instance-changes/instance/:host/:port", this.InstanceChanges)
+	this.registerAPIRequest(m, "instance-changes/instance/:host/:port/:page", this.InstanceChanges)
+	this.registerAPIRequest(m, "instance-changes/cluster/:clusterHint", this.InstanceChanges)
+	this.registerAPIRequest(m, "instance-changes/cluster/:clusterHint/:page", this.InstanceChanges)
+	this.registerAPIRequest(m, "generate-fleet-report", this.GenerateFleetReport)
+	this.registerAPIRequest(m, "fleet-reports", this.FleetReports)
+	this.registerAPIRequest(m, "fleet-reports/:page", this.FleetReports)
+	this.registerAPIRequest(m, "fleet-report/:reportId", this.FleetReport)
 	this.registerAPIRequest(m, "resolve/:host/:port", this.Resolve)
 
 	// Meta, no proxy
@@ -3751,13 +5343,22 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequestNoProxy(m, "raft-snapshot", this.RaftSnapshot)
 	this.registerAPIRequestNoProxy(m, "raft-follower-health-report/:authenticationToken/:raftBind/:raftAdvertise", this.RaftFollowerHealthReport)
 	this.registerAPIRequestNoProxy(m, "reload-configuration", this.ReloadConfiguration)
+	this.registerAPIRequestNoProxy(m, "effective-configuration", this.EffectiveConfiguration)
 	this.registerAPIRequestNoProxy(m, "hostname-resolve-cache", this.HostnameResolveCache)
 	this.registerAPIRequestNoProxy(m, "reset-hostname-resolve-cache", this.ResetHostnameResolveCache)
+	this.registerAPIRequestNoProxy(m, "hostname-resolve-test/:host", this.HostnameResolveTest)
+	this.registerAPIRequestNoProxy(m, "hostname-resolve-test/:host/:port", this.HostnameResolveTest)
+	this.registerAPIRequest(m, "hostname-rules", this.HostnameRules)
+	this.registerAPIPostRequest(m, "hostname-rule", this.WriteHostnameRule)
+	this.registerAPIRequest(m, "delete-hostname-rule/:name", this.DeleteHostnameRule)
 	// Meta
 	this.registerAPIRequest(m, "reelect", this.Reelect)
 	this.registerAPIRequest(m, "reload-cluster-alias", this.ReloadClusterAlias)
 	this.registerAPIRequest(m, "deregister-hostname-unresolve/:host/:port", this.DeregisterHostnameUnresolve)
 	this.registerAPIRequest(m, "register-hostname-unresolve/:host/:port/:virtualname", this.RegisterHostnameUnresolve)
+	this.registerAPIRequest(m, "reported-addresses", this.ReportedAddresses)
+	this.registerAPIRequest(m, "register-reported-address/:host/:port/:reportedHost/:reportedPort", this.RegisterReportedAddress)
+	this.registerAPIRequest(m, "deregister-reported-address/:host/:port", this.DeregisterReportedAddress)
 
 	// Bulk access to information
 	this.registerAPIRequest(m, "bulk-instances", this.BulkInstances)
@@ -3787,6 +5388,13 @@ func (this *HttpAPI) RegisterRequests(m *martini.ClassicMartini) {
 	this.registerAPIRequest(m, "agent-seed-states/:seedId", this.AgentSeedStates)
 	this.registerAPIRequest(m, "agent-abort-seed/:seedId", this.AbortSeed)
 	this.registerAPIRequest(m, "agent-custom-command/:host/:command", this.AgentCustomCommand)
+	this.registerAPIRequest(m, "rebuild-replica/:host/:port", this.RebuildReplica)
+	this.registerAPIRequest(m, "rebuild-replica/:host/:port/:sourceHost/:sourcePort", this.RebuildReplica)
+	this.registerAPIRequest(m, "reseed-instance/:host/:port", this.ReseedInstance)
+	this.registerAPIRequest(m, "reseed-instance/:host/:port/:donorHost/:donorPort", this.ReseedInstance)
+	this.registerAPIRequest(m, "replica-rebuilds", this.ReplicaRebuilds)
+	this.registerAPIRequest(m, "replica-rebuilds/:host/:port", this.ReplicaRebuilds)
+	this.registerAPIRequest(m, "replica-rebuild/:uid", this.ReplicaRebuild)
 	this.registerAPIRequest(m, "seeds", this.Seeds)
 
 	// Configurable status check endpoint
@@ -0,0 +1,94 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+)
+
+// analysisRuleMaxRequestBytes caps the size of an analysis rule request body
+const analysisRuleMaxRequestBytes = 64 * 1024
+
+// AnalysisRules lists the effective analysis rules: configured ones, followed by those managed via API
+func (this *HttpAPI) AnalysisRules(params martini.Params, r render.Render, req *http.Request) {
+	rules, err := inst.ReadAnalysisRules()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, rules)
+}
+
+// WriteAnalysisRule creates or replaces an analysis rule. The request body is the rule as JSON:
+// Code, Description, ClusterFilters and Conditions.
+func (this *HttpAPI) WriteAnalysisRule(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	rule := &config.AnalysisRule{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, analysisRuleMaxRequestBytes)).Decode(rule); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot parse request body: %+v", err)})
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-analysis-rule", rule)
+	} else {
+		err = inst.WriteAnalysisRule(rule)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Analysis rule saved: %s", rule.Code), Details: rule})
+}
+
+// DeleteAnalysisRule removes an analysis rule managed via API
+func (this *HttpAPI) DeleteAnalysisRule(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	code := params["code"]
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-analysis-rule", code)
+	} else {
+		err = inst.DeleteAnalysisRule(code)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Analysis rule deleted: %s", code)})
+}
@@ -0,0 +1,238 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+	"github.com/openark/golib/util"
+)
+
+// BulkOperationRequest is the JSON body of bulk API calls. Instances are selected by any combination of
+// explicit keys, a tag expression and a cluster; the operation applies to the union of all selected.
+type BulkOperationRequest struct {
+	Instances     []string // "host:port" entries
+	Tag           string   // tag expression, e.g. "env=prod and role=reporting"
+	Cluster       string   // cluster name, alias, or any instance in the cluster
+	Owner         string   // bulk-begin-downtime
+	Reason        string   // bulk-begin-downtime
+	Duration      string   // bulk-begin-downtime, e.g. "30m"; empty for the default duration
+	PromotionRule string   // bulk-register-candidate
+	Expiry        string   // bulk-register-candidate, e.g. "2h"; empty for the default expiry
+}
+
+// BulkOperationResult is the outcome of a bulk operation on a single instance
+type BulkOperationResult struct {
+	Key     inst.InstanceKey
+	Code    APIResponseCode
+	Message string
+}
+
+// readBulkOperationRequest parses the request body and selects the instances to operate on, sorted
+func readBulkOperationRequest(req *http.Request, resolve bool) (*BulkOperationRequest, []inst.InstanceKey, error) {
+	bulkRequest := &BulkOperationRequest{}
+	if err := json.NewDecoder(req.Body).Decode(bulkRequest); err != nil {
+		return nil, nil, fmt.Errorf("Cannot parse request body: %+v", err)
+	}
+	if len(bulkRequest.Instances) == 0 && bulkRequest.Tag == "" && bulkRequest.Cluster == "" {
+		return nil, nil, fmt.Errorf("No instances selected. Expecting Instances, Tag and/or Cluster")
+	}
+	selected := inst.NewInstanceKeyMap()
+	for _, hostPort := range bulkRequest.Instances {
+		var instanceKey *inst.InstanceKey
+		var err error
+		if resolve {
+			instanceKey, err = inst.ParseResolveInstanceKey(hostPort)
+		} else {
+			instanceKey, err = inst.ParseRawInstanceKey(hostPort)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		selected.AddKey(*instanceKey)
+	}
+	if bulkRequest.Tag != "" {
+		tagged, err := inst.GetInstanceKeysByTags(bulkRequest.Tag)
+		if err != nil {
+			return nil, nil, err
+		}
+		selected.AddKeys(tagged.GetInstanceKeys())
+	}
+	if bulkRequest.Cluster != "" {
+		clusterName, err := figureClusterName(bulkRequest.Cluster)
+		if err != nil {
+			return nil, nil, err
+		}
+		instances, err := inst.ReadClusterInstances(clusterName)
+		if err != nil {
+			return nil, nil, err
+		}
+		selected.AddInstances(instances)
+	}
+	return bulkRequest, selected.GetInstanceKeys(), nil
+}
+
+// runBulkOperation applies given operation on each instance in turn; a failure on one instance does not stop the others
+func runBulkOperation(instanceKeys []inst.InstanceKey, operation func(instanceKey *inst.InstanceKey) error) (results []BulkOperationResult, countFailed int) {
+	results = []BulkOperationResult{}
+	for i := range instanceKeys {
+		result := BulkOperationResult{Key: instanceKeys[i], Code: OK}
+		if err := operation(&instanceKeys[i]); err != nil {
+			result.Code = ERROR
+			result.Message = err.Error()
+			countFailed++
+		}
+		results = append(results, result)
+	}
+	return results, countFailed
+}
+
+// respondBulkOperation responds with per-instance results. The response code is ERROR if the operation failed on any instance.
+func respondBulkOperation(r render.Render, operationName string, results []BulkOperationResult, countFailed int) {
+	code := OK
+	if countFailed > 0 {
+		code = ERROR
+	}
+	message := fmt.Sprintf("%s: %d succeeded, %d failed", operationName, len(results)-countFailed, countFailed)
+	Respond(r, &APIResponse{Code: code, Message: message, Details: results})
+}
+
+// BulkBeginDowntime sets a downtime flag on all selected instances
+func (this *HttpAPI) BulkBeginDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	bulkRequest, instanceKeys, err := readBulkOperationRequest(req, true)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if bulkRequest.Owner == "" || bulkRequest.Reason == "" {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Owner and Reason are required"})
+		return
+	}
+	var durationSeconds int = 0
+	if bulkRequest.Duration != "" {
+		durationSeconds, err = util.SimpleTimeToSeconds(bulkRequest.Duration)
+		if durationSeconds < 0 {
+			err = fmt.Errorf("Duration value must be non-negative. Given value: %d", durationSeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+	}
+	duration := time.Duration(durationSeconds) * time.Second
+	results, countFailed := runBulkOperation(instanceKeys, func(instanceKey *inst.InstanceKey) (err error) {
+		downtime := inst.NewDowntime(instanceKey, bulkRequest.Owner, bulkRequest.Reason, duration)
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("begin-downtime", downtime)
+		} else {
+			err = inst.BeginDowntime(downtime)
+		}
+		return err
+	})
+	respondBulkOperation(r, "begin-downtime", results, countFailed)
+}
+
+// BulkEndDowntime removes the downtime flag from all selected instances
+func (this *HttpAPI) BulkEndDowntime(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	_, instanceKeys, err := readBulkOperationRequest(req, true)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	results, countFailed := runBulkOperation(instanceKeys, func(instanceKey *inst.InstanceKey) (err error) {
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("end-downtime", *instanceKey)
+		} else {
+			_, err = inst.EndDowntime(instanceKey)
+		}
+		return err
+	})
+	respondBulkOperation(r, "end-downtime", results, countFailed)
+}
+
+// BulkRegisterCandidate registers given promotion rule on all selected instances
+func (this *HttpAPI) BulkRegisterCandidate(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	bulkRequest, instanceKeys, err := readBulkOperationRequest(req, true)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	promotionRule, err := inst.ParseCandidatePromotionRule(bulkRequest.PromotionRule)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var expiry time.Duration
+	if bulkRequest.Expiry != "" {
+		expirySeconds, err := util.SimpleTimeToSeconds(bulkRequest.Expiry)
+		if err == nil && expirySeconds <= 0 {
+			err = fmt.Errorf("Expiry value must be positive. Given value: %d", expirySeconds)
+		}
+		if err != nil {
+			Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		expiry = time.Duration(expirySeconds) * time.Second
+	}
+	results, countFailed := runBulkOperation(instanceKeys, func(instanceKey *inst.InstanceKey) error {
+		return registerCandidates([]inst.InstanceKey{*instanceKey}, promotionRule, expiry)
+	})
+	respondBulkOperation(r, "register-candidate", results, countFailed)
+}
+
+// BulkForget removes all selected instances from the backend database
+func (this *HttpAPI) BulkForget(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	_, instanceKeys, err := readBulkOperationRequest(req, false)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	results, countFailed := runBulkOperation(instanceKeys, func(instanceKey *inst.InstanceKey) (err error) {
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("forget", *instanceKey)
+		} else {
+			err = inst.ForgetInstance(instanceKey)
+		}
+		return err
+	})
+	respondBulkOperation(r, "forget", results, countFailed)
+}
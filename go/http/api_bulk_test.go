@@ -0,0 +1,52 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func newBulkOperationTestRequest(body string) *http.Request {
+	req, _ := http.NewRequest("POST", "/api/bulk-forget", strings.NewReader(body))
+	return req
+}
+
+func TestReadBulkOperationRequest(t *testing.T) {
+	{
+		bulkRequest, instanceKeys, err := readBulkOperationRequest(newBulkOperationTestRequest(`{"Instances": ["db2:3306", "db1:3306", "db2:3306"], "Owner": "ops"}`), false)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(bulkRequest.Owner, "ops")
+		test.S(t).ExpectEquals(len(instanceKeys), 2)
+		test.S(t).ExpectEquals(instanceKeys[0].StringCode(), "db1:3306")
+		test.S(t).ExpectEquals(instanceKeys[1].StringCode(), "db2:3306")
+	}
+	{
+		_, _, err := readBulkOperationRequest(newBulkOperationTestRequest(`{"Owner": "ops"}`), false)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, _, err := readBulkOperationRequest(newBulkOperationTestRequest(`{"Instances": [`), false)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestRunBulkOperation(t *testing.T) {
+	instanceKeys := []inst.InstanceKey{{Hostname: "db1", Port: 3306}, {Hostname: "db2", Port: 3306}, {Hostname: "db3", Port: 3306}}
+	results, countFailed := runBulkOperation(instanceKeys, func(instanceKey *inst.InstanceKey) error {
+		if instanceKey.Hostname == "db2" {
+			return fmt.Errorf("cannot operate on %s", instanceKey.Hostname)
+		}
+		return nil
+	})
+	test.S(t).ExpectEquals(countFailed, 1)
+	test.S(t).ExpectEquals(len(results), 3)
+	test.S(t).ExpectEquals(results[0].Code, OK)
+	test.S(t).ExpectEquals(results[1].Code, ERROR)
+	test.S(t).ExpectEquals(results[1].Key, instanceKeys[1])
+	test.S(t).ExpectEquals(results[1].Message, "cannot operate on db2")
+	test.S(t).ExpectEquals(results[2].Code, OK)
+}
@@ -0,0 +1,262 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+	"github.com/openark/golib/log"
+
+	"github.com/github/orchestrator/go/chatops"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+	"github.com/github/orchestrator/go/raft"
+)
+
+// slackCommandPath is where Slack posts slash commands. Requests are authenticated by their Slack signature,
+// rather than by AuthenticationMethod.
+const slackCommandPath = "/api/chatops/slack"
+
+// slackCommandMaxBodySize bounds the size of a slash command request
+const slackCommandMaxBodySize = 64 * 1024
+
+// isChatOpsPath returns true for chatops paths, which authenticate requests by themselves
+func isChatOpsPath(path string) bool {
+	return path == config.Config.URLPrefix+slackCommandPath
+}
+
+// ExemptChatOps wraps an authentication middleware such that it does not apply to chatops paths
+func ExemptChatOps(authHandler martini.Handler) martini.Handler {
+	return func(req *http.Request, c martini.Context) {
+		if isChatOpsPath(req.URL.Path) {
+			c.Map(auth.User(""))
+			return
+		}
+		if _, err := c.Invoke(authHandler); err != nil {
+			log.Errore(err)
+		}
+	}
+}
+
+// slackUserId returns the user id by which actions taken via Slack are audited and acknowledged
+func slackUserId(form url.Values) string {
+	return fmt.Sprintf("slack:%s", form.Get("user_name"))
+}
+
+// SlackCommand serves a Slack slash command: verifies the request's signature, parses the command text and runs
+// the command, responding with Slack blocks. Mutating commands require the Slack user to be one of SlackPowerUsers.
+func (this *HttpAPI) SlackCommand(params martini.Params, r render.Render, req *http.Request) {
+	if !chatops.IsSlackEnabled() {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Slack chatops is not enabled"})
+		return
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, slackCommandMaxBodySize))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if err := chatops.VerifySlackRequest(req.Header.Get(chatops.SlackTimestampHeader), req.Header.Get(chatops.SlackSignatureHeader), body, time.Now()); err != nil {
+		log.Warningf("chatops: rejecting Slack request: %+v", err)
+		r.JSON(http.StatusUnauthorized, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+
+	command, err := chatops.ParseCommand(form.Get("text"))
+	if err != nil {
+		r.JSON(http.StatusOK, chatops.NewSlackErrorMessage(fmt.Errorf("%s\n```%s```", err.Error(), chatops.Usage)))
+		return
+	}
+	if command.IsMutating() {
+		markMutatingRequest(req)
+		if config.Config.ReadOnly {
+			r.JSON(http.StatusOK, chatops.NewSlackErrorMessage(fmt.Errorf("orchestrator is read-only")))
+			return
+		}
+		if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+			// A follower does not run mutating commands. One serving the request locally forwards it to the leader.
+			r.JSON(http.StatusOK, chatops.NewSlackErrorMessage(fmt.Errorf("orchestrator node %s is not the raft leader", orcraft.ThisHostname)))
+			return
+		}
+		if !chatops.IsPowerUser(form.Get("user_id"), form.Get("user_name")) {
+			inst.AuditOperation("chatops", nil, fmt.Sprintf("Unauthorized %s by %s", command.Action, slackUserId(form)))
+			r.JSON(http.StatusOK, chatops.NewSlackErrorMessage(fmt.Errorf("%s is not authorized to %s", form.Get("user_name"), command.Action)))
+			return
+		}
+	}
+
+	var message *chatops.SlackMessage
+	switch command.Action {
+	case chatops.HelpAction:
+		message = chatops.NewSlackMessage(chatops.SlackEphemeral, "orchestrator commands").AddCode(chatops.Usage)
+	case chatops.ShowClusterAction:
+		message, err = this.slackShowCluster(command)
+	case chatops.AckRecoveryAction:
+		message, err = this.slackAckRecovery(command, form)
+	case chatops.BeginDowntimeAction:
+		message, err = this.slackBeginDowntime(command, form)
+	case chatops.GracefulTakeoverAction:
+		message, err = this.slackGracefulTakeover(command, form)
+	}
+	if err != nil {
+		message = chatops.NewSlackErrorMessage(err)
+	}
+	r.JSON(http.StatusOK, message)
+}
+
+// slackShowCluster reports a cluster's master, its pending recoveries, and its topology
+func (this *HttpAPI) slackShowCluster(command *chatops.Command) (*chatops.SlackMessage, error) {
+	clusterName, err := figureClusterName(command.ClusterHint)
+	if err != nil {
+		return nil, err
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	masters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	master := "unknown"
+	if len(masters) > 0 {
+		master = masters[0].Key.DisplayString()
+	}
+	recoveries, err := logic.ReadRecentRecoveries(clusterName, true, 0)
+	if err != nil {
+		return nil, err
+	}
+	topology, err := inst.ASCIITopology(clusterName, "", false)
+	if err != nil {
+		return nil, err
+	}
+	message := chatops.NewSlackMessage(chatops.SlackEphemeral, fmt.Sprintf("Cluster %s", clusterInfo.ClusterAlias))
+	message.AddSection("",
+		"Cluster", clusterInfo.ClusterName,
+		"Master", master,
+		"Instances", fmt.Sprintf("%d", clusterInfo.CountInstances),
+		"Unacknowledged recoveries", fmt.Sprintf("%d", len(recoveries)),
+	)
+	for i := range recoveries {
+		recovery := &recoveries[i]
+		message.AddSection(fmt.Sprintf("`%s` %s on %s, at %s", recovery.UID, recovery.AnalysisEntry.Analysis, recovery.AnalysisEntry.AnalyzedInstanceKey.DisplayString(), recovery.RecoveryStartTimestamp))
+	}
+	return message.AddCode(topology), nil
+}
+
+// slackAckRecovery acknowledges recoveries of a cluster, or a single recovery given by id or uid
+func (this *HttpAPI) slackAckRecovery(command *chatops.Command, form url.Values) (message *chatops.SlackMessage, err error) {
+	userId := slackUserId(form)
+	ack := logic.NewRecoveryAcknowledgement(userId, command.Comment)
+	acknowledged := command.Recovery
+	if recoveryId, parseErr := strconv.ParseInt(command.Recovery, 10, 0); parseErr == nil {
+		ack.Id = recoveryId
+	} else if clusterName, figureErr := figureClusterName(command.Recovery); figureErr == nil {
+		ack.ClusterName = clusterName
+		acknowledged = fmt.Sprintf("recoveries of %s", clusterName)
+	} else {
+		ack.UID = command.Recovery
+	}
+
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("ack-recovery", ack)
+	} else if ack.ClusterName != "" {
+		_, err = logic.AcknowledgeClusterRecoveries(ack.ClusterName, userId, command.Comment)
+	} else if ack.UID != "" {
+		_, err = logic.AcknowledgeRecoveryByUID(ack.UID, userId, command.Comment)
+	} else {
+		_, err = logic.AcknowledgeRecovery(ack.Id, userId, command.Comment)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chatops.NewSlackMessage(chatops.SlackInChannel, fmt.Sprintf("Acknowledged %s", acknowledged)).
+		AddSection("", "By", form.Get("user_name"), "Comment", command.Comment), nil
+}
+
+// slackBeginDowntime downtimes an instance
+func (this *HttpAPI) slackBeginDowntime(command *chatops.Command, form url.Values) (message *chatops.SlackMessage, err error) {
+	instanceKey, err := inst.ParseResolveInstanceKey(command.Instance)
+	if err != nil {
+		return nil, err
+	}
+	downtime := inst.NewDowntime(instanceKey, slackUserId(form), command.Comment, command.Duration)
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("begin-downtime", downtime)
+	} else {
+		err = inst.BeginDowntime(downtime)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return chatops.NewSlackMessage(chatops.SlackInChannel, fmt.Sprintf("Downtimed %s", instanceKey.DisplayString())).
+		AddSection("", "By", form.Get("user_name"), "Duration", command.Duration.String(), "Reason", command.Comment), nil
+}
+
+// slackGracefulTakeover begins a graceful master takeover. A takeover outlasts the time Slack waits for a
+// response, so it runs in the background, and its outcome is posted to the command's response_url.
+func (this *HttpAPI) slackGracefulTakeover(command *chatops.Command, form url.Values) (message *chatops.SlackMessage, err error) {
+	clusterName, err := figureClusterName(command.ClusterHint)
+	if err != nil {
+		return nil, err
+	}
+	designatedKey := &inst.InstanceKey{}
+	designated := "any"
+	if command.Instance != "" {
+		if designatedKey, err = inst.ParseResolveInstanceKey(command.Instance); err != nil {
+			return nil, err
+		}
+		designated = designatedKey.DisplayString()
+	}
+	responseURL := form.Get("response_url")
+	if responseURL == "" {
+		return nil, fmt.Errorf("No response_url given; cannot report takeover outcome")
+	}
+	inst.AuditOperation("chatops", nil, fmt.Sprintf("graceful takeover of %s by %s", clusterName, slackUserId(form)))
+	go func() {
+		var result *chatops.SlackMessage
+		topologyRecovery, _, err := logic.GracefulMasterTakeover(clusterName, designatedKey)
+		if err == nil && (topologyRecovery == nil || topologyRecovery.SuccessorKey == nil) {
+			err = fmt.Errorf("graceful-master-takeover: no successor promoted")
+		}
+		if err != nil {
+			result = chatops.NewSlackMessage(chatops.SlackInChannel, fmt.Sprintf("Graceful takeover of %s failed", clusterName)).AddSection(err.Error())
+		} else {
+			result = chatops.NewSlackMessage(chatops.SlackInChannel, fmt.Sprintf("Graceful takeover of %s complete", clusterName)).
+				AddSection("", "Promoted", topologyRecovery.SuccessorKey.DisplayString(), "Recovery", topologyRecovery.UID)
+		}
+		if err := chatops.PostSlackResponse(responseURL, result); err != nil {
+			log.Errorf("chatops: reporting graceful takeover of %s: %+v", clusterName, err)
+		}
+	}()
+	return chatops.NewSlackMessage(chatops.SlackInChannel, fmt.Sprintf("Graceful takeover of %s begun", clusterName)).
+		AddSection("", "By", form.Get("user_name"), "Designated", designated), nil
+}
@@ -0,0 +1,125 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+)
+
+// dashboardMaxRequestBytes caps the size of a dashboard request body
+const dashboardMaxRequestBytes = 128 * 1024
+
+// isAuthorizedForDashboardChange checks whether the user may change their own dashboards. Unlike
+// isAuthorizedForAction, this requires no write privileges on topologies: read-only users may keep dashboards too.
+func isAuthorizedForDashboardChange(req *http.Request) bool {
+	markMutatingRequest(req)
+
+	if config.Config.ReadOnly {
+		return false
+	}
+	if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+		return false
+	}
+	return true
+}
+
+// Dashboards lists the dashboards of the current user
+func (this *HttpAPI) Dashboards(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	dashboards, err := inst.ReadDashboards(getOriginalUserId(req, user))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, dashboards)
+}
+
+// Dashboard shows a dashboard of the current user
+func (this *HttpAPI) Dashboard(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	dashboard, found, err := inst.ReadDashboard(getOriginalUserId(req, user), params["name"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if !found {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Dashboard %s not found", params["name"])})
+		return
+	}
+	r.JSON(http.StatusOK, dashboard)
+}
+
+// SaveDashboard creates or replaces a dashboard of the current user. The request body is the dashboard as JSON:
+// PinnedClusters, ProblemFilters and Layout.
+func (this *HttpAPI) SaveDashboard(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForDashboardChange(req) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	dashboard := &inst.Dashboard{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, dashboardMaxRequestBytes)).Decode(dashboard); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot parse request body: %+v", err)})
+		return
+	}
+	dashboard.Owner = getOriginalUserId(req, user)
+	dashboard.Name = params["name"]
+	dashboard.LastUpdated = ""
+	if err := dashboard.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-dashboard", dashboard)
+	} else {
+		err = inst.WriteDashboard(dashboard)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dashboard saved: %s", dashboard.Name), Details: dashboard})
+}
+
+// DeleteDashboard removes a dashboard of the current user
+func (this *HttpAPI) DeleteDashboard(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForDashboardChange(req) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	dashboard := &inst.Dashboard{Owner: getOriginalUserId(req, user), Name: params["name"]}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-dashboard", dashboard)
+	} else {
+		err = inst.DeleteDashboard(dashboard.Owner, dashboard.Name)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Dashboard deleted: %s", dashboard.Name)})
+}
@@ -0,0 +1,155 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/federation"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+)
+
+// federationRecoveriesLimit is the number of recovery events served to a federation member per request
+const federationRecoveriesLimit = 100
+
+// readFederationLocalClusters returns the federation metadata of the clusters this member monitors
+func readFederationLocalClusters() ([]federation.ClusterMetadata, error) {
+	clustersInfo, err := inst.ReadClustersInfo("")
+	if err != nil {
+		return nil, err
+	}
+	clusters := []federation.ClusterMetadata{}
+	for i := range clustersInfo {
+		clusters = append(clusters, federation.NewClusterMetadata(&clustersInfo[i]))
+	}
+	return clusters, nil
+}
+
+// newFederationRecoveryEvent returns the federation event of a local recovery
+func newFederationRecoveryEvent(topologyRecovery *logic.TopologyRecovery) federation.RecoveryEvent {
+	event := federation.RecoveryEvent{
+		Id:                     topologyRecovery.Id,
+		UID:                    topologyRecovery.UID,
+		Member:                 config.Config.FederationName,
+		ClusterName:            topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName,
+		ClusterAlias:           topologyRecovery.AnalysisEntry.ClusterDetails.ClusterAlias,
+		Analysis:               string(topologyRecovery.AnalysisEntry.Analysis),
+		FailedInstance:         topologyRecovery.AnalysisEntry.AnalyzedInstanceKey.StringCode(),
+		IsSuccessful:           topologyRecovery.IsSuccessful,
+		RecoveryStartTimestamp: topologyRecovery.RecoveryStartTimestamp,
+		RecoveryEndTimestamp:   topologyRecovery.RecoveryEndTimestamp,
+	}
+	if topologyRecovery.SuccessorKey != nil && topologyRecovery.SuccessorKey.IsValid() {
+		event.SuccessorInstance = topologyRecovery.SuccessorKey.StringCode()
+	}
+	return event
+}
+
+// FederationTopology reports the clusters this member monitors, to other federation members
+func (this *HttpAPI) FederationTopology(params martini.Params, r render.Render, req *http.Request) {
+	if !federation.IsEnabled() {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Federation is not enabled"})
+		return
+	}
+	clusters, err := readFederationLocalClusters()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, &federation.TopologyReport{Member: config.Config.FederationName, Clusters: clusters})
+}
+
+// FederationRecoveries reports this member's recoveries to other federation members, oldest first: those
+// following the recovery id given by the "since" query parameter, or else the most recent ones.
+func (this *HttpAPI) FederationRecoveries(params martini.Params, r render.Render, req *http.Request) {
+	if !federation.IsEnabled() {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Federation is not enabled"})
+		return
+	}
+	filter := inst.NewListingFilter()
+	filter.Limit = federationRecoveriesLimit
+	if since := req.URL.Query().Get("since"); since != "" {
+		sinceId, err := strconv.ParseInt(since, 10, 64)
+		if err != nil || sinceId < 0 {
+			Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Invalid since: %s", since)})
+			return
+		}
+		filter.AfterId = sinceId
+		filter.Ascending = true
+	}
+	recoveries, err := logic.ReadRecoveriesByFilter(filter, nil, false)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	events := []federation.RecoveryEvent{}
+	for i := range recoveries {
+		events = append(events, newFederationRecoveryEvent(&recoveries[i]))
+	}
+	if !filter.Ascending {
+		for i, j := 0, len(events)-1; i < j; i, j = i+1, j-1 {
+			events[i], events[j] = events[j], events[i]
+		}
+	}
+	r.JSON(http.StatusOK, events)
+}
+
+// FederationStatus shows the federation as seen by this member: local and remote clusters, their owners,
+// recent recoveries of other members, and clusters contested by multiple members
+func (this *HttpAPI) FederationStatus(params martini.Params, r render.Render, req *http.Request) {
+	if !federation.IsEnabled() {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Federation is not enabled"})
+		return
+	}
+	clusters, err := readFederationLocalClusters()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, federation.GetStatus(clusters))
+}
+
+// FederationClusterOwner shows which federation member owns a cluster, and whether this member may recover it
+func (this *HttpAPI) FederationClusterOwner(params martini.Params, r render.Render, req *http.Request) {
+	if !federation.IsEnabled() {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Federation is not enabled"})
+		return
+	}
+	clusterName, err := figureClusterName(params["clusterHint"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	owner := federation.ClusterOwner(clusterName, clusterInfo.ClusterAlias)
+	if err := federation.MayRecoverCluster(clusterName, clusterInfo.ClusterAlias); err != nil {
+		Respond(r, &APIResponse{Code: OK, Message: err.Error(), Details: map[string]interface{}{"Owner": owner, "MayRecover": false}})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%s may recover cluster %s", config.Config.FederationName, clusterName), Details: map[string]interface{}{"Owner": owner, "MayRecover": true}})
+}
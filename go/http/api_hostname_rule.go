@@ -0,0 +1,109 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+)
+
+// hostnameRuleMaxRequestBytes caps the size of a hostname rule request body
+const hostnameRuleMaxRequestBytes = 64 * 1024
+
+// HostnameRules lists the effective hostname rules: configured ones, followed by those managed via API
+func (this *HttpAPI) HostnameRules(params martini.Params, r render.Render, req *http.Request) {
+	rules, err := inst.ReadHostnameRules()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, rules)
+}
+
+// WriteHostnameRule creates or replaces a hostname rule. The request body is the rule as JSON:
+// Name, Pattern, Rewrite, ResolveMethod, NormalizeIPv6 and MapPorts.
+func (this *HttpAPI) WriteHostnameRule(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	rule := &config.HostnameRule{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, hostnameRuleMaxRequestBytes)).Decode(rule); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot parse request body: %+v", err)})
+		return
+	}
+	if err := rule.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-hostname-rule", rule)
+	} else {
+		err = inst.WriteHostnameRule(rule)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Hostname rule saved: %s", rule.Name), Details: rule})
+}
+
+// DeleteHostnameRule removes a hostname rule managed via API
+func (this *HttpAPI) DeleteHostnameRule(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	name := params["name"]
+	var err error
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-hostname-rule", name)
+	} else {
+		err = inst.DeleteHostnameRule(name)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Hostname rule deleted: %s", name)})
+}
+
+// HostnameResolveTest tells how a hostname, and port, would resolve per current hostname rules, without caching
+// the result
+func (this *HttpAPI) HostnameResolveTest(params martini.Params, r render.Render, req *http.Request) {
+	port := params["port"]
+	if port == "" {
+		port = fmt.Sprintf("%d", config.Config.DefaultInstancePort)
+	}
+	instanceKey, err := inst.NewRawInstanceKeyStrings(params["host"], port)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	r.JSON(http.StatusOK, inst.ExplainHostnameResolve(instanceKey))
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+)
+
+// ReportedAddresses lists the registered reported addresses of instances
+func (this *HttpAPI) ReportedAddresses(params martini.Params, r render.Render, req *http.Request) {
+	addresses, err := inst.ReadReportedAddresses()
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	if addresses == nil {
+		addresses = []inst.ReportedAddress{}
+	}
+	r.JSON(http.StatusOK, addresses)
+}
+
+// RegisterReportedAddress registers the address an instance is known by within its topology, as opposed to the
+// address orchestrator connects to it by
+func (this *HttpAPI) RegisterReportedAddress(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	reportedKey, err := this.getInstanceKey(params["reportedHost"], params["reportedPort"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	address := &inst.ReportedAddress{Key: instanceKey, ReportedKey: reportedKey}
+	if err := address.Validate(); err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("write-reported-address", address)
+	} else {
+		err = inst.WriteReportedAddress(address)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("%+v reported as %+v", instanceKey, reportedKey), Details: address})
+}
+
+// DeregisterReportedAddress removes the reported address of an instance
+func (this *HttpAPI) DeregisterReportedAddress(params martini.Params, r render.Render, req *http.Request, user auth.User) {
+	if !isAuthorizedForAction(req, user) {
+		Respond(r, &APIResponse{Code: ERROR, Message: "Unauthorized"})
+		return
+	}
+	instanceKey, err := this.getInstanceKey(params["host"], params["port"])
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("delete-reported-address", instanceKey)
+	} else {
+		err = inst.DeleteReportedAddress(&instanceKey)
+	}
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	Respond(r, &APIResponse{Code: OK, Message: fmt.Sprintf("Reported address of %+v deregistered", instanceKey), Details: instanceKey})
+}
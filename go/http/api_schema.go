@@ -0,0 +1,298 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+)
+
+// API versions:
+// - 1: objects are serialized as they are, e.g. "SlaveHosts"
+// - 2: "Slave" fields are renamed to "Replica", e.g. "ReplicaHosts". With APIVersionCompatibilityMode, both names are present.
+// A client requests a version via the "api-version" query parameter or the X-Orchestrator-API-Version header. Default is 1.
+const (
+	apiVersionLegacy  = 1
+	apiVersionLatest  = 2
+	apiVersionHeader  = "X-Orchestrator-API-Version"
+	apiVersionParam   = "api-version"
+	jsonSchemaDialect = "http://json-schema.org/draft-07/schema#"
+)
+
+// apiSchemaTypes are the core API objects for which a schema is published
+var apiSchemaTypes = map[string]reflect.Type{
+	"analysis": reflect.TypeOf(inst.ReplicationAnalysis{}),
+	"recovery": reflect.TypeOf(logic.TopologyRecovery{}),
+	"instance": reflect.TypeOf(inst.Instance{}),
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var instanceKeyMapType = reflect.TypeOf(inst.InstanceKeyMap{})
+
+// jsonSchemaGenerator builds the JSON schema of a type, as serialized by encoding/json.
+// Named struct types are listed under "definitions" and referenced.
+type jsonSchemaGenerator struct {
+	definitions map[string]interface{}
+}
+
+func (this *jsonSchemaGenerator) schemaOf(t reflect.Type) map[string]interface{} {
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == instanceKeyMapType:
+		// InstanceKeyMap marshals as a list of keys
+		return map[string]interface{}{"type": "array", "items": this.schemaOf(reflect.TypeOf(inst.InstanceKey{}))}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return this.schemaOf(t.Elem())
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "contentEncoding": "base64"}
+		}
+		return map[string]interface{}{"type": "array", "items": this.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": this.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return this.structSchemaOf(t)
+		}
+		if _, found := this.definitions[t.Name()]; !found {
+			this.definitions[t.Name()] = nil // placeholder, for recursive types
+			this.definitions[t.Name()] = this.structSchemaOf(t)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	}
+	return map[string]interface{}{}
+}
+
+func (this *jsonSchemaGenerator) structSchemaOf(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	this.addStructProperties(t, properties)
+	return map[string]interface{}{"type": "object", "properties": properties}
+}
+
+func (this *jsonSchemaGenerator) addStructProperties(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			// embedded struct: its fields are promoted
+			this.addStructProperties(field.Type, properties)
+			continue
+		}
+		if field.PkgPath != "" {
+			// unexported
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		properties[name] = this.schemaOf(field.Type)
+	}
+}
+
+// getAPIFieldRenames returns the field renames introduced in API version 2, e.g. SlaveHosts => ReplicaHosts
+var getAPIFieldRenames = func() func() map[string]string {
+	var renames map[string]string
+	var once sync.Once
+	return func() map[string]string {
+		once.Do(func() {
+			renames = map[string]string{}
+			generator := &jsonSchemaGenerator{definitions: map[string]interface{}{}}
+			for _, t := range apiSchemaTypes {
+				generator.schemaOf(t)
+			}
+			for _, definition := range generator.definitions {
+				for name := range definition.(map[string]interface{})["properties"].(map[string]interface{}) {
+					if strings.Contains(name, "Slave") {
+						renames[name] = strings.Replace(name, "Slave", "Replica", -1)
+					}
+				}
+			}
+		})
+		return renames
+	}
+}()
+
+// renameJSONKeys renames keys of decoded JSON objects, recursively. When keepOriginal is true, renamed
+// keys are present under both their original and their new names.
+func renameJSONKeys(value interface{}, renames map[string]string, keepOriginal bool) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, element := range value {
+			element = renameJSONKeys(element, renames, keepOriginal)
+			if renamed, found := renames[key]; found {
+				result[renamed] = element
+				if !keepOriginal {
+					continue
+				}
+			}
+			result[key] = element
+		}
+		return result
+	case []interface{}:
+		for i := range value {
+			value[i] = renameJSONKeys(value[i], renames, keepOriginal)
+		}
+		return value
+	}
+	return value
+}
+
+// renameSchemaProperties applies API version renames onto the properties of all schema definitions
+func renameSchemaProperties(definitions map[string]interface{}, renames map[string]string, keepOriginal bool) {
+	for _, definition := range definitions {
+		definition := definition.(map[string]interface{})
+		definition["properties"] = renameJSONKeys(definition["properties"], renames, keepOriginal)
+	}
+}
+
+// getAPISchema returns the JSON schema of given object type, in given API version
+func getAPISchema(typeName string, version int) (map[string]interface{}, error) {
+	t, found := apiSchemaTypes[typeName]
+	if !found {
+		typeNames := []string{}
+		for typeName := range apiSchemaTypes {
+			typeNames = append(typeNames, typeName)
+		}
+		sort.Strings(typeNames)
+		return nil, fmt.Errorf("Unknown schema type: %s. Known types: %s", typeName, strings.Join(typeNames, ", "))
+	}
+	if version < apiVersionLegacy || version > apiVersionLatest {
+		return nil, fmt.Errorf("Unknown API version: %d. Known versions: %d-%d", version, apiVersionLegacy, apiVersionLatest)
+	}
+	generator := &jsonSchemaGenerator{definitions: map[string]interface{}{}}
+	generator.schemaOf(t)
+	if version >= 2 {
+		renameSchemaProperties(generator.definitions, getAPIFieldRenames(), config.Config.APIVersionCompatibilityMode)
+	}
+	return map[string]interface{}{
+		"$schema":     jsonSchemaDialect,
+		"$id":         fmt.Sprintf("%s/api/schema/%s/%d", config.Config.URLPrefix, typeName, version),
+		"title":       t.Name(),
+		"version":     version,
+		"$ref":        "#/definitions/" + t.Name(),
+		"definitions": generator.definitions,
+	}, nil
+}
+
+// getRequestedAPIVersion returns the API version requested by the client, or the legacy version if none
+func getRequestedAPIVersion(req *http.Request) int {
+	requested := req.URL.Query().Get(apiVersionParam)
+	if requested == "" {
+		requested = req.Header.Get(apiVersionHeader)
+	}
+	if version, err := strconv.Atoi(strings.TrimPrefix(requested, "v")); err == nil {
+		return version
+	}
+	return apiVersionLegacy
+}
+
+// versionedResponseWriter buffers a response, so that it may be converted to the requested API version
+type versionedResponseWriter struct {
+	martini.ResponseWriter
+	body   bytes.Buffer
+	status int
+}
+
+func (this *versionedResponseWriter) WriteHeader(status int) {
+	this.status = status
+}
+
+func (this *versionedResponseWriter) Write(b []byte) (int, error) {
+	return this.body.Write(b)
+}
+
+// flush converts the buffered JSON response, if any, and writes it onto the underlying writer
+func (this *versionedResponseWriter) flush(renames map[string]string, keepOriginal bool) {
+	body := this.body.Bytes()
+	if strings.HasPrefix(this.Header().Get("Content-Type"), "application/json") {
+		var decoded interface{}
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		if err := decoder.Decode(&decoded); err == nil {
+			if converted, err := json.Marshal(renameJSONKeys(decoded, renames, keepOriginal)); err == nil {
+				body = converted
+			}
+		}
+	}
+	this.Header().Del("Content-Length")
+	if this.status != 0 {
+		this.ResponseWriter.WriteHeader(this.status)
+	}
+	this.ResponseWriter.Write(body)
+}
+
+// APIVersioning is a martini middleware which converts API responses to the version requested by the client
+func APIVersioning(res http.ResponseWriter, req *http.Request, c martini.Context) {
+	if !strings.HasPrefix(req.URL.Path, config.Config.URLPrefix+"/api/") ||
+		strings.HasPrefix(req.URL.Path, config.Config.URLPrefix+"/api/schema/") {
+		return
+	}
+	if getRequestedAPIVersion(req) < 2 {
+		return
+	}
+	writer := &versionedResponseWriter{ResponseWriter: res.(martini.ResponseWriter)}
+	c.MapTo(writer, (*http.ResponseWriter)(nil))
+	c.Next()
+	writer.flush(getAPIFieldRenames(), config.Config.APIVersionCompatibilityMode)
+}
+
+// Schema returns the JSON schema of a core API object (analysis, recovery, instance) in a given API version
+func (this *HttpAPI) Schema(params martini.Params, r render.Render, req *http.Request) {
+	version, err := strconv.Atoi(strings.TrimPrefix(params["version"], "v"))
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("Cannot parse version: %s", params["version"])})
+		return
+	}
+	schema, err := getAPISchema(params["type"], version)
+	if err != nil {
+		Respond(r, &APIResponse{Code: ERROR, Message: fmt.Sprintf("%+v", err)})
+		return
+	}
+	r.JSON(http.StatusOK, schema)
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestAPIFieldRenames(t *testing.T) {
+	renames := getAPIFieldRenames()
+	test.S(t).ExpectEquals(renames["SlaveHosts"], "ReplicaHosts")
+	test.S(t).ExpectEquals(renames["Slave_IO_Running"], "Replica_IO_Running")
+	test.S(t).ExpectEquals(renames["LogSlaveUpdatesEnabled"], "LogReplicaUpdatesEnabled")
+	_, found := renames["MasterKey"]
+	test.S(t).ExpectFalse(found)
+}
+
+func TestRenameJSONKeys(t *testing.T) {
+	renames := map[string]string{"SlaveHosts": "ReplicaHosts"}
+	{
+		value := map[string]interface{}{
+			"SlaveHosts": []interface{}{map[string]interface{}{"Hostname": "h1"}},
+			"Nested":     []interface{}{map[string]interface{}{"SlaveHosts": 1}},
+		}
+		renamed := renameJSONKeys(value, renames, false).(map[string]interface{})
+		test.S(t).ExpectNotNil(renamed["ReplicaHosts"])
+		test.S(t).ExpectTrue(renamed["SlaveHosts"] == nil)
+		nested := renamed["Nested"].([]interface{})[0].(map[string]interface{})
+		test.S(t).ExpectEquals(nested["ReplicaHosts"], 1)
+	}
+	{
+		value := map[string]interface{}{"SlaveHosts": 1}
+		renamed := renameJSONKeys(value, renames, true).(map[string]interface{})
+		test.S(t).ExpectEquals(renamed["ReplicaHosts"], 1)
+		test.S(t).ExpectEquals(renamed["SlaveHosts"], 1)
+	}
+}
+
+func TestGetAPISchema(t *testing.T) {
+	{
+		schema, err := getAPISchema("instance", 1)
+		test.S(t).ExpectNil(err)
+		definitions := schema["definitions"].(map[string]interface{})
+		properties := definitions["Instance"].(map[string]interface{})["properties"].(map[string]interface{})
+		test.S(t).ExpectNotNil(properties["SlaveHosts"])
+		test.S(t).ExpectTrue(properties["ReplicaHosts"] == nil)
+		test.S(t).ExpectNotNil(definitions["InstanceKey"])
+	}
+	{
+		schema, err := getAPISchema("instance", 2)
+		test.S(t).ExpectNil(err)
+		definitions := schema["definitions"].(map[string]interface{})
+		properties := definitions["Instance"].(map[string]interface{})["properties"].(map[string]interface{})
+		test.S(t).ExpectNotNil(properties["ReplicaHosts"])
+		test.S(t).ExpectTrue(properties["SlaveHosts"] == nil)
+	}
+	{
+		schema, err := getAPISchema("recovery", 2)
+		test.S(t).ExpectNil(err)
+		definitions := schema["definitions"].(map[string]interface{})
+		test.S(t).ExpectNotNil(definitions["ReplicationAnalysis"])
+	}
+	{
+		_, err := getAPISchema("no-such-type", 1)
+		test.S(t).ExpectNotNil(err)
+		_, err = getAPISchema("instance", 3)
+		test.S(t).ExpectNotNil(err)
+	}
+}
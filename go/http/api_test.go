@@ -1,6 +1,7 @@
 package http
 
 import (
+	"net/http"
 	"strings"
 	"testing"
 
@@ -53,3 +54,16 @@ func TestKnownPaths(t *testing.T) {
 		test.S(t).ExpectTrue(pathsMap[synonym])
 	}
 }
+
+func TestIsVantagePointRequest(t *testing.T) {
+	vantagePoints := config.Config.MasterDeathConfirmationVantagePoints
+	defer func() { config.Config.MasterDeathConfirmationVantagePoints = vantagePoints }()
+	config.Config.MasterDeathConfirmationVantagePoints = []string{"http://127.0.0.1:3000/api"}
+
+	req, _ := http.NewRequest("GET", "/api/check-instance-reachability/db1/3306", nil)
+	req.RemoteAddr = "127.0.0.1:54321"
+	test.S(t).ExpectTrue(isVantagePointRequest(req))
+
+	req.RemoteAddr = "10.0.0.1:54321"
+	test.S(t).ExpectFalse(isVantagePointRequest(req))
+}
@@ -0,0 +1,115 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/ssl"
+)
+
+// forwardedClientIdentityHeader carries the original client's certificate identity on requests
+// a raft follower forwards to the leader. It is only trusted when presented by a raft peer.
+const forwardedClientIdentityHeader = "X-Orchestrator-Forwarded-Client-Identity"
+
+// raftReverseProxyTLSConfig is used by raft followers to present their own certificate to the leader
+var raftReverseProxyTLSConfig *tls.Config
+
+// SetRaftReverseProxyTLSConfig sets the client TLS configuration used when forwarding requests to the raft leader
+func SetRaftReverseProxyTLSConfig(tlsConfig *tls.Config) {
+	raftReverseProxyTLSConfig = tlsConfig
+}
+
+// ClientCertificateIdentity is the identity of a client as read from its verified TLS certificate
+type ClientCertificateIdentity struct {
+	CommonName string
+	OUs        []string
+	SANs       []string
+}
+
+// UserId returns a human readable identification of the client
+func (this *ClientCertificateIdentity) UserId() string {
+	if this.CommonName != "" {
+		return this.CommonName
+	}
+	if len(this.SANs) > 0 {
+		return this.SANs[0]
+	}
+	return ""
+}
+
+// IsPower checks whether the client is allowed to make changes, based on SSLPowerOUs & SSLPowerSANs
+func (this *ClientCertificateIdentity) IsPower() bool {
+	for _, ou := range this.OUs {
+		if ssl.HasString(ou, config.Config.SSLPowerOUs) {
+			return true
+		}
+	}
+	for _, san := range this.SANs {
+		if ssl.HasString(san, config.Config.SSLPowerSANs) {
+			return true
+		}
+	}
+	return false
+}
+
+func (this *ClientCertificateIdentity) isRaftPeer() bool {
+	for _, san := range this.SANs {
+		if ssl.HasString(san, config.Config.SSLRaftPeerSANs) {
+			return true
+		}
+	}
+	return false
+}
+
+// getClientCertificateIdentity returns the identity of the client issuing the request. When the request
+// was forwarded by a raft peer, this is the identity of the original client, as vouched for by the peer.
+func getClientCertificateIdentity(req *http.Request) *ClientCertificateIdentity {
+	cert := ssl.GetClientCertificate(req)
+	if cert == nil {
+		return nil
+	}
+	identity := &ClientCertificateIdentity{
+		CommonName: cert.Subject.CommonName,
+		OUs:        cert.Subject.OrganizationalUnit,
+		SANs:       ssl.CertificateSANs(cert),
+	}
+	if forwarded := req.Header.Get(forwardedClientIdentityHeader); forwarded != "" && identity.isRaftPeer() {
+		forwardedIdentity := &ClientCertificateIdentity{}
+		if err := json.Unmarshal([]byte(forwarded), forwardedIdentity); err != nil {
+			return nil
+		}
+		return forwardedIdentity
+	}
+	return identity
+}
+
+// setForwardedClientIdentity prepares a request to be forwarded to the raft leader: it
+// overwrites any client supplied identity header with the verified identity of the client
+func setForwardedClientIdentity(req *http.Request) {
+	req.Header.Del(forwardedClientIdentityHeader)
+	identity := getClientCertificateIdentity(req)
+	if identity == nil {
+		return
+	}
+	if b, err := json.Marshal(identity); err == nil {
+		req.Header.Set(forwardedClientIdentityHeader, string(b))
+	}
+}
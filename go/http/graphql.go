@@ -0,0 +1,708 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/logic"
+)
+
+// The GraphQL endpoint supports a subset of GraphQL: a single query operation, made of fields with arguments,
+// aliases and nested selections, and variables. Fragments, directives, mutations and introspection are not supported.
+// Root fields are resolved by graphQLResolvers. Nested fields select keys of the JSON encoding of resolved objects.
+// Arguments a resolver does not consume filter lists by equality of their items' keys, at any level; "limit" caps lists.
+
+const graphQLLimitArgument = "limit"
+
+type graphQLToken struct {
+	kind  rune // '{', '}', '(', ')', ':', '$', '[', ']', '!', '=', or 'n' (name), 's' (string), 'd' (number)
+	value string
+}
+
+// graphQLVariable is a reference to a query variable, substituted upon execution
+type graphQLVariable string
+
+type graphQLField struct {
+	Alias     string
+	Name      string
+	Arguments map[string]interface{}
+	Selection []*graphQLField
+}
+
+// ResponseKey is the key of this field in the response: its alias, if any, or else its name
+func (this *graphQLField) ResponseKey() string {
+	if this.Alias != "" {
+		return this.Alias
+	}
+	return this.Name
+}
+
+func lexGraphQL(query string) (tokens []graphQLToken, err error) {
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		c := runes[i]
+		switch {
+		case unicode.IsSpace(c) || c == ',':
+			i++
+		case c == '#':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case strings.ContainsRune("{}():$[]!=", c):
+			tokens = append(tokens, graphQLToken{kind: c, value: string(c)})
+			i++
+		case c == '_' || unicode.IsLetter(c):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, graphQLToken{kind: 'n', value: string(runes[start:i])})
+		case c == '-' || unicode.IsDigit(c):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, graphQLToken{kind: 'd', value: string(runes[start:i])})
+		case c == '"':
+			var value bytes.Buffer
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+					switch runes[i] {
+					case 'n':
+						value.WriteRune('\n')
+					case 't':
+						value.WriteRune('\t')
+					default:
+						value.WriteRune(runes[i])
+					}
+					continue
+				}
+				value.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return tokens, fmt.Errorf("Unterminated string")
+			}
+			i++
+			tokens = append(tokens, graphQLToken{kind: 's', value: value.String()})
+		default:
+			return tokens, fmt.Errorf("Unexpected character %q", c)
+		}
+	}
+	return tokens, nil
+}
+
+type graphQLParser struct {
+	tokens []graphQLToken
+	pos    int
+}
+
+func (this *graphQLParser) peek(kind rune) bool {
+	return this.pos < len(this.tokens) && this.tokens[this.pos].kind == kind
+}
+
+func (this *graphQLParser) peekName(name string) bool {
+	return this.peek('n') && this.tokens[this.pos].value == name
+}
+
+func (this *graphQLParser) expect(kind rune) (graphQLToken, error) {
+	if this.pos >= len(this.tokens) {
+		return graphQLToken{}, fmt.Errorf("Unexpected end of query; expected %q", kind)
+	}
+	token := this.tokens[this.pos]
+	if token.kind != kind {
+		return token, fmt.Errorf("Unexpected %q; expected %q", token.value, kind)
+	}
+	this.pos++
+	return token, nil
+}
+
+// parseGraphQLQuery parses a query document, returning its root selection and the defaults of its variables
+func parseGraphQLQuery(query string) (selection []*graphQLField, defaults map[string]interface{}, err error) {
+	tokens, err := lexGraphQL(query)
+	if err != nil {
+		return nil, nil, err
+	}
+	parser := &graphQLParser{tokens: tokens}
+	defaults = make(map[string]interface{})
+	if parser.peekName("mutation") || parser.peekName("subscription") || parser.peekName("fragment") {
+		return nil, nil, fmt.Errorf("Unsupported operation: %s", parser.tokens[parser.pos].value)
+	}
+	if parser.peekName("query") {
+		parser.pos++
+		if parser.peek('n') {
+			parser.pos++
+		}
+		if parser.peek('(') {
+			if defaults, err = parser.parseVariableDefinitions(); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if selection, err = parser.parseSelectionSet(); err != nil {
+		return nil, nil, err
+	}
+	if parser.pos < len(parser.tokens) {
+		return nil, nil, fmt.Errorf("Unexpected %q after query; only a single operation is supported", parser.tokens[parser.pos].value)
+	}
+	return selection, defaults, nil
+}
+
+func (this *graphQLParser) parseVariableDefinitions() (defaults map[string]interface{}, err error) {
+	defaults = make(map[string]interface{})
+	if _, err := this.expect('('); err != nil {
+		return nil, err
+	}
+	for !this.peek(')') {
+		if _, err := this.expect('$'); err != nil {
+			return nil, err
+		}
+		name, err := this.expect('n')
+		if err != nil {
+			return nil, err
+		}
+		if _, err := this.expect(':'); err != nil {
+			return nil, err
+		}
+		// The variable's type is not enforced
+		for this.peek('[') || this.peek(']') || this.peek('!') || this.peek('n') {
+			this.pos++
+		}
+		if this.peek('=') {
+			this.pos++
+			if defaults[name.value], err = this.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	_, err = this.expect(')')
+	return defaults, err
+}
+
+func (this *graphQLParser) parseSelectionSet() (selection []*graphQLField, err error) {
+	if _, err := this.expect('{'); err != nil {
+		return nil, err
+	}
+	for !this.peek('}') {
+		field, err := this.parseField()
+		if err != nil {
+			return nil, err
+		}
+		selection = append(selection, field)
+	}
+	if len(selection) == 0 {
+		return nil, fmt.Errorf("Empty selection")
+	}
+	_, err = this.expect('}')
+	return selection, err
+}
+
+func (this *graphQLParser) parseField() (*graphQLField, error) {
+	name, err := this.expect('n')
+	if err != nil {
+		return nil, err
+	}
+	field := &graphQLField{Name: name.value, Arguments: make(map[string]interface{})}
+	if this.peek(':') {
+		this.pos++
+		if name, err = this.expect('n'); err != nil {
+			return nil, err
+		}
+		field.Alias, field.Name = field.Name, name.value
+	}
+	if this.peek('(') {
+		this.pos++
+		for !this.peek(')') {
+			argument, err := this.expect('n')
+			if err != nil {
+				return nil, err
+			}
+			if _, err := this.expect(':'); err != nil {
+				return nil, err
+			}
+			if field.Arguments[argument.value], err = this.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		this.pos++
+	}
+	if this.peek('{') {
+		if field.Selection, err = this.parseSelectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (this *graphQLParser) parseValue() (interface{}, error) {
+	if this.pos >= len(this.tokens) {
+		return nil, fmt.Errorf("Unexpected end of query; expected value")
+	}
+	token := this.tokens[this.pos]
+	this.pos++
+	switch token.kind {
+	case 's':
+		return token.value, nil
+	case 'd':
+		if i, err := strconv.ParseInt(token.value, 10, 64); err == nil {
+			return i, nil
+		}
+		return strconv.ParseFloat(token.value, 64)
+	case 'n':
+		switch token.value {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+		// enum values are taken as strings
+		return token.value, nil
+	case '$':
+		name, err := this.expect('n')
+		return graphQLVariable(name.value), err
+	case '[':
+		values := []interface{}{}
+		for !this.peek(']') {
+			value, err := this.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		this.pos++
+		return values, nil
+	case '{':
+		values := make(map[string]interface{})
+		for !this.peek('}') {
+			name, err := this.expect('n')
+			if err != nil {
+				return nil, err
+			}
+			if _, err := this.expect(':'); err != nil {
+				return nil, err
+			}
+			if values[name.value], err = this.parseValue(); err != nil {
+				return nil, err
+			}
+		}
+		this.pos++
+		return values, nil
+	}
+	return nil, fmt.Errorf("Unexpected %q; expected value", token.value)
+}
+
+// substituteGraphQLVariables replaces variable references in given argument value
+func substituteGraphQLVariables(value interface{}, variables map[string]interface{}) (interface{}, error) {
+	switch value := value.(type) {
+	case graphQLVariable:
+		substitute, ok := variables[string(value)]
+		if !ok {
+			return nil, fmt.Errorf("Undefined variable $%s", value)
+		}
+		return substitute, nil
+	case []interface{}:
+		values := []interface{}{}
+		for _, element := range value {
+			element, err := substituteGraphQLVariables(element, variables)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, element)
+		}
+		return values, nil
+	case map[string]interface{}:
+		values := make(map[string]interface{})
+		for key, element := range value {
+			element, err := substituteGraphQLVariables(element, variables)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = element
+		}
+		return values, nil
+	}
+	return value, nil
+}
+
+// substituteGraphQLSelectionVariables replaces variable references in the arguments of given fields and their selections
+func substituteGraphQLSelectionVariables(selection []*graphQLField, variables map[string]interface{}) error {
+	for _, field := range selection {
+		arguments, err := substituteGraphQLVariables(field.Arguments, variables)
+		if err != nil {
+			return err
+		}
+		field.Arguments = arguments.(map[string]interface{})
+		if err := substituteGraphQLSelectionVariables(field.Selection, variables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// graphQLArguments are the arguments of a root field, tracking those consumed by its resolver
+type graphQLArguments struct {
+	values   map[string]interface{}
+	consumed map[string]bool
+}
+
+func (this *graphQLArguments) get(name string) (interface{}, bool) {
+	value, ok := this.values[name]
+	if ok {
+		this.consumed[name] = true
+	}
+	return value, ok && value != nil
+}
+
+func (this *graphQLArguments) String(name string) string {
+	if value, ok := this.get(name); ok {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
+}
+
+func (this *graphQLArguments) Int(name string) int {
+	if value, ok := this.get(name); ok {
+		if i, err := strconv.Atoi(fmt.Sprintf("%v", value)); err == nil {
+			return i
+		}
+	}
+	return 0
+}
+
+func (this *graphQLArguments) Bool(name string) bool {
+	value, _ := this.get(name)
+	return value == true
+}
+
+// filters returns the arguments not consumed by the resolver
+func (this *graphQLArguments) filters() map[string]interface{} {
+	filters := make(map[string]interface{})
+	for name, value := range this.values {
+		if !this.consumed[name] {
+			filters[name] = value
+		}
+	}
+	return filters
+}
+
+type graphQLResolver func(args *graphQLArguments) (interface{}, error)
+
+func graphQLInstanceKey(args *graphQLArguments) (*inst.InstanceKey, error) {
+	return inst.NewResolveInstanceKeyStrings(args.String("host"), args.String("port"))
+}
+
+var graphQLResolvers = map[string]graphQLResolver{
+	"clusters": func(args *graphQLArguments) (interface{}, error) {
+		return inst.ReadClustersInfo("")
+	},
+	"cluster": func(args *graphQLArguments) (interface{}, error) {
+		clusterName, err := figureClusterName(args.String("name"))
+		if err != nil {
+			return nil, err
+		}
+		return inst.ReadClusterInfo(clusterName)
+	},
+	"instances": func(args *graphQLArguments) (interface{}, error) {
+		if search := args.String("search"); search != "" {
+			return inst.SearchInstances(search)
+		}
+		clusterNames := []string{}
+		if clusterHint := args.String("cluster"); clusterHint != "" {
+			clusterName, err := figureClusterName(clusterHint)
+			if err != nil {
+				return nil, err
+			}
+			clusterNames = append(clusterNames, clusterName)
+		} else {
+			var err error
+			if clusterNames, err = inst.ReadClusters(); err != nil {
+				return nil, err
+			}
+		}
+		instances := [](*inst.Instance){}
+		for _, clusterName := range clusterNames {
+			clusterInstances, err := inst.ReadClusterInstances(clusterName)
+			if err != nil {
+				return nil, err
+			}
+			instances = append(instances, clusterInstances...)
+		}
+		return instances, nil
+	},
+	"instance": func(args *graphQLArguments) (interface{}, error) {
+		instanceKey, err := graphQLInstanceKey(args)
+		if err != nil {
+			return nil, err
+		}
+		instance, found, err := inst.ReadInstance(instanceKey)
+		if err != nil || !found {
+			return nil, err
+		}
+		return instance, nil
+	},
+	"analyses": func(args *graphQLArguments) (interface{}, error) {
+		clusterName := ""
+		if clusterHint := args.String("cluster"); clusterHint != "" {
+			var err error
+			if clusterName, err = figureClusterName(clusterHint); err != nil {
+				return nil, err
+			}
+		}
+		return inst.GetReplicationAnalysis(clusterName, &inst.ReplicationAnalysisHints{IncludeDowntimed: args.Bool("includeDowntimed")})
+	},
+	"recoveries": func(args *graphQLArguments) (interface{}, error) {
+		clusterName := ""
+		if clusterHint := args.String("cluster"); clusterHint != "" {
+			var err error
+			if clusterName, err = figureClusterName(clusterHint); err != nil {
+				return nil, err
+			}
+		}
+		return logic.ReadRecentRecoveries(clusterName, args.Bool("unacknowledged"), args.Int("page"))
+	},
+	"recovery": func(args *graphQLArguments) (interface{}, error) {
+		recoveries, err := logic.ReadRecoveryByUID(args.String("uid"))
+		if err != nil || len(recoveries) == 0 {
+			return nil, err
+		}
+		return &recoveries[0], nil
+	},
+	"audits": func(args *graphQLArguments) (interface{}, error) {
+		var instanceKey *inst.InstanceKey
+		if args.String("host") != "" {
+			var err error
+			if instanceKey, err = graphQLInstanceKey(args); err != nil {
+				return nil, err
+			}
+		}
+		return inst.ReadRecentAudit(instanceKey, args.Int("page"))
+	},
+}
+
+// toGraphQLValue returns the JSON encoding of given value, as generic maps, lists and scalars
+func toGraphQLValue(value interface{}) (interface{}, error) {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var generic interface{}
+	err = decoder.Decode(&generic)
+	return generic, err
+}
+
+// graphQLValueMatches returns true when given value equals given filter: per key for objects, any of the values for lists
+func graphQLValueMatches(value interface{}, filter interface{}) bool {
+	switch filter := filter.(type) {
+	case nil:
+		return value == nil
+	case []interface{}:
+		for _, element := range filter {
+			if graphQLValueMatches(value, element) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for key, keyFilter := range filter {
+			if !graphQLValueMatches(object[key], keyFilter) {
+				return false
+			}
+		}
+		return true
+	}
+	return value != nil && fmt.Sprintf("%v", value) == fmt.Sprintf("%v", filter)
+}
+
+// applyGraphQLFilters keeps the items of a list which match all filters, up to the "limit" filter, if any
+func applyGraphQLFilters(value interface{}, filters map[string]interface{}) (interface{}, error) {
+	if len(filters) == 0 {
+		return value, nil
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return value, fmt.Errorf("Arguments apply to lists only")
+	}
+	limit := -1
+	if limitValue, ok := filters[graphQLLimitArgument]; ok {
+		if i, err := strconv.Atoi(fmt.Sprintf("%v", limitValue)); err == nil {
+			limit = i
+		}
+	}
+	filtered := []interface{}{}
+	for _, element := range list {
+		if limit >= 0 && len(filtered) >= limit {
+			break
+		}
+		object, _ := element.(map[string]interface{})
+		matches := true
+		for key, filter := range filters {
+			if key == graphQLLimitArgument {
+				continue
+			}
+			if matches = object != nil && graphQLValueMatches(object[key], filter); !matches {
+				break
+			}
+		}
+		if matches {
+			filtered = append(filtered, element)
+		}
+	}
+	return filtered, nil
+}
+
+// projectGraphQLValue keeps the selected keys of given value; objects with no selection are returned whole
+func projectGraphQLValue(value interface{}, selection []*graphQLField, path string) (interface{}, error) {
+	if len(selection) == 0 {
+		return value, nil
+	}
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case []interface{}:
+		projected := []interface{}{}
+		for _, element := range value {
+			element, err := projectGraphQLValue(element, selection, path)
+			if err != nil {
+				return nil, err
+			}
+			projected = append(projected, element)
+		}
+		return projected, nil
+	case map[string]interface{}:
+		projected := make(map[string]interface{})
+		for _, field := range selection {
+			fieldValue, ok := value[field.Name]
+			if !ok {
+				return nil, fmt.Errorf("Cannot query field %q of %s", field.Name, path)
+			}
+			fieldPath := fmt.Sprintf("%s.%s", path, field.Name)
+			fieldValue, err := applyGraphQLFilters(fieldValue, field.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %+v", fieldPath, err)
+			}
+			if projected[field.ResponseKey()], err = projectGraphQLValue(fieldValue, field.Selection, fieldPath); err != nil {
+				return nil, err
+			}
+		}
+		return projected, nil
+	}
+	return nil, fmt.Errorf("Field %s has no fields to select", path)
+}
+
+// resolveGraphQLField resolves a root field, then filters and projects it
+func resolveGraphQLField(resolvers map[string]graphQLResolver, field *graphQLField) (interface{}, error) {
+	resolver, ok := resolvers[field.Name]
+	if !ok {
+		return nil, fmt.Errorf("Cannot query field %q", field.Name)
+	}
+	args := &graphQLArguments{values: field.Arguments, consumed: make(map[string]bool)}
+	resolved, err := resolver(args)
+	if err != nil {
+		return nil, err
+	}
+	value, err := toGraphQLValue(resolved)
+	if err != nil {
+		return nil, err
+	}
+	if value, err = applyGraphQLFilters(value, args.filters()); err != nil {
+		return nil, fmt.Errorf("%s: %+v", field.Name, err)
+	}
+	return projectGraphQLValue(value, field.Selection, field.Name)
+}
+
+type graphQLRequest struct {
+	Query     string                 `json:"query"`
+	Variables map[string]interface{} `json:"variables"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLResponse struct {
+	Data   map[string]interface{} `json:"data"`
+	Errors []graphQLError         `json:"errors,omitempty"`
+}
+
+// executeGraphQL executes given query. A failing root field is null in the response data, and its error is listed.
+func executeGraphQL(resolvers map[string]graphQLResolver, request *graphQLRequest) *graphQLResponse {
+	response := &graphQLResponse{}
+	selection, defaults, err := parseGraphQLQuery(request.Query)
+	if err != nil {
+		response.Errors = append(response.Errors, graphQLError{Message: err.Error()})
+		return response
+	}
+	variables := defaults
+	for name, value := range request.Variables {
+		variables[name] = value
+	}
+	if err := substituteGraphQLSelectionVariables(selection, variables); err != nil {
+		response.Errors = append(response.Errors, graphQLError{Message: err.Error()})
+		return response
+	}
+	response.Data = make(map[string]interface{})
+	for _, field := range selection {
+		value, err := resolveGraphQLField(resolvers, field)
+		if err != nil {
+			response.Errors = append(response.Errors, graphQLError{Message: err.Error()})
+		}
+		response.Data[field.ResponseKey()] = value
+	}
+	return response
+}
+
+// GraphQL executes a GraphQL query over clusters, instances, analyses, recoveries and audits. The query is given by the
+// "query" parameter, along with optional JSON encoded "variables", or as a POSTed JSON {"query": ..., "variables": ...}
+func (this *HttpAPI) GraphQL(params martini.Params, r render.Render, req *http.Request) {
+	request := &graphQLRequest{}
+	if req.Method == "POST" {
+		if err := json.NewDecoder(req.Body).Decode(request); err != nil {
+			r.JSON(http.StatusBadRequest, &graphQLResponse{Errors: []graphQLError{{Message: err.Error()}}})
+			return
+		}
+	} else {
+		request.Query = req.URL.Query().Get("query")
+		if variables := req.URL.Query().Get("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+				r.JSON(http.StatusBadRequest, &graphQLResponse{Errors: []graphQLError{{Message: err.Error()}}})
+				return
+			}
+		}
+	}
+	r.JSON(http.StatusOK, executeGraphQL(graphQLResolvers, request))
+}
@@ -0,0 +1,128 @@
+package http
+
+import (
+	"encoding/json"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+type graphQLTestKey struct {
+	Hostname string
+	Port     int
+}
+
+type graphQLTestInstance struct {
+	Key        graphQLTestKey
+	DataCenter string
+	ReadOnly   bool
+	Replicas   []graphQLTestKey
+}
+
+var graphQLTestResolvers = map[string]graphQLResolver{
+	"instances": func(args *graphQLArguments) (interface{}, error) {
+		instances := []graphQLTestInstance{
+			{Key: graphQLTestKey{"db1", 3306}, DataCenter: "dc1", ReadOnly: false, Replicas: []graphQLTestKey{{"db2", 3306}, {"db3", 3306}}},
+			{Key: graphQLTestKey{"db2", 3306}, DataCenter: "dc1", ReadOnly: true, Replicas: []graphQLTestKey{}},
+			{Key: graphQLTestKey{"db3", 3306}, DataCenter: "dc2", ReadOnly: true, Replicas: []graphQLTestKey{}},
+		}
+		if dataCenter := args.String("dc"); dataCenter != "" {
+			filtered := []graphQLTestInstance{}
+			for _, instance := range instances {
+				if instance.DataCenter == dataCenter {
+					filtered = append(filtered, instance)
+				}
+			}
+			instances = filtered
+		}
+		return instances, nil
+	},
+}
+
+func executeGraphQLTestQuery(t *testing.T, query string, variables map[string]interface{}) (string, []graphQLError) {
+	response := executeGraphQL(graphQLTestResolvers, &graphQLRequest{Query: query, Variables: variables})
+	data, err := json.Marshal(response.Data)
+	test.S(t).ExpectNil(err)
+	return string(data), response.Errors
+}
+
+func TestParseGraphQLQuery(t *testing.T) {
+	selection, defaults, err := parseGraphQLQuery(`
+		query Replicas($dc: String = "dc1", $limit: Int) {
+			# comment
+			masters: instances(ReadOnly: false, dc: $dc) { Key { Hostname } }
+			instances(Key: {Port: 3306}, DataCenter: ["dc1", "dc2"], limit: $limit)
+		}`)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(defaults["dc"], "dc1")
+	test.S(t).ExpectEquals(len(selection), 2)
+	test.S(t).ExpectEquals(selection[0].Alias, "masters")
+	test.S(t).ExpectEquals(selection[0].Name, "instances")
+	test.S(t).ExpectEquals(selection[0].ResponseKey(), "masters")
+	test.S(t).ExpectEquals(selection[0].Arguments["ReadOnly"], false)
+	test.S(t).ExpectEquals(selection[0].Arguments["dc"], graphQLVariable("dc"))
+	test.S(t).ExpectEquals(len(selection[0].Selection), 1)
+	test.S(t).ExpectEquals(selection[0].Selection[0].Selection[0].Name, "Hostname")
+	test.S(t).ExpectEquals(selection[1].ResponseKey(), "instances")
+	test.S(t).ExpectEquals(selection[1].Arguments["Key"].(map[string]interface{})["Port"], int64(3306))
+	test.S(t).ExpectEquals(len(selection[1].Arguments["DataCenter"].([]interface{})), 2)
+	test.S(t).ExpectEquals(len(selection[1].Selection), 0)
+}
+
+func TestParseGraphQLQueryErrors(t *testing.T) {
+	for _, query := range []string{
+		``,
+		`{}`,
+		`{ instances `,
+		`{ instances(dc: ) }`,
+		`{ instances(dc: "dc1) }`,
+		`mutation { instances }`,
+		`{ instances } { instances }`,
+		`{ instances @include }`,
+	} {
+		_, _, err := parseGraphQLQuery(query)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestExecuteGraphQL(t *testing.T) {
+	{
+		data, errs := executeGraphQLTestQuery(t, `{ instances { Key { Hostname } ReadOnly } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 0)
+		test.S(t).ExpectEquals(data, `{"instances":[{"Key":{"Hostname":"db1"},"ReadOnly":false},{"Key":{"Hostname":"db2"},"ReadOnly":true},{"Key":{"Hostname":"db3"},"ReadOnly":true}]}`)
+	}
+	{
+		// resolver argument, filter and limit
+		data, errs := executeGraphQLTestQuery(t, `query($dc: String) { replicas: instances(dc: $dc, ReadOnly: true, limit: 1) { Key { Hostname } } }`, map[string]interface{}{"dc": "dc1"})
+		test.S(t).ExpectEquals(len(errs), 0)
+		test.S(t).ExpectEquals(data, `{"replicas":[{"Key":{"Hostname":"db2"}}]}`)
+	}
+	{
+		// object and list filters, nested list filter
+		data, errs := executeGraphQLTestQuery(t, `{ instances(Key: {Hostname: "db1"}, DataCenter: ["dc1", "dc9"]) { Replicas(Hostname: "db3") { Hostname } } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 0)
+		test.S(t).ExpectEquals(data, `{"instances":[{"Replicas":[{"Hostname":"db3"}]}]}`)
+	}
+	{
+		// selection of whole objects
+		data, errs := executeGraphQLTestQuery(t, `{ instances(DataCenter: "dc2") { Key } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 0)
+		test.S(t).ExpectEquals(data, `{"instances":[{"Key":{"Hostname":"db3","Port":3306}}]}`)
+	}
+}
+
+func TestExecuteGraphQLErrors(t *testing.T) {
+	{
+		data, errs := executeGraphQLTestQuery(t, `{ instances { NoSuchField } clusters { ClusterName } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 2)
+		test.S(t).ExpectEquals(data, `{"clusters":null,"instances":null}`)
+	}
+	{
+		_, errs := executeGraphQLTestQuery(t, `{ instances { DataCenter { Name } } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 1)
+	}
+	{
+		_, errs := executeGraphQLTestQuery(t, `{ instances(dc: $undefined) { DataCenter } }`, nil)
+		test.S(t).ExpectEquals(len(errs), 1)
+	}
+}
@@ -40,6 +40,8 @@ func getProxyAuthUser(req *http.Request) string {
 // isAuthorizedForAction checks req to see whether authenticated user has write-privileges.
 // This depends on configured authentication method.
 func isAuthorizedForAction(req *http.Request, user auth.User) bool {
+	markMutatingRequest(req)
+
 	if config.Config.ReadOnly {
 		return false
 	}
@@ -94,6 +96,22 @@ func isAuthorizedForAction(req *http.Request, user auth.User) bool {
 		{
 			return false
 		}
+	case "oidc":
+		{
+			identity, err := getOIDCIdentity(req)
+			if err != nil {
+				return false
+			}
+			return isOIDCPowerIdentity(identity)
+		}
+	case "mtls":
+		{
+			identity := getClientCertificateIdentity(req)
+			if identity == nil {
+				return false
+			}
+			return identity.IsPower()
+		}
 	default:
 		{
 			// Default: no authentication method
@@ -136,6 +154,17 @@ func getUserId(req *http.Request, user auth.User) string {
 		{
 			return ""
 		}
+	case "oidc":
+		{
+			return string(user)
+		}
+	case "mtls":
+		{
+			if identity := getClientCertificateIdentity(req); identity != nil {
+				return identity.UserId()
+			}
+			return ""
+		}
 	default:
 		{
 			return ""
@@ -165,6 +194,19 @@ func figureClusterName(hint string) (clusterName string, err error) {
 	return inst.FigureClusterName(hint, instanceKey, nil)
 }
 
+// figureClusterAlias is a convenience function to get a cluster alias from hints
+func figureClusterAlias(hint string) (clusterAlias string, err error) {
+	clusterName, err := figureClusterName(hint)
+	if err != nil {
+		return "", err
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return "", err
+	}
+	return clusterInfo.ClusterAlias, nil
+}
+
 // getClusterNameIfExists returns a cluster name by params hint, or an empty cluster name
 // if no hint is given
 func getClusterNameIfExists(params map[string]string) (clusterName string, err error) {
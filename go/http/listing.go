@@ -0,0 +1,242 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// listingNextCursorHeader holds the cursor of the next page of a listing, when there may be one
+const listingNextCursorHeader = "X-Orchestrator-Next-Cursor"
+
+// listingQueryParams are the query parameters common to paginated listings
+var listingQueryParams = []string{"from", "to", "cluster", "cursor", "limit", "sort"}
+
+// hasListingQueryParams returns true when any listing query parameter is given. Without any, listings keep
+// their legacy page-number behavior.
+func hasListingQueryParams(req *http.Request) bool {
+	query := req.URL.Query()
+	for _, param := range listingQueryParams {
+		if query.Get(param) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// getListingSort parses the "sort" query parameter: a field name, prefixed with "-" for descending order
+func getListingSort(req *http.Request, defaultSort string) (field string, descending bool) {
+	sortParam := req.URL.Query().Get("sort")
+	if sortParam == "" {
+		sortParam = defaultSort
+	}
+	return strings.TrimPrefix(sortParam, "-"), strings.HasPrefix(sortParam, "-")
+}
+
+// getListingLimit parses the "limit" query parameter; zero stands for the default page size
+func getListingLimit(req *http.Request) (int, error) {
+	limitParam := req.URL.Query().Get("limit")
+	if limitParam == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(limitParam)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("Invalid limit: %s", limitParam)
+	}
+	return limit, nil
+}
+
+// getListingFilter reads a listing filter of a chronological listing from query parameters: "from" and "to" times
+// formatted 'YYYY-MM-DD hh:mm:ss', "cluster" hint, "cursor", "limit", and "sort" which is "-timestamp" (default) or "timestamp".
+func getListingFilter(req *http.Request) (*inst.ListingFilter, error) {
+	query := req.URL.Query()
+	filter := inst.NewListingFilter()
+	for _, timestamp := range []string{query.Get("from"), query.Get("to")} {
+		if timestamp == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02 15:04:05", timestamp); err != nil {
+			return nil, fmt.Errorf("Invalid time %q; expected 'YYYY-MM-DD hh:mm:ss'", timestamp)
+		}
+	}
+	filter.FromTimestamp = query.Get("from")
+	filter.ToTimestamp = query.Get("to")
+	if clusterHint := query.Get("cluster"); clusterHint != "" {
+		clusterName, err := figureClusterName(clusterHint)
+		if err != nil {
+			return nil, err
+		}
+		filter.ClusterName = clusterName
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		afterId, err := strconv.ParseInt(cursor, 10, 64)
+		if err != nil || afterId <= 0 {
+			return nil, fmt.Errorf("Invalid cursor: %s", cursor)
+		}
+		filter.AfterId = afterId
+	}
+	limit, err := getListingLimit(req)
+	if err != nil {
+		return nil, err
+	}
+	filter.Limit = limit
+	field, descending := getListingSort(req, "-timestamp")
+	if field != "timestamp" {
+		return nil, fmt.Errorf("Unsupported sort: %s; expecting timestamp or -timestamp", req.URL.Query().Get("sort"))
+	}
+	filter.Ascending = !descending
+	return filter, nil
+}
+
+// setListingNextCursor sets the cursor for the next page of a listing if the current page is full.
+// lastId is the id of the last entry on the current page.
+func setListingNextCursor(w http.ResponseWriter, filter *inst.ListingFilter, countEntries int, lastId int64) {
+	if countEntries > 0 && countEntries >= filter.GetLimit() {
+		w.Header().Set(listingNextCursorHeader, fmt.Sprintf("%d", lastId))
+	}
+}
+
+// getAnalysisCodes parses the "analysis" query parameter: comma separated analysis codes
+func getAnalysisCodes(req *http.Request) []string {
+	analysisCodes := []string{}
+	for _, code := range strings.Split(req.URL.Query().Get("analysis"), ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			analysisCodes = append(analysisCodes, code)
+		}
+	}
+	return analysisCodes
+}
+
+// analysisSortFields sort replication analysis entries; entries are otherwise ordered by instance
+var analysisSortFields = map[string]func(a, b *inst.ReplicationAnalysis) bool{
+	"instance": func(a, b *inst.ReplicationAnalysis) bool { return false },
+	"cluster": func(a, b *inst.ReplicationAnalysis) bool {
+		return a.ClusterDetails.ClusterName < b.ClusterDetails.ClusterName
+	},
+	"analysis": func(a, b *inst.ReplicationAnalysis) bool { return a.Analysis < b.Analysis },
+}
+
+// filterReplicationAnalysis filters, sorts and paginates replication analysis by query parameters: "analysis"
+// (comma separated codes), "cluster" hint, "sort" (instance, cluster or analysis, prefixed with "-" for descending),
+// "limit" and "cursor". Analysis is computed live, and so its cursor is merely the offset of the next page.
+func filterReplicationAnalysis(analysis []inst.ReplicationAnalysis, w http.ResponseWriter, req *http.Request) ([]inst.ReplicationAnalysis, error) {
+	query := req.URL.Query()
+	filtered := []inst.ReplicationAnalysis{}
+	analysisCodes := map[string]bool{}
+	for _, code := range getAnalysisCodes(req) {
+		analysisCodes[code] = true
+	}
+	clusterName := ""
+	if clusterHint := query.Get("cluster"); clusterHint != "" {
+		var err error
+		if clusterName, err = figureClusterName(clusterHint); err != nil {
+			return nil, err
+		}
+	}
+	for _, analysisEntry := range analysis {
+		if len(analysisCodes) > 0 && !analysisCodes[string(analysisEntry.Analysis)] {
+			continue
+		}
+		if clusterName != "" && analysisEntry.ClusterDetails.ClusterName != clusterName {
+			continue
+		}
+		filtered = append(filtered, analysisEntry)
+	}
+
+	field, descending := getListingSort(req, "instance")
+	less, ok := analysisSortFields[field]
+	if !ok {
+		return nil, fmt.Errorf("Unsupported sort: %s; expecting instance, cluster or analysis", query.Get("sort"))
+	}
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := &filtered[i], &filtered[j]
+		if descending {
+			a, b = b, a
+		}
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return a.AnalyzedInstanceKey.SmallerThan(&b.AnalyzedInstanceKey)
+	})
+
+	offset := 0
+	if cursor := query.Get("cursor"); cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(cursor); err != nil || offset < 0 {
+			return nil, fmt.Errorf("Invalid cursor: %s", cursor)
+		}
+	}
+	if offset > len(filtered) {
+		offset = len(filtered)
+	}
+	filtered = filtered[offset:]
+	limit, err := getListingLimit(req)
+	if err != nil {
+		return nil, err
+	}
+	if limit > 0 && limit < len(filtered) {
+		filtered = filtered[:limit]
+		w.Header().Set(listingNextCursorHeader, fmt.Sprintf("%d", offset+limit))
+	}
+	return filtered, nil
+}
+
+// getProblemFilters parses the problem query parameters: "includeSuppressed" (true to include problems matching
+// a suppression) and "severity" (the minimal severity to list: info, warning or critical)
+func getProblemFilters(req *http.Request) (includeSuppressed bool, minSeverity inst.ProblemSeverity, err error) {
+	query := req.URL.Query()
+	includeSuppressed = query.Get("includeSuppressed") == "true"
+	if severity := query.Get("severity"); severity != "" {
+		if minSeverity, err = inst.ParseProblemSeverity(severity); err != nil {
+			return includeSuppressed, minSeverity, err
+		}
+	}
+	return includeSuppressed, minSeverity, nil
+}
+
+// classifyReplicationAnalysis sets the severity of analysis entries and marks those matching a suppression.
+// Suppressed entries are dropped unless includeSuppressed; so are entries less severe than minSeverity.
+func classifyReplicationAnalysis(analysis []inst.ReplicationAnalysis, suppressions []inst.ProblemSuppression, includeSuppressed bool, minSeverity inst.ProblemSeverity) []inst.ReplicationAnalysis {
+	classified := []inst.ReplicationAnalysis{}
+	for _, analysisEntry := range analysis {
+		analysisEntry.Severity = inst.GetReplicationAnalysisSeverity(&analysisEntry)
+		if !analysisEntry.Severity.AtLeast(minSeverity) {
+			continue
+		}
+		for i := range suppressions {
+			if suppressions[i].MatchesAnalysis(&analysisEntry) {
+				analysisEntry.SuppressedBy = suppressions[i].Id
+				break
+			}
+		}
+		if analysisEntry.SuppressedBy != "" && !includeSuppressed {
+			continue
+		}
+		classified = append(classified, analysisEntry)
+	}
+	return classified
+}
@@ -0,0 +1,125 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+func newListingTestRequest(query string) *http.Request {
+	req, _ := http.NewRequest("GET", "/api/audit?"+query, nil)
+	return req
+}
+
+func TestGetListingFilter(t *testing.T) {
+	{
+		test.S(t).ExpectFalse(hasListingQueryParams(newListingTestRequest("")))
+		test.S(t).ExpectTrue(hasListingQueryParams(newListingTestRequest("limit=5")))
+	}
+	{
+		filter, err := getListingFilter(newListingTestRequest("from=2017-01-01+00:00:00&cursor=100&limit=5&sort=timestamp"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(filter.FromTimestamp, "2017-01-01 00:00:00")
+		test.S(t).ExpectEquals(filter.ToTimestamp, "")
+		test.S(t).ExpectEquals(filter.AfterId, int64(100))
+		test.S(t).ExpectEquals(filter.Limit, 5)
+		test.S(t).ExpectTrue(filter.Ascending)
+	}
+	{
+		filter, err := getListingFilter(newListingTestRequest("limit=5"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectFalse(filter.Ascending)
+	}
+	for _, query := range []string{"from=yesterday", "cursor=abc", "cursor=-1", "limit=0", "sort=type"} {
+		_, err := getListingFilter(newListingTestRequest(query))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestSetListingNextCursor(t *testing.T) {
+	filter := &inst.ListingFilter{Limit: 2}
+	{
+		w := httptest.NewRecorder()
+		setListingNextCursor(w, filter, 1, 17)
+		test.S(t).ExpectEquals(w.Header().Get(listingNextCursorHeader), "")
+	}
+	{
+		w := httptest.NewRecorder()
+		setListingNextCursor(w, filter, 2, 17)
+		test.S(t).ExpectEquals(w.Header().Get(listingNextCursorHeader), "17")
+	}
+}
+
+func TestFilterReplicationAnalysis(t *testing.T) {
+	analysis := []inst.ReplicationAnalysis{
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db3", Port: 3306}, Analysis: inst.DeadMaster, ClusterDetails: inst.ClusterInfo{ClusterName: "c2"}},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db1", Port: 3306}, Analysis: inst.NoProblem, ClusterDetails: inst.ClusterInfo{ClusterName: "c1"}},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db2", Port: 3306}, Analysis: inst.DeadMaster, ClusterDetails: inst.ClusterInfo{ClusterName: "c1"}},
+	}
+	{
+		w := httptest.NewRecorder()
+		filtered, err := filterReplicationAnalysis(analysis, w, newListingTestRequest(""))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(filtered), 3)
+		test.S(t).ExpectEquals(filtered[0].AnalyzedInstanceKey.Hostname, "db1")
+		test.S(t).ExpectEquals(filtered[2].AnalyzedInstanceKey.Hostname, "db3")
+		test.S(t).ExpectEquals(w.Header().Get(listingNextCursorHeader), "")
+	}
+	{
+		w := httptest.NewRecorder()
+		filtered, err := filterReplicationAnalysis(analysis, w, newListingTestRequest("analysis=DeadMaster&sort=-cluster"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(filtered), 2)
+		test.S(t).ExpectEquals(filtered[0].AnalyzedInstanceKey.Hostname, "db3")
+		test.S(t).ExpectEquals(filtered[1].AnalyzedInstanceKey.Hostname, "db2")
+	}
+	{
+		w := httptest.NewRecorder()
+		filtered, err := filterReplicationAnalysis(analysis, w, newListingTestRequest("limit=2"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(filtered), 2)
+		test.S(t).ExpectEquals(w.Header().Get(listingNextCursorHeader), "2")
+
+		w = httptest.NewRecorder()
+		filtered, err = filterReplicationAnalysis(analysis, w, newListingTestRequest("limit=2&cursor=2"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(filtered), 1)
+		test.S(t).ExpectEquals(filtered[0].AnalyzedInstanceKey.Hostname, "db3")
+		test.S(t).ExpectEquals(w.Header().Get(listingNextCursorHeader), "")
+	}
+	{
+		_, err := filterReplicationAnalysis(analysis, httptest.NewRecorder(), newListingTestRequest("sort=uptime"))
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestClassifyReplicationAnalysis(t *testing.T) {
+	analysis := []inst.ReplicationAnalysis{
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-1", Port: 3306}, Analysis: inst.DeadMaster},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-2", Port: 3306}, Analysis: inst.DeadIntermediateMaster},
+		{AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-3", Port: 3306}, Analysis: inst.NoProblem},
+	}
+	suppressions := []inst.ProblemSuppression{{Id: "s1", InstancePattern: "^db-2:"}}
+	{
+		classified := classifyReplicationAnalysis(analysis, suppressions, false, inst.NoProblemSeverity)
+		test.S(t).ExpectEquals(len(classified), 2)
+		test.S(t).ExpectEquals(classified[0].Severity, inst.ProblemSeverity(inst.CriticalProblemSeverity))
+		test.S(t).ExpectEquals(classified[1].Severity, inst.NoProblemSeverity)
+	}
+	{
+		classified := classifyReplicationAnalysis(analysis, suppressions, true, inst.WarningProblemSeverity)
+		test.S(t).ExpectEquals(len(classified), 2)
+		test.S(t).ExpectEquals(classified[1].SuppressedBy, "s1")
+	}
+	{
+		_, _, err := getProblemFilters(newListingTestRequest("severity=urgent"))
+		test.S(t).ExpectNotNil(err)
+		includeSuppressed, minSeverity, err := getProblemFilters(newListingTestRequest("severity=critical&includeSuppressed=true"))
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(includeSuppressed)
+		test.S(t).ExpectEquals(minSeverity, inst.ProblemSeverity(inst.CriticalProblemSeverity))
+	}
+}
@@ -0,0 +1,449 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/openark/golib/log"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+const (
+	oidcTokenCookieName = "orchestrator-oidc-token"
+	oidcStateCookieName = "orchestrator-oidc-state"
+	oidcClockSkew       = 30 * time.Second
+	oidcKeysMinRefresh  = time.Minute
+)
+
+// OIDCIdentity is the authenticated identity as read from a validated OpenID Connect ID token
+type OIDCIdentity struct {
+	User   string
+	Groups []string
+	Expiry time.Time
+}
+
+// oidcProviderConfiguration is the subset of the provider's discovery document used by orchestrator
+type oidcProviderConfiguration struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type oidcProvider struct {
+	mutex         sync.Mutex
+	configuration *oidcProviderConfiguration
+	keys          map[string]*rsa.PublicKey
+	keysFetchedAt time.Time
+	client        *http.Client
+}
+
+var oidc = &oidcProvider{client: &http.Client{Timeout: 10 * time.Second}}
+
+func (this *oidcProvider) getJSON(url string, v interface{}) error {
+	resp, err := this.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Unexpected status %d reading %s", resp.StatusCode, url)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// getConfiguration reads (once) the provider's configuration via OpenID Connect discovery
+func (this *oidcProvider) getConfiguration() (*oidcProviderConfiguration, error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if this.configuration != nil {
+		return this.configuration, nil
+	}
+	discoveryURL := strings.TrimRight(config.Config.OIDCIssuerURL, "/") + "/.well-known/openid-configuration"
+	configuration := &oidcProviderConfiguration{}
+	if err := this.getJSON(discoveryURL, configuration); err != nil {
+		return nil, log.Errorf("oidc: cannot read provider configuration: %+v", err)
+	}
+	if configuration.AuthorizationEndpoint == "" || configuration.TokenEndpoint == "" || configuration.JWKSURI == "" {
+		return nil, log.Errorf("oidc: incomplete provider configuration at %s", discoveryURL)
+	}
+	this.configuration = configuration
+	return this.configuration, nil
+}
+
+// getKey returns the provider's signing key by key id. Keys are re-read when an unknown key id
+// is requested, so as to support key rotation.
+func (this *oidcProvider) getKey(kid string) (*rsa.PublicKey, error) {
+	configuration, err := this.getConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	if key, found := this.keys[kid]; found {
+		return key, nil
+	}
+	if time.Since(this.keysFetchedAt) < oidcKeysMinRefresh {
+		return nil, fmt.Errorf("oidc: unknown key id %s", kid)
+	}
+	jwks := struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}{}
+	this.keysFetchedAt = time.Now()
+	if err := this.getJSON(configuration.JWKSURI, &jwks); err != nil {
+		return nil, log.Errorf("oidc: cannot read provider keys: %+v", err)
+	}
+	keys := map[string]*rsa.PublicKey{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+	}
+	this.keys = keys
+	if key, found := this.keys[kid]; found {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: unknown key id %s", kid)
+}
+
+// validateIDToken validates a raw ID token against the configured provider and returns the identity it carries.
+// A non-empty nonce is required to match the token's nonce claim; this is the case when the token has just been
+// issued for a login flow.
+func (this *oidcProvider) validateIDToken(rawToken string, nonce string) (*OIDCIdentity, error) {
+	configuration, err := this.getConfiguration()
+	if err != nil {
+		return nil, err
+	}
+	return verifyIDToken(rawToken, configuration.Issuer, config.Config.OAuthClientId, nonce, this.getKey, time.Now())
+}
+
+func decodeJWTSegment(segment string, v interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(decoded, v)
+}
+
+// claimStrings reads a claim which may be either a single string or a list of strings
+func claimStrings(claim interface{}) (result []string) {
+	switch claim := claim.(type) {
+	case string:
+		result = append(result, claim)
+	case []interface{}:
+		for _, value := range claim {
+			if s, ok := value.(string); ok {
+				result = append(result, s)
+			}
+		}
+	}
+	return result
+}
+
+// verifyIDToken verifies an RS256 signed ID token: its signature, issuer, audience and expiry, and, if given, its nonce.
+func verifyIDToken(rawToken string, issuer string, clientId string, nonce string, getKey func(kid string) (*rsa.PublicKey, error), now time.Time) (*OIDCIdentity, error) {
+	parts := strings.Split(rawToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("oidc: malformed token")
+	}
+	header := struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}{}
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("oidc: malformed token header: %+v", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("oidc: unsupported signing algorithm: %s", header.Alg)
+	}
+	key, err := getKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[2], "="))
+	if err != nil {
+		return nil, fmt.Errorf("oidc: malformed token signature: %+v", err)
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, hashed[:], signature); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token signature")
+	}
+
+	claims := map[string]interface{}{}
+	if err := decodeJWTSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("oidc: malformed token claims: %+v", err)
+	}
+	if claims["iss"] != issuer {
+		return nil, fmt.Errorf("oidc: unexpected issuer: %+v", claims["iss"])
+	}
+	audienceFound := false
+	for _, audience := range claimStrings(claims["aud"]) {
+		if audience == clientId {
+			audienceFound = true
+		}
+	}
+	if !audienceFound {
+		return nil, fmt.Errorf("oidc: token not issued for this client")
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, fmt.Errorf("oidc: token has no expiry")
+	}
+	expiry := time.Unix(int64(exp), 0)
+	if now.After(expiry.Add(oidcClockSkew)) {
+		return nil, fmt.Errorf("oidc: token expired at %+v", expiry)
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(oidcClockSkew).Before(time.Unix(int64(nbf), 0)) {
+		return nil, fmt.Errorf("oidc: token not yet valid")
+	}
+	if nonce != "" {
+		if tokenNonce, _ := claims["nonce"].(string); !auth.SecureCompare(tokenNonce, nonce) {
+			return nil, fmt.Errorf("oidc: unexpected nonce")
+		}
+	}
+
+	identity := &OIDCIdentity{Expiry: expiry}
+	if user, ok := claims[config.Config.OIDCUserClaim].(string); ok {
+		identity.User = user
+	}
+	if identity.User == "" {
+		if subject, ok := claims["sub"].(string); ok {
+			identity.User = subject
+		}
+	}
+	identity.Groups = claimStrings(claims[config.Config.OIDCGroupsClaim])
+	return identity, nil
+}
+
+// getOIDCRawToken reads the ID token from either an "Authorization: Bearer" header (API clients)
+// or the session cookie (web UI)
+func getOIDCRawToken(req *http.Request) string {
+	if authorization := req.Header.Get("Authorization"); strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	if cookie, err := req.Cookie(oidcTokenCookieName); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// getOIDCIdentity returns the validated identity of the request's user
+func getOIDCIdentity(req *http.Request) (*OIDCIdentity, error) {
+	rawToken := getOIDCRawToken(req)
+	if rawToken == "" {
+		return nil, fmt.Errorf("oidc: no token provided")
+	}
+	return oidc.validateIDToken(rawToken, "")
+}
+
+// isOIDCPowerIdentity checks whether the identity is allowed to make changes, based on PowerAuthUsers & PowerAuthGroups
+func isOIDCPowerIdentity(identity *OIDCIdentity) bool {
+	for _, configPowerAuthUser := range config.Config.PowerAuthUsers {
+		if configPowerAuthUser == "*" || configPowerAuthUser == identity.User {
+			return true
+		}
+	}
+	for _, group := range identity.Groups {
+		for _, configPowerAuthGroup := range config.Config.PowerAuthGroups {
+			if configPowerAuthGroup == group {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isOIDCExemptPath returns true for paths that must be reachable without authentication:
+// the login flow itself, load balancer health checks, and chatops, which authenticates requests by itself
+func isOIDCExemptPath(path string) bool {
+	prefix := config.Config.URLPrefix
+	if isChatOpsPath(path) {
+		return true
+	}
+	if strings.HasPrefix(path, prefix+"/oidc/") {
+		return true
+	}
+	for _, exempt := range []string{"/api/health", "/api/lb-check", "/api/_ping"} {
+		if path == prefix+exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// OIDCAuth is a martini middleware which requires a valid ID token on all requests. Web requests
+// lacking one are redirected to the login flow; API requests are rejected.
+func OIDCAuth(res http.ResponseWriter, req *http.Request, c martini.Context) {
+	if isOIDCExemptPath(req.URL.Path) {
+		c.Map(auth.User(""))
+		return
+	}
+	identity, err := getOIDCIdentity(req)
+	if err != nil {
+		if strings.HasPrefix(req.URL.Path, config.Config.URLPrefix+"/api/") {
+			http.Error(res, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		http.Redirect(res, req, config.Config.URLPrefix+"/oidc/login", http.StatusFound)
+		return
+	}
+	c.Map(auth.User(identity.User))
+}
+
+func randomOIDCState() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// OIDCLogin initiates the authorization code flow by redirecting to the provider
+func (this *HttpWeb) OIDCLogin(res http.ResponseWriter, req *http.Request) {
+	configuration, err := oidc.getConfiguration()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	state, err := randomOIDCState()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	nonce, err := randomOIDCState()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The nonce is kept alongside the state, and checked against the ID token issued on callback
+	http.SetCookie(res, &http.Cookie{Name: oidcStateCookieName, Value: state + ":" + nonce, Path: this.URLPrefix + "/", HttpOnly: true, Secure: req.TLS != nil, MaxAge: 600})
+
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", config.Config.OAuthClientId)
+	query.Set("redirect_uri", config.Config.OIDCRedirectURL)
+	query.Set("scope", strings.Join(append([]string{"openid"}, config.Config.OAuthScopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	http.Redirect(res, req, configuration.AuthorizationEndpoint+"?"+query.Encode(), http.StatusFound)
+}
+
+// OIDCCallback completes the authorization code flow: exchanges the code for an ID token, validates
+// it and stores it in a session cookie
+func (this *HttpWeb) OIDCCallback(res http.ResponseWriter, req *http.Request) {
+	if providerError := req.URL.Query().Get("error"); providerError != "" {
+		http.Error(res, fmt.Sprintf("oidc: provider returned error: %s", providerError), http.StatusUnauthorized)
+		return
+	}
+	stateCookie, err := req.Cookie(oidcStateCookieName)
+	if err != nil {
+		http.Error(res, "oidc: invalid state", http.StatusBadRequest)
+		return
+	}
+	stateTokens := strings.SplitN(stateCookie.Value, ":", 2)
+	if len(stateTokens) != 2 || stateTokens[0] == "" || stateTokens[1] == "" || stateTokens[0] != req.URL.Query().Get("state") {
+		http.Error(res, "oidc: invalid state", http.StatusBadRequest)
+		return
+	}
+	nonce := stateTokens[1]
+	configuration, err := oidc.getConfiguration()
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", req.URL.Query().Get("code"))
+	form.Set("redirect_uri", config.Config.OIDCRedirectURL)
+	tokenRequest, err := http.NewRequest("POST", configuration.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tokenRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tokenRequest.SetBasicAuth(url.QueryEscape(config.Config.OAuthClientId), url.QueryEscape(config.Config.OAuthClientSecret))
+	tokenResponse, err := oidc.client.Do(tokenRequest)
+	if err != nil {
+		http.Error(res, fmt.Sprintf("oidc: token exchange failed: %+v", err), http.StatusBadGateway)
+		return
+	}
+	defer tokenResponse.Body.Close()
+	tokens := struct {
+		IDToken string `json:"id_token"`
+	}{}
+	if err := json.NewDecoder(tokenResponse.Body).Decode(&tokens); err != nil || tokenResponse.StatusCode != http.StatusOK || tokens.IDToken == "" {
+		http.Error(res, fmt.Sprintf("oidc: token exchange failed with status %d", tokenResponse.StatusCode), http.StatusUnauthorized)
+		return
+	}
+	identity, err := oidc.validateIDToken(tokens.IDToken, nonce)
+	if err != nil {
+		log.Errore(err)
+		http.Error(res, "oidc: invalid ID token", http.StatusUnauthorized)
+		return
+	}
+	log.Infof("oidc: user %s logged in", identity.User)
+
+	http.SetCookie(res, &http.Cookie{Name: oidcStateCookieName, Value: "", Path: this.URLPrefix + "/", MaxAge: -1})
+	http.SetCookie(res, &http.Cookie{Name: oidcTokenCookieName, Value: tokens.IDToken, Path: this.URLPrefix + "/", HttpOnly: true, Secure: req.TLS != nil, Expires: identity.Expiry})
+	http.Redirect(res, req, this.URLPrefix+"/", http.StatusFound)
+}
+
+// OIDCLogout clears the session cookie
+func (this *HttpWeb) OIDCLogout(res http.ResponseWriter, req *http.Request) {
+	http.SetCookie(res, &http.Cookie{Name: oidcTokenCookieName, Value: "", Path: this.URLPrefix + "/", MaxAge: -1})
+	http.Redirect(res, req, this.URLPrefix+"/oidc/login", http.StatusFound)
+}
+
+func (this *HttpWeb) registerOIDCRequests(m *martini.ClassicMartini) {
+	m.Get(this.URLPrefix+"/oidc/login", this.OIDCLogin)
+	m.Get(this.URLPrefix+"/oidc/callback", this.OIDCCallback)
+	m.Get(this.URLPrefix+"/oidc/logout", this.OIDCLogout)
+}
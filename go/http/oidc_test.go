@@ -0,0 +1,116 @@
+package http
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+const testOIDCIssuer = "https://idp.example.com"
+const testOIDCClientId = "orchestrator"
+
+func signTestIDToken(t *testing.T, key *rsa.PrivateKey, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "k1"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hashed := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, hashed[:])
+	test.S(t).ExpectNil(err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.S(t).ExpectNil(err)
+	getKey := func(kid string) (*rsa.PublicKey, error) {
+		if kid != "k1" {
+			return nil, fmt.Errorf("unknown key %s", kid)
+		}
+		return &key.PublicKey, nil
+	}
+	now := time.Now()
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"iss":    testOIDCIssuer,
+			"aud":    testOIDCClientId,
+			"exp":    now.Add(time.Hour).Unix(),
+			"sub":    "1234",
+			"email":  "dba@example.com",
+			"groups": []string{"dba", "eng"},
+		}
+	}
+	{
+		identity, err := verifyIDToken(signTestIDToken(t, key, validClaims()), testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(identity.User, "dba@example.com")
+		test.S(t).ExpectEquals(len(identity.Groups), 2)
+		test.S(t).ExpectEquals(identity.Groups[0], "dba")
+	}
+	{
+		claims := validClaims()
+		claims["aud"] = []string{"other", testOIDCClientId}
+		_, err := verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNil(err)
+	}
+	{
+		claims := validClaims()
+		claims["aud"] = "other"
+		_, err := verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		claims := validClaims()
+		claims["iss"] = "https://evil.example.com"
+		_, err := verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		claims := validClaims()
+		claims["exp"] = now.Add(-time.Hour).Unix()
+		_, err := verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		token := signTestIDToken(t, key, validClaims())
+		tampered := token[:len(token)-4] + "AAAA"
+		_, err := verifyIDToken(tampered, testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		claims := validClaims()
+		claims["nonce"] = "n1"
+		_, err := verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "n1", getKey, now)
+		test.S(t).ExpectNil(err)
+		_, err = verifyIDToken(signTestIDToken(t, key, claims), testOIDCIssuer, testOIDCClientId, "n2", getKey, now)
+		test.S(t).ExpectNotNil(err)
+		_, err = verifyIDToken(signTestIDToken(t, key, validClaims()), testOIDCIssuer, testOIDCClientId, "n1", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := verifyIDToken("not.a-token", testOIDCIssuer, testOIDCClientId, "", getKey, now)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestIsOIDCPowerIdentity(t *testing.T) {
+	defer func(users, groups []string) {
+		config.Config.PowerAuthUsers = users
+		config.Config.PowerAuthGroups = groups
+	}(config.Config.PowerAuthUsers, config.Config.PowerAuthGroups)
+
+	config.Config.PowerAuthUsers = []string{"admin@example.com"}
+	config.Config.PowerAuthGroups = []string{"dba"}
+
+	test.S(t).ExpectTrue(isOIDCPowerIdentity(&OIDCIdentity{User: "admin@example.com"}))
+	test.S(t).ExpectTrue(isOIDCPowerIdentity(&OIDCIdentity{User: "someone@example.com", Groups: []string{"eng", "dba"}}))
+	test.S(t).ExpectFalse(isOIDCPowerIdentity(&OIDCIdentity{User: "someone@example.com", Groups: []string{"eng"}}))
+}
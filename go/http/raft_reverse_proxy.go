@@ -1,48 +1,278 @@
 package http
 
 import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/openark/golib/log"
 
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/ssl"
 	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/martini-contrib/render"
 )
 
-func raftReverseProxy(w http.ResponseWriter, r *http.Request, c martini.Context) {
+const (
+	// raftConsistencyHeader requests a read consistency, like the "consistency" query parameter
+	raftConsistencyHeader = "X-Orchestrator-Consistency"
+	// raftForwardedByHeader and raftForwardedUserHeader attribute a request a follower forwards to the leader
+	raftForwardedByHeader   = "X-Orchestrator-Forwarded-By"
+	raftForwardedUserHeader = "X-Orchestrator-Forwarded-User"
+)
+
+// Read consistency levels of API requests on raft followers
+const (
+	raftConsistencyLeader = "leader"
+	raftConsistencyLease  = "lease"
+	raftConsistencyStale  = "stale"
+)
+
+// raftLocalRequests tracks requests a follower attempts to serve by itself. A request is marked once found
+// to be mutating (see markMutatingRequest), in which case it is forwarded to the leader after all.
+var raftLocalRequests = make(map[*http.Request]bool)
+var raftLocalRequestsMutex sync.Mutex
+
+// raftLocalRenderer binds rendering to the buffered response of a request served locally
+var raftLocalRenderer martini.Handler
+var raftLocalRendererOnce sync.Once
+
+func registerRaftLocalRequest(req *http.Request) {
+	raftLocalRequestsMutex.Lock()
+	defer raftLocalRequestsMutex.Unlock()
+	raftLocalRequests[req] = false
+}
+
+// markRaftLocalRequest marks a locally served request as mutating, returning false if the request is not served locally
+func markRaftLocalRequest(req *http.Request) bool {
+	raftLocalRequestsMutex.Lock()
+	defer raftLocalRequestsMutex.Unlock()
+	if _, found := raftLocalRequests[req]; !found {
+		return false
+	}
+	raftLocalRequests[req] = true
+	return true
+}
+
+// unregisterRaftLocalRequest forgets a request, returning whether it was marked as mutating
+func unregisterRaftLocalRequest(req *http.Request) (isMutating bool) {
+	raftLocalRequestsMutex.Lock()
+	defer raftLocalRequestsMutex.Unlock()
+	isMutating = raftLocalRequests[req]
+	delete(raftLocalRequests, req)
+	return isMutating
+}
+
+// getRaftReadConsistency returns the read consistency requested via query parameter or header, defaulting to RaftReadConsistency
+func getRaftReadConsistency(req *http.Request) (string, error) {
+	consistency := req.URL.Query().Get("consistency")
+	if consistency == "" {
+		consistency = req.Header.Get(raftConsistencyHeader)
+	}
+	if consistency == "" {
+		consistency = config.Config.RaftReadConsistency
+	}
+	switch consistency {
+	case raftConsistencyLeader, raftConsistencyLease, raftConsistencyStale:
+		return consistency, nil
+	}
+	return "", fmt.Errorf("Unknown consistency: %s; expecting leader, lease or stale", consistency)
+}
+
+// isRaftLeaseValid checks whether this follower heard from the leader recently enough to serve "lease" reads
+func isRaftLeaseValid() bool {
+	contactAge, err := orcraft.LeaderContactAge()
+	if err != nil {
+		return false
+	}
+	return contactAge <= time.Duration(config.Config.RaftLeaseReadSeconds)*time.Second
+}
+
+// bufferedResponseWriter holds a response so that it may yet be discarded
+type bufferedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newBufferedResponseWriter() *bufferedResponseWriter {
+	return &bufferedResponseWriter{header: make(http.Header)}
+}
+
+func (this *bufferedResponseWriter) Header() http.Header {
+	return this.header
+}
+
+func (this *bufferedResponseWriter) Write(b []byte) (int, error) {
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	return this.body.Write(b)
+}
+
+func (this *bufferedResponseWriter) WriteHeader(status int) {
+	if this.status == 0 {
+		this.status = status
+	}
+}
+
+// flush writes the buffered response to given writer
+func (this *bufferedResponseWriter) flush(w http.ResponseWriter) {
+	for key, values := range this.header {
+		w.Header()[key] = values
+	}
+	if this.status == 0 {
+		this.status = http.StatusOK
+	}
+	w.WriteHeader(this.status)
+	w.Write(this.body.Bytes())
+}
+
+// serveRaftLocally runs the remaining handlers of an API request on this follower, into a buffered response.
+// It returns false, having written nothing, if the request turned out to be mutating; such a request must
+// go to the leader.
+func serveRaftLocally(w http.ResponseWriter, r *http.Request, c martini.Context) (served bool, err error) {
+	var body []byte
+	if r.Body != nil {
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return false, err
+		}
+		r.Body.Close()
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	raftLocalRendererOnce.Do(func() {
+		raftLocalRenderer = render.Renderer()
+	})
+
+	buffered := newBufferedResponseWriter()
+	registerRaftLocalRequest(r)
+	c.MapTo(buffered, (*http.ResponseWriter)(nil))
+	if _, err := c.Invoke(raftLocalRenderer); err != nil {
+		unregisterRaftLocalRequest(r)
+		return false, err
+	}
+	c.Next()
+	if unregisterRaftLocalRequest(r) {
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+		return false, nil
+	}
+	buffered.flush(w)
+	return true, nil
+}
+
+// isRaftPeerRequest checks whether a request was issued by a raft peer: one presenting a certificate
+// with a SAN in SSLRaftPeerSANs, or else coming from a raft peer's address
+func isRaftPeerRequest(req *http.Request) bool {
 	if !orcraft.IsRaftEnabled() {
-		// No raft, so no reverse proxy to the leader
-		return
+		return false
 	}
-	if orcraft.IsLeader() {
-		// I am the leader. I will handle the request directly.
-		return
+	if cert := ssl.GetClientCertificate(req); cert != nil {
+		for _, san := range ssl.CertificateSANs(cert) {
+			if ssl.HasString(san, config.Config.SSLRaftPeerSANs) {
+				return true
+			}
+		}
 	}
-	if orcraft.GetLeader() == "" {
+	return orcraft.IsPeerAddress(getClientAddress(req))
+}
+
+// StripForwardingHeaders is a martini middleware which removes the headers attributing a forwarded request
+// (see forwardToRaftLeader) from any request not issued by a raft peer, so that clients cannot spoof them
+func StripForwardingHeaders(req *http.Request) {
+	if req.Header.Get(raftForwardedByHeader) == "" && req.Header.Get(raftForwardedUserHeader) == "" {
 		return
 	}
-	if orcraft.LeaderURI.IsThisLeaderURI() {
-		// Although I'm not the leader, the value I see for LeaderURI is my own.
-		// I'm probably not up-to-date with my raft transaction log and don't have the latest information.
-		// But anyway, obviously not going to redirect to myself.
-		// Gonna return: this isn't ideal, because I'm not really the leader. If the user tries to
-		// run an operation they'll fail.
+	if isRaftPeerRequest(req) {
 		return
 	}
+	req.Header.Del(raftForwardedByHeader)
+	req.Header.Del(raftForwardedUserHeader)
+}
+
+// getOriginalUserId returns the id of the user issuing a request. On a request a raft follower forwarded,
+// authenticating as HTTPAuthUser, this is the user on whose behalf the follower forwarded the request.
+func getOriginalUserId(req *http.Request, user auth.User) string {
+	userId := getUserId(req, user)
+	switch strings.ToLower(config.Config.AuthenticationMethod) {
+	case "basic", "multi":
+		forwardedUser := req.Header.Get(raftForwardedUserHeader)
+		if orcraft.IsRaftEnabled() && forwardedUser != "" && userId == config.Config.HTTPAuthUser {
+			return forwardedUser
+		}
+	}
+	return userId
+}
+
+// forwardToRaftLeader reverse proxies a request to the leader, attributing it to this node and the original user
+func forwardToRaftLeader(w http.ResponseWriter, r *http.Request, user auth.User) {
 	url, err := url.Parse(orcraft.LeaderURI.Get())
 	if err != nil {
 		log.Errore(err)
 		return
 	}
 	r.Header.Del("Accept-Encoding")
+	r.Header.Set(raftForwardedByHeader, orcraft.ThisHostname)
+	r.Header.Set(raftForwardedUserHeader, getUserId(r, user))
 	switch strings.ToLower(config.Config.AuthenticationMethod) {
 	case "basic", "multi":
 		r.SetBasicAuth(config.Config.HTTPAuthUser, config.Config.HTTPAuthPassword)
+	case "mtls":
+		setForwardedClientIdentity(r)
 	}
 	proxy := httputil.NewSingleHostReverseProxy(url)
+	if raftReverseProxyTLSConfig != nil {
+		proxy.Transport = &http.Transport{TLSClientConfig: raftReverseProxyTLSConfig}
+	}
 	proxy.ServeHTTP(w, r)
 }
+
+// raftReverseProxy routes requests arriving at a raft follower. With no leader to forward to, requests are
+// served locally. Otherwise web pages are forwarded to the leader, and API requests are forwarded or served
+// locally according to their read consistency; mutating API requests are always forwarded.
+func raftReverseProxy(w http.ResponseWriter, r *http.Request, user auth.User, c martini.Context, rnd render.Render) {
+	if !orcraft.IsRaftEnabled() {
+		// No raft, so no reverse proxy to the leader
+		return
+	}
+	if orcraft.IsLeader() {
+		// I am the leader. I will handle the request directly.
+		return
+	}
+	// When LeaderURI is my own, I'm probably not up-to-date with my raft transaction log.
+	// But anyway, obviously not going to redirect to myself.
+	if orcraft.GetLeader() == "" || orcraft.LeaderURI.IsThisLeaderURI() {
+		// No leader to forward to: the request is served locally. Mutating requests are then refused
+		// by isAuthorizedForAction.
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, config.Config.URLPrefix+"/api/") {
+		forwardToRaftLeader(w, r, user)
+		return
+	}
+	consistency, err := getRaftReadConsistency(r)
+	if err != nil {
+		rnd.JSON(http.StatusBadRequest, &APIResponse{Code: ERROR, Message: err.Error()})
+		return
+	}
+	serveLocally := consistency == raftConsistencyStale || (consistency == raftConsistencyLease && isRaftLeaseValid())
+	if serveLocally {
+		served, err := serveRaftLocally(w, r, c)
+		if err != nil {
+			rnd.JSON(http.StatusInternalServerError, &APIResponse{Code: ERROR, Message: err.Error()})
+			return
+		}
+		if served {
+			return
+		}
+	}
+	forwardToRaftLeader(w, r, user)
+}
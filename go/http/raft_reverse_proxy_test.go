@@ -0,0 +1,91 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/render"
+	test "github.com/openark/golib/tests"
+)
+
+func TestGetRaftReadConsistency(t *testing.T) {
+	{
+		req, _ := http.NewRequest("GET", "/api/clusters", nil)
+		consistency, err := getRaftReadConsistency(req)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(consistency, config.Config.RaftReadConsistency)
+	}
+	{
+		req, _ := http.NewRequest("GET", "/api/clusters", nil)
+		req.Header.Set(raftConsistencyHeader, "lease")
+		consistency, err := getRaftReadConsistency(req)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(consistency, "lease")
+	}
+	{
+		req, _ := http.NewRequest("GET", "/api/clusters?consistency=stale", nil)
+		req.Header.Set(raftConsistencyHeader, "lease")
+		consistency, err := getRaftReadConsistency(req)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(consistency, "stale")
+	}
+	{
+		req, _ := http.NewRequest("GET", "/api/clusters?consistency=strong", nil)
+		_, err := getRaftReadConsistency(req)
+		test.S(t).ExpectNotNil(err)
+	}
+}
+
+func TestStripForwardingHeaders(t *testing.T) {
+	req, _ := http.NewRequest("POST", "/api/relocate/db3/3306/db1/3306", nil)
+	req.RemoteAddr = "10.0.0.1:54321"
+	req.Header.Set(raftForwardedByHeader, "orchestrator-2")
+	req.Header.Set(raftForwardedUserHeader, "admin")
+	StripForwardingHeaders(req)
+	test.S(t).ExpectEquals(req.Header.Get(raftForwardedByHeader), "")
+	test.S(t).ExpectEquals(req.Header.Get(raftForwardedUserHeader), "")
+}
+
+func TestServeRaftLocally(t *testing.T) {
+	served := map[string]bool{}
+	m := martini.New()
+	m.Use(render.Renderer())
+	router := martini.NewRouter()
+	localAttempt := func(w http.ResponseWriter, req *http.Request, c martini.Context) {
+		ok, err := serveRaftLocally(w, req, c)
+		test.S(t).ExpectNil(err)
+		served[req.URL.Path] = ok
+		if !ok {
+			w.WriteHeader(http.StatusTeapot)
+		}
+	}
+	router.Get("/api/read", localAttempt, func(r render.Render) {
+		r.JSON(http.StatusOK, &APIResponse{Code: OK, Message: "read"})
+	})
+	router.Get("/api/write", localAttempt, func(r render.Render, req *http.Request) {
+		markMutatingRequest(req)
+		r.JSON(http.StatusOK, &APIResponse{Code: OK, Message: "written"})
+	})
+	m.Action(router.Handle)
+
+	{
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/read", nil)
+		m.ServeHTTP(recorder, req)
+		test.S(t).ExpectTrue(served["/api/read"])
+		test.S(t).ExpectEquals(recorder.Code, http.StatusOK)
+		test.S(t).ExpectEquals(recorder.Body.String(), `{"Code":"OK","Message":"read","Details":null}`)
+	}
+	{
+		recorder := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/write", nil)
+		m.ServeHTTP(recorder, req)
+		test.S(t).ExpectFalse(served["/api/write"])
+		test.S(t).ExpectEquals(recorder.Code, http.StatusTeapot)
+		test.S(t).ExpectEquals(recorder.Body.String(), "")
+	}
+	test.S(t).ExpectEquals(len(raftLocalRequests), 0)
+}
@@ -0,0 +1,167 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+var rateLimitedRequestsCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("http.rate_limited", rateLimitedRequestsCounter)
+}
+
+// tokenBucket is a simple token bucket: it holds up to `burst` tokens, refilled at `rate` tokens per second
+type tokenBucket struct {
+	tokens     float64
+	lastRefill time.Time
+}
+
+// rateLimiter keeps a token bucket per client
+type rateLimiter struct {
+	mutex   sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   math.Max(float64(burst), 1),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow consumes a token from the client's bucket, returning false when the bucket is empty
+func (this *rateLimiter) allow(client string, now time.Time) bool {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	bucket, found := this.buckets[client]
+	if !found {
+		bucket = &tokenBucket{tokens: this.burst, lastRefill: now}
+		this.buckets[client] = bucket
+	}
+	bucket.tokens = math.Min(this.burst, bucket.tokens+now.Sub(bucket.lastRefill).Seconds()*this.rate)
+	bucket.lastRefill = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// expire forgets clients whose buckets have been full (i.e. idle) for a while, so as to not grow indefinitely
+func (this *rateLimiter) expire(now time.Time) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	idleDuration := time.Duration(this.burst/this.rate*float64(time.Second)) + time.Minute
+	for client, bucket := range this.buckets {
+		if now.Sub(bucket.lastRefill) > idleDuration {
+			delete(this.buckets, client)
+		}
+	}
+}
+
+var raftPeerAddresses map[string]bool
+var raftPeerAddressesOnce sync.Once
+
+// isRaftPeerAddress checks whether given IP belongs to one of the configured RaftNodes
+func isRaftPeerAddress(ip string) bool {
+	raftPeerAddressesOnce.Do(func() {
+		raftPeerAddresses = make(map[string]bool)
+		for _, raftNode := range config.Config.RaftNodes {
+			host := raftNode
+			if h, _, err := net.SplitHostPort(raftNode); err == nil {
+				host = h
+			}
+			if addresses, err := net.LookupHost(host); err == nil {
+				for _, address := range addresses {
+					raftPeerAddresses[address] = true
+				}
+			}
+		}
+	})
+	return raftPeerAddresses[ip]
+}
+
+// getClientAddress returns the IP address of the requesting client
+func getClientAddress(req *http.Request) string {
+	if host, _, err := net.SplitHostPort(req.RemoteAddr); err == nil {
+		return host
+	}
+	return req.RemoteAddr
+}
+
+// getClientIdentifier identifies a client by authenticated user, if any, or else by address
+func getClientIdentifier(req *http.Request, user auth.User) string {
+	if userId := getUserId(req, user); userId != "" {
+		return userId
+	}
+	return getClientAddress(req)
+}
+
+// isRateLimitExemptPath returns true for requests which are never limited: health checks,
+// which load balancers poll and must not be denied, and static resources.
+func isRateLimitExemptPath(path string) bool {
+	prefix := config.Config.URLPrefix
+	for _, exempt := range []string{"/api/health", "/api/lb-check", "/api/_ping", "/api/status"} {
+		if path == prefix+exempt {
+			return true
+		}
+	}
+	return !strings.HasPrefix(path, prefix+"/api/") && !strings.HasPrefix(path, prefix+"/web/")
+}
+
+// RateLimit returns a martini middleware which limits the rate of requests per client, as configured
+// by APIRateLimitRequestsPerSecond and APIRateLimitBurst. Requests beyond the limit get a 429 response.
+// Requests forwarded by raft peers are not limited, as they have already been limited by the peer.
+func RateLimit() martini.Handler {
+	limiter := newRateLimiter(config.Config.APIRateLimitRequestsPerSecond, config.Config.APIRateLimitBurst)
+	go func() {
+		for range time.Tick(time.Minute) {
+			limiter.expire(time.Now())
+		}
+	}()
+	return func(res http.ResponseWriter, req *http.Request, user auth.User) {
+		if isRateLimitExemptPath(req.URL.Path) {
+			return
+		}
+		if isRaftPeerAddress(getClientAddress(req)) {
+			return
+		}
+		if !limiter.allow(getClientIdentifier(req, user), time.Now()) {
+			rateLimitedRequestsCounter.Inc(1)
+			res.Header().Set("Retry-After", "1")
+			http.Error(res, "Too many requests", http.StatusTooManyRequests)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package http
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := newRateLimiter(2, 3)
+	now := time.Now()
+
+	// burst
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectFalse(limiter.allow("a", now))
+	// other clients are unaffected
+	test.S(t).ExpectTrue(limiter.allow("b", now))
+	// refill at 2 per second
+	now = now.Add(500 * time.Millisecond)
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectFalse(limiter.allow("a", now))
+	now = now.Add(10 * time.Second)
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectTrue(limiter.allow("a", now))
+	test.S(t).ExpectFalse(limiter.allow("a", now))
+
+	limiter.expire(now.Add(time.Hour))
+	test.S(t).ExpectEquals(len(limiter.buckets), 0)
+}
@@ -0,0 +1,92 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-martini/martini"
+	"github.com/martini-contrib/auth"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// auditedRequests tracks in-flight API requests. A request is marked once its handler checks for
+// write privileges (see isAuthorizedForAction), which is what tells a mutating request apart.
+var auditedRequests = make(map[*http.Request]bool)
+var auditedRequestsMutex sync.Mutex
+
+func registerAuditedRequest(req *http.Request) {
+	auditedRequestsMutex.Lock()
+	defer auditedRequestsMutex.Unlock()
+	auditedRequests[req] = false
+}
+
+// markMutatingRequest marks a registered request as one requiring write privileges. A request a raft follower
+// attempts to serve locally is instead marked for forwarding to the leader, which audits it.
+func markMutatingRequest(req *http.Request) {
+	if markRaftLocalRequest(req) {
+		return
+	}
+	auditedRequestsMutex.Lock()
+	defer auditedRequestsMutex.Unlock()
+	if _, found := auditedRequests[req]; found {
+		auditedRequests[req] = true
+	}
+}
+
+// unregisterAuditedRequest forgets a request, returning whether it was marked as mutating
+func unregisterAuditedRequest(req *http.Request) (isMutating bool) {
+	auditedRequestsMutex.Lock()
+	defer auditedRequestsMutex.Unlock()
+	isMutating = auditedRequests[req]
+	delete(auditedRequests, req)
+	return isMutating
+}
+
+// AuditRequests is a martini middleware which audits API requests requiring write privileges:
+// the calling user and client, the requested path, the resulting HTTP status and the duration.
+func AuditRequests(res http.ResponseWriter, req *http.Request, user auth.User, c martini.Context) {
+	if !strings.HasPrefix(req.URL.Path, config.Config.URLPrefix+"/api/") {
+		return
+	}
+	startTime := time.Now()
+	registerAuditedRequest(req)
+	c.Next()
+	if !unregisterAuditedRequest(req) {
+		return
+	}
+	status := http.StatusOK
+	if rw, ok := res.(martini.ResponseWriter); ok && rw.Status() != 0 {
+		status = rw.Status()
+	}
+	message := fmt.Sprintf("user=%s client=%s path=%s status=%d duration=%s",
+		getUserId(req, user), getClientAddress(req), req.URL.Path, status, time.Since(startTime).String(),
+	)
+	if forwardedFor := req.Header.Get("X-Forwarded-For"); forwardedFor != "" {
+		message = fmt.Sprintf("%s forwarded-for=%s", message, forwardedFor)
+	}
+	if forwardedBy := req.Header.Get(raftForwardedByHeader); forwardedBy != "" {
+		message = fmt.Sprintf("%s forwarded-by=%s forwarded-user=%s", message, forwardedBy, req.Header.Get(raftForwardedUserHeader))
+	}
+	inst.AuditOperation("api-request", nil, message)
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/rcrowley/go-metrics"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/logic"
+)
+
+var shedRequestsCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("http.shed", shedRequestsCounter)
+}
+
+// isLowPriorityPath checks whether given request path is configured in APILowPriorityPaths.
+// A configured path covers its sub-paths, e.g. "/api/audit" covers "/api/audit/1" but not "/api/audit-recovery"
+func isLowPriorityPath(path string) bool {
+	for _, lowPriorityPath := range config.Config.APILowPriorityPaths {
+		lowPriorityPath = config.Config.URLPrefix + strings.TrimRight(lowPriorityPath, "/")
+		if path == lowPriorityPath || strings.HasPrefix(path, lowPriorityPath+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// PrioritizeRequests is a martini middleware which sheds low priority API requests while a master
+// recovery is in progress, leaving the backend database to the recovery. Shed requests get a 429
+// response, hinting the client to retry once the recovery completes.
+func PrioritizeRequests(res http.ResponseWriter, req *http.Request) {
+	if !logic.IsMasterRecoveryInProgress() {
+		return
+	}
+	if !isLowPriorityPath(req.URL.Path) {
+		return
+	}
+	if isRaftPeerAddress(getClientAddress(req)) {
+		return
+	}
+	shedRequestsCounter.Inc(1)
+	res.Header().Set("Retry-After", "10")
+	res.Header().Set("X-Orchestrator-Request-Priority", "low")
+	res.Header().Set("X-Orchestrator-Shed-Reason", "master-recovery-in-progress")
+	http.Error(res, "Low priority request shed while a master recovery is in progress; retry later", http.StatusTooManyRequests)
+}
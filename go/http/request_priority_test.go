@@ -0,0 +1,21 @@
+package http
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+func TestIsLowPriorityPath(t *testing.T) {
+	lowPriorityPaths := config.Config.APILowPriorityPaths
+	defer func() { config.Config.APILowPriorityPaths = lowPriorityPaths }()
+	config.Config.APILowPriorityPaths = []string{"/api/audit", "/api/search/"}
+
+	test.S(t).ExpectTrue(isLowPriorityPath("/api/audit"))
+	test.S(t).ExpectTrue(isLowPriorityPath("/api/audit/3"))
+	test.S(t).ExpectTrue(isLowPriorityPath("/api/search/db"))
+	test.S(t).ExpectFalse(isLowPriorityPath("/api/audit-recovery"))
+	test.S(t).ExpectFalse(isLowPriorityPath("/api/recover/db/3306"))
+}
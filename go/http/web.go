@@ -22,6 +22,7 @@ import (
 	"net/http"
 	"net/http/pprof"
 	"strconv"
+	"strings"
 	"text/template"
 
 	"github.com/go-martini/martini"
@@ -400,6 +401,9 @@ func (this *HttpWeb) registerWebRequest(m *martini.ClassicMartini, path string,
 
 // RegisterRequests makes for the de-facto list of known Web calls
 func (this *HttpWeb) RegisterRequests(m *martini.ClassicMartini) {
+	if strings.ToLower(config.Config.AuthenticationMethod) == "oidc" {
+		this.registerOIDCRequests(m)
+	}
 	this.registerWebRequest(m, "access-token", this.AccessToken)
 	this.registerWebRequest(m, "", this.Index)
 	this.registerWebRequest(m, "/", this.Index)
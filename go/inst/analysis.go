@@ -17,6 +17,7 @@
 package inst
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 
@@ -53,6 +54,13 @@ const (
 	AllIntermediateMasterSlavesNotReplicating                          = "AllIntermediateMasterSlavesNotReplicating"
 	FirstTierSlaveFailingToConnectToMaster                             = "FirstTierSlaveFailingToConnectToMaster"
 	BinlogServerFailingToConnectToMaster                               = "BinlogServerFailingToConnectToMaster"
+	MasterFlapping                                                     = "MasterFlapping"
+	ReplicationStopped                                                 = "ReplicationStopped"
+	ErrantGTIDReplica                                                  = "ErrantGTIDReplica"
+	LockedSemiSyncMaster                                               = "LockedSemiSyncMaster"
+	TooManyConnectionsMaster                                           = "TooManyConnectionsMaster"
+	ReadOnlyFilesystemMaster                                           = "ReadOnlyFilesystemMaster"
+	DiskFullMaster                                                     = "DiskFullMaster"
 )
 
 const (
@@ -65,6 +73,8 @@ const (
 	ErrantGTIDStructureWarning                                               = "ErrantGTIDStructureWarning"
 	NoFailoverSupportStructureWarning                                        = "NoFailoverSupportStructureWarning"
 	NoWriteableMasterStructureWarning                                        = "NoWriteableMasterStructureWarning"
+	ReplicasWithoutTLSStructureWarning                                       = "ReplicasWithoutTLSStructureWarning"
+	ReplicasWithoutTLSVerificationStructureWarning                           = "ReplicasWithoutTLSVerificationStructureWarning"
 )
 
 type InstanceAnalysis struct {
@@ -111,15 +121,30 @@ type ReplicationAnalysis struct {
 	IsCoMaster                                bool
 	LastCheckValid                            bool
 	LastCheckPartialSuccess                   bool
+	SecondsSinceLastChecked                   int64 // age of backend data for the analyzed instance
 	CountReplicas                             uint
 	CountValidReplicas                        uint
 	CountValidReplicatingReplicas             uint
 	CountReplicasFailingToConnectToMaster     uint
 	CountDowntimedReplicas                    uint
 	ReplicationDepth                          uint
+	ReplicationLagSeconds                     sql.NullInt64
 	SlaveHosts                                InstanceKeyMap
 	IsFailingToConnectToMaster                bool
+	IsReplicationSQLThreadStopped             bool
+	LastSQLErrno                              int
+	SemiSyncMasterEnabled                     bool
+	SemiSyncMasterTimeout                     uint // milliseconds
+	SemiSyncMasterWaitReplicaCount            uint
+	SemiSyncMasterClients                     uint
+	SemiSyncMasterWaitSessions                uint
+	UnusableMasterSignal                      *UnusableMasterSignal // evidence the master is reachable but unusable, if any
+	RunningActivity                           string                // a running backup or long-running DDL on the analyzed instance, if any
+	CountReplicasRunningActivity              uint
+	ActivitySuppressionReason                 string // running activity explaining the analysis, which is then not actionable
 	Analysis                                  AnalysisCode
+	Severity                                  ProblemSeverity // set by the problems API
+	SuppressedBy                              string          // id of a matching problem suppression; set by the problems API
 	Description                               string
 	StructureAnalysis                         []StructureAnalysisCode
 	IsDowntimed                               bool
@@ -131,6 +156,7 @@ type ReplicationAnalysis struct {
 	OracleGTIDImmediateTopology               bool
 	MariaDBGTIDImmediateTopology              bool
 	BinlogServerImmediateTopology             bool
+	GTIDWithBinlogServersImmediateTopology    bool // replicas are a mix of GTID replicas and binlog servers
 	CountLoggingReplicas                      uint
 	CountStatementBasedLoggingReplicas        uint
 	CountMixedBasedLoggingReplicas            uint
@@ -138,18 +164,81 @@ type ReplicationAnalysis struct {
 	CountDistinctMajorVersionsLoggingReplicas uint
 	CountDelayedReplicas                      uint
 	CountLaggingReplicas                      uint
+	CountTLSReplicas                          uint
+	CountTLSVerifiedReplicas                  uint
 	IsActionableRecovery                      bool
 	ProcessingNodeHostname                    string
 	ProcessingNodeToken                       string
 	CountAdditionalAgreeingNodes              int
 	StartActivePeriod                         string
 	SkippableDueToDowntime                    bool
+	IsClusterInMaintenance                    bool // cluster in maintenance mode: automated recoveries are suppressed
 	GTIDMode                                  string
 	MinReplicaGTIDMode                        string
 	MaxReplicaGTIDMode                        string
 	MaxReplicaGTIDErrant                      string
+	GTIDErrant                                string
 	CommandHint                               string
 	IsReadOnly                                bool
+	ReplicaObservations                       []ReplicaObservation
+	CountReplicasObservingMasterAlive         uint
+	CountReplicasObservingMasterUnreachable   uint
+	HealthProbeResults                        []HealthProbeResult
+}
+
+// ReplicaObservation is a replica's own view of its master's health, as of the replica's last check
+type ReplicaObservation struct {
+	Key                       InstanceKey
+	LastCheckValid            bool
+	SecondsSinceLastChecked   int64
+	IOThreadRunning           bool
+	LastIOErrno               int
+	LastIOError               string
+	SecondsSinceLastHeartbeat sql.NullInt64 // adjusted by the age of the replica's last check
+}
+
+// Client errors by which the IO thread reports it cannot reach its master at all
+var masterConnectionErrnos = map[int]bool{
+	2003: true, // CR_CONN_HOST_ERROR
+	2005: true, // CR_UNKNOWN_HOST
+	2006: true, // CR_SERVER_GONE_ERROR
+	2013: true, // CR_SERVER_LOST
+}
+
+// isRecent is true when the replica was successfully checked within the last poll interval
+func (this *ReplicaObservation) isRecent() bool {
+	return this.LastCheckValid && this.SecondsSinceLastChecked <= int64(ValidSecondsFromSeenToLastAttemptedCheck())
+}
+
+// ObservesMasterAlive is true when the replica has current evidence its master is up: as of a recent check,
+// its IO thread is running and has received a heartbeat lately. An IO error is not taken as such evidence,
+// as it may predate the master's failure.
+func (this *ReplicaObservation) ObservesMasterAlive() bool {
+	if !this.isRecent() || !this.IOThreadRunning {
+		return false
+	}
+	return this.SecondsSinceLastHeartbeat.Valid && this.SecondsSinceLastHeartbeat.Int64 <= int64(config.Config.ReasonableReplicaHeartbeatSeconds)
+}
+
+// ObservesMasterUnreachable is true when the replica's IO thread fails to connect to its master. A stale
+// heartbeat is not taken as evidence: a master does not send heartbeats while it streams events.
+func (this *ReplicaObservation) ObservesMasterUnreachable() bool {
+	return this.LastCheckValid && masterConnectionErrnos[this.LastIOErrno]
+}
+
+// HealthProbeResult is the outcome of an auxiliary health probe of an analyzed instance
+type HealthProbeResult struct {
+	Type    string
+	Target  string
+	Healthy bool
+	Error   string
+}
+
+func (this HealthProbeResult) String() string {
+	if this.Healthy {
+		return fmt.Sprintf("%s %s: healthy", this.Type, this.Target)
+	}
+	return fmt.Sprintf("%s %s: %s", this.Type, this.Target, this.Error)
 }
 
 type AnalysisMap map[string](*ReplicationAnalysis)
@@ -177,6 +266,19 @@ func (this *ReplicationAnalysis) AnalysisString() string {
 	return strings.Join(result, ", ")
 }
 
+// IsLockedSemiSyncMaster checks whether the analyzed master blocks commits on semi-sync ACK waits: it is still
+// in semi-sync mode, short of semi-sync replicas, with sessions waiting, and will not fall back to async within
+// ReasonableLockedSemiSyncMasterSeconds
+func (this *ReplicationAnalysis) IsLockedSemiSyncMaster() bool {
+	if !this.SemiSyncMasterEnabled || this.SemiSyncMasterWaitReplicaCount == 0 {
+		return false
+	}
+	if this.SemiSyncMasterClients >= this.SemiSyncMasterWaitReplicaCount || this.SemiSyncMasterWaitSessions == 0 {
+		return false
+	}
+	return this.SemiSyncMasterTimeout > uint(config.Config.ReasonableLockedSemiSyncMasterSeconds)*1000
+}
+
 // ValidSecondsFromSeenToLastAttemptedCheck returns the maximum allowed elapsed time
 // between last_attempted_check to last_checked before we consider the instance as invalid.
 func ValidSecondsFromSeenToLastAttemptedCheck() uint {
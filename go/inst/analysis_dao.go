@@ -51,6 +51,48 @@ func initializeAnalysisDaoPostConfiguration() {
 	recentInstantAnalysis = cache.New(time.Duration(config.RecoveryPollSeconds*2)*time.Second, time.Second)
 }
 
+// readReplicaObservations reads each replica's view of its master's health, mapped by master
+func readReplicaObservations(clusterName string) (map[InstanceKey][]ReplicaObservation, error) {
+	observations := make(map[InstanceKey][]ReplicaObservation)
+	query := `
+		select
+			master_instance.hostname as master_hostname,
+			master_instance.port as master_port,
+			replica_instance.hostname,
+			replica_instance.port,
+			replica_instance.last_checked <= replica_instance.last_seen as is_last_check_valid,
+			unix_timestamp() - unix_timestamp(replica_instance.last_checked) as seconds_since_last_checked,
+			replica_instance.slave_io_running,
+			replica_instance.last_io_errno,
+			replica_instance.last_io_error,
+			replica_instance.seconds_since_last_heartbeat + (unix_timestamp() - unix_timestamp(replica_instance.last_checked)) as seconds_since_last_heartbeat
+		from
+			database_instance master_instance
+			left join hostname_resolve on (master_instance.hostname = hostname_resolve.hostname)
+			join database_instance replica_instance on (
+				coalesce(hostname_resolve.resolved_hostname, master_instance.hostname) = replica_instance.master_host
+				and master_instance.port = replica_instance.master_port
+			)
+		where
+			? in ('', master_instance.cluster_name)
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(clusterName), func(m sqlutils.RowMap) error {
+		masterKey := InstanceKey{Hostname: m.GetString("master_hostname"), Port: m.GetInt("master_port")}
+		observation := ReplicaObservation{
+			Key:                       InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			LastCheckValid:            m.GetBool("is_last_check_valid"),
+			SecondsSinceLastChecked:   m.GetInt64("seconds_since_last_checked"),
+			IOThreadRunning:           m.GetBool("slave_io_running"),
+			LastIOErrno:               m.GetInt("last_io_errno"),
+			LastIOError:               m.GetString("last_io_error"),
+			SecondsSinceLastHeartbeat: m.GetNullInt64("seconds_since_last_heartbeat"),
+		}
+		observations[masterKey] = append(observations[masterKey], observation)
+		return nil
+	})
+	return observations, err
+}
+
 // GetReplicationAnalysis will check for replication problems (dead master; unreachable master; etc)
 func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints) ([]ReplicationAnalysis, error) {
 	result := []ReplicationAnalysis{}
@@ -81,6 +123,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            AND master_instance.slave_io_running = 0
 		            AND master_instance.last_io_error like '%error %connecting to master%'
 		          ) /* AS is_failing_to_connect_to_master */)
+				OR (MIN(
+		            master_instance.slave_sql_running = 0
+		            AND master_instance.last_sql_errno > 0
+		          ) /* AS is_replication_sql_thread_stopped */)
+				OR (MIN(master_instance.gtid_errant) /* AS gtid_errant */ != '')
 				OR (COUNT(replica_instance.server_id) /* AS count_replicas */ > 0)
 			`
 		args = append(args, ValidSecondsFromSeenToLastAttemptedCheck())
@@ -104,13 +151,15 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				and master_instance.last_attempted_check <= master_instance.last_seen + interval ? second
 		        	) = 1 AS is_last_check_valid,
 						MIN(master_instance.last_check_partial_success) as last_check_partial_success,
+						MIN(IFNULL(unix_timestamp() - unix_timestamp(master_instance.last_checked), 0)) AS seconds_since_last_checked,
+						MIN(master_instance.slave_lag_seconds) AS replication_lag_seconds,
 		        MIN(master_instance.master_host IN ('' , '_')
 		            OR master_instance.master_port = 0
 								OR substr(master_instance.master_host, 1, 2) = '//') AS is_master,
 		        MIN(master_instance.is_co_master) AS is_co_master,
 		        MIN(CONCAT(master_instance.hostname,
 		                ':',
-		                master_instance.port) = master_instance.cluster_name) AS is_cluster_master,
+		                master_instance.port) = REPLACE(REPLACE(master_instance.cluster_name, '[', ''), ']', '')) AS is_cluster_master,
 						MIN(master_instance.gtid_mode) AS gtid_mode,
 		        COUNT(replica_instance.server_id) AS count_replicas,
 		        IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen),
@@ -131,6 +180,18 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		            AND master_instance.slave_io_running = 0
 		            AND master_instance.last_io_error like '%%error %%connecting to master%%'
 		          ) AS is_failing_to_connect_to_master,
+		        MIN(
+		            master_instance.slave_sql_running = 0
+		            AND master_instance.last_sql_errno > 0
+		          ) AS is_replication_sql_thread_stopped,
+		        MIN(master_instance.last_sql_errno) AS last_sql_errno,
+		        MIN(master_instance.gtid_errant) AS gtid_errant,
+		        MIN(master_instance.semi_sync_master_enabled) AS semi_sync_master_enabled,
+		        MIN(master_instance.semi_sync_master_timeout) AS semi_sync_master_timeout,
+		        MIN(master_instance.semi_sync_master_wait_for_slave_count) AS semi_sync_master_wait_for_slave_count,
+		        MIN(master_instance.semi_sync_master_clients) AS semi_sync_master_clients,
+		        MIN(master_instance.semi_sync_master_wait_sessions) AS semi_sync_master_wait_sessions,
+		        MIN(master_instance.running_activity) AS running_activity,
 						MIN(
 								master_downtime.downtime_active is not null
 								and ifnull(master_downtime.end_timestamp, now()) > now()
@@ -188,8 +249,16 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
               0) AS count_row_based_loggin_slaves,
 						IFNULL(SUM(replica_instance.sql_delay > 0),
               0) AS count_delayed_replicas,
+						IFNULL(SUM(replica_instance.allow_tls),
+              0) AS count_tls_replicas,
+						IFNULL(SUM(replica_instance.allow_tls
+								AND replica_instance.tls_verify_server_cert),
+              0) AS count_tls_verified_replicas,
 						IFNULL(SUM(replica_instance.slave_lag_seconds > ?),
               0) AS count_lagging_replicas,
+						IFNULL(SUM(replica_instance.last_checked <= replica_instance.last_seen
+								AND replica_instance.running_activity != ''),
+              0) AS count_replicas_running_activity,
 						IFNULL(MIN(replica_instance.gtid_mode), '')
               AS min_replica_gtid_mode,
 						IFNULL(MAX(replica_instance.gtid_mode), '')
@@ -245,7 +314,24 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			    count_replicas DESC
 	`, analysisQueryReductionClause)
 
-	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+	replicaObservations, err := readReplicaObservations(clusterName)
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	downtimeSchedules, err := ReadDowntimeSchedules()
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	analysisRules, err := ReadAnalysisRules()
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	unusableMasterSignals, err := ReadUnusableMasterSignals()
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	analysisTime := time.Now()
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		a := ReplicationAnalysis{
 			Analysis:               NoProblem,
 			ProcessingNodeHostname: process.ThisHostname,
@@ -264,6 +350,8 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.GTIDMode = m.GetString("gtid_mode")
 		a.LastCheckValid = m.GetBool("is_last_check_valid")
 		a.LastCheckPartialSuccess = m.GetBool("last_check_partial_success")
+		a.SecondsSinceLastChecked = m.GetInt64("seconds_since_last_checked")
+		a.ReplicationLagSeconds = m.GetNullInt64("replication_lag_seconds")
 		a.CountReplicas = m.GetUint("count_replicas")
 		a.CountValidReplicas = m.GetUint("count_valid_slaves")
 		a.CountValidReplicatingReplicas = m.GetUint("count_valid_replicating_slaves")
@@ -271,14 +359,33 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.CountDowntimedReplicas = m.GetUint("count_downtimed_replicas")
 		a.ReplicationDepth = m.GetUint("replication_depth")
 		a.IsFailingToConnectToMaster = m.GetBool("is_failing_to_connect_to_master")
+		a.IsReplicationSQLThreadStopped = m.GetBool("is_replication_sql_thread_stopped")
+		a.LastSQLErrno = m.GetInt("last_sql_errno")
+		a.GTIDErrant = m.GetString("gtid_errant")
+		a.SemiSyncMasterEnabled = m.GetBool("semi_sync_master_enabled")
+		a.SemiSyncMasterTimeout = m.GetUint("semi_sync_master_timeout")
+		a.SemiSyncMasterWaitReplicaCount = m.GetUint("semi_sync_master_wait_for_slave_count")
+		a.SemiSyncMasterClients = m.GetUint("semi_sync_master_clients")
+		a.SemiSyncMasterWaitSessions = m.GetUint("semi_sync_master_wait_sessions")
+		a.RunningActivity = m.GetString("running_activity")
 		a.IsDowntimed = m.GetBool("is_downtimed")
 		a.DowntimeEndTimestamp = m.GetString("downtime_end_timestamp")
 		a.DowntimeRemainingSeconds = m.GetInt("downtime_remaining_seconds")
+		if !a.IsDowntimed {
+			// Within a scheduled window, though not yet downtimed by ApplyDowntimeSchedules
+			if schedule := ActiveDowntimeScheduleFor(downtimeSchedules, &a.AnalyzedInstanceKey, a.ClusterDetails.ClusterAlias, analysisTime); schedule != nil {
+				_, endsAt, _ := schedule.ActiveWindow(analysisTime)
+				a.IsDowntimed = true
+				a.DowntimeEndTimestamp = endsAt.Format("2006-01-02 15:04:05")
+				a.DowntimeRemainingSeconds = int(endsAt.Sub(analysisTime).Seconds())
+			}
+		}
 		a.IsBinlogServer = m.GetBool("is_binlog_server")
 		a.ClusterDetails.ReadRecoveryInfo()
+		a.IsClusterInMaintenance = a.ClusterDetails.IsInMaintenance
 
 		a.SlaveHosts = *NewInstanceKeyMap()
-		a.SlaveHosts.ReadCommaDelimitedList(m.GetString("slave_hosts"))
+		a.SlaveHosts.ReadConcatenatedList(m.GetString("slave_hosts"))
 
 		countValidOracleGTIDSlaves := m.GetUint("count_valid_oracle_gtid_slaves")
 		a.OracleGTIDImmediateTopology = countValidOracleGTIDSlaves == a.CountValidReplicas && a.CountValidReplicas > 0
@@ -286,6 +393,10 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 		a.MariaDBGTIDImmediateTopology = countValidMariaDBGTIDSlaves == a.CountValidReplicas && a.CountValidReplicas > 0
 		countValidBinlogServerSlaves := m.GetUint("count_valid_binlog_server_slaves")
 		a.BinlogServerImmediateTopology = countValidBinlogServerSlaves == a.CountValidReplicas && a.CountValidReplicas > 0
+		a.GTIDWithBinlogServersImmediateTopology = countValidBinlogServerSlaves > 0 &&
+			countValidOracleGTIDSlaves+countValidMariaDBGTIDSlaves > 0 &&
+			countValidOracleGTIDSlaves+countValidMariaDBGTIDSlaves+countValidBinlogServerSlaves == a.CountValidReplicas &&
+			!a.BinlogServerImmediateTopology
 		a.PseudoGTIDImmediateTopology = m.GetBool("is_pseudo_gtid")
 
 		a.MinReplicaGTIDMode = m.GetString("min_replica_gtid_mode")
@@ -300,9 +411,26 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 
 		a.CountDelayedReplicas = m.GetUint("count_delayed_replicas")
 		a.CountLaggingReplicas = m.GetUint("count_lagging_replicas")
+		a.CountReplicasRunningActivity = m.GetUint("count_replicas_running_activity")
+		a.CountTLSReplicas = m.GetUint("count_tls_replicas")
+		a.CountTLSVerifiedReplicas = m.GetUint("count_tls_verified_replicas")
 
 		a.IsReadOnly = m.GetUint("read_only") == 1
 
+		a.ReplicaObservations = replicaObservations[a.AnalyzedInstanceKey]
+		for _, observation := range a.ReplicaObservations {
+			if observation.ObservesMasterAlive() {
+				a.CountReplicasObservingMasterAlive++
+			}
+			if observation.ObservesMasterUnreachable() {
+				a.CountReplicasObservingMasterUnreachable++
+			}
+		}
+
+		if signal, found := unusableMasterSignals[a.AnalyzedInstanceKey]; found {
+			a.UnusableMasterSignal = &signal
+		}
+
 		if !a.LastCheckValid {
 			analysisMessage := fmt.Sprintf("analysis: IsMaster: %+v, LastCheckValid: %+v, LastCheckPartialSuccess: %+v, CountReplicas: %+v, CountValidReplicatingReplicas: %+v, CountLaggingReplicas: %+v, CountDelayedReplicas: %+v, ",
 				a.IsMaster, a.LastCheckValid, a.LastCheckPartialSuccess, a.CountReplicas, a.CountValidReplicatingReplicas, a.CountLaggingReplicas, a.CountDelayedReplicas,
@@ -315,6 +443,10 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = DeadMasterWithoutSlaves
 			a.Description = "Master cannot be reached by orchestrator and has no slave"
 			//
+		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicatingReplicas == 0 && a.CountReplicasObservingMasterAlive > 0 {
+			a.Analysis = UnreachableMaster
+			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating, yet some of its replicas observe it as alive; possibly a network/host issue"
+			//
 		} else if a.IsMaster && !a.LastCheckValid && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
 			a.Analysis = DeadMaster
 			a.Description = "Master cannot be reached by orchestrator and none of its replicas is replicating"
@@ -327,6 +459,10 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = DeadMasterAndSomeSlaves
 			a.Description = "Master cannot be reached by orchestrator; some of its replicas are unreachable and none of its reachable replicas is replicating"
 			//
+		} else if a.IsMaster && a.UnusableMasterSignal != nil {
+			a.Analysis = a.UnusableMasterSignal.Analysis()
+			a.Description = fmt.Sprintf("Master is reachable but unusable: %s for %d seconds, per %s: %s", a.UnusableMasterSignal.Condition, a.UnusableMasterSignal.SecondsPersisted, a.UnusableMasterSignal.Source, a.UnusableMasterSignal.Details)
+			//
 		} else if a.IsMaster && !a.LastCheckValid && a.CountLaggingReplicas == a.CountReplicas && a.CountDelayedReplicas < a.CountReplicas && a.CountValidReplicatingReplicas > 0 {
 			a.Analysis = UnreachableMasterWithLaggingReplicas
 			a.Description = "Master cannot be reached by orchestrator and all of its replicas are lagging"
@@ -351,7 +487,15 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = AllMasterSlavesNotReplicatingOrDead
 			a.Description = "Master is reachable but none of its replicas is replicating"
 			//
-		} else /* co-master */ if a.IsCoMaster && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
+		} else if a.IsMaster && a.LastCheckValid && a.IsLockedSemiSyncMaster() {
+			a.Analysis = LockedSemiSyncMaster
+			a.Description = fmt.Sprintf("Semi-sync master is locked: %d semi-sync replicas of %d required are connected, and %d sessions wait for acknowledgement", a.SemiSyncMasterClients, a.SemiSyncMasterWaitReplicaCount, a.SemiSyncMasterWaitSessions)
+			//
+		} else /* co-master */ if a.IsCoMaster && !a.LastCheckValid && a.CountValidReplicatingReplicas == 0 && a.CountReplicasObservingMasterAlive > 0 {
+			a.Analysis = UnreachableCoMaster
+			a.Description = "Co-master cannot be reached by orchestrator and none of its replicas is replicating, yet some of its replicas observe it as alive; possibly a network/host issue"
+			//
+		} else if a.IsCoMaster && !a.LastCheckValid && a.CountReplicas > 0 && a.CountValidReplicas == a.CountReplicas && a.CountValidReplicatingReplicas == 0 {
 			a.Analysis = DeadCoMaster
 			a.Description = "Co-master cannot be reached by orchestrator and none of its replicas is replicating"
 			//
@@ -412,11 +556,31 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			a.Analysis = FirstTierSlaveFailingToConnectToMaster
 			a.Description = "1st tier slave (directly replicating from topology master) is unable to connect to the master"
 			//
+		} else if !a.IsMaster && a.LastCheckValid && a.IsReplicationSQLThreadStopped {
+			a.Analysis = ReplicationStopped
+			a.Description = fmt.Sprintf("Replica SQL thread is stopped on error %d", a.LastSQLErrno)
+			//
+		} else if !a.IsMaster && a.LastCheckValid && a.GTIDErrant != "" {
+			a.Analysis = ErrantGTIDReplica
+			a.Description = "Replica has errant GTID: transactions not executed on its master"
+			//
 		}
 		//		 else if a.IsMaster && a.CountReplicas == 0 {
 		//			a.Analysis = MasterWithoutSlaves
 		//			a.Description = "Master has no replicas"
 		//		}
+		if a.Analysis == NoProblem && a.IsMaster && a.ClusterDetails.IsMasterFlapping {
+			a.Analysis = MasterFlapping
+			a.Description = "Master has failed over too many times recently; automated master failover is blocked until acknowledged"
+		}
+		applyAnalysisRules(analysisRules, &a)
+		if reason := activitySuppressionReason(analysisRules, &a); reason != "" {
+			a.ActivitySuppressionReason = reason
+			a.Description = fmt.Sprintf("%s; explained by %s; not actionable", a.Description, reason)
+		}
+		if a.Analysis != NoProblem && a.IsClusterInMaintenance {
+			a.Description = fmt.Sprintf("%s; cluster in maintenance by %s: %s; automated recovery is suppressed", a.Description, a.ClusterDetails.MaintenanceOwner, a.ClusterDetails.MaintenanceReason)
+		}
 
 		appendAnalysis := func(analysis *ReplicationAnalysis) {
 			if a.Analysis == NoProblem && len(a.StructureAnalysis) == 0 && !hints.IncludeNoProblem {
@@ -462,6 +626,7 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 				!a.OracleGTIDImmediateTopology &&
 				!a.MariaDBGTIDImmediateTopology &&
 				!a.BinlogServerImmediateTopology &&
+				!a.GTIDWithBinlogServersImmediateTopology &&
 				!a.PseudoGTIDImmediateTopology {
 				a.StructureAnalysis = append(a.StructureAnalysis, NoFailoverSupportStructureWarning)
 			}
@@ -488,6 +653,11 @@ func GetReplicationAnalysis(clusterName string, hints *ReplicationAnalysisHints)
 			if a.IsMaster && a.IsReadOnly {
 				a.StructureAnalysis = append(a.StructureAnalysis, NoWriteableMasterStructureWarning)
 			}
+			if a.ClusterDetails.IsReplicationTLSRequired && a.CountTLSReplicas < a.CountReplicas {
+				a.StructureAnalysis = append(a.StructureAnalysis, ReplicasWithoutTLSStructureWarning)
+			} else if a.ClusterDetails.IsReplicationTLSRequired && config.Config.ReplicationTLSVerifyServerCert && a.CountTLSVerifiedReplicas < a.CountReplicas {
+				a.StructureAnalysis = append(a.StructureAnalysis, ReplicasWithoutTLSVerificationStructureWarning)
+			}
 
 		}
 		appendAnalysis(&a)
@@ -609,19 +779,6 @@ func auditInstanceAnalysisInChangelog(instanceKey *InstanceKey, analysisCode Ana
 	return log.Errore(err)
 }
 
-// ExpireInstanceAnalysisChangelog removes old-enough analysis entries from the changelog
-func ExpireInstanceAnalysisChangelog() error {
-	_, err := db.ExecOrchestrator(`
-			delete
-				from database_instance_analysis_changelog
-			where
-				analysis_timestamp < now() - interval ? hour
-			`,
-		config.Config.UnseenInstanceForgetHours,
-	)
-	return log.Errore(err)
-}
-
 // ReadReplicationAnalysisChangelog
 func ReadReplicationAnalysisChangelog() (res [](*ReplicationAnalysisChangelog), err error) {
 	query := `
@@ -0,0 +1,124 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// IsCustomAnalysisCode checks whether an analysis code is produced by an operator defined analysis rule
+func IsCustomAnalysisCode(analysisCode AnalysisCode) bool {
+	return strings.HasPrefix(string(analysisCode), config.CustomAnalysisCodePrefix)
+}
+
+func boolFieldValue(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+// analysisRuleFieldValue returns the value of a field per config.AnalysisRuleFields. A field with no value,
+// such as the lag of an instance not replicating, returns false.
+func analysisRuleFieldValue(analysis *ReplicationAnalysis, field string) (float64, bool) {
+	switch field {
+	case "IsMaster":
+		return boolFieldValue(analysis.IsMaster), true
+	case "IsCoMaster":
+		return boolFieldValue(analysis.IsCoMaster), true
+	case "IsReadOnly":
+		return boolFieldValue(analysis.IsReadOnly), true
+	case "IsBinlogServer":
+		return boolFieldValue(analysis.IsBinlogServer), true
+	case "IsDowntimed":
+		return boolFieldValue(analysis.IsDowntimed), true
+	case "IsFailingToConnectToMaster":
+		return boolFieldValue(analysis.IsFailingToConnectToMaster), true
+	case "CountReplicas":
+		return float64(analysis.CountReplicas), true
+	case "CountValidReplicas":
+		return float64(analysis.CountValidReplicas), true
+	case "CountValidReplicatingReplicas":
+		return float64(analysis.CountValidReplicatingReplicas), true
+	case "CountLaggingReplicas":
+		return float64(analysis.CountLaggingReplicas), true
+	case "CountDelayedReplicas":
+		return float64(analysis.CountDelayedReplicas), true
+	case "CountDowntimedReplicas":
+		return float64(analysis.CountDowntimedReplicas), true
+	case "ReplicationDepth":
+		return float64(analysis.ReplicationDepth), true
+	case "ReplicationLagSeconds":
+		return float64(analysis.ReplicationLagSeconds.Int64), analysis.ReplicationLagSeconds.Valid
+	case "SecondsSinceLastChecked":
+		return float64(analysis.SecondsSinceLastChecked), true
+	}
+	return 0, false
+}
+
+// analysisRuleConditionHolds evaluates a single condition of an analysis rule
+func analysisRuleConditionHolds(condition *config.AnalysisRuleCondition, analysis *ReplicationAnalysis) bool {
+	value, ok := analysisRuleFieldValue(analysis, condition.Field)
+	if !ok {
+		return false
+	}
+	switch condition.Operator {
+	case "=":
+		return value == condition.Value
+	case "!=":
+		return value != condition.Value
+	case "<":
+		return value < condition.Value
+	case "<=":
+		return value <= condition.Value
+	case ">":
+		return value > condition.Value
+	case ">=":
+		return value >= condition.Value
+	}
+	return false
+}
+
+// analysisRuleMatches checks whether an analysis rule applies to the analyzed instance's cluster, and all of
+// its conditions hold
+func analysisRuleMatches(rule *config.AnalysisRule, analysis *ReplicationAnalysis) bool {
+	if len(rule.ClusterFilters) > 0 && !analysis.ClusterDetails.filtersMatchCluster(rule.ClusterFilters) {
+		return false
+	}
+	for i := range rule.Conditions {
+		if !analysisRuleConditionHolds(&rule.Conditions[i], analysis) {
+			return false
+		}
+	}
+	return len(rule.Conditions) > 0
+}
+
+// applyAnalysisRules analyzes an instance with no built-in problem by the first matching analysis rule
+func applyAnalysisRules(rules []config.AnalysisRule, analysis *ReplicationAnalysis) {
+	if analysis.Analysis != NoProblem {
+		return
+	}
+	for i := range rules {
+		if analysisRuleMatches(&rules[i], analysis) {
+			analysis.Analysis = AnalysisCode(rules[i].Code)
+			analysis.Description = rules[i].Description
+			return
+		}
+	}
+}
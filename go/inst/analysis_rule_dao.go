@@ -0,0 +1,110 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// isConfiguredAnalysisRule checks whether an analysis rule code is defined in AnalysisRules
+func isConfiguredAnalysisRule(code string) bool {
+	for _, rule := range config.Config.AnalysisRules {
+		if rule.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteAnalysisRule creates or replaces an analysis rule managed via API. Rules defined in AnalysisRules
+// may only be changed in configuration.
+func WriteAnalysisRule(rule *config.AnalysisRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if isConfiguredAnalysisRule(rule.Code) {
+		return fmt.Errorf("Analysis rule %s is defined in configuration", rule.Code)
+	}
+	content, err := json.Marshal(rule)
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			replace
+				into analysis_rule (
+					rule_code, content, last_updated
+				) values (
+					?, ?, NOW()
+				)
+			`, rule.Code, string(content),
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("write-analysis-rule", nil, string(content))
+	return nil
+}
+
+// DeleteAnalysisRule removes an analysis rule managed via API
+func DeleteAnalysisRule(code string) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				analysis_rule
+			where
+				rule_code = ?
+			`, code,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("Analysis rule %s not found", code)
+	}
+	AuditOperation("delete-analysis-rule", nil, code)
+	return nil
+}
+
+// ReadAnalysisRules reads the effective analysis rules: those of AnalysisRules, followed by those managed via API
+func ReadAnalysisRules() (rules []config.AnalysisRule, err error) {
+	rules = append(rules, config.Config.AnalysisRules...)
+	query := `
+		select
+			rule_code, content
+		from
+			analysis_rule
+		order by
+			rule_code
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		rule := config.AnalysisRule{}
+		if err := json.Unmarshal([]byte(m.GetString("content")), &rule); err != nil {
+			return log.Errorf("Cannot parse analysis rule %s: %+v", m.GetString("rule_code"), err)
+		}
+		if isConfiguredAnalysisRule(rule.Code) {
+			return nil
+		}
+		rules = append(rules, rule)
+		return nil
+	})
+	return rules, log.Errore(err)
+}
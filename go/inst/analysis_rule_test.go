@@ -0,0 +1,60 @@
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestApplyAnalysisRules(t *testing.T) {
+	rules := []config.AnalysisRule{
+		{
+			Code:        "CustomMasterWithFewReplicas",
+			Description: "Master has fewer than 2 replicas",
+			Conditions: []config.AnalysisRuleCondition{
+				{Field: "IsMaster", Operator: "=", Value: 1},
+				{Field: "CountValidReplicas", Operator: "<", Value: 2},
+			},
+		},
+		{
+			Code:           "CustomLaggingReplica",
+			ClusterFilters: []string{"alias=main"},
+			Conditions: []config.AnalysisRuleCondition{
+				{Field: "ReplicationLagSeconds", Operator: ">", Value: 300},
+			},
+		},
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: NoProblem, IsMaster: true, CountValidReplicas: 1}
+		applyAnalysisRules(rules, analysis)
+		test.S(t).ExpectEquals(analysis.Analysis, AnalysisCode("CustomMasterWithFewReplicas"))
+		test.S(t).ExpectEquals(analysis.Description, "Master has fewer than 2 replicas")
+		test.S(t).ExpectTrue(IsCustomAnalysisCode(analysis.Analysis))
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: DeadMaster, IsMaster: true, CountValidReplicas: 1}
+		applyAnalysisRules(rules, analysis)
+		test.S(t).ExpectEquals(analysis.Analysis, AnalysisCode(DeadMaster))
+		test.S(t).ExpectFalse(IsCustomAnalysisCode(analysis.Analysis))
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: NoProblem, ReplicationLagSeconds: sql.NullInt64{Int64: 600, Valid: true}}
+		analysis.ClusterDetails = ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+		applyAnalysisRules(rules, analysis)
+		test.S(t).ExpectEquals(analysis.Analysis, AnalysisCode("CustomLaggingReplica"))
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: NoProblem, ReplicationLagSeconds: sql.NullInt64{Int64: 600, Valid: true}}
+		analysis.ClusterDetails = ClusterInfo{ClusterName: "db-other-1:3306", ClusterAlias: "other"}
+		applyAnalysisRules(rules, analysis)
+		test.S(t).ExpectEquals(analysis.Analysis, NoProblem)
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: NoProblem}
+		analysis.ClusterDetails = ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+		applyAnalysisRules(rules, analysis)
+		test.S(t).ExpectEquals(analysis.Analysis, NoProblem)
+	}
+}
@@ -0,0 +1,99 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestReplicaObservation(t *testing.T) {
+	{
+		observation := ReplicaObservation{LastCheckValid: true, IOThreadRunning: true, SecondsSinceLastHeartbeat: sql.NullInt64{Int64: 3, Valid: true}}
+		test.S(t).ExpectTrue(observation.ObservesMasterAlive())
+		test.S(t).ExpectFalse(observation.ObservesMasterUnreachable())
+	}
+	{
+		observation := ReplicaObservation{LastCheckValid: true, IOThreadRunning: true, SecondsSinceLastHeartbeat: sql.NullInt64{Int64: 300, Valid: true}}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+		test.S(t).ExpectFalse(observation.ObservesMasterUnreachable())
+	}
+	{
+		// Heartbeat as of a stale check
+		observation := ReplicaObservation{LastCheckValid: true, SecondsSinceLastChecked: 600, IOThreadRunning: true, SecondsSinceLastHeartbeat: sql.NullInt64{Int64: 3, Valid: true}}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+	}
+	{
+		observation := ReplicaObservation{LastCheckValid: false, IOThreadRunning: true, SecondsSinceLastHeartbeat: sql.NullInt64{Int64: 3, Valid: true}}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+	}
+	{
+		observation := ReplicaObservation{LastCheckValid: true, IOThreadRunning: true}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+	}
+	{
+		observation := ReplicaObservation{LastCheckValid: true, LastIOErrno: 2003}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+		test.S(t).ExpectTrue(observation.ObservesMasterUnreachable())
+	}
+	{
+		// Access denied: may predate the master's failure
+		observation := ReplicaObservation{LastCheckValid: true, LastIOErrno: 1045}
+		test.S(t).ExpectFalse(observation.ObservesMasterAlive())
+		test.S(t).ExpectFalse(observation.ObservesMasterUnreachable())
+	}
+	{
+		observation := ReplicaObservation{LastCheckValid: false, LastIOErrno: 2003}
+		test.S(t).ExpectFalse(observation.ObservesMasterUnreachable())
+	}
+}
+
+func TestIsLockedSemiSyncMaster(t *testing.T) {
+	config.Config.ReasonableLockedSemiSyncMasterSeconds = 10
+	locked := ReplicationAnalysis{SemiSyncMasterEnabled: true, SemiSyncMasterTimeout: 100000, SemiSyncMasterWaitReplicaCount: 1, SemiSyncMasterClients: 0, SemiSyncMasterWaitSessions: 3}
+	test.S(t).ExpectTrue(locked.IsLockedSemiSyncMaster())
+	{
+		a := locked
+		a.SemiSyncMasterClients = 1
+		test.S(t).ExpectFalse(a.IsLockedSemiSyncMaster())
+	}
+	{
+		a := locked
+		a.SemiSyncMasterWaitSessions = 0
+		test.S(t).ExpectFalse(a.IsLockedSemiSyncMaster())
+	}
+	{
+		// Falls back to async soon enough
+		a := locked
+		a.SemiSyncMasterTimeout = 10000
+		test.S(t).ExpectFalse(a.IsLockedSemiSyncMaster())
+	}
+	{
+		a := locked
+		a.SemiSyncMasterEnabled = false
+		test.S(t).ExpectFalse(a.IsLockedSemiSyncMaster())
+	}
+	{
+		a := locked
+		a.SemiSyncMasterWaitReplicaCount = 2
+		a.SemiSyncMasterClients = 1
+		test.S(t).ExpectTrue(a.IsLockedSemiSyncMaster())
+	}
+}
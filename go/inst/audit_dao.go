@@ -153,7 +153,42 @@ func ReadRecentAudit(instanceKey *InstanceKey, page int) ([]Audit, error) {
 
 }
 
-// ExpireAudit removes old rows from the audit table
-func ExpireAudit() error {
-	return ExpireTableData("audit", "audit_timestamp")
+// ReadAudits reads audit entries by given listing filter, optionally only those of given instance and/or of given audit type
+func ReadAudits(filter *ListingFilter, instanceKey *InstanceKey, auditType string) ([]Audit, error) {
+	res := []Audit{}
+	conditions, args := filter.Conditions("audit_id", "audit_timestamp", "cluster_name")
+	if instanceKey != nil {
+		conditions = append(conditions, `hostname=?`, `port=?`)
+		args = append(args, instanceKey.Hostname, instanceKey.Port)
+	}
+	if auditType != "" {
+		conditions = append(conditions, `audit_type=?`)
+		args = append(args, auditType)
+	}
+	query := fmt.Sprintf(`
+		select
+			audit_id,
+			audit_timestamp,
+			audit_type,
+			hostname,
+			port,
+			message
+		from
+			audit
+		%s
+		%s
+		`, WhereClause(conditions), filter.OrderLimit("audit_id"))
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		audit := Audit{}
+		audit.AuditId = m.GetInt64("audit_id")
+		audit.AuditTimestamp = m.GetString("audit_timestamp")
+		audit.AuditType = m.GetString("audit_type")
+		audit.AuditInstanceKey.Hostname = m.GetString("hostname")
+		audit.AuditInstanceKey.Port = m.GetInt("port")
+		audit.Message = m.GetString("message")
+
+		res = append(res, audit)
+		return nil
+	})
+	return res, log.Errore(err)
 }
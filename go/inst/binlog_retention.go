@@ -0,0 +1,125 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+)
+
+const secondsPerDay = 24 * 60 * 60
+
+// ReadBinlogRetentionSeconds reads the effective binary log retention of an instance. MySQL 8.0 expires binary
+// logs per binlog_expire_logs_seconds, unless 0, in which case expire_logs_days applies; earlier versions and
+// MariaDB only have expire_logs_days. 0 stands for binary logs never expiring.
+func ReadBinlogRetentionSeconds(instanceKey *InstanceKey) (seconds uint, err error) {
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return 0, err
+	}
+	var expireLogsSeconds uint
+	expireLogsSecondsErr := sqlDB.QueryRow("select @@global.binlog_expire_logs_seconds").Scan(&expireLogsSeconds)
+	if expireLogsSecondsErr == nil && expireLogsSeconds > 0 {
+		return expireLogsSeconds, nil
+	}
+	var expireLogsDays float64
+	if err := sqlDB.QueryRow("select @@global.expire_logs_days").Scan(&expireLogsDays); err != nil {
+		if expireLogsSecondsErr == nil {
+			// expire_logs_days is removed as of MySQL 8.4
+			return 0, nil
+		}
+		return 0, err
+	}
+	return uint(expireLogsDays * secondsPerDay), nil
+}
+
+// SetBinlogRetentionSeconds sets the binary log retention of an instance: binlog_expire_logs_seconds where
+// supported, otherwise expire_logs_days, rounded up to whole days. The change is not persisted to my.cnf.
+func SetBinlogRetentionSeconds(instanceKey *InstanceKey, seconds uint) error {
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting set-binlog-retention operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, "set global binlog_expire_logs_seconds = ?", seconds); err != nil {
+		days := (seconds + secondsPerDay - 1) / secondsPerDay
+		if _, err := ExecInstance(instanceKey, "set global expire_logs_days = ?", days); err != nil {
+			return log.Errore(err)
+		}
+	}
+	AuditOperation("set-binlog-retention", instanceKey, fmt.Sprintf("%d seconds", seconds))
+	return nil
+}
+
+// PurgeAppliedBinaryLogs purges the binary logs of an instance which all of its replicas, and all downstream heads
+// replicating from it via named channels, have applied. An instance with neither is left untouched, as its binary
+// logs may yet serve backups or point in time recovery.
+func PurgeAppliedBinaryLogs(instanceKey *InstanceKey) (*Instance, error) {
+	instance, found, err := ReadInstance(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("PurgeAppliedBinaryLogs: unknown instance %+v", *instanceKey)
+	}
+	replicas, err := ReadReplicaInstances(instanceKey)
+	if err != nil {
+		return nil, err
+	}
+	heads, err := ReadDownstreamHeads(instance)
+	if err != nil {
+		return nil, err
+	}
+	if len(replicas) == 0 && len(heads) == 0 {
+		return nil, fmt.Errorf("PurgeAppliedBinaryLogs: %+v has no replicas; not purging", *instanceKey)
+	}
+	appliedCoordinates := []BinlogCoordinates{}
+	for _, replica := range replicas {
+		appliedCoordinates = append(appliedCoordinates, replica.ExecBinlogCoordinates)
+	}
+	for _, head := range heads {
+		coordinates, err := ReadChannelExecBinlogCoordinates(&head.Key, head.Channel)
+		if err != nil {
+			return nil, fmt.Errorf("PurgeAppliedBinaryLogs: cannot read applied coordinates of %+v channel %s: %+v", head.Key, head.Channel, err)
+		}
+		appliedCoordinates = append(appliedCoordinates, *coordinates)
+	}
+	oldestApplied, err := oldestAppliedCoordinates(appliedCoordinates)
+	if err != nil {
+		return nil, fmt.Errorf("PurgeAppliedBinaryLogs: cannot tell which binary logs replicas of %+v have applied", *instanceKey)
+	}
+	return PurgeBinaryLogsTo(instanceKey, oldestApplied.LogFile, false)
+}
+
+// oldestAppliedCoordinates returns the smallest of given applied coordinates, failing should any be unknown
+func oldestAppliedCoordinates(appliedCoordinates []BinlogCoordinates) (oldestApplied BinlogCoordinates, err error) {
+	if len(appliedCoordinates) == 0 {
+		return oldestApplied, fmt.Errorf("No applied coordinates")
+	}
+	oldestApplied = appliedCoordinates[0]
+	for _, coordinates := range appliedCoordinates {
+		if coordinates.LogFile == "" {
+			return oldestApplied, fmt.Errorf("Unknown applied coordinates")
+		}
+		if coordinates.SmallerThan(&oldestApplied) {
+			oldestApplied = coordinates
+		}
+	}
+	return oldestApplied, nil
+}
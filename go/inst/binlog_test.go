@@ -135,3 +135,26 @@ func TestFileNumber(t *testing.T) {
 	test.S(t).ExpectEquals(fileNum, 17)
 	test.S(t).ExpectEquals(numLen, 5)
 }
+
+func TestOldestAppliedCoordinates(t *testing.T) {
+	replicaCoordinates := BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 400}
+	headCoordinates := BinlogCoordinates{LogFile: "mysql-bin.000011", LogPos: 900}
+	{
+		oldestApplied, err := oldestAppliedCoordinates([]BinlogCoordinates{replicaCoordinates, headCoordinates, testCoordinates})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(oldestApplied.Equals(&testCoordinates))
+	}
+	{
+		oldestApplied, err := oldestAppliedCoordinates([]BinlogCoordinates{replicaCoordinates, headCoordinates})
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(oldestApplied.Equals(&headCoordinates))
+	}
+	{
+		_, err := oldestAppliedCoordinates([]BinlogCoordinates{replicaCoordinates, {}})
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := oldestAppliedCoordinates([]BinlogCoordinates{})
+		test.S(t).ExpectNotNil(err)
+	}
+}
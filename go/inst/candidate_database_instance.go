@@ -18,6 +18,7 @@ package inst
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/github/orchestrator/go/db"
 )
@@ -28,7 +29,8 @@ type CandidateDatabaseInstance struct {
 	Port                int
 	PromotionRule       CandidatePromotionRule
 	LastSuggestedString string
-	PromotionRuleExpiry string // generated when retrieved from database for consistency reasons
+	PromotionRuleExpiry string        // generated when retrieved from database for consistency reasons
+	Expiry              time.Duration // optional explicit expiry, overriding CandidateInstanceExpireMinutes
 }
 
 func NewCandidateDatabaseInstance(instanceKey *InstanceKey, promotionRule CandidatePromotionRule) *CandidateDatabaseInstance {
@@ -44,6 +46,12 @@ func (cdi *CandidateDatabaseInstance) WithCurrentTime() *CandidateDatabaseInstan
 	return cdi
 }
 
+// WithExpiry sets an explicit expiry for this registration, counted from the time of registration
+func (cdi *CandidateDatabaseInstance) WithExpiry(expiry time.Duration) *CandidateDatabaseInstance {
+	cdi.Expiry = expiry
+	return cdi
+}
+
 // String returns a string representation of the CandidateDatabaseInstance struct
 func (cdi *CandidateDatabaseInstance) String() string {
 	return fmt.Sprintf("%s:%d %s", cdi.Hostname, cdi.Port, cdi.PromotionRule)
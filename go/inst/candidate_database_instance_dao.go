@@ -32,33 +32,65 @@ func RegisterCandidateInstance(candidate *CandidateDatabaseInstance) error {
 		candidate = candidate.WithCurrentTime()
 	}
 	args := sqlutils.Args(candidate.Hostname, candidate.Port, string(candidate.PromotionRule), candidate.LastSuggestedString)
+	expireTimestamp := "NULL"
+	auditMessage := string(candidate.PromotionRule)
+	if candidate.Expiry > 0 {
+		expireTimestamp = "NOW() + INTERVAL ? SECOND"
+		args = append(args, int64(candidate.Expiry.Seconds()))
+		auditMessage = fmt.Sprintf("%s, expiry: %+v", candidate.PromotionRule, candidate.Expiry)
+	}
 
 	query := fmt.Sprintf(`
 			insert into candidate_database_instance (
 					hostname,
 					port,
 					promotion_rule,
-					last_suggested
+					last_suggested,
+					expire_timestamp
 				) values (
-					?, ?, ?, ?
+					?, ?, ?, ?, %s
 				) on duplicate key update
 					last_suggested=values(last_suggested),
-					promotion_rule=values(promotion_rule)
-			`)
+					promotion_rule=values(promotion_rule),
+					expire_timestamp=values(expire_timestamp)
+			`, expireTimestamp)
 	writeFunc := func() error {
 		_, err := db.ExecOrchestrator(query, args...)
-		AuditOperation("register-candidate", candidate.Key(), string(candidate.PromotionRule))
+		AuditOperation("register-candidate", candidate.Key(), auditMessage)
 		return log.Errore(err)
 	}
 	return ExecDBWriteFunc(writeFunc)
 }
 
-// ExpireCandidateInstances removes stale master candidate suggestions.
+// UnregisterCandidateInstance removes the promotion rule of a given instance
+func UnregisterCandidateInstance(instanceKey *InstanceKey) (wasRegistered bool, err error) {
+	writeFunc := func() error {
+		res, err := db.ExecOrchestrator(`
+				delete from candidate_database_instance
+				where hostname = ? and port = ?
+				`, instanceKey.Hostname, instanceKey.Port,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		if affected, _ := res.RowsAffected(); affected > 0 {
+			wasRegistered = true
+			AuditOperation("unregister-candidate", instanceKey, "")
+		}
+		return nil
+	}
+	err = ExecDBWriteFunc(writeFunc)
+	return wasRegistered, err
+}
+
+// ExpireCandidateInstances removes stale master candidate suggestions, as well as those past their explicit expiry.
 func ExpireCandidateInstances() error {
 	writeFunc := func() error {
 		_, err := db.ExecOrchestrator(`
 				delete from candidate_database_instance
-				where last_suggested < NOW() - INTERVAL ? MINUTE
+				where
+					(expire_timestamp is null and last_suggested < NOW() - INTERVAL ? MINUTE)
+					or expire_timestamp < NOW()
 				`, config.Config.CandidateInstanceExpireMinutes,
 		)
 		return log.Errore(err)
@@ -88,7 +120,8 @@ func BulkReadCandidateDatabaseInstance() ([]CandidateDatabaseInstance, error) {
 			port,
 			promotion_rule,
 			last_suggested,
-			last_suggested + INTERVAL ? MINUTE AS promotion_rule_expiry
+			last_suggested + INTERVAL ? MINUTE AS promotion_rule_expiry,
+			expire_timestamp
 		FROM
 			candidate_database_instance
 	`
@@ -100,6 +133,9 @@ func BulkReadCandidateDatabaseInstance() ([]CandidateDatabaseInstance, error) {
 			LastSuggestedString: m.GetString("last_suggested"),
 			PromotionRuleExpiry: m.GetString("promotion_rule_expiry"),
 		}
+		if expireTimestamp := m.GetString("expire_timestamp"); expireTimestamp != "" {
+			cdi.PromotionRuleExpiry = expireTimestamp
+		}
 		// add to end of candidateDatabaseInstances
 		candidateDatabaseInstances = append(candidateDatabaseInstances, cdi)
 
@@ -26,23 +26,35 @@ import (
 )
 
 func GetClusterMasterKVKey(clusterAlias string) string {
-	return fmt.Sprintf("%s%s", config.Config.KVClusterMasterPrefix, clusterAlias)
+	return getClusterMasterKVKey(config.Config.KVClusterMasterPrefix, clusterAlias)
+}
+
+func getClusterMasterKVKey(kvPrefix string, clusterAlias string) string {
+	return fmt.Sprintf("%s%s", kvPrefix, clusterAlias)
 }
 
 func getClusterMasterKVPair(clusterAlias string, masterKey *InstanceKey) *kv.KVPair {
+	return getPrefixedClusterMasterKVPair(config.Config.KVClusterMasterPrefix, clusterAlias, masterKey)
+}
+
+func getPrefixedClusterMasterKVPair(kvPrefix string, clusterAlias string, masterKey *InstanceKey) *kv.KVPair {
 	if clusterAlias == "" {
 		return nil
 	}
 	if masterKey == nil {
 		return nil
 	}
-	return kv.NewKVPair(GetClusterMasterKVKey(clusterAlias), masterKey.StringCode())
+	return kv.NewKVPair(getClusterMasterKVKey(kvPrefix, clusterAlias), masterKey.StringCode())
 }
 
 // GetClusterMasterKVPairs returns all KV pairs associated with a master. This includes the
 // full identity of the master as well as a breakdown by hostname, port, ipv4, ipv6
 func GetClusterMasterKVPairs(clusterAlias string, masterKey *InstanceKey) (kvPairs [](*kv.KVPair)) {
-	masterKVPair := getClusterMasterKVPair(clusterAlias, masterKey)
+	return getPrefixedClusterMasterKVPairs(config.Config.KVClusterMasterPrefix, clusterAlias, masterKey)
+}
+
+func getPrefixedClusterMasterKVPairs(kvPrefix string, clusterAlias string, masterKey *InstanceKey) (kvPairs [](*kv.KVPair)) {
+	masterKVPair := getPrefixedClusterMasterKVPair(kvPrefix, clusterAlias, masterKey)
 	if masterKVPair == nil {
 		return kvPairs
 	}
@@ -86,12 +98,192 @@ type ClusterInfo struct {
 	HeuristicLag                           int64
 	HasAutomatedMasterRecovery             bool
 	HasAutomatedIntermediateMasterRecovery bool
+	IsCanaryCluster                        bool   // When running as a canary deployment, whether automated action is taken on this cluster
+	IsReplicationTLSRequired               bool   // Whether replication in this cluster must use TLS
+	IsRDSFailoverCluster                   bool   // Whether this cluster's master is failed over via the AWS RDS API
+	IsCloudSQLFailoverCluster              bool   // Whether this cluster's master is failed over via the GCP CloudSQL Admin API
+	ClusterTemplate                        string // Name of cluster template assigned to this cluster, if any
+	PriorityTier                           string // Recovery priority tier (P1, P2, P3) of this cluster, if any
+	IsMasterFlapping                       bool   // Whether this cluster's master has failed over too often recently; automated master failovers are blocked until acknowledged
+	IsInMaintenance                        bool   // Whether this cluster is in maintenance mode; automated recoveries are suppressed meanwhile
+	MaintenanceOwner                       string
+	MaintenanceReason                      string
+	MaintenanceEndTimestamp                string
 }
 
 // ReadRecoveryInfo
 func (this *ClusterInfo) ReadRecoveryInfo() {
 	this.HasAutomatedMasterRecovery = this.filtersMatchCluster(config.Config.RecoverMasterClusterFilters)
 	this.HasAutomatedIntermediateMasterRecovery = this.filtersMatchCluster(config.Config.RecoverIntermediateMasterClusterFilters)
+	this.IsCanaryCluster = this.filtersMatchCluster(config.Config.CanaryClusterFilters)
+	this.IsReplicationTLSRequired = this.filtersMatchCluster(config.Config.ReplicationTLSRequiredClusterFilters)
+	this.IsRDSFailoverCluster = this.filtersMatchCluster(config.Config.RDSFailoverClusterFilters)
+	this.IsCloudSQLFailoverCluster = this.filtersMatchCluster(config.Config.CloudSQLFailoverClusterFilters)
+
+	this.PriorityTier = this.readPriorityTier()
+	this.IsMasterFlapping = IsClusterMasterFlapping(this.ClusterAlias)
+	if maintenance, err := ReadClusterMaintenance(this.ClusterAlias); err == nil && maintenance != nil {
+		this.IsInMaintenance = true
+		this.MaintenanceOwner = maintenance.Owner
+		this.MaintenanceReason = maintenance.Reason
+		this.MaintenanceEndTimestamp = maintenance.EndTimestamp
+	}
+	if templateName, err := ReadClusterTemplateName(this.ClusterAlias); err == nil {
+		this.ClusterTemplate = templateName
+	}
+	if template := this.GetClusterTemplate(); template != nil {
+		this.HasAutomatedMasterRecovery = this.HasAutomatedMasterRecovery || template.AutoMasterRecovery
+		this.HasAutomatedIntermediateMasterRecovery = this.HasAutomatedIntermediateMasterRecovery || template.AutoIntermediateMasterRecovery
+	}
+}
+
+// readPriorityTier returns the tier assigned to this cluster via API, or else the highest configured tier whose filters match this cluster
+func (this *ClusterInfo) readPriorityTier() string {
+	if tier, err := ReadClusterPriorityTier(this.ClusterAlias); err == nil && tier != "" {
+		return tier
+	}
+	switch {
+	case this.filtersMatchCluster(config.Config.PriorityTierP1ClusterFilters):
+		return ClusterPriorityTierP1
+	case this.filtersMatchCluster(config.Config.PriorityTierP2ClusterFilters):
+		return ClusterPriorityTierP2
+	case this.filtersMatchCluster(config.Config.PriorityTierP3ClusterFilters):
+		return ClusterPriorityTierP3
+	}
+	return ""
+}
+
+// GetClusterTemplate returns the configured template assigned to this cluster, or nil if none
+func (this *ClusterInfo) GetClusterTemplate() *config.ClusterTemplate {
+	return config.Config.GetClusterTemplate(this.ClusterTemplate)
+}
+
+// GetMasterKVPairs returns the KV pairs for given master of this cluster, respecting
+// the KV prefix of the cluster's template, if any
+func (this *ClusterInfo) GetMasterKVPairs(masterKey *InstanceKey) (kvPairs [](*kv.KVPair)) {
+	kvPrefix := config.Config.KVClusterMasterPrefix
+	if template := this.GetClusterTemplate(); template != nil && template.KVClusterMasterPrefix != "" {
+		kvPrefix = template.KVClusterMasterPrefix
+	}
+	return getPrefixedClusterMasterKVPairs(kvPrefix, this.ClusterAlias, masterKey)
+}
+
+// HealthProbes returns the configured master health probes applying to this cluster
+func (this *ClusterInfo) HealthProbes() (probes []config.HealthProbe) {
+	for _, probe := range config.Config.MasterHealthProbes {
+		if len(probe.ClusterFilters) == 0 || this.filtersMatchCluster(probe.ClusterFilters) {
+			probes = append(probes, probe)
+		}
+	}
+	return probes
+}
+
+// AnalysisHookProcesses returns the processes of the configured analysis hook sets applying to this cluster and given analysis
+func (this *ClusterInfo) AnalysisHookProcesses(analysis string) (processes []string) {
+	for _, hookSet := range config.Config.AnalysisHookSets {
+		if !hookSet.AppliesToAnalysis(analysis) {
+			continue
+		}
+		if len(hookSet.ClusterFilters) == 0 || this.filtersMatchCluster(hookSet.ClusterFilters) {
+			processes = append(processes, hookSet.Processes...)
+		}
+	}
+	return processes
+}
+
+// NotifyPolicyApplies checks whether given notification policy applies to this cluster
+func (this *ClusterInfo) NotifyPolicyApplies(policy *config.NotifyPolicy) bool {
+	return len(policy.ClusterFilters) == 0 || this.filtersMatchCluster(policy.ClusterFilters)
+}
+
+// AutoAckPolicyApplies checks whether given auto-acknowledge policy applies to this cluster
+func (this *ClusterInfo) AutoAckPolicyApplies(policy *config.AutoAckPolicy) bool {
+	return len(policy.ClusterFilters) == 0 || this.filtersMatchCluster(policy.ClusterFilters)
+}
+
+// ReplicaRemedy returns the first configured replica remedy applying to this cluster, or nil if none does
+func (this *ClusterInfo) ReplicaRemedy() *config.ReplicaRemedy {
+	for i := range config.Config.ReplicaRemedies {
+		remedy := &config.Config.ReplicaRemedies[i]
+		if len(remedy.ClusterFilters) == 0 || this.filtersMatchCluster(remedy.ClusterFilters) {
+			return remedy
+		}
+	}
+	return nil
+}
+
+// SemiSyncRemedy returns the first configured locked semi-sync master remedy applying to this cluster, or nil if none does
+func (this *ClusterInfo) SemiSyncRemedy() *config.SemiSyncRemedy {
+	for i := range config.Config.SemiSyncRemedies {
+		remedy := &config.Config.SemiSyncRemedies[i]
+		if len(remedy.ClusterFilters) == 0 || this.filtersMatchCluster(remedy.ClusterFilters) {
+			return remedy
+		}
+	}
+	return nil
+}
+
+// UnusableMasterPolicy returns the first configured unusable master policy applying to this cluster and given analysis, or nil if none does
+func (this *ClusterInfo) UnusableMasterPolicy(analysis string) *config.UnusablePolicy {
+	for i := range config.Config.UnusableMasterPolicies {
+		policy := &config.Config.UnusableMasterPolicies[i]
+		if !policy.AppliesToAnalysis(analysis) {
+			continue
+		}
+		if len(policy.ClusterFilters) == 0 || this.filtersMatchCluster(policy.ClusterFilters) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// DemotedMasterKillPolicy returns the first of DemotedMasterKillPolicies applying to this cluster, or nil
+func (this *ClusterInfo) DemotedMasterKillPolicy() *config.SQLKillPolicy {
+	for i := range config.Config.DemotedMasterKillPolicies {
+		policy := &config.Config.DemotedMasterKillPolicies[i]
+		if len(policy.ClusterFilters) == 0 || this.filtersMatchCluster(policy.ClusterFilters) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// HasReadPool checks whether orchestrator maintains a read pool for this cluster, per ReadPoolClusterFilters
+func (this *ClusterInfo) HasReadPool() bool {
+	return this.filtersMatchCluster(config.Config.ReadPoolClusterFilters)
+}
+
+// PromotionChecks returns the configured promotion checks applying to this cluster, in configuration order
+func (this *ClusterInfo) PromotionChecks() (checks []*config.PromotionCheck) {
+	for i := range config.Config.PromotionChecks {
+		check := &config.Config.PromotionChecks[i]
+		if len(check.ClusterFilters) == 0 || this.filtersMatchCluster(check.ClusterFilters) {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// PromotionCanary returns the first of PromotionCanaries applying to this cluster, or nil
+func (this *ClusterInfo) PromotionCanary() *config.PromotionCanary {
+	for i := range config.Config.PromotionCanaries {
+		canary := &config.Config.PromotionCanaries[i]
+		if len(canary.ClusterFilters) == 0 || this.filtersMatchCluster(canary.ClusterFilters) {
+			return canary
+		}
+	}
+	return nil
+}
+
+// MembershipRules returns the configured membership rules applying to this cluster, in configuration order
+func (this *ClusterInfo) MembershipRules() (rules []*config.MembershipRule) {
+	for i := range config.Config.MembershipRules {
+		rule := &config.Config.MembershipRules[i]
+		if len(rule.ClusterFilters) == 0 || this.filtersMatchCluster(rule.ClusterFilters) {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
 }
 
 // filtersMatchCluster will see whether the given filters match the given cluster details
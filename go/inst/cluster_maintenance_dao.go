@@ -0,0 +1,175 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var clusterMaintenanceCache = cache.New(10*time.Second, time.Second)
+
+// ClusterMaintenance is a cluster-wide maintenance mode, by cluster alias, during which automated recoveries
+// on the cluster are suppressed. Unlike downtime, it applies to the cluster as a whole, whichever its instances.
+type ClusterMaintenance struct {
+	ClusterAlias   string
+	Owner          string
+	Reason         string
+	Duration       time.Duration
+	BeginTimestamp string
+	EndTimestamp   string
+}
+
+func NewClusterMaintenance(clusterAlias string, owner string, reason string, duration time.Duration) *ClusterMaintenance {
+	return &ClusterMaintenance{
+		ClusterAlias: clusterAlias,
+		Owner:        owner,
+		Reason:       reason,
+		Duration:     duration,
+	}
+}
+
+// BeginClusterMaintenance puts a cluster in maintenance mode (or overrides existing maintenance)
+func BeginClusterMaintenance(maintenance *ClusterMaintenance) error {
+	if maintenance.ClusterAlias == "" {
+		return fmt.Errorf("BeginClusterMaintenance: empty cluster alias")
+	}
+	if maintenance.Duration <= 0 {
+		maintenance.Duration = config.MaintenanceExpireMinutes * time.Minute
+	}
+	_, err := db.ExecOrchestrator(`
+			insert
+				into cluster_maintenance (
+					cluster_alias, owner, reason, begin_timestamp, end_timestamp
+				) values (
+					?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+				)
+				on duplicate key update
+					owner=values(owner),
+					reason=values(reason),
+					begin_timestamp=values(begin_timestamp),
+					end_timestamp=values(end_timestamp)
+			`,
+		maintenance.ClusterAlias,
+		maintenance.Owner,
+		maintenance.Reason,
+		int64(maintenance.Duration.Seconds()),
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	clusterMaintenanceCache.Delete(maintenance.ClusterAlias)
+	AuditOperation("begin-cluster-maintenance", nil, fmt.Sprintf("cluster: %s, owner: %s, reason: %s, duration: %+v", maintenance.ClusterAlias, maintenance.Owner, maintenance.Reason, maintenance.Duration))
+	return nil
+}
+
+// EndClusterMaintenance takes a cluster out of maintenance mode
+func EndClusterMaintenance(clusterAlias string) (wasInMaintenance bool, err error) {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				cluster_maintenance
+			where
+				cluster_alias = ?
+			`,
+		clusterAlias,
+	)
+	if err != nil {
+		return wasInMaintenance, log.Errore(err)
+	}
+	clusterMaintenanceCache.Delete(clusterAlias)
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		wasInMaintenance = true
+		AuditOperation("end-cluster-maintenance", nil, fmt.Sprintf("cluster: %s", clusterAlias))
+	}
+	return wasInMaintenance, nil
+}
+
+func readClusterMaintenance(whereCondition string, args []interface{}) (result []ClusterMaintenance, err error) {
+	query := fmt.Sprintf(`
+		select
+			cluster_alias,
+			owner,
+			reason,
+			begin_timestamp,
+			end_timestamp
+		from
+			cluster_maintenance
+		where
+			end_timestamp > NOW()
+			%s
+		order by
+			cluster_alias
+		`, whereCondition)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		maintenance := ClusterMaintenance{}
+		maintenance.ClusterAlias = m.GetString("cluster_alias")
+		maintenance.Owner = m.GetString("owner")
+		maintenance.Reason = m.GetString("reason")
+		maintenance.BeginTimestamp = m.GetString("begin_timestamp")
+		maintenance.EndTimestamp = m.GetString("end_timestamp")
+
+		result = append(result, maintenance)
+		return nil
+	})
+	return result, log.Errore(err)
+}
+
+// ReadActiveClusterMaintenance reads all clusters currently in maintenance mode
+func ReadActiveClusterMaintenance() ([]ClusterMaintenance, error) {
+	return readClusterMaintenance(``, sqlutils.Args())
+}
+
+// ReadClusterMaintenance returns the active maintenance of given cluster, or nil when the cluster is not in maintenance
+func ReadClusterMaintenance(clusterAlias string) (*ClusterMaintenance, error) {
+	if maintenance, found := clusterMaintenanceCache.Get(clusterAlias); found {
+		return maintenance.(*ClusterMaintenance), nil
+	}
+	result, err := readClusterMaintenance(`and cluster_alias = ?`, sqlutils.Args(clusterAlias))
+	if err != nil {
+		return nil, err
+	}
+	var maintenance *ClusterMaintenance
+	if len(result) > 0 {
+		maintenance = &result[0]
+	}
+	clusterMaintenanceCache.Set(clusterAlias, maintenance, cache.DefaultExpiration)
+	return maintenance, nil
+}
+
+// ExpireClusterMaintenance removes ended cluster maintenance
+func ExpireClusterMaintenance() error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				cluster_maintenance
+			where
+				end_timestamp < NOW()
+			`,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+		AuditOperation("expire-cluster-maintenance", nil, fmt.Sprintf("Expired %d entries", rowsAffected))
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+// Recovery priority tiers. When multiple clusters fail at once, clusters of higher tiers are recovered first.
+const (
+	ClusterPriorityTierP1 = "P1"
+	ClusterPriorityTierP2 = "P2"
+	ClusterPriorityTierP3 = "P3"
+)
+
+var clusterPriorityTierRanks = map[string]int{
+	ClusterPriorityTierP1: 1,
+	ClusterPriorityTierP2: 2,
+	ClusterPriorityTierP3: 3,
+}
+
+var clusterPriorityTierCache = cache.New(time.Minute, time.Second)
+
+// IsValidClusterPriorityTier checks whether given tier is a known priority tier
+func IsValidClusterPriorityTier(tier string) bool {
+	_, found := clusterPriorityTierRanks[tier]
+	return found
+}
+
+// ClusterPriorityTierRank returns the recovery order of given tier: lower ranks are recovered first,
+// and clusters with no tier are recovered last
+func ClusterPriorityTierRank(tier string) int {
+	if rank, found := clusterPriorityTierRanks[tier]; found {
+		return rank
+	}
+	return len(clusterPriorityTierRanks) + 1
+}
+
+// ReadClusterPriorityTier returns the priority tier explicitly assigned to given cluster alias, or empty string if none
+func ReadClusterPriorityTier(clusterAlias string) (tier string, err error) {
+	if tier, found := clusterPriorityTierCache.Get(clusterAlias); found {
+		return tier.(string), nil
+	}
+	query := `
+		select
+			priority_tier
+		from
+			cluster_priority_tier
+		where
+			cluster_alias = ?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterAlias), func(m sqlutils.RowMap) error {
+		tier = m.GetString("priority_tier")
+		return nil
+	})
+	if err != nil {
+		return "", log.Errore(err)
+	}
+	clusterPriorityTierCache.Set(clusterAlias, tier, cache.DefaultExpiration)
+	return tier, nil
+}
+
+// WriteClusterPriorityTier assigns a priority tier to a cluster alias, overriding configured tiers.
+// An empty tier removes the assignment.
+func WriteClusterPriorityTier(clusterAlias string, tier string) error {
+	if tier != "" && !IsValidClusterPriorityTier(tier) {
+		return fmt.Errorf("Unknown cluster priority tier: %s", tier)
+	}
+	writeFunc := func() error {
+		var err error
+		if tier == "" {
+			_, err = db.ExecOrchestrator(`
+				delete from cluster_priority_tier where cluster_alias = ?
+				`, clusterAlias)
+		} else {
+			_, err = db.ExecOrchestrator(`
+				insert into
+						cluster_priority_tier (cluster_alias, priority_tier, assigned_timestamp)
+					values
+						(?, ?, NOW())
+					on duplicate key update
+						priority_tier=values(priority_tier),
+						assigned_timestamp=values(assigned_timestamp)
+				`, clusterAlias, tier)
+		}
+		if err == nil {
+			clusterPriorityTierCache.Set(clusterAlias, tier, cache.DefaultExpiration)
+			AuditOperation("set-cluster-priority-tier", nil, fmt.Sprintf("cluster %s priority tier: %s", clusterAlias, tier))
+		}
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
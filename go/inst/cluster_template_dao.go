@@ -0,0 +1,151 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var clusterTemplateNameCache = cache.New(time.Minute, time.Second)
+
+// ReadClusterTemplateName returns the name of the template assigned to given cluster alias, or empty string if none
+func ReadClusterTemplateName(clusterAlias string) (templateName string, err error) {
+	if templateName, found := clusterTemplateNameCache.Get(clusterAlias); found {
+		return templateName.(string), nil
+	}
+	query := `
+		select
+			template_name
+		from
+			cluster_template
+		where
+			cluster_alias = ?
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterAlias), func(m sqlutils.RowMap) error {
+		templateName = m.GetString("template_name")
+		return nil
+	})
+	if err != nil {
+		return "", log.Errore(err)
+	}
+	clusterTemplateNameCache.Set(clusterAlias, templateName, cache.DefaultExpiration)
+	return templateName, nil
+}
+
+// ReadClusterTemplate returns the configured template assigned to given cluster alias, or nil if none
+func ReadClusterTemplate(clusterAlias string) (*config.ClusterTemplate, error) {
+	templateName, err := ReadClusterTemplateName(clusterAlias)
+	if err != nil {
+		return nil, err
+	}
+	return config.Config.GetClusterTemplate(templateName), nil
+}
+
+// WriteClusterTemplate assigns a template to a cluster alias, overriding any existing assignment. The assignment
+// is kept by the alias, such that it survives failovers, which change the cluster's name.
+func WriteClusterTemplate(clusterAlias string, templateName string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into
+					cluster_template (cluster_alias, template_name, assigned_timestamp)
+				values
+					(?, ?, NOW())
+				on duplicate key update
+					template_name=values(template_name),
+					assigned_timestamp=values(assigned_timestamp)
+			`,
+			clusterAlias, templateName)
+		if err == nil {
+			clusterTemplateNameCache.Set(clusterAlias, templateName, cache.DefaultExpiration)
+		}
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadUnassignedClusterMasters returns the masters of clusters which have no template assigned
+func ReadUnassignedClusterMasters() (masterKeys []InstanceKey, err error) {
+	query := `
+		select
+			database_instance.hostname,
+			database_instance.port
+		from
+			database_instance
+			left join cluster_alias on (database_instance.cluster_name = cluster_alias.cluster_name)
+			left join cluster_template on (ifnull(cluster_alias.alias, database_instance.cluster_name) = cluster_template.cluster_alias)
+		where
+			cluster_template.cluster_alias is null
+			and concat(database_instance.hostname, ':', database_instance.port) = replace(replace(database_instance.cluster_name, '[', ''), ']', '')
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		masterKeys = append(masterKeys, InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")})
+		return nil
+	})
+	return masterKeys, log.Errore(err)
+}
+
+// MatchClusterTemplate returns the first configured template matching given master, or nil if none matches
+func MatchClusterTemplate(masterKey *InstanceKey) *config.ClusterTemplate {
+	for i := range config.Config.ClusterTemplates {
+		if config.Config.ClusterTemplates[i].MatchesMaster(masterKey.StringCode()) {
+			return &config.Config.ClusterTemplates[i]
+		}
+	}
+	return nil
+}
+
+// ApplyClusterTemplate assigns a template to a cluster, and applies the template's tags onto the cluster's master
+func ApplyClusterTemplate(clusterName string, templateName string) error {
+	template := config.Config.GetClusterTemplate(templateName)
+	if template == nil {
+		return fmt.Errorf("Unknown cluster template: %s", templateName)
+	}
+	masters, err := ReadClusterMaster(clusterName)
+	if err != nil {
+		return err
+	}
+	if len(masters) == 0 {
+		return fmt.Errorf("Cannot find master for cluster %s", clusterName)
+	}
+	masterKey := &masters[0].Key
+	clusterInfo, err := ReadClusterInfo(clusterName)
+	if err != nil {
+		return err
+	}
+	if err := WriteClusterTemplate(clusterInfo.ClusterAlias, template.Name); err != nil {
+		return err
+	}
+	for tagName, tagValue := range template.Tags {
+		tag, err := NewTag(tagName, tagValue)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		if err := PutInstanceTag(masterKey, tag); err != nil {
+			log.Errore(err)
+		}
+	}
+	AuditOperation("apply-cluster-template", masterKey, fmt.Sprintf("applied template %s onto cluster %s", template.Name, clusterName))
+	return nil
+}
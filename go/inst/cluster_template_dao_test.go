@@ -0,0 +1,72 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	test "github.com/openark/golib/tests"
+)
+
+// withSQLiteBackend runs given function against an in-memory SQLite backend
+func withSQLiteBackend(t *testing.T, f func()) {
+	backendDB, dataFile := config.Config.BackendDB, config.Config.SQLite3DataFile
+	defer func() {
+		config.Config.BackendDB, config.Config.SQLite3DataFile = backendDB, dataFile
+	}()
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = ":memory:"
+	if _, err := db.OpenOrchestrator(); err != nil {
+		t.Fatal(err)
+	}
+	f()
+}
+
+func TestClusterTemplateSurvivesFailover(t *testing.T) {
+	templates := config.Config.ClusterTemplates
+	defer func() { config.Config.ClusterTemplates = templates }()
+	config.Config.ClusterTemplates = []config.ClusterTemplate{{Name: "orders-template", AutoMasterRecovery: true}}
+
+	withSQLiteBackend(t, func() {
+		master := NewInstance()
+		master.Key = InstanceKey{Hostname: "db-1", Port: 3306}
+		master.ClusterName = "db-1:3306"
+		test.S(t).ExpectNil(writeManyInstances([]*Instance{master}, true, true))
+		test.S(t).ExpectNil(writeClusterAlias("db-1:3306", "orders"))
+		test.S(t).ExpectNil(WriteClusterTemplate("orders", "orders-template"))
+
+		// Failover: db-2 is the new master, and the cluster is renamed after it
+		promoted := NewInstance()
+		promoted.Key = InstanceKey{Hostname: "db-2", Port: 3306}
+		promoted.ClusterName = "db-2:3306"
+		test.S(t).ExpectNil(writeManyInstances([]*Instance{promoted}, true, true))
+		test.S(t).ExpectNil(ForgetInstance(&master.Key))
+		test.S(t).ExpectNil(writeClusterAlias("db-2:3306", "orders"))
+		clusterTemplateNameCache.Flush()
+
+		clusterInfo := &ClusterInfo{ClusterName: "db-2:3306", ClusterAlias: "orders"}
+		clusterInfo.ReadRecoveryInfo()
+		test.S(t).ExpectEquals(clusterInfo.ClusterTemplate, "orders-template")
+		test.S(t).ExpectTrue(clusterInfo.HasAutomatedMasterRecovery)
+
+		masterKeys, err := ReadUnassignedClusterMasters()
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(masterKeys), 0)
+	})
+}
@@ -81,3 +81,89 @@ func TestGetClusterMasterKVPairs2(t *testing.T) {
 	kvPairs := GetClusterMasterKVPairs("", &masterKey)
 	test.S(t).ExpectEquals(len(kvPairs), 0)
 }
+
+func TestClusterPriorityTierRank(t *testing.T) {
+	test.S(t).ExpectTrue(ClusterPriorityTierRank(ClusterPriorityTierP1) < ClusterPriorityTierRank(ClusterPriorityTierP2))
+	test.S(t).ExpectTrue(ClusterPriorityTierRank(ClusterPriorityTierP2) < ClusterPriorityTierRank(ClusterPriorityTierP3))
+	test.S(t).ExpectTrue(ClusterPriorityTierRank(ClusterPriorityTierP3) < ClusterPriorityTierRank(""))
+	test.S(t).ExpectEquals(ClusterPriorityTierRank("P9"), ClusterPriorityTierRank(""))
+	test.S(t).ExpectTrue(IsValidClusterPriorityTier("P2"))
+	test.S(t).ExpectFalse(IsValidClusterPriorityTier(""))
+}
+
+func TestAutoAckPolicyApplies(t *testing.T) {
+	clusterInfo := &ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+	policy := &config.AutoAckPolicy{Analysis: []string{"DeadIntermediateMaster"}, AfterMinutes: 240}
+	test.S(t).ExpectTrue(clusterInfo.AutoAckPolicyApplies(policy))
+	test.S(t).ExpectTrue(policy.AppliesToAnalysis("DeadIntermediateMaster"))
+	test.S(t).ExpectFalse(policy.AppliesToAnalysis("DeadMaster"))
+
+	policy.ClusterFilters = []string{"alias=main"}
+	test.S(t).ExpectTrue(clusterInfo.AutoAckPolicyApplies(policy))
+	policy.ClusterFilters = []string{"alias=other", "db-other"}
+	test.S(t).ExpectFalse(clusterInfo.AutoAckPolicyApplies(policy))
+
+	policy.Analysis = []string{}
+	test.S(t).ExpectTrue(policy.AppliesToAnalysis("DeadMaster"))
+}
+
+func TestAnalysisHookProcesses(t *testing.T) {
+	config.Config.AnalysisHookSets = []config.AnalysisHookSet{
+		{Analysis: []string{"UnreachableMasterWithLaggingReplicas"}, Processes: []string{"diagnose {failedHost}"}},
+		{Analysis: []string{"UnreachableMasterWithLaggingReplicas", "CustomFewReplicas"}, ClusterFilters: []string{"alias=main"}, Processes: []string{"page {failureCluster}"}},
+	}
+	defer func() { config.Config.AnalysisHookSets = []config.AnalysisHookSet{} }()
+
+	mainCluster := &ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+	otherCluster := &ClusterInfo{ClusterName: "db-other-1:3306", ClusterAlias: "other"}
+	test.S(t).ExpectEquals(len(mainCluster.AnalysisHookProcesses("UnreachableMasterWithLaggingReplicas")), 2)
+	test.S(t).ExpectEquals(len(otherCluster.AnalysisHookProcesses("UnreachableMasterWithLaggingReplicas")), 1)
+	test.S(t).ExpectEquals(len(mainCluster.AnalysisHookProcesses("CustomFewReplicas")), 1)
+	test.S(t).ExpectEquals(len(otherCluster.AnalysisHookProcesses("CustomFewReplicas")), 0)
+	test.S(t).ExpectEquals(len(mainCluster.AnalysisHookProcesses("DeadMaster")), 0)
+}
+
+func TestReplicaRemedy(t *testing.T) {
+	config.Config.ReplicaRemedies = []config.ReplicaRemedy{
+		{ClusterFilters: []string{"alias=main"}, SkipSQLErrnos: []int{1062}},
+		{RestartSQLErrnos: []int{1205}},
+	}
+	defer func() { config.Config.ReplicaRemedies = []config.ReplicaRemedy{} }()
+
+	mainCluster := &ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+	otherCluster := &ClusterInfo{ClusterName: "db-other-1:3306", ClusterAlias: "other"}
+	test.S(t).ExpectEquals(mainCluster.ReplicaRemedy().SQLErrnoAction(1062), config.ReplicaRemedySkip)
+	test.S(t).ExpectEquals(otherCluster.ReplicaRemedy().SQLErrnoAction(1062), "")
+	test.S(t).ExpectEquals(otherCluster.ReplicaRemedy().SQLErrnoAction(1205), config.ReplicaRemedyRestart)
+
+	config.Config.ReplicaRemedies = config.Config.ReplicaRemedies[:1]
+	test.S(t).ExpectTrue(otherCluster.ReplicaRemedy() == nil)
+}
+
+func TestUnusableMasterPolicy(t *testing.T) {
+	config.Config.UnusableMasterPolicies = []config.UnusablePolicy{
+		{ClusterFilters: []string{"alias=main"}, Analyses: []string{DiskFullMaster}},
+		{ClusterFilters: []string{"alias=main"}, MinDurationSeconds: 30},
+	}
+	defer func() { config.Config.UnusableMasterPolicies = []config.UnusablePolicy{} }()
+
+	mainCluster := &ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+	otherCluster := &ClusterInfo{ClusterName: "db-other-1:3306", ClusterAlias: "other"}
+	test.S(t).ExpectEquals(mainCluster.UnusableMasterPolicy(DiskFullMaster).MinDuration(), uint(60))
+	test.S(t).ExpectEquals(mainCluster.UnusableMasterPolicy(TooManyConnectionsMaster).MinDuration(), uint(30))
+	test.S(t).ExpectTrue(otherCluster.UnusableMasterPolicy(DiskFullMaster) == nil)
+}
+
+func TestPromotionChecks(t *testing.T) {
+	config.Config.PromotionChecks = []config.PromotionCheck{
+		{Query: "select @@global.read_only = 0"},
+		{ClusterFilters: []string{"alias=main"}, Query: "insert into meta.canary values (now())"},
+	}
+	defer func() { config.Config.PromotionChecks = []config.PromotionCheck{} }()
+
+	mainCluster := &ClusterInfo{ClusterName: "db-main-1:3306", ClusterAlias: "main"}
+	otherCluster := &ClusterInfo{ClusterName: "db-other-1:3306", ClusterAlias: "other"}
+	test.S(t).ExpectEquals(len(mainCluster.PromotionChecks()), 2)
+	test.S(t).ExpectEquals(len(otherCluster.PromotionChecks()), 1)
+	test.S(t).ExpectEquals(otherCluster.PromotionChecks()[0].Query, "select @@global.read_only = 0")
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// dashboardMaxLayoutBytes caps the size of a dashboard's layout
+const dashboardMaxLayoutBytes = 64 * 1024
+
+// dashboardMaxPinnedClusters caps the number of clusters pinned to a dashboard
+const dashboardMaxPinnedClusters = 500
+
+var dashboardNameRegexp = regexp.MustCompile(`^[\w.-]{1,128}$`)
+
+// DashboardProblemFilters select the problems a dashboard shows; empty filters show all problems
+type DashboardProblemFilters struct {
+	Analysis         []string // analysis codes, e.g. "DeadMaster"
+	Clusters         []string // cluster names or aliases
+	IncludeDowntimed bool
+}
+
+// Dashboard is a user's saved view in the web interface: pinned clusters, problem filters, and layout
+type Dashboard struct {
+	Owner          string
+	Name           string
+	PinnedClusters []string // cluster names or aliases
+	ProblemFilters DashboardProblemFilters
+	Layout         json.RawMessage // owned by the web interface, stored as is
+	LastUpdated    string
+}
+
+// Validate checks the dashboard is well formed
+func (this *Dashboard) Validate() error {
+	if !dashboardNameRegexp.MatchString(this.Name) {
+		return fmt.Errorf("Invalid dashboard name %q; expecting up to 128 letters, digits, '_', '.', '-'", this.Name)
+	}
+	if len(this.PinnedClusters) > dashboardMaxPinnedClusters {
+		return fmt.Errorf("Dashboard %s pins %d clusters; at most %d are allowed", this.Name, len(this.PinnedClusters), dashboardMaxPinnedClusters)
+	}
+	if len(this.Layout) > dashboardMaxLayoutBytes {
+		return fmt.Errorf("Dashboard %s layout is %d bytes; at most %d are allowed", this.Name, len(this.Layout), dashboardMaxLayoutBytes)
+	}
+	if len(this.Layout) > 0 && !json.Valid(this.Layout) {
+		return fmt.Errorf("Dashboard %s layout is not valid JSON", this.Name)
+	}
+	return nil
+}
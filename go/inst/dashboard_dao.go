@@ -0,0 +1,123 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// dashboardContent is the stored part of a dashboard beyond its owner and name
+type dashboardContent struct {
+	PinnedClusters []string
+	ProblemFilters DashboardProblemFilters
+	Layout         json.RawMessage
+}
+
+// WriteDashboard creates or replaces a user's dashboard
+func WriteDashboard(dashboard *Dashboard) error {
+	if err := dashboard.Validate(); err != nil {
+		return err
+	}
+	content, err := json.Marshal(&dashboardContent{
+		PinnedClusters: dashboard.PinnedClusters,
+		ProblemFilters: dashboard.ProblemFilters,
+		Layout:         dashboard.Layout,
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			replace
+				into user_dashboard (
+					owner, dashboard_name, content, last_updated
+				) values (
+					?, ?, ?, now()
+				)
+			`,
+		dashboard.Owner, dashboard.Name, string(content),
+	)
+	return log.Errore(err)
+}
+
+// DeleteDashboard removes a user's dashboard
+func DeleteDashboard(owner string, name string) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				user_dashboard
+			where
+				owner = ?
+				and dashboard_name = ?
+			`, owner, name,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("Dashboard %s not found", name)
+	}
+	return nil
+}
+
+// readDashboards reads dashboards by given condition
+func readDashboards(whereCondition string, args []interface{}) (dashboards []Dashboard, err error) {
+	dashboards = []Dashboard{}
+	query := fmt.Sprintf(`
+		select
+			owner, dashboard_name, content, last_updated
+		from
+			user_dashboard
+		where
+			%s
+		order by
+			dashboard_name
+		`, whereCondition)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		content := dashboardContent{}
+		if err := json.Unmarshal([]byte(m.GetString("content")), &content); err != nil {
+			return log.Errore(err)
+		}
+		dashboards = append(dashboards, Dashboard{
+			Owner:          m.GetString("owner"),
+			Name:           m.GetString("dashboard_name"),
+			PinnedClusters: content.PinnedClusters,
+			ProblemFilters: content.ProblemFilters,
+			Layout:         content.Layout,
+			LastUpdated:    m.GetString("last_updated"),
+		})
+		return nil
+	})
+	return dashboards, log.Errore(err)
+}
+
+// ReadDashboards reads the dashboards of given user
+func ReadDashboards(owner string) ([]Dashboard, error) {
+	return readDashboards(`owner = ?`, sqlutils.Args(owner))
+}
+
+// ReadDashboard reads a user's dashboard by name
+func ReadDashboard(owner string, name string) (dashboard *Dashboard, found bool, err error) {
+	dashboards, err := readDashboards(`owner = ? and dashboard_name = ?`, sqlutils.Args(owner, name))
+	if err != nil || len(dashboards) == 0 {
+		return nil, false, err
+	}
+	return &dashboards[0], true, nil
+}
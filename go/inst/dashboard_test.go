@@ -0,0 +1,32 @@
+package inst
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestDashboardValidate(t *testing.T) {
+	{
+		dashboard := &Dashboard{Name: "payments-oncall", PinnedClusters: []string{"payments"}, Layout: json.RawMessage(`{"columns":2}`)}
+		test.S(t).ExpectNil(dashboard.Validate())
+	}
+	{
+		dashboard := &Dashboard{Name: "payments.oncall_2"}
+		test.S(t).ExpectNil(dashboard.Validate())
+	}
+	for _, name := range []string{"", "my dashboard", "a/b", strings.Repeat("a", 129)} {
+		dashboard := &Dashboard{Name: name}
+		test.S(t).ExpectNotNil(dashboard.Validate())
+	}
+	{
+		dashboard := &Dashboard{Name: "payments", Layout: json.RawMessage(`{"columns":`)}
+		test.S(t).ExpectNotNil(dashboard.Validate())
+	}
+	{
+		dashboard := &Dashboard{Name: "payments", Layout: json.RawMessage(`"` + strings.Repeat("a", dashboardMaxLayoutBytes) + `"`)}
+		test.S(t).ExpectNotNil(dashboard.Validate())
+	}
+}
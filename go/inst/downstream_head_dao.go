@@ -0,0 +1,122 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// DownstreamHead is an instance replicating from a master via a named replication channel, rather than as a
+// replica in the master's cluster. Typically it is the master of another, downstream, cluster.
+type DownstreamHead struct {
+	Key         InstanceKey
+	ClusterName string
+	Channel     string
+}
+
+// ReadDownstreamHeads reads the instances replicating from given master via a named replication channel, as
+// identified by the channel's source server UUID
+func ReadDownstreamHeads(master *Instance) (heads []DownstreamHead, err error) {
+	heads = []DownstreamHead{}
+	if master.ServerUUID == "" {
+		return heads, nil
+	}
+	condition := `replication_channels like ?`
+	instances, err := readInstancesByCondition(condition, sqlutils.Args(fmt.Sprintf(`%%"SourceUUID":"%s"%%`, master.ServerUUID)), "")
+	if err != nil {
+		return heads, err
+	}
+	for _, instance := range instances {
+		for _, channel := range instance.ReplicationChannels.NamedChannelsFrom(master.ServerUUID) {
+			heads = append(heads, DownstreamHead{Key: instance.Key, ClusterName: instance.ClusterName, Channel: channel})
+		}
+	}
+	return heads, nil
+}
+
+// ReadChannelExecBinlogCoordinates reads the master's binlog coordinates up to which given instance has applied
+// via a named replication channel
+func ReadChannelExecBinlogCoordinates(instanceKey *InstanceKey, channel string) (coordinates *BinlogCoordinates, err error) {
+	sqlDb, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	err = sqlutils.QueryRowsMap(sqlDb, "show slave status for channel ?", func(m sqlutils.RowMap) error {
+		coordinates = &BinlogCoordinates{
+			LogFile: m.GetString("Relay_Master_Log_File"),
+			LogPos:  m.GetInt64("Exec_Master_Log_Pos"),
+		}
+		return nil
+	}, channel)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if coordinates == nil {
+		return nil, fmt.Errorf("ReadChannelExecBinlogCoordinates: %+v has no channel %s", *instanceKey, channel)
+	}
+	return coordinates, nil
+}
+
+// RepointReplicationChannel points a named replication channel of given instance at a new master. The channel must
+// replicate with GTID auto positioning, as there are no binlog coordinates to carry over.
+func RepointReplicationChannel(instanceKey *InstanceKey, channel string, masterKey *InstanceKey) error {
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting repoint-channel operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	sqlDb, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return log.Errore(err)
+	}
+	var autoPosition bool
+	err = sqlDb.QueryRow("select auto_position = '1' from performance_schema.replication_connection_configuration where channel_name = ?", channel).Scan(&autoPosition)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("RepointReplicationChannel: %+v has no channel %s", *instanceKey, channel)
+	}
+	if err != nil {
+		return log.Errore(err)
+	}
+	if !autoPosition {
+		return fmt.Errorf("RepointReplicationChannel: channel %s on %+v does not use GTID auto positioning", channel, *instanceKey)
+	}
+	changeToMasterKey, reported := reportedKeyOf(masterKey)
+	if !reported {
+		unresolvedMasterKey, _, err := UnresolveHostname(masterKey)
+		if err != nil {
+			return log.Errore(err)
+		}
+		changeToMasterKey = &unresolvedMasterKey
+	}
+
+	if _, err := ExecInstance(instanceKey, "stop slave for channel ?", channel); err != nil {
+		return log.Errore(err)
+	}
+	if _, err := ExecInstance(instanceKey, "change master to master_host=?, master_port=? for channel ?", changeToMasterKey.Hostname, changeToMasterKey.Port, channel); err != nil {
+		return log.Errore(err)
+	}
+	if _, err := ExecInstance(instanceKey, "start slave for channel ?", channel); err != nil {
+		return log.Errore(err)
+	}
+	log.Infof("instance %+v channel %s repointed to %+v", *instanceKey, channel, *masterKey)
+	AuditOperation("repoint-channel", instanceKey, fmt.Sprintf("channel %s repointed to %+v", channel, *masterKey))
+	return nil
+}
@@ -20,6 +20,11 @@ import (
 	"time"
 )
 
+const (
+	// DowntimeExtendWhileBackupLock extends a downtime while a backup lock is held on the instance
+	DowntimeExtendWhileBackupLock = "backup-lock"
+)
+
 type Downtime struct {
 	Key            *InstanceKey
 	Owner          string
@@ -29,6 +34,7 @@ type Downtime struct {
 	EndsAt         time.Time
 	BeginsAtString string
 	EndsAtString   string
+	ExtendWhile    string // condition under which the downtime is extended, e.g. DowntimeExtendWhileBackupLock
 }
 
 func NewDowntime(instanceKey *InstanceKey, owner string, reason string, duration time.Duration) *Downtime {
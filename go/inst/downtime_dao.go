@@ -35,16 +35,17 @@ func BeginDowntime(downtime *Downtime) (err error) {
 		_, err = db.ExecOrchestrator(`
 				insert
 					into database_instance_downtime (
-						hostname, port, downtime_active, begin_timestamp, end_timestamp, owner, reason
+						hostname, port, downtime_active, begin_timestamp, end_timestamp, owner, reason, extend_while
 					) VALUES (
-						?, ?, 1, ?, ?, ?, ?
+						?, ?, 1, ?, ?, ?, ?, ?
 					)
 					on duplicate key update
 						downtime_active=values(downtime_active),
 						begin_timestamp=values(begin_timestamp),
 						end_timestamp=values(end_timestamp),
 						owner=values(owner),
-						reason=values(reason)
+						reason=values(reason),
+						extend_while=values(extend_while)
 				`,
 			downtime.Key.Hostname,
 			downtime.Key.Port,
@@ -52,6 +53,7 @@ func BeginDowntime(downtime *Downtime) (err error) {
 			downtime.EndsAtString,
 			downtime.Owner,
 			downtime.Reason,
+			downtime.ExtendWhile,
 		)
 	} else {
 		if downtime.Ended() {
@@ -62,28 +64,34 @@ func BeginDowntime(downtime *Downtime) (err error) {
 		_, err = db.ExecOrchestrator(`
 			insert
 				into database_instance_downtime (
-					hostname, port, downtime_active, begin_timestamp, end_timestamp, owner, reason
+					hostname, port, downtime_active, begin_timestamp, end_timestamp, owner, reason, extend_while
 				) VALUES (
-					?, ?, 1, NOW(), NOW() + INTERVAL ? SECOND, ?, ?
+					?, ?, 1, NOW(), NOW() + INTERVAL ? SECOND, ?, ?, ?
 				)
 				on duplicate key update
 					downtime_active=values(downtime_active),
 					begin_timestamp=values(begin_timestamp),
 					end_timestamp=values(end_timestamp),
 					owner=values(owner),
-					reason=values(reason)
+					reason=values(reason),
+					extend_while=values(extend_while)
 			`,
 			downtime.Key.Hostname,
 			downtime.Key.Port,
 			int(downtime.EndsIn().Seconds()),
 			downtime.Owner,
 			downtime.Reason,
+			downtime.ExtendWhile,
 		)
 	}
 	if err != nil {
 		return log.Errore(err)
 	}
-	AuditOperation("begin-downtime", downtime.Key, fmt.Sprintf("owner: %s, reason: %s", downtime.Owner, downtime.Reason))
+	if downtime.ExtendWhile == "" {
+		AuditOperation("begin-downtime", downtime.Key, fmt.Sprintf("owner: %s, reason: %s", downtime.Owner, downtime.Reason))
+	} else {
+		AuditOperation("begin-downtime", downtime.Key, fmt.Sprintf("owner: %s, reason: %s, extended while: %s", downtime.Owner, downtime.Reason, downtime.ExtendWhile))
+	}
 
 	return nil
 }
@@ -177,11 +185,74 @@ func expireLostInRecoveryDowntime() error {
 	return nil
 }
 
+// isBackupLockHeld checks whether a backup lock (LOCK INSTANCE FOR BACKUP) is held on given instance
+func isBackupLockHeld(instanceKey *InstanceKey) (bool, error) {
+	count := 0
+	query := `select count(*) from performance_schema.metadata_locks where object_type = 'BACKUP LOCK' and lock_status = 'GRANTED'`
+	if err := ScanInstanceRow(instanceKey, query, &count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// downtimeExtensionConditionHolds checks whether the condition by which given downtime is to be extended holds
+func downtimeExtensionConditionHolds(downtime *Downtime) (bool, error) {
+	switch downtime.ExtendWhile {
+	case DowntimeExtendWhileBackupLock:
+		return isBackupLockHeld(downtime.Key)
+	}
+	return false, fmt.Errorf("Unknown downtime extension condition: %q", downtime.ExtendWhile)
+}
+
+// extendConditionalDowntime extends, by DowntimeAutoExtensionMinutes, downtimes with less than half that time
+// remaining, for as long as their extension condition holds
+func extendConditionalDowntime() error {
+	downtimes, err := readDowntimeByCondition(`
+			and extend_while != ''
+			and end_timestamp < now() + interval ? second
+		`, sqlutils.Args(config.Config.DowntimeAutoExtensionMinutes*30))
+	if err != nil {
+		return err
+	}
+	for _, downtime := range downtimes {
+		holds, err := downtimeExtensionConditionHolds(&downtime)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		if !holds {
+			continue
+		}
+		_, err = db.ExecOrchestrator(`
+				update
+					database_instance_downtime
+				set
+					end_timestamp = NOW() + INTERVAL ? MINUTE
+				where
+					hostname = ?
+					and port = ?
+				`,
+			config.Config.DowntimeAutoExtensionMinutes,
+			downtime.Key.Hostname,
+			downtime.Key.Port,
+		)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		AuditOperation("extend-downtime", downtime.Key, fmt.Sprintf("%s: extended by %d minutes", downtime.ExtendWhile, config.Config.DowntimeAutoExtensionMinutes))
+	}
+	return nil
+}
+
 // ExpireDowntime will remove the maintenance flag on old downtimes
 func ExpireDowntime() error {
 	if err := renewLostInRecoveryDowntime(); err != nil {
 		return log.Errore(err)
 	}
+	if err := extendConditionalDowntime(); err != nil {
+		return log.Errore(err)
+	}
 	if err := expireLostInRecoveryDowntime(); err != nil {
 		return log.Errore(err)
 	}
@@ -204,21 +275,23 @@ func ExpireDowntime() error {
 	return nil
 }
 
-func ReadDowntime() (result []Downtime, err error) {
-	query := `
+func readDowntimeByCondition(condition string, args []interface{}) (result []Downtime, err error) {
+	query := fmt.Sprintf(`
 		select
 			hostname,
 			port,
 			begin_timestamp,
 			end_timestamp,
 			owner,
-			reason
+			reason,
+			extend_while
 		from
 			database_instance_downtime
 		where
 			end_timestamp > now()
-		`
-	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+			%s
+		`, condition)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		downtime := Downtime{
 			Key: &InstanceKey{},
 		}
@@ -230,6 +303,7 @@ func ReadDowntime() (result []Downtime, err error) {
 		downtime.EndsAtString = m.GetString("end_timestamp")
 		downtime.Owner = m.GetString("owner")
 		downtime.Reason = m.GetString("reason")
+		downtime.ExtendWhile = m.GetString("extend_while")
 
 		downtime.Duration = downtime.EndsAt.Sub(downtime.BeginsAt)
 
@@ -238,3 +312,7 @@ func ReadDowntime() (result []Downtime, err error) {
 	})
 	return result, log.Errore(err)
 }
+
+func ReadDowntime() (result []Downtime, err error) {
+	return readDowntimeByCondition(``, sqlutils.Args())
+}
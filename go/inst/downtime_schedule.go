@@ -0,0 +1,145 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/util"
+)
+
+const downtimeScheduleStartTimeLayout = "15:04"
+
+var downtimeScheduleWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// DowntimeSchedule is a recurring downtime window, e.g. a nightly backup window, of either a single instance or
+// all instances of a cluster (by alias). Windows begin at StartTime, orchestrator's local time, on given weekdays.
+type DowntimeSchedule struct {
+	Id           string
+	Key          InstanceKey // the scheduled instance; empty when scheduling a cluster
+	ClusterAlias string      // the scheduled cluster; empty when scheduling an instance
+	Weekdays     string      // comma delimited, e.g. "sat,sun"; empty for daily
+	StartTime    string      // "HH:MM"
+	Duration     time.Duration
+	ExtendWhile  string
+	Owner        string
+	Reason       string
+}
+
+// NewDowntimeSchedule returns an empty schedule with a new unique id
+func NewDowntimeSchedule() *DowntimeSchedule {
+	return &DowntimeSchedule{Id: util.PrettyUniqueToken()}
+}
+
+// Validate checks the schedule is well formed
+func (this *DowntimeSchedule) Validate() error {
+	if this.Key.IsValid() == (this.ClusterAlias != "") {
+		return fmt.Errorf("Downtime schedule must apply to either an instance or a cluster")
+	}
+	if _, err := time.Parse(downtimeScheduleStartTimeLayout, this.StartTime); err != nil {
+		return fmt.Errorf("Invalid downtime schedule start time %q; expected HH:MM", this.StartTime)
+	}
+	if _, err := this.weekdays(); err != nil {
+		return err
+	}
+	if this.Duration <= 0 || this.Duration > 24*time.Hour {
+		return fmt.Errorf("Downtime schedule duration must be positive and at most 24h; got %+v", this.Duration)
+	}
+	switch this.ExtendWhile {
+	case "", DowntimeExtendWhileBackupLock:
+	default:
+		return fmt.Errorf("Unknown downtime extension condition: %q", this.ExtendWhile)
+	}
+	return nil
+}
+
+// weekdays returns the scheduled weekdays, or nil for daily
+func (this *DowntimeSchedule) weekdays() (weekdays map[time.Weekday]bool, err error) {
+	if strings.TrimSpace(this.Weekdays) == "" {
+		return nil, nil
+	}
+	weekdays = make(map[time.Weekday]bool)
+	for _, token := range strings.Split(this.Weekdays, ",") {
+		weekday, ok := downtimeScheduleWeekdays[strings.ToLower(strings.TrimSpace(token))]
+		if !ok {
+			return nil, fmt.Errorf("Invalid downtime schedule weekday %q; expected one of sun,mon,tue,wed,thu,fri,sat", token)
+		}
+		weekdays[weekday] = true
+	}
+	return weekdays, nil
+}
+
+// ActiveWindow returns the scheduled window in which given time falls, if any. A window may cross midnight,
+// in which case it belongs to the weekday on which it begins.
+func (this *DowntimeSchedule) ActiveWindow(now time.Time) (beginsAt time.Time, endsAt time.Time, active bool) {
+	startTime, err := time.Parse(downtimeScheduleStartTimeLayout, this.StartTime)
+	if err != nil {
+		return beginsAt, endsAt, false
+	}
+	weekdays, err := this.weekdays()
+	if err != nil {
+		return beginsAt, endsAt, false
+	}
+	for _, daysAgo := range []int{0, 1} {
+		day := now.AddDate(0, 0, -daysAgo)
+		beginsAt = time.Date(day.Year(), day.Month(), day.Day(), startTime.Hour(), startTime.Minute(), 0, 0, now.Location())
+		endsAt = beginsAt.Add(this.Duration)
+		if weekdays != nil && !weekdays[beginsAt.Weekday()] {
+			continue
+		}
+		if !now.Before(beginsAt) && now.Before(endsAt) {
+			return beginsAt, endsAt, true
+		}
+	}
+	return beginsAt, endsAt, false
+}
+
+// AppliesTo returns true when this schedule covers given instance, of given cluster alias
+func (this *DowntimeSchedule) AppliesTo(instanceKey *InstanceKey, clusterAlias string) bool {
+	if this.ClusterAlias != "" {
+		return this.ClusterAlias == clusterAlias
+	}
+	return this.Key.Equals(instanceKey)
+}
+
+// DowntimeReason is the reason recorded on downtimes begun by this schedule
+func (this *DowntimeSchedule) DowntimeReason() string {
+	return fmt.Sprintf("downtime schedule %s: %s", this.Id, this.Reason)
+}
+
+// ActiveDowntimeScheduleFor returns the first of given schedules to have given instance in an active window, or nil
+func ActiveDowntimeScheduleFor(schedules []DowntimeSchedule, instanceKey *InstanceKey, clusterAlias string, now time.Time) *DowntimeSchedule {
+	for i := range schedules {
+		if !schedules[i].AppliesTo(instanceKey, clusterAlias) {
+			continue
+		}
+		if _, _, active := schedules[i].ActiveWindow(now); active {
+			return &schedules[i]
+		}
+	}
+	return nil
+}
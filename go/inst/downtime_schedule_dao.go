@@ -0,0 +1,148 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteDowntimeSchedule creates or replaces a downtime schedule
+func WriteDowntimeSchedule(schedule *DowntimeSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	_, err := db.ExecOrchestrator(`
+			replace
+				into downtime_schedule (
+					schedule_id, hostname, port, cluster_alias, weekdays, start_time,
+					duration_seconds, extend_while, owner, reason, created_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?,
+					?, ?, ?, ?, now()
+				)
+			`,
+		schedule.Id, schedule.Key.Hostname, schedule.Key.Port, schedule.ClusterAlias, schedule.Weekdays, schedule.StartTime,
+		int64(schedule.Duration.Seconds()), schedule.ExtendWhile, schedule.Owner, schedule.Reason,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("write-downtime-schedule", &schedule.Key, fmt.Sprintf("%s: cluster: %s, weekdays: %s, start: %s, duration: %+v, owner: %s, reason: %s",
+		schedule.Id, schedule.ClusterAlias, schedule.Weekdays, schedule.StartTime, schedule.Duration, schedule.Owner, schedule.Reason))
+	return nil
+}
+
+// DeleteDowntimeSchedule removes a downtime schedule. Downtimes already begun by it run their course.
+func DeleteDowntimeSchedule(scheduleId string) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				downtime_schedule
+			where
+				schedule_id = ?
+			`, scheduleId,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("Downtime schedule %s not found", scheduleId)
+	}
+	AuditOperation("delete-downtime-schedule", nil, scheduleId)
+	return nil
+}
+
+// ReadDowntimeSchedules reads all downtime schedules
+func ReadDowntimeSchedules() (schedules []DowntimeSchedule, err error) {
+	query := `
+		select
+			schedule_id, hostname, port, cluster_alias, weekdays, start_time,
+			duration_seconds, extend_while, owner, reason
+		from
+			downtime_schedule
+		order by
+			schedule_id
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		schedule := DowntimeSchedule{}
+		schedule.Id = m.GetString("schedule_id")
+		schedule.Key.Hostname = m.GetString("hostname")
+		schedule.Key.Port = m.GetInt("port")
+		schedule.ClusterAlias = m.GetString("cluster_alias")
+		schedule.Weekdays = m.GetString("weekdays")
+		schedule.StartTime = m.GetString("start_time")
+		schedule.Duration = time.Duration(m.GetInt64("duration_seconds")) * time.Second
+		schedule.ExtendWhile = m.GetString("extend_while")
+		schedule.Owner = m.GetString("owner")
+		schedule.Reason = m.GetString("reason")
+
+		schedules = append(schedules, schedule)
+		return nil
+	})
+	return schedules, log.Errore(err)
+}
+
+// scheduledInstances returns the instances covered by given schedule
+func scheduledInstances(schedule *DowntimeSchedule) ([](*Instance), error) {
+	if schedule.ClusterAlias == "" {
+		instance, found, err := ReadInstance(&schedule.Key)
+		if err != nil || !found {
+			return [](*Instance){}, err
+		}
+		return [](*Instance){instance}, nil
+	}
+	clusterName, err := ReadClusterNameByAlias(schedule.ClusterAlias)
+	if err != nil {
+		return [](*Instance){}, err
+	}
+	return ReadClusterInstances(clusterName)
+}
+
+// ApplyDowntimeSchedules downtimes instances within an active window of their schedule, until the window ends.
+// Instances already downtimed are left as they are.
+func ApplyDowntimeSchedules() error {
+	schedules, err := ReadDowntimeSchedules()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, schedule := range schedules {
+		schedule := schedule
+		_, endsAt, active := schedule.ActiveWindow(now)
+		if !active {
+			continue
+		}
+		instances, err := scheduledInstances(&schedule)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		for _, instance := range instances {
+			if instance.IsDowntimed {
+				continue
+			}
+			downtime := NewDowntime(&instance.Key, schedule.Owner, schedule.DowntimeReason(), endsAt.Sub(now))
+			downtime.ExtendWhile = schedule.ExtendWhile
+			log.Errore(BeginDowntime(downtime))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+var scheduledKey = InstanceKey{Hostname: "db-backup", Port: 3306}
+
+func TestDowntimeScheduleValidate(t *testing.T) {
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "02:30", Duration: time.Hour}
+		test.S(t).ExpectNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{ClusterAlias: "orders", StartTime: "23:00", Weekdays: "sat, Sun", Duration: 3 * time.Hour, ExtendWhile: DowntimeExtendWhileBackupLock}
+		test.S(t).ExpectNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{StartTime: "02:30", Duration: time.Hour}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, ClusterAlias: "orders", StartTime: "02:30", Duration: time.Hour}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "2am", Duration: time.Hour}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "02:30", Weekdays: "monday", Duration: time.Hour}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "02:30", Duration: 25 * time.Hour}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "02:30", Duration: time.Hour, ExtendWhile: "replication-lag"}
+		test.S(t).ExpectNotNil(schedule.Validate())
+	}
+}
+
+func TestDowntimeScheduleActiveWindow(t *testing.T) {
+	// 2017-06-03 is a Saturday
+	saturdayNight := time.Date(2017, 6, 3, 23, 30, 0, 0, time.UTC)
+	sundayMorning := time.Date(2017, 6, 4, 0, 30, 0, 0, time.UTC)
+	sundayNoon := time.Date(2017, 6, 4, 12, 0, 0, 0, time.UTC)
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "23:00", Duration: 2 * time.Hour}
+		beginsAt, endsAt, active := schedule.ActiveWindow(saturdayNight)
+		test.S(t).ExpectTrue(active)
+		test.S(t).ExpectTrue(beginsAt.Equal(time.Date(2017, 6, 3, 23, 0, 0, 0, time.UTC)))
+		test.S(t).ExpectTrue(endsAt.Equal(time.Date(2017, 6, 4, 1, 0, 0, 0, time.UTC)))
+
+		_, endsAt, active = schedule.ActiveWindow(sundayMorning)
+		test.S(t).ExpectTrue(active)
+		test.S(t).ExpectTrue(endsAt.Equal(time.Date(2017, 6, 4, 1, 0, 0, 0, time.UTC)))
+
+		_, _, active = schedule.ActiveWindow(sundayNoon)
+		test.S(t).ExpectFalse(active)
+	}
+	{
+		// window crossing midnight belongs to the weekday it begins on
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "23:00", Weekdays: "sat", Duration: 2 * time.Hour}
+		_, _, active := schedule.ActiveWindow(sundayMorning)
+		test.S(t).ExpectTrue(active)
+	}
+	{
+		schedule := DowntimeSchedule{Key: scheduledKey, StartTime: "23:00", Weekdays: "sun", Duration: 2 * time.Hour}
+		_, _, active := schedule.ActiveWindow(sundayMorning)
+		test.S(t).ExpectFalse(active)
+	}
+}
+
+func TestActiveDowntimeScheduleFor(t *testing.T) {
+	now := time.Date(2017, 6, 3, 2, 45, 0, 0, time.UTC)
+	otherKey := InstanceKey{Hostname: "db-other", Port: 3306}
+	schedules := []DowntimeSchedule{
+		{Id: "instance", Key: scheduledKey, StartTime: "02:30", Duration: time.Hour},
+		{Id: "cluster", ClusterAlias: "orders", StartTime: "02:00", Duration: time.Hour},
+	}
+	{
+		schedule := ActiveDowntimeScheduleFor(schedules, &scheduledKey, "", now)
+		test.S(t).ExpectTrue(schedule != nil)
+		test.S(t).ExpectEquals(schedule.Id, "instance")
+	}
+	{
+		schedule := ActiveDowntimeScheduleFor(schedules, &otherKey, "orders", now)
+		test.S(t).ExpectTrue(schedule != nil)
+		test.S(t).ExpectEquals(schedule.Id, "cluster")
+	}
+	{
+		schedule := ActiveDowntimeScheduleFor(schedules, &otherKey, "payments", now)
+		test.S(t).ExpectTrue(schedule == nil)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	FleetReportTriggerScheduled = "scheduled"
+	FleetReportTriggerAPI       = "api"
+)
+
+const (
+	ConstraintMultipleWritableInstances = "multiple-writable-instances"
+	ConstraintReplicaOlderThanMaster    = "replica-older-than-master"
+	ConstraintMixedGTID                 = "mixed-gtid"
+	ConstraintNoReplicas                = "no-replicas"
+)
+
+// FleetConstraintViolation describes a cluster not meeting a fleet-wide constraint
+type FleetConstraintViolation struct {
+	ClusterName string
+	Constraint  string
+	Description string
+}
+
+// FleetReport summarizes the posture of the entire fleet at a point in time
+type FleetReport struct {
+	ReportId               int64
+	GeneratedTimestamp     string
+	Trigger                string
+	ProcessingNodeHostname string
+
+	CountInstances          int
+	CountClusters           int
+	ClustersPerDataCenter   map[string]int
+	ReplicasPerMaster       map[int]int // number of direct replicas => number of cluster masters having that many
+	VersionDistribution     map[string]int
+	CountGTIDInstances      int
+	GTIDAdoptionPercent     float64
+	CountSemiSyncInstances  int
+	SemiSyncAdoptionPercent float64
+	ConstraintViolations    []FleetConstraintViolation
+}
+
+func adoptionPercent(count int, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(100*count) / float64(total)
+}
+
+// NewFleetReport computes a fleet report off given instances
+func NewFleetReport(instances [](*Instance)) *FleetReport {
+	report := &FleetReport{
+		CountInstances:        len(instances),
+		ClustersPerDataCenter: make(map[string]int),
+		ReplicasPerMaster:     make(map[int]int),
+		VersionDistribution:   make(map[string]int),
+		ConstraintViolations:  []FleetConstraintViolation{},
+	}
+	instancesMap := make(map[InstanceKey]*Instance)
+	clusterInstances := make(map[string][](*Instance))
+	countReplicas := make(map[InstanceKey]int)
+	for _, instance := range instances {
+		instancesMap[instance.Key] = instance
+		clusterInstances[instance.ClusterName] = append(clusterInstances[instance.ClusterName], instance)
+		if instance.IsReplica() {
+			countReplicas[instance.MasterKey]++
+		}
+		report.VersionDistribution[instance.MajorVersionString()]++
+		if instance.UsingGTID() {
+			report.CountGTIDInstances++
+		}
+		if instance.SemiSyncMasterEnabled || instance.SemiSyncReplicaEnabled {
+			report.CountSemiSyncInstances++
+		}
+	}
+	report.CountClusters = len(clusterInstances)
+	report.GTIDAdoptionPercent = adoptionPercent(report.CountGTIDInstances, report.CountInstances)
+	report.SemiSyncAdoptionPercent = adoptionPercent(report.CountSemiSyncInstances, report.CountInstances)
+
+	clusterNames := []string{}
+	for clusterName := range clusterInstances {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	sort.Strings(clusterNames)
+	for _, clusterName := range clusterNames {
+		var master *Instance
+		countWritable := 0
+		countGTID := 0
+		for _, instance := range clusterInstances[clusterName] {
+			if instance.ReplicationDepth == 0 && (master == nil || (master.ReadOnly && !instance.ReadOnly)) {
+				master = instance
+			}
+			if !instance.ReadOnly {
+				countWritable++
+			}
+			if instance.UsingGTID() {
+				countGTID++
+			}
+			if instanceMaster, found := instancesMap[instance.MasterKey]; found && instance.IsReplica() && instance.IsSmallerMajorVersion(instanceMaster) {
+				report.ConstraintViolations = append(report.ConstraintViolations, FleetConstraintViolation{
+					ClusterName: clusterName,
+					Constraint:  ConstraintReplicaOlderThanMaster,
+					Description: fmt.Sprintf("%+v (%s) replicates from %+v (%s)", instance.Key.DisplayString(), instance.Version, instanceMaster.Key.DisplayString(), instanceMaster.Version),
+				})
+			}
+		}
+		if master != nil {
+			report.ClustersPerDataCenter[master.DataCenter]++
+			report.ReplicasPerMaster[countReplicas[master.Key]]++
+		}
+		if countWritable > 1 {
+			report.ConstraintViolations = append(report.ConstraintViolations, FleetConstraintViolation{
+				ClusterName: clusterName,
+				Constraint:  ConstraintMultipleWritableInstances,
+				Description: fmt.Sprintf("%d instances are not read_only", countWritable),
+			})
+		}
+		if countGTID > 0 && countGTID < len(clusterInstances[clusterName]) {
+			report.ConstraintViolations = append(report.ConstraintViolations, FleetConstraintViolation{
+				ClusterName: clusterName,
+				Constraint:  ConstraintMixedGTID,
+				Description: fmt.Sprintf("%d out of %d instances use GTID", countGTID, len(clusterInstances[clusterName])),
+			})
+		}
+		if len(clusterInstances[clusterName]) == 1 {
+			report.ConstraintViolations = append(report.ConstraintViolations, FleetConstraintViolation{
+				ClusterName: clusterName,
+				Constraint:  ConstraintNoReplicas,
+				Description: "cluster has a single instance",
+			})
+		}
+	}
+	return report
+}
@@ -0,0 +1,169 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/process"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// GenerateFleetReport computes a report off all known instances, and persists it
+func GenerateFleetReport(trigger string) (*FleetReport, error) {
+	instances, err := readInstancesByCondition(`1=1`, sqlutils.Args(), "")
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	report := NewFleetReport(instances)
+	report.Trigger = trigger
+	report.ProcessingNodeHostname = process.ThisHostname
+	if err := writeFleetReport(report); err != nil {
+		return nil, err
+	}
+	AuditOperation("generate-fleet-report", nil, fmt.Sprintf("report: %d, trigger: %s, instances: %d, clusters: %d, constraint violations: %d", report.ReportId, trigger, report.CountInstances, report.CountClusters, len(report.ConstraintViolations)))
+	return report, nil
+}
+
+// GenerateScheduledFleetReport generates a fleet report if FleetReportIntervalMinutes have passed since the latest one
+func GenerateScheduledFleetReport() error {
+	if config.Config.FleetReportIntervalMinutes == 0 {
+		return nil
+	}
+	isDue := false
+	query := `
+		select
+			count(*) = 0 as is_due
+		from
+			fleet_report
+		where
+			generated_timestamp > now() - interval ? minute
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(config.Config.FleetReportIntervalMinutes), func(m sqlutils.RowMap) error {
+		isDue = m.GetBool("is_due")
+		return nil
+	})
+	if err != nil {
+		return log.Errore(err)
+	}
+	if !isDue {
+		return nil
+	}
+	_, err = GenerateFleetReport(FleetReportTriggerScheduled)
+	return err
+}
+
+func writeFleetReport(report *FleetReport) error {
+	reportDetails, err := json.Marshal(report)
+	if err != nil {
+		return log.Errore(err)
+	}
+	writeFunc := func() error {
+		sqlResult, err := db.ExecOrchestrator(`
+			insert
+				into fleet_report (
+					generated_timestamp, report_trigger, processing_node_hostname, count_instances, count_clusters, count_gtid_instances, count_semi_sync_instances, count_constraint_violations, report_details
+				) VALUES (
+					NOW(), ?, ?, ?, ?, ?, ?, ?, ?
+				)
+			`,
+			report.Trigger,
+			report.ProcessingNodeHostname,
+			report.CountInstances,
+			report.CountClusters,
+			report.CountGTIDInstances,
+			report.CountSemiSyncInstances,
+			len(report.ConstraintViolations),
+			string(reportDetails),
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		report.ReportId, err = sqlResult.LastInsertId()
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+func readFleetReports(whereCondition string, limit string, args []interface{}) ([]FleetReport, error) {
+	res := []FleetReport{}
+	query := fmt.Sprintf(`
+		select
+			report_id,
+			generated_timestamp,
+			report_trigger,
+			processing_node_hostname,
+			report_details
+		from
+			fleet_report
+		%s
+		order by
+			report_id desc
+		%s
+		`, whereCondition, limit)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		report := FleetReport{}
+		if err := json.Unmarshal([]byte(m.GetString("report_details")), &report); err != nil {
+			return log.Errore(err)
+		}
+		report.ReportId = m.GetInt64("report_id")
+		report.GeneratedTimestamp = m.GetString("generated_timestamp")
+		report.Trigger = m.GetString("report_trigger")
+		report.ProcessingNodeHostname = m.GetString("processing_node_hostname")
+
+		res = append(res, report)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadFleetReports returns fleet reports, latest first, in pages of AuditPageSize
+func ReadFleetReports(page int) ([]FleetReport, error) {
+	return readFleetReports(``, `limit ? offset ?`, sqlutils.Args(config.AuditPageSize, page*config.AuditPageSize))
+}
+
+// ReadFleetReport returns a single fleet report
+func ReadFleetReport(reportId int64) (*FleetReport, error) {
+	reports, err := readFleetReports(`where report_id = ?`, ``, sqlutils.Args(reportId))
+	if err != nil {
+		return nil, err
+	}
+	if len(reports) == 0 {
+		return nil, fmt.Errorf("Fleet report not found: %d", reportId)
+	}
+	return &reports[0], nil
+}
+
+// ExpireFleetReports removes fleet reports older than FleetReportRetentionDays
+func ExpireFleetReports() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete
+				from fleet_report
+			where
+				generated_timestamp < NOW() - INTERVAL ? DAY
+			`,
+			config.Config.FleetReportRetentionDays,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
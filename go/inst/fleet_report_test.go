@@ -0,0 +1,63 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewFleetReport(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ClusterName = "cluster1"
+		instance.DataCenter = "dc1"
+		instance.ReadOnly = true
+		instance.ReplicationDepth = 1
+		instance.MasterKey = i710Key
+		instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+	}
+	master := instancesMap[i710Key.StringCode()]
+	master.MasterKey = InstanceKey{}
+	master.ReplicationDepth = 0
+	master.ReadOnly = false
+	master.Version = "5.7.20"
+	instancesMap[i830Key.StringCode()].SemiSyncReplicaEnabled = true
+
+	single := &Instance{Key: InstanceKey{Hostname: "single", Port: 3306}, ClusterName: "cluster2", DataCenter: "dc2", Version: "5.7.20"}
+	instances = append(instances, single)
+
+	report := NewFleetReport(instances)
+	test.S(t).ExpectEquals(report.CountInstances, 7)
+	test.S(t).ExpectEquals(report.CountClusters, 2)
+	test.S(t).ExpectEquals(report.ClustersPerDataCenter["dc1"], 1)
+	test.S(t).ExpectEquals(report.ClustersPerDataCenter["dc2"], 1)
+	test.S(t).ExpectEquals(report.ReplicasPerMaster[5], 1)
+	test.S(t).ExpectEquals(report.ReplicasPerMaster[0], 1)
+	test.S(t).ExpectEquals(report.VersionDistribution["5.6"], 5)
+	test.S(t).ExpectEquals(report.VersionDistribution["5.7"], 2)
+	test.S(t).ExpectEquals(report.CountSemiSyncInstances, 1)
+
+	violations := map[string]string{}
+	for _, violation := range report.ConstraintViolations {
+		violations[violation.Constraint] = violation.ClusterName
+	}
+	test.S(t).ExpectEquals(len(report.ConstraintViolations), 6)
+	test.S(t).ExpectEquals(violations[ConstraintReplicaOlderThanMaster], "cluster1")
+	test.S(t).ExpectEquals(violations[ConstraintNoReplicas], "cluster2")
+}
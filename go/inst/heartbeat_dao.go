@@ -0,0 +1,131 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/util"
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// Writers on which the heartbeat table is known to exist. Re-validated once in a while, in case someone dropped it.
+var heartbeatPreparedWriters = cache.New(config.CheckAutoPseudoGTIDGrantsIntervalSeconds*time.Second, time.Second)
+
+// heartbeatTableName returns the escaped, schema qualified heartbeat table name
+func heartbeatTableName() (schema string, table string) {
+	tokens := strings.SplitN(config.Config.HeartbeatTable, ".", 2)
+	return fmt.Sprintf("`%s`", tokens[0]), fmt.Sprintf("`%s`.`%s`", tokens[0], tokens[1])
+}
+
+// prepareHeartbeatTable creates the heartbeat schema and table on given writer, if missing
+func prepareHeartbeatTable(instanceKey *InstanceKey) error {
+	if _, found := heartbeatPreparedWriters.Get(instanceKey.StringCode()); found {
+		return nil
+	}
+	schema, table := heartbeatTableName()
+	if _, err := ExecInstance(instanceKey, fmt.Sprintf("create database if not exists %s", schema)); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(`
+		create table if not exists %s (
+			server_id int unsigned not null,
+			ts datetime(6) not null,
+			primary key (server_id)
+		) engine=InnoDB
+		`, table)
+	if _, err := ExecInstance(instanceKey, query); err != nil {
+		return err
+	}
+	heartbeatPreparedWriters.Set(instanceKey.StringCode(), true, cache.DefaultExpiration)
+	return nil
+}
+
+// InjectHeartbeat writes a heartbeat on given writeable instance. The heartbeat replicates down the
+// topology, where it is read by ReadHeartbeatLag.
+func InjectHeartbeat(instance *Instance) error {
+	if instance == nil {
+		return log.Errorf("InjectHeartbeat: instance is nil")
+	}
+	if instance.ReadOnly {
+		return log.Errorf("InjectHeartbeat: instance is read-only: %+v", instance.Key)
+	}
+	if !instance.IsLastCheckValid {
+		return nil
+	}
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting heartbeat injection on %+v; signalling error but nothing went wrong.", instance.Key)
+	}
+	if err := prepareHeartbeatTable(&instance.Key); err != nil {
+		recordUnusableMasterHeartbeatError(&instance.Key, err)
+		if util.ClearToLog("InjectHeartbeat", instance.Key.StringCode()) {
+			log.Errorf("InjectHeartbeat: cannot prepare %s on %+v: %+v", config.Config.HeartbeatTable, instance.Key, err)
+		}
+		return err
+	}
+	_, table := heartbeatTableName()
+	query := fmt.Sprintf(`
+		insert into %s
+				(server_id, ts)
+			values
+				(@@global.server_id, utc_timestamp(6))
+			on duplicate key update
+				ts=values(ts)
+		`, table)
+	if _, err := ExecInstance(&instance.Key, query); err != nil {
+		heartbeatPreparedWriters.Delete(instance.Key.StringCode())
+		recordUnusableMasterHeartbeatError(&instance.Key, err)
+		return err
+	}
+	return nil
+}
+
+// recordUnusableMasterHeartbeatError records a failed heartbeat write as an unusable master signal, when
+// the master reports a condition such as a full disk
+func recordUnusableMasterHeartbeatError(instanceKey *InstanceKey, err error) {
+	if !config.Config.DetectUnusableMasters {
+		return
+	}
+	if condition := UnusableMasterCondition(err); condition != "" {
+		RecordUnusableMasterSignal(instanceKey, condition, UnusableMasterSourceHeartbeat, err.Error())
+	}
+}
+
+// ReadHeartbeatLag reads replication lag, in milliseconds, on a replica, as the time passed since the
+// most recent heartbeat it applied. Returns an invalid value when no heartbeat is found.
+func ReadHeartbeatLag(db *sql.DB) (lag sql.NullInt64, err error) {
+	_, table := heartbeatTableName()
+	query := fmt.Sprintf(`
+		select
+			timestampdiff(microsecond, max(ts), utc_timestamp(6)) div 1000
+		from
+			%s
+		`, table)
+	if err := db.QueryRow(query).Scan(&lag); err != nil {
+		return lag, err
+	}
+	if lag.Valid && lag.Int64 < 0 {
+		// Clock skew between master and replica
+		lag.Int64 = 0
+	}
+	return lag, nil
+}
@@ -0,0 +1,171 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"net"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// hostnameRulesCache holds the effective, compiled, hostname rules, so as not to read them off the backend
+// nor compile them on each resolve
+var hostnameRulesCache = cache.New(time.Minute, time.Minute)
+
+const hostnameRulesCacheKey = "hostname-rules"
+
+// hostnameRulesReadFailureRetry is how long a failure to read the rules managed via API is cached, meanwhile
+// applying only the configured rules
+const hostnameRulesReadFailureRetry = 10 * time.Second
+
+// compiledHostnameRule is a hostname rule with its pattern compiled
+type compiledHostnameRule struct {
+	config.HostnameRule
+	pattern *regexp.Regexp
+}
+
+// compileHostnameRules compiles the patterns of given rules. A rule whose pattern does not compile is skipped.
+func compileHostnameRules(rules []config.HostnameRule) []compiledHostnameRule {
+	compiledRules := []compiledHostnameRule{}
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Errorf("Hostname rule %s: invalid pattern %s: %+v", rule.Name, rule.Pattern, err)
+			continue
+		}
+		compiledRules = append(compiledRules, compiledHostnameRule{HostnameRule: rule, pattern: pattern})
+	}
+	return compiledRules
+}
+
+// HostnameResolution explains how an instance key resolves, per hostname rules and resolve method
+type HostnameResolution struct {
+	Key               InstanceKey
+	Rule              string // name of the hostname rule applied; empty when no rule matches
+	RewrittenHostname string // hostname, as rewritten by the rule, to be resolved
+	ResolveMethod     string
+	ResolvedKey       InstanceKey
+	Rejected          bool   // resolved hostname matches RejectHostnameResolvePattern, and so is not used
+	CachedHostname    string // hostname the resolve cache currently holds, if any
+	Error             string
+}
+
+// normalizeIPv6Hostname returns an IPv6 address in canonical form: lowercase, compressed, with no brackets nor zone.
+// Any other hostname is returned as is.
+func normalizeIPv6Hostname(hostname string) string {
+	address := strings.TrimSuffix(strings.TrimPrefix(hostname, "["), "]")
+	if i := strings.Index(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+	ip := net.ParseIP(address)
+	if ip == nil || !strings.Contains(address, ":") {
+		return hostname
+	}
+	return ip.String()
+}
+
+// matchHostnameRule returns the first rule whose pattern matches given hostname, or nil
+func matchHostnameRule(rules []compiledHostnameRule, hostname string) *compiledHostnameRule {
+	for i := range rules {
+		if rules[i].pattern.MatchString(hostname) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+// rewriteHostname applies a rule's rewrite and IPv6 normalization to a hostname
+func rewriteHostname(rule *compiledHostnameRule, hostname string) string {
+	if rule.Rewrite != "" {
+		hostname = rule.pattern.ReplaceAllString(hostname, rule.Rewrite)
+	}
+	if rule.NormalizeIPv6 {
+		hostname = normalizeIPv6Hostname(hostname)
+	}
+	return hostname
+}
+
+// mapPort returns the port an instance is known by, per a rule's port mapping
+func mapPort(rule *compiledHostnameRule, port int) int {
+	if mappedPort, found := rule.MapPorts[port]; found {
+		return mappedPort
+	}
+	return port
+}
+
+// readHostnameRules returns the effective hostname rules: configured ones, followed by those managed via API.
+// Rules managed via API are kept in the backend, which is not used for resolving when HostnameResolveMethod is "none".
+func readHostnameRules() []compiledHostnameRule {
+	if compiledRules, found := hostnameRulesCache.Get(hostnameRulesCacheKey); found {
+		return compiledRules.([]compiledHostnameRule)
+	}
+	rules := config.Config.HostnameRules
+	if HostnameResolveMethodIsNone() {
+		compiledRules := compileHostnameRules(rules)
+		hostnameRulesCache.Set(hostnameRulesCacheKey, compiledRules, cache.DefaultExpiration)
+		return compiledRules
+	}
+	apiRules, err := readAPIHostnameRules()
+	if err != nil {
+		compiledRules := compileHostnameRules(rules)
+		hostnameRulesCache.Set(hostnameRulesCacheKey, compiledRules, hostnameRulesReadFailureRetry)
+		return compiledRules
+	}
+	compiledRules := compileHostnameRules(append(rules[:len(rules):len(rules)], apiRules...))
+	hostnameRulesCache.Set(hostnameRulesCacheKey, compiledRules, cache.DefaultExpiration)
+	return compiledRules
+}
+
+// ExplainHostnameResolve tells how an instance key resolves, per current hostname rules. The hostname is
+// resolved afresh, bypassing and not updating the resolve cache.
+func ExplainHostnameResolve(instanceKey *InstanceKey) *HostnameResolution {
+	resolution := &HostnameResolution{
+		Key:               *instanceKey,
+		RewrittenHostname: instanceKey.Hostname,
+		ResolveMethod:     config.Config.HostnameResolveMethod,
+		ResolvedKey:       *instanceKey,
+	}
+	if rule := matchHostnameRule(readHostnameRules(), instanceKey.Hostname); rule != nil {
+		resolution.Rule = rule.Name
+		resolution.RewrittenHostname = rewriteHostname(rule, instanceKey.Hostname)
+		resolution.ResolvedKey.Port = mapPort(rule, instanceKey.Port)
+		if rule.ResolveMethod != "" {
+			resolution.ResolveMethod = rule.ResolveMethod
+		}
+	}
+	if cachedHostname, found := getHostnameResolvesLightweightCache().Get(instanceKey.Hostname); found {
+		resolution.CachedHostname = cachedHostname.(string)
+	}
+	resolvedHostname, err := resolveHostname(resolution.ResolveMethod, resolution.RewrittenHostname)
+	if err != nil {
+		resolution.Error = err.Error()
+		return resolution
+	}
+	resolution.ResolvedKey.Hostname = resolvedHostname
+	if config.Config.RejectHostnameResolvePattern != "" {
+		if matched, _ := regexp.MatchString(config.Config.RejectHostnameResolvePattern, resolvedHostname); matched {
+			resolution.Rejected = true
+			resolution.ResolvedKey.Hostname = instanceKey.Hostname
+		}
+	}
+	return resolution
+}
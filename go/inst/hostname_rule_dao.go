@@ -0,0 +1,126 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// isConfiguredHostnameRule checks whether a hostname rule name is defined in HostnameRules
+func isConfiguredHostnameRule(name string) bool {
+	for _, rule := range config.Config.HostnameRules {
+		if rule.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// hostnameRulesChanged makes hostname rule changes apply to hostnames already resolved
+func hostnameRulesChanged() {
+	hostnameRulesCache.Flush()
+	if err := ResetHostnameResolveCache(); err != nil {
+		log.Errore(err)
+	}
+}
+
+// WriteHostnameRule creates or replaces a hostname rule managed via API. Rules defined in HostnameRules
+// may only be changed in configuration.
+func WriteHostnameRule(rule *config.HostnameRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if isConfiguredHostnameRule(rule.Name) {
+		return fmt.Errorf("Hostname rule %s is defined in configuration", rule.Name)
+	}
+	content, err := json.Marshal(rule)
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestrator(`
+			replace
+				into hostname_rule (
+					rule_name, content, last_updated
+				) values (
+					?, ?, NOW()
+				)
+			`, rule.Name, string(content),
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	hostnameRulesChanged()
+	AuditOperation("write-hostname-rule", nil, string(content))
+	return nil
+}
+
+// DeleteHostnameRule removes a hostname rule managed via API
+func DeleteHostnameRule(name string) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				hostname_rule
+			where
+				rule_name = ?
+			`, name,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("Hostname rule %s not found", name)
+	}
+	hostnameRulesChanged()
+	AuditOperation("delete-hostname-rule", nil, name)
+	return nil
+}
+
+// readAPIHostnameRules reads the hostname rules managed via API, ordered by name
+func readAPIHostnameRules() (rules []config.HostnameRule, err error) {
+	query := `
+		select
+			rule_name, content
+		from
+			hostname_rule
+		order by
+			rule_name
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		rule := config.HostnameRule{}
+		if err := json.Unmarshal([]byte(m.GetString("content")), &rule); err != nil {
+			return log.Errorf("Cannot parse hostname rule %s: %+v", m.GetString("rule_name"), err)
+		}
+		if isConfiguredHostnameRule(rule.Name) {
+			return nil
+		}
+		rules = append(rules, rule)
+		return nil
+	})
+	return rules, log.Errore(err)
+}
+
+// ReadHostnameRules reads the effective hostname rules: those of HostnameRules, followed by those managed via API
+func ReadHostnameRules() (rules []config.HostnameRule, err error) {
+	rules = append(rules, config.Config.HostnameRules...)
+	apiRules, err := readAPIHostnameRules()
+	return append(rules, apiRules...), err
+}
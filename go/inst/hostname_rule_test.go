@@ -0,0 +1,56 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestNormalizeIPv6Hostname(t *testing.T) {
+	test.S(t).ExpectEquals(normalizeIPv6Hostname("2001:DB8:0:0:0:0:0:1"), "2001:db8::1")
+	test.S(t).ExpectEquals(normalizeIPv6Hostname("[2001:db8::1]"), "2001:db8::1")
+	test.S(t).ExpectEquals(normalizeIPv6Hostname("fe80::1%eth0"), "fe80::1")
+	test.S(t).ExpectEquals(normalizeIPv6Hostname("10.0.0.1"), "10.0.0.1")
+	test.S(t).ExpectEquals(normalizeIPv6Hostname("db-1.example.com"), "db-1.example.com")
+}
+
+func TestApplyHostnameRules(t *testing.T) {
+	rules := compileHostnameRules([]config.HostnameRule{
+		{
+			Name:          "containers",
+			Pattern:       `^([0-9a-f]{12})$`,
+			Rewrite:       "${1}.containers.example.com",
+			ResolveMethod: "none",
+			MapPorts:      map[int]int{13306: 3306},
+		},
+		{
+			Name:          "ipv6",
+			Pattern:       `:`,
+			NormalizeIPv6: true,
+		},
+		{
+			Name:    "invalid",
+			Pattern: `(`,
+		},
+	})
+	test.S(t).ExpectEquals(len(rules), 2)
+	{
+		rule := matchHostnameRule(rules, "4f2a9c0b1d3e")
+		test.S(t).ExpectNotNil(rule)
+		test.S(t).ExpectEquals(rule.Name, "containers")
+		test.S(t).ExpectEquals(rewriteHostname(rule, "4f2a9c0b1d3e"), "4f2a9c0b1d3e.containers.example.com")
+		test.S(t).ExpectEquals(mapPort(rule, 13306), 3306)
+		test.S(t).ExpectEquals(mapPort(rule, 3307), 3307)
+	}
+	{
+		rule := matchHostnameRule(rules, "[2001:DB8::0:1]")
+		test.S(t).ExpectNotNil(rule)
+		test.S(t).ExpectEquals(rule.Name, "ipv6")
+		test.S(t).ExpectEquals(rewriteHostname(rule, "[2001:DB8::0:1]"), "2001:db8::1")
+	}
+	{
+		rule := matchHostnameRule(rules, "db-1.example.com")
+		test.S(t).ExpectTrue(rule == nil)
+	}
+}
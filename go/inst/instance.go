@@ -65,12 +65,17 @@ type Instance struct {
 	IsDetached                bool
 	RelaylogCoordinates       BinlogCoordinates
 	LastSQLError              string
+	LastSQLErrno              int
 	LastIOError               string
+	LastIOErrno               int
+	SecondsSinceLastHeartbeat sql.NullInt64 // as seen by the IO thread; MySQL 5.7 and above
 	SecondsBehindMaster       sql.NullInt64
+	HeartbeatLagMilliseconds  sql.NullInt64 // as measured by orchestrator's own heartbeat, when enabled
 	SQLDelay                  uint
 	ExecutedGtidSet           string
 	GtidPurged                string
 	GtidErrant                string
+	ReplicationChannels       ReplicationChannels // MySQL 8.0 and above, per performance_schema
 
 	masterExecutedGtidSet string // Not exported
 
@@ -88,6 +93,11 @@ type Instance struct {
 	SemiSyncEnforced                bool
 	SemiSyncMasterEnabled           bool
 	SemiSyncReplicaEnabled          bool
+	SemiSyncMasterTimeout           uint // milliseconds
+	SemiSyncMasterWaitReplicaCount  uint
+	SemiSyncMasterClients           uint
+	SemiSyncMasterWaitSessions      uint
+	RunningActivity                 string // a running backup or long-running DDL, as detected with DetectRunningActivities
 
 	LastSeenTimestamp    string
 	IsLastCheckValid     bool
@@ -107,8 +117,13 @@ type Instance struct {
 	DowntimeOwner        string
 	DowntimeEndTimestamp string
 	ElapsedDowntime      time.Duration
+	IsNoTouch            bool
+	NoTouchOwner         string
+	NoTouchReason        string
+	NoTouchEndTimestamp  string
 	UnresolvedHostname   string
 	AllowTLS             bool
+	TLSVerifyServerCert  bool
 
 	Problems []string
 
@@ -196,11 +211,19 @@ func (this *Instance) IsNDB() bool {
 	return strings.Contains(this.Version, "-ndb-")
 }
 
-// IsBinlogServer checks whether this is any type of a binlog server (currently only maxscale)
+// IsRipple checks whether this is a Ripple binlog server
+func (this *Instance) IsRipple() bool {
+	return strings.Contains(this.Version, "-ripple")
+}
+
+// IsBinlogServer checks whether this is any type of a binlog server (maxscale or ripple)
 func (this *Instance) IsBinlogServer() bool {
 	if this.isMaxScale() {
 		return true
 	}
+	if this.IsRipple() {
+		return true
+	}
 	return false
 }
 
@@ -234,6 +257,8 @@ func (this *Instance) applyFlavorName() {
 		this.FlavorName = "Percona"
 	} else if this.isMaxScale() {
 		this.FlavorName = "MaxScale"
+	} else if this.IsRipple() {
+		this.FlavorName = "Ripple"
 	} else {
 		this.FlavorName = "unknown"
 	}
@@ -390,6 +415,10 @@ func (this *Instance) CanReplicateFrom(other *Instance) (bool, error) {
 
 // HasReasonableMaintenanceReplicationLag returns true when the replica lag is reasonable, and maintenance operations should have a green light to go.
 func (this *Instance) HasReasonableMaintenanceReplicationLag() bool {
+	if this.HeartbeatLagMilliseconds.Valid {
+		// Heartbeat lag is accurate to the sub-second, and is preferred over Seconds_Behind_Master
+		return math.AbsInt64(this.HeartbeatLagMilliseconds.Int64-int64(this.SQLDelay)*1000) <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)*1000
+	}
 	// replicas with SQLDelay are a special case
 	if this.SQLDelay > 0 {
 		return math.AbsInt64(this.SecondsBehindMaster.Int64-int64(this.SQLDelay)) <= int64(config.Config.ReasonableMaintenanceReplicationLagSeconds)
@@ -412,7 +441,7 @@ func (this *Instance) CanMove() (bool, error) {
 	if !this.ReplicationIOThreadState.IsRunning() {
 		return false, fmt.Errorf("%+v: instance is not replicating", this.Key)
 	}
-	if !this.SecondsBehindMaster.Valid {
+	if !this.SecondsBehindMaster.Valid && !this.HeartbeatLagMilliseconds.Valid {
 		return false, fmt.Errorf("%+v: cannot determine slave lag", this.Key)
 	}
 	if !this.HasReasonableMaintenanceReplicationLag() {
@@ -511,6 +540,9 @@ func (this *Instance) descriptionTokens() (tokens []string) {
 		if this.IsDowntimed {
 			extraTokens = append(extraTokens, "downtimed")
 		}
+		if this.IsNoTouch {
+			extraTokens = append(extraTokens, "no-touch")
+		}
 		tokens = append(tokens, strings.Join(extraTokens, ","))
 	}
 	return tokens
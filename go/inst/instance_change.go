@@ -0,0 +1,77 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+)
+
+// InstanceChange is a material change in an instance's state, as detected between two consecutive discoveries
+type InstanceChange struct {
+	ChangeId        int64
+	Key             InstanceKey
+	ClusterName     string
+	ChangeTimestamp string
+	FieldName       string
+	BeforeValue     string
+	AfterValue      string
+}
+
+// InstanceChangeFilter narrows down a listing of instance changes. Empty fields do not filter.
+type InstanceChangeFilter struct {
+	InstanceKey *InstanceKey
+	ClusterName string
+	FieldName   string
+}
+
+// instanceChangeFields lists the instance properties whose change is considered material
+var instanceChangeFields = []struct {
+	name  string
+	value func(instance *Instance) string
+}{
+	{"MasterKey", func(instance *Instance) string { return instance.MasterKey.StringCode() }},
+	{"ReadOnly", func(instance *Instance) string { return fmt.Sprintf("%t", instance.ReadOnly) }},
+	{"Version", func(instance *Instance) string { return instance.Version }},
+	{"GTIDMode", func(instance *Instance) string { return instance.GTIDMode }},
+	{"UsingGTID", func(instance *Instance) string { return fmt.Sprintf("%t", instance.UsingGTID()) }},
+	{"ServerID", func(instance *Instance) string { return fmt.Sprintf("%d", instance.ServerID) }},
+	{"ServerUUID", func(instance *Instance) string { return instance.ServerUUID }},
+	{"Binlog_format", func(instance *Instance) string { return instance.Binlog_format }},
+	{"LogBinEnabled", func(instance *Instance) string { return fmt.Sprintf("%t", instance.LogBinEnabled) }},
+	{"LogSlaveUpdatesEnabled", func(instance *Instance) string { return fmt.Sprintf("%t", instance.LogSlaveUpdatesEnabled) }},
+}
+
+// GetInstanceChanges compares two states of the same instance, and returns the material changes between them
+func GetInstanceChanges(before *Instance, after *Instance) (changes []InstanceChange) {
+	if before == nil || after == nil {
+		return changes
+	}
+	for _, field := range instanceChangeFields {
+		beforeValue := field.value(before)
+		afterValue := field.value(after)
+		if beforeValue != afterValue {
+			changes = append(changes, InstanceChange{
+				Key:         after.Key,
+				ClusterName: after.ClusterName,
+				FieldName:   field.name,
+				BeforeValue: beforeValue,
+				AfterValue:  afterValue,
+			})
+		}
+	}
+	return changes
+}
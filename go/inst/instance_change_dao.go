@@ -0,0 +1,135 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteInstanceChanges persists given instance changes
+func WriteInstanceChanges(changes []InstanceChange) error {
+	for _, change := range changes {
+		change := change
+		writeFunc := func() error {
+			_, err := db.ExecOrchestrator(`
+				insert
+					into database_instance_change (
+						hostname, port, cluster_name, change_timestamp, field_name, before_value, after_value
+					) VALUES (
+						?, ?, ?, NOW(), ?, ?, ?
+					)
+				`,
+				change.Key.Hostname,
+				change.Key.Port,
+				change.ClusterName,
+				change.FieldName,
+				change.BeforeValue,
+				change.AfterValue,
+			)
+			return log.Errore(err)
+		}
+		if err := ExecDBWriteFunc(writeFunc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AuditInstanceChanges detects material changes between two states of an instance, and
+// writes them down both as structured instance changes and as general audit entries.
+func AuditInstanceChanges(before *Instance, after *Instance) error {
+	changes := GetInstanceChanges(before, after)
+	if len(changes) == 0 {
+		return nil
+	}
+	if err := WriteInstanceChanges(changes); err != nil {
+		return err
+	}
+	descriptions := []string{}
+	for _, change := range changes {
+		descriptions = append(descriptions, fmt.Sprintf("%s: %s -> %s", change.FieldName, change.BeforeValue, change.AfterValue))
+	}
+	return AuditOperation("instance-change", &after.Key, strings.Join(descriptions, "; "))
+}
+
+// ReadInstanceChanges returns instance changes matching given filter, chronologically descending, using page number.
+func ReadInstanceChanges(filter *InstanceChangeFilter, page int) ([]InstanceChange, error) {
+	res := []InstanceChange{}
+	whereConditions := []string{`1=1`}
+	args := sqlutils.Args()
+	if filter.InstanceKey != nil {
+		whereConditions = append(whereConditions, `hostname=? and port=?`)
+		args = append(args, filter.InstanceKey.Hostname, filter.InstanceKey.Port)
+	}
+	if filter.ClusterName != "" {
+		whereConditions = append(whereConditions, `cluster_name=?`)
+		args = append(args, filter.ClusterName)
+	}
+	if filter.FieldName != "" {
+		whereConditions = append(whereConditions, `field_name=?`)
+		args = append(args, filter.FieldName)
+	}
+	query := fmt.Sprintf(`
+		select
+			change_id,
+			hostname,
+			port,
+			cluster_name,
+			change_timestamp,
+			field_name,
+			before_value,
+			after_value
+		from
+			database_instance_change
+		where
+			%s
+		order by
+			change_id desc
+		limit ?
+		offset ?
+		`, strings.Join(whereConditions, " and "))
+	args = append(args, config.AuditPageSize, page*config.AuditPageSize)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		change := InstanceChange{}
+		change.ChangeId = m.GetInt64("change_id")
+		change.Key.Hostname = m.GetString("hostname")
+		change.Key.Port = m.GetInt("port")
+		change.ClusterName = m.GetString("cluster_name")
+		change.ChangeTimestamp = m.GetString("change_timestamp")
+		change.FieldName = m.GetString("field_name")
+		change.BeforeValue = m.GetString("before_value")
+		change.AfterValue = m.GetString("after_value")
+
+		res = append(res, change)
+		return nil
+	})
+	if err != nil {
+		log.Errore(err)
+	}
+	return res, err
+}
+
+// ExpireInstanceChanges removes old rows from the database_instance_change table
+func ExpireInstanceChanges() error {
+	return ExpireTableData("database_instance_change", "change_timestamp")
+}
@@ -0,0 +1,127 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// Error3707RestartServerFailed is returned by CLONE when the recipient is not run by a supervisor which restarts it
+const Error3707RestartServerFailed = 3707
+
+// CloneStage is the progress of a single stage of a MySQL 8 CLONE operation, as in performance_schema.clone_progress
+type CloneStage struct {
+	Stage    string
+	State    string
+	Estimate int64
+	Data     int64
+}
+
+// CloneStatus is the outcome of the latest MySQL 8 CLONE operation on a recipient, as in performance_schema.clone_status.
+// Coordinates are the donor's binary log coordinates at the point of the clone.
+type CloneStatus struct {
+	State        string
+	ErrorNo      int
+	ErrorMessage string
+	Coordinates  BinlogCoordinates
+}
+
+// IsCompleted returns true when the clone operation completed successfully
+func (this *CloneStatus) IsCompleted() bool {
+	return this.State == "Completed"
+}
+
+// CheckClonePlugin validates that given instance is a MySQL 8 server with an active clone plugin
+func CheckClonePlugin(instance *Instance) error {
+	if instance.IsMariaDB() || instance.IsSmallerMajorVersionByString("8.0") {
+		return fmt.Errorf("%+v runs %s; CLONE requires MySQL 8.0.17 or above", instance.Key, instance.Version)
+	}
+	pluginStatus := ""
+	query := `select plugin_status from information_schema.plugins where plugin_name = 'clone'`
+	if err := ScanInstanceRow(&instance.Key, query, &pluginStatus); err != nil {
+		return fmt.Errorf("%+v: clone plugin not found: %+v", instance.Key, err)
+	}
+	if pluginStatus != "ACTIVE" {
+		return fmt.Errorf("%+v: clone plugin is %s", instance.Key, pluginStatus)
+	}
+	return nil
+}
+
+// CloneInstance clones the donor's data onto the recipient, using the MySQL 8 CLONE plugin. The recipient's data is
+// replaced, and the recipient restarts once done. A broken connection is therefore expected, and only errors reported
+// by the recipient are returned, including a failure to restart; the outcome should be read via ReadCloneStatus.
+func CloneInstance(recipientKey *InstanceKey, donorKey *InstanceKey) error {
+	if err := CheckNoTouch(recipientKey); err != nil {
+		return err
+	}
+	if _, err := ExecInstance(recipientKey, `set global clone_valid_donor_list = ?`, donorKey.StringCode()); err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("clone-instance", recipientKey, fmt.Sprintf("cloning from %+v", *donorKey))
+	_, err := ExecInstance(recipientKey, `clone instance from ?@?:? identified by ?`,
+		config.Config.MySQLTopologyUser, donorKey.Hostname, donorKey.Port, config.Config.MySQLTopologyPassword,
+	)
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		if mysqlErr.Number != Error3707RestartServerFailed {
+			return log.Errore(err)
+		}
+		return log.Errorf("CloneInstance: %+v cloned, but not restarted: it is not run by a supervisor", *recipientKey)
+	} else if err != nil {
+		log.Debugf("CloneInstance: connection to %+v broke as expected: %+v", *recipientKey, err)
+	}
+	return nil
+}
+
+// ReadCloneProgress reads the stages of the latest CLONE operation on given recipient
+func ReadCloneProgress(recipientKey *InstanceKey) (stages []CloneStage, err error) {
+	db, err := db.OpenTopology(recipientKey.Hostname, recipientKey.Port)
+	if err != nil {
+		return stages, err
+	}
+	query := `select stage, state, estimate, data from performance_schema.clone_progress order by id`
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		stage := CloneStage{
+			Stage:    m.GetString("stage"),
+			State:    m.GetString("state"),
+			Estimate: m.GetInt64("estimate"),
+			Data:     m.GetInt64("data"),
+		}
+		stages = append(stages, stage)
+		return nil
+	})
+	return stages, err
+}
+
+// ReadCloneStatus reads the outcome of the latest CLONE operation on given recipient
+func ReadCloneStatus(recipientKey *InstanceKey) (*CloneStatus, error) {
+	status := &CloneStatus{Coordinates: BinlogCoordinates{Type: BinaryLog}}
+	query := `select state, error_no, error_message, binlog_file, binlog_position from performance_schema.clone_status`
+	err := ScanInstanceRow(recipientKey, query,
+		&status.State, &status.ErrorNo, &status.ErrorMessage, &status.Coordinates.LogFile, &status.Coordinates.LogPos,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
@@ -76,6 +76,8 @@ var accessDeniedCounter = metrics.NewCounter()
 var readTopologyInstanceCounter = metrics.NewCounter()
 var readInstanceCounter = metrics.NewCounter()
 var writeInstanceCounter = metrics.NewCounter()
+var writeBufferFlushCounter = metrics.NewCounter()
+var writeBufferCoalescedCounter = metrics.NewCounter()
 var backendWrites = collection.CreateOrReturnCollection("BACKEND_WRITES")
 
 var emptyQuotesRegexp = regexp.MustCompile(`^""$`)
@@ -85,6 +87,8 @@ func init() {
 	metrics.Register("instance.read_topology", readTopologyInstanceCounter)
 	metrics.Register("instance.read", readInstanceCounter)
 	metrics.Register("instance.write", writeInstanceCounter)
+	metrics.Register("instance.write_buffer.flush", writeBufferFlushCounter)
+	metrics.Register("instance.write_buffer.coalesced", writeBufferCoalescedCounter)
 
 	go initializeInstanceDao()
 }
@@ -252,6 +256,46 @@ func (instance *Instance) checkMaxScale(db *sql.DB, latency *stopwatch.NamedStop
 	return isMaxScale, resolvedHostname, err
 }
 
+// checkRipple checks whether the instance is a Ripple binlog server. Ripple speaks the MySQL protocol, but only
+// supports a handful of statements; it replicates via GTID, and has no SQL thread.
+func (instance *Instance) checkRipple(db *sql.DB, latency *stopwatch.NamedStopwatch) (isRipple bool, err error) {
+	if !config.Config.DetectRippleBinlogServers {
+		return isRipple, err
+	}
+	latency.Start("instance")
+	defer latency.Stop("instance")
+
+	versionComment := ""
+	if err = db.QueryRow("select @@version_comment limit 1").Scan(&versionComment); err != nil {
+		logReadTopologyInstanceError(&instance.Key, "select @@version_comment", err)
+		return isRipple, err
+	}
+	if !strings.Contains(strings.ToLower(versionComment), "ripple") {
+		return isRipple, nil
+	}
+	version := ""
+	db.QueryRow("select @@version").Scan(&version)
+	if version == "" {
+		version = "0.0.0"
+	}
+	instance.Version = version + "-ripple"
+	instance.VersionComment = versionComment
+	db.QueryRow("select @@server_id").Scan(&instance.ServerID)
+	instance.Uptime = 0
+	instance.Binlog_format = "INHERIT"
+	instance.ReadOnly = true
+	instance.LogBinEnabled = true
+	instance.LogSlaveUpdatesEnabled = true
+	instance.SupportsOracleGTID = true
+	err = sqlutils.QueryRowsMap(db, "show master status", func(m sqlutils.RowMap) error {
+		instance.SelfBinlogCoordinates.LogFile = m.GetString("File")
+		instance.SelfBinlogCoordinates.LogPos = m.GetInt64("Position")
+		return nil
+	})
+	logReadTopologyInstanceError(&instance.Key, "show master status", err)
+	return true, nil
+}
+
 // expectReplicationThreadsState expects both replication threads to be running, or both to be not running.
 // Specifically, it looks for both to be "Yes" or for both to be "No".
 func expectReplicationThreadsState(instanceKey *InstanceKey, expectedState ReplicationThreadState) (expectationMet bool, err error) {
@@ -293,6 +337,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	maxScaleMasterHostname := ""
 	isMaxScale := false
 	isMaxScale110 := false
+	isRipple := false
+	isBinlogServer := false
 	slaveStatusFound := false
 	var resolveErr error
 
@@ -331,9 +377,18 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			goto Cleanup
 		}
 	}
+	if !isMaxScale {
+		if isRipple, err = instance.checkRipple(db, latency); err != nil && unrecoverableError(err) {
+			goto Cleanup
+		}
+	}
+	isBinlogServer = isMaxScale || isRipple
 
 	latency.Start("instance")
-	if isMaxScale {
+	if isRipple {
+		resolvedHostname = instance.Key.Hostname
+		partialSuccess = true
+	} else if isMaxScale {
 		if strings.Contains(instance.Version, "1.1.0") {
 			isMaxScale110 = true
 
@@ -415,14 +470,38 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			waitGroup.Add(1)
 			go func() {
 				defer waitGroup.Done()
-				err = sqlutils.QueryRowsMap(db, "show global status like 'rpl_semi_sync_%_status'", func(m sqlutils.RowMap) error {
-					if m.GetString("Variable_name") == "Rpl_semi_sync_master_status" {
+				err = sqlutils.QueryRowsMap(db, "show global status like 'rpl_semi_sync_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "Rpl_semi_sync_master_status":
 						instance.SemiSyncMasterEnabled = (m.GetString("Value") == "ON")
-					} else if m.GetString("Variable_name") == "Rpl_semi_sync_slave_status" {
+					case "Rpl_semi_sync_slave_status":
 						instance.SemiSyncReplicaEnabled = (m.GetString("Value") == "ON")
+					case "Rpl_semi_sync_master_clients":
+						instance.SemiSyncMasterClients = m.GetUint("Value")
+					case "Rpl_semi_sync_master_wait_sessions":
+						instance.SemiSyncMasterWaitSessions = m.GetUint("Value")
+					}
+					return nil
+				})
+				if err != nil {
+					logReadTopologyInstanceError(instanceKey, "show global status like 'rpl_semi_sync_%'", err)
+					return
+				}
+				semiSyncMasterPluginLoaded := false
+				semiSyncMasterWaitReplicaCount := uint(1) // rpl_semi_sync_master_wait_for_slave_count is only found on MySQL 5.7 and above
+				err = sqlutils.QueryRowsMap(db, "show global variables like 'rpl_semi_sync_master_%'", func(m sqlutils.RowMap) error {
+					switch m.GetString("Variable_name") {
+					case "rpl_semi_sync_master_timeout":
+						semiSyncMasterPluginLoaded = true
+						instance.SemiSyncMasterTimeout = m.GetUint("Value")
+					case "rpl_semi_sync_master_wait_for_slave_count":
+						semiSyncMasterWaitReplicaCount = m.GetUint("Value")
 					}
 					return nil
 				})
+				if semiSyncMasterPluginLoaded {
+					instance.SemiSyncMasterWaitReplicaCount = semiSyncMasterWaitReplicaCount
+				}
 			}()
 		}
 		if (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.6") {
@@ -501,14 +580,22 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		instance.ReadBinlogCoordinates.LogPos = m.GetInt64("Read_Master_Log_Pos")
 		instance.ExecBinlogCoordinates.LogFile = m.GetString("Relay_Master_Log_File")
 		instance.ExecBinlogCoordinates.LogPos = m.GetInt64("Exec_Master_Log_Pos")
+		if isRipple {
+			// Ripple has no SQL thread: whatever it reads, it has "executed" by writing to its own binary logs.
+			instance.ReplicationSQLThreadState = instance.ReplicationIOThreadState
+			instance.Slave_SQL_Running = instance.Slave_IO_Running
+			instance.ExecBinlogCoordinates = instance.ReadBinlogCoordinates
+		}
 		instance.IsDetached, _ = instance.ExecBinlogCoordinates.ExtractDetachedCoordinates()
 		instance.RelaylogCoordinates.LogFile = m.GetString("Relay_Log_File")
 		instance.RelaylogCoordinates.LogPos = m.GetInt64("Relay_Log_Pos")
 		instance.RelaylogCoordinates.Type = RelayLog
 		instance.LastSQLError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_SQL_Error")), "")
 		instance.LastIOError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(m.GetString("Last_IO_Error")), "")
+		instance.LastSQLErrno = m.GetIntD("Last_SQL_Errno", 0)
+		instance.LastIOErrno = m.GetIntD("Last_IO_Errno", 0)
 		instance.SQLDelay = m.GetUintD("SQL_Delay", 0)
-		instance.UsingOracleGTID = (m.GetIntD("Auto_Position", 0) == 1)
+		instance.UsingOracleGTID = (m.GetIntD("Auto_Position", 0) == 1) || isRipple
 		instance.UsingMariaDBGTID = (m.GetStringD("Using_Gtid", "No") != "No")
 		instance.MasterUUID = m.GetStringD("Master_UUID", "No")
 		instance.HasReplicationFilters = ((m.GetStringD("Replicate_Do_DB", "") != "") || (m.GetStringD("Replicate_Ignore_DB", "") != "") || (m.GetStringD("Replicate_Do_Table", "") != "") || (m.GetStringD("Replicate_Ignore_Table", "") != "") || (m.GetStringD("Replicate_Wild_Do_Table", "") != "") || (m.GetStringD("Replicate_Wild_Ignore_Table", "") != ""))
@@ -527,7 +614,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		if resolveErr != nil {
 			logReadTopologyInstanceError(instanceKey, fmt.Sprintf("ResolveHostname(%q)", masterKey.Hostname), resolveErr)
 		}
-		instance.MasterKey = *masterKey
+		instance.MasterKey = *connectKeyOf(masterKey)
 		instance.IsDetachedMaster = instance.MasterKey.IsDetached()
 		instance.SecondsBehindMaster = m.GetNullInt64("Seconds_Behind_Master")
 		if instance.SecondsBehindMaster.Valid && instance.SecondsBehindMaster.Int64 < 0 {
@@ -538,6 +625,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		instance.SlaveLagSeconds = instance.SecondsBehindMaster
 
 		instance.AllowTLS = (m.GetString("Master_SSL_Allowed") == "Yes")
+		instance.TLSVerifyServerCert = (m.GetString("Master_SSL_Verify_Server_Cert") == "Yes")
 		// Not breaking the flow even on error
 		slaveStatusFound = true
 		return nil
@@ -549,8 +637,29 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		err = fmt.Errorf("No 'SHOW SLAVE STATUS' output found for a MaxScale instance: %+v", instanceKey)
 		goto Cleanup
 	}
+	if slaveStatusFound && (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("8.0") {
+		// performance_schema tells of channels, and of parallel applier workers, where SHOW SLAVE STATUS does not.
+		// Thread states and errors of the default channel take precedence; binlog coordinates are only found above.
+		if channels, err := ReadReplicationChannels(db); err != nil {
+			logReadTopologyInstanceError(instanceKey, "ReadReplicationChannels", err)
+		} else {
+			instance.ReplicationChannels = channels
+			if channel := channels.DefaultChannel(); channel != nil {
+				instance.ReplicationIOThreadState = channel.IOThreadState
+				instance.ReplicationSQLThreadState = channel.SQLThreadState
+				instance.Slave_IO_Running = instance.ReplicationIOThreadState.IsRunning()
+				instance.Slave_SQL_Running = instance.ReplicationSQLThreadState.IsRunning()
+				instance.LastIOErrno = channel.LastIOErrno
+				instance.LastIOError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(channel.LastIOError), "")
+				if channel.LastSQLErrno != 0 {
+					instance.LastSQLErrno = channel.LastSQLErrno
+					instance.LastSQLError = emptyQuotesRegexp.ReplaceAllString(strconv.QuoteToASCII(channel.LastSQLError), "")
+				}
+			}
+		}
+	}
 
-	if config.Config.ReplicationLagQuery != "" && !isMaxScale {
+	if config.Config.ReplicationLagQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -566,6 +675,43 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if config.Config.HeartbeatIntervalMilliseconds > 0 && slaveStatusFound && !isBinlogServer {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			heartbeatLag, err := ReadHeartbeatLag(db)
+			if err != nil {
+				logReadTopologyInstanceError(instanceKey, "ReadHeartbeatLag", err)
+				return
+			}
+			instance.HeartbeatLagMilliseconds = heartbeatLag
+			if config.Config.ReplicationLagQuery == "" && heartbeatLag.Valid {
+				// ReplicationLagQuery, when given, takes precedence
+				instance.SlaveLagSeconds = sql.NullInt64{Int64: heartbeatLag.Int64 / 1000, Valid: true}
+			}
+		}()
+	}
+
+	if slaveStatusFound && (instance.IsOracleMySQL() || instance.IsPercona()) && !instance.IsSmallerMajorVersionByString("5.7") {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			// Zero timestamp stands for "no heartbeat received", which we read as NULL
+			err := db.QueryRow(`
+				select
+					unix_timestamp() - unix_timestamp(last_heartbeat_timestamp)
+				from
+					performance_schema.replication_connection_status
+				where
+					channel_name = ''
+					and last_heartbeat_timestamp > '1970-01-02'
+				`).Scan(&instance.SecondsSinceLastHeartbeat)
+			if err != nil && err != sql.ErrNoRows {
+				logReadTopologyInstanceError(instanceKey, "replication_connection_status", err)
+			}
+		}()
+	}
+
 	instanceFound = true
 
 	// -------------------------------------------------------------------------
@@ -574,8 +720,8 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	// -------------------------------------------------------------------------
 
 	// Get replicas, either by SHOW SLAVE HOSTS or via PROCESSLIST
-	// MaxScale does not support PROCESSLIST, so SHOW SLAVE HOSTS is the only option
-	if config.Config.DiscoverByShowSlaveHosts || isMaxScale {
+	// MaxScale and Ripple do not support PROCESSLIST, so SHOW SLAVE HOSTS is the only option
+	if config.Config.DiscoverByShowSlaveHosts || isBinlogServer {
 		err := sqlutils.QueryRowsMap(db, `show slave hosts`,
 			func(m sqlutils.RowMap) error {
 				// MaxScale 1.1 may trigger an error with this command, but
@@ -596,7 +742,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 				replicaKey, err := NewResolveInstanceKey(host, port)
 				if err == nil && replicaKey.IsValid() {
-					instance.AddReplicaKey(replicaKey)
+					instance.AddReplicaKey(connectKeyOf(replicaKey))
 					foundByShowSlaveHosts = true
 				}
 				return err
@@ -604,7 +750,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 
 		logReadTopologyInstanceError(instanceKey, "show slave hosts", err)
 	}
-	if !foundByShowSlaveHosts && !isMaxScale {
+	if !foundByShowSlaveHosts && !isBinlogServer {
 		// Either not configured to read SHOW SLAVE HOSTS or nothing was there.
 		// Discover by information_schema.processlist
 		waitGroup.Add(1)
@@ -612,19 +758,19 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			defer waitGroup.Done()
 			err := sqlutils.QueryRowsMap(db, `
       	select
-      		substring_index(host, ':', 1) as slave_hostname
+      		host as slave_host
       	from
       		information_schema.processlist
       	where
           command IN ('Binlog Dump', 'Binlog Dump GTID')
   		`,
 				func(m sqlutils.RowMap) error {
-					cname, resolveErr := ResolveHostname(m.GetString("slave_hostname"))
+					cname, resolveErr := ResolveHostname(hostnameOfHostPort(m.GetString("slave_host")))
 					if resolveErr != nil {
 						logReadTopologyInstanceError(instanceKey, "ResolveHostname: processlist", resolveErr)
 					}
 					replicaKey := InstanceKey{Hostname: cname, Port: instance.Key.Port}
-					instance.AddReplicaKey(&replicaKey)
+					instance.AddReplicaKey(connectKeyOf(&replicaKey))
 					return err
 				})
 
@@ -651,7 +797,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 						logReadTopologyInstanceError(instanceKey, "ResolveHostname: ndbinfo", resolveErr)
 					}
 					replicaKey := InstanceKey{Hostname: cname, Port: instance.Key.Port}
-					instance.AddReplicaKey(&replicaKey)
+					instance.AddReplicaKey(connectKeyOf(&replicaKey))
 					return err
 				})
 
@@ -659,7 +805,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectDataCenterQuery != "" && !isMaxScale {
+	if config.Config.DetectDataCenterQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -668,7 +814,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectRegionQuery != "" && !isMaxScale {
+	if config.Config.DetectRegionQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -677,7 +823,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectPhysicalEnvironmentQuery != "" && !isMaxScale {
+	if config.Config.DetectPhysicalEnvironmentQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -686,7 +832,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectInstanceAliasQuery != "" && !isMaxScale {
+	if config.Config.DetectInstanceAliasQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -695,7 +841,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
-	if config.Config.DetectSemiSyncEnforcedQuery != "" && !isMaxScale {
+	if config.Config.DetectSemiSyncEnforcedQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -704,6 +850,19 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 		}()
 	}
 
+	if config.Config.DetectRunningActivities && !isBinlogServer {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			runningActivity, err := ReadRunningActivity(db)
+			if err != nil {
+				logReadTopologyInstanceError(instanceKey, "ReadRunningActivity", err)
+				return
+			}
+			instance.RunningActivity = runningActivity
+		}()
+	}
+
 	{
 		latency.Start("backend")
 		err = ReadInstanceClusterAttributes(instance)
@@ -748,7 +907,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	// Then check if the instance wants to set a different PromotionRule.
 	// We'll set it here on their behalf so there's no race between the first
 	// time an instance is discovered, and setting a rule like "must_not".
-	if config.Config.DetectPromotionRuleQuery != "" && !isMaxScale {
+	if config.Config.DetectPromotionRuleQuery != "" && !isBinlogServer {
 		waitGroup.Add(1)
 		go func() {
 			defer waitGroup.Done()
@@ -769,7 +928,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 	}
 
 	ReadClusterAliasOverride(instance)
-	if !isMaxScale {
+	if !isBinlogServer {
 		if instance.SuggestedClusterAlias == "" {
 			// Only need to do on masters
 			if config.Config.DetectClusterAliasQuery != "" {
@@ -789,7 +948,7 @@ func ReadTopologyInstanceBufferable(instanceKey *InstanceKey, bufferWrites bool,
 			}
 		}
 	}
-	if instance.ReplicationDepth == 0 && config.Config.DetectClusterDomainQuery != "" && !isMaxScale {
+	if instance.ReplicationDepth == 0 && config.Config.DetectClusterDomainQuery != "" && !isBinlogServer {
 		// Only need to do on masters
 		domainName := ""
 		if err := db.QueryRow(config.Config.DetectClusterDomainQuery).Scan(&domainName); err != nil {
@@ -864,7 +1023,11 @@ Cleanup:
 	// tried to check the instance. last_attempted_check is also
 	// updated on success by writeInstance.
 	latency.Start("backend")
-	_ = UpdateInstanceLastChecked(&instance.Key, partialSuccess)
+	if bufferWrites {
+		enqueueInstanceLastCheckedUpdate(&instance.Key, partialSuccess)
+	} else {
+		_ = UpdateInstanceLastChecked(&instance.Key, partialSuccess)
+	}
 	latency.Stop("backend")
 	return nil, err
 }
@@ -1074,8 +1237,12 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.RelaylogCoordinates.LogPos = m.GetInt64("relay_log_pos")
 	instance.RelaylogCoordinates.Type = RelayLog
 	instance.LastSQLError = m.GetString("last_sql_error")
+	instance.LastSQLErrno = m.GetInt("last_sql_errno")
 	instance.LastIOError = m.GetString("last_io_error")
+	instance.LastIOErrno = m.GetInt("last_io_errno")
+	instance.SecondsSinceLastHeartbeat = m.GetNullInt64("seconds_since_last_heartbeat")
 	instance.SecondsBehindMaster = m.GetNullInt64("seconds_behind_master")
+	instance.HeartbeatLagMilliseconds = m.GetNullInt64("heartbeat_lag_milliseconds")
 	instance.SlaveLagSeconds = m.GetNullInt64("slave_lag_seconds")
 	instance.SQLDelay = m.GetUint("sql_delay")
 	slaveHostsJSON := m.GetString("slave_hosts")
@@ -1087,6 +1254,12 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.SemiSyncEnforced = m.GetBool("semi_sync_enforced")
 	instance.SemiSyncMasterEnabled = m.GetBool("semi_sync_master_enabled")
 	instance.SemiSyncReplicaEnabled = m.GetBool("semi_sync_replica_enabled")
+	instance.SemiSyncMasterTimeout = m.GetUint("semi_sync_master_timeout")
+	instance.SemiSyncMasterWaitReplicaCount = m.GetUint("semi_sync_master_wait_for_slave_count")
+	instance.SemiSyncMasterClients = m.GetUint("semi_sync_master_clients")
+	instance.SemiSyncMasterWaitSessions = m.GetUint("semi_sync_master_wait_sessions")
+	instance.RunningActivity = m.GetString("running_activity")
+	instance.ReplicationChannels.ReadJson(m.GetString("replication_channels"))
 	instance.ReplicationDepth = m.GetUint("replication_depth")
 	instance.IsCoMaster = m.GetBool("is_co_master")
 	instance.ReplicationCredentialsAvailable = m.GetBool("replication_credentials_available")
@@ -1103,8 +1276,13 @@ func readInstanceRow(m sqlutils.RowMap) *Instance {
 	instance.DowntimeOwner = m.GetString("downtime_owner")
 	instance.DowntimeEndTimestamp = m.GetString("downtime_end_timestamp")
 	instance.ElapsedDowntime = time.Second * time.Duration(m.GetInt("elapsed_downtime_seconds"))
+	instance.IsNoTouch = m.GetBool("is_no_touch")
+	instance.NoTouchOwner = m.GetString("no_touch_owner")
+	instance.NoTouchReason = m.GetString("no_touch_reason")
+	instance.NoTouchEndTimestamp = m.GetString("no_touch_end_timestamp")
 	instance.UnresolvedHostname = m.GetString("unresolved_hostname")
 	instance.AllowTLS = m.GetBool("allow_tls")
+	instance.TLSVerifyServerCert = m.GetBool("tls_verify_server_cert")
 	instance.InstanceAlias = m.GetString("instance_alias")
 	instance.LastDiscoveryLatency = time.Duration(m.GetInt64("last_discovery_latency")) * time.Nanosecond
 
@@ -1150,12 +1328,17 @@ func readInstancesByCondition(condition string, args []interface{}, sort string)
     	ifnull(database_instance_downtime.reason, '') as downtime_reason,
 			ifnull(database_instance_downtime.owner, '') as downtime_owner,
 			ifnull(unix_timestamp() - unix_timestamp(begin_timestamp), 0) as elapsed_downtime_seconds,
-    	ifnull(database_instance_downtime.end_timestamp, '') as downtime_end_timestamp
+    	ifnull(database_instance_downtime.end_timestamp, '') as downtime_end_timestamp,
+			(database_instance_no_touch.lock_end_timestamp is not null and database_instance_no_touch.lock_end_timestamp > now()) as is_no_touch,
+			ifnull(database_instance_no_touch.lock_owner, '') as no_touch_owner,
+			ifnull(database_instance_no_touch.lock_reason, '') as no_touch_reason,
+			ifnull(database_instance_no_touch.lock_end_timestamp, '') as no_touch_end_timestamp
 		from
 			database_instance
 			left join candidate_database_instance using (hostname, port)
 			left join hostname_unresolve using (hostname)
 			left join database_instance_downtime using (hostname, port)
+			left join database_instance_no_touch using (hostname, port)
 		where
 			%s
 		order by
@@ -1393,7 +1576,7 @@ func ReadFuzzyInstanceKey(fuzzyInstanceKey *InstanceKey) *InstanceKey {
 	if fuzzyInstanceKey == nil {
 		return nil
 	}
-	if fuzzyInstanceKey.IsIPv4() {
+	if fuzzyInstanceKey.IsIPv4() || fuzzyInstanceKey.IsIPv6() {
 		// avoid fuzziness. When looking for 10.0.0.1 we don't want to match 10.0.0.15!
 		return nil
 	}
@@ -1421,7 +1604,7 @@ func ReadFuzzyInstance(fuzzyInstanceKey *InstanceKey) (*Instance, error) {
 	if fuzzyInstanceKey == nil {
 		return nil, log.Errorf("ReadFuzzyInstance received nil input")
 	}
-	if fuzzyInstanceKey.IsIPv4() {
+	if fuzzyInstanceKey.IsIPv4() || fuzzyInstanceKey.IsIPv6() {
 		// avoid fuzziness. When looking for 10.0.0.1 we don't want to match 10.0.0.15!
 		instance, _, err := ReadInstance(fuzzyInstanceKey)
 		return instance, err
@@ -2077,12 +2260,12 @@ func ReadClustersInfo(clusterName string) ([]ClusterInfo, error) {
 // Get a listing of KVPair for clusters masters, for all clusters or for a specific cluster.
 func GetMastersKVPairs(clusterName string) (kvPairs [](*kv.KVPair), err error) {
 
-	clusterAliasMap := make(map[string]string)
+	clusterInfoMap := make(map[string]ClusterInfo)
 	if clustersInfo, err := ReadClustersInfo(clusterName); err != nil {
 		return kvPairs, err
 	} else {
 		for _, clusterInfo := range clustersInfo {
-			clusterAliasMap[clusterInfo.ClusterName] = clusterInfo.ClusterAlias
+			clusterInfoMap[clusterInfo.ClusterName] = clusterInfo
 		}
 	}
 
@@ -2091,7 +2274,8 @@ func GetMastersKVPairs(clusterName string) (kvPairs [](*kv.KVPair), err error) {
 		return kvPairs, err
 	}
 	for _, master := range masters {
-		clusterPairs := GetClusterMasterKVPairs(clusterAliasMap[master.ClusterName], &master.Key)
+		clusterInfo := clusterInfoMap[master.ClusterName]
+		clusterPairs := clusterInfo.GetMasterKVPairs(&master.Key)
 		kvPairs = append(kvPairs, clusterPairs...)
 	}
 
@@ -2162,6 +2346,28 @@ func ReadAllInstanceKeys() ([]InstanceKey, error) {
 	return res, log.Errore(err)
 }
 
+// ReadInstanceDiscoveryPartitions returns the datacenter, or the cluster name, of all known instances,
+// by which discoveries are partitioned
+func ReadInstanceDiscoveryPartitions(partitionBy string) (map[InstanceKey]string, error) {
+	res := make(map[InstanceKey]string)
+	query := `
+		select
+			hostname, port, data_center, cluster_name
+		from
+			database_instance
+			`
+	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		if partitionBy == "cluster" {
+			res[instanceKey] = m.GetString("cluster_name")
+		} else {
+			res[instanceKey] = m.GetString("data_center")
+		}
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
 // ReadAllInstanceKeysMasterKeys
 func ReadAllMinimalInstances() ([]MinimalInstance, error) {
 	res := []MinimalInstance{}
@@ -2198,7 +2404,23 @@ func ReadAllMinimalInstances() ([]MinimalInstance, error) {
 // resulted in an actual check! This can happen when TCP/IP connections are hung, in which case the "check"
 // never returns. In such case we multiply interval by a factor, so as not to open too many connections on
 // the instance.
+// With adaptive polling, instances are read once outdated by AdaptiveInstancePollMinSeconds, and
+// then filtered by their own effective poll interval.
 func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
+	if !config.Config.IsAdaptiveInstancePolling() {
+		return readOutdatedInstanceKeys(config.Config.InstancePollSeconds)
+	}
+	instanceKeys, err := readOutdatedInstanceKeys(config.Config.AdaptiveInstancePollMinSeconds)
+	res := []InstanceKey{}
+	for i := range instanceKeys {
+		if IsInstancePollDue(&instanceKeys[i]) {
+			res = append(res, instanceKeys[i])
+		}
+	}
+	return res, err
+}
+
+func readOutdatedInstanceKeys(pollSeconds uint) ([]InstanceKey, error) {
 	res := []InstanceKey{}
 	query := `
 		select
@@ -2212,7 +2434,7 @@ func ReadOutdatedInstanceKeys() ([]InstanceKey, error) {
 				else last_checked < now() - interval ? second
 			end
 			`
-	args := sqlutils.Args(config.Config.InstancePollSeconds, 2*config.Config.InstancePollSeconds)
+	args := sqlutils.Args(pollSeconds, 2*pollSeconds)
 
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		instanceKey, merr := NewResolveInstanceKey(m.GetString("hostname"), m.GetInt("port"))
@@ -2330,8 +2552,12 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"relay_log_file",
 		"relay_log_pos",
 		"last_sql_error",
+		"last_sql_errno",
 		"last_io_error",
+		"last_io_errno",
+		"seconds_since_last_heartbeat",
 		"seconds_behind_master",
+		"heartbeat_lag_milliseconds",
 		"slave_lag_seconds",
 		"sql_delay",
 		"num_slave_hosts",
@@ -2346,9 +2572,16 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		"replication_credentials_available",
 		"has_replication_credentials",
 		"allow_tls",
+		"tls_verify_server_cert",
 		"semi_sync_enforced",
 		"semi_sync_master_enabled",
 		"semi_sync_replica_enabled",
+		"semi_sync_master_timeout",
+		"semi_sync_master_wait_for_slave_count",
+		"semi_sync_master_clients",
+		"semi_sync_master_wait_sessions",
+		"running_activity",
+		"replication_channels",
 		"instance_alias",
 		"last_discovery_latency",
 	}
@@ -2410,8 +2643,12 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.RelaylogCoordinates.LogFile)
 		args = append(args, instance.RelaylogCoordinates.LogPos)
 		args = append(args, instance.LastSQLError)
+		args = append(args, instance.LastSQLErrno)
 		args = append(args, instance.LastIOError)
+		args = append(args, instance.LastIOErrno)
+		args = append(args, instance.SecondsSinceLastHeartbeat)
 		args = append(args, instance.SecondsBehindMaster)
+		args = append(args, instance.HeartbeatLagMilliseconds)
 		args = append(args, instance.SlaveLagSeconds)
 		args = append(args, instance.SQLDelay)
 		args = append(args, len(instance.SlaveHosts))
@@ -2426,9 +2663,16 @@ func mkInsertOdkuForInstances(instances []*Instance, instanceWasActuallyFound bo
 		args = append(args, instance.ReplicationCredentialsAvailable)
 		args = append(args, instance.HasReplicationCredentials)
 		args = append(args, instance.AllowTLS)
+		args = append(args, instance.TLSVerifyServerCert)
 		args = append(args, instance.SemiSyncEnforced)
 		args = append(args, instance.SemiSyncMasterEnabled)
 		args = append(args, instance.SemiSyncReplicaEnabled)
+		args = append(args, instance.SemiSyncMasterTimeout)
+		args = append(args, instance.SemiSyncMasterWaitReplicaCount)
+		args = append(args, instance.SemiSyncMasterClients)
+		args = append(args, instance.SemiSyncMasterWaitSessions)
+		args = append(args, instance.RunningActivity)
+		args = append(args, instance.ReplicationChannels.ToJSONString())
 		args = append(args, instance.InstanceAlias)
 		args = append(args, instance.LastDiscoveryLatency.Nanoseconds())
 	}
@@ -2468,6 +2712,8 @@ type instanceUpdateObject struct {
 	instance                 *Instance
 	instanceWasActuallyFound bool
 	lastError                error
+	lastCheckedOnly          bool // a failed check, only updating last_checked
+	partialSuccess           bool
 }
 
 // instances sorter by instanceKey
@@ -2480,16 +2726,34 @@ func (a byInstanceKey) Less(i, j int) bool { return a[i].Key.SmallerThan(&a[j].K
 var instanceWriteBuffer chan instanceUpdateObject
 var forceFlushInstanceWriteBuffer = make(chan bool)
 
+// signalInstanceWriteBufferFlush signals the "flushing" goroutine that there's work, unless it is already
+// busy flushing. We prefer doing all bulk flushes from one goroutine.
+func signalInstanceWriteBufferFlush() {
+	select {
+	case forceFlushInstanceWriteBuffer <- true:
+	default:
+	}
+}
+
 func enqueueInstanceWrite(instance *Instance, instanceWasActuallyFound bool, lastError error) {
 	if len(instanceWriteBuffer) == config.Config.InstanceWriteBufferSize {
-		// Signal the "flushing" gorouting that there's work.
-		// We prefer doing all bulk flushes from one goroutine.
-		forceFlushInstanceWriteBuffer <- true
+		signalInstanceWriteBufferFlush()
+	}
+	instanceWriteBuffer <- instanceUpdateObject{instance: instance, instanceWasActuallyFound: instanceWasActuallyFound, lastError: lastError}
+}
+
+// enqueueInstanceLastCheckedUpdate buffers a last_checked update, to be flushed along with buffered instance writes
+func enqueueInstanceLastCheckedUpdate(instanceKey *InstanceKey, partialSuccess bool) {
+	if len(instanceWriteBuffer) == config.Config.InstanceWriteBufferSize {
+		signalInstanceWriteBufferFlush()
 	}
-	instanceWriteBuffer <- instanceUpdateObject{instance, instanceWasActuallyFound, lastError}
+	instanceWriteBuffer <- instanceUpdateObject{instance: &Instance{Key: *instanceKey}, lastCheckedOnly: true, partialSuccess: partialSuccess}
 }
 
-// flushInstanceWriteBuffer saves enqueued instances to Orchestrator Db
+// flushInstanceWriteBuffer saves enqueued instances to Orchestrator Db. An instance enqueued more than
+// once since the last flush is only written once, with its latest state. Failed checks, which only update
+// last_checked, are written after instance writes; a failed check followed by an instance write is
+// superseded by the latter, which updates last_checked by itself.
 func flushInstanceWriteBuffer() {
 	var instances []*Instance
 	var lastseen []*Instance // instances to update with last_seen field
@@ -2498,8 +2762,29 @@ func flushInstanceWriteBuffer() {
 		return
 	}
 
-	for i := 0; i < len(instanceWriteBuffer); i++ {
+	// Only drain what's buffered by now; writers may keep enqueueing while we flush
+	latestUpdates := make(map[InstanceKey]instanceUpdateObject)
+	latestLastCheckedUpdates := make(map[InstanceKey]instanceUpdateObject)
+	for countBuffered := len(instanceWriteBuffer); countBuffered > 0; countBuffered-- {
 		upd := <-instanceWriteBuffer
+		if _, found := latestLastCheckedUpdates[upd.instance.Key]; found {
+			writeBufferCoalescedCounter.Inc(1)
+			delete(latestLastCheckedUpdates, upd.instance.Key)
+		}
+		if upd.lastCheckedOnly {
+			latestLastCheckedUpdates[upd.instance.Key] = upd
+			continue
+		}
+		if _, found := latestUpdates[upd.instance.Key]; found {
+			writeBufferCoalescedCounter.Inc(1)
+		}
+		latestUpdates[upd.instance.Key] = upd
+	}
+	lastCheckedKeys := map[bool][]InstanceKey{}
+	for instanceKey, upd := range latestLastCheckedUpdates {
+		lastCheckedKeys[upd.partialSuccess] = append(lastCheckedKeys[upd.partialSuccess], instanceKey)
+	}
+	for _, upd := range latestUpdates {
 		if upd.instanceWasActuallyFound && upd.lastError == nil {
 			lastseen = append(lastseen, upd.instance)
 		} else {
@@ -2507,6 +2792,7 @@ func flushInstanceWriteBuffer() {
 			log.Debugf("flushInstanceWriteBuffer: will not update database_instance.last_seen due to error: %+v", upd.lastError)
 		}
 	}
+	writeBufferFlushCounter.Inc(1)
 	// sort instances by instanceKey (table pk) to make locking predictable
 	sort.Sort(byInstanceKey(instances))
 	sort.Sort(byInstanceKey(lastseen))
@@ -2520,6 +2806,12 @@ func flushInstanceWriteBuffer() {
 		if err != nil {
 			return log.Errorf("flushInstanceWriteBuffer last_seen: %v", err)
 		}
+		for partialSuccess, keys := range lastCheckedKeys {
+			sql, args := mkUpdateLastCheckedForInstances(keys, partialSuccess)
+			if _, err := db.ExecOrchestrator(sql, args...); err != nil {
+				return log.Errorf("flushInstanceWriteBuffer last_checked: %v", err)
+			}
+		}
 
 		writeInstanceCounter.Inc(int64(len(instances) + len(lastseen)))
 		return nil
@@ -2539,6 +2831,29 @@ func WriteInstance(instance *Instance, instanceWasActuallyFound bool, lastError
 	return writeManyInstances([]*Instance{instance}, instanceWasActuallyFound, true)
 }
 
+// mkUpdateLastCheckedForInstances returns a single statement updating last_checked for given instances
+func mkUpdateLastCheckedForInstances(instanceKeys []InstanceKey, partialSuccess bool) (string, []interface{}) {
+	if len(instanceKeys) == 0 {
+		return "", nil
+	}
+	conditions := make([]string, len(instanceKeys))
+	args := []interface{}{partialSuccess}
+	for i, instanceKey := range instanceKeys {
+		conditions[i] = "(hostname = ? and port = ?)"
+		args = append(args, instanceKey.Hostname, instanceKey.Port)
+	}
+	sql := fmt.Sprintf(`
+		update
+			database_instance
+		set
+			last_checked = NOW(),
+			last_check_partial_success = ?
+		where
+			%s
+		`, strings.Join(conditions, " or "))
+	return sql, args
+}
+
 // UpdateInstanceLastChecked updates the last_check timestamp in the orchestrator backed database
 // for a given instance
 func UpdateInstanceLastChecked(instanceKey *InstanceKey, partialSuccess bool) error {
@@ -2599,6 +2914,7 @@ func ForgetInstance(instanceKey *InstanceKey) error {
 		return log.Errorf("ForgetInstance(): nil instanceKey")
 	}
 	forgetInstanceKeys.Set(instanceKey.StringCode(), true, cache.DefaultExpiration)
+	ForgetInstancePollInterval(instanceKey)
 	sqlResult, err := db.ExecOrchestrator(`
 			delete
 				from database_instance
@@ -19,6 +19,7 @@ package inst
 import (
 	"fmt"
 	"github.com/github/orchestrator/go/config"
+	"net"
 	"regexp"
 	"strconv"
 	"strings"
@@ -30,12 +31,17 @@ type InstanceKey struct {
 	Port     int
 }
 
+// ipv6AddressPattern matches an IPv6 address, optionally ending with an IPv4 address and a zone, as in
+// ::ffff:10.0.0.1 or fe80::1%eth0
+const ipv6AddressPattern = `[:0-9a-fA-F]+(?:[0-9]+[.][0-9]+[.][0-9]+[.][0-9]+)?(?:%[^\]]+)?`
+
 var (
 	ipv4Regexp         = regexp.MustCompile("^([0-9]+)[.]([0-9]+)[.]([0-9]+)[.]([0-9]+)$")
 	ipv4HostPortRegexp = regexp.MustCompile("^([^:]+):([0-9]+)$")
 	ipv4HostRegexp     = regexp.MustCompile("^([^:]+)$")
-	ipv6HostPortRegexp = regexp.MustCompile("^\\[([:0-9a-fA-F]+)\\]:([0-9]+)$") // e.g. [2001:db8:1f70::999:de8:7648:6e8]:3308
-	ipv6HostRegexp     = regexp.MustCompile("^([:0-9a-fA-F]+)$")                // e.g. 2001:db8:1f70::999:de8:7648:6e8
+	ipv6HostPortRegexp = regexp.MustCompile("^\\[(" + ipv6AddressPattern + ")\\]:([0-9]+)$") // e.g. [2001:db8:1f70::999:de8:7648:6e8]:3308
+	ipv6BracketRegexp  = regexp.MustCompile("^\\[(" + ipv6AddressPattern + ")\\]$")          // e.g. [2001:db8:1f70::999:de8:7648:6e8]
+	ipv6HostRegexp     = regexp.MustCompile("^(" + ipv6AddressPattern + ")$")                // e.g. 2001:db8:1f70::999:de8:7648:6e8
 )
 
 const detachHint = "//"
@@ -61,6 +67,13 @@ func newInstanceKeyStrings(hostname string, port string, resolve bool) (*Instanc
 	}
 }
 func parseRawInstanceKey(hostPort string, resolve bool) (instanceKey *InstanceKey, err error) {
+	if strings.HasPrefix(hostPort, detachHint) {
+		// A detached key, e.g. //[2001:db8::1]:3306, as listed by StringCode()
+		if instanceKey, err = parseRawInstanceKey(hostPort[len(detachHint):], false); err != nil {
+			return instanceKey, err
+		}
+		return instanceKey.DetachedKey(), nil
+	}
 	hostname := ""
 	port := ""
 	if submatch := ipv4HostPortRegexp.FindStringSubmatch(hostPort); len(submatch) > 0 {
@@ -71,6 +84,8 @@ func parseRawInstanceKey(hostPort string, resolve bool) (instanceKey *InstanceKe
 	} else if submatch := ipv6HostPortRegexp.FindStringSubmatch(hostPort); len(submatch) > 0 {
 		hostname = submatch[1]
 		port = submatch[2]
+	} else if submatch := ipv6BracketRegexp.FindStringSubmatch(hostPort); len(submatch) > 0 {
+		hostname = submatch[1]
 	} else if submatch := ipv6HostRegexp.FindStringSubmatch(hostPort); len(submatch) > 0 {
 		hostname = submatch[1]
 	} else {
@@ -82,6 +97,28 @@ func parseRawInstanceKey(hostPort string, resolve bool) (instanceKey *InstanceKe
 	return newInstanceKeyStrings(hostname, port, resolve)
 }
 
+// parseHostPortInstanceKey parses a "hostname:port" string, as concatenated by SQL, where the hostname may be
+// an unbracketed IPv6 address. The port follows the last colon.
+func parseHostPortInstanceKey(hostPort string) (*InstanceKey, error) {
+	i := strings.LastIndex(hostPort, ":")
+	if i < 0 {
+		return nil, fmt.Errorf("Cannot parse address: %s", hostPort)
+	}
+	return newInstanceKeyStrings(hostPort[:i], hostPort[i+1:], false)
+}
+
+// hostnameOfHostPort strips the port off a "hostname:port" string, e.g. a processlist host, where the hostname
+// may be an IPv6 address, bracketed or not
+func hostnameOfHostPort(hostPort string) string {
+	if hostname, _, err := net.SplitHostPort(hostPort); err == nil {
+		return hostname
+	}
+	if i := strings.LastIndex(hostPort, ":"); i >= 0 {
+		return hostPort[:i]
+	}
+	return hostPort
+}
+
 func NewResolveInstanceKey(hostname string, port int) (instanceKey *InstanceKey, err error) {
 	return newInstanceKey(hostname, port, true)
 }
@@ -110,6 +147,9 @@ func (this *InstanceKey) ResolveHostname() (*InstanceKey, error) {
 		return this, nil
 	}
 
+	if rule := matchHostnameRule(readHostnameRules(), this.Hostname); rule != nil {
+		this.Port = mapPort(rule, this.Port)
+	}
 	hostname, err := ResolveHostname(this.Hostname)
 	if err == nil {
 		this.Hostname = hostname
@@ -169,9 +209,13 @@ func (this *InstanceKey) ReattachedKey() *InstanceKey {
 	return &InstanceKey{Hostname: this.Hostname[len(detachHint):], Port: this.Port}
 }
 
-// StringCode returns an official string representation of this key
+// StringCode returns an official string representation of this key. IPv6 addresses are bracketed, as in
+// [2001:db8::1]:3306; a detached key keeps its hint ahead of the brackets, as in //[2001:db8::1]:3306
 func (this *InstanceKey) StringCode() string {
-	return fmt.Sprintf("%s:%d", this.Hostname, this.Port)
+	if this.IsDetached() {
+		return detachHint + this.ReattachedKey().StringCode()
+	}
+	return net.JoinHostPort(this.Hostname, strconv.Itoa(this.Port))
 }
 
 // DisplayString returns a user-friendly string representation of this key
@@ -188,3 +232,12 @@ func (this InstanceKey) String() string {
 func (this *InstanceKey) IsIPv4() bool {
 	return ipv4Regexp.MatchString(this.Hostname)
 }
+
+// IsIPv6 returns true when this key's hostname is an IPv6 address
+func (this *InstanceKey) IsIPv6() bool {
+	address := this.Hostname
+	if i := strings.Index(address, "%"); i >= 0 {
+		address = address[:i]
+	}
+	return strings.Contains(address, ":") && net.ParseIP(address) != nil
+}
@@ -139,3 +139,19 @@ func (this *InstanceKeyMap) ReadCommaDelimitedList(list string) error {
 	}
 	return nil
 }
+
+// ReadConcatenatedList reads a comma delimited list of "hostname:port" keys, as concatenated by SQL, where
+// hostnames may be unbracketed IPv6 addresses
+func (this *InstanceKeyMap) ReadConcatenatedList(list string) error {
+	if list == "" {
+		return nil
+	}
+	for _, token := range strings.Split(list, ",") {
+		key, err := parseHostPortInstanceKey(token)
+		if err != nil {
+			return err
+		}
+		this.AddKey(*key)
+	}
+	return nil
+}
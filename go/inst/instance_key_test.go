@@ -131,6 +131,72 @@ func TestParseResolveInstanceKey(t *testing.T) {
 	}
 }
 
+func TestParseIPv6InstanceKey(t *testing.T) {
+	{
+		key, err := ParseRawInstanceKey("[fe80::1%eth0]:3307")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(key.Hostname, "fe80::1%eth0")
+		test.S(t).ExpectEquals(key.Port, 3307)
+		test.S(t).ExpectEquals(key.StringCode(), "[fe80::1%eth0]:3307")
+	}
+	{
+		key, err := ParseRawInstanceKey("[2001:db8::1]")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(key.Hostname, "2001:db8::1")
+		test.S(t).ExpectEquals(key.Port, 3306)
+	}
+	{
+		key, err := ParseRawInstanceKey("::ffff:10.0.0.1")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(key.Hostname, "::ffff:10.0.0.1")
+		test.S(t).ExpectEquals(key.Port, 3306)
+	}
+	{
+		key, err := parseHostPortInstanceKey("2001:db8::1:3308")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(key.Hostname, "2001:db8::1")
+		test.S(t).ExpectEquals(key.Port, 3308)
+	}
+	{
+		keyMap := NewInstanceKeyMap()
+		err := keyMap.ReadConcatenatedList("2001:db8::1:3306,db1:3307")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(keyMap.HasKey(InstanceKey{Hostname: "2001:db8::1", Port: 3306}))
+		test.S(t).ExpectTrue(keyMap.HasKey(InstanceKey{Hostname: "db1", Port: 3307}))
+	}
+	test.S(t).ExpectEquals(hostnameOfHostPort("2001:db8::1:51234"), "2001:db8::1")
+	test.S(t).ExpectEquals(hostnameOfHostPort("[2001:db8::1]:51234"), "2001:db8::1")
+	test.S(t).ExpectEquals(hostnameOfHostPort("10.0.0.1:51234"), "10.0.0.1")
+	test.S(t).ExpectEquals(hostnameOfHostPort("localhost"), "localhost")
+}
+
+func TestInstanceKeyStringCode(t *testing.T) {
+	test.S(t).ExpectEquals(key1.StringCode(), "host1:3306")
+	test.S(t).ExpectEquals((&InstanceKey{Hostname: "10.0.0.1", Port: 3306}).StringCode(), "10.0.0.1:3306")
+	test.S(t).ExpectEquals((&InstanceKey{Hostname: "2001:db8::1", Port: 3306}).StringCode(), "[2001:db8::1]:3306")
+	test.S(t).ExpectEquals(key1.DetachedKey().StringCode(), "//host1:3306")
+	test.S(t).ExpectEquals((&InstanceKey{Hostname: "2001:db8::1", Port: 3306}).DetachedKey().StringCode(), "//[2001:db8::1]:3306")
+}
+
+func TestParseDetachedInstanceKey(t *testing.T) {
+	for _, instanceKey := range []InstanceKey{key1, {Hostname: "2001:db8::1", Port: 3307}} {
+		detachedKey := instanceKey.DetachedKey()
+		parsedKey, err := ParseRawInstanceKey(detachedKey.StringCode())
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectTrue(parsedKey.IsDetached())
+		test.S(t).ExpectTrue(parsedKey.Equals(detachedKey))
+		test.S(t).ExpectTrue(parsedKey.ReattachedKey().Equals(&instanceKey))
+	}
+}
+
+func TestIsIPv6(t *testing.T) {
+	test.S(t).ExpectFalse(key1.IsIPv6())
+	test.S(t).ExpectFalse((&InstanceKey{Hostname: "10.0.0.1", Port: 3306}).IsIPv6())
+	test.S(t).ExpectTrue((&InstanceKey{Hostname: "2001:db8::1", Port: 3306}).IsIPv6())
+	test.S(t).ExpectTrue((&InstanceKey{Hostname: "fe80::1%eth0", Port: 3306}).IsIPv6())
+	test.S(t).ExpectFalse((&InstanceKey{Hostname: "127::0::0::1", Port: 3306}).IsIPv6())
+}
+
 func TestNewResolveInstanceKeyStrings(t *testing.T) {
 	{
 		i, err := NewResolveInstanceKeyStrings("127.0.0.1", "3306")
@@ -0,0 +1,228 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// With adaptive polling, an instance found to be unstable (replication errors, lag spikes, changed
+// replication state, a recent recovery) is polled every AdaptiveInstancePollMinSeconds. Every stable
+// poll doubles its interval, up to AdaptiveInstancePollMaxSeconds. Masters and intermediate masters are
+// never polled less often than every InstancePollSeconds, as failure detection depends on them.
+
+// InstancePollInterval describes the interval at which an instance is polled
+type InstancePollInterval struct {
+	Key                      InstanceKey
+	EffectiveIntervalSeconds uint
+	IsStable                 bool
+	LastInstabilityReason    string
+	LastInstabilityTimestamp string
+	LastPollTimestamp        string
+
+	lastPoll time.Time
+}
+
+var instancePollIntervals = make(map[InstanceKey]*InstancePollInterval)
+var instancePollIntervalsMutex sync.Mutex
+
+// basePollIntervalSeconds is the interval of an instance not evaluated yet, or whose last poll failed
+func basePollIntervalSeconds() uint {
+	return config.Config.InstancePollSeconds
+}
+
+// GetInstanceInstabilityReason returns the reason by which an instance is considered unstable,
+// given its state before and after a poll, or an empty string if it is stable
+func GetInstanceInstabilityReason(before *Instance, after *Instance) string {
+	if after.LastSQLError != "" || after.LastIOError != "" {
+		return "replication error"
+	}
+	if after.ReplicationThreadsExist() && !after.ReplicaRunning() {
+		return "replication not running"
+	}
+	if after.SlaveLagSeconds.Valid && after.SlaveLagSeconds.Int64 > int64(config.Config.ReasonableReplicationLagSeconds) {
+		return "replication lag"
+	}
+	if before == nil {
+		return ""
+	}
+	if !before.MasterKey.Equals(&after.MasterKey) {
+		return "master changed"
+	}
+	if before.ReadOnly != after.ReadOnly {
+		return "read_only changed"
+	}
+	if before.ReplicaRunning() != after.ReplicaRunning() {
+		return "replication state changed"
+	}
+	return ""
+}
+
+// maxPollIntervalSeconds is the longest interval at which given instance may be polled
+func maxPollIntervalSeconds(instance *Instance) uint {
+	if instance.IsMaster() || len(instance.SlaveHosts) > 0 {
+		return basePollIntervalSeconds()
+	}
+	return config.Config.AdaptiveInstancePollMaxSeconds
+}
+
+// nextPollIntervalSeconds computes an instance's poll interval following a poll
+func nextPollIntervalSeconds(currentIntervalSeconds uint, isStable bool, maxIntervalSeconds uint) uint {
+	if !isStable {
+		return config.Config.AdaptiveInstancePollMinSeconds
+	}
+	nextIntervalSeconds := 2 * currentIntervalSeconds
+	if nextIntervalSeconds > maxIntervalSeconds {
+		nextIntervalSeconds = maxIntervalSeconds
+	}
+	return nextIntervalSeconds
+}
+
+func getOrCreateInstancePollInterval(instanceKey *InstanceKey) *InstancePollInterval {
+	pollInterval, found := instancePollIntervals[*instanceKey]
+	if !found {
+		pollInterval = &InstancePollInterval{
+			Key:                      *instanceKey,
+			EffectiveIntervalSeconds: basePollIntervalSeconds(),
+			IsStable:                 true,
+		}
+		instancePollIntervals[*instanceKey] = pollInterval
+	}
+	return pollInterval
+}
+
+func (this *InstancePollInterval) markUnstable(reason string) {
+	this.IsStable = false
+	this.LastInstabilityReason = reason
+	this.LastInstabilityTimestamp = time.Now().Format("2006-01-02 15:04:05")
+	this.EffectiveIntervalSeconds = nextPollIntervalSeconds(this.EffectiveIntervalSeconds, false, config.Config.AdaptiveInstancePollMaxSeconds)
+}
+
+// RecordInstancePoll adapts the poll interval of an instance following a successful poll
+func RecordInstancePoll(before *Instance, after *Instance) {
+	if !config.Config.IsAdaptiveInstancePolling() {
+		return
+	}
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	pollInterval := getOrCreateInstancePollInterval(&after.Key)
+	pollInterval.lastPoll = time.Now()
+	pollInterval.LastPollTimestamp = pollInterval.lastPoll.Format("2006-01-02 15:04:05")
+	if reason := GetInstanceInstabilityReason(before, after); reason != "" {
+		pollInterval.markUnstable(reason)
+		return
+	}
+	pollInterval.IsStable = true
+	pollInterval.EffectiveIntervalSeconds = nextPollIntervalSeconds(pollInterval.EffectiveIntervalSeconds, true, maxPollIntervalSeconds(after))
+}
+
+// RecordInstancePollFailure resets the poll interval of an instance which could not be polled. Such
+// an instance is not polled aggressively, as it would take up discovery workers waiting on timeouts.
+func RecordInstancePollFailure(instanceKey *InstanceKey) {
+	if !config.Config.IsAdaptiveInstancePolling() {
+		return
+	}
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	pollInterval := getOrCreateInstancePollInterval(instanceKey)
+	pollInterval.lastPoll = time.Now()
+	pollInterval.LastPollTimestamp = pollInterval.lastPoll.Format("2006-01-02 15:04:05")
+	pollInterval.IsStable = false
+	pollInterval.LastInstabilityReason = "poll failed"
+	pollInterval.LastInstabilityTimestamp = pollInterval.LastPollTimestamp
+	pollInterval.EffectiveIntervalSeconds = basePollIntervalSeconds()
+}
+
+// MarkInstancesUnstable has given instances polled aggressively, e.g. following a recovery they took part in
+func MarkInstancesUnstable(instanceKeys []InstanceKey, reason string) {
+	if !config.Config.IsAdaptiveInstancePolling() {
+		return
+	}
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	for i := range instanceKeys {
+		getOrCreateInstancePollInterval(&instanceKeys[i]).markUnstable(reason)
+	}
+}
+
+// GetInstancePollInterval returns the effective poll interval of an instance
+func GetInstancePollInterval(instanceKey *InstanceKey) time.Duration {
+	if !config.Config.IsAdaptiveInstancePolling() {
+		return time.Duration(config.Config.InstancePollSeconds) * time.Second
+	}
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	if pollInterval, found := instancePollIntervals[*instanceKey]; found {
+		return time.Duration(pollInterval.EffectiveIntervalSeconds) * time.Second
+	}
+	return time.Duration(basePollIntervalSeconds()) * time.Second
+}
+
+// IsInstancePollDue checks whether an instance's effective poll interval has passed since it was last polled
+// by this node. An instance not polled by this node is due.
+func IsInstancePollDue(instanceKey *InstanceKey) bool {
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	pollInterval, found := instancePollIntervals[*instanceKey]
+	if !found || pollInterval.lastPoll.IsZero() {
+		return true
+	}
+	return time.Since(pollInterval.lastPoll) >= time.Duration(pollInterval.EffectiveIntervalSeconds)*time.Second
+}
+
+// ReadInstancePollIntervals returns the poll intervals of all instances polled by this node
+func ReadInstancePollIntervals() []InstancePollInterval {
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	res := []InstancePollInterval{}
+	for _, pollInterval := range instancePollIntervals {
+		res = append(res, *pollInterval)
+	}
+	return res
+}
+
+// ReadInstancePollInterval returns the poll interval of given instance
+func ReadInstancePollInterval(instanceKey *InstanceKey) InstancePollInterval {
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	if pollInterval, found := instancePollIntervals[*instanceKey]; found {
+		return *pollInterval
+	}
+	return InstancePollInterval{
+		Key:                      *instanceKey,
+		EffectiveIntervalSeconds: basePollIntervalSeconds(),
+		IsStable:                 true,
+	}
+}
+
+// ForgetInstancePollInterval removes the poll interval state of a forgotten instance
+func ForgetInstancePollInterval(instanceKey *InstanceKey) {
+	instancePollIntervalsMutex.Lock()
+	defer instancePollIntervalsMutex.Unlock()
+
+	delete(instancePollIntervals, *instanceKey)
+}
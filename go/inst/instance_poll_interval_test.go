@@ -0,0 +1,105 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func withAdaptiveInstancePolling(f func()) {
+	defer func(maxSeconds, minSeconds uint) {
+		config.Config.AdaptiveInstancePollMaxSeconds = maxSeconds
+		config.Config.AdaptiveInstancePollMinSeconds = minSeconds
+		instancePollIntervals = make(map[InstanceKey]*InstancePollInterval)
+	}(config.Config.AdaptiveInstancePollMaxSeconds, config.Config.AdaptiveInstancePollMinSeconds)
+
+	config.Config.AdaptiveInstancePollMaxSeconds = 8 * config.Config.InstancePollSeconds
+	config.Config.AdaptiveInstancePollMinSeconds = 1
+	f()
+}
+
+func TestGetInstanceInstabilityReason(t *testing.T) {
+	before := &Instance{Key: i710Key, MasterKey: i720Key, ReadOnly: true, ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning}
+	before.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql.000007", LogPos: 10}
+	after := &Instance{}
+	*after = *before
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(before, after), "")
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(nil, after), "")
+
+	after.ReadOnly = false
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(before, after), "read_only changed")
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(nil, after), "")
+
+	after.MasterKey = i730Key
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(before, after), "master changed")
+
+	after.LastSQLError = "Duplicate entry"
+	test.S(t).ExpectEquals(GetInstanceInstabilityReason(nil, after), "replication error")
+}
+
+func TestAdaptiveInstancePollInterval(t *testing.T) {
+	withAdaptiveInstancePolling(func() {
+		base := time.Duration(config.Config.InstancePollSeconds) * time.Second
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), base)
+		test.S(t).ExpectTrue(IsInstancePollDue(&i710Key))
+
+		stable := &Instance{Key: i710Key, MasterKey: i720Key, ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning}
+		stable.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql.000007", LogPos: 10}
+		RecordInstancePoll(nil, stable)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), 2*base)
+		test.S(t).ExpectFalse(IsInstancePollDue(&i710Key))
+		RecordInstancePoll(stable, stable)
+		RecordInstancePoll(stable, stable)
+		RecordInstancePoll(stable, stable)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), 8*base)
+
+		MarkInstancesUnstable([]InstanceKey{i710Key}, "recovery")
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), time.Second)
+		test.S(t).ExpectEquals(ReadInstancePollInterval(&i710Key).LastInstabilityReason, "recovery")
+		test.S(t).ExpectFalse(ReadInstancePollInterval(&i710Key).IsStable)
+
+		RecordInstancePoll(stable, stable)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), 2*time.Second)
+		test.S(t).ExpectTrue(ReadInstancePollInterval(&i710Key).IsStable)
+
+		RecordInstancePollFailure(&i710Key)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), base)
+	})
+}
+
+func TestAdaptiveInstancePollIntervalMasters(t *testing.T) {
+	withAdaptiveInstancePolling(func() {
+		base := time.Duration(config.Config.InstancePollSeconds) * time.Second
+
+		master := &Instance{Key: i720Key, ReplicationIOThreadState: ReplicationThreadStateNoThread, ReplicationSQLThreadState: ReplicationThreadStateNoThread}
+		RecordInstancePoll(master, master)
+		RecordInstancePoll(master, master)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i720Key), base)
+
+		intermediateMaster := &Instance{Key: i710Key, MasterKey: i720Key, ReplicationIOThreadState: ReplicationThreadStateRunning, ReplicationSQLThreadState: ReplicationThreadStateRunning}
+		intermediateMaster.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql.000007", LogPos: 10}
+		intermediateMaster.SlaveHosts = *NewInstanceKeyMap()
+		intermediateMaster.SlaveHosts.AddKey(i730Key)
+		RecordInstancePoll(intermediateMaster, intermediateMaster)
+		RecordInstancePoll(intermediateMaster, intermediateMaster)
+		test.S(t).ExpectEquals(GetInstancePollInterval(&i710Key), base)
+	})
+}
@@ -17,6 +17,8 @@
 package inst
 
 import (
+	"database/sql"
+
 	"github.com/github/orchestrator/go/config"
 	"github.com/openark/golib/log"
 	test "github.com/openark/golib/tests"
@@ -238,3 +240,52 @@ func TestReplicationThreads(t *testing.T) {
 		test.S(t).ExpectFalse(i.ReplicationThreadsExist())
 	}
 }
+
+func TestHasReasonableMaintenanceReplicationLag(t *testing.T) {
+	{
+		i := Instance{Key: key1, SecondsBehindMaster: sql.NullInt64{Int64: 5, Valid: true}}
+		test.S(t).ExpectTrue(i.HasReasonableMaintenanceReplicationLag())
+		i.SecondsBehindMaster.Int64 = 50
+		test.S(t).ExpectFalse(i.HasReasonableMaintenanceReplicationLag())
+	}
+	{
+		// Heartbeat lag is preferred over Seconds_Behind_Master
+		i := Instance{Key: key1, SecondsBehindMaster: sql.NullInt64{Int64: 50, Valid: true}}
+		i.HeartbeatLagMilliseconds = sql.NullInt64{Int64: 350, Valid: true}
+		test.S(t).ExpectTrue(i.HasReasonableMaintenanceReplicationLag())
+		i.HeartbeatLagMilliseconds.Int64 = 20001
+		test.S(t).ExpectFalse(i.HasReasonableMaintenanceReplicationLag())
+	}
+	{
+		i := Instance{Key: key1, SQLDelay: 3600}
+		i.HeartbeatLagMilliseconds = sql.NullInt64{Int64: 3600500, Valid: true}
+		test.S(t).ExpectTrue(i.HasReasonableMaintenanceReplicationLag())
+	}
+}
+
+func TestGetInstanceChanges(t *testing.T) {
+	before := Instance{Key: key1, Version: "5.7.20-log", ReadOnly: false}
+	before.MasterKey = key2
+	{
+		after := before
+		changes := GetInstanceChanges(&before, &after)
+		test.S(t).ExpectEquals(len(changes), 0)
+	}
+	{
+		after := before
+		after.ReadOnly = true
+		after.MasterKey = key3
+		changes := GetInstanceChanges(&before, &after)
+		test.S(t).ExpectEquals(len(changes), 2)
+		test.S(t).ExpectEquals(changes[0].FieldName, "MasterKey")
+		test.S(t).ExpectEquals(changes[0].BeforeValue, key2.StringCode())
+		test.S(t).ExpectEquals(changes[0].AfterValue, key3.StringCode())
+		test.S(t).ExpectEquals(changes[1].FieldName, "ReadOnly")
+		test.S(t).ExpectEquals(changes[1].BeforeValue, "false")
+		test.S(t).ExpectEquals(changes[1].AfterValue, "true")
+	}
+	{
+		changes := GetInstanceChanges(nil, &before)
+		test.S(t).ExpectEquals(len(changes), 0)
+	}
+}
@@ -211,6 +211,9 @@ func MoveEquivalent(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if instance.Key.Equals(otherKey) {
 		return instance, fmt.Errorf("MoveEquivalent: attempt to move an instance below itself %+v", instance.Key)
 	}
+	if err := CheckNoTouch(otherKey); err != nil {
+		return instance, err
+	}
 
 	// Are there equivalent coordinates to this instance?
 	instanceCoordinates := &InstanceBinlogCoordinates{Key: instance.MasterKey, Coordinates: instance.ExecBinlogCoordinates}
@@ -478,6 +481,9 @@ func MoveBelow(instanceKey, siblingKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckNoTouch(siblingKey); err != nil {
+		return instance, err
+	}
 
 	if sibling.IsBinlogServer() {
 		// Binlog server has same coordinates as master
@@ -556,16 +562,34 @@ Cleanup:
 	return instance, err
 }
 
-func canReplicateAssumingOracleGTID(instance, masterInstance *Instance) (canReplicate bool, err error) {
+// GTIDPurgedError indicates a replica cannot replicate via GTID from a master, since the master has purged
+// GTID entries the replica has yet to apply
+type GTIDPurgedError struct {
+	InstanceKey        InstanceKey
+	MasterKey          InstanceKey
+	MissingGtidSet     string
+	MissingGtidEntries []string
+}
+
+func (this *GTIDPurgedError) Error() string {
+	return fmt.Sprintf("Instance %+v has purged GTID entries not found on %+v: %s", this.MasterKey, this.InstanceKey, strings.Join(this.MissingGtidEntries, ", "))
+}
+
+// missingPurgedGTIDs returns the GTID entries purged on the master, yet not executed on the instance
+func missingPurgedGTIDs(instance, masterInstance *Instance) (missing *OracleGtidSet, err error) {
 	subtract, err := GTIDSubtract(&instance.Key, masterInstance.GtidPurged, instance.ExecutedGtidSet)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	subtractGtidSet, err := NewOracleGtidSet(subtract)
+	return NewOracleGtidSet(subtract)
+}
+
+func canReplicateAssumingOracleGTID(instance, masterInstance *Instance) (canReplicate bool, err error) {
+	missing, err := missingPurgedGTIDs(instance, masterInstance)
 	if err != nil {
 		return false, err
 	}
-	return subtractGtidSet.IsEmpty(), nil
+	return missing.IsEmpty(), nil
 }
 
 func instancesAreGTIDAndCompatible(instance, otherInstance *Instance) (isOracleGTID bool, isMariaDBGTID, compatible bool) {
@@ -581,12 +605,16 @@ func CheckMoveViaGTID(instance, otherInstance *Instance) (err error) {
 		return fmt.Errorf("Instances %+v, %+v not GTID compatible or not using GTID", instance.Key, otherInstance.Key)
 	}
 	if isOracleGTID {
-		canReplicate, err := canReplicateAssumingOracleGTID(instance, otherInstance)
+		missing, err := missingPurgedGTIDs(instance, otherInstance)
 		if err != nil {
 			return err
 		}
-		if !canReplicate {
-			return fmt.Errorf("Instance %+v has purged GTID entries not found on %+v", otherInstance.Key, instance.Key)
+		if !missing.IsEmpty() {
+			purgedErr := &GTIDPurgedError{InstanceKey: instance.Key, MasterKey: otherInstance.Key, MissingGtidSet: missing.String()}
+			for _, entry := range missing.GtidEntries {
+				purgedErr.MissingGtidEntries = append(purgedErr.MissingGtidEntries, entry.String())
+			}
+			return purgedErr
 		}
 	}
 
@@ -604,7 +632,12 @@ func moveInstanceBelowViaGTID(instance, otherInstance *Instance) (*Instance, err
 		return instance, err
 	}
 	if err := CheckMoveViaGTID(instance, otherInstance); err != nil {
-		return instance, err
+		purgedErr, isPurgedErr := err.(*GTIDPurgedError)
+		if !isPurgedErr || config.Config.GTIDPurgedCheck != config.GTIDPurgedCheckWarn {
+			return instance, err
+		}
+		log.Warningf("moveInstanceBelowViaGTID: proceeding as per GTIDPurgedCheck=%s, though replication is expected to break: %+v", config.Config.GTIDPurgedCheck, purgedErr)
+		AuditOperation("move-below-gtid-purged", &instance.Key, purgedErr.Error())
 	}
 	log.Infof("Will move %+v below %+v via GTID", instance.Key, otherInstance.Key)
 
@@ -649,6 +682,9 @@ func MoveBelowGTID(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if err != nil {
 		return instance, err
 	}
+	if err := CheckNoTouch(otherKey); err != nil {
+		return instance, err
+	}
 	return moveInstanceBelowViaGTID(instance, other)
 }
 
@@ -760,6 +796,11 @@ func Repoint(instanceKey *InstanceKey, masterKey *InstanceKey, gtidHint Operatio
 	if masterKey == nil {
 		masterKey = &instance.MasterKey
 	}
+	if !masterKey.Equals(&instance.MasterKey) {
+		if err := CheckNoTouch(masterKey); err != nil {
+			return instance, err
+		}
+	}
 	// With repoint we *prefer* the master to be alive, but we don't strictly require it.
 	// The use case for the master being alive is with hostname-resolve or hostname-unresolve: asking the replica
 	// to reconnect to its same master while changing the MASTER_HOST in CHANGE MASTER TO due to DNS changes etc.
@@ -834,6 +875,9 @@ func RepointTo(replicas [](*Instance), belowKey *InstanceKey) ([](*Instance), er
 	if belowKey == nil {
 		return res, log.Errorf("RepointTo received nil belowKey"), errs
 	}
+	if err := CheckNoTouch(belowKey); err != nil {
+		return res, err, errs
+	}
 
 	log.Infof("Will repoint %+v replicas below %+v", len(replicas), *belowKey)
 	barrier := make(chan *InstanceKey)
@@ -987,7 +1031,7 @@ func MakeCoMaster(instanceKey *InstanceKey) (*Instance, error) {
 
 	if instance.AllowTLS {
 		log.Debugf("Enabling SSL replication")
-		_, err = EnableMasterSSL(&master.Key)
+		_, err = EnableReplicationTLS(&master.Key, instance.TLSVerifyServerCert)
 		if err != nil {
 			goto Cleanup
 		}
@@ -1245,6 +1289,55 @@ func LocateErrantGTID(instanceKey *InstanceKey) (errantBinlogs []string, err err
 	return errantBinlogs, err
 }
 
+// ErrantGTIDRemediationPlan lists the exact statements an errant GTID remediation would issue, and where
+type ErrantGTIDRemediationPlan struct {
+	Method      string
+	InstanceKey InstanceKey // the instance with errant GTID
+	TargetKey   InstanceKey // the instance on which statements are issued
+	GtidErrant  string
+	Statements  []string
+}
+
+func validateErrantGTIDResetMaster(instance *Instance) error {
+	if instance.GtidErrant == "" {
+		return log.Errorf("gtid-errant-reset-master will not operate on %+v because no errant GTID is found", instance.Key)
+	}
+	if !instance.SupportsOracleGTID {
+		return log.Errorf("gtid-errant-reset-master requested for %+v but it is not using oracle-gtid", instance.Key)
+	}
+	if len(instance.SlaveHosts) > 0 {
+		return log.Errorf("gtid-errant-reset-master will not operate on %+v because it has %+v replicas. Expecting no replicas", instance.Key, len(instance.SlaveHosts))
+	}
+	return nil
+}
+
+// PlanErrantGTIDResetMaster returns the statements ErrantGTIDResetMaster would issue on given instance, without issuing them
+func PlanErrantGTIDResetMaster(instanceKey *InstanceKey) (plan *ErrantGTIDRemediationPlan, err error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return plan, err
+	}
+	if err := validateErrantGTIDResetMaster(instance); err != nil {
+		return plan, err
+	}
+	gtidSubtract, err := GTIDSubtract(instanceKey, instance.ExecutedGtidSet, instance.GtidErrant)
+	if err != nil {
+		return plan, err
+	}
+	plan = &ErrantGTIDRemediationPlan{Method: "reset-master", InstanceKey: *instanceKey, TargetKey: *instanceKey, GtidErrant: instance.GtidErrant}
+	if instance.IsReplica() {
+		plan.Statements = append(plan.Statements, "stop slave")
+	}
+	plan.Statements = append(plan.Statements,
+		"reset master",
+		fmt.Sprintf("set global gtid_purged := '%s'", gtidSubtract),
+	)
+	if instance.IsReplica() {
+		plan.Statements = append(plan.Statements, "start slave")
+	}
+	return plan, nil
+}
+
 // ErrantGTIDResetMaster will issue a safe RESET MASTER on a replica that replicates via GTID:
 // It will make sure the gtid_purged set matches the executed set value as read just before the RESET.
 // this will enable new replicas to be attached to given instance without complaints about missing/purged entries.
@@ -1254,14 +1347,8 @@ func ErrantGTIDResetMaster(instanceKey *InstanceKey) (instance *Instance, err er
 	if err != nil {
 		return instance, err
 	}
-	if instance.GtidErrant == "" {
-		return instance, log.Errorf("gtid-errant-reset-master will not operate on %+v because no errant GTID is found", *instanceKey)
-	}
-	if !instance.SupportsOracleGTID {
-		return instance, log.Errorf("gtid-errant-reset-master requested for %+v but it is not using oracle-gtid", *instanceKey)
-	}
-	if len(instance.SlaveHosts) > 0 {
-		return instance, log.Errorf("gtid-errant-reset-master will not operate on %+v because it has %+v replicas. Expecting no replicas", *instanceKey, len(instance.SlaveHosts))
+	if err := validateErrantGTIDResetMaster(instance); err != nil {
+		return instance, err
 	}
 
 	gtidSubtract := ""
@@ -1354,31 +1441,62 @@ Cleanup:
 	return instance, err
 }
 
-// ErrantGTIDInjectEmpty will inject an empty transaction on the master of an instance's cluster in order to get rid
-// of an errant transaction observed on the instance.
-func ErrantGTIDInjectEmpty(instanceKey *InstanceKey) (instance *Instance, clusterMaster *Instance, countInjectedTransactions int64, err error) {
-	instance, err = ReadTopologyInstance(instanceKey)
-	if err != nil {
-		return instance, clusterMaster, countInjectedTransactions, err
-	}
+// readErrantGTIDInjectEmptyMaster validates errant GTID on given instance may be injected on its cluster's master,
+// and returns that master
+func readErrantGTIDInjectEmptyMaster(instance *Instance) (clusterMaster *Instance, err error) {
 	if instance.GtidErrant == "" {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty will not operate on %+v because no errant GTID is found", *instanceKey)
+		return clusterMaster, log.Errorf("gtid-errant-inject-empty will not operate on %+v because no errant GTID is found", instance.Key)
 	}
 	if !instance.SupportsOracleGTID {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but it does not support oracle-gtid", *instanceKey)
+		return clusterMaster, log.Errorf("gtid-errant-inject-empty requested for %+v but it does not support oracle-gtid", instance.Key)
 	}
 
 	masters, err := ReadClusterWriteableMaster(instance.ClusterName)
 	if err != nil {
-		return instance, clusterMaster, countInjectedTransactions, err
+		return clusterMaster, err
 	}
 	if len(masters) == 0 {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty found no writabel master for %+v cluster", instance.ClusterName)
+		return clusterMaster, log.Errorf("gtid-errant-inject-empty found no writabel master for %+v cluster", instance.ClusterName)
 	}
 	clusterMaster = masters[0]
 
 	if !clusterMaster.SupportsOracleGTID {
-		return instance, clusterMaster, countInjectedTransactions, log.Errorf("gtid-errant-inject-empty requested for %+v but the cluster's master %+v does not support oracle-gtid", *instanceKey, clusterMaster.Key)
+		return clusterMaster, log.Errorf("gtid-errant-inject-empty requested for %+v but the cluster's master %+v does not support oracle-gtid", instance.Key, clusterMaster.Key)
+	}
+	return clusterMaster, nil
+}
+
+// PlanErrantGTIDInjectEmpty returns the statements ErrantGTIDInjectEmpty would issue on the cluster's master, without issuing them
+func PlanErrantGTIDInjectEmpty(instanceKey *InstanceKey) (plan *ErrantGTIDRemediationPlan, err error) {
+	instance, err := ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return plan, err
+	}
+	clusterMaster, err := readErrantGTIDInjectEmptyMaster(instance)
+	if err != nil {
+		return plan, err
+	}
+	gtidSet, err := NewOracleGtidSet(instance.GtidErrant)
+	if err != nil {
+		return plan, err
+	}
+	plan = &ErrantGTIDRemediationPlan{Method: "inject-empty", InstanceKey: *instanceKey, TargetKey: clusterMaster.Key, GtidErrant: instance.GtidErrant}
+	for _, entry := range gtidSet.Explode() {
+		plan.Statements = append(plan.Statements, emptyGTIDTransactionStatements(entry)...)
+	}
+	return plan, nil
+}
+
+// ErrantGTIDInjectEmpty will inject an empty transaction on the master of an instance's cluster in order to get rid
+// of an errant transaction observed on the instance.
+func ErrantGTIDInjectEmpty(instanceKey *InstanceKey) (instance *Instance, clusterMaster *Instance, countInjectedTransactions int64, err error) {
+	instance, err = ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, clusterMaster, countInjectedTransactions, err
+	}
+	clusterMaster, err = readErrantGTIDInjectEmptyMaster(instance)
+	if err != nil {
+		return instance, clusterMaster, countInjectedTransactions, err
 	}
 
 	gtidSet, err := NewOracleGtidSet(instance.GtidErrant)
@@ -1518,6 +1636,9 @@ func MatchBelow(instanceKey, otherKey *InstanceKey, requireInstanceMaintenance b
 	if instanceKey.Equals(otherKey) {
 		return instance, nil, fmt.Errorf("MatchBelow: attempt to match an instance below itself %+v", *instanceKey)
 	}
+	if err := CheckNoTouch(otherKey); err != nil {
+		return instance, nil, err
+	}
 	otherInstance, err := ReadTopologyInstance(otherKey)
 	if err != nil {
 		return instance, nil, err
@@ -1897,6 +2018,9 @@ func MultiMatchBelow(replicas [](*Instance), belowKey *InstanceKey, postponedFun
 		// Nothing to do
 		return replicas, belowInstance, err, errs
 	}
+	if err := CheckNoTouch(belowKey); err != nil {
+		return matchedReplicas, belowInstance, err, errs
+	}
 
 	log.Infof("Will match %+v replicas below %+v via Pseudo-GTID, independently", len(replicas), belowKey)
 
@@ -2087,6 +2211,10 @@ func IsBannedFromBeingCandidateReplica(replica *Instance) bool {
 		log.Debugf("instance %+v is banned because of promotion rule", replica.Key)
 		return true
 	}
+	if replica.IsNoTouch {
+		log.Debugf("instance %+v is banned because it is no-touch locked", replica.Key)
+		return true
+	}
 	for _, filter := range config.Config.PromotionIgnoreHostnameFilters {
 		if matched, _ := regexp.MatchString(filter, replica.Key.Hostname); matched {
 			return true
@@ -2141,15 +2269,27 @@ func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance,
 	priorityMajorVersion, _ := getPriorityMajorVersionForCandidate(replicas)
 	priorityBinlogFormat, _ := getPriorityBinlogFormatForCandidate(replicas)
 
+	// Of all valid candidates, pick the one stranding the fewest replicas. Replicas are sorted, most up-to-date
+	// first, and so ties favor the most up-to-date candidate.
+	candidateStrandedCount := 0
 	for _, replica := range replicas {
 		replica := replica
 		if isGenerallyValidAsCandidateReplica(replica) &&
 			!IsBannedFromBeingCandidateReplica(replica) &&
 			!IsSmallerMajorVersion(priorityMajorVersion, replica.MajorVersionString()) &&
 			!IsSmallerBinlogFormat(priorityBinlogFormat, replica.Binlog_format) {
-			// this is the one
-			candidateReplica = replica
-			break
+			strandedCount := NewCandidateCompatibility(replica, replicas).StrandedCount()
+			if candidateReplica == nil || strandedCount < candidateStrandedCount {
+				if candidateReplica != nil {
+					log.Debugf("chooseCandidateReplica: %+v strands %d replicas, fewer than %d by %+v", replica.Key, strandedCount, candidateStrandedCount, candidateReplica.Key)
+				}
+				candidateReplica = replica
+				candidateStrandedCount = strandedCount
+			}
+			if candidateStrandedCount == 0 {
+				// this is the one
+				break
+			}
 		}
 	}
 	if candidateReplica == nil {
@@ -2188,6 +2328,12 @@ func chooseCandidateReplica(replicas [](*Instance)) (candidateReplica *Instance,
 
 // GetCandidateReplica chooses the best replica to promote given a (possibly dead) master
 func GetCandidateReplica(masterKey *InstanceKey, forRematchPurposes bool) (*Instance, [](*Instance), [](*Instance), [](*Instance), [](*Instance), error) {
+	return getCandidateReplica(masterKey, forRematchPurposes, false)
+}
+
+// getCandidateReplica chooses the best replica to promote given a (possibly dead) master. When excludeBinlogServers
+// is true, binlog servers are not considered at all: neither as candidates nor as replicas to regroup.
+func getCandidateReplica(masterKey *InstanceKey, forRematchPurposes bool, excludeBinlogServers bool) (*Instance, [](*Instance), [](*Instance), [](*Instance), [](*Instance), error) {
 	var candidateReplica *Instance
 	aheadReplicas := [](*Instance){}
 	equalReplicas := [](*Instance){}
@@ -2202,6 +2348,9 @@ func GetCandidateReplica(masterKey *InstanceKey, forRematchPurposes bool) (*Inst
 	if err != nil {
 		return candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err
 	}
+	if excludeBinlogServers {
+		replicas = RemoveBinlogServerInstances(replicas)
+	}
 	stopReplicationMethod := NoStopReplication
 	if forRematchPurposes {
 		stopReplicationMethod = StopReplicationNicely
@@ -2449,10 +2598,46 @@ func RegroupReplicasGTID(
 	cannotReplicateReplicas [](*Instance),
 	candidateReplica *Instance,
 	err error,
+) {
+	return regroupReplicasGTID(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations, false)
+}
+
+// RegroupReplicasGTIDExcludingBinlogServers is similar to RegroupReplicasGTID, but only works on the replicas
+// which are not binlog servers; binlog servers are left untouched, for the caller to handle independently.
+// This is useful in mixed topologies, where GTID replicas and binlog servers replicate from the same master.
+func RegroupReplicasGTIDExcludingBinlogServers(
+	masterKey *InstanceKey,
+	returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer,
+	postponeAllMatchOperations func(*Instance) bool,
+) (
+	lostReplicas [](*Instance),
+	movedReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	candidateReplica *Instance,
+	err error,
+) {
+	return regroupReplicasGTID(masterKey, returnReplicaEvenOnFailureToRegroup, onCandidateReplicaChosen, postponedFunctionsContainer, postponeAllMatchOperations, true)
+}
+
+func regroupReplicasGTID(
+	masterKey *InstanceKey,
+	returnReplicaEvenOnFailureToRegroup bool,
+	onCandidateReplicaChosen func(*Instance),
+	postponedFunctionsContainer *PostponedFunctionsContainer,
+	postponeAllMatchOperations func(*Instance) bool,
+	excludeBinlogServers bool,
+) (
+	lostReplicas [](*Instance),
+	movedReplicas [](*Instance),
+	cannotReplicateReplicas [](*Instance),
+	candidateReplica *Instance,
+	err error,
 ) {
 	var emptyReplicas [](*Instance)
 	var unmovedReplicas [](*Instance)
-	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := GetCandidateReplica(masterKey, true)
+	candidateReplica, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := getCandidateReplica(masterKey, true, excludeBinlogServers)
 	if err != nil {
 		if !returnReplicaEvenOnFailureToRegroup {
 			candidateReplica = nil
@@ -2679,6 +2864,9 @@ func RelocateBelow(instanceKey, otherKey *InstanceKey) (*Instance, error) {
 	if other.IsDescendantOf(instance) {
 		return instance, log.Errorf("relocate: %+v is a descendant of %+v", *otherKey, instance.Key)
 	}
+	if err := CheckNoTouch(otherKey); err != nil {
+		return instance, err
+	}
 	instance, err = relocateBelowInternal(instance, other)
 	if err == nil {
 		AuditOperation("relocate-below", instanceKey, fmt.Sprintf("relocated %+v below %+v", *instanceKey, *otherKey))
@@ -2765,6 +2953,16 @@ func relocateReplicasInternal(replicas [](*Instance), instance, other *Instance)
 // Orchestrator will try and figure out the best way to relocate the servers. This could span normal
 // binlog-position, pseudo-gtid, repointing, binlog servers...
 func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (replicas [](*Instance), other *Instance, err error, errs []error) {
+	return relocateReplicas(instanceKey, otherKey, pattern, "")
+}
+
+// RelocateReplicasByTags will attempt moving replicas of an instance indicated by instanceKey below another instance,
+// limited to those replicas matching given tag expression (see GetInstanceKeysByTags)
+func RelocateReplicasByTags(instanceKey, otherKey *InstanceKey, pattern string, tagsString string) (replicas [](*Instance), other *Instance, err error, errs []error) {
+	return relocateReplicas(instanceKey, otherKey, pattern, tagsString)
+}
+
+func relocateReplicas(instanceKey, otherKey *InstanceKey, pattern string, tagsString string) (replicas [](*Instance), other *Instance, err error, errs []error) {
 
 	instance, found, err := ReadInstance(instanceKey)
 	if err != nil || !found {
@@ -2774,6 +2972,9 @@ func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (repli
 	if err != nil || !found {
 		return replicas, other, log.Errorf("Error reading %+v", *otherKey), errs
 	}
+	if err := CheckNoTouch(otherKey); err != nil {
+		return replicas, other, err, errs
+	}
 
 	replicas, err = ReadReplicaInstances(instanceKey)
 	if err != nil {
@@ -2781,6 +2982,11 @@ func RelocateReplicas(instanceKey, otherKey *InstanceKey, pattern string) (repli
 	}
 	replicas = RemoveInstance(replicas, otherKey)
 	replicas = filterInstancesByPattern(replicas, pattern)
+	if tagsString != "" {
+		if replicas, err = FilterInstancesByTags(replicas, tagsString); err != nil {
+			return replicas, other, err, errs
+		}
+	}
 	if len(replicas) == 0 {
 		// Nothing to do
 		return replicas, other, nil, errs
@@ -51,6 +51,9 @@ const (
 
 // ExecInstance executes a given query on the given MySQL topology instance
 func ExecInstance(instanceKey *InstanceKey, query string, args ...interface{}) (sql.Result, error) {
+	if err := CheckNoTouch(instanceKey); err != nil {
+		return nil, err
+	}
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
 	if err != nil {
 		return nil, err
@@ -76,6 +79,44 @@ func ScanInstanceRow(instanceKey *InstanceKey, query string, dest ...interface{}
 	return err
 }
 
+// AssertInstanceQuery runs a query on a given instance and tells whether it holds. A query returning rows holds when
+// the first column of its first row is neither NULL, empty nor 0. A statement returning no rows, e.g. a write, holds
+// when it succeeds. result describes what the query returned.
+func AssertInstanceQuery(instanceKey *InstanceKey, query string) (holds bool, result string, err error) {
+	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return false, "", err
+	}
+	rows, err := db.Query(query)
+	if err != nil {
+		return false, "", err
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, "", err
+	}
+	if len(columns) == 0 {
+		return true, "ok", nil
+	}
+	if !rows.Next() {
+		return false, "no rows", rows.Err()
+	}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return false, "", err
+	}
+	if !values[0].Valid {
+		return false, "NULL", nil
+	}
+	result = values[0].String
+	return result != "" && result != "0", result, nil
+}
+
 // EmptyCommitInstance issues an empty COMMIT on a given instance
 func EmptyCommitInstance(instanceKey *InstanceKey) error {
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
@@ -263,6 +304,10 @@ func StopSlaveNicely(instanceKey *InstanceKey, timeout time.Duration) (*Instance
 	if !instance.ReplicationThreadsExist() {
 		return instance, fmt.Errorf("instance is not a replica: %+v", instanceKey)
 	}
+	if instance.IsRipple() {
+		// Ripple has no SQL thread, hence nothing to align
+		return StopSlave(instanceKey)
+	}
 
 	// stop io_thread, start sql_thread but catch any errors
 	for _, cmd := range []string{`stop slave io_thread`, `start slave sql_thread`} {
@@ -295,8 +340,28 @@ func StopSlaveNicely(instanceKey *InstanceKey, timeout time.Duration) (*Instance
 }
 
 func WaitForSQLThreadUpToDate(instanceKey *InstanceKey, overallTimeout time.Duration, staleCoordinatesTimeout time.Duration) (instance *Instance, err error) {
+	return WaitForSQLThreadUpToDateWithProgress(instanceKey, overallTimeout, staleCoordinatesTimeout, nil)
+}
+
+// WaitForSQLThreadUpToDateWithProgress waits for given replica to apply its relay logs, periodically reporting
+// its progress towards its read coordinates to onProgress, if given.
+func WaitForSQLThreadUpToDateWithProgress(instanceKey *InstanceKey, overallTimeout time.Duration, staleCoordinatesTimeout time.Duration, onProgress func(*ReplicationCatchupProgress)) (instance *Instance, err error) {
 	// Otherwise we don't bother.
 	var lastExecBinlogCoordinates BinlogCoordinates
+	startTime := time.Now()
+	var initialProgress *ReplicationCatchupProgress
+	var lastProgressTime time.Time
+	reportProgress := func(instance *Instance) {
+		if onProgress == nil || time.Since(lastProgressTime) < replicationCatchupProgressInterval {
+			return
+		}
+		lastProgressTime = time.Now()
+		progress := newReplicationCatchupProgress(instance, &instance.ReadBinlogCoordinates, -1, initialProgress, time.Since(startTime))
+		if initialProgress == nil {
+			initialProgress = progress
+		}
+		onProgress(progress)
+	}
 
 	if overallTimeout == 0 {
 		overallTimeout = 24 * time.Hour
@@ -331,6 +396,7 @@ func WaitForSQLThreadUpToDate(instanceKey *InstanceKey, overallTimeout time.Dura
 			staleTimer.Reset(staleCoordinatesTimeout)
 		}
 		lastExecBinlogCoordinates = instance.ExecBinlogCoordinates
+		reportProgress(instance)
 
 		select {
 		case <-generalTimer.C:
@@ -505,7 +571,34 @@ func StartSlaves(replicas [](*Instance)) {
 }
 
 func WaitForExecBinlogCoordinatesToReach(instanceKey *InstanceKey, coordinates *BinlogCoordinates, maxWait time.Duration) (instance *Instance, exactMatch bool, err error) {
+	return WaitForExecBinlogCoordinatesToReachWithProgress(instanceKey, coordinates, "", maxWait, nil)
+}
+
+// WaitForExecBinlogCoordinatesToReachWithProgress waits for given replica to execute up to given coordinates, periodically
+// reporting its progress to onProgress, if given. When targetGtidSet is given, transactions remaining are reported, too.
+func WaitForExecBinlogCoordinatesToReachWithProgress(instanceKey *InstanceKey, coordinates *BinlogCoordinates, targetGtidSet string, maxWait time.Duration, onProgress func(*ReplicationCatchupProgress)) (instance *Instance, exactMatch bool, err error) {
 	startTime := time.Now()
+	var initialProgress *ReplicationCatchupProgress
+	var lastProgressTime time.Time
+	reportProgress := func() {
+		if onProgress == nil || time.Since(lastProgressTime) < replicationCatchupProgressInterval {
+			return
+		}
+		lastProgressTime = time.Now()
+		transactionsRemaining := int64(-1)
+		if targetGtidSet != "" && instance.UsingOracleGTID {
+			if gtidRemaining, err := GTIDSubtract(instanceKey, targetGtidSet, instance.ExecutedGtidSet); err == nil {
+				if gtidSet, err := NewOracleGtidSet(gtidRemaining); err == nil {
+					transactionsRemaining = gtidSet.Count()
+				}
+			}
+		}
+		progress := newReplicationCatchupProgress(instance, coordinates, transactionsRemaining, initialProgress, time.Since(startTime))
+		if initialProgress == nil {
+			initialProgress = progress
+		}
+		onProgress(progress)
+	}
 	for {
 		if maxWait != 0 && time.Since(startTime) > maxWait {
 			return nil, exactMatch, fmt.Errorf("WaitForExecBinlogCoordinatesToReach: reached maxWait %+v on %+v", maxWait, *instanceKey)
@@ -517,6 +610,7 @@ func WaitForExecBinlogCoordinatesToReach(instanceKey *InstanceKey, coordinates *
 
 		switch {
 		case instance.ExecBinlogCoordinates.SmallerThan(coordinates):
+			reportProgress()
 			time.Sleep(retryInterval)
 		case instance.ExecBinlogCoordinates.Equals(coordinates):
 			return instance, true, nil
@@ -577,6 +671,61 @@ func StartSlaveUntilMasterCoordinates(instanceKey *InstanceKey, masterCoordinate
 	return instance, err
 }
 
+// ReadReplicaParallelWorkers reads the number of parallel (MTS) applier workers configured on given instance.
+// 0 stands for a single threaded replica.
+func ReadReplicaParallelWorkers(instanceKey *InstanceKey) (workers uint, err error) {
+	err = ScanInstanceRow(instanceKey, "select @@global.slave_parallel_workers", &workers)
+	return workers, err
+}
+
+// CloseMTSGaps issues a START SLAVE UNTIL SQL_AFTER_MTS_GAPS statement on a multi-threaded replica whose SQL thread
+// is stopped, and waits for the SQL thread to stop again, having closed any gaps in its applied transactions. A
+// replica stopped uncleanly (on a worker error, or by a crash) may have such gaps; a running or cleanly stopped one
+// has none. Returns false, with no error, when there is nothing to do.
+func CloseMTSGaps(instanceKey *InstanceKey, timeout time.Duration) (instance *Instance, closed bool, err error) {
+	instance, err = ReadTopologyInstance(instanceKey)
+	if err != nil {
+		return instance, false, log.Errore(err)
+	}
+	if !instance.IsReplica() {
+		return instance, false, fmt.Errorf("instance is not a replica: %+v", instanceKey)
+	}
+	if instance.IsMariaDB() || instance.Slave_SQL_Running {
+		return instance, false, nil
+	}
+	workers, err := ReadReplicaParallelWorkers(instanceKey)
+	if err != nil {
+		return instance, false, log.Errore(err)
+	}
+	if workers == 0 {
+		return instance, false, nil
+	}
+
+	log.Infof("Will start SQL thread on %+v until MTS gaps are closed", instanceKey)
+	if _, err := ExecInstance(instanceKey, "start slave sql_thread until sql_after_mts_gaps"); err != nil {
+		return instance, false, log.Errore(err)
+	}
+	for startTime := time.Now(); ; time.Sleep(retryInterval) {
+		instance, err = ReadTopologyInstance(instanceKey)
+		if err != nil {
+			return instance, false, log.Errore(err)
+		}
+		if !instance.Slave_SQL_Running {
+			break
+		}
+		if time.Since(startTime) > timeout {
+			if _, err := ExecInstance(instanceKey, "stop slave sql_thread"); err != nil {
+				return instance, false, log.Errorf("CloseMTSGaps: timeout on %+v after %+v, and cannot stop SQL thread: %+v", *instanceKey, timeout, err)
+			}
+			return instance, false, fmt.Errorf("CloseMTSGaps: timeout on %+v after %+v", *instanceKey, timeout)
+		}
+	}
+	if instance.LastSQLErrno != 0 {
+		return instance, false, fmt.Errorf("CloseMTSGaps: SQL thread on %+v stopped on error %d: %s", *instanceKey, instance.LastSQLErrno, instance.LastSQLError)
+	}
+	return instance, true, nil
+}
+
 // EnableSemiSync sets the rpl_semi_sync_(master|slave)_enabled variables
 // on a given instance.
 func EnableSemiSync(instanceKey *InstanceKey, master, slave bool) error {
@@ -620,31 +769,73 @@ func ChangeMasterCredentials(instanceKey *InstanceKey, masterUser string, master
 
 // EnableMasterSSL issues CHANGE MASTER TO MASTER_SSL=1
 func EnableMasterSSL(instanceKey *InstanceKey) (*Instance, error) {
+	return EnableReplicationTLS(instanceKey, false)
+}
+
+// EnableReplicationTLS issues CHANGE MASTER TO MASTER_SSL=1, and optionally MASTER_SSL_VERIFY_SERVER_CERT=1
+func EnableReplicationTLS(instanceKey *InstanceKey, verifyServerCert bool) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
 	if err != nil {
 		return instance, log.Errore(err)
 	}
 
 	if instance.ReplicationThreadsExist() && !instance.ReplicationThreadsStopped() {
-		return instance, fmt.Errorf("EnableMasterSSL: Cannot enable SSL replication on %+v because replication threads are not stopped", *instanceKey)
+		return instance, fmt.Errorf("EnableReplicationTLS: Cannot enable SSL replication on %+v because replication threads are not stopped", *instanceKey)
 	}
-	log.Debugf("EnableMasterSSL: Will attempt enabling SSL replication on %+v", *instanceKey)
+	log.Debugf("EnableReplicationTLS: Will attempt enabling SSL replication on %+v", *instanceKey)
 
 	if *config.RuntimeCLIFlags.Noop {
 		return instance, fmt.Errorf("noop: aborting CHANGE MASTER TO MASTER_SSL=1 operation on %+v; signaling error but nothing went wrong.", *instanceKey)
 	}
-	_, err = ExecInstance(instanceKey, "change master to master_ssl=1")
+	if verifyServerCert {
+		_, err = ExecInstance(instanceKey, "change master to master_ssl=1, master_ssl_verify_server_cert=1")
+	} else {
+		_, err = ExecInstance(instanceKey, "change master to master_ssl=1")
+	}
 
 	if err != nil {
 		return instance, log.Errore(err)
 	}
 
-	log.Infof("EnableMasterSSL: Enabled SSL replication on %+v", *instanceKey)
+	log.Infof("EnableReplicationTLS: Enabled SSL replication on %+v; verify server cert: %+v", *instanceKey, verifyServerCert)
 
 	instance, err = ReadTopologyInstance(instanceKey)
 	return instance, err
 }
 
+// IsReplicationTLSRequired checks whether given cluster must replicate over TLS, as per ReplicationTLSRequiredClusterFilters
+func IsReplicationTLSRequired(clusterName string) bool {
+	if len(config.Config.ReplicationTLSRequiredClusterFilters) == 0 {
+		return false
+	}
+	clusterInfo := &ClusterInfo{ClusterName: clusterName}
+	clusterInfo.ClusterAlias, _ = ReadAliasByClusterName(clusterName)
+	return clusterInfo.filtersMatchCluster(config.Config.ReplicationTLSRequiredClusterFilters)
+}
+
+// enforceReplicationTLS enables TLS replication on given instance, whose replication threads are stopped,
+// if its cluster requires TLS replication and it does not use it yet. Otherwise, the instance's
+// TLS settings are preserved as they are.
+func enforceReplicationTLS(instance *Instance) error {
+	if !IsReplicationTLSRequired(instance.ClusterName) {
+		return nil
+	}
+	if instance.AllowTLS && (instance.TLSVerifyServerCert || !config.Config.ReplicationTLSVerifyServerCert) {
+		return nil
+	}
+	var err error
+	if config.Config.ReplicationTLSVerifyServerCert {
+		_, err = ExecInstance(&instance.Key, "change master to master_ssl=1, master_ssl_verify_server_cert=1")
+	} else {
+		_, err = ExecInstance(&instance.Key, "change master to master_ssl=1")
+	}
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("enforce-replication-tls", &instance.Key, fmt.Sprintf("cluster %s requires TLS replication; verify server cert: %+v", instance.ClusterName, config.Config.ReplicationTLSVerifyServerCert))
+	return nil
+}
+
 // See https://bugs.mysql.com/bug.php?id=83713
 func workaroundBug83713(instanceKey *InstanceKey) {
 	log.Debugf("workaroundBug83713: %+v", *instanceKey)
@@ -673,7 +864,11 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	}
 	log.Debugf("ChangeMasterTo: will attempt changing master on %+v to %+v, %+v", *instanceKey, *masterKey, *masterBinlogCoordinates)
 	changeToMasterKey := masterKey
-	if !skipUnresolve {
+	if reportedMasterKey, reported := reportedKeyOf(masterKey); reported {
+		// Replicas reach the master by its reported address, e.g. behind NAT
+		log.Debugf("ChangeMasterTo: %+v is reported as %+v", *masterKey, *reportedMasterKey)
+		changeToMasterKey = reportedMasterKey
+	} else if !skipUnresolve {
 		unresolvedMasterKey, nameUnresolved, err := UnresolveHostname(masterKey)
 		if err != nil {
 			log.Debugf("ChangeMasterTo: aborting operation on %+v due to resolving error on %+v: %+v", *instanceKey, *masterKey, err)
@@ -759,6 +954,9 @@ func ChangeMasterTo(instanceKey *InstanceKey, masterKey *InstanceKey, masterBinl
 	}
 	WriteMasterPositionEquivalence(&originalMasterKey, &originalExecBinlogCoordinates, changeToMasterKey, masterBinlogCoordinates)
 	ResetInstanceRelaylogCoordinatesHistory(instanceKey)
+	if err := enforceReplicationTLS(instance); err != nil {
+		return instance, err
+	}
 
 	log.Infof("ChangeMasterTo: Changed master on %+v to: %+v, %+v. GTID: %+v", *instanceKey, masterKey, masterBinlogCoordinates, changedViaGTID)
 
@@ -863,6 +1061,16 @@ func setGTIDPurged(instance *Instance, gtidPurged string) error {
 	return err
 }
 
+// emptyGTIDTransactionStatements lists the statements injectEmptyGTIDTransaction issues for a given entry
+func emptyGTIDTransactionStatements(gtidEntry *OracleGtidSetEntry) []string {
+	return []string{
+		fmt.Sprintf(`SET GTID_NEXT="%s"`, gtidEntry.String()),
+		"BEGIN",
+		"COMMIT",
+		`SET GTID_NEXT="AUTOMATIC"`,
+	}
+}
+
 // injectEmptyGTIDTransaction
 func injectEmptyGTIDTransaction(instanceKey *InstanceKey, gtidEntry *OracleGtidSetEntry) error {
 	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
@@ -1053,6 +1261,102 @@ func SetReadOnly(instanceKey *InstanceKey, readOnly bool) (*Instance, error) {
 	return instance, err
 }
 
+// SetReadOnlyVerified sets or clears the instance's global read_only variable, then re-reads it to verify
+// the change took effect. On failure or mismatch it tries again, up to given number of retries.
+func SetReadOnlyVerified(instanceKey *InstanceKey, readOnly bool, retries uint, retryInterval time.Duration) (instance *Instance, err error) {
+	if *config.RuntimeCLIFlags.Noop {
+		return SetReadOnly(instanceKey, readOnly)
+	}
+	for attempt := uint(0); attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Warningf("SetReadOnlyVerified: attempt %d of %d to set %+v read_only=%t: %+v", attempt+1, retries+1, *instanceKey, readOnly, err)
+			time.Sleep(retryInterval)
+		}
+		instance, err = SetReadOnly(instanceKey, readOnly)
+		if err != nil {
+			continue
+		}
+		var actualReadOnly bool
+		if err = ScanInstanceRow(instanceKey, "select @@global.read_only", &actualReadOnly); err != nil {
+			continue
+		}
+		if actualReadOnly != readOnly {
+			err = fmt.Errorf("%+v read_only is %t after setting it to %t", *instanceKey, actualReadOnly, readOnly)
+			continue
+		}
+		return instance, nil
+	}
+	AuditOperation("read-only-failed", instanceKey, fmt.Sprintf("could not set as %t: %+v", readOnly, err))
+	return instance, log.Errore(err)
+}
+
+// SetEventScheduler turns the instance's global event_scheduler on or off
+func SetEventScheduler(instanceKey *InstanceKey, enabled bool) error {
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting set-event-scheduler operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	value := "OFF"
+	if enabled {
+		value = "ON"
+	}
+	if _, err := ExecInstance(instanceKey, fmt.Sprintf("set global event_scheduler = %s", value)); err != nil {
+		return log.Errore(err)
+	}
+	log.Infof("instance %+v event_scheduler: %s", instanceKey, value)
+	AuditOperation("event-scheduler", instanceKey, fmt.Sprintf("set as %s", value))
+	return nil
+}
+
+// VariableDiscrepancy is a global variable whose value on a replica differs from its value on the master
+type VariableDiscrepancy struct {
+	Key         InstanceKey
+	Variable    string
+	Value       string
+	MasterValue string
+}
+
+func (this *VariableDiscrepancy) String() string {
+	return fmt.Sprintf("%+v: %s=%s, master has %s", this.Key, this.Variable, this.Value, this.MasterValue)
+}
+
+// gtidConsistencyVariables are the variables a replica and its master must agree on for GTID replication to work
+var gtidConsistencyVariables = []string{"gtid_mode", "enforce_gtid_consistency"}
+
+// readGTIDConsistencyVariables reads the instance's gtidConsistencyVariables, in order
+func readGTIDConsistencyVariables(instanceKey *InstanceKey) (values []string, err error) {
+	values = make([]string, len(gtidConsistencyVariables))
+	dest := make([]interface{}, len(values))
+	selected := make([]string, len(values))
+	for i, variable := range gtidConsistencyVariables {
+		dest[i] = &values[i]
+		selected[i] = fmt.Sprintf("@@global.%s", variable)
+	}
+	err = ScanInstanceRow(instanceKey, fmt.Sprintf("select %s", strings.Join(selected, ", ")), dest...)
+	return values, err
+}
+
+// GTIDConsistencyDiscrepancies compares gtid_mode and enforce_gtid_consistency on given replicas with their values on
+// the master. Replicas that cannot be read are skipped; their errors are returned along with found discrepancies.
+func GTIDConsistencyDiscrepancies(masterKey *InstanceKey, replicaKeys []InstanceKey) (discrepancies []VariableDiscrepancy, errs []error) {
+	masterValues, err := readGTIDConsistencyVariables(masterKey)
+	if err != nil {
+		return discrepancies, append(errs, fmt.Errorf("%+v: %+v", *masterKey, err))
+	}
+	for _, replicaKey := range replicaKeys {
+		replicaValues, err := readGTIDConsistencyVariables(&replicaKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%+v: %+v", replicaKey, err))
+			continue
+		}
+		for i, variable := range gtidConsistencyVariables {
+			if replicaValues[i] != masterValues[i] {
+				discrepancies = append(discrepancies, VariableDiscrepancy{Key: replicaKey, Variable: variable, Value: replicaValues[i], MasterValue: masterValues[i]})
+			}
+		}
+	}
+	return discrepancies, errs
+}
+
 // KillQuery stops replication on a given instance
 func KillQuery(instanceKey *InstanceKey, process int64) (*Instance, error) {
 	instance, err := ReadTopologyInstance(instanceKey)
@@ -1079,6 +1383,112 @@ func KillQuery(instanceKey *InstanceKey, process int64) (*Instance, error) {
 	return instance, err
 }
 
+// FenceInstance makes a (presumably failed) master unable to take writes: it sets the instance read-only,
+// kills client connections, and verifies read_only is in effect
+func FenceInstance(instanceKey *InstanceKey) error {
+	if *config.RuntimeCLIFlags.Noop {
+		return fmt.Errorf("noop: aborting fence operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	if _, err := ExecInstance(instanceKey, "set global read_only = 1"); err != nil {
+		return log.Errore(err)
+	}
+	if config.Config.UseSuperReadOnly {
+		if _, err := ExecInstance(instanceKey, "set global super_read_only = 1"); err != nil {
+			// Best effort, as in SetReadOnly
+			log.Errore(err)
+		}
+	}
+	killed, err := KillClientConnections(instanceKey)
+	if err != nil {
+		return log.Errore(err)
+	}
+	var readOnly bool
+	if err := ScanInstanceRow(instanceKey, "select @@global.read_only", &readOnly); err != nil {
+		return log.Errore(err)
+	}
+	if !readOnly {
+		return fmt.Errorf("FenceInstance: %+v is still writable", *instanceKey)
+	}
+	AuditOperation("fence", instanceKey, fmt.Sprintf("set read_only; killed %d connections", killed))
+	return nil
+}
+
+// KillClientConnections kills all client connections on an instance, apart from replication threads, binlog
+// dumps and orchestrator's own connection. Returns the number of connections found.
+func KillClientConnections(instanceKey *InstanceKey) (int, error) {
+	if *config.RuntimeCLIFlags.Noop {
+		return 0, fmt.Errorf("noop: aborting kill-connections operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return 0, err
+	}
+	processIds := []int64{}
+	query := `
+		select
+			id
+		from
+			information_schema.processlist
+		where
+			id != connection_id()
+			and user != 'system user'
+			and command not in ('Binlog Dump', 'Binlog Dump GTID', 'Daemon')
+		`
+	err = sqlutils.QueryRowsMap(db, query, func(m sqlutils.RowMap) error {
+		processIds = append(processIds, m.GetInt64("id"))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for _, processId := range processIds {
+		// Connections may be gone by now
+		ExecInstance(instanceKey, `kill ?`, processId)
+	}
+	return len(processIds), nil
+}
+
+// CountClientConnections counts the client connections of an instance, apart from replication threads, binlog
+// dumps and orchestrator's own connections
+func CountClientConnections(instanceKey *InstanceKey) (count int, err error) {
+	query := `
+		select
+			count(*)
+		from
+			information_schema.processlist
+		where
+			id != connection_id()
+			and user not in ('system user', ?)
+			and command not in ('Binlog Dump', 'Binlog Dump GTID', 'Daemon')
+		`
+	db, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return count, err
+	}
+	err = db.QueryRow(query, config.Config.MySQLTopologyUser).Scan(&count)
+	return count, err
+}
+
+// InstanceReachability is the outcome of this node's attempt to connect to an instance
+type InstanceReachability struct {
+	Key       InstanceKey
+	Reachable bool
+	Error     string
+}
+
+// CheckInstanceReachability attempts to connect to and query given instance, independently of discovery.
+// Other orchestrator nodes use it to confirm, or refute, a failure seen by the leader.
+func CheckInstanceReachability(instanceKey *InstanceKey) *InstanceReachability {
+	reachability := &InstanceReachability{Key: *instanceKey}
+	var dummy int
+	if err := ScanInstanceRow(instanceKey, "select 1", &dummy); err != nil {
+		reachability.Error = err.Error()
+		return reachability
+	}
+	reachability.Reachable = true
+	return reachability
+}
+
 // injectPseudoGTID injects a Pseudo-GTID statement on a writable instance
 func injectPseudoGTID(instance *Instance) (hint string, err error) {
 	if *config.RuntimeCLIFlags.Noop {
@@ -1,6 +1,7 @@
 package inst
 
 import (
+	"database/sql"
 	"math/rand"
 
 	"github.com/github/orchestrator/go/config"
@@ -113,6 +114,30 @@ func TestSortInstancesGtidErrant(t *testing.T) {
 	test.S(t).ExpectEquals(instances[0].Key, i810Key)
 }
 
+func TestSortInstancesHeartbeatLag(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+		instance.HeartbeatLagMilliseconds = sql.NullInt64{Int64: 800, Valid: true}
+	}
+	instancesMap[i810Key.StringCode()].HeartbeatLagMilliseconds.Int64 = 150
+	sortInstances(instances)
+	test.S(t).ExpectEquals(instances[0].Key, i810Key)
+}
+
+func TestSortInstancesPromotionRuleOverHeartbeatLag(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	for _, instance := range instances {
+		instance.ExecBinlogCoordinates = instances[0].ExecBinlogCoordinates
+		instance.HeartbeatLagMilliseconds = sql.NullInt64{Int64: 150, Valid: true}
+		instance.PromotionRule = NeutralPromoteRule
+	}
+	instancesMap[i830Key.StringCode()].PromotionRule = PreferPromoteRule
+	instancesMap[i830Key.StringCode()].HeartbeatLagMilliseconds.Int64 = 800
+	sortInstances(instances)
+	test.S(t).ExpectEquals(instances[0].Key, i830Key)
+}
+
 func TestGetPriorityMajorVersionForCandidate(t *testing.T) {
 	{
 		instances, instancesMap := generateTestInstances()
@@ -256,6 +281,15 @@ func TestIsBannedFromBeingCandidateReplica(t *testing.T) {
 			test.S(t).ExpectTrue(IsBannedFromBeingCandidateReplica(instance))
 		}
 	}
+	{
+		instances, _ := generateTestInstances()
+		for _, instance := range instances {
+			instance.IsNoTouch = true
+		}
+		for _, instance := range instances {
+			test.S(t).ExpectTrue(IsBannedFromBeingCandidateReplica(instance))
+		}
+	}
 	{
 		instances, _ := generateTestInstances()
 		config.Config.PromotionIgnoreHostnameFilters = []string{
@@ -568,3 +602,28 @@ func TestChooseCandidateReplicaPromoteRuleOrdering3(t *testing.T) {
 	test.S(t).ExpectEquals(len(laterReplicas), 0)
 	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
 }
+
+func TestChooseCandidateReplicaFewestStranded(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	// i830 collides on server_id with two of its siblings, which would not be able to replicate from it
+	instancesMap[i710Key.StringCode()].ServerID = 830
+	instancesMap[i720Key.StringCode()].ServerID = 830
+	instances = sortedReplicas(instances, NoStopReplication)
+	candidate, aheadReplicas, equalReplicas, laterReplicas, cannotReplicateReplicas, err := chooseCandidateReplica(instances)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(candidate.Key, i820Key)
+	test.S(t).ExpectEquals(len(aheadReplicas), 1)
+	test.S(t).ExpectEquals(len(equalReplicas), 0)
+	test.S(t).ExpectEquals(len(laterReplicas), 4)
+	test.S(t).ExpectEquals(len(cannotReplicateReplicas), 0)
+}
+
+func TestGTIDPurgedError(t *testing.T) {
+	err := &GTIDPurgedError{
+		InstanceKey:        i710Key,
+		MasterKey:          i720Key,
+		MissingGtidEntries: []string{"00020194-3333-3333-3333-333333333333:1-7", "00020192-1111-1111-1111-111111111111:3"},
+	}
+	test.S(t).ExpectEquals(err.Error(), "Instance i720:3306 has purged GTID entries not found on i710:3306: 00020194-3333-3333-3333-333333333333:1-7, 00020192-1111-1111-1111-111111111111:3")
+}
@@ -149,6 +149,14 @@ func (this *InstancesSorterByExec) Less(i, j int) bool {
 		if this.instances[j].PromotionRule.SmallerThan(this.instances[i].PromotionRule) {
 			return true
 		}
+		if this.instances[i].PromotionRule.SmallerThan(this.instances[j].PromotionRule) {
+			return false
+		}
+		// Prefer lower heartbeat lag, where measured:
+		if this.instances[i].HeartbeatLagMilliseconds.Valid && this.instances[j].HeartbeatLagMilliseconds.Valid &&
+			this.instances[j].HeartbeatLagMilliseconds.Int64 < this.instances[i].HeartbeatLagMilliseconds.Int64 {
+			return true
+		}
 	}
 	return this.instances[i].ExecBinlogCoordinates.SmallerThan(&this.instances[j].ExecBinlogCoordinates)
 }
@@ -0,0 +1,256 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// LagSample is a replica's replication lag as sampled at a point in time. A replica whose lag is unknown, e.g.
+// because it was unreachable or its replication was broken, has an invalid lag.
+type LagSample struct {
+	Key        InstanceKey
+	Timestamp  time.Time
+	Valid      bool
+	LagSeconds int64
+}
+
+// LagDistribution summarizes lag samples. Samples of unknown lag do not take part in percentiles, and count
+// against compliance.
+type LagDistribution struct {
+	Samples           int
+	UnknownSamples    int
+	P50Seconds        int64
+	P90Seconds        int64
+	P99Seconds        int64
+	MaxSeconds        int64
+	CompliancePercent float64
+}
+
+// ReplicaLagSLOReport is a single replica's lag SLO compliance
+type ReplicaLagSLOReport struct {
+	Key InstanceKey
+	LagDistribution
+	SLOMet bool
+}
+
+// LagSLOTrendPoint is a cluster's lag SLO compliance within an hour
+type LagSLOTrendPoint struct {
+	Hour string
+	LagDistribution
+}
+
+// LagSLOReport is a cluster's lag SLO compliance over a window of time: the percent of samples, across all of the
+// cluster's replicas, in which replication lag was below ThresholdSeconds
+type LagSLOReport struct {
+	ClusterAlias     string
+	ThresholdSeconds uint
+	TargetPercent    float64
+	Hours            uint
+	LagDistribution
+	SLOMet   bool
+	Replicas []ReplicaLagSLOReport
+	Trend    []LagSLOTrendPoint
+}
+
+// lagPercentile returns the nearest-rank percentile of sorted lags
+func lagPercentile(sortedLags []int64, percentile float64) int64 {
+	if len(sortedLags) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sortedLags))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sortedLags[rank-1]
+}
+
+// NewLagDistribution summarizes given samples against a lag threshold
+func NewLagDistribution(samples []LagSample, thresholdSeconds uint) LagDistribution {
+	distribution := LagDistribution{Samples: len(samples)}
+	lags := []int64{}
+	compliant := 0
+	for _, sample := range samples {
+		if !sample.Valid {
+			distribution.UnknownSamples++
+			continue
+		}
+		lags = append(lags, sample.LagSeconds)
+		if sample.LagSeconds < int64(thresholdSeconds) {
+			compliant++
+		}
+	}
+	sort.Slice(lags, func(i, j int) bool { return lags[i] < lags[j] })
+	distribution.P50Seconds = lagPercentile(lags, 50)
+	distribution.P90Seconds = lagPercentile(lags, 90)
+	distribution.P99Seconds = lagPercentile(lags, 99)
+	if len(lags) > 0 {
+		distribution.MaxSeconds = lags[len(lags)-1]
+	}
+	if distribution.Samples > 0 {
+		distribution.CompliancePercent = 100 * float64(compliant) / float64(distribution.Samples)
+	}
+	return distribution
+}
+
+// NewLagSLOReport computes a cluster's lag SLO compliance, overall, per replica and per hour, out of its samples
+func NewLagSLOReport(clusterAlias string, samples []LagSample, thresholdSeconds uint, targetPercent float64, hours uint) *LagSLOReport {
+	report := &LagSLOReport{
+		ClusterAlias:     clusterAlias,
+		ThresholdSeconds: thresholdSeconds,
+		TargetPercent:    targetPercent,
+		Hours:            hours,
+		LagDistribution:  NewLagDistribution(samples, thresholdSeconds),
+		Replicas:         []ReplicaLagSLOReport{},
+		Trend:            []LagSLOTrendPoint{},
+	}
+	report.SLOMet = report.Samples == 0 || report.CompliancePercent >= targetPercent
+
+	replicaSamples := make(map[InstanceKey][]LagSample)
+	hourSamples := make(map[string][]LagSample)
+	for _, sample := range samples {
+		replicaSamples[sample.Key] = append(replicaSamples[sample.Key], sample)
+		hour := sample.Timestamp.Truncate(time.Hour).Format("2006-01-02 15:00:00")
+		hourSamples[hour] = append(hourSamples[hour], sample)
+	}
+	for key, samples := range replicaSamples {
+		replicaReport := ReplicaLagSLOReport{Key: key, LagDistribution: NewLagDistribution(samples, thresholdSeconds)}
+		replicaReport.SLOMet = replicaReport.CompliancePercent >= targetPercent
+		report.Replicas = append(report.Replicas, replicaReport)
+	}
+	sort.Slice(report.Replicas, func(i, j int) bool {
+		return report.Replicas[i].Key.SmallerThan(&report.Replicas[j].Key)
+	})
+	for hour, samples := range hourSamples {
+		report.Trend = append(report.Trend, LagSLOTrendPoint{Hour: hour, LagDistribution: NewLagDistribution(samples, thresholdSeconds)})
+	}
+	sort.Slice(report.Trend, func(i, j int) bool { return report.Trend[i].Hour < report.Trend[j].Hour })
+	return report
+}
+
+// BreachingReplicas counts the replicas not meeting the lag SLO
+func (this *LagSLOReport) BreachingReplicas() int {
+	count := 0
+	for _, replica := range this.Replicas {
+		if !replica.SLOMet {
+			count++
+		}
+	}
+	return count
+}
+
+// lagSampleCounts counts a replica's lag samples within an hour, and those of them complying with the lag SLO
+type lagSampleCounts struct {
+	samples   int
+	compliant int
+}
+
+// LagSLOAggregate tracks the lag SLO compliance of all clusters and replicas incrementally: new samples are counted
+// into hourly buckets, and buckets are dropped as they fall out of the window. The window thus spans whole hours.
+type LagSLOAggregate struct {
+	LastSampleTimestamp string
+
+	buckets    map[string]map[InstanceKey]map[time.Time]*lagSampleCounts // by cluster alias, replica, hour
+	latestHour time.Time
+}
+
+// NewLagSLOAggregate returns an empty aggregate
+func NewLagSLOAggregate() *LagSLOAggregate {
+	return &LagSLOAggregate{
+		buckets: make(map[string]map[InstanceKey]map[time.Time]*lagSampleCounts),
+	}
+}
+
+// Add counts given samples, mapped by cluster alias, against a lag threshold. lastSampleTimestamp is that of the
+// latest of the samples, from which to read further samples.
+func (this *LagSLOAggregate) Add(samples map[string][]LagSample, lastSampleTimestamp string, thresholdSeconds uint) {
+	for clusterAlias, clusterSamples := range samples {
+		clusterBuckets, found := this.buckets[clusterAlias]
+		if !found {
+			clusterBuckets = make(map[InstanceKey]map[time.Time]*lagSampleCounts)
+			this.buckets[clusterAlias] = clusterBuckets
+		}
+		for _, sample := range clusterSamples {
+			replicaBuckets, found := clusterBuckets[sample.Key]
+			if !found {
+				replicaBuckets = make(map[time.Time]*lagSampleCounts)
+				clusterBuckets[sample.Key] = replicaBuckets
+			}
+			hour := sample.Timestamp.Truncate(time.Hour)
+			counts, found := replicaBuckets[hour]
+			if !found {
+				counts = &lagSampleCounts{}
+				replicaBuckets[hour] = counts
+			}
+			counts.samples++
+			if sample.Valid && sample.LagSeconds < int64(thresholdSeconds) {
+				counts.compliant++
+			}
+			if hour.After(this.latestHour) {
+				this.latestHour = hour
+			}
+		}
+	}
+	if lastSampleTimestamp > this.LastSampleTimestamp {
+		this.LastSampleTimestamp = lastSampleTimestamp
+	}
+}
+
+// Expire drops the hourly buckets falling out of a window of given hours, ending with the latest sample
+func (this *LagSLOAggregate) Expire(hours uint) {
+	oldestHour := this.latestHour.Add(-time.Duration(hours-1) * time.Hour)
+	for clusterAlias, clusterBuckets := range this.buckets {
+		for key, replicaBuckets := range clusterBuckets {
+			for hour := range replicaBuckets {
+				if hour.Before(oldestHour) {
+					delete(replicaBuckets, hour)
+				}
+			}
+			if len(replicaBuckets) == 0 {
+				delete(clusterBuckets, key)
+			}
+		}
+		if len(clusterBuckets) == 0 {
+			delete(this.buckets, clusterAlias)
+		}
+	}
+}
+
+// Breaching counts the clusters and replicas not meeting the lag SLO, given its target percent
+func (this *LagSLOAggregate) Breaching(targetPercent float64) (clusters int, replicas int) {
+	for _, clusterBuckets := range this.buckets {
+		clusterCounts := lagSampleCounts{}
+		for _, replicaBuckets := range clusterBuckets {
+			replicaCounts := lagSampleCounts{}
+			for _, counts := range replicaBuckets {
+				replicaCounts.samples += counts.samples
+				replicaCounts.compliant += counts.compliant
+			}
+			if replicaCounts.samples > 0 && 100*float64(replicaCounts.compliant)/float64(replicaCounts.samples) < targetPercent {
+				replicas++
+			}
+			clusterCounts.samples += replicaCounts.samples
+			clusterCounts.compliant += replicaCounts.compliant
+		}
+		if clusterCounts.samples > 0 && 100*float64(clusterCounts.compliant)/float64(clusterCounts.samples) < targetPercent {
+			clusters++
+		}
+	}
+	return clusters, replicas
+}
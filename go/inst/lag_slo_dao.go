@@ -0,0 +1,126 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// RecordReplicaLagHistory samples the replication lag of all replicas into the replica_lag_history table.
+// A replica whose last check failed is sampled with unknown lag. Samples are keyed by cluster alias, which,
+// unlike the cluster name, survives a master failover.
+func RecordReplicaLagHistory() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert ignore
+				into replica_lag_history (
+					hostname, port, sample_timestamp, cluster_alias, lag_seconds
+				)
+			select
+				database_instance.hostname,
+				database_instance.port,
+				NOW(),
+				ifnull(cluster_alias.alias, database_instance.cluster_name),
+				case when database_instance.last_checked <= database_instance.last_seen and database_instance.slave_sql_running = 1 then database_instance.slave_lag_seconds else null end
+			from
+				database_instance
+				left join cluster_alias using (cluster_name)
+			where
+				database_instance.master_host != ''
+				and database_instance.cluster_name != ''
+			`,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ExpireReplicaLagHistory removes samples older than ReplicaLagHistoryRetentionDays
+func ExpireReplicaLagHistory() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from replica_lag_history
+				where sample_timestamp < NOW() - INTERVAL ? DAY
+			`,
+			config.Config.ReplicaLagHistoryRetentionDays,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// readLagSamples reads the lag samples of the past given hours, mapped by cluster alias. Samples may be further
+// limited by given condition. It also returns the timestamp of the latest sample read.
+func readLagSamples(hours uint, whereCondition string, args ...interface{}) (samples map[string][]LagSample, lastSampleTimestamp string, err error) {
+	samples = make(map[string][]LagSample)
+	query := fmt.Sprintf(`
+		select
+			hostname,
+			port,
+			sample_timestamp,
+			cluster_alias,
+			lag_seconds
+		from
+			replica_lag_history
+		where
+			sample_timestamp >= NOW() - INTERVAL ? HOUR
+			%s
+		`, whereCondition)
+	err = db.QueryOrchestrator(query, append(sqlutils.Args(hours), args...), func(m sqlutils.RowMap) error {
+		lag := m.GetNullInt64("lag_seconds")
+		sample := LagSample{
+			Key:        InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			Timestamp:  m.GetTime("sample_timestamp"),
+			Valid:      lag.Valid,
+			LagSeconds: lag.Int64,
+		}
+		clusterAlias := m.GetString("cluster_alias")
+		samples[clusterAlias] = append(samples[clusterAlias], sample)
+		if sampleTimestamp := m.GetString("sample_timestamp"); sampleTimestamp > lastSampleTimestamp {
+			lastSampleTimestamp = sampleTimestamp
+		}
+		return nil
+	})
+	return samples, lastSampleTimestamp, log.Errore(err)
+}
+
+// ReadLagSamplesSince reads the lag samples taken after given sample timestamp, within the past given hours.
+// An empty timestamp reads all samples of the past given hours.
+func ReadLagSamplesSince(sampleTimestamp string, hours uint) (samples map[string][]LagSample, lastSampleTimestamp string, err error) {
+	if sampleTimestamp == "" {
+		return readLagSamples(hours, ``)
+	}
+	samples, lastSampleTimestamp, err = readLagSamples(hours, `and sample_timestamp > ?`, sampleTimestamp)
+	if lastSampleTimestamp == "" {
+		lastSampleTimestamp = sampleTimestamp
+	}
+	return samples, lastSampleTimestamp, err
+}
+
+// ReadLagSLOReport computes the lag SLO compliance of given cluster over the past given hours
+func ReadLagSLOReport(clusterAlias string, hours uint) (*LagSLOReport, error) {
+	samples, _, err := readLagSamples(hours, `and cluster_alias = ?`, clusterAlias)
+	if err != nil {
+		return nil, err
+	}
+	return NewLagSLOReport(clusterAlias, samples[clusterAlias], config.Config.ReplicaLagSLOSeconds, config.Config.ReplicaLagSLOTargetPercent, hours), nil
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewLagDistribution(t *testing.T) {
+	key := InstanceKey{Hostname: "db-1", Port: 3306}
+	samples := []LagSample{}
+	for lag := int64(1); lag <= 9; lag++ {
+		samples = append(samples, LagSample{Key: key, Valid: true, LagSeconds: lag})
+	}
+	samples = append(samples, LagSample{Key: key})
+
+	distribution := NewLagDistribution(samples, 5)
+	test.S(t).ExpectEquals(distribution.Samples, 10)
+	test.S(t).ExpectEquals(distribution.UnknownSamples, 1)
+	test.S(t).ExpectEquals(distribution.P50Seconds, int64(5))
+	test.S(t).ExpectEquals(distribution.P90Seconds, int64(9))
+	test.S(t).ExpectEquals(distribution.MaxSeconds, int64(9))
+	// 1s-4s comply; the unknown sample does not
+	test.S(t).ExpectEquals(distribution.CompliancePercent, float64(40))
+
+	empty := NewLagDistribution([]LagSample{}, 5)
+	test.S(t).ExpectEquals(empty.Samples, 0)
+	test.S(t).ExpectEquals(empty.CompliancePercent, float64(0))
+}
+
+func TestNewLagSLOReport(t *testing.T) {
+	hour := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	key1 := InstanceKey{Hostname: "db-1", Port: 3306}
+	key2 := InstanceKey{Hostname: "db-2", Port: 3306}
+	samples := []LagSample{
+		{Key: key2, Timestamp: hour, Valid: true, LagSeconds: 0},
+		{Key: key2, Timestamp: hour.Add(time.Minute), Valid: true, LagSeconds: 30},
+		{Key: key1, Timestamp: hour, Valid: true, LagSeconds: 1},
+		{Key: key1, Timestamp: hour.Add(time.Hour), Valid: true, LagSeconds: 2},
+	}
+	report := NewLagSLOReport("db-master:3306", samples, 5, 75, 24)
+	test.S(t).ExpectEquals(report.Samples, 4)
+	test.S(t).ExpectEquals(report.CompliancePercent, float64(75))
+	test.S(t).ExpectTrue(report.SLOMet)
+
+	test.S(t).ExpectEquals(len(report.Replicas), 2)
+	test.S(t).ExpectEquals(report.Replicas[0].Key, key1)
+	test.S(t).ExpectTrue(report.Replicas[0].SLOMet)
+	test.S(t).ExpectEquals(report.Replicas[1].CompliancePercent, float64(50))
+	test.S(t).ExpectFalse(report.Replicas[1].SLOMet)
+	test.S(t).ExpectEquals(report.BreachingReplicas(), 1)
+
+	test.S(t).ExpectEquals(len(report.Trend), 2)
+	test.S(t).ExpectEquals(report.Trend[0].Hour, "2018-01-01 10:00:00")
+	test.S(t).ExpectEquals(report.Trend[0].Samples, 3)
+	test.S(t).ExpectEquals(report.Trend[1].CompliancePercent, float64(100))
+}
+
+func TestLagSLOAggregate(t *testing.T) {
+	hour := time.Date(2018, 1, 1, 10, 0, 0, 0, time.UTC)
+	key1 := InstanceKey{Hostname: "db-1", Port: 3306}
+	key2 := InstanceKey{Hostname: "db-2", Port: 3306}
+	aggregate := NewLagSLOAggregate()
+	aggregate.Add(map[string][]LagSample{
+		"cluster1": {
+			{Key: key1, Timestamp: hour, Valid: true, LagSeconds: 30},
+			{Key: key2, Timestamp: hour, Valid: true, LagSeconds: 1},
+		},
+	}, "2018-01-01 10:00:00", 5)
+	clusters, replicas := aggregate.Breaching(75)
+	test.S(t).ExpectEquals(clusters, 1)
+	test.S(t).ExpectEquals(replicas, 1)
+	test.S(t).ExpectEquals(aggregate.LastSampleTimestamp, "2018-01-01 10:00:00")
+
+	aggregate.Add(map[string][]LagSample{
+		"cluster1": {
+			{Key: key1, Timestamp: hour.Add(2 * time.Hour), Valid: true, LagSeconds: 1},
+			{Key: key2, Timestamp: hour.Add(2 * time.Hour), Valid: true, LagSeconds: 1},
+		},
+	}, "2018-01-01 12:00:00", 5)
+	clusters, replicas = aggregate.Breaching(75)
+	test.S(t).ExpectEquals(clusters, 0)
+	test.S(t).ExpectEquals(replicas, 1)
+
+	// A window of two hours no longer includes the 10:00 samples
+	aggregate.Expire(2)
+	clusters, replicas = aggregate.Breaching(75)
+	test.S(t).ExpectEquals(clusters, 0)
+	test.S(t).ExpectEquals(replicas, 0)
+}
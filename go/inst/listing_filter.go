@@ -0,0 +1,101 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/sqlutils"
+)
+
+// ListingMaxLimit caps the number of entries in a single page of a listing
+const ListingMaxLimit = 1000
+
+// ListingFilter filters, orders and paginates listings of append-only tables such as audit and topology_recovery.
+// Pagination is by cursor: the id of the last entry of the previous page. Unlike page numbers, a cursor is
+// stable while new entries are written.
+type ListingFilter struct {
+	FromTimestamp string // inclusive, formatted 'YYYY-MM-DD hh:mm:ss'
+	ToTimestamp   string // inclusive, formatted 'YYYY-MM-DD hh:mm:ss'
+	ClusterName   string
+	AfterId       int64 // cursor; 0 to start with the first entry
+	Limit         int
+	Ascending     bool // oldest first; default is newest first
+}
+
+// NewListingFilter returns a filter listing a single page of AuditPageSize entries, newest first
+func NewListingFilter() *ListingFilter {
+	return &ListingFilter{Limit: config.AuditPageSize}
+}
+
+// GetLimit returns the effective page size
+func (this *ListingFilter) GetLimit() int {
+	if this.Limit <= 0 {
+		return config.AuditPageSize
+	}
+	if this.Limit > ListingMaxLimit {
+		return ListingMaxLimit
+	}
+	return this.Limit
+}
+
+// Conditions returns the filter's SQL conditions on given columns, with their arguments. An empty clusterColumn
+// is for tables without a cluster column.
+func (this *ListingFilter) Conditions(idColumn string, timestampColumn string, clusterColumn string) (conditions []string, args []interface{}) {
+	conditions = []string{}
+	args = sqlutils.Args()
+	if this.FromTimestamp != "" {
+		conditions = append(conditions, fmt.Sprintf("%s >= ?", timestampColumn))
+		args = append(args, this.FromTimestamp)
+	}
+	if this.ToTimestamp != "" {
+		conditions = append(conditions, fmt.Sprintf("%s <= ?", timestampColumn))
+		args = append(args, this.ToTimestamp)
+	}
+	if this.ClusterName != "" && clusterColumn != "" {
+		conditions = append(conditions, fmt.Sprintf("%s = ?", clusterColumn))
+		args = append(args, this.ClusterName)
+	}
+	if this.AfterId > 0 {
+		if this.Ascending {
+			conditions = append(conditions, fmt.Sprintf("%s > ?", idColumn))
+		} else {
+			conditions = append(conditions, fmt.Sprintf("%s < ?", idColumn))
+		}
+		args = append(args, this.AfterId)
+	}
+	return conditions, args
+}
+
+// OrderLimit returns the "order by ... limit ..." clause. Ids grow with time, and so ordering by id is chronological.
+func (this *ListingFilter) OrderLimit(idColumn string) string {
+	direction := "desc"
+	if this.Ascending {
+		direction = "asc"
+	}
+	return fmt.Sprintf("order by %s %s limit %d", idColumn, direction, this.GetLimit())
+}
+
+// WhereClause joins given conditions into a where clause, or an empty string if there are none
+func WhereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("where %s", strings.Join(conditions, " and "))
+}
@@ -0,0 +1,45 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestListingFilterGetLimit(t *testing.T) {
+	filter := NewListingFilter()
+	test.S(t).ExpectEquals(filter.GetLimit(), config.AuditPageSize)
+	filter.Limit = 0
+	test.S(t).ExpectEquals(filter.GetLimit(), config.AuditPageSize)
+	filter.Limit = 7
+	test.S(t).ExpectEquals(filter.GetLimit(), 7)
+	filter.Limit = ListingMaxLimit + 1
+	test.S(t).ExpectEquals(filter.GetLimit(), ListingMaxLimit)
+}
+
+func TestListingFilterConditions(t *testing.T) {
+	{
+		filter := NewListingFilter()
+		conditions, args := filter.Conditions("audit_id", "audit_timestamp", "cluster_name")
+		test.S(t).ExpectEquals(len(conditions), 0)
+		test.S(t).ExpectEquals(len(args), 0)
+		test.S(t).ExpectEquals(WhereClause(conditions), "")
+		test.S(t).ExpectEquals(filter.OrderLimit("audit_id"), "order by audit_id desc limit 20")
+	}
+	{
+		filter := &ListingFilter{FromTimestamp: "2017-01-01 00:00:00", ClusterName: "c1", AfterId: 100, Limit: 5}
+		conditions, args := filter.Conditions("audit_id", "audit_timestamp", "cluster_name")
+		test.S(t).ExpectEquals(WhereClause(conditions), "where audit_timestamp >= ? and cluster_name = ? and audit_id < ?")
+		test.S(t).ExpectEquals(len(args), 3)
+		test.S(t).ExpectEquals(args[2], int64(100))
+		test.S(t).ExpectEquals(filter.OrderLimit("audit_id"), "order by audit_id desc limit 5")
+	}
+	{
+		filter := &ListingFilter{ToTimestamp: "2017-01-02 00:00:00", ClusterName: "c1", AfterId: 100, Ascending: true}
+		conditions, args := filter.Conditions("recovery_id", "start_active_period", "")
+		test.S(t).ExpectEquals(WhereClause(conditions), "where start_active_period <= ? and recovery_id > ?")
+		test.S(t).ExpectEquals(len(args), 2)
+		test.S(t).ExpectEquals(filter.OrderLimit("recovery_id"), "order by recovery_id asc limit 20")
+	}
+}
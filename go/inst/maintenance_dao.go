@@ -72,6 +72,9 @@ func ReadActiveMaintenance() ([]Maintenance, error) {
 // BeginBoundedMaintenance will make new maintenance entry for given instanceKey.
 func BeginBoundedMaintenance(instanceKey *InstanceKey, owner string, reason string, durationSeconds uint, explicitlyBounded bool) (int64, error) {
 	var maintenanceToken int64 = 0
+	if err := CheckNoTouch(instanceKey); err != nil {
+		return maintenanceToken, log.Errore(err)
+	}
 	if durationSeconds == 0 {
 		durationSeconds = config.MaintenanceExpireMinutes * 60
 	}
@@ -0,0 +1,103 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+var masterFlappingCache = cache.New(10*time.Second, time.Second)
+
+// CountRecentMasterFailovers counts the successful master and co-master failovers on given cluster within
+// MasterFlapDetectionPeriodSeconds, which have not been acknowledged as flapping since.
+// Failovers are counted by cluster alias: each failover renames the cluster after its new master.
+func CountRecentMasterFailovers(clusterAlias string) (count int, err error) {
+	query := `
+		select
+			count(*) as count_failovers
+		from
+			topology_recovery
+			left join cluster_master_flapping_acknowledgement using (cluster_alias)
+		where
+			topology_recovery.cluster_alias = ?
+			and topology_recovery.is_successful = 1
+			and topology_recovery.analysis in (?, ?, ?, ?, ?)
+			and topology_recovery.start_active_period >= now() - interval ? second
+			and (
+				cluster_master_flapping_acknowledgement.acknowledged_at is null
+				or topology_recovery.start_active_period > cluster_master_flapping_acknowledgement.acknowledged_at
+			)
+		`
+	args := sqlutils.Args(clusterAlias,
+		string(DeadMaster), string(DeadMasterAndSomeSlaves), string(DeadMasterWithoutSlaves), string(DeadCoMaster), string(DeadCoMasterAndSomeSlaves),
+		config.Config.MasterFlapDetectionPeriodSeconds,
+	)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		count = m.GetInt("count_failovers")
+		return nil
+	})
+	return count, log.Errore(err)
+}
+
+// IsClusterMasterFlapping checks whether the master of given cluster has failed over MasterFlapThreshold
+// times within MasterFlapDetectionPeriodSeconds, without a human acknowledging the flapping since
+func IsClusterMasterFlapping(clusterAlias string) bool {
+	if config.Config.MasterFlapDetectionPeriodSeconds == 0 {
+		return false
+	}
+	if isFlapping, found := masterFlappingCache.Get(clusterAlias); found {
+		return isFlapping.(bool)
+	}
+	count, err := CountRecentMasterFailovers(clusterAlias)
+	if err != nil {
+		return false
+	}
+	isFlapping := uint(count) >= config.Config.MasterFlapThreshold
+	masterFlappingCache.Set(clusterAlias, isFlapping, cache.DefaultExpiration)
+	return isFlapping
+}
+
+// AcknowledgeMasterFlapping re-enables automated master failovers on a flapping cluster. Failovers
+// preceding the acknowledgement no longer count towards flapping.
+func AcknowledgeMasterFlapping(clusterAlias string, owner string, comment string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert into
+					cluster_master_flapping_acknowledgement (cluster_alias, acknowledged_by, acknowledged_comment, acknowledged_at)
+				values
+					(?, ?, ?, NOW())
+				on duplicate key update
+					acknowledged_by=values(acknowledged_by),
+					acknowledged_comment=values(acknowledged_comment),
+					acknowledged_at=values(acknowledged_at)
+			`,
+			clusterAlias, owner, comment)
+		if err == nil {
+			masterFlappingCache.Delete(clusterAlias)
+			AuditOperation("ack-master-flapping", nil, fmt.Sprintf("cluster %s: acknowledged by %s: %s", clusterAlias, owner, comment))
+		}
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
@@ -0,0 +1,101 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// Kinds of membership violations
+const (
+	MembershipViolationMinReplicas = "min-replicas"
+	MembershipViolationNoCandidate = "no-candidate"
+)
+
+// MembershipViolation is a data center of a cluster falling short of a membership rule
+type MembershipViolation struct {
+	ClusterName  string
+	ClusterAlias string
+	Rule         string
+	DataCenter   string
+	Kind         string
+	Description  string
+}
+
+// Code identifies a violation across evaluations. It does not change as the violation's counts change, nor as
+// the cluster fails over.
+func (this *MembershipViolation) Code() string {
+	return fmt.Sprintf("%s/%s/%s/%s", this.ClusterAlias, this.Rule, this.DataCenter, this.Kind)
+}
+
+// isHealthyMember checks whether an instance counts as a healthy replica of the cluster whose master is given:
+// reachable, not downtimed and applying replication
+func isHealthyMember(instance *Instance, masterKey *InstanceKey) bool {
+	if instance.Key.Equals(masterKey) {
+		return false
+	}
+	return instance.IsLastCheckValid && !instance.IsDowntimed && instance.IsReplica() && instance.ReplicationSQLThreadState.IsRunning()
+}
+
+// EvaluateMembershipRule checks a cluster's instances against a membership rule, with given instance as master.
+// Passing a replica as master forecasts the cluster's membership once that replica is promoted.
+func EvaluateMembershipRule(rule *config.MembershipRule, clusterInfo *ClusterInfo, instances [](*Instance), masterKey *InstanceKey) (violations []MembershipViolation) {
+	dataCenters := rule.DataCenters
+	if len(dataCenters) == 0 {
+		dataCentersMap := make(map[string]bool)
+		for _, instance := range instances {
+			if instance.DataCenter != "" && !dataCentersMap[instance.DataCenter] {
+				dataCentersMap[instance.DataCenter] = true
+				dataCenters = append(dataCenters, instance.DataCenter)
+			}
+		}
+		sort.Strings(dataCenters)
+	}
+	replicas := make(map[string]int)
+	candidates := make(map[string]int)
+	for _, instance := range instances {
+		if !isHealthyMember(instance, masterKey) {
+			continue
+		}
+		replicas[instance.DataCenter]++
+		if instance.PromotionRule == MustPromoteRule || instance.PromotionRule == PreferPromoteRule {
+			candidates[instance.DataCenter]++
+		}
+	}
+	addViolation := func(dataCenter string, kind string, description string) {
+		violations = append(violations, MembershipViolation{
+			ClusterName:  clusterInfo.ClusterName,
+			ClusterAlias: clusterInfo.ClusterAlias,
+			Rule:         rule.Name,
+			DataCenter:   dataCenter,
+			Kind:         kind,
+			Description:  description,
+		})
+	}
+	for _, dataCenter := range dataCenters {
+		if uint(replicas[dataCenter]) < rule.MinReplicasPerDataCenter {
+			addViolation(dataCenter, MembershipViolationMinReplicas, fmt.Sprintf("%d healthy replicas; expecting at least %d", replicas[dataCenter], rule.MinReplicasPerDataCenter))
+		}
+		if rule.CandidatePerDataCenter && candidates[dataCenter] == 0 {
+			addViolation(dataCenter, MembershipViolationNoCandidate, "no healthy promotion candidate")
+		}
+	}
+	return violations
+}
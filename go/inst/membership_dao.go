@@ -0,0 +1,85 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReadMembershipViolations reads the membership violations found by the latest evaluation
+func ReadMembershipViolations() (violations []MembershipViolation, err error) {
+	query := `
+		select
+			cluster_alias,
+			rule_name,
+			data_center,
+			violation_kind,
+			cluster_name,
+			description
+		from
+			membership_violation
+		order by
+			cluster_alias, rule_name, data_center, violation_kind
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		violations = append(violations, MembershipViolation{
+			ClusterName:  m.GetString("cluster_name"),
+			ClusterAlias: m.GetString("cluster_alias"),
+			Rule:         m.GetString("rule_name"),
+			DataCenter:   m.GetString("data_center"),
+			Kind:         m.GetString("violation_kind"),
+			Description:  m.GetString("description"),
+		})
+		return nil
+	})
+	return violations, log.Errore(err)
+}
+
+// WriteMembershipViolations replaces the stored membership violations with those of the latest evaluation
+func WriteMembershipViolations(violations []MembershipViolation) error {
+	writeFunc := func() error {
+		dbh, err := db.OpenOrchestrator()
+		if err != nil {
+			return log.Errore(err)
+		}
+		tx, err := dbh.Begin()
+		if err != nil {
+			return log.Errore(err)
+		}
+		if _, err := db.ExecOrchestratorTx(tx, `delete from membership_violation`); err != nil {
+			tx.Rollback()
+			return log.Errore(err)
+		}
+		query := `
+			insert into membership_violation (
+				cluster_alias, rule_name, data_center, violation_kind, cluster_name, description, last_updated
+			) values (
+				?, ?, ?, ?, ?, ?, NOW()
+			)
+			`
+		for _, violation := range violations {
+			if _, err := db.ExecOrchestratorTx(tx, query, violation.ClusterAlias, violation.Rule, violation.DataCenter, violation.Kind, violation.ClusterName, violation.Description); err != nil {
+				tx.Rollback()
+				return log.Errore(err)
+			}
+		}
+		return log.Errore(tx.Commit())
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
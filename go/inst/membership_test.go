@@ -0,0 +1,84 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+
+	test "github.com/openark/golib/tests"
+)
+
+func newMembershipTestInstance(hostname string, dataCenter string, promotionRule CandidatePromotionRule) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: hostname, Port: 3306}
+	instance.MasterKey = InstanceKey{Hostname: "master", Port: 3306}
+	instance.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql-bin.000001", LogPos: 4}
+	instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	instance.IsLastCheckValid = true
+	instance.DataCenter = dataCenter
+	instance.PromotionRule = promotionRule
+	return instance
+}
+
+func TestEvaluateMembershipRule(t *testing.T) {
+	master := NewInstance()
+	master.Key = InstanceKey{Hostname: "master", Port: 3306}
+	master.IsLastCheckValid = true
+	master.DataCenter = "dc1"
+	clusterInfo := &ClusterInfo{ClusterName: "master:3306", ClusterAlias: "main"}
+	instances := []*Instance{
+		master,
+		newMembershipTestInstance("r1", "dc1", PreferPromoteRule),
+		newMembershipTestInstance("r2", "dc1", NeutralPromoteRule),
+		newMembershipTestInstance("r3", "dc2", NeutralPromoteRule),
+		newMembershipTestInstance("r4", "dc2", PreferPromoteRule),
+	}
+	{
+		rule := &config.MembershipRule{Name: "spread", MinReplicasPerDataCenter: 2, CandidatePerDataCenter: true}
+		violations := EvaluateMembershipRule(rule, clusterInfo, instances, &master.Key)
+		test.S(t).ExpectEquals(len(violations), 0)
+	}
+	{
+		// A downtimed candidate does not count
+		instances[4].IsDowntimed = true
+		rule := &config.MembershipRule{Name: "spread", MinReplicasPerDataCenter: 2, CandidatePerDataCenter: true}
+		violations := EvaluateMembershipRule(rule, clusterInfo, instances, &master.Key)
+		test.S(t).ExpectEquals(len(violations), 2)
+		test.S(t).ExpectEquals(violations[0].DataCenter, "dc2")
+		test.S(t).ExpectEquals(violations[0].ClusterAlias, "main")
+		test.S(t).ExpectEquals(violations[1].Description, "no healthy promotion candidate")
+		test.S(t).ExpectEquals(violations[0].Code(), "main/spread/dc2/min-replicas")
+		test.S(t).ExpectEquals(violations[1].Code(), "main/spread/dc2/no-candidate")
+		instances[4].IsDowntimed = false
+	}
+	{
+		// Promoting r1 leaves dc1 with a single replica and no candidate
+		rule := &config.MembershipRule{Name: "spread", MinReplicasPerDataCenter: 2, CandidatePerDataCenter: true}
+		violations := EvaluateMembershipRule(rule, clusterInfo, instances, &instances[1].Key)
+		test.S(t).ExpectEquals(len(violations), 2)
+		test.S(t).ExpectEquals(violations[0].DataCenter, "dc1")
+		test.S(t).ExpectEquals(violations[0].Rule, "spread")
+	}
+	{
+		rule := &config.MembershipRule{Name: "three-dcs", DataCenters: []string{"dc1", "dc2", "dc3"}, MinReplicasPerDataCenter: 1}
+		violations := EvaluateMembershipRule(rule, clusterInfo, instances, &master.Key)
+		test.S(t).ExpectEquals(len(violations), 1)
+		test.S(t).ExpectEquals(violations[0].DataCenter, "dc3")
+	}
+}
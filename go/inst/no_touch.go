@@ -0,0 +1,47 @@
+/*
+   Copyright 2017 Shlomi Noach, GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+)
+
+// NoTouchLock is a time-limited instance level lock, stronger than downtime: while it is held,
+// orchestrator does not relocate, restart replication on, promote, nor run emergent operations
+// against the instance.
+type NoTouchLock struct {
+	Key             InstanceKey
+	Owner           string
+	Reason          string
+	DurationSeconds uint
+	BeginTimestamp  string
+	EndTimestamp    string
+}
+
+func NewNoTouchLock(instanceKey *InstanceKey, owner string, reason string, durationSeconds uint) *NoTouchLock {
+	return &NoTouchLock{
+		Key:             *instanceKey,
+		Owner:           owner,
+		Reason:          reason,
+		DurationSeconds: durationSeconds,
+	}
+}
+
+// RefusalError returns the error by which an operation on the locked instance is refused
+func (this *NoTouchLock) RefusalError() error {
+	return fmt.Errorf("%+v is no-touch locked by %s until %s; reason: %s", this.Key.DisplayString(), this.Owner, this.EndTimestamp, this.Reason)
+}
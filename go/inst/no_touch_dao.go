@@ -0,0 +1,177 @@
+/*
+   Copyright 2015 Shlomi Noach, courtesy Booking.com
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+)
+
+const noTouchLocksCacheKey = "no-touch-locks"
+
+// noTouchLocksCache holds the active locks briefly, as they are checked on every write to a topology instance
+var noTouchLocksCache = cache.New(time.Second, time.Second)
+
+// BeginNoTouch will no-touch lock an instance, or override an existing lock
+func BeginNoTouch(lock *NoTouchLock) error {
+	if lock.DurationSeconds == 0 {
+		lock.DurationSeconds = config.MaintenanceExpireMinutes * 60
+	}
+	_, err := db.ExecOrchestrator(`
+			insert
+				into database_instance_no_touch (
+					hostname, port, lock_owner, lock_reason, lock_begin_timestamp, lock_end_timestamp
+				) VALUES (
+					?, ?, ?, ?, NOW(), NOW() + INTERVAL ? SECOND
+				)
+				on duplicate key update
+					lock_owner=values(lock_owner),
+					lock_reason=values(lock_reason),
+					lock_begin_timestamp=values(lock_begin_timestamp),
+					lock_end_timestamp=values(lock_end_timestamp)
+			`,
+		lock.Key.Hostname,
+		lock.Key.Port,
+		lock.Owner,
+		lock.Reason,
+		lock.DurationSeconds,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	noTouchLocksCache.Flush()
+	AuditOperation("begin-no-touch", &lock.Key, fmt.Sprintf("owner: %s, reason: %s, duration: %ds", lock.Owner, lock.Reason, lock.DurationSeconds))
+
+	return nil
+}
+
+// EndNoTouch will release the no-touch lock of an instance
+func EndNoTouch(instanceKey *InstanceKey) (wasLocked bool, err error) {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				database_instance_no_touch
+			where
+				hostname = ?
+				and port = ?
+			`,
+		instanceKey.Hostname,
+		instanceKey.Port,
+	)
+	if err != nil {
+		return wasLocked, log.Errore(err)
+	}
+	noTouchLocksCache.Flush()
+
+	if affected, _ := res.RowsAffected(); affected > 0 {
+		wasLocked = true
+		AuditOperation("end-no-touch", instanceKey, "")
+	}
+	return wasLocked, err
+}
+
+// ReadNoTouchLocks returns all currently held no-touch locks
+func ReadNoTouchLocks() ([]NoTouchLock, error) {
+	res := []NoTouchLock{}
+	query := `
+		select
+			hostname,
+			port,
+			lock_owner,
+			lock_reason,
+			lock_begin_timestamp,
+			lock_end_timestamp
+		from
+			database_instance_no_touch
+		where
+			lock_end_timestamp > now()
+		order by
+			hostname, port
+		`
+	err := db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		lock := NoTouchLock{}
+		lock.Key.Hostname = m.GetString("hostname")
+		lock.Key.Port = m.GetInt("port")
+		lock.Owner = m.GetString("lock_owner")
+		lock.Reason = m.GetString("lock_reason")
+		lock.BeginTimestamp = m.GetString("lock_begin_timestamp")
+		lock.EndTimestamp = m.GetString("lock_end_timestamp")
+
+		res = append(res, lock)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadNoTouchLock returns the no-touch lock held on given instance, or nil if there is none
+func ReadNoTouchLock(instanceKey *InstanceKey) (*NoTouchLock, error) {
+	locks, found := noTouchLocksCache.Get(noTouchLocksCacheKey)
+	if !found {
+		locksList, err := ReadNoTouchLocks()
+		if err != nil {
+			return nil, err
+		}
+		locksMap := make(map[InstanceKey]*NoTouchLock)
+		for i := range locksList {
+			locksMap[locksList[i].Key] = &locksList[i]
+		}
+		noTouchLocksCache.Set(noTouchLocksCacheKey, locksMap, cache.DefaultExpiration)
+		locks = locksMap
+	}
+	return locks.(map[InstanceKey]*NoTouchLock)[*instanceKey], nil
+}
+
+// CheckNoTouch returns an error if given instance is no-touch locked.
+// When locks cannot be read off the backend we fail open, so that an unavailable backend does not block
+// recoveries; the failure is audited.
+func CheckNoTouch(instanceKey *InstanceKey) error {
+	lock, err := ReadNoTouchLock(instanceKey)
+	if err != nil {
+		message := fmt.Sprintf("Cannot verify whether %+v is no-touch locked; proceeding: %+v", instanceKey.DisplayString(), err)
+		log.Warningf("%s", message)
+		AuditOperation("no-touch-check-failed", instanceKey, message)
+		return nil
+	}
+	if lock != nil {
+		return lock.RefusalError()
+	}
+	return nil
+}
+
+// ExpireNoTouchLocks removes no-touch locks past their end time
+func ExpireNoTouchLocks() error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				database_instance_no_touch
+			where
+				lock_end_timestamp < NOW()
+			`,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+		noTouchLocksCache.Flush()
+		AuditOperation("expire-no-touch", nil, fmt.Sprintf("Expired %d entries", rowsAffected))
+	}
+	return nil
+}
@@ -115,6 +115,14 @@ func (this *OracleGtidSet) Explode() (result [](*OracleGtidSetEntry)) {
 	return result
 }
 
+// Count returns the number of transactions in this set
+func (this *OracleGtidSet) Count() (count int64) {
+	for _, entry := range this.GtidEntries {
+		count += entry.Count()
+	}
+	return count
+}
+
 func (this *OracleGtidSet) String() string {
 	tokens := []string{}
 	for _, entry := range this.GtidEntries {
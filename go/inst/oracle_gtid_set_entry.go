@@ -73,3 +73,18 @@ func (this *OracleGtidSetEntry) Explode() (result [](*OracleGtidSetEntry)) {
 	}
 	return result
 }
+
+// Count returns the number of transactions in this entry
+func (this *OracleGtidSetEntry) Count() (count int64) {
+	intervals := strings.Split(this.Ranges, ":")
+	for _, interval := range intervals {
+		if submatch := multiValueInterval.FindStringSubmatch(interval); submatch != nil {
+			intervalStart, _ := strconv.ParseInt(submatch[1], 10, 64)
+			intervalEnd, _ := strconv.ParseInt(submatch[2], 10, 64)
+			count += intervalEnd - intervalStart + 1
+		} else if singleValueInterval.MatchString(interval) {
+			count++
+		}
+	}
+	return count
+}
@@ -225,3 +225,25 @@ func TestSharedUUIDs(t *testing.T) {
 		}
 	}
 }
+
+func TestOracleGtidSetCount(t *testing.T) {
+	{
+		gtidSet, err := NewOracleGtidSet("")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(gtidSet.Count(), int64(0))
+	}
+	{
+		gtidSet, err := NewOracleGtidSet("00020192-1111-1111-1111-111111111111:20,00020194-3333-3333-3333-333333333333:1-8935:8984-8990")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(gtidSet.Count(), int64(1+8935+7))
+	}
+}
+
+func TestEmptyGTIDTransactionStatements(t *testing.T) {
+	entry, err := NewOracleGtidSetEntry("00020194-3333-3333-3333-333333333333:7")
+	test.S(t).ExpectNil(err)
+	statements := emptyGTIDTransactionStatements(entry.Explode()[0])
+	test.S(t).ExpectEquals(len(statements), 4)
+	test.S(t).ExpectEquals(statements[0], `SET GTID_NEXT="00020194-3333-3333-3333-333333333333:7"`)
+	test.S(t).ExpectEquals(statements[3], `SET GTID_NEXT="AUTOMATIC"`)
+}
@@ -33,13 +33,13 @@ func writePoolInstances(pool string, instanceKeys [](*InstanceKey)) error {
 			return log.Errore(err)
 		}
 		tx, err := dbh.Begin()
-		if _, err := tx.Exec(`delete from database_instance_pool where pool = ?`, pool); err != nil {
+		if _, err := db.ExecOrchestratorTx(tx, `delete from database_instance_pool where pool = ?`, pool); err != nil {
 			tx.Rollback()
 			return log.Errore(err)
 		}
 		query := `insert into database_instance_pool (hostname, port, pool, registered_at) values (?, ?, ?, now())`
 		for _, instanceKey := range instanceKeys {
-			if _, err := tx.Exec(query, instanceKey.Hostname, instanceKey.Port, pool); err != nil {
+			if _, err := db.ExecOrchestratorTx(tx, query, instanceKey.Hostname, instanceKey.Port, pool); err != nil {
 				tx.Rollback()
 				return log.Errore(err)
 			}
@@ -119,25 +119,39 @@ func ReadClusterPoolInstancesMap(clusterName string, pool string) (*PoolInstance
 
 func ReadAllPoolInstancesSubmissions() ([]PoolInstancesSubmission, error) {
 	result := []PoolInstancesSubmission{}
+	pools := []string{}
+	submissionsMap := make(map[string]*PoolInstancesSubmission)
 	query := `
 		select
 			pool,
-			min(registered_at) as registered_at,
-			GROUP_CONCAT(concat(hostname, ':', port)) as hosts
+			registered_at,
+			hostname,
+			port
 		from
 			database_instance_pool
-		group by
-			pool
+		order by
+			pool, registered_at
 	`
 	err := db.QueryOrchestrator(query, sqlutils.Args(), func(m sqlutils.RowMap) error {
-		submission := PoolInstancesSubmission{}
-		submission.Pool = m.GetString("pool")
-		submission.CreatedAt = m.GetTime("registered_at")
-		submission.RegisteredAt = m.GetString("registered_at")
-		submission.DelimitedInstances = m.GetString("hosts")
-		result = append(result, submission)
+		pool := m.GetString("pool")
+		// Keys are listed in their string code, so that IPv6 addresses are bracketed
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		if submission, ok := submissionsMap[pool]; ok {
+			submission.DelimitedInstances = fmt.Sprintf("%s,%s", submission.DelimitedInstances, instanceKey.StringCode())
+			return nil
+		}
+		submissionsMap[pool] = &PoolInstancesSubmission{
+			Pool:               pool,
+			CreatedAt:          m.GetTime("registered_at"),
+			RegisteredAt:       m.GetString("registered_at"),
+			DelimitedInstances: instanceKey.StringCode(),
+		}
+		pools = append(pools, pool)
 		return nil
 	})
+	for _, pool := range pools {
+		result = append(result, *submissionsMap[pool])
+	}
 
 	return result, log.Errore(err)
 }
@@ -19,13 +19,32 @@ package inst
 import (
 	"sync"
 
+	"github.com/github/orchestrator/go/util"
+
 	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
 )
 
+var postponedFunctionSucceededCounter = metrics.NewCounter()
+var postponedFunctionFailedCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("postponed_functions.succeeded", postponedFunctionSucceededCounter)
+	metrics.Register("postponed_functions.failed", postponedFunctionFailedCounter)
+}
+
+// PostponedFunctionListener is notified as postponed functions are added and as they complete,
+// e.g. so as to persist them
+type PostponedFunctionListener interface {
+	PostponedFunctionAdded(uid string, description string)
+	PostponedFunctionCompleted(uid string, description string, err error)
+}
+
 type PostponedFunctionsContainer struct {
 	waitGroup    sync.WaitGroup
 	mutex        sync.Mutex
 	descriptions []string
+	listener     PostponedFunctionListener
 }
 
 func NewPostponedFunctionsContainer() *PostponedFunctionsContainer {
@@ -35,16 +54,37 @@ func NewPostponedFunctionsContainer() *PostponedFunctionsContainer {
 	return postponedFunctionsContainer
 }
 
-func (this *PostponedFunctionsContainer) AddPostponedFunction(postponedFunction func() error, description string) {
+// SetPostponedFunctionListener sets a listener to be notified of postponed functions added from now on
+func (this *PostponedFunctionsContainer) SetPostponedFunctionListener(listener PostponedFunctionListener) {
 	this.mutex.Lock()
 	defer this.mutex.Unlock()
 
+	this.listener = listener
+}
+
+func (this *PostponedFunctionsContainer) AddPostponedFunction(postponedFunction func() error, description string) {
+	this.mutex.Lock()
 	this.descriptions = append(this.descriptions, description)
+	listener := this.listener
+	this.mutex.Unlock()
 
+	uid := util.PrettyUniqueToken()
+	if listener != nil {
+		listener.PostponedFunctionAdded(uid, description)
+	}
 	this.waitGroup.Add(1)
 	go func() {
 		defer this.waitGroup.Done()
-		postponedFunction()
+		err := postponedFunction()
+		if err == nil {
+			postponedFunctionSucceededCounter.Inc(1)
+		} else {
+			postponedFunctionFailedCounter.Inc(1)
+			log.Errorf("PostponedFunctionsContainer: %s failed: %+v", description, err)
+		}
+		if listener != nil {
+			listener.PostponedFunctionCompleted(uid, description, err)
+		}
 	}()
 }
 
@@ -0,0 +1,45 @@
+package inst
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+type testPostponedFunctionListener struct {
+	mutex     sync.Mutex
+	added     map[string]string
+	completed map[string]error
+}
+
+func (this *testPostponedFunctionListener) PostponedFunctionAdded(uid string, description string) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.added[uid] = description
+}
+
+func (this *testPostponedFunctionListener) PostponedFunctionCompleted(uid string, description string, err error) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+	this.completed[description] = err
+}
+
+func TestPostponedFunctionListener(t *testing.T) {
+	listener := &testPostponedFunctionListener{added: map[string]string{}, completed: map[string]error{}}
+	container := NewPostponedFunctionsContainer()
+	container.AddPostponedFunction(func() error { return nil }, "before listener")
+	container.SetPostponedFunctionListener(listener)
+	container.AddPostponedFunction(func() error { return nil }, "succeeding")
+	container.AddPostponedFunction(func() error { return errors.New("failure") }, "failing")
+	container.Wait()
+
+	test.S(t).ExpectEquals(container.Len(), 3)
+	test.S(t).ExpectEquals(len(listener.added), 2)
+	test.S(t).ExpectEquals(len(listener.completed), 2)
+	test.S(t).ExpectNil(listener.completed["succeeding"])
+	test.S(t).ExpectNotNil(listener.completed["failing"])
+	_, found := listener.completed["before listener"]
+	test.S(t).ExpectFalse(found)
+}
@@ -0,0 +1,192 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/util"
+)
+
+// ProblemSeverity grades analysis entries, so that operators may look at what matters first
+type ProblemSeverity string
+
+const (
+	NoProblemSeverity       ProblemSeverity = ""
+	InfoProblemSeverity                     = "info"
+	WarningProblemSeverity                  = "warning"
+	CriticalProblemSeverity                 = "critical"
+)
+
+var problemSeverityRanks = map[ProblemSeverity]int{
+	NoProblemSeverity:       0,
+	InfoProblemSeverity:     1,
+	WarningProblemSeverity:  2,
+	CriticalProblemSeverity: 3,
+}
+
+// criticalAnalysisCodes are critical by default: the master, or writes to the cluster, are affected.
+// Other analysis codes are warnings, and structure analysis codes are informational, by default.
+var criticalAnalysisCodes = map[AnalysisCode]bool{
+	DeadMaster:                           true,
+	DeadMasterAndSlaves:                  true,
+	DeadMasterAndSomeSlaves:              true,
+	DeadMasterWithoutSlaves:              true,
+	UnreachableMasterWithLaggingReplicas: true,
+	DeadCoMaster:                         true,
+	DeadCoMasterAndSomeSlaves:            true,
+	MasterFlapping:                       true,
+	LockedSemiSyncMaster:                 true,
+	TooManyConnectionsMaster:             true,
+	ReadOnlyFilesystemMaster:             true,
+	DiskFullMaster:                       true,
+}
+
+// ParseProblemSeverity parses a severity name
+func ParseProblemSeverity(severity string) (ProblemSeverity, error) {
+	switch ProblemSeverity(severity) {
+	case InfoProblemSeverity, WarningProblemSeverity, CriticalProblemSeverity:
+		return ProblemSeverity(severity), nil
+	}
+	return NoProblemSeverity, fmt.Errorf("Unknown severity: %q; expecting critical, warning or info", severity)
+}
+
+// AtLeast checks whether this severity is the same as, or more severe than, given severity
+func (this ProblemSeverity) AtLeast(severity ProblemSeverity) bool {
+	return problemSeverityRanks[this] >= problemSeverityRanks[severity]
+}
+
+// GetAnalysisSeverity returns the severity of an analysis code, per AnalysisSeverities or else built-in
+func GetAnalysisSeverity(analysisCode AnalysisCode) ProblemSeverity {
+	if analysisCode == NoProblem {
+		return NoProblemSeverity
+	}
+	if severity, found := config.Config.AnalysisSeverities[string(analysisCode)]; found {
+		return ProblemSeverity(severity)
+	}
+	if criticalAnalysisCodes[analysisCode] {
+		return CriticalProblemSeverity
+	}
+	return WarningProblemSeverity
+}
+
+// GetStructureAnalysisSeverity returns the severity of a structure analysis code, per AnalysisSeverities or else built-in
+func GetStructureAnalysisSeverity(structureAnalysisCode StructureAnalysisCode) ProblemSeverity {
+	if severity, found := config.Config.AnalysisSeverities[string(structureAnalysisCode)]; found {
+		return ProblemSeverity(severity)
+	}
+	return InfoProblemSeverity
+}
+
+// GetReplicationAnalysisSeverity returns the highest severity of an entry's analysis and structure analysis
+func GetReplicationAnalysisSeverity(analysisEntry *ReplicationAnalysis) ProblemSeverity {
+	severity := GetAnalysisSeverity(analysisEntry.Analysis)
+	if analysisEntry.ActivitySuppressionReason != "" && severity != NoProblemSeverity {
+		// Explained by a running backup or DDL
+		severity = InfoProblemSeverity
+	}
+	for _, structureAnalysisCode := range analysisEntry.StructureAnalysis {
+		if structureSeverity := GetStructureAnalysisSeverity(structureAnalysisCode); !severity.AtLeast(structureSeverity) {
+			severity = structureSeverity
+		}
+	}
+	return severity
+}
+
+// ProblemSuppression hides matching problems from the problems API until it expires. It matches by any
+// combination of cluster, instance and analysis code; an empty criterion matches everything.
+type ProblemSuppression struct {
+	Id               string
+	ClusterPattern   string // regexp, matched against cluster name and alias
+	InstancePattern  string // regexp, matched against "host:port"
+	Analysis         string // analysis or structure analysis code
+	Owner            string
+	Reason           string
+	Duration         time.Duration
+	BeginTimestamp   string
+	ExpiresTimestamp string
+}
+
+// NewProblemSuppression returns an empty suppression with a new unique id
+func NewProblemSuppression() *ProblemSuppression {
+	return &ProblemSuppression{Id: util.PrettyUniqueToken()}
+}
+
+// Validate checks the suppression is well formed
+func (this *ProblemSuppression) Validate() error {
+	if this.ClusterPattern == "" && this.InstancePattern == "" && this.Analysis == "" {
+		return fmt.Errorf("Problem suppression must specify a cluster pattern, an instance pattern and/or an analysis")
+	}
+	for _, pattern := range []string{this.ClusterPattern, this.InstancePattern} {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("Invalid problem suppression pattern %q: %+v", pattern, err)
+		}
+	}
+	if this.Duration <= 0 {
+		return fmt.Errorf("Problem suppression duration must be positive")
+	}
+	if this.Owner == "" || this.Reason == "" {
+		return fmt.Errorf("Problem suppression requires owner and reason")
+	}
+	return nil
+}
+
+// matchesLocation checks the suppression's cluster and instance patterns
+func (this *ProblemSuppression) matchesLocation(instanceKey *InstanceKey, clusterName string, clusterAlias string) bool {
+	if this.ClusterPattern != "" {
+		matchedName, _ := regexp.MatchString(this.ClusterPattern, clusterName)
+		matchedAlias, _ := regexp.MatchString(this.ClusterPattern, clusterAlias)
+		if !matchedName && !(clusterAlias != "" && matchedAlias) {
+			return false
+		}
+	}
+	if this.InstancePattern != "" {
+		if matched, _ := regexp.MatchString(this.InstancePattern, instanceKey.StringCode()); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesAnalysis checks whether the suppression applies to an analysis entry. A suppression by analysis code
+// applies to an entry whose analysis, or any of its structure analysis, is that code.
+func (this *ProblemSuppression) MatchesAnalysis(analysisEntry *ReplicationAnalysis) bool {
+	if !this.matchesLocation(&analysisEntry.AnalyzedInstanceKey, analysisEntry.ClusterDetails.ClusterName, analysisEntry.ClusterDetails.ClusterAlias) {
+		return false
+	}
+	if this.Analysis == "" || this.Analysis == string(analysisEntry.Analysis) {
+		return true
+	}
+	for _, structureAnalysisCode := range analysisEntry.StructureAnalysis {
+		if this.Analysis == string(structureAnalysisCode) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesInstance checks whether the suppression applies to a problem instance. Instance problems have no
+// analysis code, and so suppressions by analysis code do not apply.
+func (this *ProblemSuppression) MatchesInstance(instance *Instance) bool {
+	if this.Analysis != "" {
+		return false
+	}
+	return this.matchesLocation(&instance.Key, instance.ClusterName, instance.SuggestedClusterAlias)
+}
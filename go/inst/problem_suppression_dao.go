@@ -0,0 +1,119 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteProblemSuppression creates or replaces a problem suppression, which expires after its duration
+func WriteProblemSuppression(suppression *ProblemSuppression) error {
+	if err := suppression.Validate(); err != nil {
+		return err
+	}
+	_, err := db.ExecOrchestrator(`
+			replace
+				into problem_suppression (
+					suppression_id, cluster_pattern, instance_pattern, analysis,
+					owner, reason, begin_timestamp, expires_timestamp
+				) values (
+					?, ?, ?, ?,
+					?, ?, NOW(), NOW() + INTERVAL ? SECOND
+				)
+			`,
+		suppression.Id, suppression.ClusterPattern, suppression.InstancePattern, suppression.Analysis,
+		suppression.Owner, suppression.Reason, int64(suppression.Duration.Seconds()),
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	AuditOperation("suppress-problems", nil, fmt.Sprintf("%s: cluster: %s, instance: %s, analysis: %s, duration: %+v, owner: %s, reason: %s",
+		suppression.Id, suppression.ClusterPattern, suppression.InstancePattern, suppression.Analysis, suppression.Duration, suppression.Owner, suppression.Reason))
+	return nil
+}
+
+// DeleteProblemSuppression removes a problem suppression
+func DeleteProblemSuppression(suppressionId string) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				problem_suppression
+			where
+				suppression_id = ?
+			`, suppressionId,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("Problem suppression %s not found", suppressionId)
+	}
+	AuditOperation("unsuppress-problems", nil, suppressionId)
+	return nil
+}
+
+// ReadActiveProblemSuppressions reads all unexpired problem suppressions
+func ReadActiveProblemSuppressions() (suppressions []ProblemSuppression, err error) {
+	suppressions = []ProblemSuppression{}
+	query := `
+		select
+			suppression_id, cluster_pattern, instance_pattern, analysis,
+			owner, reason, begin_timestamp, expires_timestamp
+		from
+			problem_suppression
+		where
+			expires_timestamp > NOW()
+		order by
+			begin_timestamp, suppression_id
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		suppression := ProblemSuppression{}
+		suppression.Id = m.GetString("suppression_id")
+		suppression.ClusterPattern = m.GetString("cluster_pattern")
+		suppression.InstancePattern = m.GetString("instance_pattern")
+		suppression.Analysis = m.GetString("analysis")
+		suppression.Owner = m.GetString("owner")
+		suppression.Reason = m.GetString("reason")
+		suppression.BeginTimestamp = m.GetString("begin_timestamp")
+		suppression.ExpiresTimestamp = m.GetString("expires_timestamp")
+
+		suppressions = append(suppressions, suppression)
+		return nil
+	})
+	return suppressions, log.Errore(err)
+}
+
+// ExpireProblemSuppressions removes expired problem suppressions
+func ExpireProblemSuppressions() error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				problem_suppression
+			where
+				expires_timestamp < NOW()
+			`,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if rowsAffected, _ := res.RowsAffected(); rowsAffected > 0 {
+		AuditOperation("expire-problem-suppression", nil, fmt.Sprintf("Expired %d entries", rowsAffected))
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestGetReplicationAnalysisSeverity(t *testing.T) {
+	test.S(t).ExpectEquals(GetAnalysisSeverity(NoProblem), NoProblemSeverity)
+	test.S(t).ExpectEquals(GetAnalysisSeverity(DeadMaster), ProblemSeverity(CriticalProblemSeverity))
+	test.S(t).ExpectEquals(GetAnalysisSeverity(DeadIntermediateMaster), ProblemSeverity(WarningProblemSeverity))
+
+	analysisEntry := &ReplicationAnalysis{Analysis: NoProblem, StructureAnalysis: []StructureAnalysisCode{NoFailoverSupportStructureWarning}}
+	test.S(t).ExpectEquals(GetReplicationAnalysisSeverity(analysisEntry), ProblemSeverity(InfoProblemSeverity))
+
+	explainedEntry := &ReplicationAnalysis{Analysis: AllMasterSlavesNotReplicating, ActivitySuppressionReason: "2 replicas running a backup or long-running DDL"}
+	test.S(t).ExpectEquals(GetReplicationAnalysisSeverity(explainedEntry), ProblemSeverity(InfoProblemSeverity))
+
+	config.Config.AnalysisSeverities = map[string]string{
+		string(DeadIntermediateMaster):            "info",
+		string(NoFailoverSupportStructureWarning): "critical",
+	}
+	defer func() { config.Config.AnalysisSeverities = map[string]string{} }()
+	test.S(t).ExpectEquals(GetAnalysisSeverity(DeadIntermediateMaster), ProblemSeverity(InfoProblemSeverity))
+	test.S(t).ExpectEquals(GetReplicationAnalysisSeverity(analysisEntry), ProblemSeverity(CriticalProblemSeverity))
+
+	test.S(t).ExpectTrue(ProblemSeverity(CriticalProblemSeverity).AtLeast(WarningProblemSeverity))
+	test.S(t).ExpectFalse(ProblemSeverity(InfoProblemSeverity).AtLeast(WarningProblemSeverity))
+	test.S(t).ExpectTrue(NoProblemSeverity.AtLeast(NoProblemSeverity))
+}
+
+func TestProblemSuppressionValidate(t *testing.T) {
+	{
+		suppression := &ProblemSuppression{ClusterPattern: "^staging-", Duration: time.Hour, Owner: "noc", Reason: "staging noise"}
+		test.S(t).ExpectNil(suppression.Validate())
+	}
+	{
+		suppression := &ProblemSuppression{Duration: time.Hour, Owner: "noc", Reason: "everything"}
+		test.S(t).ExpectNotNil(suppression.Validate())
+	}
+	{
+		suppression := &ProblemSuppression{InstancePattern: "db-(", Duration: time.Hour, Owner: "noc", Reason: "bad pattern"}
+		test.S(t).ExpectNotNil(suppression.Validate())
+	}
+	{
+		suppression := &ProblemSuppression{Analysis: string(DeadMaster), Owner: "noc", Reason: "no duration"}
+		test.S(t).ExpectNotNil(suppression.Validate())
+	}
+}
+
+func TestProblemSuppressionMatches(t *testing.T) {
+	analysisEntry := &ReplicationAnalysis{
+		AnalyzedInstanceKey: InstanceKey{Hostname: "db-7.staging", Port: 3306},
+		ClusterDetails:      ClusterInfo{ClusterName: "db-1.staging:3306", ClusterAlias: "payments-staging"},
+		Analysis:            UnreachableMaster,
+		StructureAnalysis:   []StructureAnalysisCode{NoFailoverSupportStructureWarning},
+	}
+	test.S(t).ExpectTrue((&ProblemSuppression{ClusterPattern: "staging$"}).MatchesAnalysis(analysisEntry))
+	test.S(t).ExpectTrue((&ProblemSuppression{ClusterPattern: "^payments-"}).MatchesAnalysis(analysisEntry))
+	test.S(t).ExpectFalse((&ProblemSuppression{ClusterPattern: "^ledger"}).MatchesAnalysis(analysisEntry))
+	test.S(t).ExpectTrue((&ProblemSuppression{InstancePattern: "^db-7[.]", Analysis: UnreachableMaster}).MatchesAnalysis(analysisEntry))
+	test.S(t).ExpectFalse((&ProblemSuppression{InstancePattern: "^db-7[.]", Analysis: DeadMaster}).MatchesAnalysis(analysisEntry))
+	test.S(t).ExpectTrue((&ProblemSuppression{Analysis: NoFailoverSupportStructureWarning}).MatchesAnalysis(analysisEntry))
+
+	instance := &Instance{Key: analysisEntry.AnalyzedInstanceKey, ClusterName: analysisEntry.ClusterDetails.ClusterName}
+	test.S(t).ExpectTrue((&ProblemSuppression{ClusterPattern: "staging"}).MatchesInstance(instance))
+	test.S(t).ExpectFalse((&ProblemSuppression{ClusterPattern: "staging", Analysis: UnreachableMaster}).MatchesInstance(instance))
+}
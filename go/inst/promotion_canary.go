@@ -0,0 +1,127 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// promotionCanaryTableName returns the escaped schema and schema qualified table of a canary
+func promotionCanaryTableName(canary *config.PromotionCanary) (schema string, table string) {
+	schemaName, tableName := canary.TableName()
+	return fmt.Sprintf("`%s`", schemaName), fmt.Sprintf("`%s`.`%s`", schemaName, tableName)
+}
+
+// promotionCanaryDSN bounds a canary DSN's dial and I/O timeouts by given timeout, unless the DSN sets its own
+func promotionCanaryDSN(dsn string, timeout time.Duration) (string, error) {
+	dsnConfig, err := mysql.ParseDSN(dsn)
+	if err != nil {
+		return "", err
+	}
+	if dsnConfig.Timeout == 0 || dsnConfig.Timeout > timeout {
+		dsnConfig.Timeout = timeout
+	}
+	if dsnConfig.ReadTimeout == 0 || dsnConfig.ReadTimeout > timeout {
+		dsnConfig.ReadTimeout = timeout
+	}
+	if dsnConfig.WriteTimeout == 0 || dsnConfig.WriteTimeout > timeout {
+		dsnConfig.WriteTimeout = timeout
+	}
+	return dsnConfig.FormatDSN(), nil
+}
+
+// WritePromotionCanary writes a canary, identified by given token, through the canary's DSN, creating its table
+// if missing. Returns the server_id of the server the write landed on. The write, from connecting onwards, is
+// bounded by the canary's timeout.
+func WritePromotionCanary(canary *config.PromotionCanary, dsn string, token string) (serverId uint, err error) {
+	if *config.RuntimeCLIFlags.Noop {
+		return 0, fmt.Errorf("noop: aborting promotion canary write; signalling error but nothing went wrong.")
+	}
+	timeout := time.Duration(canary.Timeout()) * time.Second
+	if dsn, err = promotionCanaryDSN(dsn, timeout); err != nil {
+		return 0, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	sqlDB, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer sqlDB.Close()
+	// The access path may balance connections; a single connection makes sure the canary is read where written
+	sqlDB.SetMaxOpenConns(1)
+
+	schema, table := promotionCanaryTableName(canary)
+	if _, err := sqlDB.ExecContext(ctx, fmt.Sprintf("create database if not exists %s", schema)); err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf(`
+		create table if not exists %s (
+			id tinyint unsigned not null,
+			token varchar(128) not null,
+			server_id int unsigned not null,
+			ts datetime(6) not null,
+			primary key (id)
+		) engine=InnoDB
+		`, table)
+	if _, err := sqlDB.ExecContext(ctx, query); err != nil {
+		return 0, err
+	}
+	query = fmt.Sprintf(`
+		replace into %s
+				(id, token, server_id, ts)
+			values
+				(1, ?, @@global.server_id, utc_timestamp(6))
+		`, table)
+	if _, err := sqlDB.ExecContext(ctx, query, token); err != nil {
+		return 0, err
+	}
+	err = sqlDB.QueryRowContext(ctx, fmt.Sprintf("select server_id from %s where token = ?", table), token).Scan(&serverId)
+	return serverId, err
+}
+
+// ReadPromotionCanaryToken reads the token of the canary last replicated to given instance. An instance the canary
+// table has yet to replicate to has no token. Reads are bounded by given context.
+func ReadPromotionCanaryToken(ctx context.Context, instanceKey *InstanceKey, canary *config.PromotionCanary) (token string, err error) {
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return "", err
+	}
+	_, table := promotionCanaryTableName(canary)
+	var schemaTables int
+	schemaName, tableName := canary.TableName()
+	if err := sqlDB.QueryRowContext(ctx, "select count(*) from information_schema.tables where table_schema = ? and table_name = ?", schemaName, tableName).Scan(&schemaTables); err != nil {
+		return "", err
+	}
+	if schemaTables == 0 {
+		return "", nil
+	}
+	err = sqlDB.QueryRowContext(ctx, fmt.Sprintf("select token from %s where id = 1", table)).Scan(&token)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return token, err
+}
@@ -48,10 +48,8 @@ func (this *CandidatePromotionRule) SmallerThan(other CandidatePromotionRule) bo
 // It returns an error if there is no known rule by the given name.
 func ParseCandidatePromotionRule(ruleName string) (CandidatePromotionRule, error) {
 	switch ruleName {
-	case "prefer", "neutral", "prefer_not", "must_not":
+	case "must", "prefer", "neutral", "prefer_not", "must_not":
 		return CandidatePromotionRule(ruleName), nil
-	case "must":
-		return CandidatePromotionRule(""), fmt.Errorf("CandidatePromotionRule: %v not supported yet", ruleName)
 	default:
 		return CandidatePromotionRule(""), fmt.Errorf("Invalid CandidatePromotionRule: %v", ruleName)
 	}
@@ -0,0 +1,23 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestParseCandidatePromotionRule(t *testing.T) {
+	for _, ruleName := range []string{"must", "prefer", "neutral", "prefer_not", "must_not"} {
+		promotionRule, err := ParseCandidatePromotionRule(ruleName)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(string(promotionRule), ruleName)
+	}
+	{
+		_, err := ParseCandidatePromotionRule("")
+		test.S(t).ExpectNotNil(err)
+	}
+	{
+		_, err := ParseCandidatePromotionRule("always")
+		test.S(t).ExpectNotNil(err)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// ReadPoolMember is a replica in its cluster's read pool
+type ReadPoolMember struct {
+	Key        InstanceKey
+	LagSeconds int64
+	Capacity   uint
+	Weight     uint
+}
+
+// ReadPool is a cluster's healthy, low-lag replicas, weighted for load balancing
+type ReadPool struct {
+	ClusterName  string
+	ClusterAlias string
+	Members      []ReadPoolMember
+}
+
+// isReadPoolEligible checks whether an instance may serve reads in its cluster's read pool
+func isReadPoolEligible(instance *Instance) bool {
+	if !instance.IsReplica() || instance.IsBinlogServer() {
+		return false
+	}
+	if !instance.IsLastCheckValid || !instance.IsRecentlyChecked {
+		return false
+	}
+	if !instance.ReplicaRunning() || instance.IsDowntimed {
+		return false
+	}
+	if !instance.SlaveLagSeconds.Valid || instance.SlaveLagSeconds.Int64 > int64(config.Config.ReadPoolMaxLagSeconds) {
+		return false
+	}
+	return true
+}
+
+// NewReadPool composes a cluster's read pool out of its instances. capacities maps instances to their relative read
+// capacity; unlisted instances have a capacity of 1. A member's weight is its capacity times the number of seconds
+// it is ahead of ReadPoolMaxLagSeconds, plus one: the least lagging, most capable replicas get the most reads.
+func NewReadPool(clusterInfo *ClusterInfo, instances [](*Instance), capacities map[InstanceKey]uint) *ReadPool {
+	pool := &ReadPool{
+		ClusterName:  clusterInfo.ClusterName,
+		ClusterAlias: clusterInfo.ClusterAlias,
+		Members:      []ReadPoolMember{},
+	}
+	for _, instance := range instances {
+		if !isReadPoolEligible(instance) {
+			continue
+		}
+		capacity, found := capacities[instance.Key]
+		if !found {
+			capacity = 1
+		}
+		if capacity == 0 {
+			continue
+		}
+		lag := instance.SlaveLagSeconds.Int64
+		if lag < 0 {
+			lag = 0
+		}
+		member := ReadPoolMember{
+			Key:        instance.Key,
+			LagSeconds: lag,
+			Capacity:   capacity,
+			Weight:     capacity * uint(int64(config.Config.ReadPoolMaxLagSeconds)-lag+1),
+		}
+		pool.Members = append(pool.Members, member)
+	}
+	sort.Slice(pool.Members, func(i, j int) bool {
+		return pool.Members[i].Key.SmallerThan(&pool.Members[j].Key)
+	})
+	return pool
+}
+
+// MembersJSON returns the pool's members as a JSON array
+func (this *ReadPool) MembersJSON() string {
+	b, _ := json.Marshal(this.Members)
+	return string(b)
+}
+
+// ReadReadPool composes the current read pool of given cluster
+func ReadReadPool(clusterInfo *ClusterInfo) (*ReadPool, error) {
+	instances, err := ReadClusterInstances(clusterInfo.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+	capacities := make(map[InstanceKey]uint)
+	if config.Config.ReadPoolCapacityTag != "" {
+		tagValues, err := ReadInstanceTagValues(config.Config.ReadPoolCapacityTag)
+		if err != nil {
+			return nil, err
+		}
+		for instanceKey, tagValue := range tagValues {
+			if capacity, err := strconv.ParseUint(tagValue, 10, 32); err == nil {
+				capacities[instanceKey] = uint(capacity)
+			}
+		}
+	}
+	return NewReadPool(clusterInfo, instances, capacities), nil
+}
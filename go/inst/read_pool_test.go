@@ -0,0 +1,62 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func newReadPoolTestReplica(hostname string, lag int64) *Instance {
+	instance := NewInstance()
+	instance.Key = InstanceKey{Hostname: hostname, Port: 3306}
+	instance.MasterKey = InstanceKey{Hostname: "db-master", Port: 3306}
+	instance.ReadBinlogCoordinates = BinlogCoordinates{LogFile: "mysql-bin.000017", LogPos: 4}
+	instance.IsLastCheckValid = true
+	instance.IsRecentlyChecked = true
+	instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+	instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	instance.SlaveLagSeconds = sql.NullInt64{Int64: lag, Valid: true}
+	return instance
+}
+
+func TestNewReadPool(t *testing.T) {
+	config.Config.ReadPoolMaxLagSeconds = 10
+
+	master := NewInstance()
+	master.Key = InstanceKey{Hostname: "db-master", Port: 3306}
+	master.IsLastCheckValid = true
+	lagging := newReadPoolTestReplica("db-lagging", 30)
+	downtimed := newReadPoolTestReplica("db-downtimed", 0)
+	downtimed.IsDowntimed = true
+	drained := newReadPoolTestReplica("db-drained", 0)
+	big := newReadPoolTestReplica("db-big", 2)
+	small := newReadPoolTestReplica("db-small", 0)
+	instances := [](*Instance){master, small, lagging, downtimed, drained, big}
+	capacities := map[InstanceKey]uint{big.Key: 3, drained.Key: 0}
+
+	pool := NewReadPool(&ClusterInfo{ClusterName: "db-master:3306", ClusterAlias: "main"}, instances, capacities)
+	test.S(t).ExpectEquals(pool.ClusterAlias, "main")
+	test.S(t).ExpectEquals(len(pool.Members), 2)
+	test.S(t).ExpectEquals(pool.Members[0].Key.Hostname, "db-big")
+	test.S(t).ExpectEquals(pool.Members[0].Weight, uint(27))
+	test.S(t).ExpectEquals(pool.Members[1].Key.Hostname, "db-small")
+	test.S(t).ExpectEquals(pool.Members[1].Weight, uint(11))
+}
@@ -0,0 +1,102 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// How often a replica catching up on coordinates reports progress
+const replicationCatchupProgressInterval = 5 * time.Second
+
+// ReplicationCatchupProgress describes how far a replica is from executing up to given coordinates
+type ReplicationCatchupProgress struct {
+	InstanceKey           InstanceKey
+	TargetCoordinates     BinlogCoordinates
+	ExecCoordinates       BinlogCoordinates
+	BytesRemaining        int64 // -1 when the replica executes a different binary log than the target's
+	TransactionsRemaining int64 // -1 when unknown, e.g. without GTID
+	ElapsedSeconds        float64
+	BytesPerSecond        float64 // rate of applying binary log events; -1 when cannot be estimated
+	ETASeconds            float64 // -1 when cannot be estimated
+}
+
+// newReplicationCatchupProgress computes progress of a replica towards target coordinates. The initial progress,
+// if given, is the baseline by which catch up rate and ETA are estimated.
+func newReplicationCatchupProgress(instance *Instance, targetCoordinates *BinlogCoordinates, transactionsRemaining int64, initial *ReplicationCatchupProgress, elapsed time.Duration) *ReplicationCatchupProgress {
+	progress := &ReplicationCatchupProgress{
+		InstanceKey:           instance.Key,
+		TargetCoordinates:     *targetCoordinates,
+		ExecCoordinates:       instance.ExecBinlogCoordinates,
+		BytesRemaining:        -1,
+		TransactionsRemaining: transactionsRemaining,
+		ElapsedSeconds:        elapsed.Seconds(),
+		BytesPerSecond:        -1,
+		ETASeconds:            -1,
+	}
+	if instance.ExecBinlogCoordinates.LogFile == targetCoordinates.LogFile {
+		progress.BytesRemaining = targetCoordinates.LogPos - instance.ExecBinlogCoordinates.LogPos
+		if progress.BytesRemaining < 0 {
+			progress.BytesRemaining = 0
+		}
+	}
+	if initial == nil || progress.ElapsedSeconds <= initial.ElapsedSeconds {
+		return progress
+	}
+	rateSeconds := progress.ElapsedSeconds - initial.ElapsedSeconds
+	if initial.BytesRemaining >= 0 && progress.BytesRemaining >= 0 && progress.BytesRemaining <= initial.BytesRemaining {
+		progress.BytesPerSecond = float64(initial.BytesRemaining-progress.BytesRemaining) / rateSeconds
+	}
+	estimate := func(initialRemaining, remaining int64) float64 {
+		if initialRemaining < 0 || remaining < 0 || remaining >= initialRemaining {
+			return -1
+		}
+		ratePerSecond := float64(initialRemaining-remaining) / rateSeconds
+		return float64(remaining) / ratePerSecond
+	}
+	if eta := estimate(initial.TransactionsRemaining, progress.TransactionsRemaining); eta >= 0 {
+		progress.ETASeconds = eta
+	} else if eta := estimate(initial.BytesRemaining, progress.BytesRemaining); eta >= 0 {
+		progress.ETASeconds = eta
+	}
+	return progress
+}
+
+// String returns a user-friendly description of the progress
+func (this *ReplicationCatchupProgress) String() string {
+	tokens := []string{
+		fmt.Sprintf("%+v at %+v, target %+v", this.InstanceKey, this.ExecCoordinates.DisplayString(), this.TargetCoordinates.DisplayString()),
+	}
+	if this.BytesRemaining >= 0 {
+		tokens = append(tokens, fmt.Sprintf("%d bytes remaining", this.BytesRemaining))
+	}
+	if this.TransactionsRemaining >= 0 {
+		tokens = append(tokens, fmt.Sprintf("%d transactions remaining", this.TransactionsRemaining))
+	}
+	if this.BytesPerSecond >= 0 {
+		tokens = append(tokens, fmt.Sprintf("%.0f bytes/s", this.BytesPerSecond))
+	}
+	tokens = append(tokens, fmt.Sprintf("elapsed %.1fs", this.ElapsedSeconds))
+	if this.ETASeconds >= 0 {
+		tokens = append(tokens, fmt.Sprintf("ETA %.1fs", this.ETASeconds))
+	} else {
+		tokens = append(tokens, "ETA unknown")
+	}
+	return strings.Join(tokens, ", ")
+}
@@ -0,0 +1,39 @@
+package inst
+
+import (
+	"testing"
+	"time"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewReplicationCatchupProgress(t *testing.T) {
+	target := BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 5000}
+	instance := &Instance{Key: key1, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 1000}}
+	initial := newReplicationCatchupProgress(instance, &target, -1, nil, time.Second)
+	test.S(t).ExpectEquals(initial.BytesRemaining, int64(4000))
+	test.S(t).ExpectEquals(initial.ETASeconds, float64(-1))
+	test.S(t).ExpectEquals(initial.BytesPerSecond, float64(-1))
+
+	instance.ExecBinlogCoordinates.LogPos = 3000
+	progress := newReplicationCatchupProgress(instance, &target, -1, initial, 3*time.Second)
+	test.S(t).ExpectEquals(progress.BytesRemaining, int64(2000))
+	test.S(t).ExpectEquals(progress.BytesPerSecond, float64(1000))
+	test.S(t).ExpectEquals(progress.ETASeconds, float64(2))
+
+	// No progress made: cannot estimate
+	progress = newReplicationCatchupProgress(instance, &target, -1, progress, 4*time.Second)
+	test.S(t).ExpectEquals(progress.BytesPerSecond, float64(0))
+	test.S(t).ExpectEquals(progress.ETASeconds, float64(-1))
+}
+
+func TestNewReplicationCatchupProgressTransactions(t *testing.T) {
+	target := BinlogCoordinates{LogFile: "mysql-bin.000012", LogPos: 5000}
+	instance := &Instance{Key: key1, ExecBinlogCoordinates: BinlogCoordinates{LogFile: "mysql-bin.000011", LogPos: 1000}}
+	initial := newReplicationCatchupProgress(instance, &target, 100, nil, 0)
+	test.S(t).ExpectEquals(initial.BytesRemaining, int64(-1))
+
+	progress := newReplicationCatchupProgress(instance, &target, 40, initial, 6*time.Second)
+	test.S(t).ExpectEquals(progress.TransactionsRemaining, int64(40))
+	test.S(t).ExpectEquals(progress.ETASeconds, float64(4))
+}
@@ -0,0 +1,109 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+)
+
+// ReplicationThreadStateFromServiceState converts a performance_schema SERVICE_STATE into a thread state
+func ReplicationThreadStateFromServiceState(serviceState string) ReplicationThreadState {
+	switch serviceState {
+	case "OFF":
+		return ReplicationThreadStateStopped
+	case "ON":
+		return ReplicationThreadStateRunning
+	}
+	return ReplicationThreadStateOther
+}
+
+// ApplierWorker is a replication applier worker, as seen in performance_schema. Without parallel
+// replication, a channel has a single worker: the SQL thread.
+type ApplierWorker struct {
+	Id              uint
+	ThreadState     ReplicationThreadState
+	LagMilliseconds int64 // since the transaction being applied committed on its originating master; 0 when idle
+	LastErrno       int
+	LastError       string
+}
+
+// ReplicationChannel is a replication channel, as seen in performance_schema
+type ReplicationChannel struct {
+	Name           string // empty for the default channel
+	SourceUUID     string
+	IOThreadState  ReplicationThreadState
+	SQLThreadState ReplicationThreadState
+	LastIOErrno    int
+	LastIOError    string
+	LastSQLErrno   int    // error of the first failing worker, which SHOW SLAVE STATUS only reports as a coordinator error
+	LastSQLError   string // ditto
+	ApplierWorkers []ApplierWorker
+}
+
+// MaxWorkerLagMilliseconds returns the lag of this channel's most lagging applier worker
+func (this *ReplicationChannel) MaxWorkerLagMilliseconds() (lag int64) {
+	for _, worker := range this.ApplierWorkers {
+		if worker.LagMilliseconds > lag {
+			lag = worker.LagMilliseconds
+		}
+	}
+	return lag
+}
+
+// ReplicationChannels are the replication channels of an instance
+type ReplicationChannels []ReplicationChannel
+
+// DefaultChannel returns the default, unnamed, channel, or nil if there's none
+func (this ReplicationChannels) DefaultChannel() *ReplicationChannel {
+	for i := range this {
+		if this[i].Name == "" {
+			return &this[i]
+		}
+	}
+	return nil
+}
+
+// NamedChannelsFrom returns the names of named, i.e. non default, channels replicating from given source server UUID
+func (this ReplicationChannels) NamedChannelsFrom(sourceUUID string) (names []string) {
+	if sourceUUID == "" {
+		return names
+	}
+	for _, channel := range this {
+		if channel.Name != "" && channel.SourceUUID == sourceUUID {
+			names = append(names, channel.Name)
+		}
+	}
+	return names
+}
+
+// ToJSONString returns the channels as a JSON array
+func (this ReplicationChannels) ToJSONString() string {
+	if len(this) == 0 {
+		return "[]"
+	}
+	bytes, _ := json.Marshal(this)
+	return string(bytes)
+}
+
+// ReadJson reads channels from a JSON array. An empty string reads as no channels.
+func (this *ReplicationChannels) ReadJson(jsonString string) error {
+	if jsonString == "" {
+		*this = ReplicationChannels{}
+		return nil
+	}
+	return json.Unmarshal([]byte(jsonString), this)
+}
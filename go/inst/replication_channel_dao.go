@@ -0,0 +1,105 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReadReplicationChannels reads the replication channels of a MySQL 8.0 instance, and their applier workers,
+// from performance_schema replication tables
+func ReadReplicationChannels(db *sql.DB) (channels ReplicationChannels, err error) {
+	channels = ReplicationChannels{}
+	channelIndexes := make(map[string]int)
+
+	err = sqlutils.QueryRowsMap(db, `
+		select
+			connection_status.channel_name,
+			connection_status.source_uuid,
+			connection_status.service_state as io_service_state,
+			connection_status.last_error_number as io_errno,
+			connection_status.last_error_message as io_error,
+			applier_status.service_state as sql_service_state
+		from
+			performance_schema.replication_connection_status as connection_status
+			join performance_schema.replication_applier_status as applier_status using (channel_name)
+		order by
+			connection_status.channel_name
+		`,
+		func(m sqlutils.RowMap) error {
+			channel := ReplicationChannel{
+				Name:           m.GetString("channel_name"),
+				SourceUUID:     m.GetString("source_uuid"),
+				IOThreadState:  ReplicationThreadStateFromServiceState(m.GetString("io_service_state")),
+				SQLThreadState: ReplicationThreadStateFromServiceState(m.GetString("sql_service_state")),
+				LastIOErrno:    m.GetInt("io_errno"),
+				LastIOError:    m.GetString("io_error"),
+				ApplierWorkers: []ApplierWorker{},
+			}
+			channelIndexes[channel.Name] = len(channels)
+			channels = append(channels, channel)
+			return nil
+		})
+	if err != nil {
+		return channels, err
+	}
+
+	// Zero timestamp stands for "no original commit timestamp", e.g. a transaction from a pre 8.0 master
+	err = sqlutils.QueryRowsMap(db, `
+		select
+			channel_name,
+			worker_id,
+			service_state,
+			last_error_number,
+			last_error_message,
+			if(
+				applying_transaction != '' and applying_transaction_original_commit_timestamp > '1970-01-02',
+				timestampdiff(microsecond, applying_transaction_original_commit_timestamp, now(6)) div 1000,
+				0
+			) as lag_milliseconds
+		from
+			performance_schema.replication_applier_status_by_worker
+		order by
+			channel_name, worker_id
+		`,
+		func(m sqlutils.RowMap) error {
+			i, found := channelIndexes[m.GetString("channel_name")]
+			if !found {
+				return nil
+			}
+			worker := ApplierWorker{
+				Id:              m.GetUint("worker_id"),
+				ThreadState:     ReplicationThreadStateFromServiceState(m.GetString("service_state")),
+				LagMilliseconds: m.GetInt64("lag_milliseconds"),
+				LastErrno:       m.GetInt("last_error_number"),
+				LastError:       m.GetString("last_error_message"),
+			}
+			if worker.LagMilliseconds < 0 {
+				// Clock skew between master and replica
+				worker.LagMilliseconds = 0
+			}
+			if worker.LastErrno != 0 && channels[i].LastSQLErrno == 0 {
+				channels[i].LastSQLErrno = worker.LastErrno
+				channels[i].LastSQLError = worker.LastError
+			}
+			channels[i].ApplierWorkers = append(channels[i].ApplierWorkers, worker)
+			return nil
+		})
+	return channels, err
+}
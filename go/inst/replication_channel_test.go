@@ -0,0 +1,57 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestReplicationThreadStateFromServiceState(t *testing.T) {
+	test.S(t).ExpectEquals(ReplicationThreadStateFromServiceState("ON"), ReplicationThreadState(ReplicationThreadStateRunning))
+	test.S(t).ExpectEquals(ReplicationThreadStateFromServiceState("OFF"), ReplicationThreadState(ReplicationThreadStateStopped))
+	test.S(t).ExpectEquals(ReplicationThreadStateFromServiceState("CONNECTING"), ReplicationThreadState(ReplicationThreadStateOther))
+}
+
+func TestReplicationChannels(t *testing.T) {
+	channels := ReplicationChannels{
+		{Name: "source-2", SQLThreadState: ReplicationThreadStateRunning},
+		{
+			Name:           "",
+			SQLThreadState: ReplicationThreadStateRunning,
+			ApplierWorkers: []ApplierWorker{
+				{Id: 1, ThreadState: ReplicationThreadStateRunning, LagMilliseconds: 1200},
+				{Id: 2, ThreadState: ReplicationThreadStateRunning, LagMilliseconds: 3400},
+				{Id: 3, ThreadState: ReplicationThreadStateRunning},
+			},
+		},
+	}
+	channel := channels.DefaultChannel()
+	test.S(t).ExpectNotNil(channel)
+	test.S(t).ExpectEquals(len(channel.ApplierWorkers), 3)
+	test.S(t).ExpectEquals(channel.MaxWorkerLagMilliseconds(), int64(3400))
+	test.S(t).ExpectEquals(channels[0].MaxWorkerLagMilliseconds(), int64(0))
+	test.S(t).ExpectTrue(channels[:1].DefaultChannel() == nil)
+
+	var read ReplicationChannels
+	err := read.ReadJson(channels.ToJSONString())
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(read), 2)
+	test.S(t).ExpectEquals(read.DefaultChannel().MaxWorkerLagMilliseconds(), int64(3400))
+
+	test.S(t).ExpectEquals(ReplicationChannels{}.ToJSONString(), "[]")
+	err = read.ReadJson("")
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(len(read), 0)
+}
+
+func TestNamedChannelsFrom(t *testing.T) {
+	channels := ReplicationChannels{
+		{Name: "", SourceUUID: "00020192-1111-1111-1111-111111111111"},
+		{Name: "upstream", SourceUUID: "00020193-2222-2222-2222-222222222222"},
+		{Name: "archive", SourceUUID: "00020193-2222-2222-2222-222222222222"},
+	}
+	test.S(t).ExpectEquals(len(channels.NamedChannelsFrom("00020192-1111-1111-1111-111111111111")), 0)
+	test.S(t).ExpectEquals(len(channels.NamedChannelsFrom("00020193-2222-2222-2222-222222222222")), 2)
+	test.S(t).ExpectEquals(channels.NamedChannelsFrom("00020193-2222-2222-2222-222222222222")[0], "upstream")
+	test.S(t).ExpectEquals(len(channels.NamedChannelsFrom("")), 0)
+}
@@ -0,0 +1,81 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+// ReplicationIncompatibility is the reason a replica cannot replicate from a candidate
+type ReplicationIncompatibility struct {
+	ReplicaKey InstanceKey
+	Reason     string
+}
+
+// CandidateCompatibility lists the sibling replicas which would be stranded, were a candidate promoted over them:
+// those which cannot replicate from the candidate (binlog format, replication filters, version, server id collisions),
+// and those which are ahead of the candidate.
+type CandidateCompatibility struct {
+	CandidateKey    InstanceKey
+	CannotReplicate []ReplicationIncompatibility
+	Ahead           []InstanceKey
+}
+
+// NewCandidateCompatibility computes which of given replicas would be stranded by promoting given candidate
+func NewCandidateCompatibility(candidate *Instance, replicas [](*Instance)) *CandidateCompatibility {
+	compatibility := &CandidateCompatibility{
+		CandidateKey:    candidate.Key,
+		CannotReplicate: []ReplicationIncompatibility{},
+		Ahead:           []InstanceKey{},
+	}
+	for _, replica := range replicas {
+		if replica.Key.Equals(&candidate.Key) {
+			continue
+		}
+		if canReplicate, err := replica.CanReplicateFrom(candidate); !canReplicate {
+			reason := ""
+			if err != nil {
+				reason = err.Error()
+			}
+			compatibility.CannotReplicate = append(compatibility.CannotReplicate, ReplicationIncompatibility{ReplicaKey: replica.Key, Reason: reason})
+		} else if candidate.ExecBinlogCoordinates.SmallerThan(&replica.ExecBinlogCoordinates) {
+			compatibility.Ahead = append(compatibility.Ahead, replica.Key)
+		}
+	}
+	return compatibility
+}
+
+// StrandedCount returns the number of replicas which would be stranded by promoting the candidate
+func (this *CandidateCompatibility) StrandedCount() int {
+	return len(this.CannotReplicate) + len(this.Ahead)
+}
+
+// NewReplicationCompatibilityMatrix computes, for each of given replicas as a candidate, which of its siblings
+// it would strand.
+func NewReplicationCompatibilityMatrix(replicas [](*Instance)) []CandidateCompatibility {
+	matrix := []CandidateCompatibility{}
+	for _, candidate := range replicas {
+		matrix = append(matrix, *NewCandidateCompatibility(candidate, replicas))
+	}
+	return matrix
+}
+
+// ReadReplicationCompatibilityMatrix computes the compatibility matrix of the replicas of given master
+func ReadReplicationCompatibilityMatrix(masterKey *InstanceKey) ([]CandidateCompatibility, error) {
+	replicas, err := getReplicasForSorting(masterKey, false)
+	if err != nil {
+		return nil, err
+	}
+	replicas = sortedReplicas(replicas, NoStopReplication)
+	return NewReplicationCompatibilityMatrix(replicas), nil
+}
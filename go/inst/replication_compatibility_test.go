@@ -0,0 +1,36 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestNewReplicationCompatibilityMatrix(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.Binlog_format = "ROW"
+	}
+	instancesMap[i810Key.StringCode()].Binlog_format = "STATEMENT"
+	instances = sortedReplicas(instances, NoStopReplication)
+
+	matrix := NewReplicationCompatibilityMatrix(instances)
+	test.S(t).ExpectEquals(len(matrix), len(instances))
+	{
+		compatibility := matrix[0]
+		// i810 logs STATEMENT, and cannot replicate from a ROW candidate
+		test.S(t).ExpectEquals(compatibility.CandidateKey, i830Key)
+		test.S(t).ExpectEquals(len(compatibility.Ahead), 0)
+		test.S(t).ExpectEquals(len(compatibility.CannotReplicate), 1)
+		test.S(t).ExpectEquals(compatibility.CannotReplicate[0].ReplicaKey, i810Key)
+		test.S(t).ExpectEquals(compatibility.StrandedCount(), 1)
+	}
+	{
+		compatibility := matrix[2]
+		test.S(t).ExpectEquals(compatibility.CandidateKey, i810Key)
+		test.S(t).ExpectEquals(len(compatibility.Ahead), 2)
+		test.S(t).ExpectEquals(len(compatibility.CannotReplicate), 0)
+		test.S(t).ExpectEquals(compatibility.StrandedCount(), 2)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/patrickmn/go-cache"
+)
+
+// reportedAddressesCache holds the reported address mappings, so as not to read them off the backend on each discovery
+var reportedAddressesCache = cache.New(time.Minute, time.Minute)
+
+const reportedAddressesCacheKey = "reported-addresses"
+
+// ReportedAddress maps the address orchestrator connects to an instance by, onto the address the instance is known
+// by in its topology: the address its replicas report via SHOW SLAVE HOSTS and replicate from. The two differ
+// behind NAT, port forwarding, or when orchestrator reaches a host by a public IP while replication uses a private one.
+type ReportedAddress struct {
+	Key         InstanceKey // connect address
+	ReportedKey InstanceKey // address as reported within the topology
+}
+
+// reportedAddressMaps indexes reported address mappings both ways
+type reportedAddressMaps struct {
+	byKey         map[InstanceKey]InstanceKey
+	byReportedKey map[InstanceKey]InstanceKey
+}
+
+// Validate checks that both addresses of a mapping are valid, and differ
+func (this *ReportedAddress) Validate() error {
+	if !this.Key.IsValid() {
+		return fmt.Errorf("Invalid connect address: %+v", this.Key)
+	}
+	if !this.ReportedKey.IsValid() {
+		return fmt.Errorf("Invalid reported address: %+v", this.ReportedKey)
+	}
+	if this.Key.Equals(&this.ReportedKey) {
+		return fmt.Errorf("Reported address of %+v is the same as its connect address", this.Key)
+	}
+	return nil
+}
+
+// readReportedAddressMaps returns the reported address mappings, cached
+func readReportedAddressMaps() *reportedAddressMaps {
+	if maps, found := reportedAddressesCache.Get(reportedAddressesCacheKey); found {
+		return maps.(*reportedAddressMaps)
+	}
+	maps := &reportedAddressMaps{
+		byKey:         make(map[InstanceKey]InstanceKey),
+		byReportedKey: make(map[InstanceKey]InstanceKey),
+	}
+	addresses, err := ReadReportedAddresses()
+	if err != nil {
+		return maps
+	}
+	for _, address := range addresses {
+		maps.byKey[address.Key] = address.ReportedKey
+		maps.byReportedKey[address.ReportedKey] = address.Key
+	}
+	reportedAddressesCache.Set(reportedAddressesCacheKey, maps, cache.DefaultExpiration)
+	return maps
+}
+
+// connectKeyOf returns the connect address of an address as reported within a topology, e.g. a master's address as
+// reported by SHOW SLAVE STATUS. Addresses with no mapping are returned as is.
+func connectKeyOf(reportedKey *InstanceKey) *InstanceKey {
+	if key, found := readReportedAddressMaps().byReportedKey[*reportedKey]; found {
+		return &key
+	}
+	return reportedKey
+}
+
+// reportedKeyOf returns the address an instance is known by within its topology, if it differs from its connect
+// address. Replicas are pointed at this address.
+func reportedKeyOf(instanceKey *InstanceKey) (*InstanceKey, bool) {
+	if reportedKey, found := readReportedAddressMaps().byKey[*instanceKey]; found {
+		return &reportedKey, true
+	}
+	return instanceKey, false
+}
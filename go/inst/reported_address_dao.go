@@ -0,0 +1,112 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// WriteReportedAddress registers the address an instance is known by within its topology. A reported address
+// registered for another instance is taken over. Both are done in a single transaction: on PostgreSQL, "replace"
+// only resolves conflicts on the instance's own key, and so the other instance's row is explicitly deleted.
+func WriteReportedAddress(address *ReportedAddress) error {
+	if err := address.Validate(); err != nil {
+		return err
+	}
+	dbh, err := db.OpenOrchestrator()
+	if err != nil {
+		return log.Errore(err)
+	}
+	tx, err := dbh.Begin()
+	if err != nil {
+		return log.Errore(err)
+	}
+	if _, err := db.ExecOrchestratorTx(tx, `
+			delete from
+				instance_reported_address
+			where
+				reported_hostname = ?
+				and reported_port = ?
+			`, address.ReportedKey.Hostname, address.ReportedKey.Port,
+	); err != nil {
+		tx.Rollback()
+		return log.Errore(err)
+	}
+	if _, err := db.ExecOrchestratorTx(tx, `
+			replace
+				into instance_reported_address (
+					hostname, port, reported_hostname, reported_port, last_updated
+				) values (
+					?, ?, ?, ?, NOW()
+				)
+			`, address.Key.Hostname, address.Key.Port, address.ReportedKey.Hostname, address.ReportedKey.Port,
+	); err != nil {
+		tx.Rollback()
+		return log.Errore(err)
+	}
+	if err := tx.Commit(); err != nil {
+		return log.Errore(err)
+	}
+	reportedAddressesCache.Flush()
+	AuditOperation("write-reported-address", &address.Key, fmt.Sprintf("reported as %+v", address.ReportedKey))
+	return nil
+}
+
+// DeleteReportedAddress removes the reported address of an instance
+func DeleteReportedAddress(instanceKey *InstanceKey) error {
+	res, err := db.ExecOrchestrator(`
+			delete from
+				instance_reported_address
+			where
+				hostname = ?
+				and port = ?
+			`, instanceKey.Hostname, instanceKey.Port,
+	)
+	if err != nil {
+		return log.Errore(err)
+	}
+	if affected, _ := res.RowsAffected(); affected == 0 {
+		return fmt.Errorf("No reported address registered for %+v", *instanceKey)
+	}
+	reportedAddressesCache.Flush()
+	AuditOperation("delete-reported-address", instanceKey, "")
+	return nil
+}
+
+// ReadReportedAddresses reads all reported address mappings
+func ReadReportedAddresses() (addresses []ReportedAddress, err error) {
+	query := `
+		select
+			hostname, port, reported_hostname, reported_port
+		from
+			instance_reported_address
+		order by
+			hostname, port
+		`
+	err = db.QueryOrchestratorRowsMap(query, func(m sqlutils.RowMap) error {
+		addresses = append(addresses, ReportedAddress{
+			Key:         InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			ReportedKey: InstanceKey{Hostname: m.GetString("reported_hostname"), Port: m.GetInt("reported_port")},
+		})
+		return nil
+	})
+	return addresses, log.Errore(err)
+}
@@ -0,0 +1,65 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	"github.com/patrickmn/go-cache"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestReportedAddressValidate(t *testing.T) {
+	{
+		address := &ReportedAddress{Key: InstanceKey{Hostname: "db1.public", Port: 13306}, ReportedKey: InstanceKey{Hostname: "10.0.0.11", Port: 3306}}
+		test.S(t).ExpectNil(address.Validate())
+	}
+	{
+		address := &ReportedAddress{Key: InstanceKey{Hostname: "db1.public", Port: 13306}, ReportedKey: InstanceKey{Hostname: "db1.public", Port: 13306}}
+		test.S(t).ExpectNotNil(address.Validate())
+	}
+	{
+		address := &ReportedAddress{Key: InstanceKey{Hostname: "db1.public", Port: 13306}, ReportedKey: InstanceKey{Hostname: "10.0.0.11"}}
+		test.S(t).ExpectNotNil(address.Validate())
+	}
+}
+
+func TestReportedAddressMapping(t *testing.T) {
+	defer reportedAddressesCache.Flush()
+
+	connectKey := InstanceKey{Hostname: "db1.public", Port: 13306}
+	reportedKey := InstanceKey{Hostname: "10.0.0.11", Port: 3306}
+	otherKey := InstanceKey{Hostname: "10.0.0.12", Port: 3306}
+	reportedAddressesCache.Set(reportedAddressesCacheKey, &reportedAddressMaps{
+		byKey:         map[InstanceKey]InstanceKey{connectKey: reportedKey},
+		byReportedKey: map[InstanceKey]InstanceKey{reportedKey: connectKey},
+	}, cache.DefaultExpiration)
+
+	test.S(t).ExpectEquals(*connectKeyOf(&reportedKey), connectKey)
+	test.S(t).ExpectEquals(*connectKeyOf(&otherKey), otherKey)
+	{
+		key, reported := reportedKeyOf(&connectKey)
+		test.S(t).ExpectTrue(reported)
+		test.S(t).ExpectEquals(*key, reportedKey)
+	}
+	{
+		key, reported := reportedKeyOf(&otherKey)
+		test.S(t).ExpectFalse(reported)
+		test.S(t).ExpectEquals(*key, otherKey)
+	}
+}
@@ -103,8 +103,9 @@ func GetCNAME(hostname string) (string, error) {
 	return res, nil
 }
 
-func resolveHostname(hostname string) (string, error) {
-	switch strings.ToLower(config.Config.HostnameResolveMethod) {
+// resolveHostname resolves a hostname by given method, any of HostnameResolveMethod's
+func resolveHostname(resolveMethod string, hostname string) (string, error) {
+	switch strings.ToLower(resolveMethod) {
 	case "none":
 		return hostname, nil
 	case "default":
@@ -154,7 +155,15 @@ func ResolveHostname(hostname string) (string, error) {
 
 	// Unfound: resolve!
 	log.Debugf("Hostname unresolved yet: %s", hostname)
-	resolvedHostname, err := resolveHostname(hostname)
+	resolveMethod := config.Config.HostnameResolveMethod
+	resolvableHostname := hostname
+	if rule := matchHostnameRule(readHostnameRules(), hostname); rule != nil {
+		resolvableHostname = rewriteHostname(rule, hostname)
+		if rule.ResolveMethod != "" {
+			resolveMethod = rule.ResolveMethod
+		}
+	}
+	resolvedHostname, err := resolveHostname(resolveMethod, resolvableHostname)
 	if config.Config.RejectHostnameResolvePattern != "" {
 		// Reject, don't even cache
 		if matched, _ := regexp.MatchString(config.Config.RejectHostnameResolvePattern, resolvedHostname); matched {
@@ -307,6 +316,9 @@ func getHostnameIP(hostname string) (ipString string, err error) {
 		return ipString, err
 	}
 	ipv4String, ipv6String := extractIPs(ips)
+	if config.Config.ConnectIPPreference == config.IPPreferenceIPv6 && ipv6String != "" {
+		return ipv6String, nil
+	}
 	if ipv4String != "" {
 		return ipv4String, nil
 	}
@@ -0,0 +1,123 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// runningActivityQuery detects, via performance_schema, activities known to stall or lag replication:
+// backups holding a backup or global read lock, logical dumps, and long-running DDL, including DDL applied
+// by the replication SQL thread
+const runningActivityQuery = `
+	select activity from (
+		select
+			concat('backup: ', if(metadata_locks.object_type = 'GLOBAL', 'global read lock', 'backup lock'), ' held by ', ifnull(threads.processlist_user, ''), '@', ifnull(threads.processlist_host, '')) as activity
+		from
+			performance_schema.metadata_locks
+			join performance_schema.threads on (metadata_locks.owner_thread_id = threads.thread_id)
+		where
+			metadata_locks.object_type in ('BACKUP LOCK', 'GLOBAL')
+			and metadata_locks.lock_type = 'SHARED'
+			and metadata_locks.lock_status = 'GRANTED'
+		union all
+		select
+			concat('backup: logical dump by ', ifnull(processlist_user, ''), '@', ifnull(processlist_host, '')) as activity
+		from
+			performance_schema.threads
+		where
+			processlist_info like 'SELECT /*!40001 SQL_NO_CACHE */%'
+		union all
+		select
+			concat('ddl: ', left(processlist_info, 256), ' (', processlist_time, 's)') as activity
+		from
+			performance_schema.threads
+		where
+			processlist_command != 'Sleep'
+			and processlist_time >= ?
+			and lower(processlist_info) regexp '^[[:space:]]*(alter|create[[:space:]]+(unique[[:space:]]+)?index|drop[[:space:]]+index|optimize|truncate)[[:space:]]'
+	) activities
+	limit 1
+	`
+
+// ReadRunningActivity describes a backup or blocking activity running on an instance, per DetectRunningActivityQuery
+// or else built-in detection. It returns an empty string when nothing runs.
+func ReadRunningActivity(db *sql.DB) (activity string, err error) {
+	if config.Config.DetectRunningActivityQuery != "" {
+		err = db.QueryRow(config.Config.DetectRunningActivityQuery).Scan(&activity)
+	} else {
+		err = db.QueryRow(runningActivityQuery, config.Config.ReasonableLongRunningDDLSeconds).Scan(&activity)
+	}
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return activity, err
+}
+
+// replicationStalledAnalyses are analyses of reachable replicas not replicating, which backups are known to
+// cause by stopping the replication SQL thread
+var replicationStalledAnalyses = map[AnalysisCode]bool{
+	MasterSingleSlaveNotReplicating:           true,
+	AllMasterSlavesNotReplicating:             true,
+	AllCoMasterSlavesNotReplicating:           true,
+	AllIntermediateMasterSlavesNotReplicating: true,
+}
+
+// replicationLagRuleFields are analysis rule fields telling of lag or stalled replication, either of the
+// analyzed instance itself (false) or of its replicas (true)
+var replicationLagRuleFields = map[string]bool{
+	"ReplicationLagSeconds":         false,
+	"CountLaggingReplicas":          true,
+	"CountValidReplicatingReplicas": true,
+}
+
+// activitySuppressionReason returns the running activity explaining an analysis of lag or stalled replication,
+// or an empty string if there's none: either no such analysis, or no such activity
+func activitySuppressionReason(rules []config.AnalysisRule, analysis *ReplicationAnalysis) string {
+	replicasActivity := fmt.Sprintf("%d replicas running a backup or long-running DDL", analysis.CountReplicasRunningActivity)
+	if replicationStalledAnalyses[analysis.Analysis] {
+		countNotReplicating := analysis.CountValidReplicas - analysis.CountValidReplicatingReplicas
+		if analysis.CountReplicasRunningActivity > 0 && analysis.CountReplicasRunningActivity >= countNotReplicating {
+			return replicasActivity
+		}
+		return ""
+	}
+	if !IsCustomAnalysisCode(analysis.Analysis) {
+		return ""
+	}
+	for i := range rules {
+		if AnalysisCode(rules[i].Code) != analysis.Analysis {
+			continue
+		}
+		for _, condition := range rules[i].Conditions {
+			ofReplicas, found := replicationLagRuleFields[condition.Field]
+			if !found {
+				continue
+			}
+			if !ofReplicas && analysis.RunningActivity != "" {
+				return analysis.RunningActivity
+			}
+			if ofReplicas && analysis.CountReplicasRunningActivity > 0 {
+				return replicasActivity
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,53 @@
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	test "github.com/openark/golib/tests"
+)
+
+func TestActivitySuppressionReason(t *testing.T) {
+	rules := []config.AnalysisRule{
+		{
+			Code: "CustomLaggingReplica",
+			Conditions: []config.AnalysisRuleCondition{
+				{Field: "ReplicationLagSeconds", Operator: ">", Value: 300},
+			},
+		},
+		{
+			Code: "CustomMasterWithFewReplicas",
+			Conditions: []config.AnalysisRuleCondition{
+				{Field: "CountValidReplicas", Operator: "<", Value: 2},
+			},
+		},
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: AllMasterSlavesNotReplicating, CountValidReplicas: 3, CountValidReplicatingReplicas: 0, CountReplicasRunningActivity: 3}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "3 replicas running a backup or long-running DDL")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: AllMasterSlavesNotReplicating, CountValidReplicas: 3, CountValidReplicatingReplicas: 0, CountReplicasRunningActivity: 1}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: MasterSingleSlaveNotReplicating, CountValidReplicas: 1}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: DeadMaster, CountReplicasRunningActivity: 2, RunningActivity: "ddl: alter table t engine=innodb (600s)"}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: "CustomLaggingReplica", RunningActivity: "backup: backup lock held by backup@localhost"}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "backup: backup lock held by backup@localhost")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: "CustomLaggingReplica", CountReplicasRunningActivity: 1}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "")
+	}
+	{
+		analysis := &ReplicationAnalysis{Analysis: "CustomMasterWithFewReplicas", RunningActivity: "backup: backup lock held by backup@localhost"}
+		test.S(t).ExpectEquals(activitySuppressionReason(rules, analysis), "")
+	}
+}
@@ -0,0 +1,117 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// SessionKill is a session a kill policy finds on an instance, and how it is to be killed
+type SessionKill struct {
+	Process
+	OpenWriteTransaction bool
+	KillConnection       bool // kill the connection, rolling back its transaction, rather than just its query
+	Reason               string
+}
+
+// sessionKillOf decides whether, and how, a kill policy kills given session
+func sessionKillOf(policy *config.SQLKillPolicy, process Process, openWriteTransaction bool) (kill *SessionKill) {
+	if !policy.MatchesUser(process.User) {
+		return nil
+	}
+	if policy.KillOpenTransactions && openWriteTransaction {
+		return &SessionKill{Process: process, OpenWriteTransaction: true, KillConnection: true, Reason: "open write transaction"}
+	}
+	if policy.LongQuerySeconds > 0 && process.Command == "Query" && process.Time >= int64(policy.LongQuerySeconds) {
+		return &SessionKill{Process: process, Reason: fmt.Sprintf("query running for %ds", process.Time)}
+	}
+	return nil
+}
+
+// ReadSessionKills lists the sessions on an instance which a kill policy kills. Replication threads, binlog
+// dumps and orchestrator's own session are never listed.
+func ReadSessionKills(instanceKey *InstanceKey, policy *config.SQLKillPolicy) (kills []SessionKill, err error) {
+	sqlDB, err := db.OpenTopology(instanceKey.Hostname, instanceKey.Port)
+	if err != nil {
+		return kills, err
+	}
+	query := `
+		select
+			processlist.id,
+			processlist.user,
+			processlist.host,
+			ifnull(processlist.db, '') as db,
+			processlist.command,
+			processlist.time,
+			ifnull(processlist.state, '') as state,
+			ifnull(left(processlist.info, 256), '') as info,
+			ifnull(innodb_trx.trx_rows_modified, 0) > 0 as open_write_transaction
+		from
+			information_schema.processlist
+			left join information_schema.innodb_trx on (innodb_trx.trx_mysql_thread_id = processlist.id)
+		where
+			processlist.id != connection_id()
+			and processlist.user != 'system user'
+			and processlist.command not in ('Binlog Dump', 'Binlog Dump GTID', 'Daemon')
+		`
+	err = sqlutils.QueryRowsMap(sqlDB, query, func(m sqlutils.RowMap) error {
+		process := Process{
+			InstanceHostname: instanceKey.Hostname,
+			InstancePort:     instanceKey.Port,
+			Id:               m.GetInt64("id"),
+			User:             m.GetString("user"),
+			Host:             m.GetString("host"),
+			Db:               m.GetString("db"),
+			Command:          m.GetString("command"),
+			Time:             m.GetInt64("time"),
+			State:            m.GetString("state"),
+			Info:             m.GetString("info"),
+		}
+		if kill := sessionKillOf(policy, process, m.GetBool("open_write_transaction")); kill != nil {
+			kills = append(kills, *kill)
+		}
+		return nil
+	})
+	return kills, err
+}
+
+// KillSessions kills given sessions on an instance, returning the number killed. Sessions may have completed
+// in the meantime; such failures are not errors.
+func KillSessions(instanceKey *InstanceKey, kills []SessionKill) (killed int, err error) {
+	if *config.RuntimeCLIFlags.Noop {
+		return killed, fmt.Errorf("noop: aborting kill-sessions operation on %+v; signalling error but nothing went wrong.", *instanceKey)
+	}
+	for _, kill := range kills {
+		statement, what := `kill query ?`, "query"
+		if kill.KillConnection {
+			statement, what = `kill ?`, "connection"
+		}
+		if _, err := ExecInstance(instanceKey, statement, kill.Id); err != nil {
+			log.Debugf("KillSessions: %+v: %d: %+v", *instanceKey, kill.Id, err)
+			continue
+		}
+		killed++
+		AuditOperation("kill-session", instanceKey, fmt.Sprintf("%s: killed %s of %s@%s, id %d", kill.Reason, what, kill.User, kill.Host, kill.Id))
+	}
+	return killed, nil
+}
@@ -0,0 +1,53 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestSessionKillOf(t *testing.T) {
+	policy := &config.SQLKillPolicy{KillOpenTransactions: true, LongQuerySeconds: 30, ExcludeUserPattern: "^monitor$"}
+	{
+		kill := sessionKillOf(policy, Process{Id: 11, User: "app", Command: "Sleep", Time: 2}, true)
+		test.S(t).ExpectNotNil(kill)
+		test.S(t).ExpectTrue(kill.KillConnection)
+		test.S(t).ExpectEquals(kill.Reason, "open write transaction")
+	}
+	{
+		kill := sessionKillOf(policy, Process{Id: 12, User: "app", Command: "Query", Time: 45}, false)
+		test.S(t).ExpectNotNil(kill)
+		test.S(t).ExpectFalse(kill.KillConnection)
+	}
+	{
+		kill := sessionKillOf(policy, Process{Id: 13, User: "app", Command: "Query", Time: 5}, false)
+		test.S(t).ExpectTrue(kill == nil)
+	}
+	{
+		kill := sessionKillOf(policy, Process{Id: 14, User: "monitor", Command: "Query", Time: 45}, true)
+		test.S(t).ExpectTrue(kill == nil)
+	}
+	{
+		policy := &config.SQLKillPolicy{LongQuerySeconds: 30}
+		kill := sessionKillOf(policy, Process{Id: 15, User: "app", Command: "Sleep", Time: 600}, true)
+		test.S(t).ExpectTrue(kill == nil)
+	}
+}
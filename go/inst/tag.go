@@ -34,6 +34,8 @@ var (
 	TagEqualsRegexp       = regexp.MustCompile("^([^=]+)=(.*)$")
 	negateTagExistsRegexp = regexp.MustCompile("^~([^=]+)$")
 	tagExistsRegexp       = regexp.MustCompile("^([^=]+)$")
+	tagAndRegexp          = regexp.MustCompile(`(?i),|\s+and\s+`)
+	tagOrRegexp           = regexp.MustCompile(`(?i)\s+or\s+`)
 )
 
 func NewTag(tagName string, tagValue string) (*Tag, error) {
@@ -78,8 +80,9 @@ func (tag *Tag) String() string {
 	return fmt.Sprintf("%s=%s", tag.TagName, tag.TagValue)
 }
 
+// ParseIntersectTags parses tags to intersect, delimited by commas or by "and", e.g. "env=prod and role=reporting"
 func ParseIntersectTags(tagsString string) (tags [](*Tag), err error) {
-	for _, tagString := range strings.Split(tagsString, ",") {
+	for _, tagString := range tagAndRegexp.Split(tagsString, -1) {
 		tag, err := ParseTag(tagString)
 		if err != nil {
 			return tags, err
@@ -94,7 +97,24 @@ type InstanceTag struct {
 	T   Tag
 }
 
+// GetInstanceKeysByTags returns the instances matching a tag expression: intersections of tags (see ParseIntersectTags),
+// optionally united by "or", e.g. "env=prod and role=reporting or role=backup"
 func GetInstanceKeysByTags(tagsString string) (tagged *InstanceKeyMap, err error) {
+	for i, intersectTagsString := range tagOrRegexp.Split(tagsString, -1) {
+		taggedByIntersection, err := getInstanceKeysByIntersectTags(intersectTagsString)
+		if err != nil {
+			return tagged, err
+		}
+		if i == 0 {
+			tagged = taggedByIntersection
+		} else {
+			tagged.AddKeys(taggedByIntersection.GetInstanceKeys())
+		}
+	}
+	return tagged, nil
+}
+
+func getInstanceKeysByIntersectTags(tagsString string) (tagged *InstanceKeyMap, err error) {
 	tags, err := ParseIntersectTags(tagsString)
 	if err != nil {
 		return tagged, err
@@ -112,3 +132,18 @@ func GetInstanceKeysByTags(tagsString string) (tagged *InstanceKeyMap, err error
 	}
 	return tagged, nil
 }
+
+// FilterInstancesByTags returns those of given instances matching a tag expression (see GetInstanceKeysByTags)
+func FilterInstancesByTags(instances [](*Instance), tagsString string) ([](*Instance), error) {
+	tagged, err := GetInstanceKeysByTags(tagsString)
+	if err != nil {
+		return instances, err
+	}
+	filtered := [](*Instance){}
+	for _, instance := range instances {
+		if tagged.HasKey(instance.Key) {
+			filtered = append(filtered, instance)
+		}
+	}
+	return filtered, nil
+}
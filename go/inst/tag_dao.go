@@ -149,6 +149,25 @@ func ReadInstanceTags(instanceKey *InstanceKey) (tags [](*Tag), err error) {
 	return tags, log.Errore(err)
 }
 
+// ReadInstanceTagValues reads the value of given tag on all instances tagged with it
+func ReadInstanceTagValues(tagName string) (tagValues map[InstanceKey]string, err error) {
+	tagValues = make(map[InstanceKey]string)
+	query := `
+		select
+			hostname, port, tag_value
+		from
+			database_instance_tags
+		where
+			tag_name = ?
+			`
+	err = db.QueryOrchestrator(query, sqlutils.Args(tagName), func(m sqlutils.RowMap) error {
+		instanceKey := InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")}
+		tagValues[instanceKey] = m.GetString("tag_value")
+		return nil
+	})
+	return tagValues, log.Errore(err)
+}
+
 func GetInstanceKeysByTag(tag *Tag) (tagged *InstanceKeyMap, err error) {
 	if tag == nil {
 		return nil, log.Errorf("GetInstanceKeysByTag: tag is nil")
@@ -137,4 +137,21 @@ func TestParseIntersectTags(t *testing.T) {
 		test.S(t).ExpectTrue(tags[1].Negate)
 		test.S(t).ExpectTrue(tags[1].HasValue)
 	}
+	{
+		tags, err := ParseIntersectTags("env=prod and role=reporting AND ~dc")
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(tags), 3)
+
+		test.S(t).ExpectEquals(tags[0].TagName, "env")
+		test.S(t).ExpectEquals(tags[0].TagValue, "prod")
+		test.S(t).ExpectEquals(tags[1].TagName, "role")
+		test.S(t).ExpectEquals(tags[1].TagValue, "reporting")
+		test.S(t).ExpectEquals(tags[2].TagName, "dc")
+		test.S(t).ExpectTrue(tags[2].Negate)
+		test.S(t).ExpectFalse(tags[2].HasValue)
+	}
+	{
+		_, err := ParseIntersectTags("env=prod and ")
+		test.S(t).ExpectNotNil(err)
+	}
 }
@@ -0,0 +1,124 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+)
+
+// MovedInstance is an instance which replicates from a different master in one snapshot than in another
+type MovedInstance struct {
+	Key             InstanceKey
+	BeforeMasterKey InstanceKey
+	AfterMasterKey  InstanceKey
+}
+
+// TopologyDiff is the structured difference between two snapshots of a cluster's topology
+type TopologyDiff struct {
+	BeforeClusterName       string
+	BeforeSnapshotTimestamp string
+	BeforeMasterKey         InstanceKey
+	AfterClusterName        string
+	AfterSnapshotTimestamp  string
+	AfterMasterKey          InstanceKey
+	Moved                   []MovedInstance // replicating from a different master
+	Promoted                []InstanceKey   // top-level masters only after
+	Demoted                 []InstanceKey   // top-level masters only before
+	Lost                    []InstanceKey   // gone from the topology
+	Added                   []InstanceKey   // new to the topology
+	ReplicationStopped      []InstanceKey   // replicating before, not after
+}
+
+// NewTopologyDiff computes the difference between given snapshots
+func NewTopologyDiff(before *TopologySnapshot, after *TopologySnapshot) *TopologyDiff {
+	diff := &TopologyDiff{
+		BeforeClusterName:       before.ClusterName,
+		BeforeSnapshotTimestamp: before.SnapshotTimestamp,
+		BeforeMasterKey:         before.MasterKey,
+		AfterClusterName:        after.ClusterName,
+		AfterSnapshotTimestamp:  after.SnapshotTimestamp,
+		AfterMasterKey:          after.MasterKey,
+		Moved:                   []MovedInstance{},
+		Promoted:                []InstanceKey{},
+		Demoted:                 []InstanceKey{},
+		Lost:                    []InstanceKey{},
+		Added:                   []InstanceKey{},
+		ReplicationStopped:      []InstanceKey{},
+	}
+	isMaster := func(snapshot *TopologySnapshot, instance *TopologySnapshotInstance) bool {
+		return instance.IsCoMaster || snapshot.GetInstance(&instance.MasterKey) == nil
+	}
+	for i := range before.Instances {
+		beforeInstance := &before.Instances[i]
+		afterInstance := after.GetInstance(&beforeInstance.Key)
+		if afterInstance == nil {
+			diff.Lost = append(diff.Lost, beforeInstance.Key)
+			continue
+		}
+		wasMaster := isMaster(before, beforeInstance)
+		nowMaster := isMaster(after, afterInstance)
+		if !wasMaster && nowMaster {
+			diff.Promoted = append(diff.Promoted, afterInstance.Key)
+		} else if wasMaster && !nowMaster {
+			diff.Demoted = append(diff.Demoted, afterInstance.Key)
+		}
+		if !nowMaster && !wasMaster && !beforeInstance.MasterKey.Equals(&afterInstance.MasterKey) {
+			diff.Moved = append(diff.Moved, MovedInstance{Key: afterInstance.Key, BeforeMasterKey: beforeInstance.MasterKey, AfterMasterKey: afterInstance.MasterKey})
+		}
+		if beforeInstance.ReplicationRunning && !afterInstance.ReplicationRunning && !nowMaster {
+			diff.ReplicationStopped = append(diff.ReplicationStopped, afterInstance.Key)
+		}
+	}
+	for _, afterInstance := range after.Instances {
+		if before.GetInstance(&afterInstance.Key) == nil {
+			diff.Added = append(diff.Added, afterInstance.Key)
+		}
+	}
+	return diff
+}
+
+// HasChanges returns true when the snapshots differ in any of the diffed aspects
+func (this *TopologyDiff) HasChanges() bool {
+	return len(this.Moved)+len(this.Promoted)+len(this.Demoted)+len(this.Lost)+len(this.Added)+len(this.ReplicationStopped) > 0
+}
+
+// Summary describes the changes, one per line
+func (this *TopologyDiff) Summary() []string {
+	summary := []string{}
+	if !this.BeforeMasterKey.Equals(&this.AfterMasterKey) {
+		summary = append(summary, fmt.Sprintf("master: %s -> %s", this.BeforeMasterKey.DisplayString(), this.AfterMasterKey.DisplayString()))
+	}
+	for _, key := range this.Promoted {
+		summary = append(summary, fmt.Sprintf("promoted: %s", key.DisplayString()))
+	}
+	for _, key := range this.Demoted {
+		summary = append(summary, fmt.Sprintf("demoted: %s", key.DisplayString()))
+	}
+	for _, moved := range this.Moved {
+		summary = append(summary, fmt.Sprintf("moved: %s from below %s to below %s", moved.Key.DisplayString(), moved.BeforeMasterKey.DisplayString(), moved.AfterMasterKey.DisplayString()))
+	}
+	for _, key := range this.Lost {
+		summary = append(summary, fmt.Sprintf("lost: %s", key.DisplayString()))
+	}
+	for _, key := range this.Added {
+		summary = append(summary, fmt.Sprintf("added: %s", key.DisplayString()))
+	}
+	for _, key := range this.ReplicationStopped {
+		summary = append(summary, fmt.Sprintf("replication stopped: %s", key.DisplayString()))
+	}
+	return summary
+}
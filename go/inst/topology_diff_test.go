@@ -0,0 +1,61 @@
+package inst
+
+import (
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestTopologyDiffNoChanges(t *testing.T) {
+	diff := NewTopologyDiff(generateTestTopologySnapshot(), generateTestTopologySnapshot())
+	test.S(t).ExpectFalse(diff.HasChanges())
+	test.S(t).ExpectEquals(len(diff.Summary()), 0)
+}
+
+func TestTopologyDiffFailover(t *testing.T) {
+	before := generateTestTopologySnapshot()
+
+	// i710 is gone; i720 is promoted in its place; i820 is taken down
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.MasterKey = i720Key
+		instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+		instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+		instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	}
+	instancesMap[i720Key.StringCode()].MasterKey = InstanceKey{}
+	instancesMap[i810Key.StringCode()].MasterKey = i730Key
+	instancesMap[i830Key.StringCode()].ReplicationIOThreadState = ReplicationThreadStateStopped
+	afterInstances := [](*Instance){}
+	for _, instance := range instances {
+		if !instance.Key.Equals(&i710Key) && !instance.Key.Equals(&i820Key) {
+			afterInstances = append(afterInstances, instance)
+		}
+	}
+	after := NewTopologySnapshot("i720:3306", afterInstances)
+
+	diff := NewTopologyDiff(before, after)
+	test.S(t).ExpectTrue(diff.HasChanges())
+	test.S(t).ExpectEquals(diff.BeforeMasterKey, i710Key)
+	test.S(t).ExpectEquals(diff.AfterMasterKey, i720Key)
+	test.S(t).ExpectEquals(len(diff.Promoted), 1)
+	test.S(t).ExpectEquals(diff.Promoted[0], i720Key)
+	test.S(t).ExpectEquals(len(diff.Demoted), 0)
+	test.S(t).ExpectEquals(len(diff.Lost), 2)
+	test.S(t).ExpectEquals(diff.Lost[0], i710Key)
+	test.S(t).ExpectEquals(diff.Lost[1], i820Key)
+	test.S(t).ExpectEquals(len(diff.Added), 0)
+	test.S(t).ExpectEquals(len(diff.Moved), 2)
+	test.S(t).ExpectEquals(diff.Moved[0].Key, i730Key)
+	test.S(t).ExpectEquals(diff.Moved[0].BeforeMasterKey, i710Key)
+	test.S(t).ExpectEquals(diff.Moved[0].AfterMasterKey, i720Key)
+	test.S(t).ExpectEquals(diff.Moved[1].Key, i830Key)
+	test.S(t).ExpectEquals(len(diff.ReplicationStopped), 1)
+	test.S(t).ExpectEquals(diff.ReplicationStopped[0], i830Key)
+
+	summary := diff.Summary()
+	test.S(t).ExpectEquals(summary[0], "master: i710:3306 -> i720:3306")
+	test.S(t).ExpectEquals(summary[1], "promoted: i720:3306")
+	test.S(t).ExpectEquals(len(summary), 7)
+}
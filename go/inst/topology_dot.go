@@ -0,0 +1,121 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+)
+
+// graphvizTimeout caps the time graphviz may take to render a diagram
+const graphvizTimeout = 10 * time.Second
+
+// topologyDOTLabel annotates an instance in a topology diagram: version, data center, lag and promotion rule
+func topologyDOTLabel(instance *Instance) string {
+	lines := []string{instance.Key.DisplayString(), instance.Version}
+	if instance.DataCenter != "" {
+		lines = append(lines, fmt.Sprintf("dc: %s", instance.DataCenter))
+	}
+	if instance.IsReplica() {
+		if instance.SlaveLagSeconds.Valid && instance.ReplicaRunning() {
+			lines = append(lines, fmt.Sprintf("lag: %ds", instance.SlaveLagSeconds.Int64))
+		} else {
+			lines = append(lines, "lag: n/a")
+		}
+	}
+	if instance.PromotionRule != "" {
+		lines = append(lines, fmt.Sprintf("promotion: %s", instance.PromotionRule))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// TopologyDOT renders the replication tree formed by given instances as a graphviz digraph, with an edge from
+// each master to each of its replicas. Masters are boxed; unreachable instances are red, lagging ones orange and
+// downtimed ones gray; edges of replicas not replicating are dashed.
+func TopologyDOT(clusterName string, instances [](*Instance)) string {
+	sorted := append([](*Instance){}, instances...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Key.SmallerThan(&sorted[j].Key)
+	})
+	instancesMap := make(map[InstanceKey]*Instance)
+	for _, instance := range sorted {
+		instancesMap[instance.Key] = instance
+	}
+
+	lines := []string{fmt.Sprintf("digraph %q {", clusterName)}
+	for _, instance := range sorted {
+		attributes := []string{fmt.Sprintf("label=%q", topologyDOTLabel(instance))}
+		if _, found := instancesMap[instance.MasterKey]; !found || instance.IsCoMaster {
+			attributes = append(attributes, "shape=box")
+		}
+		if !instance.IsLastCheckValid {
+			attributes = append(attributes, "color=red")
+		} else if instance.IsDowntimed {
+			attributes = append(attributes, "color=gray")
+		} else if instance.SlaveLagSeconds.Valid && instance.SlaveLagSeconds.Int64 > int64(config.Config.ReasonableReplicationLagSeconds) {
+			attributes = append(attributes, "color=orange")
+		}
+		lines = append(lines, fmt.Sprintf("  %q [%s];", instance.Key.DisplayString(), strings.Join(attributes, ",")))
+	}
+	for _, instance := range sorted {
+		if _, found := instancesMap[instance.MasterKey]; !found {
+			continue
+		}
+		style := ""
+		if !instance.ReplicaRunning() {
+			style = " [style=dashed]"
+		}
+		lines = append(lines, fmt.Sprintf("  %q -> %q%s;", instance.MasterKey.DisplayString(), instance.Key.DisplayString(), style))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
+
+// ClusterTopologyDOT renders the current replication tree of given cluster as a graphviz digraph
+func ClusterTopologyDOT(clusterName string) (string, error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return "", err
+	}
+	return TopologyDOT(clusterName, instances), nil
+}
+
+// RenderDOTAsSVG renders a graphviz digraph as SVG, via GraphvizDotCommand
+func RenderDOTAsSVG(dot string) ([]byte, error) {
+	if config.Config.GraphvizDotCommand == "" {
+		return nil, fmt.Errorf("GraphvizDotCommand is not configured; SVG rendering is disabled")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), graphvizTimeout)
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, config.Config.GraphvizDotCommand, "-Tsvg")
+	cmd.Stdin = strings.NewReader(dot)
+	cmd.Stderr = &stderr
+	svg, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %+v: %s", config.Config.GraphvizDotCommand, err, strings.TrimSpace(stderr.String()))
+	}
+	return svg, nil
+}
@@ -0,0 +1,42 @@
+package inst
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func TestTopologyDOT(t *testing.T) {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.MasterKey = i710Key
+		instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+		instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+		instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+		instance.SlaveLagSeconds = sql.NullInt64{Int64: 1, Valid: true}
+		instance.DataCenter = "dc1"
+	}
+	instancesMap[i710Key.StringCode()].MasterKey = InstanceKey{}
+	instancesMap[i710Key.StringCode()].SlaveLagSeconds = sql.NullInt64{}
+	instancesMap[i810Key.StringCode()].MasterKey = i730Key
+	instancesMap[i810Key.StringCode()].SlaveLagSeconds = sql.NullInt64{Int64: 3600, Valid: true}
+	instancesMap[i820Key.StringCode()].ReplicationSQLThreadState = ReplicationThreadStateStopped
+	instancesMap[i830Key.StringCode()].PromotionRule = PreferPromoteRule
+	instancesMap[i830Key.StringCode()].IsLastCheckValid = false
+
+	dot := TopologyDOT("i710:3306", instances)
+	test.S(t).ExpectTrue(strings.HasPrefix(dot, `digraph "i710:3306" {`))
+	test.S(t).ExpectTrue(strings.HasSuffix(dot, "}"))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i710:3306" [label="i710:3306\n5.6.7\ndc: dc1",shape=box];`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i720:3306" [label="i720:3306\n5.6.7\ndc: dc1\nlag: 1s"];`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i810:3306" [label="i810:3306\n5.6.7\ndc: dc1\nlag: 3600s",color=orange];`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i820:3306" [label="i820:3306\n5.6.7\ndc: dc1\nlag: n/a"];`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i830:3306" [label="i830:3306\n5.6.7\ndc: dc1\nlag: 1s\npromotion: prefer",color=red];`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i710:3306" -> "i730:3306";`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i730:3306" -> "i810:3306";`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i710:3306" -> "i820:3306" [style=dashed];`))
+	test.S(t).ExpectFalse(strings.Contains(dot, `-> "i710:3306"`))
+}
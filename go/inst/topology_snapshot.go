@@ -0,0 +1,215 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TopologySnapshotInstance is the state of an instance, as captured by a topology snapshot
+type TopologySnapshotInstance struct {
+	Key                   InstanceKey
+	MasterKey             InstanceKey
+	IsCoMaster            bool
+	Version               string
+	ReadOnly              bool
+	IsLastCheckValid      bool
+	ReplicationRunning    bool
+	IsDowntimed           bool
+	ExecBinlogCoordinates BinlogCoordinates
+	SelfBinlogCoordinates BinlogCoordinates
+	ExecutedGtidSet       string
+	Description           string
+}
+
+// NewTopologySnapshotInstance captures the state of given instance
+func NewTopologySnapshotInstance(instance *Instance) TopologySnapshotInstance {
+	return TopologySnapshotInstance{
+		Key:                   instance.Key,
+		MasterKey:             instance.MasterKey,
+		IsCoMaster:            instance.IsCoMaster,
+		Version:               instance.Version,
+		ReadOnly:              instance.ReadOnly,
+		IsLastCheckValid:      instance.IsLastCheckValid,
+		ReplicationRunning:    instance.ReplicaRunning(),
+		IsDowntimed:           instance.IsDowntimed,
+		ExecBinlogCoordinates: instance.ExecBinlogCoordinates,
+		SelfBinlogCoordinates: instance.SelfBinlogCoordinates,
+		ExecutedGtidSet:       instance.ExecutedGtidSet,
+		Description:           instance.HumanReadableDescription(),
+	}
+}
+
+// TopologySnapshot is the replication tree of a cluster at a point in time
+type TopologySnapshot struct {
+	ClusterName       string
+	ClusterAlias      string
+	SnapshotTimestamp string
+	MasterKey         InstanceKey
+	CountInstances    int
+	Instances         []TopologySnapshotInstance
+}
+
+// NewTopologySnapshot captures the replication tree formed by given instances
+func NewTopologySnapshot(clusterName string, instances [](*Instance)) *TopologySnapshot {
+	snapshot := &TopologySnapshot{
+		ClusterName: clusterName,
+		Instances:   []TopologySnapshotInstance{},
+	}
+	for _, instance := range instances {
+		snapshot.Instances = append(snapshot.Instances, NewTopologySnapshotInstance(instance))
+	}
+	sort.Slice(snapshot.Instances, func(i, j int) bool {
+		return snapshot.Instances[i].Key.SmallerThan(&snapshot.Instances[j].Key)
+	})
+	snapshot.resolveMaster()
+	return snapshot
+}
+
+// ParseTopologySnapshot returns the snapshot of given cluster formed by given JSON encoded instances
+func ParseTopologySnapshot(clusterName string, snapshotTimestamp string, instancesJSON string) (*TopologySnapshot, error) {
+	snapshot := &TopologySnapshot{
+		ClusterName:       clusterName,
+		SnapshotTimestamp: snapshotTimestamp,
+		Instances:         []TopologySnapshotInstance{},
+	}
+	if err := json.Unmarshal([]byte(instancesJSON), &snapshot.Instances); err != nil {
+		return nil, err
+	}
+	snapshot.resolveMaster()
+	return snapshot, nil
+}
+
+// InstancesJSON returns the captured instances, JSON encoded
+func (this *TopologySnapshot) InstancesJSON() (string, error) {
+	instancesJSON, err := json.Marshal(this.Instances)
+	return string(instancesJSON), err
+}
+
+// resolveMaster figures the master of the snapshot: the single top-level instance, or else the writable co-master
+func (this *TopologySnapshot) resolveMaster() {
+	this.CountInstances = len(this.Instances)
+	this.MasterKey = InstanceKey{}
+	masters := this.Masters()
+	for _, master := range masters {
+		if len(masters) == 1 || !master.ReadOnly {
+			this.MasterKey = master.Key
+			return
+		}
+	}
+}
+
+// ReadTopologySnapshot captures the current replication tree of given cluster
+func ReadTopologySnapshot(clusterName string) (*TopologySnapshot, error) {
+	instances, err := ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return NewTopologySnapshot(clusterName, instances), nil
+}
+
+// GetInstance returns the captured state of given instance, or nil when the instance is not in the snapshot
+func (this *TopologySnapshot) GetInstance(instanceKey *InstanceKey) *TopologySnapshotInstance {
+	for i := range this.Instances {
+		if this.Instances[i].Key.Equals(instanceKey) {
+			return &this.Instances[i]
+		}
+	}
+	return nil
+}
+
+// Masters returns the instances replicating from no other instance in the snapshot, or else the co-masters
+func (this *TopologySnapshot) Masters() (masters []TopologySnapshotInstance) {
+	for _, instance := range this.Instances {
+		if instance.IsCoMaster || this.GetInstance(&instance.MasterKey) == nil {
+			masters = append(masters, instance)
+		}
+	}
+	return masters
+}
+
+// replicasMap maps each captured instance to its captured replicas
+func (this *TopologySnapshot) replicasMap() map[InstanceKey][]TopologySnapshotInstance {
+	replicasMap := make(map[InstanceKey][]TopologySnapshotInstance)
+	for _, instance := range this.Instances {
+		if instance.IsCoMaster || this.GetInstance(&instance.MasterKey) == nil {
+			continue
+		}
+		replicasMap[instance.MasterKey] = append(replicasMap[instance.MasterKey], instance)
+	}
+	return replicasMap
+}
+
+func (this *TopologySnapshot) asciiEntries(depth int, instance TopologySnapshotInstance, replicasMap map[InstanceKey][]TopologySnapshotInstance) []string {
+	prefix := ""
+	if depth > 0 {
+		prefix = strings.Repeat(asciiFillerCharacter, (depth-1)*2)
+		if instance.ReplicationRunning && instance.IsLastCheckValid {
+			prefix += "+" + asciiFillerCharacter
+		} else {
+			prefix += "-" + asciiFillerCharacter
+		}
+	}
+	entries := []string{fmt.Sprintf("%s%s%s%s", prefix, instance.Key.DisplayString(), asciiFillerCharacter, instance.Description)}
+	for _, replica := range replicasMap[instance.Key] {
+		entries = append(entries, this.asciiEntries(depth+1, replica, replicasMap)...)
+	}
+	return entries
+}
+
+// ASCII renders the snapshot as a tree, in the format of the "topology" command
+func (this *TopologySnapshot) ASCII() string {
+	replicasMap := this.replicasMap()
+	entries := []string{}
+	for _, master := range this.Masters() {
+		depth := 0
+		if master.IsCoMaster {
+			depth = 1
+		}
+		entries = append(entries, this.asciiEntries(depth, master, replicasMap)...)
+	}
+	return strings.Join(entries, "\n")
+}
+
+// DOT renders the snapshot as a graphviz digraph, with an edge from each master to each of its replicas
+func (this *TopologySnapshot) DOT() string {
+	lines := []string{fmt.Sprintf("digraph %q {", this.ClusterName)}
+	for _, instance := range this.Instances {
+		attributes := fmt.Sprintf("label=%q", fmt.Sprintf("%s\n%s", instance.Key.DisplayString(), instance.Description))
+		if !instance.IsLastCheckValid {
+			attributes += ",color=red"
+		} else if instance.IsDowntimed {
+			attributes += ",color=gray"
+		}
+		lines = append(lines, fmt.Sprintf("  %q [%s];", instance.Key.DisplayString(), attributes))
+	}
+	for _, instance := range this.Instances {
+		if this.GetInstance(&instance.MasterKey) == nil {
+			continue
+		}
+		style := ""
+		if !instance.ReplicationRunning {
+			style = " [style=dashed]"
+		}
+		lines = append(lines, fmt.Sprintf("  %q -> %q%s;", instance.MasterKey.DisplayString(), instance.Key.DisplayString(), style))
+	}
+	lines = append(lines, "}")
+	return strings.Join(lines, "\n")
+}
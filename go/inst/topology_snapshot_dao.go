@@ -0,0 +1,159 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// ReadCurrentClusterTopologySnapshots captures the current topology of all known clusters
+func ReadCurrentClusterTopologySnapshots() (snapshots []*TopologySnapshot, err error) {
+	clusters, err := ReadClustersInfo("")
+	if err != nil {
+		return snapshots, log.Errore(err)
+	}
+	for _, clusterInfo := range clusters {
+		snapshot, err := ReadTopologySnapshot(clusterInfo.ClusterName)
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		snapshot.ClusterAlias = clusterInfo.ClusterAlias
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, nil
+}
+
+// WriteClusterTopologySnapshot persists a captured cluster topology
+func WriteClusterTopologySnapshot(snapshot *TopologySnapshot) error {
+	instancesJSON, err := snapshot.InstancesJSON()
+	if err != nil {
+		return log.Errore(err)
+	}
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert
+				into cluster_topology_snapshot (
+					cluster_name, cluster_alias, snapshot_timestamp, master_host, master_port, count_instances, instances
+				) values (
+					?, ?, NOW(), ?, ?, ?, ?
+				)
+			`,
+			snapshot.ClusterName,
+			snapshot.ClusterAlias,
+			snapshot.MasterKey.Hostname,
+			snapshot.MasterKey.Port,
+			snapshot.CountInstances,
+			instancesJSON,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// readClusterTopologySnapshots reads persisted snapshots of a cluster, which is given by either name or alias.
+// The alias is kept by a cluster across master failovers, whereas its name changes with its master.
+func readClusterTopologySnapshots(clusterNameOrAlias string, whereCondition string, args []interface{}, limit string, withInstances bool) (result []TopologySnapshot, err error) {
+	instancesColumn := `''`
+	if withInstances {
+		instancesColumn = `instances`
+	}
+	query := fmt.Sprintf(`
+		select
+			cluster_name,
+			cluster_alias,
+			snapshot_timestamp,
+			master_host,
+			master_port,
+			count_instances,
+			%s as instances
+		from
+			cluster_topology_snapshot
+		where
+			(cluster_name = ? or cluster_alias = ?)
+			%s
+		order by
+			snapshot_timestamp desc
+		%s
+		`, instancesColumn, whereCondition, limit)
+	args = append(sqlutils.Args(clusterNameOrAlias, clusterNameOrAlias), args...)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		snapshot := TopologySnapshot{
+			ClusterName:       m.GetString("cluster_name"),
+			SnapshotTimestamp: m.GetString("snapshot_timestamp"),
+			Instances:         []TopologySnapshotInstance{},
+		}
+		if withInstances {
+			parsed, err := ParseTopologySnapshot(snapshot.ClusterName, snapshot.SnapshotTimestamp, m.GetString("instances"))
+			if err != nil {
+				return log.Errore(err)
+			}
+			snapshot = *parsed
+		}
+		snapshot.ClusterAlias = m.GetString("cluster_alias")
+		snapshot.MasterKey = InstanceKey{Hostname: m.GetString("master_host"), Port: m.GetInt("master_port")}
+		snapshot.CountInstances = m.GetInt("count_instances")
+
+		result = append(result, snapshot)
+		return nil
+	})
+	return result, log.Errore(err)
+}
+
+// ReadClusterTopologySnapshotAt returns the latest snapshot of given cluster (by name or alias) taken at or before
+// given time, formatted 'YYYY-MM-DD hh:mm:ss'. An empty time returns the latest snapshot.
+func ReadClusterTopologySnapshotAt(clusterNameOrAlias string, at string) (*TopologySnapshot, error) {
+	whereCondition := ``
+	args := sqlutils.Args()
+	if at != "" {
+		whereCondition = `and snapshot_timestamp <= ?`
+		args = append(args, at)
+	}
+	snapshots, err := readClusterTopologySnapshots(clusterNameOrAlias, whereCondition, args, `limit 1`, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(snapshots) == 0 {
+		return nil, fmt.Errorf("No topology snapshot found for %s at %s", clusterNameOrAlias, at)
+	}
+	return &snapshots[0], nil
+}
+
+// ReadClusterTopologySnapshotTimeline lists the snapshots of given cluster (by name or alias), newest first, without
+// their instances; each names the cluster's master at the time. Optional from/to times limit the listing.
+func ReadClusterTopologySnapshotTimeline(clusterNameOrAlias string, from string, to string) ([]TopologySnapshot, error) {
+	whereCondition := ``
+	args := sqlutils.Args()
+	if from != "" {
+		whereCondition += ` and snapshot_timestamp >= ?`
+		args = append(args, from)
+	}
+	if to != "" {
+		whereCondition += ` and snapshot_timestamp <= ?`
+		args = append(args, to)
+	}
+	return readClusterTopologySnapshots(clusterNameOrAlias, whereCondition, args, ``, false)
+}
+
+// ExpireClusterTopologySnapshots removes old rows from the cluster_topology_snapshot table
+func ExpireClusterTopologySnapshots() error {
+	return ExpireTableData("cluster_topology_snapshot", "snapshot_timestamp")
+}
@@ -0,0 +1,73 @@
+package inst
+
+import (
+	"strings"
+	"testing"
+
+	test "github.com/openark/golib/tests"
+)
+
+func generateTestTopologySnapshot() *TopologySnapshot {
+	instances, instancesMap := generateTestInstances()
+	applyGeneralGoodToGoReplicationParams(instances)
+	for _, instance := range instances {
+		instance.MasterKey = i710Key
+		instance.ReadBinlogCoordinates = instance.ExecBinlogCoordinates
+		instance.ReplicationIOThreadState = ReplicationThreadStateRunning
+		instance.ReplicationSQLThreadState = ReplicationThreadStateRunning
+	}
+	instancesMap[i710Key.StringCode()].MasterKey = InstanceKey{}
+	instancesMap[i810Key.StringCode()].MasterKey = i730Key
+	instancesMap[i820Key.StringCode()].ReplicationSQLThreadState = ReplicationThreadStateStopped
+	return NewTopologySnapshot("i710:3306", instances)
+}
+
+func TestTopologySnapshotMasters(t *testing.T) {
+	snapshot := generateTestTopologySnapshot()
+	masters := snapshot.Masters()
+	test.S(t).ExpectEquals(len(masters), 1)
+	test.S(t).ExpectEquals(masters[0].Key, i710Key)
+	test.S(t).ExpectTrue(snapshot.GetInstance(&i810Key) != nil)
+	test.S(t).ExpectTrue(snapshot.GetInstance(&InstanceKey{Hostname: "i999", Port: 3306}) == nil)
+}
+
+func TestTopologySnapshotASCII(t *testing.T) {
+	snapshot := generateTestTopologySnapshot()
+	lines := strings.Split(snapshot.ASCII(), "\n")
+	test.S(t).ExpectEquals(len(lines), 6)
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[0], "i710:3306 ["))
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[1], "+ i720:3306 ["))
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[2], "+ i730:3306 ["))
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[3], "  + i810:3306 ["))
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[4], "- i820:3306 ["))
+	test.S(t).ExpectTrue(strings.HasPrefix(lines[5], "+ i830:3306 ["))
+}
+
+func TestTopologySnapshotDOT(t *testing.T) {
+	snapshot := generateTestTopologySnapshot()
+	dot := snapshot.DOT()
+	test.S(t).ExpectTrue(strings.HasPrefix(dot, `digraph "i710:3306" {`))
+	test.S(t).ExpectTrue(strings.HasSuffix(dot, "}"))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i710:3306" -> "i730:3306";`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i730:3306" -> "i810:3306";`))
+	test.S(t).ExpectTrue(strings.Contains(dot, `"i710:3306" -> "i820:3306" [style=dashed];`))
+	test.S(t).ExpectFalse(strings.Contains(dot, `-> "i710:3306"`))
+}
+
+func TestParseTopologySnapshot(t *testing.T) {
+	snapshot := generateTestTopologySnapshot()
+	test.S(t).ExpectEquals(snapshot.MasterKey, i710Key)
+	test.S(t).ExpectEquals(snapshot.CountInstances, 6)
+
+	instancesJSON, err := snapshot.InstancesJSON()
+	test.S(t).ExpectNil(err)
+	parsed, err := ParseTopologySnapshot(snapshot.ClusterName, "2017-01-02 03:04:05", instancesJSON)
+	test.S(t).ExpectNil(err)
+	test.S(t).ExpectEquals(parsed.MasterKey, i710Key)
+	test.S(t).ExpectEquals(parsed.CountInstances, 6)
+	test.S(t).ExpectEquals(parsed.SnapshotTimestamp, "2017-01-02 03:04:05")
+	test.S(t).ExpectEquals(parsed.ASCII(), snapshot.ASCII())
+
+	_, err = ParseTopologySnapshot(snapshot.ClusterName, "", "not json")
+	test.S(t).ExpectNotNil(err)
+}
@@ -0,0 +1,107 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// Conditions leaving a master reachable, but unusable
+const (
+	UnusableMasterTooManyConnections = "too-many-connections"
+	UnusableMasterReadOnlyFilesystem = "read-only-filesystem"
+	UnusableMasterDiskFull           = "disk-full"
+)
+
+// Sources of unusable master signals
+const (
+	UnusableMasterSourceProbe     = "probe"
+	UnusableMasterSourceHeartbeat = "heartbeat"
+	UnusableMasterSourceAgent     = "agent"
+)
+
+// unusableMasterConditionSeverity orders conditions for when a master signals more than one; higher is more severe
+var unusableMasterConditionSeverity = map[string]int{
+	UnusableMasterTooManyConnections: 1,
+	UnusableMasterReadOnlyFilesystem: 2,
+	UnusableMasterDiskFull:           3,
+}
+
+// unusableMasterConditionAnalysis maps conditions to the analysis of a master reporting them
+var unusableMasterConditionAnalysis = map[string]AnalysisCode{
+	UnusableMasterTooManyConnections: TooManyConnectionsMaster,
+	UnusableMasterReadOnlyFilesystem: ReadOnlyFilesystemMaster,
+	UnusableMasterDiskFull:           DiskFullMaster,
+}
+
+// Server errors by which a master reports a condition leaving it unusable
+var unusableMasterErrnos = map[uint16]string{
+	1021: UnusableMasterDiskFull,           // ER_DISK_FULL
+	1036: UnusableMasterReadOnlyFilesystem, // ER_OPEN_AS_READONLY
+	1040: UnusableMasterTooManyConnections, // ER_CON_COUNT_ERROR
+	1114: UnusableMasterDiskFull,           // ER_RECORD_FILE_FULL
+}
+
+// OS errors (ENOSPC, EROFS) a master reports within generic write errors, e.g. "Errcode: 28 - No space left on device"
+var unusableMasterErrorTexts = map[string]string{
+	"No space left on device": UnusableMasterDiskFull,
+	"Errcode: 28":             UnusableMasterDiskFull,
+	"Got error 28 ":           UnusableMasterDiskFull,
+	"Read-only file system":   UnusableMasterReadOnlyFilesystem,
+	"Errcode: 30":             UnusableMasterReadOnlyFilesystem,
+	"Got error 30 ":           UnusableMasterReadOnlyFilesystem,
+}
+
+// UnusableMasterSignal is evidence, recently seen, that a master is reachable but unusable
+type UnusableMasterSignal struct {
+	Key              InstanceKey
+	Condition        string
+	Source           string
+	Details          string
+	SecondsPersisted uint // since the condition was first seen, uninterrupted
+}
+
+// Analysis returns the analysis of a master reporting this signal
+func (this *UnusableMasterSignal) Analysis() AnalysisCode {
+	return unusableMasterConditionAnalysis[this.Condition]
+}
+
+// moreSevereThan checks whether this signal's condition is more severe than other's
+func (this *UnusableMasterSignal) moreSevereThan(other *UnusableMasterSignal) bool {
+	return unusableMasterConditionSeverity[this.Condition] > unusableMasterConditionSeverity[other.Condition]
+}
+
+// UnusableMasterCondition classifies an error returned by a master as a condition leaving it unusable, or
+// returns an empty string if the error indicates no such condition
+func UnusableMasterCondition(err error) string {
+	if err == nil {
+		return ""
+	}
+	if mysqlErr, ok := err.(*mysql.MySQLError); ok {
+		if condition, found := unusableMasterErrnos[mysqlErr.Number]; found {
+			return condition
+		}
+	}
+	for text, condition := range unusableMasterErrorTexts {
+		if strings.Contains(err.Error(), text) {
+			return condition
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// unusableMasterSignalValiditySeconds is the time a signal remains valid unless seen again. Probes run
+// once per instance poll; a signal not seen for two polls is considered gone.
+func unusableMasterSignalValiditySeconds() uint {
+	return 2 * config.Config.InstancePollSeconds
+}
+
+// RecordUnusableMasterSignal records evidence that a master is reachable but unusable. A signal seen again
+// within its validity keeps the time it was first seen, so that analysis tells how long the condition persists.
+func RecordUnusableMasterSignal(instanceKey *InstanceKey, condition string, source string, details string) error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			insert ignore into unusable_master_signal (
+				hostname, port, unusable_condition, source, details, first_seen_timestamp, last_seen_timestamp
+			) values (
+				?, ?, ?, ?, ?, NOW(), NOW()
+			)
+			`, instanceKey.Hostname, instanceKey.Port, condition, source, details,
+		)
+		if err != nil {
+			return log.Errore(err)
+		}
+		_, err = db.ExecOrchestrator(`
+			update unusable_master_signal set
+				first_seen_timestamp = case
+					when last_seen_timestamp < NOW() - INTERVAL ? SECOND then NOW()
+					else first_seen_timestamp
+				end,
+				last_seen_timestamp = NOW(),
+				details = ?
+			where
+				hostname = ?
+				and port = ?
+				and unusable_condition = ?
+				and source = ?
+			`, unusableMasterSignalValiditySeconds(), details, instanceKey.Hostname, instanceKey.Port, condition, source,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
+
+// ReadUnusableMasterSignals reads valid unusable master signals, returning the most severe signal per master
+func ReadUnusableMasterSignals() (signals map[InstanceKey]UnusableMasterSignal, err error) {
+	signals = make(map[InstanceKey]UnusableMasterSignal)
+	query := `
+		select
+			hostname,
+			port,
+			unusable_condition,
+			source,
+			details,
+			unix_timestamp() - unix_timestamp(first_seen_timestamp) as seconds_persisted
+		from
+			unusable_master_signal
+		where
+			last_seen_timestamp >= NOW() - INTERVAL ? SECOND
+		order by
+			first_seen_timestamp asc
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(unusableMasterSignalValiditySeconds()), func(m sqlutils.RowMap) error {
+		signal := UnusableMasterSignal{
+			Key:              InstanceKey{Hostname: m.GetString("hostname"), Port: m.GetInt("port")},
+			Condition:        m.GetString("unusable_condition"),
+			Source:           m.GetString("source"),
+			Details:          m.GetString("details"),
+			SecondsPersisted: m.GetUint("seconds_persisted"),
+		}
+		if existing, found := signals[signal.Key]; !found || signal.moreSevereThan(&existing) {
+			signals[signal.Key] = signal
+		}
+		return nil
+	})
+	return signals, log.Errore(err)
+}
+
+// ExpireUnusableMasterSignals removes signals no longer seen
+func ExpireUnusableMasterSignals() error {
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(`
+			delete from unusable_master_signal
+			where
+				last_seen_timestamp < NOW() - INTERVAL 1 HOUR
+			`,
+		)
+		return log.Errore(err)
+	}
+	return ExecDBWriteFunc(writeFunc)
+}
@@ -0,0 +1,44 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package inst
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	test "github.com/openark/golib/tests"
+)
+
+func TestUnusableMasterCondition(t *testing.T) {
+	test.S(t).ExpectEquals(UnusableMasterCondition(nil), "")
+	test.S(t).ExpectEquals(UnusableMasterCondition(fmt.Errorf("dial tcp: connection refused")), "")
+	test.S(t).ExpectEquals(UnusableMasterCondition(&mysql.MySQLError{Number: 1040, Message: "Too many connections"}), UnusableMasterTooManyConnections)
+	test.S(t).ExpectEquals(UnusableMasterCondition(&mysql.MySQLError{Number: 1114, Message: "The table 'orchestrator_heartbeat' is full"}), UnusableMasterDiskFull)
+	test.S(t).ExpectEquals(UnusableMasterCondition(&mysql.MySQLError{Number: 3, Message: "Error writing file './binlog.000012' (Errcode: 28 - No space left on device)"}), UnusableMasterDiskFull)
+	test.S(t).ExpectEquals(UnusableMasterCondition(&mysql.MySQLError{Number: 1030, Message: "Got error 30 - 'Read-only file system' from storage engine"}), UnusableMasterReadOnlyFilesystem)
+	test.S(t).ExpectEquals(UnusableMasterCondition(&mysql.MySQLError{Number: 1290, Message: "The MySQL server is running with the --read-only option"}), "")
+}
+
+func TestUnusableMasterSignalAnalysis(t *testing.T) {
+	tooManyConnections := UnusableMasterSignal{Condition: UnusableMasterTooManyConnections}
+	diskFull := UnusableMasterSignal{Condition: UnusableMasterDiskFull}
+	test.S(t).ExpectEquals(tooManyConnections.Analysis(), AnalysisCode(TooManyConnectionsMaster))
+	test.S(t).ExpectEquals(diskFull.Analysis(), AnalysisCode(DiskFullMaster))
+	test.S(t).ExpectTrue(diskFull.moreSevereThan(&tooManyConnections))
+	test.S(t).ExpectFalse(tooManyConnections.moreSevereThan(&diskFull))
+}
@@ -0,0 +1,151 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+)
+
+// BackendTableVerification compares a table's exported rows with the rows found in the backend after import
+type BackendTableVerification struct {
+	TableName    string
+	ExportedRows int
+	BackendRows  int
+}
+
+// BackendDataVerification is the outcome of verifying an import: every exported row and instance is expected to
+// be found in the backend
+type BackendDataVerification struct {
+	Tables            []BackendTableVerification
+	ExportedInstances int
+	MissingInstances  []inst.InstanceKey
+}
+
+// IsVerified checks whether all exported data was found in the backend. The backend may hold more, when
+// imported into a backend which already had data.
+func (this *BackendDataVerification) IsVerified() bool {
+	for _, table := range this.Tables {
+		if table.BackendRows < table.ExportedRows {
+			return false
+		}
+	}
+	return len(this.MissingInstances) == 0
+}
+
+// createBackendData reads the backend's state, as does a raft snapshot, failing on any unreadable table
+func createBackendData() (*SnapshotData, error) {
+	snapshotData := NewSnapshotData()
+	var err error
+	if snapshotData.MinimalInstances, err = inst.ReadAllMinimalInstances(); err != nil {
+		return nil, err
+	}
+	for _, minimalInstance := range snapshotData.MinimalInstances {
+		snapshotData.Keys = append(snapshotData.Keys, minimalInstance.Key)
+	}
+	if snapshotData.RecoveryDisabled, err = IsRecoveryDisabled(); err != nil {
+		return nil, err
+	}
+	for _, table := range snapshotData.tables() {
+		if err := readTableData(table.TableName, table.Data); err != nil {
+			return nil, fmt.Errorf("Exporting %s: %+v", table.TableName, err)
+		}
+	}
+	return snapshotData, nil
+}
+
+// ExportBackendData writes the backend's state, i.e. instances, cluster aliases, candidates, downtimes, recoveries
+// and the like, as gzipped JSON. This is the format of raft snapshots, and is independent of the backend type.
+func ExportBackendData(writer io.Writer) (*SnapshotData, error) {
+	snapshotData, err := createBackendData()
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	gzipWriter := gzip.NewWriter(writer)
+	if err := json.NewEncoder(gzipWriter).Encode(snapshotData); err != nil {
+		return nil, log.Errore(err)
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return nil, log.Errore(err)
+	}
+	for _, table := range snapshotData.tables() {
+		log.Infof("export-backend-data: %s: %d rows", table.TableName, len(table.Data.Data))
+	}
+	log.Infof("export-backend-data: %d instances", len(snapshotData.MinimalInstances))
+	return snapshotData, nil
+}
+
+// verifyBackendData compares exported data with the backend's state
+func verifyBackendData(snapshotData *SnapshotData) (*BackendDataVerification, error) {
+	verification := &BackendDataVerification{
+		Tables:            []BackendTableVerification{},
+		ExportedInstances: len(snapshotData.MinimalInstances),
+		MissingInstances:  []inst.InstanceKey{},
+	}
+	backendData, err := createBackendData()
+	if err != nil {
+		return nil, err
+	}
+	backendTables := backendData.tables()
+	for i, table := range snapshotData.tables() {
+		verification.Tables = append(verification.Tables, BackendTableVerification{
+			TableName:    table.TableName,
+			ExportedRows: len(table.Data.Data),
+			BackendRows:  len(backendTables[i].Data.Data),
+		})
+	}
+	backendKeys := inst.NewInstanceKeyMap()
+	backendKeys.AddKeys(backendData.Keys)
+	for _, minimalInstance := range snapshotData.MinimalInstances {
+		if !backendKeys.HasKey(minimalInstance.Key) {
+			verification.MissingInstances = append(verification.MissingInstances, minimalInstance.Key)
+		}
+	}
+	return verification, nil
+}
+
+// ImportBackendData reads data written by ExportBackendData, applies it onto the backend as would a raft snapshot
+// restore, and verifies all exported data is found in the backend. Unlike a raft snapshot restore, instances not
+// found in the exported data are kept, and the raft leader is not taken from the exported data. Import into a
+// backend not in use by a running orchestrator service.
+func ImportBackendData(reader io.Reader) (*BackendDataVerification, error) {
+	snapshotData := NewSnapshotData()
+	gzipReader, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if err := json.NewDecoder(gzipReader).Decode(&snapshotData); err != nil {
+		return nil, log.Errore(err)
+	}
+	if err := applySnapshotData(snapshotData, false); err != nil {
+		return nil, log.Errore(err)
+	}
+	verification, err := verifyBackendData(snapshotData)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if !verification.IsVerified() {
+		return verification, fmt.Errorf("Import verification failed: backend is missing exported data")
+	}
+	return verification, nil
+}
@@ -0,0 +1,110 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Minimal data loss mode: before promotion, the dead master's binary log events the promoted replica is missing are
+// fetched from a binlog backup/streaming location (or a surviving binlog server) and applied onto the promoted replica.
+
+var applyBinlogArchiveSuccessCounter = metrics.NewCounter()
+var applyBinlogArchiveFailureCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("recover.dead_master.binlog_archive.success", applyBinlogArchiveSuccessCounter)
+	metrics.Register("recover.dead_master.binlog_archive.fail", applyBinlogArchiveFailureCounter)
+}
+
+// replaceBinlogArchivePlaceholders replaces the placeholders describing the promoted replica's position
+func replaceBinlogArchivePlaceholders(command string, promotedReplica *inst.Instance) string {
+	command = strings.Replace(command, "{candidateHost}", promotedReplica.Key.Hostname, -1)
+	command = strings.Replace(command, "{candidatePort}", fmt.Sprintf("%d", promotedReplica.Key.Port), -1)
+	command = strings.Replace(command, "{candidateExecutedGtidSet}", promotedReplica.ExecutedGtidSet, -1)
+	command = strings.Replace(command, "{candidateMasterLogFile}", promotedReplica.ExecBinlogCoordinates.LogFile, -1)
+	command = strings.Replace(command, "{candidateMasterLogPos}", fmt.Sprintf("%d", promotedReplica.ExecBinlogCoordinates.LogPos), -1)
+	return command
+}
+
+// binlogArchiveEnvironmentVariables adds the variables describing the promoted replica's position
+func binlogArchiveEnvironmentVariables(env []string, promotedReplica *inst.Instance) []string {
+	env = append(env, fmt.Sprintf("ORC_CANDIDATE_HOST=%s", promotedReplica.Key.Hostname))
+	env = append(env, fmt.Sprintf("ORC_CANDIDATE_PORT=%d", promotedReplica.Key.Port))
+	env = append(env, fmt.Sprintf("ORC_CANDIDATE_EXECUTED_GTID_SET=%s", promotedReplica.ExecutedGtidSet))
+	env = append(env, fmt.Sprintf("ORC_CANDIDATE_MASTER_LOG_FILE=%s", promotedReplica.ExecBinlogCoordinates.LogFile))
+	env = append(env, fmt.Sprintf("ORC_CANDIDATE_MASTER_LOG_POS=%d", promotedReplica.ExecBinlogCoordinates.LogPos))
+	return env
+}
+
+// describeAppliedBinlogArchiveRange describes what was applied onto the promoted replica, by its state before and
+// after applying: the GTID entries executed meanwhile, or else the range of its own binary logs written meanwhile.
+func describeAppliedBinlogArchiveRange(before *inst.Instance, after *inst.Instance) string {
+	if before.UsingGTID() && after.ExecutedGtidSet != "" {
+		applied, err := inst.GTIDSubtract(&after.Key, after.ExecutedGtidSet, before.ExecutedGtidSet)
+		if err == nil {
+			if applied == "" {
+				return "no GTID entries applied"
+			}
+			return fmt.Sprintf("applied GTID entries: %s", applied)
+		}
+	}
+	if before.SelfBinlogCoordinates.Equals(&after.SelfBinlogCoordinates) {
+		return "no events applied"
+	}
+	return fmt.Sprintf("applied events logged at %+v to %+v", before.SelfBinlogCoordinates.DisplayString(), after.SelfBinlogCoordinates.DisplayString())
+}
+
+// applyBinlogArchiveTail runs MasterFailoverBinlogArchiveFetchCommand, which fetches the dead master's binary log events
+// the promoted replica is missing and applies them onto it. Replication on the promoted replica is first stopped, once
+// its relay logs are applied, so that its position is final. The applied range is audited.
+func applyBinlogArchiveTail(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (*inst.Instance, error) {
+	timeout := time.Duration(config.Config.ReasonableReplicationLagSeconds) * time.Second
+	before, err := inst.StopSlaveNicely(&promotedReplica.Key, timeout)
+	if err != nil {
+		applyBinlogArchiveFailureCounter.Inc(1)
+		return promotedReplica, err
+	}
+	command := replaceBinlogArchivePlaceholders(config.Config.MasterFailoverBinlogArchiveFetchCommand, before)
+	command = replaceCommandPlaceholders(command, topologyRecovery)
+	env := binlogArchiveEnvironmentVariables(applyEnvironmentVariables(topologyRecovery), before)
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fetching binlog archive tail onto %+v, executed up to %+v: %s", before.Key, before.ExecBinlogCoordinates.DisplayString(), command))
+	start := time.Now()
+	if err := os.CommandRun(command, env); err != nil {
+		applyBinlogArchiveFailureCounter.Inc(1)
+		return before, fmt.Errorf("MasterFailoverBinlogArchiveFetchCommand failed in %v: %+v", time.Since(start), err)
+	}
+	after, err := inst.ReadTopologyInstance(&before.Key)
+	if err != nil {
+		applyBinlogArchiveFailureCounter.Inc(1)
+		return before, err
+	}
+	appliedRange := describeAppliedBinlogArchiveRange(before, after)
+	applyBinlogArchiveSuccessCounter.Inc(1)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: binlog archive tail on %+v completed in %v; %s", after.Key, time.Since(start), appliedRange))
+	inst.AuditOperation("apply-binlog-archive", &after.Key, appliedRange)
+	return after, nil
+}
@@ -0,0 +1,152 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/github/orchestrator/go/agent"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+var binlogWatchdogMastersFailingGauge = metrics.NewGauge()
+
+// binlogWatchdogFindings holds the latest findings per master, so that persisting findings are audited once
+var binlogWatchdogFindings = make(map[inst.InstanceKey]string)
+var binlogWatchdogMutex sync.Mutex
+
+func init() {
+	metrics.Register("binlog_watchdog.masters_failing", binlogWatchdogMastersFailingGauge)
+}
+
+func isBinlogWatchdogEnabled() bool {
+	return config.Config.BinlogRetentionSeconds > 0 || config.Config.BinlogMinDiskHeadroomMB > 0
+}
+
+// readAgentHostnames returns the hosts running orchestrator-agent
+func readAgentHostnames() map[string]bool {
+	agentHostnames := make(map[string]bool)
+	if agents, err := agent.ReadAgents(); err == nil {
+		for _, hostAgent := range agents {
+			agentHostnames[hostAgent.Hostname] = true
+		}
+	}
+	return agentHostnames
+}
+
+// checkMasterBinlogSpace checks a master's binary log retention is at least BinlogRetentionSeconds, and its datadir's
+// free space against BinlogMinDiskHeadroomMB, remediating with BinlogWatchdogMode "enforce". Disk headroom is only
+// checked where orchestrator-agent runs. Returned findings describe the master's failed checks, and the outcome
+// of any remediation.
+func checkMasterBinlogSpace(masterKey *inst.InstanceKey, hasAgent bool) (findings []string, err error) {
+	enforce := config.Config.BinlogWatchdogMode == config.BinlogWatchdogEnforce
+	if expected := config.Config.BinlogRetentionSeconds; expected > 0 {
+		retention, err := inst.ReadBinlogRetentionSeconds(masterKey)
+		if err != nil {
+			return findings, err
+		}
+		// 0 stands for binary logs never expiring; a retention longer than expected is never shortened
+		if retention != 0 && retention < expected {
+			finding := fmt.Sprintf("binary log retention is %d seconds; expecting %d", retention, expected)
+			if enforce {
+				if err := inst.SetBinlogRetentionSeconds(masterKey, expected); err != nil {
+					finding = fmt.Sprintf("%s; failed setting retention: %+v", finding, err)
+				} else {
+					finding = fmt.Sprintf("%s; retention set", finding)
+				}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	if minHeadroom := int64(config.Config.BinlogMinDiskHeadroomMB) * 1024 * 1024; minHeadroom > 0 && hasAgent {
+		diskFree, err := agent.MySQLDatadirDiskFree(masterKey.Hostname)
+		if err != nil {
+			return findings, err
+		}
+		if diskFree < minHeadroom {
+			finding := fmt.Sprintf("%d bytes free on MySQL datadir; expecting at least %d MB", diskFree, config.Config.BinlogMinDiskHeadroomMB)
+			if enforce {
+				if _, err := inst.PurgeAppliedBinaryLogs(masterKey); err != nil {
+					finding = fmt.Sprintf("%s; failed purging binary logs: %+v", finding, err)
+				} else {
+					finding = fmt.Sprintf("%s; purged binary logs applied by all replicas", finding)
+				}
+			}
+			findings = append(findings, finding)
+		}
+	}
+	return findings, nil
+}
+
+// checkPromotedMasterBinlogSpace runs the binary log space watchdog on a newly promoted master, whose retention
+// was configured for a replica. Findings are warnings; it runs asynchronously, off the promotion's critical path.
+func checkPromotedMasterBinlogSpace(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) {
+	if !isBinlogWatchdogEnabled() {
+		return
+	}
+	findings, err := checkMasterBinlogSpace(promotedKey, readAgentHostnames()[promotedKey.Hostname])
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot check binary log space on promoted master: %+v", err))
+	}
+	for _, finding := range findings {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: binary log watchdog: %s", finding))
+		inst.AuditOperation("binlog-watchdog", promotedKey, fmt.Sprintf("recovery %s: %s", topologyRecovery.UID, finding))
+	}
+}
+
+// WatchMastersBinlogSpace runs the binary log space watchdog on all writeable masters. Findings are audited when
+// first found, or when changed.
+func WatchMastersBinlogSpace() error {
+	if !isBinlogWatchdogEnabled() {
+		return nil
+	}
+	masters, err := inst.ReadWriteableClustersMasters()
+	if err != nil {
+		return log.Errore(err)
+	}
+	binlogWatchdogMutex.Lock()
+	defer binlogWatchdogMutex.Unlock()
+
+	agentHostnames := readAgentHostnames()
+	latestFindings := make(map[inst.InstanceKey]string)
+	for _, master := range masters {
+		findings, err := checkMasterBinlogSpace(&master.Key, agentHostnames[master.Key.Hostname])
+		if err != nil {
+			log.Errore(err)
+			continue
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		latestFindings[master.Key] = strings.Join(findings, "; ")
+		if latestFindings[master.Key] == binlogWatchdogFindings[master.Key] {
+			continue
+		}
+		for _, finding := range findings {
+			inst.AuditOperation("binlog-watchdog", &master.Key, finding)
+		}
+	}
+	binlogWatchdogFindings = latestFindings
+	binlogWatchdogMastersFailingGauge.Update(int64(len(latestFindings)))
+	return nil
+}
@@ -0,0 +1,314 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/patrickmn/go-cache"
+	"github.com/rcrowley/go-metrics"
+)
+
+// A canary deployment only takes automated action on clusters matching CanaryClusterFilters. On all
+// other clusters it shadow-evaluates: it logs and persists the action it would have taken, without
+// taking it. The canary report then compares these shadow decisions with the recoveries actually
+// run by the incumbent deployment.
+
+const (
+	CanaryVerdictAgree         = "agree"
+	CanaryVerdictCanaryOnly    = "canary-only"
+	CanaryVerdictIncumbentOnly = "incumbent-only"
+)
+
+const maxCanaryIncumbentPages = 50
+
+var canaryShadowDecisionsCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("canary.shadow_decisions", canaryShadowDecisionsCounter)
+}
+
+// shadowDecisionsMap dedupes shadow decisions on a continuously detected failure, same as a recovery
+// is blocked for RecoveryPeriodBlockSeconds
+var shadowDecisionsMap = cache.New(time.Minute, time.Minute)
+
+var canaryHttpClient = &http.Client{Timeout: 10 * time.Second}
+
+// CanaryShadowDecision is an action a canary deployment would have taken on a non-canary cluster
+type CanaryShadowDecision struct {
+	Id                     int64
+	AnalyzedInstanceKey    inst.InstanceKey
+	ClusterName            string
+	Analysis               inst.AnalysisCode
+	IsActionable           bool
+	WouldRecover           bool
+	AppVersion             string
+	ProcessingNodeHostname string
+	DecisionTimestamp      string
+}
+
+// CanaryComparison pairs a shadow decision with the incumbent's recovery on the same instance, if any
+type CanaryComparison struct {
+	Verdict           string
+	ShadowDecision    *CanaryShadowDecision
+	IncumbentRecovery *TopologyRecovery
+}
+
+// CanaryReport compares a canary deployment's shadow decisions with the incumbent's actual recoveries
+type CanaryReport struct {
+	Since              string
+	IncumbentSource    string
+	CountAgree         int
+	CountCanaryOnly    int
+	CountIncumbentOnly int
+	Comparisons        []CanaryComparison
+}
+
+// isShadowEvaluated checks whether automated action on given analysis is to be shadow-evaluated rather than taken
+func isShadowEvaluated(analysisEntry *inst.ReplicationAnalysis) bool {
+	return config.Config.IsCanary() && !analysisEntry.ClusterDetails.IsCanaryCluster
+}
+
+// shadowWouldRecover checks whether an automated recovery would be attempted for given analysis
+func shadowWouldRecover(analysisEntry *inst.ReplicationAnalysis) bool {
+	if !analysisEntry.IsActionableRecovery {
+		return false
+	}
+	switch analysisEntry.Analysis {
+	case inst.DeadIntermediateMaster,
+		inst.DeadIntermediateMasterAndSomeSlaves,
+		inst.DeadIntermediateMasterWithSingleSlaveFailingToConnect,
+		inst.AllIntermediateMasterSlavesFailingToConnectOrDead:
+		return analysisEntry.ClusterDetails.HasAutomatedIntermediateMasterRecovery
+	}
+	return analysisEntry.ClusterDetails.HasAutomatedMasterRecovery
+}
+
+// recordShadowDecision logs and persists the action which would have been taken on given analysis
+func recordShadowDecision(analysisEntry *inst.ReplicationAnalysis) error {
+	decisionKey := fmt.Sprintf("%s:%s", analysisEntry.AnalyzedInstanceKey.StringCode(), analysisEntry.Analysis)
+	if err := shadowDecisionsMap.Add(decisionKey, true, time.Duration(config.Config.RecoveryPeriodBlockSeconds)*time.Second); err != nil {
+		// Already recorded
+		return nil
+	}
+	wouldRecover := shadowWouldRecover(analysisEntry)
+	canaryShadowDecisionsCounter.Inc(1)
+	log.Infof("canary: shadow evaluating %+v on %+v (cluster %+v is not a canary cluster); wouldRecover: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, analysisEntry.ClusterDetails.ClusterName, wouldRecover)
+
+	_, err := db.ExecOrchestrator(`
+			insert into canary_shadow_decision (
+				hostname, port, cluster_name, analysis, is_actionable, would_recover, app_version, processing_node_hostname, decision_timestamp
+			) values (
+				?, ?, ?, ?, ?, ?, ?, ?, now()
+			)
+		`,
+		analysisEntry.AnalyzedInstanceKey.Hostname,
+		analysisEntry.AnalyzedInstanceKey.Port,
+		analysisEntry.ClusterDetails.ClusterName,
+		string(analysisEntry.Analysis),
+		analysisEntry.IsActionableRecovery,
+		wouldRecover,
+		config.RuntimeCLIFlags.ConfiguredVersion,
+		process.ThisHostname,
+	)
+	return log.Errore(err)
+}
+
+// ReadCanaryShadowDecisions returns shadow decisions made in the last given number of seconds
+func ReadCanaryShadowDecisions(seconds uint) ([]CanaryShadowDecision, error) {
+	res := []CanaryShadowDecision{}
+	query := `
+		select
+			decision_id,
+			hostname,
+			port,
+			cluster_name,
+			analysis,
+			is_actionable,
+			would_recover,
+			app_version,
+			processing_node_hostname,
+			decision_timestamp
+		from
+			canary_shadow_decision
+		where
+			decision_timestamp >= now() - interval ? second
+		order by
+			decision_id desc
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(seconds), func(m sqlutils.RowMap) error {
+		decision := CanaryShadowDecision{}
+		decision.Id = m.GetInt64("decision_id")
+		decision.AnalyzedInstanceKey.Hostname = m.GetString("hostname")
+		decision.AnalyzedInstanceKey.Port = m.GetInt("port")
+		decision.ClusterName = m.GetString("cluster_name")
+		decision.Analysis = inst.AnalysisCode(m.GetString("analysis"))
+		decision.IsActionable = m.GetBool("is_actionable")
+		decision.WouldRecover = m.GetBool("would_recover")
+		decision.AppVersion = m.GetString("app_version")
+		decision.ProcessingNodeHostname = m.GetString("processing_node_hostname")
+		decision.DecisionTimestamp = m.GetString("decision_timestamp")
+		res = append(res, decision)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpireCanaryShadowDecisions removes old rows from the canary_shadow_decision table
+func ExpireCanaryShadowDecisions() error {
+	return inst.ExpireTableData("canary_shadow_decision", "decision_timestamp")
+}
+
+// readIncumbentRecoveries reads recoveries run by the incumbent deployment since given time.
+func readIncumbentRecoveries(since time.Time) (recoveries []*TopologyRecovery, err error) {
+	if config.Config.CanaryIncumbentURL == "" {
+		// Incumbent shares our backend
+		localRecoveries, err := readRecoveries(`where start_active_period >= ?`, ``, sqlutils.Args(since.Format("2006-01-02 15:04:05")))
+		for i := range localRecoveries {
+			recoveries = append(recoveries, &localRecoveries[i])
+		}
+		return recoveries, err
+	}
+	baseURL := strings.TrimRight(config.Config.CanaryIncumbentURL, "/")
+	for page := 0; page < maxCanaryIncumbentPages; page++ {
+		pageRecoveries, err := fetchIncumbentRecoveries(fmt.Sprintf("%s/api/audit-recovery/%d", baseURL, page))
+		if err != nil {
+			return recoveries, err
+		}
+		if len(pageRecoveries) == 0 {
+			return recoveries, nil
+		}
+		for i := range pageRecoveries {
+			recovery := &pageRecoveries[i]
+			if startTime, err := parseCanaryTimestamp(recovery.RecoveryStartTimestamp); err == nil && startTime.Before(since) {
+				// Recoveries are listed latest first; we're done
+				return recoveries, nil
+			}
+			recoveries = append(recoveries, recovery)
+		}
+	}
+	return recoveries, nil
+}
+
+func fetchIncumbentRecoveries(url string) (recoveries []TopologyRecovery, err error) {
+	response, err := canaryHttpClient.Get(url)
+	if err != nil {
+		return recoveries, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return recoveries, fmt.Errorf("canary: got %d reading incumbent recoveries from %s", response.StatusCode, url)
+	}
+	err = json.NewDecoder(response.Body).Decode(&recoveries)
+	return recoveries, err
+}
+
+func parseCanaryTimestamp(timestamp string) (time.Time, error) {
+	return time.ParseInLocation("2006-01-02 15:04:05", timestamp, time.Local)
+}
+
+// compareCanaryDecisions matches shadow decisions with incumbent recoveries on the same instance, started
+// within given tolerance of the decision. Incumbent recoveries on canary clusters are not compared, since
+// the canary acts on those by itself.
+func compareCanaryDecisions(decisions []CanaryShadowDecision, recoveries []*TopologyRecovery, tolerance time.Duration, isCanaryCluster func(recovery *TopologyRecovery) bool) (comparisons []CanaryComparison) {
+	matchedRecoveries := make(map[int]bool)
+	for i := range decisions {
+		decision := &decisions[i]
+		comparison := CanaryComparison{ShadowDecision: decision}
+		decisionTime, decisionTimeErr := parseCanaryTimestamp(decision.DecisionTimestamp)
+		for j, recovery := range recoveries {
+			if matchedRecoveries[j] || !recovery.AnalysisEntry.AnalyzedInstanceKey.Equals(&decision.AnalyzedInstanceKey) {
+				continue
+			}
+			if decisionTimeErr == nil {
+				startTime, err := parseCanaryTimestamp(recovery.RecoveryStartTimestamp)
+				if err != nil || startTime.Sub(decisionTime) > tolerance || decisionTime.Sub(startTime) > tolerance {
+					continue
+				}
+			}
+			matchedRecoveries[j] = true
+			comparison.IncumbentRecovery = recovery
+			break
+		}
+		switch {
+		case decision.WouldRecover == (comparison.IncumbentRecovery != nil):
+			comparison.Verdict = CanaryVerdictAgree
+		case decision.WouldRecover:
+			comparison.Verdict = CanaryVerdictCanaryOnly
+		default:
+			comparison.Verdict = CanaryVerdictIncumbentOnly
+		}
+		comparisons = append(comparisons, comparison)
+	}
+	for j, recovery := range recoveries {
+		if matchedRecoveries[j] || isCanaryCluster(recovery) {
+			continue
+		}
+		// The incumbent recovered where the canary did not even detect a failure
+		comparisons = append(comparisons, CanaryComparison{Verdict: CanaryVerdictIncumbentOnly, IncumbentRecovery: recovery})
+	}
+	return comparisons
+}
+
+// GetCanaryReport compares shadow decisions made in the last given number of seconds with the
+// recoveries the incumbent deployment ran in the same time frame
+func GetCanaryReport(seconds uint) (report *CanaryReport, err error) {
+	since := time.Now().Add(-time.Duration(seconds) * time.Second)
+	report = &CanaryReport{
+		Since:           since.Format("2006-01-02 15:04:05"),
+		IncumbentSource: config.Config.CanaryIncumbentURL,
+	}
+	if report.IncumbentSource == "" {
+		report.IncumbentSource = "backend"
+	}
+	decisions, err := ReadCanaryShadowDecisions(seconds)
+	if err != nil {
+		return report, err
+	}
+	recoveries, err := readIncumbentRecoveries(since)
+	if err != nil {
+		return report, log.Errore(err)
+	}
+	isCanaryCluster := func(recovery *TopologyRecovery) bool {
+		clusterInfo := recovery.AnalysisEntry.ClusterDetails
+		clusterInfo.ReadRecoveryInfo()
+		return clusterInfo.IsCanaryCluster
+	}
+	tolerance := time.Duration(config.Config.RecoveryPeriodBlockSeconds) * time.Second
+	report.Comparisons = compareCanaryDecisions(decisions, recoveries, tolerance, isCanaryCluster)
+	for _, comparison := range report.Comparisons {
+		switch comparison.Verdict {
+		case CanaryVerdictAgree:
+			report.CountAgree++
+		case CanaryVerdictCanaryOnly:
+			report.CountCanaryOnly++
+		case CanaryVerdictIncumbentOnly:
+			report.CountIncumbentOnly++
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,47 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+)
+
+// SetClusterPriorityTier assigns a recovery priority tier to a cluster alias, or removes the assignment
+// given an empty tier; this is raft-aware
+func SetClusterPriorityTier(clusterAlias string, tier string) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("set-cluster-priority-tier", []string{clusterAlias, tier})
+		return err
+	}
+	return inst.WriteClusterPriorityTier(clusterAlias, tier)
+}
+
+// prioritizedAnalysisOrder returns the order in which to process given analysis entries: by the
+// priority tier of their clusters, and in random order within a tier. This only orders the launch of
+// recoveries, which then run concurrently; tiers are enforced by the recovery queue.
+func prioritizedAnalysisOrder(replicationAnalysis []inst.ReplicationAnalysis) []int {
+	order := rand.Perm(len(replicationAnalysis))
+	sort.SliceStable(order, func(i, j int) bool {
+		return inst.ClusterPriorityTierRank(replicationAnalysis[order[i]].ClusterDetails.PriorityTier) <
+			inst.ClusterPriorityTierRank(replicationAnalysis[order[j]].ClusterDetails.PriorityTier)
+	})
+	return order
+}
@@ -0,0 +1,55 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// SetClusterTemplate assigns a template to a cluster; this is raft-aware
+func SetClusterTemplate(clusterName string, templateName string) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("apply-cluster-template", []string{clusterName, templateName})
+		return err
+	}
+	return inst.ApplyClusterTemplate(clusterName, templateName)
+}
+
+// ApplyClusterTemplates assigns templates to newly discovered clusters, based on the templates'
+// master patterns. A cluster which matches no template is re-evaluated on next run; a cluster which
+// has been assigned a template keeps it even if patterns change later on.
+func ApplyClusterTemplates() error {
+	if len(config.Config.ClusterTemplates) == 0 {
+		return nil
+	}
+	masterKeys, err := inst.ReadUnassignedClusterMasters()
+	if err != nil {
+		return err
+	}
+	for _, masterKey := range masterKeys {
+		if template := inst.MatchClusterTemplate(&masterKey); template != nil {
+			if err := SetClusterTemplate(masterKey.StringCode(), template.Name); err != nil {
+				log.Errorf("ApplyClusterTemplates: cannot assign template %s to cluster %s: %+v", template.Name, masterKey.StringCode(), err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// WriteClusterTopologySnapshots persists the current topology of all known clusters; this is raft-aware,
+// so that the topology history is kept by all raft members
+func WriteClusterTopologySnapshots() error {
+	snapshots, err := inst.ReadCurrentClusterTopologySnapshots()
+	if err != nil {
+		return err
+	}
+	for _, snapshot := range snapshots {
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("write-cluster-topology-snapshot", snapshot)
+		} else {
+			err = inst.WriteClusterTopologySnapshot(snapshot)
+		}
+		log.Errore(err)
+	}
+	return nil
+}
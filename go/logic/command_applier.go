@@ -19,6 +19,7 @@ package logic
 import (
 	"encoding/json"
 
+	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	"github.com/github/orchestrator/go/raft"
@@ -55,8 +56,14 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.beginDowntime(value)
 	case "end-downtime":
 		return applier.endDowntime(value)
+	case "begin-no-touch":
+		return applier.beginNoTouch(value)
+	case "end-no-touch":
+		return applier.endNoTouch(value)
 	case "register-candidate":
 		return applier.registerCandidate(value)
+	case "unregister-candidate":
+		return applier.unregisterCandidate(value)
 	case "ack-recovery":
 		return applier.ackRecovery(value)
 	case "register-hostname-unresolve":
@@ -69,6 +76,12 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.writeRecovery(value)
 	case "write-recovery-step":
 		return applier.writeRecoveryStep(value)
+	case "write-recovery-journal":
+		return applier.writeRecoveryJournal(value)
+	case "write-recovery-topology":
+		return applier.writeRecoveryTopology(value)
+	case "write-postponed-function":
+		return applier.writePostponedFunction(value)
 	case "resolve-recovery":
 		return applier.resolveRecovery(value)
 	case "disable-global-recoveries":
@@ -87,6 +100,50 @@ func (applier *CommandApplier) ApplyCommand(op string, value []byte) interface{}
 		return applier.healthReport(value)
 	case "set-cluster-alias-manual-override":
 		return applier.setClusterAliasManualOverride(value)
+	case "apply-cluster-template":
+		return applier.applyClusterTemplate(value)
+	case "set-cluster-priority-tier":
+		return applier.setClusterPriorityTier(value)
+	case "ack-master-flapping":
+		return applier.ackMasterFlapping(value)
+	case "write-scheduled-master-takeover":
+		return applier.writeScheduledMasterTakeover(value)
+	case "write-replica-provisioning-request":
+		return applier.writeReplicaProvisioningRequest(value)
+	case "begin-cluster-maintenance":
+		return applier.beginClusterMaintenance(value)
+	case "end-cluster-maintenance":
+		return applier.endClusterMaintenance(value)
+	case "write-downtime-schedule":
+		return applier.writeDowntimeSchedule(value)
+	case "delete-downtime-schedule":
+		return applier.deleteDowntimeSchedule(value)
+	case "write-dashboard":
+		return applier.writeDashboard(value)
+	case "delete-dashboard":
+		return applier.deleteDashboard(value)
+	case "write-problem-suppression":
+		return applier.writeProblemSuppression(value)
+	case "delete-problem-suppression":
+		return applier.deleteProblemSuppression(value)
+	case "write-analysis-rule":
+		return applier.writeAnalysisRule(value)
+	case "delete-analysis-rule":
+		return applier.deleteAnalysisRule(value)
+	case "write-hostname-rule":
+		return applier.writeHostnameRule(value)
+	case "delete-hostname-rule":
+		return applier.deleteHostnameRule(value)
+	case "write-reported-address":
+		return applier.writeReportedAddress(value)
+	case "delete-reported-address":
+		return applier.deleteReportedAddress(value)
+	case "write-membership-violations":
+		return applier.writeMembershipViolations(value)
+	case "write-cluster-topology-snapshot":
+		return applier.writeClusterTopologySnapshot(value)
+	case "register-recovery-escalation":
+		return applier.registerRecoveryEscalation(value)
 	}
 	return log.Errorf("Unknown command op: %s", op)
 }
@@ -154,6 +211,24 @@ func (applier *CommandApplier) endDowntime(value []byte) interface{} {
 	return err
 }
 
+func (applier *CommandApplier) beginNoTouch(value []byte) interface{} {
+	lock := inst.NoTouchLock{}
+	if err := json.Unmarshal(value, &lock); err != nil {
+		return log.Errore(err)
+	}
+	err := inst.BeginNoTouch(&lock)
+	return err
+}
+
+func (applier *CommandApplier) endNoTouch(value []byte) interface{} {
+	instanceKey := inst.InstanceKey{}
+	if err := json.Unmarshal(value, &instanceKey); err != nil {
+		return log.Errore(err)
+	}
+	_, err := inst.EndNoTouch(&instanceKey)
+	return err
+}
+
 func (applier *CommandApplier) registerCandidate(value []byte) interface{} {
 	candidate := inst.CandidateDatabaseInstance{}
 	if err := json.Unmarshal(value, &candidate); err != nil {
@@ -163,12 +238,25 @@ func (applier *CommandApplier) registerCandidate(value []byte) interface{} {
 	return err
 }
 
+func (applier *CommandApplier) unregisterCandidate(value []byte) interface{} {
+	instanceKey := inst.InstanceKey{}
+	if err := json.Unmarshal(value, &instanceKey); err != nil {
+		return log.Errore(err)
+	}
+	_, err := inst.UnregisterCandidateInstance(&instanceKey)
+	return err
+}
+
 func (applier *CommandApplier) ackRecovery(value []byte) interface{} {
 	ack := RecoveryAcknowledgement{}
 	err := json.Unmarshal(value, &ack)
 	if err != nil {
 		return log.Errore(err)
 	}
+	if ack.Analysis != "" {
+		_, err = AcknowledgeAnalysisRecoveries(ack.Analysis, ack.ClusterName, ack.FromTimestamp, ack.ToTimestamp, ack.Owner, ack.Comment)
+		return err
+	}
 	if ack.AllRecoveries {
 		_, err = AcknowledgeAllRecoveries(ack.Owner, ack.Comment)
 	}
@@ -234,6 +322,30 @@ func (applier *CommandApplier) writeRecoveryStep(value []byte) interface{} {
 	return err
 }
 
+func (applier *CommandApplier) writeRecoveryJournal(value []byte) interface{} {
+	entry := RecoveryJournalEntry{}
+	if err := json.Unmarshal(value, &entry); err != nil {
+		return log.Errore(err)
+	}
+	return writeRecoveryJournalEntry(&entry)
+}
+
+func (applier *CommandApplier) writeRecoveryTopology(value []byte) interface{} {
+	recoveryTopology := RecoveryTopologySnapshot{}
+	if err := json.Unmarshal(value, &recoveryTopology); err != nil {
+		return log.Errore(err)
+	}
+	return writeRecoveryTopologySnapshot(&recoveryTopology)
+}
+
+func (applier *CommandApplier) writePostponedFunction(value []byte) interface{} {
+	postponedFunction := PostponedFunction{}
+	if err := json.Unmarshal(value, &postponedFunction); err != nil {
+		return log.Errore(err)
+	}
+	return writePostponedFunction(&postponedFunction)
+}
+
 func (applier *CommandApplier) resolveRecovery(value []byte) interface{} {
 	topologyRecovery := TopologyRecovery{}
 	if err := json.Unmarshal(value, &topologyRecovery); err != nil {
@@ -309,3 +421,190 @@ func (applier *CommandApplier) setClusterAliasManualOverride(value []byte) inter
 	err := inst.SetClusterAliasManualOverride(clusterName, alias)
 	return err
 }
+
+func (applier *CommandApplier) applyClusterTemplate(value []byte) interface{} {
+	var params [2]string
+	if err := json.Unmarshal(value, &params); err != nil {
+		return log.Errore(err)
+	}
+	clusterName, templateName := params[0], params[1]
+	err := inst.ApplyClusterTemplate(clusterName, templateName)
+	return err
+}
+
+func (applier *CommandApplier) setClusterPriorityTier(value []byte) interface{} {
+	var params [2]string
+	if err := json.Unmarshal(value, &params); err != nil {
+		return log.Errore(err)
+	}
+	clusterAlias, tier := params[0], params[1]
+	err := inst.WriteClusterPriorityTier(clusterAlias, tier)
+	return err
+}
+
+func (applier *CommandApplier) ackMasterFlapping(value []byte) interface{} {
+	var params [3]string
+	if err := json.Unmarshal(value, &params); err != nil {
+		return log.Errore(err)
+	}
+	clusterAlias, owner, comment := params[0], params[1], params[2]
+	err := inst.AcknowledgeMasterFlapping(clusterAlias, owner, comment)
+	return err
+}
+
+func (applier *CommandApplier) writeScheduledMasterTakeover(value []byte) interface{} {
+	takeover := ScheduledMasterTakeover{}
+	if err := json.Unmarshal(value, &takeover); err != nil {
+		return log.Errore(err)
+	}
+	return writeScheduledMasterTakeover(&takeover)
+}
+
+func (applier *CommandApplier) writeReplicaProvisioningRequest(value []byte) interface{} {
+	request := ReplicaProvisioningRequest{}
+	if err := json.Unmarshal(value, &request); err != nil {
+		return log.Errore(err)
+	}
+	return writeReplicaProvisioningRequest(&request)
+}
+
+func (applier *CommandApplier) writeDowntimeSchedule(value []byte) interface{} {
+	schedule := inst.DowntimeSchedule{}
+	if err := json.Unmarshal(value, &schedule); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteDowntimeSchedule(&schedule)
+}
+
+func (applier *CommandApplier) deleteDowntimeSchedule(value []byte) interface{} {
+	var scheduleId string
+	if err := json.Unmarshal(value, &scheduleId); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteDowntimeSchedule(scheduleId)
+}
+
+func (applier *CommandApplier) beginClusterMaintenance(value []byte) interface{} {
+	maintenance := inst.ClusterMaintenance{}
+	if err := json.Unmarshal(value, &maintenance); err != nil {
+		return log.Errore(err)
+	}
+	return inst.BeginClusterMaintenance(&maintenance)
+}
+
+func (applier *CommandApplier) endClusterMaintenance(value []byte) interface{} {
+	var clusterAlias string
+	if err := json.Unmarshal(value, &clusterAlias); err != nil {
+		return log.Errore(err)
+	}
+	_, err := inst.EndClusterMaintenance(clusterAlias)
+	return err
+}
+
+func (applier *CommandApplier) writeDashboard(value []byte) interface{} {
+	dashboard := inst.Dashboard{}
+	if err := json.Unmarshal(value, &dashboard); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteDashboard(&dashboard)
+}
+
+func (applier *CommandApplier) deleteDashboard(value []byte) interface{} {
+	dashboard := inst.Dashboard{}
+	if err := json.Unmarshal(value, &dashboard); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteDashboard(dashboard.Owner, dashboard.Name)
+}
+
+func (applier *CommandApplier) writeProblemSuppression(value []byte) interface{} {
+	suppression := inst.ProblemSuppression{}
+	if err := json.Unmarshal(value, &suppression); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteProblemSuppression(&suppression)
+}
+
+func (applier *CommandApplier) deleteProblemSuppression(value []byte) interface{} {
+	var suppressionId string
+	if err := json.Unmarshal(value, &suppressionId); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteProblemSuppression(suppressionId)
+}
+
+func (applier *CommandApplier) writeAnalysisRule(value []byte) interface{} {
+	rule := config.AnalysisRule{}
+	if err := json.Unmarshal(value, &rule); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteAnalysisRule(&rule)
+}
+
+func (applier *CommandApplier) deleteAnalysisRule(value []byte) interface{} {
+	var code string
+	if err := json.Unmarshal(value, &code); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteAnalysisRule(code)
+}
+
+func (applier *CommandApplier) writeHostnameRule(value []byte) interface{} {
+	rule := config.HostnameRule{}
+	if err := json.Unmarshal(value, &rule); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteHostnameRule(&rule)
+}
+
+func (applier *CommandApplier) deleteHostnameRule(value []byte) interface{} {
+	var name string
+	if err := json.Unmarshal(value, &name); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteHostnameRule(name)
+}
+
+func (applier *CommandApplier) writeReportedAddress(value []byte) interface{} {
+	address := inst.ReportedAddress{}
+	if err := json.Unmarshal(value, &address); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteReportedAddress(&address)
+}
+
+func (applier *CommandApplier) deleteReportedAddress(value []byte) interface{} {
+	instanceKey := inst.InstanceKey{}
+	if err := json.Unmarshal(value, &instanceKey); err != nil {
+		return log.Errore(err)
+	}
+	return inst.DeleteReportedAddress(&instanceKey)
+}
+
+func (applier *CommandApplier) writeMembershipViolations(value []byte) interface{} {
+	violations := []inst.MembershipViolation{}
+	if err := json.Unmarshal(value, &violations); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteMembershipViolations(violations)
+}
+
+func (applier *CommandApplier) writeClusterTopologySnapshot(value []byte) interface{} {
+	snapshot := inst.TopologySnapshot{}
+	if err := json.Unmarshal(value, &snapshot); err != nil {
+		return log.Errore(err)
+	}
+	return inst.WriteClusterTopologySnapshot(&snapshot)
+}
+
+func (applier *CommandApplier) registerRecoveryEscalation(value []byte) interface{} {
+	escalation := RecoveryEscalation{}
+	if err := json.Unmarshal(value, &escalation); err != nil {
+		return log.Errore(err)
+	}
+	registered, err := registerRecoveryEscalation(&escalation)
+	if err != nil {
+		return err
+	}
+	return registered
+}
@@ -0,0 +1,146 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/proxysql"
+
+	"github.com/openark/golib/log"
+)
+
+// drainWebhookTimeout caps the time given to a drain webhook request
+const drainWebhookTimeout = 10 * time.Second
+
+// Actions of drain webhook requests
+const (
+	DrainActionDrain   = "drain"
+	DrainActionUndrain = "undrain"
+)
+
+// DrainWebhookRequest is POSTed to GracefulTakeoverDrainWebhookURL by the "webhook" drain method
+type DrainWebhookRequest struct {
+	Action           string
+	Hostname         string
+	Port             int
+	ClusterName      string
+	ClusterAlias     string
+	OrchestratorHost string
+}
+
+func drainViaWebhook(clusterInfo *inst.ClusterInfo, masterKey *inst.InstanceKey, action string) error {
+	body, err := json.Marshal(&DrainWebhookRequest{
+		Action:           action,
+		Hostname:         masterKey.Hostname,
+		Port:             masterKey.Port,
+		ClusterName:      clusterInfo.ClusterName,
+		ClusterAlias:     clusterInfo.ClusterAlias,
+		OrchestratorHost: process.ThisHostname,
+	})
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: drainWebhookTimeout}
+	response, err := httpClient.Post(config.Config.GracefulTakeoverDrainWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("drain webhook: unexpected status %d: %s", response.StatusCode, string(responseBody))
+	}
+	return nil
+}
+
+// drainVia drains, or undrains, a master at the proxy of given method
+func drainVia(method string, clusterInfo *inst.ClusterInfo, masterKey *inst.InstanceKey, action string) error {
+	switch method {
+	case "proxysql":
+		status := proxysql.ServerStatusOfflineSoft
+		if action == DrainActionUndrain {
+			status = proxysql.ServerStatusOnline
+		}
+		return proxysql.SetServerStatus(masterKey.Hostname, masterKey.Port, status)
+	case "webhook":
+		return drainViaWebhook(clusterInfo, masterKey, action)
+	}
+	return fmt.Errorf("Unknown drain method: %s", method)
+}
+
+// undrainMaster returns a master to service at the proxies of all GracefulTakeoverDrainMethods. Failures are
+// audited, and do not stop other proxies from being undrained.
+func undrainMaster(clusterInfo *inst.ClusterInfo, masterKey *inst.InstanceKey) {
+	for _, method := range config.Config.GracefulTakeoverDrainMethods {
+		if err := drainVia(method, clusterInfo, masterKey, DrainActionUndrain); err != nil {
+			log.Errorf("GracefulMasterTakeover: failed undraining %+v via %s: %+v", *masterKey, method, err)
+			inst.AuditOperation("graceful-master-takeover-undrain", masterKey, fmt.Sprintf("failed via %s: %+v", method, err))
+			continue
+		}
+		inst.AuditOperation("graceful-master-takeover-undrain", masterKey, fmt.Sprintf("undrained via %s", method))
+	}
+}
+
+// drainMaster drains a master at the proxies of all GracefulTakeoverDrainMethods, then waits for its client
+// connections to fall to GracefulTakeoverDrainMaxConnections. A master which fails to drain, or, unless
+// GracefulTakeoverDrainProceedOnTimeout, does not drain in time, is undrained, and an error returned.
+// drained tells whether the master was left drained.
+func drainMaster(clusterInfo *inst.ClusterInfo, masterKey *inst.InstanceKey) (drained bool, err error) {
+	if len(config.Config.GracefulTakeoverDrainMethods) == 0 {
+		return false, nil
+	}
+	for _, method := range config.Config.GracefulTakeoverDrainMethods {
+		if err := drainVia(method, clusterInfo, masterKey, DrainActionDrain); err != nil {
+			undrainMaster(clusterInfo, masterKey)
+			return false, fmt.Errorf("GracefulMasterTakeover: failed draining %+v via %s: %+v", *masterKey, method, err)
+		}
+		inst.AuditOperation("graceful-master-takeover-drain", masterKey, fmt.Sprintf("drained via %s", method))
+	}
+
+	maxConnections := int(config.Config.GracefulTakeoverDrainMaxConnections)
+	timeout := time.Duration(config.Config.GracefulTakeoverDrainTimeoutSeconds) * time.Second
+	start := time.Now()
+	for {
+		connections, err := inst.CountClientConnections(masterKey)
+		if err == nil && connections <= maxConnections {
+			inst.AuditOperation("graceful-master-takeover-drain", masterKey, fmt.Sprintf("%d client connections after %v", connections, time.Since(start)))
+			return true, nil
+		}
+		if time.Since(start) >= timeout {
+			if err == nil {
+				err = fmt.Errorf("%d client connections remain; expecting at most %d", connections, maxConnections)
+			}
+			if config.Config.GracefulTakeoverDrainProceedOnTimeout {
+				inst.AuditOperation("graceful-master-takeover-drain", masterKey, fmt.Sprintf("timed out after %v: %+v; proceeding", timeout, err))
+				return true, nil
+			}
+			undrainMaster(clusterInfo, masterKey)
+			return false, fmt.Errorf("GracefulMasterTakeover: %+v did not drain in %v: %+v", *masterKey, timeout, err)
+		}
+		log.Debugf("GracefulMasterTakeover: waiting for %+v to drain: %d client connections", *masterKey, connections)
+		time.Sleep(time.Second)
+	}
+}
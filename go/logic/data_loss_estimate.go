@@ -0,0 +1,123 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// DataLossEstimate is the gap between a dead master's last known position, as last polled by orchestrator, and the
+// most advanced surviving replica. Events the master wrote after it was last polled are unknown, hence this is
+// a lower bound.
+type DataLossEstimate struct {
+	MasterKey                      inst.InstanceKey
+	LastKnownMasterCoordinates     inst.BinlogCoordinates
+	LastKnownMasterExecutedGtidSet string
+	ReplicaKey                     inst.InstanceKey
+	ReplicaCoordinates             inst.BinlogCoordinates
+	MissingGtidSet                 string
+	MissingTransactions            int64 // -1 when unknown, e.g. without GTID
+	MissingBinlogFiles             int
+	MissingBytes                   int64 // -1 when unknown: the replica executes a different binary log than the master's last
+}
+
+// HasDataLoss returns true when the surviving replica is known to be behind the master's last known position
+func (this *DataLossEstimate) HasDataLoss() bool {
+	return this.MissingTransactions > 0 || this.MissingBinlogFiles > 0 || this.MissingBytes > 0
+}
+
+// String returns a user-friendly description of the estimate
+func (this *DataLossEstimate) String() string {
+	if this == nil {
+		return "unknown"
+	}
+	if !this.HasDataLoss() {
+		return fmt.Sprintf("none: %+v executed up to %+v's last known coordinates %+v", this.ReplicaKey, this.MasterKey, this.LastKnownMasterCoordinates.DisplayString())
+	}
+	tokens := []string{}
+	if this.MissingTransactions >= 0 {
+		tokens = append(tokens, fmt.Sprintf("%d transactions (%s)", this.MissingTransactions, this.MissingGtidSet))
+	}
+	if this.MissingBytes >= 0 {
+		tokens = append(tokens, fmt.Sprintf("%d bytes", this.MissingBytes))
+	} else {
+		tokens = append(tokens, fmt.Sprintf("%d binary logs", this.MissingBinlogFiles))
+	}
+	return fmt.Sprintf("%s: %+v executed up to %+v; %+v's last known coordinates are %+v", strings.Join(tokens, ", "),
+		this.ReplicaKey, this.ReplicaCoordinates.DisplayString(), this.MasterKey, this.LastKnownMasterCoordinates.DisplayString())
+}
+
+// estimateDataLoss compares the dead master's last known position with that of the most advanced surviving replica
+// still replicating from it: the promoted replica, or a replica lost by being ahead of it.
+func estimateDataLoss(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (*DataLossEstimate, error) {
+	failedMasterKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	master, found, err := inst.ReadInstance(failedMasterKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("estimateDataLoss: no record of %+v", *failedMasterKey)
+	}
+	mostAdvancedReplica := promotedReplica
+	for _, lostReplicaKey := range topologyRecovery.LostReplicas.GetInstanceKeys() {
+		lostReplica, found, err := inst.ReadInstance(&lostReplicaKey)
+		if err != nil || !found {
+			continue
+		}
+		if !lostReplica.MasterKey.Equals(failedMasterKey) {
+			// Moved elsewhere; its coordinates do not compare
+			continue
+		}
+		if !mostAdvancedReplica.MasterKey.Equals(failedMasterKey) || mostAdvancedReplica.ExecBinlogCoordinates.SmallerThan(&lostReplica.ExecBinlogCoordinates) {
+			mostAdvancedReplica = lostReplica
+		}
+	}
+
+	estimate := &DataLossEstimate{
+		MasterKey:                      master.Key,
+		LastKnownMasterCoordinates:     master.SelfBinlogCoordinates,
+		LastKnownMasterExecutedGtidSet: master.ExecutedGtidSet,
+		ReplicaKey:                     mostAdvancedReplica.Key,
+		ReplicaCoordinates:             mostAdvancedReplica.ExecBinlogCoordinates,
+		MissingTransactions:            -1,
+		MissingBytes:                   -1,
+	}
+	if mostAdvancedReplica.MasterKey.Equals(failedMasterKey) {
+		replicaCoordinates := &mostAdvancedReplica.ExecBinlogCoordinates
+		if replicaCoordinates.SmallerThan(&master.SelfBinlogCoordinates) {
+			estimate.MissingBinlogFiles = replicaCoordinates.FileNumberDistance(&master.SelfBinlogCoordinates)
+			if replicaCoordinates.LogFile == master.SelfBinlogCoordinates.LogFile {
+				estimate.MissingBytes = master.SelfBinlogCoordinates.LogPos - replicaCoordinates.LogPos
+			}
+		} else {
+			estimate.MissingBytes = 0
+		}
+	}
+	if master.ExecutedGtidSet != "" && mostAdvancedReplica.UsingOracleGTID {
+		if missingGtidSet, err := inst.GTIDSubtract(&mostAdvancedReplica.Key, master.ExecutedGtidSet, mostAdvancedReplica.ExecutedGtidSet); err == nil {
+			estimate.MissingGtidSet = missingGtidSet
+			estimate.MissingTransactions = 0
+			if gtidSet, err := inst.NewOracleGtidSet(missingGtidSet); err == nil {
+				estimate.MissingTransactions = gtidSet.Count()
+			}
+		}
+	}
+	return estimate, nil
+}
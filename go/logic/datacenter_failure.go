@@ -0,0 +1,273 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/process"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+// DatacenterFailure is a failure of many masters of a single data center at once, recovered in bulk
+// rather than by independent recoveries
+type DatacenterFailure struct {
+	DataCenter          string
+	DetectedAt          time.Time
+	CompletedAt         time.Time
+	FailedMasters       []inst.InstanceKey
+	FailedClusters      []string
+	RecoveredClusters   []string
+	UnrecoveredClusters []string
+	SkippedClusters     []string // clusters whose master came back, or was otherwise recovered, before its turn
+}
+
+// IsCompleted returns true once the bulk recovery of this failure is done
+func (this *DatacenterFailure) IsCompleted() bool {
+	return !this.CompletedAt.IsZero()
+}
+
+var datacenterFailures = make(map[string]*DatacenterFailure)
+var datacenterFailuresMutex = &sync.Mutex{}
+
+var recoverDatacenterFailureCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("recover.datacenter_failure", recoverDatacenterFailureCounter)
+}
+
+// isDeadMasterAnalysis checks whether given analysis is of a dead master, as counted towards a data center failure
+func isDeadMasterAnalysis(analysisEntry *inst.ReplicationAnalysis) bool {
+	switch analysisEntry.Analysis {
+	case inst.DeadMaster, inst.DeadMasterAndSomeSlaves, inst.DeadMasterWithoutSlaves:
+		return true
+	}
+	return false
+}
+
+// detectDatacenterFailures groups dead master analyses by data center, and returns those data centers
+// with enough dead masters to be considered failed
+func detectDatacenterFailures(replicationAnalysis []inst.ReplicationAnalysis) map[string][]inst.ReplicationAnalysis {
+	failedDataCenters := make(map[string][]inst.ReplicationAnalysis)
+	if config.Config.DatacenterFailureMinDeadMasters == 0 {
+		return failedDataCenters
+	}
+	deadMastersByDataCenter := make(map[string][]inst.ReplicationAnalysis)
+	for _, analysisEntry := range replicationAnalysis {
+		if analysisEntry.AnalyzedInstanceDataCenter == "" || analysisEntry.SkippableDueToDowntime {
+			continue
+		}
+		if isDeadMasterAnalysis(&analysisEntry) {
+			deadMastersByDataCenter[analysisEntry.AnalyzedInstanceDataCenter] = append(deadMastersByDataCenter[analysisEntry.AnalyzedInstanceDataCenter], analysisEntry)
+		}
+	}
+	for dataCenter, deadMasters := range deadMastersByDataCenter {
+		if uint(len(deadMasters)) >= config.Config.DatacenterFailureMinDeadMasters {
+			failedDataCenters[dataCenter] = deadMasters
+		}
+	}
+	return failedDataCenters
+}
+
+// checkAndRecoverDatacenterFailures starts a bulk recovery for each newly failed data center, and returns
+// the keys of dead masters handled by bulk recoveries; these are not to be recovered independently.
+func checkAndRecoverDatacenterFailures(replicationAnalysis []inst.ReplicationAnalysis, skipProcesses bool) (bulkRecoveredKeys map[inst.InstanceKey]bool) {
+	bulkRecoveredKeys = make(map[inst.InstanceKey]bool)
+	if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+		return bulkRecoveredKeys
+	}
+	failedDataCenters := detectDatacenterFailures(replicationAnalysis)
+
+	datacenterFailuresMutex.Lock()
+	defer datacenterFailuresMutex.Unlock()
+
+	blockPeriod := time.Duration(config.Config.RecoveryPeriodBlockSeconds) * time.Second
+	for dataCenter, failure := range datacenterFailures {
+		if failure.IsCompleted() && time.Since(failure.CompletedAt) > blockPeriod {
+			delete(datacenterFailures, dataCenter)
+		}
+	}
+	for dataCenter, deadMasters := range failedDataCenters {
+		if failure, found := datacenterFailures[dataCenter]; found {
+			if !failure.IsCompleted() {
+				// The bulk recovery is in progress and will get to these masters
+				for _, analysisEntry := range deadMasters {
+					bulkRecoveredKeys[analysisEntry.AnalyzedInstanceKey] = true
+				}
+			}
+			// A completed bulk recovery leaves further recoveries on this data center to the normal flow
+			continue
+		}
+		failure := &DatacenterFailure{
+			DataCenter: dataCenter,
+			DetectedAt: time.Now(),
+		}
+		for _, analysisEntry := range deadMasters {
+			failure.FailedMasters = append(failure.FailedMasters, analysisEntry.AnalyzedInstanceKey)
+			failure.FailedClusters = append(failure.FailedClusters, analysisEntry.ClusterDetails.ClusterAlias)
+			bulkRecoveredKeys[analysisEntry.AnalyzedInstanceKey] = true
+		}
+		datacenterFailures[dataCenter] = failure
+		go recoverDatacenterFailure(failure, deadMasters, skipProcesses)
+	}
+	return bulkRecoveredKeys
+}
+
+// recoverDatacenterFailure recovers the dead masters of a failed data center one by one, in order of
+// cluster priority tier. Per-cluster hooks are replaced by the data center failure hooks.
+func recoverDatacenterFailure(failure *DatacenterFailure, deadMasters []inst.ReplicationAnalysis, skipProcesses bool) {
+	recoverDatacenterFailureCounter.Inc(1)
+	summary := readDatacenterFailure(failure)
+	log.Warningf("recoverDatacenterFailure: data center %s failed: %d dead masters: %s", failure.DataCenter, len(summary.FailedMasters), strings.Join(summary.FailedClusters, ", "))
+	inst.AuditOperation("datacenter-failure", nil, fmt.Sprintf("data center %s failed; %d dead masters: %s", failure.DataCenter, len(summary.FailedMasters), strings.Join(summary.FailedClusters, ", ")))
+
+	completeFailure := func() {
+		datacenterFailuresMutex.Lock()
+		defer datacenterFailuresMutex.Unlock()
+		failure.CompletedAt = time.Now()
+	}
+	if !skipProcesses {
+		if err := executeDatacenterFailureProcesses(config.Config.OnDatacenterFailureProcesses, "OnDatacenterFailureProcesses", failure, true); err != nil {
+			log.Errorf("recoverDatacenterFailure: OnDatacenterFailureProcesses failed; not recovering data center %s: %+v", failure.DataCenter, err)
+			inst.AuditOperation("datacenter-failure-aborted", nil, fmt.Sprintf("data center %s: OnDatacenterFailureProcesses failed: %+v", failure.DataCenter, err))
+			completeFailure()
+			return
+		}
+	}
+	for i, j := range prioritizedAnalysisOrder(deadMasters) {
+		if i > 0 {
+			time.Sleep(time.Duration(config.Config.DatacenterFailureRecoveryIntervalSeconds) * time.Second)
+		}
+		analysisEntry := deadMasters[j]
+		if !isAnalysisStillValid(&analysisEntry) {
+			// Recovering the preceding masters took a while; this one may have come back meanwhile
+			inst.AuditOperation("datacenter-failure-skipped", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("data center %s: %+v no longer holds on cluster %s", failure.DataCenter, analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterAlias))
+			datacenterFailuresMutex.Lock()
+			failure.SkippedClusters = append(failure.SkippedClusters, analysisEntry.ClusterDetails.ClusterAlias)
+			datacenterFailuresMutex.Unlock()
+			continue
+		}
+		_, topologyRecovery, err := executeCheckAndRecoverFunction(analysisEntry, nil, false, true)
+		log.Errore(err)
+
+		datacenterFailuresMutex.Lock()
+		if topologyRecovery != nil && topologyRecovery.SuccessorKey != nil {
+			failure.RecoveredClusters = append(failure.RecoveredClusters, analysisEntry.ClusterDetails.ClusterAlias)
+		} else {
+			failure.UnrecoveredClusters = append(failure.UnrecoveredClusters, analysisEntry.ClusterDetails.ClusterAlias)
+		}
+		datacenterFailuresMutex.Unlock()
+	}
+	completeFailure()
+
+	summary = readDatacenterFailure(failure)
+	log.Infof("recoverDatacenterFailure: data center %s: recovered %d of %d clusters; unrecovered: %s; skipped: %s", failure.DataCenter, len(summary.RecoveredClusters), len(summary.FailedClusters), strings.Join(summary.UnrecoveredClusters, ", "), strings.Join(summary.SkippedClusters, ", "))
+	inst.AuditOperation("datacenter-failure-recovered", nil, fmt.Sprintf("data center %s: recovered %d of %d clusters: %s; unrecovered: %s; skipped: %s", failure.DataCenter, len(summary.RecoveredClusters), len(summary.FailedClusters), strings.Join(summary.RecoveredClusters, ", "), strings.Join(summary.UnrecoveredClusters, ", "), strings.Join(summary.SkippedClusters, ", ")))
+	if !skipProcesses {
+		executeDatacenterFailureProcesses(config.Config.PostDatacenterFailureProcesses, "PostDatacenterFailureProcesses", failure, false)
+	}
+}
+
+// readDatacenterFailure returns a copy of given failure, safe to read while its recovery is in progress
+func readDatacenterFailure(failure *DatacenterFailure) DatacenterFailure {
+	datacenterFailuresMutex.Lock()
+	defer datacenterFailuresMutex.Unlock()
+
+	summary := *failure
+	summary.RecoveredClusters = append([]string{}, failure.RecoveredClusters...)
+	summary.UnrecoveredClusters = append([]string{}, failure.UnrecoveredClusters...)
+	summary.SkippedClusters = append([]string{}, failure.SkippedClusters...)
+	return summary
+}
+
+// ReadDatacenterFailures returns the data center failures in progress, or completed within RecoveryPeriodBlockSeconds
+func ReadDatacenterFailures() []DatacenterFailure {
+	datacenterFailuresMutex.Lock()
+	failures := [](*DatacenterFailure){}
+	for _, failure := range datacenterFailures {
+		failures = append(failures, failure)
+	}
+	datacenterFailuresMutex.Unlock()
+
+	res := []DatacenterFailure{}
+	for _, failure := range failures {
+		res = append(res, readDatacenterFailure(failure))
+	}
+	return res
+}
+
+// replaceDatacenterFailurePlaceholders replaces agreed-upon placeholders with data center failure data
+func replaceDatacenterFailurePlaceholders(command string, failure *DatacenterFailure) string {
+	command = strings.Replace(command, "{failedDataCenter}", failure.DataCenter, -1)
+	command = strings.Replace(command, "{failedClusters}", strings.Join(failure.FailedClusters, ","), -1)
+	command = strings.Replace(command, "{countFailedClusters}", fmt.Sprintf("%d", len(failure.FailedClusters)), -1)
+	command = strings.Replace(command, "{recoveredClusters}", strings.Join(failure.RecoveredClusters, ","), -1)
+	command = strings.Replace(command, "{countRecoveredClusters}", fmt.Sprintf("%d", len(failure.RecoveredClusters)), -1)
+	command = strings.Replace(command, "{unrecoveredClusters}", strings.Join(failure.UnrecoveredClusters, ","), -1)
+	command = strings.Replace(command, "{skippedClusters}", strings.Join(failure.SkippedClusters, ","), -1)
+	command = strings.Replace(command, "{orchestratorHost}", process.ThisHostname, -1)
+	return command
+}
+
+// applyDatacenterFailureEnvironmentVariables sets the relevant environment variables for a data center failure
+func applyDatacenterFailureEnvironmentVariables(failure *DatacenterFailure) []string {
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_FAILED_DATA_CENTER=%s", failure.DataCenter))
+	env = append(env, fmt.Sprintf("ORC_FAILED_CLUSTERS=%s", strings.Join(failure.FailedClusters, ",")))
+	env = append(env, fmt.Sprintf("ORC_COUNT_FAILED_CLUSTERS=%d", len(failure.FailedClusters)))
+	env = append(env, fmt.Sprintf("ORC_RECOVERED_CLUSTERS=%s", strings.Join(failure.RecoveredClusters, ",")))
+	env = append(env, fmt.Sprintf("ORC_COUNT_RECOVERED_CLUSTERS=%d", len(failure.RecoveredClusters)))
+	env = append(env, fmt.Sprintf("ORC_UNRECOVERED_CLUSTERS=%s", strings.Join(failure.UnrecoveredClusters, ",")))
+	env = append(env, fmt.Sprintf("ORC_SKIPPED_CLUSTERS=%s", strings.Join(failure.SkippedClusters, ",")))
+	env = append(env, fmt.Sprintf("ORC_ORCHESTRATOR_HOST=%s", process.ThisHostname))
+	return env
+}
+
+// executeDatacenterFailureProcesses executes data center failure hooks, auditing each
+func executeDatacenterFailureProcesses(processes []string, description string, failure *DatacenterFailure, failOnError bool) (err error) {
+	summary := readDatacenterFailure(failure)
+	for i, command := range processes {
+		fullDescription := fmt.Sprintf("%s hook %d of %d", description, i+1, len(processes))
+		command := replaceDatacenterFailurePlaceholders(command, &summary)
+		env := applyDatacenterFailureEnvironmentVariables(&summary)
+
+		start := time.Now()
+		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+			inst.AuditOperation("datacenter-failure-hook", nil, fmt.Sprintf("data center %s: completed %s in %v: %s", failure.DataCenter, fullDescription, time.Since(start), command))
+		} else {
+			inst.AuditOperation("datacenter-failure-hook", nil, fmt.Sprintf("data center %s: %s failed in %v with error: %v: %s", failure.DataCenter, fullDescription, time.Since(start), cmdErr, command))
+			log.Errorf("Execution of %s failed: %+v", fullDescription, cmdErr)
+			if err == nil {
+				err = cmdErr
+			}
+			if failOnError {
+				return err
+			}
+		}
+	}
+	return err
+}
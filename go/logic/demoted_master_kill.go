@@ -0,0 +1,69 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// killSessionsPerPolicy kills the sessions a kill policy finds on a demoted master, once given grace period
+// elapses. Outcomes are recorded on given recovery, if any.
+func killSessionsPerPolicy(topologyRecovery *TopologyRecovery, policy *config.SQLKillPolicy, demotedKey *inst.InstanceKey, gracePeriod time.Duration) (killed int, err error) {
+	deadline := time.Now().Add(gracePeriod)
+	for {
+		kills, err := inst.ReadSessionKills(demotedKey, policy)
+		if err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- DemotedMasterKillPolicy: cannot read sessions on demoted master %+v: %+v", *demotedKey, err))
+			return killed, err
+		}
+		if len(kills) == 0 {
+			return killed, nil
+		}
+		if time.Now().Before(deadline) {
+			time.Sleep(time.Second)
+			continue
+		}
+		killed, err = inst.KillSessions(demotedKey, kills)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- DemotedMasterKillPolicy: killed %d of %d sessions on demoted master %+v", killed, len(kills), *demotedKey))
+		return killed, err
+	}
+}
+
+// killDemotedMasterSessions applies the cluster's DemotedMasterKillPolicy to a gracefully demoted master, at the
+// stage given: before or after setting read_only. Matching sessions are given the policy's grace period to
+// complete, and are then killed. Outcomes are recorded on given recovery, if any.
+func killDemotedMasterSessions(topologyRecovery *TopologyRecovery, clusterInfo *inst.ClusterInfo, demotedKey *inst.InstanceKey, beforeReadOnly bool) (killed int, err error) {
+	policy := clusterInfo.DemotedMasterKillPolicy()
+	if policy == nil || policy.IsBeforeReadOnly() != beforeReadOnly {
+		return 0, nil
+	}
+	return killSessionsPerPolicy(topologyRecovery, policy, demotedKey, time.Duration(policy.GracePeriodSeconds)*time.Second)
+}
+
+// killFailedOverMasterSessions applies the cluster's DemotedMasterKillPolicy to a failed over master, once it is
+// set read_only, whatever the policy's stage. A replacement is already promoted; there is no grace period.
+func killFailedOverMasterSessions(topologyRecovery *TopologyRecovery, clusterInfo *inst.ClusterInfo, demotedKey *inst.InstanceKey) (killed int, err error) {
+	policy := clusterInfo.DemotedMasterKillPolicy()
+	if policy == nil {
+		return 0, nil
+	}
+	return killSessionsPerPolicy(topologyRecovery, policy, demotedKey, 0)
+}
@@ -0,0 +1,95 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// FailoverProvider promotes a new master via an external (typically cloud provider) API, rather than
+// by SQL-level regrouping of replicas. Detection, KV updates, aliasing and hooks still go through
+// the normal recovery pipeline.
+type FailoverProvider interface {
+	Name() string
+	// Failover promotes a replacement for the failed master of given recovery, preferring the given candidate (may be nil).
+	// It returns the key of the promoted, writable server, and the replicas it knowingly left behind.
+	Failover(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey) (promotedKey *inst.InstanceKey, lostReplicaKeys []inst.InstanceKey, err error)
+}
+
+// getFailoverProvider returns the provider through which the analyzed cluster fails over, or nil
+// when the cluster is failed over by orchestrator itself
+func getFailoverProvider(analysisEntry *inst.ReplicationAnalysis) FailoverProvider {
+	if analysisEntry.ClusterDetails.IsRDSFailoverCluster {
+		return &RDSFailoverProvider{}
+	}
+	if analysisEntry.ClusterDetails.IsCloudSQLFailoverCluster {
+		return &CloudSQLFailoverProvider{}
+	}
+	return nil
+}
+
+const failoverProviderPollInterval = 5 * time.Second
+
+// waitForFailoverProvider polls the given condition until it holds or timeoutSeconds elapse
+func waitForFailoverProvider(timeoutSeconds uint, description string, condition func() (bool, error)) error {
+	timeout := time.Duration(timeoutSeconds) * time.Second
+	for start := time.Now(); time.Since(start) < timeout; time.Sleep(failoverProviderPollInterval) {
+		if ok, err := condition(); err != nil {
+			return err
+		} else if ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("Timeout waiting for %s", description)
+}
+
+// mostUpToDateReplica returns the replica that has executed furthest into its master's binary logs,
+// ignoring replicas that must not be promoted. Returns nil when there is no such replica.
+func mostUpToDateReplica(replicas [](*inst.Instance)) (mostUpToDate *inst.Instance) {
+	for _, replica := range replicas {
+		if replica.PromotionRule == inst.MustNotPromoteRule {
+			continue
+		}
+		if mostUpToDate == nil || mostUpToDate.ExecBinlogCoordinates.SmallerThan(&replica.ExecBinlogCoordinates) {
+			mostUpToDate = replica
+		}
+	}
+	return mostUpToDate
+}
+
+// recoverDeadMasterViaProvider has the given provider promote a new master, and reads the promoted server
+func recoverDeadMasterViaProvider(topologyRecovery *TopologyRecovery, provider FailoverProvider, candidateInstanceKey *inst.InstanceKey) (promotedReplica *inst.Instance, lostReplicas [](*inst.Instance), err error) {
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: failing over via %s", provider.Name()))
+	promotedKey, lostReplicaKeys, err := provider.Failover(topologyRecovery, candidateInstanceKey)
+	for _, lostReplicaKey := range lostReplicaKeys {
+		if lostReplica, _, _ := inst.ReadInstance(&lostReplicaKey); lostReplica != nil {
+			lostReplicas = append(lostReplicas, lostReplica)
+		}
+	}
+	if err != nil {
+		return nil, lostReplicas, err
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %s promoted %+v", provider.Name(), *promotedKey))
+	promotedReplica, err = inst.ReadTopologyInstance(promotedKey)
+	if err != nil {
+		return nil, lostReplicas, fmt.Errorf("%s promoted %+v, but it cannot be read: %+v", provider.Name(), *promotedKey, err)
+	}
+	return promotedReplica, lostReplicas, nil
+}
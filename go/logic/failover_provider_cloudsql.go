@@ -0,0 +1,124 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/cloud"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// CloudSQLFailoverProvider fails over GCP CloudSQL MySQL clusters by promoting a read replica via the CloudSQL Admin API
+type CloudSQLFailoverProvider struct{}
+
+func (this *CloudSQLFailoverProvider) Name() string {
+	return "cloudsql"
+}
+
+func cloudSQLFailoverProject() string {
+	if config.Config.CloudSQLFailoverProject != "" {
+		return config.Config.CloudSQLFailoverProject
+	}
+	return config.Config.DiscoverySeedCloudSQLProject
+}
+
+// cloudSQLInstanceMatches checks whether given hostname is the name or any of the addresses of given CloudSQL instance
+func cloudSQLInstanceMatches(instance *cloud.CloudSQLInstance, hostname string) bool {
+	return instance.Name == hostname || instance.IPAddressType(hostname) != ""
+}
+
+func (this *CloudSQLFailoverProvider) Failover(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey) (promotedKey *inst.InstanceKey, lostReplicaKeys []inst.InstanceKey, err error) {
+	failedInstanceKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	project := cloudSQLFailoverProject()
+	cloudSQLInstances, err := cloud.ListCloudSQLInstances(project)
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	var failedCloudSQLInstance *cloud.CloudSQLInstance
+	for i := range cloudSQLInstances {
+		if cloudSQLInstanceMatches(&cloudSQLInstances[i], failedInstanceKey.Hostname) {
+			failedCloudSQLInstance = &cloudSQLInstances[i]
+		}
+	}
+	if failedCloudSQLInstance == nil {
+		return nil, lostReplicaKeys, fmt.Errorf("%+v is not a CloudSQL instance of project %s", *failedInstanceKey, project)
+	}
+	// Identify replicas by the same type of address the failed master is known by
+	ipType := failedCloudSQLInstance.IPAddressType(failedInstanceKey.Hostname)
+	if ipType == "" {
+		ipType = config.Config.DiscoverySeedCloudSQLIPType
+	}
+
+	replicas := [](*inst.Instance){}
+	replicaNames := make(map[*inst.Instance]string)
+	promotedName := ""
+	for i := range cloudSQLInstances {
+		cloudSQLReplica := &cloudSQLInstances[i]
+		if !cloudSQLReplica.IsReplicaOf(project, failedCloudSQLInstance.Name) {
+			continue
+		}
+		if candidateInstanceKey != nil && cloudSQLInstanceMatches(cloudSQLReplica, candidateInstanceKey.Hostname) {
+			promotedName = cloudSQLReplica.Name
+		}
+		replicaKey := inst.InstanceKey{Hostname: cloudSQLReplica.IPAddress(ipType), Port: cloud.CloudSQLPort}
+		if replica, _, err := inst.ReadInstance(&replicaKey); err == nil && replica != nil {
+			replicas = append(replicas, replica)
+			replicaNames[replica] = cloudSQLReplica.Name
+		}
+	}
+	if candidateInstanceKey != nil {
+		if promotedName == "" {
+			return nil, lostReplicaKeys, fmt.Errorf("candidate %+v is not a CloudSQL replica of %s", *candidateInstanceKey, failedCloudSQLInstance.Name)
+		}
+	} else if promotedReplica := mostUpToDateReplica(replicas); promotedReplica != nil {
+		promotedName = replicaNames[promotedReplica]
+	} else {
+		return nil, lostReplicaKeys, fmt.Errorf("found no promotable replica of CloudSQL instance %s", failedCloudSQLInstance.Name)
+	}
+	for _, replica := range replicas {
+		if replicaNames[replica] != promotedName {
+			// CloudSQL cannot repoint the remaining replicas
+			lostReplicaKeys = append(lostReplicaKeys, replica.Key)
+		}
+	}
+
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("CloudSQLFailoverProvider: promoteReplica %s", promotedName))
+	operation, err := cloud.PromoteCloudSQLReplica(project, promotedName)
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	err = waitForFailoverProvider(config.Config.CloudSQLFailoverTimeoutSeconds, fmt.Sprintf("promotion of CloudSQL instance %s", promotedName), func() (bool, error) {
+		if operation, err = cloud.GetCloudSQLOperation(project, operation.Name); err != nil {
+			return false, err
+		}
+		return operation.IsDone(), operation.Err()
+	})
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	promotedCloudSQLInstance, err := cloud.GetCloudSQLInstance(project, promotedName)
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	promotedAddress := promotedCloudSQLInstance.IPAddress(ipType)
+	if promotedAddress == "" {
+		return nil, lostReplicaKeys, fmt.Errorf("promoted CloudSQL instance %s has no %s address", promotedName, ipType)
+	}
+	return &inst.InstanceKey{Hostname: promotedAddress, Port: cloud.CloudSQLPort}, lostReplicaKeys, nil
+}
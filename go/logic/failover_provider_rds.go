@@ -0,0 +1,156 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/cloud"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+)
+
+// RDSFailoverProvider fails over AWS RDS and Aurora MySQL clusters via the RDS API:
+// Aurora clusters via FailoverDBCluster, RDS MySQL via PromoteReadReplica.
+type RDSFailoverProvider struct{}
+
+func (this *RDSFailoverProvider) Name() string {
+	return "rds"
+}
+
+// rdsRegion returns the region parsed off an RDS endpoint, or else the configured region
+func rdsRegion(endpointRegion string) string {
+	if endpointRegion != "" {
+		return endpointRegion
+	}
+	if config.Config.RDSFailoverRegion != "" {
+		return config.Config.RDSFailoverRegion
+	}
+	return config.Config.DiscoverySeedAWSRegion
+}
+
+func (this *RDSFailoverProvider) Failover(topologyRecovery *TopologyRecovery, candidateInstanceKey *inst.InstanceKey) (promotedKey *inst.InstanceKey, lostReplicaKeys []inst.InstanceKey, err error) {
+	failedInstanceKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	failedIdentifier, endpointRegion, ok := cloud.ParseRDSEndpoint(failedInstanceKey.Hostname)
+	if !ok {
+		return nil, lostReplicaKeys, fmt.Errorf("%+v is not an RDS instance endpoint", *failedInstanceKey)
+	}
+	region := rdsRegion(endpointRegion)
+	failedDBInstance, err := cloud.DescribeRDSDBInstance(region, failedIdentifier)
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	candidateIdentifier := ""
+	if candidateInstanceKey != nil {
+		if candidateIdentifier, _, ok = cloud.ParseRDSEndpoint(candidateInstanceKey.Hostname); !ok {
+			return nil, lostReplicaKeys, fmt.Errorf("candidate %+v is not an RDS instance endpoint", *candidateInstanceKey)
+		}
+	}
+	var promotedIdentifier string
+	if failedDBInstance.DBClusterIdentifier != "" {
+		promotedIdentifier, err = this.failoverAuroraCluster(topologyRecovery, region, failedDBInstance, candidateIdentifier)
+	} else {
+		promotedIdentifier, lostReplicaKeys, err = this.promoteReadReplica(topologyRecovery, region, failedDBInstance, candidateIdentifier)
+	}
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	promotedDBInstance, err := cloud.DescribeRDSDBInstance(region, promotedIdentifier)
+	if err != nil {
+		return nil, lostReplicaKeys, err
+	}
+	return &inst.InstanceKey{Hostname: promotedDBInstance.Endpoint.Address, Port: promotedDBInstance.Endpoint.Port}, lostReplicaKeys, nil
+}
+
+// failoverAuroraCluster fails over an Aurora cluster and waits for a new writer to take over.
+// Aurora readers share the cluster volume and follow the new writer on their own; nothing is lost.
+func (this *RDSFailoverProvider) failoverAuroraCluster(topologyRecovery *TopologyRecovery, region string, failedDBInstance *cloud.RDSDBInstance, candidateIdentifier string) (promotedIdentifier string, err error) {
+	clusterIdentifier := failedDBInstance.DBClusterIdentifier
+	dbCluster, err := cloud.DescribeRDSDBCluster(region, clusterIdentifier)
+	if err != nil {
+		return "", err
+	}
+	if writer := dbCluster.Writer(); writer != "" && writer != failedDBInstance.DBInstanceIdentifier {
+		// Aurora has already failed over on its own
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RDSFailoverProvider: aurora cluster %s already failed over to %s", clusterIdentifier, writer))
+		return writer, nil
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RDSFailoverProvider: FailoverDBCluster %s, target: %q", clusterIdentifier, candidateIdentifier))
+	if err := cloud.FailoverRDSDBCluster(region, clusterIdentifier, candidateIdentifier); err != nil {
+		return "", err
+	}
+	err = waitForFailoverProvider(config.Config.RDSFailoverTimeoutSeconds, fmt.Sprintf("new writer of aurora cluster %s", clusterIdentifier), func() (bool, error) {
+		dbCluster, err := cloud.DescribeRDSDBCluster(region, clusterIdentifier)
+		if err != nil {
+			return false, err
+		}
+		promotedIdentifier = dbCluster.Writer()
+		return promotedIdentifier != "" && promotedIdentifier != failedDBInstance.DBInstanceIdentifier && dbCluster.Status == "available", nil
+	})
+	return promotedIdentifier, err
+}
+
+// promoteReadReplica promotes the candidate, or else the most up-to-date read replica of a failed RDS MySQL instance.
+// The remaining read replicas cannot be repointed via the RDS API and are reported as lost.
+func (this *RDSFailoverProvider) promoteReadReplica(topologyRecovery *TopologyRecovery, region string, failedDBInstance *cloud.RDSDBInstance, candidateIdentifier string) (promotedIdentifier string, lostReplicaKeys []inst.InstanceKey, err error) {
+	if len(failedDBInstance.ReadReplicaDBInstanceIdentifiers) == 0 {
+		return "", lostReplicaKeys, fmt.Errorf("RDS instance %s has no read replicas to promote", failedDBInstance.DBInstanceIdentifier)
+	}
+	replicas := [](*inst.Instance){}
+	replicaIdentifiers := make(map[*inst.Instance]string)
+	for _, replicaKey := range topologyRecovery.AnalysisEntry.SlaveHosts.GetInstanceKeys() {
+		replicaIdentifier, _, ok := cloud.ParseRDSEndpoint(replicaKey.Hostname)
+		if !ok {
+			continue
+		}
+		if replica, _, err := inst.ReadInstance(&replicaKey); err == nil && replica != nil {
+			replicas = append(replicas, replica)
+			replicaIdentifiers[replica] = replicaIdentifier
+		}
+	}
+	var promotedReplica *inst.Instance
+	if candidateIdentifier != "" {
+		// The candidate may be unknown to orchestrator; trust the user with it
+		promotedIdentifier = candidateIdentifier
+		for _, replica := range replicas {
+			if replicaIdentifiers[replica] == candidateIdentifier {
+				promotedReplica = replica
+			}
+		}
+	} else if promotedReplica = mostUpToDateReplica(replicas); promotedReplica != nil {
+		promotedIdentifier = replicaIdentifiers[promotedReplica]
+	} else {
+		return "", lostReplicaKeys, fmt.Errorf("found no promotable read replica of RDS instance %s", failedDBInstance.DBInstanceIdentifier)
+	}
+	for _, replica := range replicas {
+		if replica != promotedReplica {
+			lostReplicaKeys = append(lostReplicaKeys, replica.Key)
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RDSFailoverProvider: PromoteReadReplica %s", promotedIdentifier))
+	if err := cloud.PromoteRDSReadReplica(region, promotedIdentifier); err != nil {
+		return "", lostReplicaKeys, err
+	}
+	err = waitForFailoverProvider(config.Config.RDSFailoverTimeoutSeconds, fmt.Sprintf("promotion of RDS instance %s", promotedIdentifier), func() (bool, error) {
+		dbInstance, err := cloud.DescribeRDSDBInstance(region, promotedIdentifier)
+		if err != nil {
+			return false, err
+		}
+		return dbInstance.ReadReplicaSourceDBInstanceIdentifier == "" && dbInstance.IsAvailable(), nil
+	})
+	return promotedIdentifier, lostReplicaKeys, err
+}
@@ -0,0 +1,206 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/cloud"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Fencing (STONITH) makes sure a dead master stays dead -- unable to take writes -- before a replacement is promoted
+const (
+	FencingStatusFenced = "fenced"
+	FencingStatusFailed = "failed"
+)
+
+var fenceDeadMasterSuccessCounter = metrics.NewCounter()
+var fenceDeadMasterFailureCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("recover.dead_master.fence.success", fenceDeadMasterSuccessCounter)
+	metrics.Register("recover.dead_master.fence.fail", fenceDeadMasterFailureCounter)
+}
+
+// FencingWebhookRequest is POSTed to MasterFencingWebhookURL by the "webhook" fencing method
+type FencingWebhookRequest struct {
+	Hostname         string
+	Port             int
+	ClusterName      string
+	ClusterAlias     string
+	RecoveryUID      string
+	OrchestratorHost string
+}
+
+func fenceViaWebhook(topologyRecovery *TopologyRecovery) error {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	body, err := json.Marshal(&FencingWebhookRequest{
+		Hostname:         analysisEntry.AnalyzedInstanceKey.Hostname,
+		Port:             analysisEntry.AnalyzedInstanceKey.Port,
+		ClusterName:      analysisEntry.ClusterDetails.ClusterName,
+		ClusterAlias:     analysisEntry.ClusterDetails.ClusterAlias,
+		RecoveryUID:      topologyRecovery.UID,
+		OrchestratorHost: process.ThisHostname,
+	})
+	if err != nil {
+		return err
+	}
+	httpClient := &http.Client{Timeout: time.Duration(config.Config.MasterFencingTimeoutSeconds) * time.Second}
+	response, err := httpClient.Post(config.Config.MasterFencingWebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		responseBody, _ := ioutil.ReadAll(response.Body)
+		return fmt.Errorf("fencing webhook: unexpected status %d: %s", response.StatusCode, string(responseBody))
+	}
+	return nil
+}
+
+func fenceViaEC2(instanceKey *inst.InstanceKey) error {
+	region := config.Config.MasterFencingEC2Region
+	ec2Instance, err := cloud.FindEC2Instance(region, instanceKey.Hostname)
+	if err != nil {
+		return err
+	}
+	if !ec2Instance.IsStopped() {
+		if err := cloud.StopEC2Instance(region, ec2Instance.InstanceId); err != nil {
+			return err
+		}
+	}
+	return waitForFailoverProvider(config.Config.MasterFencingTimeoutSeconds, fmt.Sprintf("EC2 instance %s to stop", ec2Instance.InstanceId), func() (bool, error) {
+		if ec2Instance, err = cloud.DescribeEC2Instance(region, ec2Instance.InstanceId); err != nil {
+			return false, err
+		}
+		return ec2Instance.IsStopped(), nil
+	})
+}
+
+func fenceViaGCE(instanceKey *inst.InstanceKey) error {
+	name, zone, project, ok := cloud.ParseGCEHostname(instanceKey.Hostname)
+	if !ok {
+		return fmt.Errorf("%s is not a GCE internal DNS name; cannot identify instance to stop", instanceKey.Hostname)
+	}
+	if err := cloud.StopGCEInstance(project, zone, name); err != nil {
+		return err
+	}
+	return waitForFailoverProvider(config.Config.MasterFencingTimeoutSeconds, fmt.Sprintf("GCE instance %s to stop", name), func() (bool, error) {
+		gceInstance, err := cloud.GetGCEInstance(project, zone, name)
+		if err != nil {
+			return false, err
+		}
+		return gceInstance.IsStopped(), nil
+	})
+}
+
+func fenceVia(method string, topologyRecovery *TopologyRecovery) error {
+	failedInstanceKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	switch method {
+	case "sql":
+		return inst.FenceInstance(failedInstanceKey)
+	case "webhook":
+		return fenceViaWebhook(topologyRecovery)
+	case "ec2":
+		return fenceViaEC2(failedInstanceKey)
+	case "gce":
+		return fenceViaGCE(failedInstanceKey)
+	}
+	return fmt.Errorf("Unknown fencing method: %s", method)
+}
+
+// fenceDeadMaster tries the configured fencing methods in order, until one is verified to have fenced the
+// dead master. The outcome is recorded in the recovery. Returns an error when no method succeeded.
+func fenceDeadMaster(topologyRecovery *TopologyRecovery) error {
+	if len(config.Config.MasterFencingMethods) == 0 {
+		return nil
+	}
+	failedInstanceKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	if topologyRecovery.AnalysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint {
+		// The demoted master is alive and well, and is taken care of by the takeover
+		return nil
+	}
+	fencingErrors := []string{}
+	for _, method := range config.Config.MasterFencingMethods {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fencing %+v via %s", *failedInstanceKey, method))
+		if err := fenceVia(method, topologyRecovery); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fencing via %s failed: %+v", method, err))
+			fencingErrors = append(fencingErrors, fmt.Sprintf("%s: %+v", method, err))
+			continue
+		}
+		topologyRecovery.FencingMethod = method
+		topologyRecovery.FencingStatus = FencingStatusFenced
+		fenceDeadMasterSuccessCounter.Inc(1)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fenced %+v via %s", *failedInstanceKey, method))
+		inst.AuditOperation("fence-dead-master", failedInstanceKey, fmt.Sprintf("recovery %s: fenced via %s", topologyRecovery.UID, method))
+		return nil
+	}
+	topologyRecovery.FencingStatus = FencingStatusFailed
+	fenceDeadMasterFailureCounter.Inc(1)
+	inst.AuditOperation("fence-dead-master-failed", failedInstanceKey, fmt.Sprintf("recovery %s: %s", topologyRecovery.UID, strings.Join(fencingErrors, "; ")))
+	return fmt.Errorf("could not fence %+v: %s", *failedInstanceKey, strings.Join(fencingErrors, "; "))
+}
+
+// isLiveMasterAnalysis checks whether an analysis fails over a master which is alive, per policy
+func isLiveMasterAnalysis(analysisCode inst.AnalysisCode) bool {
+	switch analysisCode {
+	case inst.LockedSemiSyncMaster, inst.TooManyConnectionsMaster, inst.ReadOnlyFilesystemMaster, inst.DiskFullMaster:
+		return true
+	}
+	return false
+}
+
+// fenceLiveMaster fences a master which is alive, yet failed over per policy, before a replacement is promoted.
+// Unless a MasterFencingMethod already fenced it, client connections are killed (e.g. those waiting on semi-sync
+// ACKs, which hold up read_only), read_only is set, and remaining client connections are killed. A master left
+// writable would keep taking writes alongside the promoted master; an error is returned, and the recovery is
+// to be aborted, regardless of MasterFencingRequired.
+func fenceLiveMaster(topologyRecovery *TopologyRecovery) error {
+	if topologyRecovery.FencingStatus == FencingStatusFenced {
+		return nil
+	}
+	masterKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %+v is alive; fencing before promotion", *masterKey))
+	killed, err := inst.KillClientConnections(masterKey)
+	if err == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: killed %d client connections on %+v", killed, *masterKey))
+		err = inst.FenceInstance(masterKey)
+	}
+	topologyRecovery.FencingMethod = "sql"
+	if err != nil {
+		topologyRecovery.FencingStatus = FencingStatusFailed
+		fenceDeadMasterFailureCounter.Inc(1)
+		inst.AuditOperation("fence-live-master-failed", masterKey, fmt.Sprintf("recovery %s: %+v", topologyRecovery.UID, err))
+		return fmt.Errorf("RecoverDeadMaster: could not fence live master %+v; aborting promotion: %+v", *masterKey, err)
+	}
+	topologyRecovery.FencingStatus = FencingStatusFenced
+	fenceDeadMasterSuccessCounter.Inc(1)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: fenced live master %+v", *masterKey))
+	inst.AuditOperation("fence-live-master", masterKey, fmt.Sprintf("recovery %s: set read_only and killed client connections", topologyRecovery.UID))
+	return nil
+}
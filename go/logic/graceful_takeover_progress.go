@@ -0,0 +1,65 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/github/orchestrator/go/inst"
+)
+
+// GracefulTakeoverProgress is the latest progress of an in-flight graceful master takeover, waiting for
+// the designated replica to catch up with the demoted master
+type GracefulTakeoverProgress struct {
+	ClusterName string
+	Progress    inst.ReplicationCatchupProgress
+}
+
+var gracefulTakeoverProgressMutex sync.Mutex
+var gracefulTakeoverProgress = make(map[string]inst.ReplicationCatchupProgress)
+
+func registerGracefulTakeoverProgress(clusterName string, progress *inst.ReplicationCatchupProgress) {
+	gracefulTakeoverProgressMutex.Lock()
+	defer gracefulTakeoverProgressMutex.Unlock()
+
+	gracefulTakeoverProgress[clusterName] = *progress
+}
+
+func clearGracefulTakeoverProgress(clusterName string) {
+	gracefulTakeoverProgressMutex.Lock()
+	defer gracefulTakeoverProgressMutex.Unlock()
+
+	delete(gracefulTakeoverProgress, clusterName)
+}
+
+// ReadGracefulTakeoverProgress returns the progress of in-flight graceful master takeovers, optionally
+// filtered by cluster
+func ReadGracefulTakeoverProgress(clusterName string) []GracefulTakeoverProgress {
+	gracefulTakeoverProgressMutex.Lock()
+	defer gracefulTakeoverProgressMutex.Unlock()
+
+	res := []GracefulTakeoverProgress{}
+	for name, progress := range gracefulTakeoverProgress {
+		if clusterName != "" && name != clusterName {
+			continue
+		}
+		res = append(res, GracefulTakeoverProgress{ClusterName: name, Progress: progress})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ClusterName < res[j].ClusterName })
+	return res
+}
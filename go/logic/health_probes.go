@@ -0,0 +1,182 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/agent"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+	"github.com/rcrowley/go-metrics"
+)
+
+// Probe results are reused within a recovery poll, so that analysis ticks do not pile up probes
+var healthProbeResultsCache = cache.New(time.Duration(config.RecoveryPollSeconds)*time.Second, time.Second)
+
+var healthProbeOverruleCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("analysis.health_probe.overrule", healthProbeOverruleCounter)
+}
+
+func probeTCP(probe *config.HealthProbe, instanceKey *inst.InstanceKey) (target string, err error) {
+	port := probe.Port
+	if port == 0 {
+		port = instanceKey.Port
+	}
+	target = net.JoinHostPort(instanceKey.Hostname, fmt.Sprintf("%d", port))
+	conn, err := net.DialTimeout("tcp", target, time.Duration(probe.TimeoutSeconds)*time.Second)
+	if err != nil {
+		return target, err
+	}
+	conn.Close()
+	return target, nil
+}
+
+func probeHTTP(probe *config.HealthProbe, instanceKey *inst.InstanceKey) (target string, err error) {
+	target = strings.Replace(probe.URL, "{host}", instanceKey.Hostname, -1)
+	target = strings.Replace(target, "{port}", fmt.Sprintf("%d", instanceKey.Port), -1)
+	httpClient := &http.Client{Timeout: time.Duration(probe.TimeoutSeconds) * time.Second}
+	response, err := httpClient.Get(target)
+	if err != nil {
+		return target, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return target, fmt.Errorf("got %d status", response.StatusCode)
+	}
+	return target, nil
+}
+
+func probeAgent(probe *config.HealthProbe, instanceKey *inst.InstanceKey) (target string, err error) {
+	target = instanceKey.Hostname
+	running, err := agent.MySQLRunning(instanceKey.Hostname)
+	if err != nil {
+		return target, err
+	}
+	if !running {
+		return target, fmt.Errorf("MySQL not running")
+	}
+	return target, nil
+}
+
+// runHealthProbe runs a single probe, bounded by the probe's timeout
+func runHealthProbe(probe config.HealthProbe, instanceKey *inst.InstanceKey) inst.HealthProbeResult {
+	result := inst.HealthProbeResult{Type: probe.Type, Target: instanceKey.StringCode()}
+	probeFunc := probeTCP
+	switch probe.Type {
+	case config.HealthProbeHTTP:
+		probeFunc = probeHTTP
+	case config.HealthProbeAgent:
+		probeFunc = probeAgent
+	}
+	type probeOutcome struct {
+		target string
+		err    error
+	}
+	outcome := make(chan probeOutcome, 1)
+	go func() {
+		target, err := probeFunc(&probe, instanceKey)
+		outcome <- probeOutcome{target: target, err: err}
+	}()
+	select {
+	case o := <-outcome:
+		result.Target = o.target
+		if o.err != nil {
+			result.Error = o.err.Error()
+		} else {
+			result.Healthy = true
+		}
+	case <-time.After(time.Duration(probe.TimeoutSeconds) * time.Second):
+		result.Error = fmt.Sprintf("timed out after %d seconds", probe.TimeoutSeconds)
+	}
+	return result
+}
+
+// runHealthProbes runs all probes applying to the analyzed instance's cluster, concurrently
+func runHealthProbes(analysisEntry *inst.ReplicationAnalysis) []inst.HealthProbeResult {
+	instanceKey := &analysisEntry.AnalyzedInstanceKey
+	if results, found := healthProbeResultsCache.Get(instanceKey.StringCode()); found {
+		return results.([]inst.HealthProbeResult)
+	}
+	probes := analysisEntry.ClusterDetails.HealthProbes()
+	results := make([]inst.HealthProbeResult, len(probes))
+	var wg sync.WaitGroup
+	for i, probe := range probes {
+		i, probe := i, probe
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runHealthProbe(probe, instanceKey)
+		}()
+	}
+	wg.Wait()
+	healthProbeResultsCache.Set(instanceKey.StringCode(), results, cache.DefaultExpiration)
+	return results
+}
+
+// applyHealthProbes probes a master that analysis finds dead. Should any probe find the master healthy,
+// the analysis is turned into an unreachable master, for which there is no failover.
+func applyHealthProbes(analysisEntry *inst.ReplicationAnalysis, forceInstanceRecovery bool) {
+	if forceInstanceRecovery || len(config.Config.MasterHealthProbes) == 0 {
+		return
+	}
+	if orcraft.IsRaftEnabled() && !orcraft.IsLeader() {
+		return
+	}
+	var unreachableAnalysis inst.AnalysisCode
+	switch analysisEntry.Analysis {
+	case inst.DeadMaster, inst.DeadMasterAndSomeSlaves, inst.DeadMasterWithoutSlaves:
+		unreachableAnalysis = inst.UnreachableMaster
+	case inst.DeadCoMaster, inst.DeadCoMasterAndSomeSlaves:
+		unreachableAnalysis = inst.UnreachableCoMaster
+	default:
+		return
+	}
+	analysisEntry.HealthProbeResults = runHealthProbes(analysisEntry)
+	if len(analysisEntry.HealthProbeResults) == 0 {
+		return
+	}
+	summary := []string{}
+	healthy := false
+	for _, result := range analysisEntry.HealthProbeResults {
+		summary = append(summary, result.String())
+		healthy = healthy || result.Healthy
+	}
+	if !healthy {
+		analysisEntry.Description = fmt.Sprintf("%s; health probes: %s", analysisEntry.Description, strings.Join(summary, "; "))
+		return
+	}
+	healthProbeOverruleCounter.Inc(1)
+	if util.ClearToLog("applyHealthProbes", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+		log.Warningf("%+v on %+v overruled by health probes: %s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, strings.Join(summary, "; "))
+		inst.AuditOperation("health-probe-overrule", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%s overruled: %s", analysisEntry.Analysis, strings.Join(summary, "; ")))
+	}
+	analysisEntry.Analysis = unreachableAnalysis
+	analysisEntry.Description = fmt.Sprintf("Master cannot be reached by orchestrator, but health probes find it healthy; possibly a network/host issue. Health probes: %s", strings.Join(summary, "; "))
+}
@@ -0,0 +1,89 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+var lagSLOClustersBreachingGauge = metrics.NewGauge()
+var lagSLOReplicasBreachingGauge = metrics.NewGauge()
+
+// lagSLOAggregate tracks lag SLO compliance for the metrics, so that sampling does not re-read the whole window
+var lagSLOAggregate = inst.NewLagSLOAggregate()
+var lagSLOAggregateMutex sync.Mutex
+
+func init() {
+	metrics.Register("lag_slo.clusters_breaching", lagSLOClustersBreachingGauge)
+	metrics.Register("lag_slo.replicas_breaching", lagSLOReplicasBreachingGauge)
+}
+
+func isReplicaLagSLOEnabled() bool {
+	return config.Config.ReplicaLagSLOSeconds > 0
+}
+
+// sampleReplicaLag records the lag of all replicas, and updates lag SLO metrics over ReplicaLagSLOWindowHours.
+// Only samples new since the previous sampling are read.
+func sampleReplicaLag() {
+	if !isReplicaLagSLOEnabled() {
+		return
+	}
+	if err := inst.RecordReplicaLagHistory(); err != nil {
+		return
+	}
+	lagSLOAggregateMutex.Lock()
+	defer lagSLOAggregateMutex.Unlock()
+
+	samples, lastSampleTimestamp, err := inst.ReadLagSamplesSince(lagSLOAggregate.LastSampleTimestamp, config.Config.ReplicaLagSLOWindowHours)
+	if err != nil {
+		return
+	}
+	lagSLOAggregate.Add(samples, lastSampleTimestamp, config.Config.ReplicaLagSLOSeconds)
+	lagSLOAggregate.Expire(config.Config.ReplicaLagSLOWindowHours)
+	clustersBreaching, replicasBreaching := lagSLOAggregate.Breaching(config.Config.ReplicaLagSLOTargetPercent)
+	lagSLOClustersBreachingGauge.Update(int64(clustersBreaching))
+	lagSLOReplicasBreachingGauge.Update(int64(replicasBreaching))
+}
+
+// ExpireReplicaLagHistory removes old replica lag samples
+func ExpireReplicaLagHistory() {
+	if err := inst.ExpireReplicaLagHistory(); err != nil {
+		log.Errore(err)
+	}
+}
+
+// GetLagSLOReport reports a cluster's replica lag SLO compliance over the past given hours; 0 stands for
+// ReplicaLagSLOWindowHours
+func GetLagSLOReport(clusterAlias string, hours uint) (*inst.LagSLOReport, error) {
+	if !isReplicaLagSLOEnabled() {
+		return nil, fmt.Errorf("Replica lag SLO tracking is disabled; see ReplicaLagSLOSeconds")
+	}
+	if hours == 0 {
+		hours = config.Config.ReplicaLagSLOWindowHours
+	}
+	if hours > 24*config.Config.ReplicaLagHistoryRetentionDays {
+		return nil, fmt.Errorf("Lag samples are only kept for %d days", config.Config.ReplicaLagHistoryRetentionDays)
+	}
+	return inst.ReadLagSLOReport(clusterAlias, hours)
+}
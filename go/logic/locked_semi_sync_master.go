@@ -0,0 +1,112 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// semiSyncRestoreMap tracks masters on which semi-sync was disabled, and is pending restore
+var semiSyncRestoreMap = cache.New(time.Hour, time.Minute)
+
+// countSemiSyncReplicas counts the replicas of given master that are replicating in semi-sync mode, as last seen
+func countSemiSyncReplicas(masterKey *inst.InstanceKey) (count uint, err error) {
+	replicas, err := inst.ReadReplicaInstances(masterKey)
+	if err != nil {
+		return 0, err
+	}
+	for _, replica := range replicas {
+		if replica.IsLastCheckValid && replica.SemiSyncReplicaEnabled && replica.ReplicaRunning() {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// restoreSemiSyncMaster re-enables semi-sync on a master once it has enough semi-sync replicas, or gives up
+// after given timeout, leaving semi-sync disabled
+func restoreSemiSyncMaster(masterKey *inst.InstanceKey, waitReplicaCount uint, timeout time.Duration) {
+	defer semiSyncRestoreMap.Delete(masterKey.StringCode())
+
+	pollInterval := time.Duration(config.Config.InstancePollSeconds) * time.Second
+	for startTime := time.Now(); time.Since(startTime) < timeout; time.Sleep(pollInterval) {
+		count, err := countSemiSyncReplicas(masterKey)
+		if err != nil || count < waitReplicaCount {
+			continue
+		}
+		if _, err := inst.SetSemiSyncMaster(masterKey, true); err != nil {
+			log.Errore(err)
+			continue
+		}
+		inst.AuditOperation("restore-semi-sync-master", masterKey, fmt.Sprintf("re-enabled semi-sync: %d semi-sync replicas replicating", count))
+		return
+	}
+	inst.AuditOperation("restore-semi-sync-master-timeout", masterKey, fmt.Sprintf("semi-sync left disabled: fewer than %d semi-sync replicas replicating after %+v", waitReplicaCount, timeout))
+}
+
+// checkAndRecoverLockedSemiSyncMaster disables semi-sync on a master locked on semi-sync ACK waits, as configured by
+// the cluster's semi-sync remedy, and restores semi-sync once enough semi-sync replicas replicate. This is not a
+// recovery: nothing is registered in topology_recovery. Analysis hook sets run as for any analysis without a recovery.
+func checkAndRecoverLockedSemiSyncMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	remedy := analysisEntry.ClusterDetails.SemiSyncRemedy()
+	if remedy == nil || remedy.Action != config.SemiSyncRemedyDisable {
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	masterKey := &analysisEntry.AnalyzedInstanceKey
+	refuse := func(reason string) (bool, *TopologyRecovery, error) {
+		if forceInstanceRecovery || util.ClearToLog("checkAndRecoverLockedSemiSyncMaster", masterKey.StringCode()) {
+			inst.AuditOperation("refuse-disable-semi-sync-master", masterKey, reason)
+		}
+		if forceInstanceRecovery {
+			return false, nil, fmt.Errorf("Refusing to disable semi-sync on %+v: %s", *masterKey, reason)
+		}
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	if err := inst.CheckNoTouch(masterKey); err != nil {
+		return refuse(err.Error())
+	}
+	master, found, err := inst.ReadInstance(masterKey)
+	if err != nil || !found {
+		return false, nil, err
+	}
+	if master.SemiSyncEnforced {
+		return refuse("semi-sync is enforced; async fallback is not allowed")
+	}
+	restoreTimeout := time.Duration(remedy.RestoreTimeout()) * time.Minute
+	if err := semiSyncRestoreMap.Add(masterKey.StringCode(), true, restoreTimeout); err != nil {
+		// Already disabled, and pending restore
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	if _, err := inst.SetSemiSyncMaster(masterKey, false); err != nil {
+		semiSyncRestoreMap.Delete(masterKey.StringCode())
+		inst.AuditOperation("disable-semi-sync-master-failed", masterKey, err.Error())
+		return false, nil, log.Errore(err)
+	}
+	inst.AuditOperation("disable-semi-sync-master", masterKey, analysisEntry.Description)
+	go restoreSemiSyncMaster(masterKey, analysisEntry.SemiSyncMasterWaitReplicaCount, restoreTimeout)
+
+	_, _, err = checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	return false, nil, err
+}
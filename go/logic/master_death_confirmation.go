@@ -0,0 +1,145 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+var recoverMasterUnconfirmedRefusalCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("recover.master.unconfirmed_refusal", recoverMasterUnconfirmedRefusalCounter)
+}
+
+// masterDeathVantagePoints returns the API base URLs of the vantage points asked to confirm a master's death
+func masterDeathVantagePoints() (apiURLs []string) {
+	apiURLs = append(apiURLs, config.Config.MasterDeathConfirmationVantagePoints...)
+	if config.Config.MasterDeathConfirmationRaftPeers && orcraft.IsRaftEnabled() {
+		peerURIs, err := orcraft.HealthyPeerURIs()
+		if err != nil {
+			log.Errore(err)
+		}
+		for _, peerURI := range peerURIs {
+			apiURLs = append(apiURLs, fmt.Sprintf("%s%s/api", peerURI, config.Config.URLPrefix))
+		}
+	}
+	return apiURLs
+}
+
+// checkReachabilityFromVantagePoint asks a vantage point whether it can reach given instance
+func checkReachabilityFromVantagePoint(httpClient *http.Client, apiURL string, instanceKey *inst.InstanceKey) (*inst.InstanceReachability, error) {
+	url := fmt.Sprintf("%s/check-instance-reachability/%s/%d", strings.TrimRight(apiURL, "/"), instanceKey.Hostname, instanceKey.Port)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(config.Config.AuthenticationMethod) {
+	case "basic", "multi":
+		req.SetBasicAuth(config.Config.HTTPAuthUser, config.Config.HTTPAuthPassword)
+	}
+	response, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	body, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("got %d status on %s", response.StatusCode, url)
+	}
+	reachability := &inst.InstanceReachability{}
+	if err := json.Unmarshal(body, reachability); err != nil {
+		return nil, err
+	}
+	return reachability, nil
+}
+
+// confirmMasterDeath asks all vantage points, concurrently, whether they can reach given master.
+// Returns the number of vantage points confirming the master is unreachable. A vantage point that
+// cannot be asked, or that can reach the master, does not confirm.
+func confirmMasterDeath(instanceKey *inst.InstanceKey) (confirmations int, details []string) {
+	httpClient := &http.Client{
+		Timeout:   time.Duration(config.Config.MasterDeathConfirmationTimeoutSeconds) * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: config.Config.MySQLOrchestratorSSLSkipVerify}},
+	}
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, apiURL := range masterDeathVantagePoints() {
+		apiURL := apiURL
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			reachability, err := checkReachabilityFromVantagePoint(httpClient, apiURL, instanceKey)
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			switch {
+			case err != nil:
+				details = append(details, fmt.Sprintf("%s: no answer: %+v", apiURL, err))
+			case reachability.Reachable:
+				details = append(details, fmt.Sprintf("%s: reachable", apiURL))
+			default:
+				confirmations++
+				details = append(details, fmt.Sprintf("%s: unreachable: %s", apiURL, reachability.Error))
+			}
+		}()
+	}
+	wg.Wait()
+	return confirmations, details
+}
+
+// refuseUnconfirmedMasterRecovery checks whether an automated master recovery must be refused because
+// not enough vantage points confirm the master is dead. Returns true when refused.
+func refuseUnconfirmedMasterRecovery(analysisEntry *inst.ReplicationAnalysis, forceInstanceRecovery bool) bool {
+	if forceInstanceRecovery || config.Config.MasterDeathConfirmationQuorum == 0 {
+		return false
+	}
+	switch analysisEntry.Analysis {
+	case inst.LockedSemiSyncMaster, inst.TooManyConnectionsMaster, inst.ReadOnlyFilesystemMaster, inst.DiskFullMaster:
+		// The master is alive by definition; failing it over is a policy decision. It is fenced before promotion.
+		return false
+	}
+	confirmations, details := confirmMasterDeath(&analysisEntry.AnalyzedInstanceKey)
+	if uint(confirmations) >= config.Config.MasterDeathConfirmationQuorum {
+		log.Infof("%+v on %+v confirmed by %d vantage points: %s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, confirmations, strings.Join(details, "; "))
+		return false
+	}
+	recoverMasterUnconfirmedRefusalCounter.Inc(1)
+	if util.ClearToLog("refuseUnconfirmedMasterRecovery", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+		log.Errorf("Refusing %+v recovery on %+v: confirmed by %d vantage points, quorum is %d: %s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, confirmations, config.Config.MasterDeathConfirmationQuorum, strings.Join(details, "; "))
+		inst.AuditOperation("refuse-unconfirmed-master-recovery", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%s: confirmed by %d/%d vantage points: %s", analysisEntry.Analysis, confirmations, config.Config.MasterDeathConfirmationQuorum, strings.Join(details, "; ")))
+	}
+	return true
+}
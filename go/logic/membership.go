@@ -0,0 +1,209 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/process"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/rcrowley/go-metrics"
+)
+
+var membershipViolationsGauge = metrics.NewGauge()
+
+func init() {
+	metrics.Register("membership.violations", membershipViolationsGauge)
+}
+
+// evaluateClusterMembership checks a cluster against its membership rules, with given instance as master
+func evaluateClusterMembership(clusterInfo *inst.ClusterInfo, masterKey *inst.InstanceKey) (violations []inst.MembershipViolation, err error) {
+	rules := clusterInfo.MembershipRules()
+	if len(rules) == 0 {
+		return violations, nil
+	}
+	instances, err := inst.ReadClusterInstances(clusterInfo.ClusterName)
+	if err != nil {
+		return violations, err
+	}
+	for _, rule := range rules {
+		violations = append(violations, inst.EvaluateMembershipRule(rule, clusterInfo, instances, masterKey)...)
+	}
+	return violations, nil
+}
+
+// GetMembershipViolations evaluates the membership rules of a cluster, or of all clusters when none is given
+func GetMembershipViolations(clusterName string) (violations []inst.MembershipViolation, err error) {
+	clustersInfo, err := inst.ReadClustersInfo(clusterName)
+	if err != nil {
+		return violations, err
+	}
+	for i := range clustersInfo {
+		clusterInfo := &clustersInfo[i]
+		if len(clusterInfo.MembershipRules()) == 0 {
+			continue
+		}
+		masters, err := inst.ReadClusterMaster(clusterInfo.ClusterName)
+		if err != nil {
+			return violations, err
+		}
+		masterKey := &inst.InstanceKey{}
+		if len(masters) > 0 {
+			masterKey = &masters[0].Key
+		}
+		clusterViolations, err := evaluateClusterMembership(clusterInfo, masterKey)
+		if err != nil {
+			return violations, err
+		}
+		violations = append(violations, clusterViolations...)
+	}
+	return violations, nil
+}
+
+// GetMembershipViolationsAfterPromotion forecasts the membership violations of a replica's cluster once the
+// replica is promoted
+func GetMembershipViolationsAfterPromotion(promotedKey *inst.InstanceKey) (violations []inst.MembershipViolation, err error) {
+	instance, found, err := inst.ReadInstance(promotedKey)
+	if err != nil {
+		return violations, err
+	}
+	if !found {
+		return violations, fmt.Errorf("Unknown instance %+v", *promotedKey)
+	}
+	clusterInfo, err := inst.ReadClusterInfo(instance.ClusterName)
+	if err != nil {
+		return violations, err
+	}
+	return evaluateClusterMembership(clusterInfo, promotedKey)
+}
+
+// replaceMembershipViolationPlaceholders replaces the placeholders of an OnMembershipViolationProcesses hook
+func replaceMembershipViolationPlaceholders(command string, violation *inst.MembershipViolation) string {
+	command = strings.Replace(command, "{failureCluster}", violation.ClusterName, -1)
+	command = strings.Replace(command, "{failureClusterAlias}", violation.ClusterAlias, -1)
+	command = strings.Replace(command, "{membershipRule}", violation.Rule, -1)
+	command = strings.Replace(command, "{dataCenter}", violation.DataCenter, -1)
+	command = strings.Replace(command, "{violation}", violation.Description, -1)
+	command = strings.Replace(command, "{orchestratorHost}", process.ThisHostname, -1)
+	return command
+}
+
+// applyMembershipViolationEnvironmentVariables sets the relevant environment variables for a membership violation
+func applyMembershipViolationEnvironmentVariables(violation *inst.MembershipViolation) []string {
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER=%s", violation.ClusterName))
+	env = append(env, fmt.Sprintf("ORC_FAILURE_CLUSTER_ALIAS=%s", violation.ClusterAlias))
+	env = append(env, fmt.Sprintf("ORC_MEMBERSHIP_RULE=%s", violation.Rule))
+	env = append(env, fmt.Sprintf("ORC_DATA_CENTER=%s", violation.DataCenter))
+	env = append(env, fmt.Sprintf("ORC_VIOLATION=%s", violation.Description))
+	env = append(env, fmt.Sprintf("ORC_ORCHESTRATOR_HOST=%s", process.ThisHostname))
+	return env
+}
+
+// executeMembershipViolationProcesses notifies OnMembershipViolationProcesses of a new violation.
+// Failures are audited.
+func executeMembershipViolationProcesses(violation *inst.MembershipViolation) {
+	processes := config.Config.OnMembershipViolationProcesses
+	for i, command := range processes {
+		fullDescription := fmt.Sprintf("OnMembershipViolationProcesses hook %d of %d", i+1, len(processes))
+		command := replaceMembershipViolationPlaceholders(command, violation)
+		env := applyMembershipViolationEnvironmentVariables(violation)
+
+		start := time.Now()
+		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+			inst.AuditOperation("membership-violation-hook", nil, fmt.Sprintf("%s: completed %s in %v: %s", violation.ClusterName, fullDescription, time.Since(start), command))
+		} else {
+			inst.AuditOperation("membership-violation-hook", nil, fmt.Sprintf("%s: %s failed in %v with error: %v: %s", violation.ClusterName, fullDescription, time.Since(start), cmdErr, command))
+			log.Errorf("Execution of %s failed: %+v", fullDescription, cmdErr)
+		}
+	}
+}
+
+// writeMembershipViolations stores the violations found by the latest evaluation, so that a newly elected
+// leader does not alert on them again
+func writeMembershipViolations(violations []inst.MembershipViolation) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-membership-violations", violations)
+		return err
+	}
+	return inst.WriteMembershipViolations(violations)
+}
+
+// EvaluateMembershipRules checks all clusters against MembershipRules. New violations are audited and run
+// OnMembershipViolationProcesses; violations no longer found are audited as restored. Violations are compared
+// with those stored by the previous evaluation, possibly by a previous leader.
+func EvaluateMembershipRules() {
+	if len(config.Config.MembershipRules) == 0 {
+		return
+	}
+	violations, err := GetMembershipViolations("")
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	storedViolations, err := inst.ReadMembershipViolations()
+	if err != nil {
+		return
+	}
+	current := make(map[string]inst.MembershipViolation)
+	for _, violation := range violations {
+		current[violation.Code()] = violation
+	}
+	previous := make(map[string]inst.MembershipViolation)
+	for _, violation := range storedViolations {
+		previous[violation.Code()] = violation
+	}
+	membershipViolationsGauge.Update(int64(len(current)))
+
+	changed := len(current) != len(previous)
+	for code, violation := range current {
+		if previousViolation, found := previous[code]; !found || previousViolation != violation {
+			changed = true
+		}
+	}
+	if !changed {
+		return
+	}
+	if err := writeMembershipViolations(violations); err != nil {
+		// Alerting without having stored the violations would alert again on the next evaluation
+		log.Errore(err)
+		return
+	}
+
+	for code, violation := range current {
+		if _, found := previous[code]; found {
+			continue
+		}
+		violation := violation
+		inst.AuditOperation("membership-violation", nil, fmt.Sprintf("cluster %s: rule %s: data center %s: %s", violation.ClusterName, violation.Rule, violation.DataCenter, violation.Description))
+		go executeMembershipViolationProcesses(&violation)
+	}
+	for code, violation := range previous {
+		if _, found := current[code]; found {
+			continue
+		}
+		inst.AuditOperation("membership-restored", nil, fmt.Sprintf("cluster %s: rule %s: data center %s: %s", violation.ClusterName, violation.Rule, violation.DataCenter, violation.Description))
+	}
+}
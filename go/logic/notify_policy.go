@@ -0,0 +1,153 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// escalationGraceMinutes bounds how overdue an escalation may be. Recoveries that became due for escalation
+// longer ago, e.g. before the policy was configured, are not escalated.
+const escalationGraceMinutes = 60
+
+// countRecentDetections counts failure detections of given analysis on given cluster within the last minutes,
+// including the detection just registered
+func countRecentDetections(clusterName string, analysis inst.AnalysisCode, minutes uint) (count int, err error) {
+	query := `
+		select
+			count(*) as count_detections
+		from
+			topology_failure_detection
+		where
+			cluster_name = ?
+			and analysis = ?
+			and start_active_period >= NOW() - INTERVAL ? MINUTE
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(clusterName, string(analysis), minutes), func(m sqlutils.RowMap) error {
+		count = m.GetInt("count_detections")
+		return nil
+	})
+	return count, log.Errore(err)
+}
+
+// silenceDetectionReason returns the reason a detection does not notify per the cluster's notification
+// policies, or an empty string if it should notify
+func silenceDetectionReason(analysisEntry *inst.ReplicationAnalysis) string {
+	for i := range config.Config.NotifyPolicies {
+		policy := &config.Config.NotifyPolicies[i]
+		if !analysisEntry.ClusterDetails.NotifyPolicyApplies(policy) || !policy.AppliesToAnalysis(string(analysisEntry.Analysis)) {
+			continue
+		}
+		if policy.SilenceDowntimed && analysisEntry.IsDowntimed {
+			return fmt.Sprintf("%+v is downtimed (notify policy #%d)", analysisEntry.AnalyzedInstanceKey, i)
+		}
+		if policy.DedupWindowMinutes > 0 {
+			count, err := countRecentDetections(analysisEntry.ClusterDetails.ClusterName, analysisEntry.Analysis, policy.DedupWindowMinutes)
+			if err == nil && count > 1 {
+				return fmt.Sprintf("%+v already detected on %s within %d minutes (notify policy #%d)", analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterName, policy.DedupWindowMinutes, i)
+			}
+		}
+	}
+	return ""
+}
+
+// RecoveryEscalation marks a recovery as escalated by a notification policy
+type RecoveryEscalation struct {
+	RecoveryUID string
+	PolicyIndex int
+}
+
+// registerRecoveryEscalation marks a recovery as escalated, returning false if it already was
+func registerRecoveryEscalation(escalation *RecoveryEscalation) (bool, error) {
+	sqlResult, err := db.ExecOrchestrator(`
+			insert ignore into topology_recovery_escalation (
+				recovery_uid, policy_index, escalated_at
+			) values (
+				?, ?, NOW()
+			)
+		`, escalation.RecoveryUID, escalation.PolicyIndex,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	rows, err := sqlResult.RowsAffected()
+	return rows > 0, log.Errore(err)
+}
+
+// publishRecoveryEscalation marks a recovery as escalated, returning false if it already was; this is raft-aware,
+// so that a new leader does not escalate the recovery again
+func publishRecoveryEscalation(escalation *RecoveryEscalation) (bool, error) {
+	if orcraft.IsRaftEnabled() {
+		response, err := orcraft.PublishCommand("register-recovery-escalation", escalation)
+		if err != nil {
+			return false, log.Errore(err)
+		}
+		registered, _ := response.(bool)
+		return registered, nil
+	}
+	return registerRecoveryEscalation(escalation)
+}
+
+// EscalateUnacknowledgedRecoveries runs the EscalationProcesses of notification policies on recoveries
+// left unacknowledged for the policy's EscalateAfterMinutes. A recovery is escalated at most once.
+func EscalateUnacknowledgedRecoveries() error {
+	for i := range config.Config.NotifyPolicies {
+		policy := &config.Config.NotifyPolicies[i]
+		if policy.EscalateAfterMinutes == 0 {
+			continue
+		}
+		whereClause := `
+			where
+				acknowledged = 0
+				and start_active_period <= NOW() - INTERVAL ? MINUTE
+				and start_active_period > NOW() - INTERVAL ? MINUTE
+				and uid not in (select recovery_uid from topology_recovery_escalation)
+			`
+		args := sqlutils.Args(policy.EscalateAfterMinutes, policy.EscalateAfterMinutes+escalationGraceMinutes)
+		recoveries, err := readRecoveries(whereClause, ``, args)
+		if err != nil {
+			return log.Errore(err)
+		}
+		for j := range recoveries {
+			topologyRecovery := &recoveries[j]
+			if !topologyRecovery.AnalysisEntry.ClusterDetails.NotifyPolicyApplies(policy) || !policy.AppliesToAnalysis(string(topologyRecovery.AnalysisEntry.Analysis)) {
+				continue
+			}
+			registered, err := publishRecoveryEscalation(&RecoveryEscalation{RecoveryUID: topologyRecovery.UID, PolicyIndex: i})
+			if err != nil || !registered {
+				continue
+			}
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("escalating: recovery unacknowledged after %d minutes (notify policy #%d)", policy.EscalateAfterMinutes, i))
+			inst.AuditOperation("escalate-recovery", &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, fmt.Sprintf("recovery %d on %s unacknowledged after %d minutes", topologyRecovery.Id, topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName, policy.EscalateAfterMinutes))
+			executeProcesses(policy.EscalationProcesses, "EscalationProcesses", topologyRecovery, false)
+		}
+	}
+	return nil
+}
+
+// ExpireRecoveryEscalations removes old rows from the topology_recovery_escalation table
+func ExpireRecoveryEscalations() error {
+	return inst.ExpireTableData("topology_recovery_escalation", "escalated_at")
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/github/orchestrator/go/collection"
 	"github.com/github/orchestrator/go/config"
 	"github.com/github/orchestrator/go/discovery"
+	"github.com/github/orchestrator/go/federation"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	ometrics "github.com/github/orchestrator/go/metrics"
@@ -45,6 +46,7 @@ import (
 const (
 	discoveryMetricsName        = "DISCOVERY_METRICS"
 	yieldAfterUnhealthyDuration = 5 * config.HealthPollSeconds * time.Second
+	minPartitionRequeueDelay    = 100 * time.Millisecond
 	fatalAfterUnhealthyDuration = 30 * config.HealthPollSeconds * time.Second
 )
 
@@ -52,12 +54,16 @@ const (
 // that were requested for discovery.  It can be continuously updated
 // as discovery process progresses.
 var discoveryQueue *discovery.Queue
+var discoveryPartitions = discovery.NewPartitions()
+var deferredDiscoveryKeys = cache.New(time.Minute, time.Minute)
 var snapshotDiscoveryKeys chan inst.InstanceKey
 var snapshotDiscoveryKeysMutex sync.Mutex
 
 var discoveriesCounter = metrics.NewCounter()
 var failedDiscoveriesCounter = metrics.NewCounter()
 var instancePollSecondsExceededCounter = metrics.NewCounter()
+var partitionDeferredDiscoveriesCounter = metrics.NewCounter()
+var seededDiscoveriesCounter = metrics.NewCounter()
 var discoveryQueueLengthGauge = metrics.NewGauge()
 var discoveryRecentCountGauge = metrics.NewGauge()
 var isElectedGauge = metrics.NewGauge()
@@ -71,6 +77,12 @@ var isElectedNode int64 = 0
 var recentDiscoveryOperationKeys *cache.Cache
 var pseudoGTIDPublishCache = cache.New(time.Minute, time.Second)
 var kvFoundCache = cache.New(10*time.Minute, time.Minute)
+var heartbeatWritersCache = cache.New(time.Second, time.Second)
+
+// heartbeatsInFlight tracks writers with a heartbeat injection under way. A writer slow to respond gets no
+// further injections until its pending one returns.
+var heartbeatsInFlight = make(map[inst.InstanceKey]bool)
+var heartbeatsInFlightMutex sync.Mutex
 
 func init() {
 	snapshotDiscoveryKeys = make(chan inst.InstanceKey, 10)
@@ -78,6 +90,8 @@ func init() {
 	metrics.Register("discoveries.attempt", discoveriesCounter)
 	metrics.Register("discoveries.fail", failedDiscoveriesCounter)
 	metrics.Register("discoveries.instance_poll_seconds_exceeded", instancePollSecondsExceededCounter)
+	metrics.Register("discoveries.partition_deferred", partitionDeferredDiscoveriesCounter)
+	metrics.Register("discoveries.seeded", seededDiscoveriesCounter)
 	metrics.Register("discoveries.queue_length", discoveryQueueLengthGauge)
 	metrics.Register("discoveries.recent_count", discoveryRecentCountGauge)
 	metrics.Register("elect.is_elected", isElectedGauge)
@@ -131,6 +145,21 @@ func instancePollSecondsDuration() time.Duration {
 	return time.Duration(config.Config.InstancePollSeconds) * time.Second
 }
 
+// ReloadConfiguration re-reads configuration files and applies changed settings, unless the new configuration
+// is invalid, in which case the running configuration stays in place
+func ReloadConfiguration(trigger string) (*config.ReloadReport, error) {
+	report, err := config.Reload()
+	if err != nil {
+		log.Errorf("Configuration reload via %s failed; running configuration unchanged: %+v", trigger, err)
+		inst.AuditOperation("reload-configuration-failed", nil, fmt.Sprintf("Triggered via %s: %+v", trigger, err))
+		return nil, err
+	}
+	discoveryMetrics.SetExpirePeriod(time.Duration(config.Config.DiscoveryCollectionRetentionSeconds) * time.Second)
+	log.Infof("Configuration reloaded via %s; %s", trigger, report.String())
+	inst.AuditOperation("reload-configuration", nil, fmt.Sprintf("Triggered via %s; %s", trigger, report.String()))
+	return report, nil
+}
+
 // acceptSignals registers for OS signals
 func acceptSignals() {
 	c := make(chan os.Signal, 1)
@@ -142,9 +171,7 @@ func acceptSignals() {
 			switch sig {
 			case syscall.SIGHUP:
 				log.Infof("Received SIGHUP. Reloading configuration")
-				inst.AuditOperation("reload-configuration", nil, "Triggered via SIGHUP")
-				config.Reload()
-				discoveryMetrics.SetExpirePeriod(time.Duration(config.Config.DiscoveryCollectionRetentionSeconds) * time.Second)
+				ReloadConfiguration("SIGHUP")
 			case syscall.SIGTERM:
 				log.Infof("Received SIGTERM. Shutting down orchestrator")
 				discoveryMetrics.StopAutoExpiration()
@@ -175,13 +202,73 @@ func handleDiscoveryRequests() {
 					continue
 				}
 
+				partition, acquired := discoveryPartitions.Acquire(instanceKey)
+				if !acquired {
+					// Leave this worker to other partitions
+					discoveryQueue.Release(instanceKey)
+					deferPartitionedDiscovery(instanceKey, partition)
+					continue
+				}
+
 				DiscoverInstance(instanceKey)
+				discoveryPartitions.Release(partition)
 				discoveryQueue.Release(instanceKey)
 			}
 		}()
 	}
 }
 
+// deferPartitionedDiscovery requeues the discovery of given key, whose partition has reached its concurrency
+// limit, after a jittered delay
+func deferPartitionedDiscovery(instanceKey inst.InstanceKey, partition string) {
+	partitionDeferredDiscoveriesCounter.Inc(1)
+	delay := minPartitionRequeueDelay
+	if jitter := int64(config.Config.DiscoveryPartitionRequeueJitterMillis); jitter > 0 {
+		delay += time.Duration(rand.Int63n(jitter)) * time.Millisecond
+	}
+	if err := deferredDiscoveryKeys.Add(instanceKey.StringCode(), true, delay); err != nil {
+		// Already deferred
+		return
+	}
+	if util.ClearToLog("deferPartitionedDiscovery", partition) {
+		log.Debugf("deferPartitionedDiscovery: partition %q at its concurrency limit; deferring discovery of %+v by %+v", partition, instanceKey, delay)
+	}
+	go func() {
+		time.Sleep(delay)
+		discoveryQueue.Push(instanceKey)
+	}()
+}
+
+// seedDiscovery queues for discovery the instances listed by inventory sources (cloud provider APIs,
+// HTTP inventory endpoints)
+func seedDiscovery() {
+	if !discovery.IsSeedingEnabled() {
+		return
+	}
+	seededKeys := 0
+	for _, instanceKey := range discovery.ReadSeedKeys() {
+		if inst.InstanceIsForgotten(&instanceKey) {
+			continue
+		}
+		discoveryQueue.Push(instanceKey)
+		seededKeys++
+	}
+	seededDiscoveriesCounter.Inc(int64(seededKeys))
+}
+
+// refreshDiscoveryPartitions reads the partitions of all known instances from the backend
+func refreshDiscoveryPartitions() error {
+	if !discovery.IsPartitioningEnabled() {
+		return nil
+	}
+	keyPartitions, err := inst.ReadInstanceDiscoveryPartitions(config.Config.DiscoveryPartitionBy)
+	if err != nil {
+		return err
+	}
+	discoveryPartitions.Reset(keyPartitions)
+	return nil
+}
+
 // DiscoverInstance will attempt to discover (poll) an instance (unless
 // it is already up to date) and will also ensure that its master and
 // replicas (if any) are also checked.
@@ -215,7 +302,7 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 	// Calculate the expiry period each time as InstancePollSeconds
 	// _may_ change during the run of the process (via SIGHUP) and
 	// it is not possible to change the cache's default expiry..
-	if existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, instancePollSecondsDuration()); existsInCacheError != nil {
+	if existsInCacheError := recentDiscoveryOperationKeys.Add(instanceKey.DisplayString(), true, inst.GetInstancePollInterval(&instanceKey)); existsInCacheError != nil {
 		// Just recently attempted
 		return
 	}
@@ -225,7 +312,14 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 	latency.Stop("backend")
 	if found && instance.IsUpToDate && instance.IsLastCheckValid {
 		// we've already discovered this one. Skip!
-		return
+		// With adaptive polling, IsUpToDate is by InstancePollSeconds, whereas an unstable instance is due earlier.
+		if !config.Config.IsAdaptiveInstancePolling() || !inst.IsInstancePollDue(&instanceKey) {
+			return
+		}
+	}
+	var previousInstance *inst.Instance
+	if found && instance.IsLastCheckValid {
+		previousInstance = instance
 	}
 
 	discoveriesCounter.Inc(1)
@@ -256,8 +350,10 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 				instanceLatency.Seconds(),
 				err)
 		}
+		inst.RecordInstancePollFailure(&instanceKey)
 		return
 	}
+	inst.RecordInstancePoll(previousInstance, instance)
 
 	discoveryMetrics.Append(&discovery.Metric{
 		Timestamp:       time.Now(),
@@ -267,6 +363,12 @@ func DiscoverInstance(instanceKey inst.InstanceKey) {
 		InstanceLatency: instanceLatency,
 		Err:             nil,
 	})
+	if discovery.IsPartitioningEnabled() {
+		discoveryPartitions.SetPartition(instanceKey, discovery.InstancePartition(instance))
+	}
+	if err == nil && previousInstance != nil {
+		go inst.AuditInstanceChanges(previousInstance, instance)
+	}
 
 	if !IsLeaderOrActive() {
 		// Maybe this node was elected before, but isn't elected anymore.
@@ -378,6 +480,43 @@ func publishDiscoverMasters() error {
 	return log.Errore(err)
 }
 
+// InjectHeartbeatOnWriters writes a heartbeat on all writable, accessible masters
+func InjectHeartbeatOnWriters() error {
+	// Heartbeats are frequent; the list of writers need not be read from the backend on each
+	var instances [](*inst.Instance)
+	if cached, found := heartbeatWritersCache.Get("writers"); found {
+		instances = cached.([](*inst.Instance))
+	} else {
+		var err error
+		if instances, err = inst.ReadWriteableClustersMasters(); err != nil {
+			return log.Errore(err)
+		}
+		heartbeatWritersCache.Set("writers", instances, cache.DefaultExpiration)
+	}
+	for _, instance := range instances {
+		go injectHeartbeat(instance)
+	}
+	return nil
+}
+
+// injectHeartbeat writes a heartbeat on given writer, unless an injection on it is already under way
+func injectHeartbeat(instance *inst.Instance) {
+	heartbeatsInFlightMutex.Lock()
+	if heartbeatsInFlight[instance.Key] {
+		heartbeatsInFlightMutex.Unlock()
+		return
+	}
+	heartbeatsInFlight[instance.Key] = true
+	heartbeatsInFlightMutex.Unlock()
+
+	defer func() {
+		heartbeatsInFlightMutex.Lock()
+		defer heartbeatsInFlightMutex.Unlock()
+		delete(heartbeatsInFlight, instance.Key)
+	}()
+	inst.InjectHeartbeat(instance)
+}
+
 // InjectPseudoGTIDOnWriters will inject a PseudoGTID entry on all writable, accessible,
 // supported writers.
 func InjectPseudoGTIDOnWriters() error {
@@ -463,6 +602,7 @@ func ContinuousDiscovery() {
 	recentDiscoveryOperationKeys = cache.New(instancePollSecondsDuration(), time.Second)
 
 	inst.LoadHostnameResolveCache()
+	log.Errore(refreshDiscoveryPartitions())
 	go handleDiscoveryRequests()
 
 	healthTick := time.Tick(config.HealthPollSeconds * time.Second)
@@ -473,9 +613,33 @@ func ContinuousDiscovery() {
 	autoPseudoGTIDTick := time.Tick(time.Duration(config.PseudoGTIDIntervalSeconds) * time.Second)
 	var recoveryEntrance int64
 	var snapshotTopologiesTick <-chan time.Time
+	var clusterTopologySnapshotTick <-chan time.Time
+	var seedDiscoveryTick <-chan time.Time
+	var heartbeatTick <-chan time.Time
+	var federationPollTick <-chan time.Time
+	var readPoolTick <-chan time.Time
+	var retentionArchiveTick <-chan time.Time
+	if config.Config.DiscoverySeedIntervalSeconds > 0 {
+		seedDiscoveryTick = time.Tick(time.Duration(config.Config.DiscoverySeedIntervalSeconds) * time.Second)
+	}
+	if config.Config.HeartbeatIntervalMilliseconds > 0 {
+		heartbeatTick = time.Tick(time.Duration(config.Config.HeartbeatIntervalMilliseconds) * time.Millisecond)
+	}
+	if federation.IsEnabled() {
+		federationPollTick = time.Tick(time.Duration(config.Config.FederationPollSeconds) * time.Second)
+	}
+	if isReadPoolEnabled() {
+		readPoolTick = time.Tick(time.Duration(config.Config.ReadPoolPublishIntervalSeconds) * time.Second)
+	}
+	if isRetentionArchiveEnabled() {
+		retentionArchiveTick = time.Tick(time.Duration(config.Config.RetentionArchiveIntervalMinutes) * time.Minute)
+	}
 	if config.Config.SnapshotTopologiesIntervalHours > 0 {
 		snapshotTopologiesTick = time.Tick(time.Duration(config.Config.SnapshotTopologiesIntervalHours) * time.Hour)
 	}
+	if config.Config.ClusterTopologySnapshotIntervalMinutes > 0 {
+		clusterTopologySnapshotTick = time.Tick(time.Duration(config.Config.ClusterTopologySnapshotIntervalMinutes) * time.Minute)
+	}
 
 	runCheckAndRecoverOperationsTimeRipe := func() bool {
 		return time.Since(continuousDiscoveryStartTime) >= checkAndRecoverWaitPeriod
@@ -511,6 +675,18 @@ func ContinuousDiscovery() {
 				if IsLeaderOrActive() {
 					go inst.UpdateClusterAliases()
 					go inst.ExpireDowntime()
+					go inst.ApplyDowntimeSchedules()
+					go inst.ExpireNoTouchLocks()
+					go ProbeUnusableMasters()
+				}
+				if IsLeader() {
+					go RunScheduledMasterTakeovers()
+				}
+			}()
+		case <-seedDiscoveryTick:
+			go func() {
+				if IsLeaderOrActive() {
+					seedDiscovery()
 				}
 			}()
 		case <-autoPseudoGTIDTick:
@@ -519,11 +695,36 @@ func ContinuousDiscovery() {
 					go InjectPseudoGTIDOnWriters()
 				}
 			}()
+		case <-heartbeatTick:
+			go func() {
+				if IsLeader() {
+					go InjectHeartbeatOnWriters()
+				}
+			}()
+		case <-federationPollTick:
+			go func() {
+				if IsLeaderOrActive() {
+					federation.PollMembers()
+				}
+			}()
+		case <-readPoolTick:
+			go func() {
+				if IsLeaderOrActive() {
+					publishReadPools()
+				}
+			}()
+		case <-retentionArchiveTick:
+			go func() {
+				if IsLeaderOrActive() {
+					ArchiveRetainedTables()
+				}
+			}()
 		case <-caretakingTick:
 			// Various periodic internal maintenance tasks
 			go func() {
 				if IsLeaderOrActive() {
 					go inst.RecordInstanceCoordinatesHistory()
+					go sampleReplicaLag()
 					go inst.ReviewUnseenInstances()
 					go inst.InjectUnseenMasters()
 
@@ -533,10 +734,15 @@ func ContinuousDiscovery() {
 					go inst.DeleteInvalidHostnameResolves()
 					go inst.ResolveUnknownMasterHostnameResolves()
 					go inst.ExpireMaintenance()
+					go inst.ExpireClusterMaintenance()
+					go inst.ExpireProblemSuppressions()
 					go inst.ExpireCandidateInstances()
 					go inst.ExpireHostnameUnresolve()
 					go inst.ExpireClusterDomainName()
-					go inst.ExpireAudit()
+					go ApplyClusterTemplates()
+					go refreshDiscoveryPartitions()
+					go ExpireRetainedTables()
+					go inst.ExpireInstanceChanges()
 					go inst.ExpireMasterPositionEquivalence()
 					go inst.ExpirePoolInstances()
 					go inst.FlushNontrivialResolveCacheToDatabase()
@@ -544,13 +750,32 @@ func ContinuousDiscovery() {
 					go process.ExpireNodesHistory()
 					go process.ExpireAccessTokens()
 					go process.ExpireAvailableNodes()
-					go ExpireFailureDetectionHistory()
-					go ExpireTopologyRecoveryHistory()
-					go ExpireTopologyRecoveryStepsHistory()
+					go ExpireRecoveryJournal()
+					go ExpireRecoveryEscalations()
+					go ExpireReplicaRemedies()
+					go inst.ExpireUnusableMasterSignals()
+					go ExpireRecoveryTopologySnapshots()
+					go inst.ExpireClusterTopologySnapshots()
+					go ExpireReplicaLagHistory()
+					go ExpirePostponedFunctions()
+					go ExpireScheduledMasterTakeovers()
+					go ExpireReplicaProvisioningRequests()
+					go ExpireReplicaRebuilds()
+					go FailStaleReplicaRebuilds()
+					go ExpireCanaryShadowDecisions()
+					go inst.ExpireFleetReports()
+					go inst.GenerateScheduledFleetReport()
 
 					if runCheckAndRecoverOperationsTimeRipe() && IsLeader() {
 						go SubmitMastersToKvStores("", false)
 					}
+					if IsLeader() {
+						go ResolveReplicaProvisioningRequests()
+						go AutoAcknowledgeRecoveries()
+						go EscalateUnacknowledgedRecoveries()
+						go EvaluateMembershipRules()
+						go WatchMastersBinlogSpace()
+					}
 				} else {
 					// Take this opportunity to refresh yourself
 					go inst.LoadHostnameResolveCache()
@@ -566,8 +791,12 @@ func ContinuousDiscovery() {
 					go ClearActiveFailureDetections()
 					go ClearActiveRecoveries()
 					go ExpireBlockedRecoveries()
-					go AcknowledgeCrashedRecoveries()
-					go inst.ExpireInstanceAnalysisChangelog()
+					go func() {
+						// Resume what can be resumed before acknowledging crashed recoveries
+						ResumeInterruptedRecoveries()
+						AcknowledgeCrashedRecoveries()
+					}()
+					go DetectDroppedPostponedFunctions()
 
 					go func() {
 						// This function is non re-entrant (it can only be running once at any point in time)
@@ -590,6 +819,12 @@ func ContinuousDiscovery() {
 					go inst.SnapshotTopologies()
 				}
 			}()
+		case <-clusterTopologySnapshotTick:
+			go func() {
+				if IsLeaderOrActive() {
+					go WriteClusterTopologySnapshots()
+				}
+			}()
 		}
 	}
 }
@@ -0,0 +1,249 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// pagerDutyRequestTimeout caps the time given to posting an event to PagerDuty
+const pagerDutyRequestTimeout = 10 * time.Second
+
+// PagerDuty Events API v2 event actions
+const (
+	pagerDutyTrigger     = "trigger"
+	pagerDutyAcknowledge = "acknowledge"
+	pagerDutyResolve     = "resolve"
+)
+
+// pagerDutyResolvedRecoveries holds the UIDs of recoveries whose outcome was posted, so that a recovery
+// resolved again (e.g. a promotion failed after the fact) does not reopen its incident
+var pagerDutyResolvedRecoveries = cache.New(time.Hour, time.Minute)
+
+// pagerDutyPayload describes the incident of a trigger event
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Component     string            `json:"component,omitempty"`
+	Group         string            `json:"group,omitempty"`
+	Class         string            `json:"class,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+// pagerDutyEvent is a PagerDuty Events API v2 event. Events sharing a dedup key apply to the same incident.
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+func isPagerDutyEnabled() bool {
+	return config.Config.PagerDutyRoutingKey != ""
+}
+
+// pagerDutySeverity returns the severity of a cluster's incidents, per PagerDutySeverities
+func pagerDutySeverity(clusterName string, clusterAlias string) string {
+	patterns := []string{}
+	for pattern := range config.Config.PagerDutySeverities {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	for _, pattern := range patterns {
+		if matched, _ := regexp.MatchString(pattern, clusterName); matched {
+			return config.Config.PagerDutySeverities[pattern]
+		}
+		if clusterAlias == "" {
+			continue
+		}
+		if matched, _ := regexp.MatchString(pattern, clusterAlias); matched {
+			return config.Config.PagerDutySeverities[pattern]
+		}
+	}
+	return config.Config.PagerDutyDefaultSeverity
+}
+
+// pagerDutyDedupKey returns the key of the incident a recovery, or a detection, belongs to, per PagerDutyDedupKey.
+// A detection has no recovery UID yet, and so has no key when incidents are keyed by recovery.
+func pagerDutyDedupKey(topologyRecovery *TopologyRecovery) string {
+	if config.Config.PagerDutyDedupKey == config.PagerDutyDedupKeyRecovery {
+		if topologyRecovery.UID == "" {
+			return ""
+		}
+		return fmt.Sprintf("orchestrator/recovery/%s", topologyRecovery.UID)
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	return fmt.Sprintf("orchestrator/%s/%s", analysisEntry.ClusterDetails.ClusterName, analysisEntry.Analysis)
+}
+
+// newPagerDutyPayload describes a recovery's, or a detection's, incident
+func newPagerDutyPayload(topologyRecovery *TopologyRecovery, summary string) *pagerDutyPayload {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	payload := &pagerDutyPayload{
+		Summary:   summary,
+		Source:    analysisEntry.AnalyzedInstanceKey.StringCode(),
+		Severity:  pagerDutySeverity(analysisEntry.ClusterDetails.ClusterName, analysisEntry.ClusterDetails.ClusterAlias),
+		Component: analysisEntry.ClusterDetails.ClusterAlias,
+		Group:     analysisEntry.ClusterDetails.ClusterName,
+		Class:     string(analysisEntry.Analysis),
+		CustomDetails: map[string]string{
+			"description":   analysisEntry.Description,
+			"orchestrator":  process.ThisHostname,
+			"cluster":       analysisEntry.ClusterDetails.ClusterName,
+			"cluster_alias": analysisEntry.ClusterDetails.ClusterAlias,
+		},
+	}
+	if topologyRecovery.UID != "" {
+		payload.CustomDetails["recovery_uid"] = topologyRecovery.UID
+	}
+	if topologyRecovery.SuccessorKey != nil {
+		payload.CustomDetails["successor"] = topologyRecovery.SuccessorKey.StringCode()
+	}
+	if len(topologyRecovery.AllErrors) > 0 {
+		payload.CustomDetails["errors"] = fmt.Sprintf("%+v", topologyRecovery.AllErrors)
+	}
+	return payload
+}
+
+// sendPagerDutyEvent posts an event to the PagerDuty Events API
+func sendPagerDutyEvent(event *pagerDutyEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: pagerDutyRequestTimeout}
+	resp, err := client.Post(config.Config.PagerDutyEventsURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("PagerDuty responded %s", resp.Status)
+	}
+	return nil
+}
+
+// notifyPagerDuty posts an event on a recovery's incident in the background, so as not to hold up recovery.
+// A command line invocation posts synchronously, as it would otherwise exit before the event is sent.
+func notifyPagerDuty(topologyRecovery *TopologyRecovery, eventAction string, payload *pagerDutyPayload) {
+	dedupKey := pagerDutyDedupKey(topologyRecovery)
+	if dedupKey == "" {
+		return
+	}
+	event := &pagerDutyEvent{
+		RoutingKey:  config.Config.PagerDutyRoutingKey,
+		EventAction: eventAction,
+		DedupKey:    dedupKey,
+		Payload:     payload,
+	}
+	analyzedInstanceKey := topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	send := func() {
+		if err := sendPagerDutyEvent(event); err != nil {
+			log.Errorf("PagerDuty %s of %s: %+v", eventAction, dedupKey, err)
+			inst.AuditOperation("pagerduty-failed", &analyzedInstanceKey, fmt.Sprintf("%s of %s: %+v", eventAction, dedupKey, err))
+			return
+		}
+		inst.AuditOperation("pagerduty", &analyzedInstanceKey, fmt.Sprintf("%s of %s", eventAction, dedupKey))
+	}
+	if config.RuntimeCLIFlags.CLIMode {
+		send()
+		return
+	}
+	go send()
+}
+
+// pagerDutyTriggerDetection pages on a failure detection, when incidents are keyed by analysis
+func pagerDutyTriggerDetection(analysisEntry *inst.ReplicationAnalysis) {
+	if !isPagerDutyEnabled() || config.Config.PagerDutyDedupKey != config.PagerDutyDedupKeyAnalysis {
+		return
+	}
+	topologyRecovery := NewTopologyRecovery(*analysisEntry)
+	// Not a registered recovery; no UID to report
+	topologyRecovery.UID = ""
+	summary := fmt.Sprintf("%s on %s (cluster %s)", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey.DisplayString(), analysisEntry.ClusterDetails.ClusterAlias)
+	notifyPagerDuty(topologyRecovery, pagerDutyTrigger, newPagerDutyPayload(topologyRecovery, summary))
+}
+
+// pagerDutyTriggerRecovery pages when recovery of a detected failure begins, when incidents are keyed by recovery.
+// Recoveries an operator requested, e.g. graceful takeovers, do not page.
+func pagerDutyTriggerRecovery(topologyRecovery *TopologyRecovery) {
+	if !isPagerDutyEnabled() || config.Config.PagerDutyDedupKey != config.PagerDutyDedupKeyRecovery {
+		return
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	if analysisEntry.CommandHint != "" {
+		return
+	}
+	summary := fmt.Sprintf("Recovering %s on %s (cluster %s)", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey.DisplayString(), analysisEntry.ClusterDetails.ClusterAlias)
+	notifyPagerDuty(topologyRecovery, pagerDutyTrigger, newPagerDutyPayload(topologyRecovery, summary))
+}
+
+// pagerDutyResolveRecovery resolves the incident of a successful recovery. A failed recovery updates the
+// incident, which stays open.
+func pagerDutyResolveRecovery(topologyRecovery *TopologyRecovery) {
+	if !isPagerDutyEnabled() {
+		return
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	if analysisEntry.CommandHint != "" {
+		return
+	}
+	if topologyRecovery.UID != "" {
+		if err := pagerDutyResolvedRecoveries.Add(topologyRecovery.UID, true, cache.DefaultExpiration); err != nil {
+			// Outcome already posted
+			inst.AuditOperation("pagerduty-skipped", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("recovery %s already resolved; successful: %+v", topologyRecovery.UID, topologyRecovery.IsSuccessful))
+			return
+		}
+	}
+	if topologyRecovery.IsSuccessful {
+		notifyPagerDuty(topologyRecovery, pagerDutyResolve, nil)
+		return
+	}
+	summary := fmt.Sprintf("Failed recovering %s on %s (cluster %s)", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey.DisplayString(), analysisEntry.ClusterDetails.ClusterAlias)
+	notifyPagerDuty(topologyRecovery, pagerDutyTrigger, newPagerDutyPayload(topologyRecovery, summary))
+}
+
+// pagerDutyAcknowledgeRecoveries acknowledges the incidents of acknowledged recoveries
+func pagerDutyAcknowledgeRecoveries(topologyRecoveries []TopologyRecovery) {
+	if !isPagerDutyEnabled() {
+		return
+	}
+	acknowledgedKeys := make(map[string]bool)
+	for i := range topologyRecoveries {
+		topologyRecovery := &topologyRecoveries[i]
+		dedupKey := pagerDutyDedupKey(topologyRecovery)
+		if acknowledgedKeys[dedupKey] {
+			continue
+		}
+		acknowledgedKeys[dedupKey] = true
+		notifyPagerDuty(topologyRecovery, pagerDutyAcknowledge, nil)
+	}
+}
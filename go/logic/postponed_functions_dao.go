@@ -0,0 +1,189 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/process"
+	orcraft "github.com/github/orchestrator/go/raft"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/rcrowley/go-metrics"
+)
+
+const (
+	PostponedFunctionPending   = "pending"
+	PostponedFunctionSucceeded = "succeeded"
+	PostponedFunctionFailed    = "failed"
+	PostponedFunctionDropped   = "dropped"
+)
+
+var postponedFunctionDroppedCounter = metrics.NewCounter()
+
+func init() {
+	metrics.Register("postponed_functions.dropped", postponedFunctionDroppedCounter)
+}
+
+// PostponedFunction describes a function postponed by a recovery. The function itself only lives in
+// memory; its descriptor is persisted so that failed and dropped (by a crashed node) functions are visible.
+type PostponedFunction struct {
+	UID                    string
+	RecoveryUID            string
+	Description            string
+	Status                 string
+	ErrorMessage           string
+	ProcessingNodeHostname string
+	ProcessingNodeToken    string
+	AddedTimestamp         string
+	CompletedTimestamp     string
+}
+
+// PostponedFunctionAdded persists a postponed function of this recovery as pending
+func (this *TopologyRecovery) PostponedFunctionAdded(uid string, description string) {
+	postponedFunction := &PostponedFunction{
+		UID:                    uid,
+		RecoveryUID:            this.UID,
+		Description:            description,
+		Status:                 PostponedFunctionPending,
+		ProcessingNodeHostname: process.ThisHostname,
+		ProcessingNodeToken:    util.ProcessToken.Hash,
+	}
+	writePostponedFunctionStatus(postponedFunction)
+}
+
+// PostponedFunctionCompleted persists the outcome of a postponed function of this recovery
+func (this *TopologyRecovery) PostponedFunctionCompleted(uid string, description string, err error) {
+	postponedFunction := &PostponedFunction{
+		UID:                    uid,
+		RecoveryUID:            this.UID,
+		Description:            description,
+		Status:                 PostponedFunctionSucceeded,
+		ProcessingNodeHostname: process.ThisHostname,
+		ProcessingNodeToken:    util.ProcessToken.Hash,
+	}
+	if err != nil {
+		postponedFunction.Status = PostponedFunctionFailed
+		postponedFunction.ErrorMessage = err.Error()
+		AuditTopologyRecovery(this, fmt.Sprintf("postponed function failed: %s: %+v", description, err))
+		inst.AuditOperation("postponed-function-failed", &this.AnalysisEntry.AnalyzedInstanceKey, fmt.Sprintf("recovery %s: %s: %+v", this.UID, description, err))
+	}
+	writePostponedFunctionStatus(postponedFunction)
+}
+
+func writePostponedFunctionStatus(postponedFunction *PostponedFunction) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-postponed-function", postponedFunction)
+		return log.Errore(err)
+	}
+	return writePostponedFunction(postponedFunction)
+}
+
+// writePostponedFunction inserts a pending postponed function, or updates its status
+func writePostponedFunction(postponedFunction *PostponedFunction) error {
+	_, err := db.ExecOrchestrator(`
+			insert into postponed_function (
+					uid, recovery_uid, description, status, error_message, processing_node_hostname, processing_node_token, added_timestamp, completed_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?, ?, now(), NULL
+				)
+				on duplicate key update
+					status=values(status),
+					error_message=values(error_message),
+					completed_timestamp=now()
+			`, postponedFunction.UID, postponedFunction.RecoveryUID, postponedFunction.Description,
+		postponedFunction.Status, postponedFunction.ErrorMessage,
+		postponedFunction.ProcessingNodeHostname, postponedFunction.ProcessingNodeToken,
+	)
+	return log.Errore(err)
+}
+
+func readPostponedFunctions(whereCondition string, args []interface{}) ([]PostponedFunction, error) {
+	res := []PostponedFunction{}
+	query := fmt.Sprintf(`
+		select
+			uid, recovery_uid, description, status, error_message, processing_node_hostname, processing_node_token,
+			added_timestamp, ifnull(completed_timestamp, '') as completed_timestamp
+		from
+			postponed_function
+		%s
+		order by
+			added_timestamp asc
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		postponedFunction := PostponedFunction{}
+		postponedFunction.UID = m.GetString("uid")
+		postponedFunction.RecoveryUID = m.GetString("recovery_uid")
+		postponedFunction.Description = m.GetString("description")
+		postponedFunction.Status = m.GetString("status")
+		postponedFunction.ErrorMessage = m.GetString("error_message")
+		postponedFunction.ProcessingNodeHostname = m.GetString("processing_node_hostname")
+		postponedFunction.ProcessingNodeToken = m.GetString("processing_node_token")
+		postponedFunction.AddedTimestamp = m.GetString("added_timestamp")
+		postponedFunction.CompletedTimestamp = m.GetString("completed_timestamp")
+
+		res = append(res, postponedFunction)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadPendingPostponedFunctions reads postponed functions that have not completed yet
+func ReadPendingPostponedFunctions() ([]PostponedFunction, error) {
+	return readPostponedFunctions(`where status = ?`, sqlutils.Args(PostponedFunctionPending))
+}
+
+// ReadRecoveryPostponedFunctions reads the postponed functions of given recovery
+func ReadRecoveryPostponedFunctions(recoveryUID string) ([]PostponedFunction, error) {
+	return readPostponedFunctions(`where recovery_uid = ?`, sqlutils.Args(recoveryUID))
+}
+
+// DetectDroppedPostponedFunctions marks pending postponed functions whose processing node is gone as dropped:
+// they were lost along with the node that was to run them.
+func DetectDroppedPostponedFunctions() error {
+	droppedFunctions, err := readPostponedFunctions(`
+		where
+			status = ?
+			and concat(processing_node_hostname, ':', processing_node_token) not in (
+				select concat(hostname, ':', token) from node_health
+			)
+		`, sqlutils.Args(PostponedFunctionPending))
+	if err != nil {
+		return err
+	}
+	for _, postponedFunction := range droppedFunctions {
+		postponedFunction := postponedFunction
+		postponedFunction.Status = PostponedFunctionDropped
+		postponedFunction.ErrorMessage = fmt.Sprintf("processing node %s is gone", postponedFunction.ProcessingNodeHostname)
+		if err := writePostponedFunctionStatus(&postponedFunction); err != nil {
+			continue
+		}
+		postponedFunctionDroppedCounter.Inc(1)
+		log.Warningf("postponed function dropped: recovery %s: %s", postponedFunction.RecoveryUID, postponedFunction.Description)
+		inst.AuditOperation("postponed-function-dropped", nil, fmt.Sprintf("recovery %s: %s; %s", postponedFunction.RecoveryUID, postponedFunction.Description, postponedFunction.ErrorMessage))
+	}
+	return nil
+}
+
+// ExpirePostponedFunctions removes old rows from the postponed_function table
+func ExpirePostponedFunctions() error {
+	return inst.ExpireTableData("postponed_function", "added_timestamp")
+}
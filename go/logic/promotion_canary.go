@@ -0,0 +1,106 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+// checkPromotionCanary writes a canary through the canary's DSN, verifies it landed on the promoted master, and
+// waits for it to replicate to the canary's MinReplicas of the master's replicas. result describes the outcome.
+func checkPromotionCanary(canary *config.PromotionCanary, clusterInfo *inst.ClusterInfo, promotedKey *inst.InstanceKey) (passed bool, result string, err error) {
+	promoted, found, err := inst.ReadInstance(promotedKey)
+	if err != nil {
+		return false, "", err
+	}
+	if !found {
+		return false, "", fmt.Errorf("Unknown instance %+v", *promotedKey)
+	}
+	token := util.PrettyUniqueToken()
+	serverId, err := inst.WritePromotionCanary(canary, canary.ClusterDSN(clusterInfo.ClusterName, clusterInfo.ClusterAlias), token)
+	if err != nil {
+		return false, "", err
+	}
+	if serverId != promoted.ServerID {
+		return false, fmt.Sprintf("canary written on server_id %d; promoted master's server_id is %d", serverId, promoted.ServerID), nil
+	}
+	if canary.MinReplicas == 0 {
+		return true, "canary written on promoted master", nil
+	}
+
+	replicas, err := inst.ReadReplicaInstances(promotedKey)
+	if err != nil {
+		return false, "", err
+	}
+	replicated := make(map[inst.InstanceKey]bool)
+	timeout := time.Duration(canary.Timeout()) * time.Second
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	for {
+		for _, replica := range replicas {
+			if replicated[replica.Key] {
+				continue
+			}
+			if replicaToken, err := inst.ReadPromotionCanaryToken(ctx, &replica.Key, canary); err == nil && replicaToken == token {
+				replicated[replica.Key] = true
+			}
+		}
+		if uint(len(replicated)) >= canary.MinReplicas {
+			return true, fmt.Sprintf("canary replicated to %d of %d replicas in %v", len(replicated), len(replicas), time.Since(start)), nil
+		}
+		if time.Since(start) >= timeout {
+			return false, fmt.Sprintf("canary replicated to %d of %d replicas in %v; expecting %d", len(replicated), len(replicas), timeout, canary.MinReplicas), nil
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// runPromotionCanary runs the cluster's PromotionCanary, if any, on a promoted master. A failed canary marks the
+// recovery as failed (with no rollback of the promotion), warns or just records a step, per the canary's failure policy.
+func runPromotionCanary(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) error {
+	clusterInfo := &topologyRecovery.AnalysisEntry.ClusterDetails
+	canary := clusterInfo.PromotionCanary()
+	if canary == nil {
+		return nil
+	}
+	passed, result, err := checkPromotionCanary(canary, clusterInfo, promotedKey)
+	if err != nil {
+		result = err.Error()
+	}
+	if passed {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promotion canary on %+v: passed (%s)", *promotedKey, result))
+		return nil
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promotion canary on %+v: failed (%s); policy: %s", *promotedKey, result, canary.FailurePolicy()))
+	switch canary.FailurePolicy() {
+	case config.PromotionCheckAbort:
+		return fmt.Errorf("RecoverDeadMaster: failed promotion. Promotion canary failed on %+v: %s", *promotedKey, result)
+	case config.PromotionCheckWarn:
+		log.Warningf("Promotion canary failed on %+v: %s", *promotedKey, result)
+		inst.AuditOperation("promotion-canary-failed", promotedKey, fmt.Sprintf("recovery %s: %s", topologyRecovery.UID, result))
+	}
+	return nil
+}
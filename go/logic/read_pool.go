@@ -0,0 +1,144 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/kv"
+	"github.com/github/orchestrator/go/proxysql"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/patrickmn/go-cache"
+)
+
+// readPoolsPublished maps cluster names to the members JSON of their last published read pool, so that only
+// changed pools are published. Entries expire so that pools are republished now and then regardless.
+var readPoolsPublished = cache.New(10*time.Minute, time.Minute)
+
+// readPoolPublishMutex serializes publishing of read pools: the periodic publishing and a refresh following
+// a failover must not interleave their writes to KV stores and ProxySQL
+var readPoolPublishMutex sync.Mutex
+
+func isReadPoolEnabled() bool {
+	return len(config.Config.ReadPoolClusterFilters) > 0
+}
+
+// publishReadPool publishes a cluster's read pool to KV stores and ProxySQL, if it changed since last published.
+// An empty pool is not published, so that readers are not left with no servers at all.
+func publishReadPool(clusterInfo *inst.ClusterInfo) error {
+	readPoolPublishMutex.Lock()
+	defer readPoolPublishMutex.Unlock()
+
+	pool, err := inst.ReadReadPool(clusterInfo)
+	if err != nil {
+		return err
+	}
+	membersJSON := pool.MembersJSON()
+	if published, found := readPoolsPublished.Get(pool.ClusterName); found && published.(string) == membersJSON {
+		return nil
+	}
+	readPoolsPublished.Set(pool.ClusterName, membersJSON, cache.DefaultExpiration)
+	if len(pool.Members) == 0 {
+		log.Warningf("Read pool of %s is empty; not publishing", pool.ClusterName)
+		inst.AuditOperation("read-pool-empty", nil, fmt.Sprintf("cluster %s (%s): no healthy replicas; keeping last published pool", pool.ClusterName, pool.ClusterAlias))
+		return nil
+	}
+
+	if config.Config.ReadPoolKVPrefix != "" {
+		kvPair := kv.NewKVPair(fmt.Sprintf("%s%s", config.Config.ReadPoolKVPrefix, pool.ClusterAlias), membersJSON)
+		if orcraft.IsRaftEnabled() {
+			_, err = orcraft.PublishCommand("put-key-value", kvPair)
+		} else {
+			err = kv.PutKVPair(kvPair)
+		}
+		if err != nil {
+			readPoolsPublished.Delete(pool.ClusterName)
+			return err
+		}
+	}
+	if hostgroup, found := config.Config.ReadPoolProxySQLHostgroups[pool.ClusterAlias]; found && proxysql.IsEnabled() {
+		servers := []proxysql.Server{}
+		for _, member := range pool.Members {
+			servers = append(servers, proxysql.Server{Hostname: member.Key.Hostname, Port: member.Key.Port, Weight: member.Weight})
+		}
+		if err := proxysql.ReplaceHostgroupServers(hostgroup, servers); err != nil {
+			readPoolsPublished.Delete(pool.ClusterName)
+			return err
+		}
+	}
+	inst.AuditOperation("read-pool", nil, fmt.Sprintf("cluster %s (%s): %d members: %s", pool.ClusterName, pool.ClusterAlias, len(pool.Members), membersJSON))
+	return nil
+}
+
+// publishReadPools publishes the changed read pools of all clusters having one. Run periodically by the leader.
+func publishReadPools() {
+	if !isReadPoolEnabled() {
+		return
+	}
+	clusters, err := inst.ReadClustersInfo("")
+	if err != nil {
+		log.Errore(err)
+		return
+	}
+	for i := range clusters {
+		clusterInfo := &clusters[i]
+		if !clusterInfo.HasReadPool() {
+			continue
+		}
+		if err := publishReadPool(clusterInfo); err != nil {
+			log.Errorf("Publishing read pool of %s: %+v", clusterInfo.ClusterName, err)
+		}
+	}
+}
+
+// refreshReadPool publishes the read pool of the cluster of given instance, whether changed or not. Used after
+// a master failover changes the cluster's topology.
+func refreshReadPool(instanceKey *inst.InstanceKey) {
+	if !isReadPoolEnabled() {
+		return
+	}
+	instance, found, err := inst.ReadInstance(instanceKey)
+	if err != nil || !found {
+		return
+	}
+	clusterInfo, err := inst.ReadClusterInfo(instance.ClusterName)
+	if err != nil || !clusterInfo.HasReadPool() {
+		return
+	}
+	readPoolsPublished.Delete(clusterInfo.ClusterName)
+	if err := publishReadPool(clusterInfo); err != nil {
+		log.Errorf("Publishing read pool of %s: %+v", clusterInfo.ClusterName, err)
+	}
+}
+
+// GetReadPool returns the current read pool of given cluster
+func GetReadPool(clusterName string) (*inst.ReadPool, error) {
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if !clusterInfo.HasReadPool() {
+		return nil, fmt.Errorf("Cluster %s has no read pool; see ReadPoolClusterFilters", clusterName)
+	}
+	return inst.ReadReadPool(clusterInfo)
+}
@@ -0,0 +1,261 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// RecoveryMonthlyCount counts a cluster's recoveries within a calendar month
+type RecoveryMonthlyCount struct {
+	ClusterAlias string
+	Month        string
+	Recoveries   int
+	Successful   int
+	Promotions   int
+}
+
+// RecoveryDurationStats summarizes the time successful recoveries took, from detection to resolution
+type RecoveryDurationStats struct {
+	Recoveries  int
+	MeanSeconds float64
+	P50Seconds  float64
+	P90Seconds  float64
+	P99Seconds  float64
+	MaxSeconds  float64
+}
+
+// RecoveryAnalysisCount counts recoveries of an analysis code
+type RecoveryAnalysisCount struct {
+	Analysis   inst.AnalysisCode
+	Recoveries int
+	Successful int
+}
+
+// PromotionRuleCount counts successful recoveries whose promoted instance had a promotion rule. Recoveries made
+// before promotion rules were recorded have an empty rule.
+type PromotionRuleCount struct {
+	PromotionRule inst.CandidatePromotionRule
+	Promotions    int
+}
+
+// RecoveryAnalytics aggregates recovery history, for capacity and reliability reviews
+type RecoveryAnalytics struct {
+	ClusterAlias   string
+	Days           uint
+	Monthly        []RecoveryMonthlyCount
+	TimeToRecovery RecoveryDurationStats
+	Analysis       []RecoveryAnalysisCount
+	PromotionRules []PromotionRuleCount
+}
+
+// recoveryAnalyticsCondition limits analytics to the past given days, and optionally to a cluster alias
+func recoveryAnalyticsCondition(clusterAlias string, days uint) (string, []interface{}) {
+	whereCondition := `where start_active_period >= NOW() - INTERVAL ? DAY`
+	args := sqlutils.Args(days)
+	if clusterAlias != "" {
+		whereCondition += ` and cluster_alias = ?`
+		args = append(args, clusterAlias)
+	}
+	return whereCondition, args
+}
+
+func readRecoveryMonthlyCounts(whereCondition string, args []interface{}) (result []RecoveryMonthlyCount, err error) {
+	result = []RecoveryMonthlyCount{}
+	query := fmt.Sprintf(`
+		select
+			cluster_alias,
+			start_active_period,
+			is_successful,
+			ifnull(successor_hostname, '') as successor_hostname
+		from
+			topology_recovery
+		%s
+		`, whereCondition)
+	counts := make(map[string]*RecoveryMonthlyCount)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		// Grouped here rather than in SQL, as backends do not agree on formatting timestamps
+		clusterAlias := m.GetString("cluster_alias")
+		month := m.GetString("start_active_period")
+		if len(month) >= len("YYYY-MM") {
+			month = month[0:len("YYYY-MM")]
+		}
+		countKey := clusterAlias + "/" + month
+		count, found := counts[countKey]
+		if !found {
+			count = &RecoveryMonthlyCount{ClusterAlias: clusterAlias, Month: month}
+			counts[countKey] = count
+		}
+		count.Recoveries++
+		if m.GetBool("is_successful") {
+			count.Successful++
+			if m.GetString("successor_hostname") != "" {
+				count.Promotions++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return result, log.Errore(err)
+	}
+	for _, count := range counts {
+		result = append(result, *count)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ClusterAlias != result[j].ClusterAlias {
+			return result[i].ClusterAlias < result[j].ClusterAlias
+		}
+		return result[i].Month < result[j].Month
+	})
+	return result, nil
+}
+
+// durationPercentile returns the nearest-rank percentile of sorted durations
+func durationPercentile(sortedSeconds []float64, percentile float64) float64 {
+	if len(sortedSeconds) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(percentile / 100 * float64(len(sortedSeconds))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sortedSeconds[rank-1]
+}
+
+func readRecoveryDurationStats(whereCondition string, args []interface{}) (stats RecoveryDurationStats, err error) {
+	query := fmt.Sprintf(`
+		select
+			start_active_period,
+			end_recovery
+		from
+			topology_recovery
+		%s
+			and is_successful = 1
+			and end_recovery is not null
+		`, whereCondition)
+	durations := []float64{}
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		start := m.GetTime("start_active_period")
+		end := m.GetTime("end_recovery")
+		if start.IsZero() || end.IsZero() || end.Before(start) {
+			return nil
+		}
+		durations = append(durations, end.Sub(start).Seconds())
+		return nil
+	})
+	if err != nil {
+		return stats, log.Errore(err)
+	}
+	sort.Float64s(durations)
+	stats.Recoveries = len(durations)
+	if stats.Recoveries == 0 {
+		return stats, nil
+	}
+	total := float64(0)
+	for _, duration := range durations {
+		total += duration
+	}
+	stats.MeanSeconds = total / float64(stats.Recoveries)
+	stats.P50Seconds = durationPercentile(durations, 50)
+	stats.P90Seconds = durationPercentile(durations, 90)
+	stats.P99Seconds = durationPercentile(durations, 99)
+	stats.MaxSeconds = durations[len(durations)-1]
+	return stats, nil
+}
+
+func readRecoveryAnalysisCounts(whereCondition string, args []interface{}) (result []RecoveryAnalysisCount, err error) {
+	result = []RecoveryAnalysisCount{}
+	query := fmt.Sprintf(`
+		select
+			analysis,
+			count(*) as count_recoveries,
+			sum(case when is_successful = 1 then 1 else 0 end) as count_successful
+		from
+			topology_recovery
+		%s
+		group by
+			analysis
+		order by
+			count_recoveries desc, analysis
+		`, whereCondition)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		result = append(result, RecoveryAnalysisCount{
+			Analysis:   inst.AnalysisCode(m.GetString("analysis")),
+			Recoveries: m.GetInt("count_recoveries"),
+			Successful: m.GetInt("count_successful"),
+		})
+		return nil
+	})
+	return result, log.Errore(err)
+}
+
+func readPromotionRuleCounts(whereCondition string, args []interface{}) (result []PromotionRuleCount, err error) {
+	result = []PromotionRuleCount{}
+	query := fmt.Sprintf(`
+		select
+			successor_promotion_rule,
+			count(*) as count_promotions
+		from
+			topology_recovery
+		%s
+			and is_successful = 1
+			and successor_hostname != ''
+		group by
+			successor_promotion_rule
+		order by
+			count_promotions desc, successor_promotion_rule
+		`, whereCondition)
+	err = db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		result = append(result, PromotionRuleCount{
+			PromotionRule: inst.CandidatePromotionRule(m.GetString("successor_promotion_rule")),
+			Promotions:    m.GetInt("count_promotions"),
+		})
+		return nil
+	})
+	return result, log.Errore(err)
+}
+
+// ReadRecoveryAnalytics aggregates the recoveries of the past given days, of all clusters or of a single cluster,
+// given by alias: the alias is kept by a cluster across master failovers, whereas its name is not
+func ReadRecoveryAnalytics(clusterAlias string, days uint) (analytics *RecoveryAnalytics, err error) {
+	if days == 0 {
+		return nil, fmt.Errorf("ReadRecoveryAnalytics: days must be positive")
+	}
+	whereCondition, args := recoveryAnalyticsCondition(clusterAlias, days)
+	analytics = &RecoveryAnalytics{ClusterAlias: clusterAlias, Days: days}
+	if analytics.Monthly, err = readRecoveryMonthlyCounts(whereCondition, args); err != nil {
+		return nil, err
+	}
+	if analytics.TimeToRecovery, err = readRecoveryDurationStats(whereCondition, args); err != nil {
+		return nil, err
+	}
+	if analytics.Analysis, err = readRecoveryAnalysisCounts(whereCondition, args); err != nil {
+		return nil, err
+	}
+	if analytics.PromotionRules, err = readPromotionRuleCounts(whereCondition, args); err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
@@ -0,0 +1,159 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+)
+
+// recoveryEmailBodyTemplate is the built-in body of recovery emails, used unless RecoveryEmailBodyTemplateFile is given
+const recoveryEmailBodyTemplate = `{{with .Recovery}}Recovery {{.UID}}
+Analysis: {{.AnalysisEntry.Analysis}} on {{.AnalysisEntry.AnalyzedInstanceKey.DisplayString}}
+Description: {{.AnalysisEntry.Description}}
+Cluster: {{.AnalysisEntry.ClusterDetails.ClusterName}} (alias: {{.AnalysisEntry.ClusterDetails.ClusterAlias}})
+Started: {{.RecoveryStartTimestamp}}
+Ended: {{.RecoveryEndTimestamp}}
+Processed by: {{.ProcessingNodeHostname}}
+Successful: {{.IsSuccessful}}{{if .SuccessorKey}}
+Promoted: {{.SuccessorKey.DisplayString}}{{end}}{{if .LostReplicas}}
+Lost replicas: {{.LostReplicas.ToCommaDelimitedList}}{{end}}{{if .AllErrors}}
+Errors:{{range .AllErrors}}
+  {{.}}{{end}}{{end}}{{end}}
+{{with .TopologyBefore}}
+Topology before ({{.Snapshot.ClusterName}}, captured at {{.Snapshot.SnapshotTimestamp}}):
+
+{{.ASCII}}
+{{end}}{{with .TopologyAfter}}
+Topology after ({{.Snapshot.ClusterName}}, captured at {{.Snapshot.SnapshotTimestamp}}):
+
+{{.ASCII}}
+{{end}}{{with .TopologyDiff}}
+Topology changes:{{range .Summary}}
+  {{.}}{{end}}
+{{end}}
+Audit trail:{{range .Timeline}}
+  {{.AuditAt}} {{.Message}}{{end}}
+`
+
+// recoveryEmailTimeout caps the time given to sending a recovery email
+const recoveryEmailTimeout = 30 * time.Second
+
+func isRecoveryEmailEnabled() bool {
+	return config.Config.SMTPServer != ""
+}
+
+// renderRecoveryEmail executes the recovery email subject and body templates on given report
+func renderRecoveryEmail(report *RecoveryReport) (subject string, body string, err error) {
+	subjectTemplate, err := template.New("subject").Parse(config.Config.RecoveryEmailSubjectTemplate)
+	if err != nil {
+		return "", "", err
+	}
+	var bodyTemplate *template.Template
+	if config.Config.RecoveryEmailBodyTemplateFile != "" {
+		bodyTemplate, err = template.ParseFiles(config.Config.RecoveryEmailBodyTemplateFile)
+	} else {
+		bodyTemplate, err = template.New("body").Parse(recoveryEmailBodyTemplate)
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	var subjectBuffer, bodyBuffer bytes.Buffer
+	if err := subjectTemplate.Execute(&subjectBuffer, report); err != nil {
+		return "", "", err
+	}
+	if err := bodyTemplate.Execute(&bodyBuffer, report); err != nil {
+		return "", "", err
+	}
+	// A subject is a single header line
+	subject = strings.Join(strings.Fields(subjectBuffer.String()), " ")
+	return subject, bodyBuffer.String(), nil
+}
+
+// RenderRecoveryEmail renders the email of given recovery, as it is sent once the recovery completes
+func RenderRecoveryEmail(recoveryUID string) (subject string, body string, err error) {
+	report, err := GenerateRecoveryReport(recoveryUID)
+	if err != nil {
+		return "", "", err
+	}
+	return renderRecoveryEmail(report)
+}
+
+// sendEmail sends a plain text email to RecoveryEmailTo via SMTPServer
+func sendEmail(subject string, body string) error {
+	host, _, err := net.SplitHostPort(config.Config.SMTPServer)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if config.Config.SMTPUser != "" {
+		auth = smtp.PlainAuth("", config.Config.SMTPUser, config.Config.SMTPPassword, host)
+	}
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", config.Config.RecoveryEmailFrom)
+	fmt.Fprintf(&message, "To: %s\r\n", strings.Join(config.Config.RecoveryEmailTo, ", "))
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&message, "\r\n")
+	message.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	result := make(chan error, 1)
+	go func() {
+		result <- smtp.SendMail(config.Config.SMTPServer, auth, config.Config.RecoveryEmailFrom, config.Config.RecoveryEmailTo, message.Bytes())
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(recoveryEmailTimeout):
+		return fmt.Errorf("Timed out sending email via %s", config.Config.SMTPServer)
+	}
+}
+
+// emailRecoverySummary emails the summary of a completed recovery, in the background
+func emailRecoverySummary(topologyRecovery *TopologyRecovery) {
+	if !isRecoveryEmailEnabled() {
+		return
+	}
+	recoveryUID := topologyRecovery.UID
+	analyzedInstanceKey := topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	go func() {
+		subject, body, err := RenderRecoveryEmail(recoveryUID)
+		if err == nil {
+			err = sendEmail(subject, body)
+		}
+		if err != nil {
+			log.Errorf("Emailing summary of recovery %s: %+v", recoveryUID, err)
+			inst.AuditOperation("recovery-email-failed", &analyzedInstanceKey, fmt.Sprintf("recovery %s: %+v", recoveryUID, err))
+			return
+		}
+		inst.AuditOperation("recovery-email", &analyzedInstanceKey, fmt.Sprintf("recovery %s: emailed %s", recoveryUID, strings.Join(config.Config.RecoveryEmailTo, ", ")))
+	}()
+}
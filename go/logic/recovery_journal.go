@@ -0,0 +1,188 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// The recovery journal persists the completed steps of a master recovery, past the point of promotion.
+// Should orchestrator die mid-recovery, a leader finds the recovery's processing node gone, and
+// resumes the recovery from its last journaled step.
+const (
+	RecoveryJournalStepPromoted        = "promoted"
+	RecoveryJournalStepMySQLPromotion  = "mysql-promotion"
+	RecoveryJournalStepPromotionChecks = "promotion-checks"
+	RecoveryJournalStepKV              = "kv"
+	RecoveryJournalStepClusterAlias    = "cluster-alias"
+	RecoveryJournalStepPostProcesses   = "post-processes"
+	RecoveryJournalStepPromotionCanary = "promotion-canary"
+	RecoveryJournalStepCompleted       = "completed"
+)
+
+// RecoveryJournalEntry is a single completed step of a recovery
+type RecoveryJournalEntry struct {
+	RecoveryUID      string
+	Step             string
+	JournalTimestamp string
+	Details          string
+}
+
+// MasterPromotion is journaled once a master recovery has promoted a server; it has all
+// that is needed to complete the recovery
+type MasterPromotion struct {
+	SuccessorKey        inst.InstanceKey
+	ClusterDomain       string
+	ApplyMySQLPromotion bool
+	SkipProcesses       bool
+}
+
+// journalRecoveryStep persists a completed step of given recovery
+func journalRecoveryStep(topologyRecovery *TopologyRecovery, step string, details interface{}) error {
+	entry := &RecoveryJournalEntry{RecoveryUID: topologyRecovery.UID, Step: step}
+	if details != nil {
+		detailsJSON, err := json.Marshal(details)
+		if err != nil {
+			return log.Errore(err)
+		}
+		entry.Details = string(detailsJSON)
+	}
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-recovery-journal", entry)
+		return log.Errore(err)
+	}
+	return writeRecoveryJournalEntry(entry)
+}
+
+func writeRecoveryJournalEntry(entry *RecoveryJournalEntry) error {
+	_, err := db.ExecOrchestratorRecovery(`
+			replace
+				into topology_recovery_journal (
+					recovery_uid, journal_step, journal_timestamp, step_details
+				) values (?, ?, now(), ?)
+			`, entry.RecoveryUID, entry.Step, entry.Details,
+	)
+	return log.Errore(err)
+}
+
+// ReadRecoveryJournal reads the journaled steps of given recovery
+func ReadRecoveryJournal(recoveryUID string) ([]RecoveryJournalEntry, error) {
+	res := []RecoveryJournalEntry{}
+	query := `
+		select
+			recovery_uid, journal_step, journal_timestamp, step_details
+		from
+			topology_recovery_journal
+		where
+			recovery_uid=?
+		order by
+			journal_timestamp asc
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(recoveryUID), func(m sqlutils.RowMap) error {
+		entry := RecoveryJournalEntry{}
+		entry.RecoveryUID = m.GetString("recovery_uid")
+		entry.Step = m.GetString("journal_step")
+		entry.JournalTimestamp = m.GetString("journal_timestamp")
+		entry.Details = m.GetString("step_details")
+
+		res = append(res, entry)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// readInterruptedRecoveries reads recoveries that have promoted a server, were not journaled as completed,
+// and whose processing node is no longer alive
+func readInterruptedRecoveries() ([]TopologyRecovery, error) {
+	whereClause := `
+		where
+			in_active_period = 1
+			and concat(processing_node_hostname, ':', processcing_node_token) not in (
+				select concat(hostname, ':', token) from node_health
+			)
+			and uid in (
+				select recovery_uid from topology_recovery_journal where journal_step = ?
+			)
+			and uid not in (
+				select recovery_uid from topology_recovery_journal where journal_step = ?
+			)
+		`
+	return readRecoveries(whereClause, ``, sqlutils.Args(RecoveryJournalStepPromoted, RecoveryJournalStepCompleted))
+}
+
+// ResumeInterruptedRecoveries completes recoveries whose processing node died after promoting a new master
+func ResumeInterruptedRecoveries() error {
+	recoveries, err := readInterruptedRecoveries()
+	if err != nil {
+		return err
+	}
+	for i := range recoveries {
+		topologyRecovery := &recoveries[i]
+		if err := resumeInterruptedRecovery(topologyRecovery); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("ResumeInterruptedRecovery: %+v", err))
+		}
+	}
+	return nil
+}
+
+func resumeInterruptedRecovery(topologyRecovery *TopologyRecovery) error {
+	journal, err := ReadRecoveryJournal(topologyRecovery.UID)
+	if err != nil {
+		return err
+	}
+	journaledSteps := make(map[string]bool)
+	promotion := &MasterPromotion{}
+	for _, entry := range journal {
+		journaledSteps[entry.Step] = true
+		if entry.Step == RecoveryJournalStepPromoted {
+			if err := json.Unmarshal([]byte(entry.Details), promotion); err != nil {
+				return err
+			}
+		}
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("ResumeInterruptedRecovery: recovery of %+v by %s was interrupted after promoting %+v; resuming", topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, topologyRecovery.ProcessingNodeHostname, promotion.SuccessorKey))
+	inst.AuditOperation("resume-recovery", &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, fmt.Sprintf("recovery %s; promoted: %+v", topologyRecovery.UID, promotion.SuccessorKey))
+
+	topologyRecovery.AnalysisEntry.ClusterDetails.ClusterDomain = promotion.ClusterDomain
+	if topologyRecovery.RecoveryEndTimestamp == "" {
+		promotedReplica, _, err := inst.ReadInstance(&promotion.SuccessorKey)
+		if err != nil || promotedReplica == nil {
+			return fmt.Errorf("cannot read promoted server %+v: %+v", promotion.SuccessorKey, err)
+		}
+		resolveRecovery(topologyRecovery, promotedReplica)
+	} else {
+		topologyRecovery.SuccessorKey = &promotion.SuccessorKey
+	}
+	if err := finalizeMasterPromotion(topologyRecovery, promotion, journaledSteps); err != nil {
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepCompleted, nil)
+		return err
+	}
+	return journalRecoveryStep(topologyRecovery, RecoveryJournalStepCompleted, nil)
+}
+
+// ExpireRecoveryJournal removes old rows from the topology_recovery_journal table
+func ExpireRecoveryJournal() error {
+	return inst.ExpireTableData("topology_recovery_journal", "journal_timestamp")
+}
@@ -0,0 +1,146 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/github/orchestrator/go/inst"
+	test "github.com/openark/golib/tests"
+)
+
+// readJournaledSteps returns the steps journaled for given recovery
+func readJournaledSteps(t *testing.T, recoveryUID string) map[string]bool {
+	journal, err := ReadRecoveryJournal(recoveryUID)
+	test.S(t).ExpectNil(err)
+	journaledSteps := make(map[string]bool)
+	for _, entry := range journal {
+		journaledSteps[entry.Step] = true
+	}
+	return journaledSteps
+}
+
+// auditedRecoveryStep checks whether given recovery audited a step with given message prefix
+func auditedRecoveryStep(t *testing.T, recoveryUID string, messagePrefix string) bool {
+	steps, err := ReadTopologyRecoverySteps(recoveryUID)
+	test.S(t).ExpectNil(err)
+	for _, step := range steps {
+		if strings.HasPrefix(step.Message, messagePrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func newJournalTestRecovery() *TopologyRecovery {
+	analysisEntry := inst.ReplicationAnalysis{
+		Analysis:            inst.DeadMaster,
+		AnalyzedInstanceKey: inst.InstanceKey{Hostname: "db-1", Port: 3306},
+	}
+	analysisEntry.ClusterDetails.ClusterName = "db-1:3306"
+	analysisEntry.ClusterDetails.ClusterAlias = "orders"
+	return NewTopologyRecovery(analysisEntry)
+}
+
+func TestRecoveryJournalWriteRead(t *testing.T) {
+	withSQLiteBackend(t, func() {
+		topologyRecovery := newJournalTestRecovery()
+		promotion := &MasterPromotion{
+			SuccessorKey:        inst.InstanceKey{Hostname: "db-2", Port: 3306},
+			ClusterDomain:       "orders.example.com",
+			ApplyMySQLPromotion: true,
+		}
+		test.S(t).ExpectNil(journalRecoveryStep(topologyRecovery, RecoveryJournalStepPromoted, promotion))
+		test.S(t).ExpectNil(journalRecoveryStep(topologyRecovery, RecoveryJournalStepKV, nil))
+		// Journaling a step twice keeps a single entry
+		test.S(t).ExpectNil(journalRecoveryStep(topologyRecovery, RecoveryJournalStepKV, nil))
+
+		journal, err := ReadRecoveryJournal(topologyRecovery.UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(journal), 2)
+		for _, entry := range journal {
+			test.S(t).ExpectEquals(entry.RecoveryUID, topologyRecovery.UID)
+			switch entry.Step {
+			case RecoveryJournalStepPromoted:
+				journaledPromotion := &MasterPromotion{}
+				test.S(t).ExpectNil(json.Unmarshal([]byte(entry.Details), journaledPromotion))
+				test.S(t).ExpectEquals(*journaledPromotion, *promotion)
+			case RecoveryJournalStepKV:
+				test.S(t).ExpectEquals(entry.Details, "")
+			default:
+				t.Errorf("unexpected journal step: %s", entry.Step)
+			}
+		}
+
+		journal, err = ReadRecoveryJournal(newJournalTestRecovery().UID)
+		test.S(t).ExpectNil(err)
+		test.S(t).ExpectEquals(len(journal), 0)
+	})
+}
+
+func TestFinalizeMasterPromotionSkipsJournaledSteps(t *testing.T) {
+	// Steps finalizeMasterPromotion journals. The MySQL promotion step is always journaled here, so that no
+	// MySQL server is contacted.
+	steps := []string{
+		RecoveryJournalStepPromotionChecks,
+		RecoveryJournalStepKV,
+		RecoveryJournalStepClusterAlias,
+		RecoveryJournalStepPostProcesses,
+		RecoveryJournalStepPromotionCanary,
+	}
+	// Audited messages by which to tell a step was applied
+	stepMessages := map[string]string{
+		RecoveryJournalStepKV:           "Writing KV",
+		RecoveryJournalStepClusterAlias: "- RecoverDeadMaster: updating cluster_alias",
+	}
+
+	withSQLiteBackend(t, func() {
+		// pendingStep is the single step not yet journaled; none when empty
+		for _, pendingStep := range append(steps, "") {
+			topologyRecovery := newJournalTestRecovery()
+			promotion := &MasterPromotion{
+				SuccessorKey:        inst.InstanceKey{Hostname: "db-2", Port: 3306},
+				ApplyMySQLPromotion: true,
+			}
+			journaledSteps := map[string]bool{RecoveryJournalStepMySQLPromotion: true}
+			for _, step := range steps {
+				if step != pendingStep {
+					journaledSteps[step] = true
+				}
+			}
+			for step := range journaledSteps {
+				test.S(t).ExpectNil(journalRecoveryStep(topologyRecovery, step, nil))
+			}
+
+			test.S(t).ExpectNil(finalizeMasterPromotion(topologyRecovery, promotion, journaledSteps))
+
+			test.S(t).ExpectFalse(auditedRecoveryStep(t, topologyRecovery.UID, "- RecoverDeadMaster: will apply MySQL changes"))
+			for step, message := range stepMessages {
+				if applied := auditedRecoveryStep(t, topologyRecovery.UID, message); applied != (step == pendingStep) {
+					t.Errorf("pending step %q: step %s applied=%t", pendingStep, step, applied)
+				}
+			}
+			journal := readJournaledSteps(t, topologyRecovery.UID)
+			for _, step := range steps {
+				test.S(t).ExpectTrue(journal[step])
+			}
+			test.S(t).ExpectEquals(len(journal), len(steps)+1)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/rcrowley/go-metrics"
+)
+
+// Recovery priorities: when recoveries are queued, recoveries of higher cluster priority tiers are dequeued first,
+// and within a tier, higher priority recoveries are dequeued first
+const (
+	RecoveryPriorityOther              = 0
+	RecoveryPriorityIntermediateMaster = 1
+	RecoveryPriorityCoMaster           = 2
+	RecoveryPriorityMaster             = 3
+)
+
+// QueuedRecovery is an actionable analysis waiting for a recovery slot
+type QueuedRecovery struct {
+	AnalyzedInstanceKey inst.InstanceKey
+	Analysis            inst.AnalysisCode
+	ClusterName         string
+	PriorityTier        string
+	DataCenter          string
+	Priority            int
+	EnqueuedAt          time.Time
+
+	seq int64
+}
+
+// recoverySlots caps the number of concurrently executing recoveries, globally and per data center
+type recoverySlots struct {
+	mutex        *sync.Mutex
+	cond         *sync.Cond
+	running      uint
+	runningPerDC map[string]uint
+	runningKeys  map[inst.InstanceKey]uint
+	queued       []*QueuedRecovery
+	seq          int64
+}
+
+var recoveryQueue = newRecoverySlots()
+
+var recoveryQueueDepthGauge = metrics.NewGauge()
+var recoveryQueueWaitTimer = metrics.NewTimer()
+
+func init() {
+	metrics.Register("recover.queue.depth", recoveryQueueDepthGauge)
+	metrics.Register("recover.queue.wait", recoveryQueueWaitTimer)
+}
+
+func newRecoverySlots() *recoverySlots {
+	mutex := &sync.Mutex{}
+	return &recoverySlots{
+		mutex:        mutex,
+		cond:         sync.NewCond(mutex),
+		runningPerDC: make(map[string]uint),
+		runningKeys:  make(map[inst.InstanceKey]uint),
+		queued:       [](*QueuedRecovery){},
+	}
+}
+
+// recoveryPriority orders recoveries by the impact of the failure: masters, then co-masters, then intermediate masters
+func recoveryPriority(analysisCode inst.AnalysisCode) int {
+	switch analysisCode {
+	case inst.DeadMaster, inst.DeadMasterAndSomeSlaves, inst.DeadMasterWithoutSlaves:
+		return RecoveryPriorityMaster
+	case inst.DeadCoMaster, inst.DeadCoMasterAndSomeSlaves:
+		return RecoveryPriorityCoMaster
+	case inst.DeadIntermediateMaster, inst.DeadIntermediateMasterAndSomeSlaves, inst.DeadIntermediateMasterWithSingleSlave,
+		inst.DeadIntermediateMasterWithSingleSlaveFailingToConnect, inst.DeadIntermediateMasterAndSlaves,
+		inst.AllIntermediateMasterSlavesFailingToConnectOrDead:
+		return RecoveryPriorityIntermediateMaster
+	}
+	return RecoveryPriorityOther
+}
+
+// hasCapacity checks whether a recovery on given data center may start now. Must be called under lock.
+func (this *recoverySlots) hasCapacity(dataCenter string) bool {
+	if limit := config.Config.MaxConcurrentRecoveries; limit > 0 && this.running >= limit {
+		return false
+	}
+	if limit := config.Config.MaxConcurrentRecoveriesPerDataCenter; limit > 0 && this.runningPerDC[dataCenter] >= limit {
+		return false
+	}
+	return true
+}
+
+// isNext checks whether given queued recovery is the highest priority queued recovery that may start now.
+// Must be called under lock.
+func (this *recoverySlots) isNext(queuedRecovery *QueuedRecovery) bool {
+	for _, other := range this.queued {
+		if other == queuedRecovery {
+			// this.queued is sorted; no preceding recovery could start
+			return this.hasCapacity(queuedRecovery.DataCenter)
+		}
+		if this.hasCapacity(other.DataCenter) {
+			return false
+		}
+	}
+	return false
+}
+
+func (this *recoverySlots) take(analysisEntry *inst.ReplicationAnalysis) {
+	this.running++
+	this.runningPerDC[analysisEntry.AnalyzedInstanceDataCenter]++
+	this.runningKeys[analysisEntry.AnalyzedInstanceKey]++
+}
+
+// isDuplicate checks whether a recovery of the instance of given analysis is already queued or running.
+// Must be called under lock.
+func (this *recoverySlots) isDuplicate(analysisEntry *inst.ReplicationAnalysis) bool {
+	if this.runningKeys[analysisEntry.AnalyzedInstanceKey] > 0 {
+		return true
+	}
+	for _, queuedRecovery := range this.queued {
+		if queuedRecovery.AnalyzedInstanceKey.Equals(&analysisEntry.AnalyzedInstanceKey) {
+			return true
+		}
+	}
+	return false
+}
+
+// acquire blocks until a recovery of given analysis may execute, and takes a slot. Forced recoveries
+// take a slot without waiting. Returns the time spent waiting.
+// An automated recovery of an instance already queued or being recovered takes no slot, and returns with
+// acquired false: repeated analyses of the same failure only hold a single place in the queue.
+func (this *recoverySlots) acquire(analysisEntry *inst.ReplicationAnalysis, force bool) (wait time.Duration, acquired bool) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	dataCenter := analysisEntry.AnalyzedInstanceDataCenter
+	if !force && this.isDuplicate(analysisEntry) {
+		return 0, false
+	}
+	if force || (len(this.queued) == 0 && this.hasCapacity(dataCenter)) {
+		this.take(analysisEntry)
+		return 0, true
+	}
+	this.seq++
+	queuedRecovery := &QueuedRecovery{
+		AnalyzedInstanceKey: analysisEntry.AnalyzedInstanceKey,
+		Analysis:            analysisEntry.Analysis,
+		ClusterName:         analysisEntry.ClusterDetails.ClusterName,
+		PriorityTier:        analysisEntry.ClusterDetails.PriorityTier,
+		DataCenter:          dataCenter,
+		Priority:            recoveryPriority(analysisEntry.Analysis),
+		EnqueuedAt:          time.Now(),
+		seq:                 this.seq,
+	}
+	this.queued = append(this.queued, queuedRecovery)
+	sort.SliceStable(this.queued, func(i, j int) bool {
+		if rankI, rankJ := inst.ClusterPriorityTierRank(this.queued[i].PriorityTier), inst.ClusterPriorityTierRank(this.queued[j].PriorityTier); rankI != rankJ {
+			return rankI < rankJ
+		}
+		if this.queued[i].Priority != this.queued[j].Priority {
+			return this.queued[i].Priority > this.queued[j].Priority
+		}
+		return this.queued[i].seq < this.queued[j].seq
+	})
+	for !this.isNext(queuedRecovery) {
+		this.cond.Wait()
+	}
+	for i := range this.queued {
+		if this.queued[i] == queuedRecovery {
+			this.queued = append(this.queued[:i], this.queued[i+1:]...)
+			break
+		}
+	}
+	this.take(analysisEntry)
+	// Others queued behind may now be next in line
+	this.cond.Broadcast()
+
+	wait = time.Since(queuedRecovery.EnqueuedAt)
+	recoveryQueueWaitTimer.Update(wait)
+	return wait, true
+}
+
+// release frees the slot taken by a recovery of given analysis
+func (this *recoverySlots) release(analysisEntry *inst.ReplicationAnalysis) {
+	this.mutex.Lock()
+	defer this.mutex.Unlock()
+
+	dataCenter := analysisEntry.AnalyzedInstanceDataCenter
+	this.running--
+	if this.runningPerDC[dataCenter]--; this.runningPerDC[dataCenter] == 0 {
+		delete(this.runningPerDC, dataCenter)
+	}
+	if this.runningKeys[analysisEntry.AnalyzedInstanceKey]--; this.runningKeys[analysisEntry.AnalyzedInstanceKey] == 0 {
+		delete(this.runningKeys, analysisEntry.AnalyzedInstanceKey)
+	}
+	this.cond.Broadcast()
+}
+
+// ReadQueuedRecoveries returns the recoveries waiting for a slot, in dequeue order
+func ReadQueuedRecoveries() []QueuedRecovery {
+	recoveryQueue.mutex.Lock()
+	defer recoveryQueue.mutex.Unlock()
+
+	res := []QueuedRecovery{}
+	for _, queuedRecovery := range recoveryQueue.queued {
+		res = append(res, *queuedRecovery)
+	}
+	return res
+}
+
+func getRecoveryQueueDepth() int64 {
+	recoveryQueue.mutex.Lock()
+	defer recoveryQueue.mutex.Unlock()
+
+	return int64(len(recoveryQueue.queued))
+}
+
+// isAnalysisStillValid re-analyzes the cluster of given analysis, to check the problem still stands after
+// the recovery has been queued
+func isAnalysisStillValid(analysisEntry *inst.ReplicationAnalysis) bool {
+	replicationAnalysis, err := inst.GetReplicationAnalysis(analysisEntry.ClusterDetails.ClusterName, &inst.ReplicationAnalysisHints{})
+	if err != nil {
+		// Cannot tell; the recovery function validates its own prerequisites
+		return true
+	}
+	for _, entry := range replicationAnalysis {
+		if entry.AnalyzedInstanceKey.Equals(&analysisEntry.AnalyzedInstanceKey) && entry.Analysis == analysisEntry.Analysis {
+			return true
+		}
+	}
+	return false
+}
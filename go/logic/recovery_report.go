@@ -0,0 +1,268 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// A recovery report is a self-contained post-mortem of a recovery, for attaching to incident reviews. The topology
+// of the recovered cluster is captured as the recovery is registered, and again once it completes.
+const (
+	RecoveryTopologyPhaseBefore = "before"
+	RecoveryTopologyPhaseAfter  = "after"
+)
+
+const (
+	hookOutputAuditPrefix    = "Output of "
+	maxHookOutputAuditLength = 4096
+)
+
+// RecoveryTopologySnapshot is the topology of a recovered cluster, captured at a phase of the recovery
+type RecoveryTopologySnapshot struct {
+	RecoveryUID string
+	Phase       string
+	Snapshot    inst.TopologySnapshot
+}
+
+// snapshotRecoveryTopology captures the current topology of given cluster as that of given recovery phase
+func snapshotRecoveryTopology(topologyRecovery *TopologyRecovery, clusterName string, phase string) error {
+	snapshot, err := inst.ReadTopologySnapshot(clusterName)
+	if err != nil {
+		return log.Errore(err)
+	}
+	recoveryTopology := &RecoveryTopologySnapshot{RecoveryUID: topologyRecovery.UID, Phase: phase, Snapshot: *snapshot}
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-recovery-topology", recoveryTopology)
+		return log.Errore(err)
+	}
+	return writeRecoveryTopologySnapshot(recoveryTopology)
+}
+
+// snapshotRecoveryTopologyAfter captures the topology of the recovered cluster once the recovery completes. The
+// cluster is then named by the promoted server, if any.
+func snapshotRecoveryTopologyAfter(topologyRecovery *TopologyRecovery) error {
+	clusterName := topologyRecovery.AnalysisEntry.ClusterDetails.ClusterName
+	if topologyRecovery.SuccessorKey != nil {
+		if successor, found, _ := inst.ReadInstance(topologyRecovery.SuccessorKey); found {
+			clusterName = successor.ClusterName
+		}
+	}
+	return snapshotRecoveryTopology(topologyRecovery, clusterName, RecoveryTopologyPhaseAfter)
+}
+
+func writeRecoveryTopologySnapshot(recoveryTopology *RecoveryTopologySnapshot) error {
+	instancesJSON, err := recoveryTopology.Snapshot.InstancesJSON()
+	if err != nil {
+		return log.Errore(err)
+	}
+	_, err = db.ExecOrchestratorRecovery(`
+			replace
+				into topology_recovery_topology (
+					recovery_uid, snapshot_phase, snapshot_timestamp, cluster_name, instances
+				) values (?, ?, now(), ?, ?)
+			`, recoveryTopology.RecoveryUID, recoveryTopology.Phase, recoveryTopology.Snapshot.ClusterName, instancesJSON,
+	)
+	return log.Errore(err)
+}
+
+// ReadRecoveryTopologySnapshots reads the captured topologies of given recovery, by phase
+func ReadRecoveryTopologySnapshots(recoveryUID string) (map[string]*inst.TopologySnapshot, error) {
+	res := make(map[string]*inst.TopologySnapshot)
+	query := `
+		select
+			snapshot_phase, snapshot_timestamp, cluster_name, instances
+		from
+			topology_recovery_topology
+		where
+			recovery_uid=?
+		`
+	err := db.QueryOrchestrator(query, sqlutils.Args(recoveryUID), func(m sqlutils.RowMap) error {
+		snapshot, err := inst.ParseTopologySnapshot(m.GetString("cluster_name"), m.GetString("snapshot_timestamp"), m.GetString("instances"))
+		if err != nil {
+			return log.Errore(err)
+		}
+		res[m.GetString("snapshot_phase")] = snapshot
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ExpireRecoveryTopologySnapshots removes old rows from the topology_recovery_topology table
+func ExpireRecoveryTopologySnapshots() error {
+	return inst.ExpireTableData("topology_recovery_topology", "snapshot_timestamp")
+}
+
+// ReadRecoveryTopologyDiff returns the difference between the topology before and after given recovery
+func ReadRecoveryTopologyDiff(recoveryUID string) (*inst.TopologyDiff, error) {
+	snapshots, err := ReadRecoveryTopologySnapshots(recoveryUID)
+	if err != nil {
+		return nil, err
+	}
+	before, after := snapshots[RecoveryTopologyPhaseBefore], snapshots[RecoveryTopologyPhaseAfter]
+	if before == nil || after == nil {
+		return nil, fmt.Errorf("ReadRecoveryTopologyDiff: topology before and after recovery %s not captured", recoveryUID)
+	}
+	return inst.NewTopologyDiff(before, after), nil
+}
+
+// RecoveryReportTopology is a captured topology, along with its ASCII and graphviz renderings
+type RecoveryReportTopology struct {
+	Snapshot *inst.TopologySnapshot
+	ASCII    string
+	DOT      string
+}
+
+func newRecoveryReportTopology(snapshot *inst.TopologySnapshot) *RecoveryReportTopology {
+	if snapshot == nil {
+		return nil
+	}
+	return &RecoveryReportTopology{
+		Snapshot: snapshot,
+		ASCII:    snapshot.ASCII(),
+		DOT:      snapshot.DOT(),
+	}
+}
+
+// RecoveryReport is a post-mortem of a recovery: the analysis which triggered it, the timeline of its steps,
+// the output of its hooks, the topology before and after, and the estimated data loss.
+type RecoveryReport struct {
+	GeneratedTimestamp string
+	Recovery           *TopologyRecovery
+	Timeline           []TopologyRecoveryStep
+	Journal            []RecoveryJournalEntry
+	HookOutputs        []TopologyRecoveryStep
+	TopologyBefore     *RecoveryReportTopology
+	TopologyAfter      *RecoveryReportTopology
+	TopologyDiff       *inst.TopologyDiff
+	DataLossEstimate   *DataLossEstimate
+}
+
+// GenerateRecoveryReport generates the post-mortem report of given recovery
+func GenerateRecoveryReport(recoveryUID string) (*RecoveryReport, error) {
+	recoveries, err := ReadRecoveryByUID(recoveryUID)
+	if err != nil {
+		return nil, err
+	}
+	if len(recoveries) == 0 {
+		return nil, fmt.Errorf("GenerateRecoveryReport: recovery not found: %s", recoveryUID)
+	}
+	report := &RecoveryReport{
+		GeneratedTimestamp: time.Now().Format("2006-01-02 15:04:05"),
+		Recovery:           &recoveries[0],
+		HookOutputs:        []TopologyRecoveryStep{},
+		DataLossEstimate:   recoveries[0].DataLossEstimate,
+	}
+	if report.Timeline, err = ReadTopologyRecoverySteps(recoveryUID); err != nil {
+		return nil, err
+	}
+	for _, step := range report.Timeline {
+		if strings.HasPrefix(step.Message, hookOutputAuditPrefix) {
+			report.HookOutputs = append(report.HookOutputs, step)
+		}
+	}
+	if report.Journal, err = ReadRecoveryJournal(recoveryUID); err != nil {
+		return nil, err
+	}
+	snapshots, err := ReadRecoveryTopologySnapshots(recoveryUID)
+	if err != nil {
+		return nil, err
+	}
+	report.TopologyBefore = newRecoveryReportTopology(snapshots[RecoveryTopologyPhaseBefore])
+	report.TopologyAfter = newRecoveryReportTopology(snapshots[RecoveryTopologyPhaseAfter])
+	if report.TopologyBefore != nil && report.TopologyAfter != nil {
+		report.TopologyDiff = inst.NewTopologyDiff(report.TopologyBefore.Snapshot, report.TopologyAfter.Snapshot)
+	}
+	return report, nil
+}
+
+func (this *RecoveryReport) topologyText(title string, topology *RecoveryReportTopology) []string {
+	if topology == nil {
+		return []string{fmt.Sprintf("## %s", title), "", "not captured", ""}
+	}
+	return []string{
+		fmt.Sprintf("## %s (%s, captured at %s)", title, topology.Snapshot.ClusterName, topology.Snapshot.SnapshotTimestamp),
+		"",
+		"```",
+		topology.ASCII,
+		"```",
+		"",
+		"```dot",
+		topology.DOT,
+		"```",
+		"",
+	}
+}
+
+// Text renders the report as markdown
+func (this *RecoveryReport) Text() string {
+	recovery := this.Recovery
+	analysis := &recovery.AnalysisEntry
+	successor := "none"
+	if recovery.SuccessorKey != nil {
+		successor = recovery.SuccessorKey.DisplayString()
+	}
+	lines := []string{
+		fmt.Sprintf("# Recovery report: %s", recovery.UID),
+		"",
+		fmt.Sprintf("- Generated at: %s", this.GeneratedTimestamp),
+		fmt.Sprintf("- Analysis: %s on %s", analysis.Analysis, analysis.AnalyzedInstanceKey.DisplayString()),
+		fmt.Sprintf("- Cluster: %s (alias: %s)", analysis.ClusterDetails.ClusterName, analysis.ClusterDetails.ClusterAlias),
+		fmt.Sprintf("- Analysis description: %s", analysis.Description),
+		fmt.Sprintf("- Replicas at analysis: %s", analysis.SlaveHosts.ToCommaDelimitedList()),
+		fmt.Sprintf("- Recovery started: %s; ended: %s", recovery.RecoveryStartTimestamp, recovery.RecoveryEndTimestamp),
+		fmt.Sprintf("- Processed by: %s", recovery.ProcessingNodeHostname),
+		fmt.Sprintf("- Successful: %t; successor: %s", recovery.IsSuccessful, successor),
+		fmt.Sprintf("- Lost replicas: %s", recovery.LostReplicas.ToCommaDelimitedList()),
+		fmt.Sprintf("- Errors: %s", strings.Join(recovery.AllErrors, "; ")),
+		fmt.Sprintf("- Data loss estimate: %s", this.DataLossEstimate.String()),
+		fmt.Sprintf("- Acknowledged: %t by %s at %s: %s", recovery.Acknowledged, recovery.AcknowledgedBy, recovery.AcknowledgedAt, recovery.AcknowledgedComment),
+		"",
+		"## Timeline",
+		"",
+	}
+	for _, step := range this.Timeline {
+		lines = append(lines, fmt.Sprintf("- %s %s", step.AuditAt, step.Message))
+	}
+	lines = append(lines, "", "## Hook outputs", "")
+	if len(this.HookOutputs) == 0 {
+		lines = append(lines, "none")
+	}
+	for _, step := range this.HookOutputs {
+		lines = append(lines, fmt.Sprintf("- %s %s", step.AuditAt, step.Message))
+	}
+	lines = append(lines, "")
+	lines = append(lines, this.topologyText("Topology before", this.TopologyBefore)...)
+	lines = append(lines, this.topologyText("Topology after", this.TopologyAfter)...)
+	if this.TopologyDiff != nil {
+		lines = append(lines, "## Topology changes", "")
+		for _, change := range this.TopologyDiff.Summary() {
+			lines = append(lines, fmt.Sprintf("- %s", change))
+		}
+		lines = append(lines, "")
+	}
+	return strings.Join(lines, "\n")
+}
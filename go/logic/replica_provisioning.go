@@ -0,0 +1,178 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+// countReachableReplicas counts the reachable, non-downtimed replicas in given cluster alias, other than the excluded ones.
+// Provisioning requests are kept by alias, which, unlike the cluster name, survives a failover.
+func countReachableReplicas(clusterAlias string, excludedKeys *inst.InstanceKeyMap) (count uint, err error) {
+	clusterName, err := inst.DeduceClusterName(clusterAlias)
+	if err != nil {
+		return 0, err
+	}
+	clusterInstances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return 0, err
+	}
+	for _, instance := range clusterInstances {
+		if excludedKeys.HasKey(instance.Key) {
+			continue
+		}
+		if instance.IsReplica() && instance.IsLastCheckValid && !instance.IsDowntimed {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// replaceReplicaProvisioningPlaceholders replaces provisioning placeholders, as well as those of the recovery, in given command
+func replaceReplicaProvisioningPlaceholders(command string, request *ReplicaProvisioningRequest, topologyRecovery *TopologyRecovery) string {
+	command = strings.Replace(command, "{provisionUID}", request.UID, -1)
+	command = strings.Replace(command, "{remainingReplicas}", fmt.Sprintf("%d", request.RemainingReplicas), -1)
+	command = strings.Replace(command, "{minReplicas}", fmt.Sprintf("%d", request.MinReplicas), -1)
+	command = strings.Replace(command, "{missingReplicas}", fmt.Sprintf("%d", request.MinReplicas-request.RemainingReplicas), -1)
+	return replaceCommandPlaceholders(command, topologyRecovery)
+}
+
+// applyReplicaProvisioningEnvironmentVariables sets the relevant environment variables for a provisioning request
+func applyReplicaProvisioningEnvironmentVariables(request *ReplicaProvisioningRequest, topologyRecovery *TopologyRecovery) []string {
+	env := applyEnvironmentVariables(topologyRecovery)
+	env = append(env, fmt.Sprintf("ORC_PROVISION_UID=%s", request.UID))
+	env = append(env, fmt.Sprintf("ORC_REMAINING_REPLICAS=%d", request.RemainingReplicas))
+	env = append(env, fmt.Sprintf("ORC_MIN_REPLICAS=%d", request.MinReplicas))
+	env = append(env, fmt.Sprintf("ORC_MISSING_REPLICAS=%d", request.MinReplicas-request.RemainingReplicas))
+	return env
+}
+
+// requestReplicaProvisioning runs ReplicaProvisioningProcesses when a successful master recovery leaves its cluster with
+// fewer than ReplicaProvisioningMinReplicas reachable replicas. A cluster with a pending request is not requested again.
+func requestReplicaProvisioning(topologyRecovery *TopologyRecovery) error {
+	if config.Config.ReplicaProvisioningMinReplicas == 0 || topologyRecovery.SuccessorKey == nil {
+		return nil
+	}
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	excludedKeys := inst.NewInstanceKeyMap()
+	excludedKeys.AddKey(analysisEntry.AnalyzedInstanceKey)
+	excludedKeys.AddKey(*topologyRecovery.SuccessorKey)
+	for _, lostReplicaKey := range topologyRecovery.LostReplicas.GetInstanceKeys() {
+		excludedKeys.AddKey(lostReplicaKey)
+	}
+	remainingReplicas, err := countReachableReplicas(analysisEntry.ClusterDetails.ClusterAlias, excludedKeys)
+	if err != nil {
+		return err
+	}
+	if remainingReplicas >= config.Config.ReplicaProvisioningMinReplicas {
+		return nil
+	}
+	pendingRequests, err := readPendingReplicaProvisioningRequests(analysisEntry.ClusterDetails.ClusterAlias)
+	if err != nil {
+		return err
+	}
+	if len(pendingRequests) > 0 {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Replica provisioning: %d replicas remain, below minimum %d; provisioning already requested by %s", remainingReplicas, config.Config.ReplicaProvisioningMinReplicas, pendingRequests[0].UID))
+		return nil
+	}
+	request := &ReplicaProvisioningRequest{
+		UID:               util.PrettyUniqueToken(),
+		ClusterName:       analysisEntry.ClusterDetails.ClusterName,
+		ClusterAlias:      analysisEntry.ClusterDetails.ClusterAlias,
+		RecoveryUID:       topologyRecovery.UID,
+		RemainingReplicas: remainingReplicas,
+		MinReplicas:       config.Config.ReplicaProvisioningMinReplicas,
+		Status:            ReplicaProvisioningPending,
+	}
+	if err := writeReplicaProvisioningRequestStatus(request); err != nil {
+		return err
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Replica provisioning: %d replicas remain, below minimum %d; requesting provisioning: %s", remainingReplicas, request.MinReplicas, request.UID))
+	inst.AuditOperation("replica-provisioning-request", topologyRecovery.SuccessorKey, fmt.Sprintf("%s: cluster %s: %d replicas remain, minimum %d", request.UID, request.ClusterAlias, remainingReplicas, request.MinReplicas))
+
+	processes := config.Config.ReplicaProvisioningProcesses
+	for i, command := range processes {
+		fullDescription := fmt.Sprintf("ReplicaProvisioningProcesses hook %d of %d", i+1, len(processes))
+		command := replaceReplicaProvisioningPlaceholders(command, request, topologyRecovery)
+		env := applyReplicaProvisioningEnvironmentVariables(request, topologyRecovery)
+
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %s: %s", fullDescription, command))
+		start := time.Now()
+		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Completed %s in %v", fullDescription, time.Since(start)))
+		} else {
+			info := fmt.Sprintf("Execution of %s failed in %v with error: %v", fullDescription, time.Since(start), cmdErr)
+			AuditTopologyRecovery(topologyRecovery, info)
+			log.Error(info)
+			if err == nil {
+				err = cmdErr
+			}
+		}
+	}
+	if err != nil {
+		request.StatusMessage = err.Error()
+		log.Errore(writeReplicaProvisioningRequestStatus(request))
+	}
+	return err
+}
+
+// ResolveReplicaProvisioningRequests marks pending provisioning requests as fulfilled once their cluster has
+// enough reachable replicas, or as expired once overdue, allowing for new requests
+func ResolveReplicaProvisioningRequests() error {
+	overdueRequests, err := readOverduePendingReplicaProvisioningRequests()
+	if err != nil {
+		return err
+	}
+	for _, request := range overdueRequests {
+		request := request
+		request.Status = ReplicaProvisioningExpired
+		request.StatusMessage = fmt.Sprintf("not fulfilled within %d minutes", config.Config.ReplicaProvisioningExpiryMinutes)
+		if err := writeReplicaProvisioningRequestStatus(&request); err == nil {
+			inst.AuditOperation("replica-provisioning-expired", nil, fmt.Sprintf("%s: cluster %s", request.UID, request.ClusterAlias))
+		}
+	}
+	pendingRequests, err := readPendingReplicaProvisioningRequests("")
+	if err != nil {
+		return err
+	}
+	for _, request := range pendingRequests {
+		request := request
+		replicas, err := countReachableReplicas(request.ClusterAlias, inst.NewInstanceKeyMap())
+		if err != nil {
+			log.Errorf("ResolveReplicaProvisioningRequests: cannot count replicas of %s for %s: %+v", request.ClusterAlias, request.UID, err)
+			continue
+		}
+		if replicas < request.MinReplicas {
+			continue
+		}
+		request.Status = ReplicaProvisioningFulfilled
+		request.StatusMessage = fmt.Sprintf("%d replicas reachable", replicas)
+		if err := writeReplicaProvisioningRequestStatus(&request); err == nil {
+			inst.AuditOperation("replica-provisioning-fulfilled", nil, fmt.Sprintf("%s: cluster %s: %d replicas reachable", request.UID, request.ClusterAlias, replicas))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const (
+	ReplicaProvisioningPending   = "pending"
+	ReplicaProvisioningFulfilled = "fulfilled"
+	ReplicaProvisioningExpired   = "expired"
+)
+
+// ReplicaProvisioningRequest is a request for new replicas, made on behalf of a cluster left with too few replicas
+type ReplicaProvisioningRequest struct {
+	UID                string
+	ClusterName        string
+	ClusterAlias       string
+	RecoveryUID        string
+	RemainingReplicas  uint
+	MinReplicas        uint
+	RequestedTimestamp string
+	Status             string
+	StatusMessage      string
+	StatusTimestamp    string
+}
+
+func writeReplicaProvisioningRequestStatus(request *ReplicaProvisioningRequest) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-replica-provisioning-request", request)
+		return log.Errore(err)
+	}
+	return writeReplicaProvisioningRequest(request)
+}
+
+// writeReplicaProvisioningRequest inserts a provisioning request, or updates its status
+func writeReplicaProvisioningRequest(request *ReplicaProvisioningRequest) error {
+	_, err := db.ExecOrchestrator(`
+			insert into replica_provisioning_request (
+					request_uid, cluster_name, cluster_alias, recovery_uid, remaining_replicas, min_replicas,
+					requested_timestamp, status, status_message, status_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?,
+					now(), ?, ?, now()
+				)
+				on duplicate key update
+					status=values(status),
+					status_message=values(status_message),
+					status_timestamp=now()
+			`, request.UID, request.ClusterName, request.ClusterAlias, request.RecoveryUID, request.RemainingReplicas, request.MinReplicas,
+		request.Status, request.StatusMessage,
+	)
+	return log.Errore(err)
+}
+
+func readReplicaProvisioningRequests(whereCondition string, args []interface{}) ([]ReplicaProvisioningRequest, error) {
+	res := []ReplicaProvisioningRequest{}
+	query := fmt.Sprintf(`
+		select
+			request_uid, cluster_name, cluster_alias, recovery_uid, remaining_replicas, min_replicas,
+			requested_timestamp, status, status_message, status_timestamp
+		from
+			replica_provisioning_request
+		%s
+		order by
+			requested_timestamp desc
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		request := ReplicaProvisioningRequest{}
+		request.UID = m.GetString("request_uid")
+		request.ClusterName = m.GetString("cluster_name")
+		request.ClusterAlias = m.GetString("cluster_alias")
+		request.RecoveryUID = m.GetString("recovery_uid")
+		request.RemainingReplicas = m.GetUint("remaining_replicas")
+		request.MinReplicas = m.GetUint("min_replicas")
+		request.RequestedTimestamp = m.GetString("requested_timestamp")
+		request.Status = m.GetString("status")
+		request.StatusMessage = m.GetString("status_message")
+		request.StatusTimestamp = m.GetString("status_timestamp")
+
+		res = append(res, request)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadReplicaProvisioningRequests reads provisioning requests, optionally filtered by cluster alias, latest first
+func ReadReplicaProvisioningRequests(clusterAlias string) ([]ReplicaProvisioningRequest, error) {
+	if clusterAlias == "" {
+		return readReplicaProvisioningRequests(``, sqlutils.Args())
+	}
+	return readReplicaProvisioningRequests(`where cluster_alias = ?`, sqlutils.Args(clusterAlias))
+}
+
+// readPendingReplicaProvisioningRequests reads pending requests, optionally filtered by cluster alias
+func readPendingReplicaProvisioningRequests(clusterAlias string) ([]ReplicaProvisioningRequest, error) {
+	if clusterAlias == "" {
+		return readReplicaProvisioningRequests(`where status = ?`, sqlutils.Args(ReplicaProvisioningPending))
+	}
+	return readReplicaProvisioningRequests(`where cluster_alias = ? and status = ?`, sqlutils.Args(clusterAlias, ReplicaProvisioningPending))
+}
+
+// readOverduePendingReplicaProvisioningRequests reads pending requests not fulfilled within ReplicaProvisioningExpiryMinutes
+func readOverduePendingReplicaProvisioningRequests() ([]ReplicaProvisioningRequest, error) {
+	return readReplicaProvisioningRequests(`
+		where
+			status = ?
+			and requested_timestamp < now() - interval ? minute
+		`, sqlutils.Args(ReplicaProvisioningPending, config.Config.ReplicaProvisioningExpiryMinutes))
+}
+
+// ExpireReplicaProvisioningRequests removes old rows from the replica_provisioning_request table
+func ExpireReplicaProvisioningRequests() error {
+	return inst.ExpireTableData("replica_provisioning_request", "requested_timestamp")
+}
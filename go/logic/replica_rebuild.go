@@ -0,0 +1,408 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/agent"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+const replicaRebuildSeedPollInterval = 10 * time.Second
+
+// replicaRebuildSourceClusterName is the cluster from which to pick a source for rebuilding given target.
+// A lost replica, detached from its master, is rebuilt from its original cluster.
+func replicaRebuildSourceClusterName(target *inst.Instance) string {
+	if target.MasterKey.IsDetached() {
+		if master, found, _ := inst.ReadInstance(target.MasterKey.ReattachedKey()); found {
+			return master.ClusterName
+		}
+	}
+	return target.ClusterName
+}
+
+// validateReplicaRebuildSource checks a replica is healthy enough to have its data copied onto target
+func validateReplicaRebuildSource(source *inst.Instance, target *inst.Instance) error {
+	if source.Key.Equals(&target.Key) {
+		return fmt.Errorf("Cannot rebuild %+v from itself", target.Key)
+	}
+	if !source.IsLastCheckValid {
+		return fmt.Errorf("Source %+v is not reachable", source.Key)
+	}
+	if !source.ReplicaRunning() {
+		return fmt.Errorf("Source %+v must be a replicating replica", source.Key)
+	}
+	if source.IsBinlogServer() {
+		return fmt.Errorf("Source %+v is a binlog server", source.Key)
+	}
+	if !source.HasReasonableMaintenanceReplicationLag() {
+		return fmt.Errorf("Source %+v lags too much", source.Key)
+	}
+	if target.Version != "" && source.MajorVersionString() != target.MajorVersionString() {
+		return fmt.Errorf("Source %+v runs version %s; target %+v runs %s", source.Key, source.Version, target.Key, target.Version)
+	}
+	return nil
+}
+
+// chooseReplicaRebuildSource picks the healthiest replica to rebuild target from, preferring same data center.
+// With agent seeds, only replicas with an agent are considered.
+func chooseReplicaRebuildSource(target *inst.Instance, method string) (*inst.Instance, error) {
+	clusterInstances, err := inst.ReadClusterInstances(replicaRebuildSourceClusterName(target))
+	if err != nil {
+		return nil, err
+	}
+	agentHosts := make(map[string]bool)
+	if method == config.ReplicaRebuildMethodAgentSeed {
+		agents, err := agent.ReadAgents()
+		if err != nil {
+			return nil, err
+		}
+		for _, hostAgent := range agents {
+			agentHosts[hostAgent.Hostname] = true
+		}
+	}
+	candidates := [](*inst.Instance){}
+	for _, instance := range clusterInstances {
+		if instance.IsDowntimed || validateReplicaRebuildSource(instance, target) != nil {
+			continue
+		}
+		if method == config.ReplicaRebuildMethodAgentSeed && !agentHosts[instance.Key.Hostname] {
+			continue
+		}
+		candidates = append(candidates, instance)
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("No healthy replica found to rebuild %+v from", target.Key)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		iSameDC := candidates[i].DataCenter == target.DataCenter
+		jSameDC := candidates[j].DataCenter == target.DataCenter
+		if iSameDC != jSameDC {
+			return iSameDC
+		}
+		return candidates[i].SlaveLagSeconds.Int64 < candidates[j].SlaveLagSeconds.Int64
+	})
+	return candidates[0], nil
+}
+
+// BeginReplicaRebuild validates and registers the rebuild of a diverged or lost replica from the data of a healthy
+// replica, via ReplicaRebuildMethod. The source key may be nil, in which case orchestrator picks the source.
+// The rebuild itself is run by RunReplicaRebuild.
+func BeginReplicaRebuild(targetKey *inst.InstanceKey, sourceKey *inst.InstanceKey, owner string) (*ReplicaRebuild, error) {
+	return beginReplicaRebuild(targetKey, sourceKey, config.Config.ReplicaRebuildMethod, owner)
+}
+
+// BeginReseedInstance validates and registers the reseeding of an instance from a donor replica via the MySQL 8
+// CLONE plugin, regardless of ReplicaRebuildMethod. The donor key may be nil, in which case orchestrator picks the
+// donor. The reseed is run, and tracked, as a replica rebuild.
+func BeginReseedInstance(targetKey *inst.InstanceKey, donorKey *inst.InstanceKey, owner string) (*ReplicaRebuild, error) {
+	return beginReplicaRebuild(targetKey, donorKey, config.ReplicaRebuildMethodClone, owner)
+}
+
+func beginReplicaRebuild(targetKey *inst.InstanceKey, sourceKey *inst.InstanceKey, method string, owner string) (*ReplicaRebuild, error) {
+	if method == config.ReplicaRebuildMethodAgentSeed && !config.Config.ServeAgentsHttp {
+		return nil, fmt.Errorf("ReplicaRebuildMethod is %q, but agents are not served", config.ReplicaRebuildMethodAgentSeed)
+	}
+	target, found, err := inst.ReadInstance(targetKey)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("Target %+v not found", *targetKey)
+	}
+	if err := inst.CheckNoTouch(targetKey); err != nil {
+		return nil, err
+	}
+	if replicas, err := inst.ReadReplicaInstances(targetKey); err != nil {
+		return nil, err
+	} else if len(replicas) > 0 {
+		return nil, fmt.Errorf("Target %+v has %d replicas; cannot rebuild it", *targetKey, len(replicas))
+	}
+	var source *inst.Instance
+	if sourceKey != nil && sourceKey.IsValid() {
+		if source, err = inst.ReadTopologyInstance(sourceKey); err != nil {
+			return nil, err
+		}
+		if err := validateReplicaRebuildSource(source, target); err != nil {
+			return nil, err
+		}
+	} else if source, err = chooseReplicaRebuildSource(target, method); err != nil {
+		return nil, err
+	}
+	if method == config.ReplicaRebuildMethodClone {
+		// CLONE is run on the target, which must therefore be up
+		liveTarget, err := inst.ReadTopologyInstance(targetKey)
+		if err != nil {
+			return nil, fmt.Errorf("Target %+v must be reachable to be cloned onto: %+v", *targetKey, err)
+		}
+		for _, instance := range []*inst.Instance{liveTarget, source} {
+			if err := inst.CheckClonePlugin(instance); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, instanceKey := range []*inst.InstanceKey{&target.Key, &source.Key} {
+		if running, err := readRunningReplicaRebuilds(instanceKey); err != nil {
+			return nil, err
+		} else if len(running) > 0 {
+			return nil, fmt.Errorf("%+v is already involved in replica rebuild %s", *instanceKey, running[0].UID)
+		}
+	}
+	rebuild := &ReplicaRebuild{
+		UID:       util.PrettyUniqueToken(),
+		TargetKey: target.Key,
+		SourceKey: source.Key,
+		Method:    method,
+		Owner:     owner,
+		Status:    ReplicaRebuildRunning,
+	}
+	if err := writeReplicaRebuild(rebuild); err != nil {
+		return nil, err
+	}
+	inst.AuditOperation("rebuild-replica", targetKey, fmt.Sprintf("%s: rebuilding from %+v via %s, by %s", rebuild.UID, source.Key, rebuild.Method, owner))
+	return rebuild, nil
+}
+
+// replicaRebuildStep records and audits progress of a rebuild
+func replicaRebuildStep(rebuild *ReplicaRebuild, message string) {
+	rebuild.StatusMessage = message
+	log.Errore(writeReplicaRebuild(rebuild))
+	log.Infof("rebuild-replica %s: %+v: %s", rebuild.UID, rebuild.TargetKey, message)
+	inst.AuditOperation("rebuild-replica", &rebuild.TargetKey, fmt.Sprintf("%s: %s", rebuild.UID, message))
+}
+
+// copyReplicaRebuildDataViaAgentSeed copies source's data onto the target via orchestrator-agent seed, which
+// leaves MySQL running on the target
+func copyReplicaRebuildDataViaAgentSeed(rebuild *ReplicaRebuild, timeout time.Duration) error {
+	if _, err := agent.MySQLStop(rebuild.TargetKey.Hostname); err != nil {
+		return fmt.Errorf("Cannot stop MySQL on target: %+v", err)
+	}
+	seedId, err := agent.Seed(rebuild.TargetKey.Hostname, rebuild.SourceKey.Hostname)
+	if err != nil {
+		return err
+	}
+	rebuild.SeedId = seedId
+	replicaRebuildStep(rebuild, fmt.Sprintf("agent seed %d started", seedId))
+	for start := time.Now(); time.Since(start) <= timeout; time.Sleep(replicaRebuildSeedPollInterval) {
+		seeds, err := agent.AgentSeedDetails(seedId)
+		if err != nil || len(seeds) == 0 {
+			continue
+		}
+		if !seeds[0].IsComplete {
+			continue
+		}
+		if !seeds[0].IsSuccessful {
+			states, _ := agent.ReadSeedStates(seedId)
+			if len(states) > 0 {
+				return fmt.Errorf("agent seed %d failed: %s %s", seedId, states[0].Action, states[0].ErrorMessage)
+			}
+			return fmt.Errorf("agent seed %d failed", seedId)
+		}
+		return nil
+	}
+	agent.AbortSeed(seedId)
+	return fmt.Errorf("agent seed %d did not complete within %+v; aborted", seedId, timeout)
+}
+
+// copyReplicaRebuildDataViaCommand copies source's data onto the target via ReplicaRebuildCommand
+func copyReplicaRebuildDataViaCommand(rebuild *ReplicaRebuild) error {
+	command := config.Config.ReplicaRebuildCommand
+	command = strings.Replace(command, "{sourceHost}", rebuild.SourceKey.Hostname, -1)
+	command = strings.Replace(command, "{sourcePort}", fmt.Sprintf("%d", rebuild.SourceKey.Port), -1)
+	command = strings.Replace(command, "{targetHost}", rebuild.TargetKey.Hostname, -1)
+	command = strings.Replace(command, "{targetPort}", fmt.Sprintf("%d", rebuild.TargetKey.Port), -1)
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_SOURCE_HOST=%s", rebuild.SourceKey.Hostname))
+	env = append(env, fmt.Sprintf("ORC_SOURCE_PORT=%d", rebuild.SourceKey.Port))
+	env = append(env, fmt.Sprintf("ORC_TARGET_HOST=%s", rebuild.TargetKey.Hostname))
+	env = append(env, fmt.Sprintf("ORC_TARGET_PORT=%d", rebuild.TargetKey.Port))
+	env = append(env, fmt.Sprintf("ORC_REBUILD_UID=%s", rebuild.UID))
+	return os.CommandRun(command, env)
+}
+
+// describeCloneProgress summarizes the stages of a CLONE operation, e.g. "FILE COPY: 42%"
+func describeCloneProgress(stages []inst.CloneStage) string {
+	description := "clone starting"
+	for _, stage := range stages {
+		if stage.State == "Not Started" {
+			break
+		}
+		description = fmt.Sprintf("clone %s: %s", stage.Stage, stage.State)
+		if stage.State == "In Progress" && stage.Estimate > 0 {
+			description = fmt.Sprintf("clone %s: %d%%", stage.Stage, 100*stage.Data/stage.Estimate)
+		}
+	}
+	return description
+}
+
+// copyReplicaRebuildDataViaClone clones the source's data onto the target via the MySQL 8 CLONE plugin, following
+// its progress. The target restarts once done. Returned are the source's binary log coordinates as of the clone.
+func copyReplicaRebuildDataViaClone(rebuild *ReplicaRebuild, timeout time.Duration) (*inst.BinlogCoordinates, error) {
+	cloneDone := make(chan error, 1)
+	go func() {
+		cloneDone <- inst.CloneInstance(&rebuild.TargetKey, &rebuild.SourceKey)
+	}()
+	start := time.Now()
+	progress := ""
+	for cloning := true; cloning; {
+		select {
+		case err := <-cloneDone:
+			if err != nil {
+				return nil, err
+			}
+			cloning = false
+		case <-time.After(replicaRebuildSeedPollInterval):
+			if time.Since(start) > timeout {
+				return nil, fmt.Errorf("clone did not complete within %+v", timeout)
+			}
+			if stages, err := inst.ReadCloneProgress(&rebuild.TargetKey); err == nil && describeCloneProgress(stages) != progress {
+				progress = describeCloneProgress(stages)
+				replicaRebuildStep(rebuild, progress)
+			}
+		}
+	}
+	// The target restarts; the outcome is read once it is back
+	for time.Since(start) <= timeout {
+		status, err := inst.ReadCloneStatus(&rebuild.TargetKey)
+		if err == nil && status.IsCompleted() {
+			return &status.Coordinates, nil
+		}
+		if err == nil && status.State == "Failed" {
+			return nil, fmt.Errorf("clone failed: error %d: %s", status.ErrorNo, status.ErrorMessage)
+		}
+		time.Sleep(time.Second)
+	}
+	return nil, fmt.Errorf("clone outcome unknown: %+v not back within %+v", rebuild.TargetKey, timeout)
+}
+
+// awaitReachableInstance waits for an instance to accept connections
+func awaitReachableInstance(instanceKey *inst.InstanceKey, timeout time.Duration) (instance *inst.Instance, err error) {
+	for start := time.Now(); time.Since(start) <= timeout; time.Sleep(time.Second) {
+		if instance, err = inst.ReadTopologyInstance(instanceKey); err == nil {
+			return instance, nil
+		}
+	}
+	return nil, fmt.Errorf("%+v not reachable after %+v: %+v", *instanceKey, timeout, err)
+}
+
+// configureRebuiltReplication points the rebuilt target at the source's master. A target which came up with
+// replication configured, as copied from the source, is relocated; otherwise replication is set up via GTID, or,
+// given the source's coordinates as of the copy, via replicating from the source and relocating.
+func configureRebuiltReplication(rebuild *ReplicaRebuild, target *inst.Instance, sourceCoordinates *inst.BinlogCoordinates) error {
+	source, err := inst.ReadTopologyInstance(&rebuild.SourceKey)
+	if err != nil {
+		return err
+	}
+	masterKey := &source.MasterKey
+	if target.ReplicationThreadsExist() {
+		if target.MasterKey.Equals(masterKey) {
+			return nil
+		}
+		_, err := inst.RelocateBelow(&target.Key, masterKey)
+		return err
+	}
+	usingGTID := (source.UsingOracleGTID && target.SupportsOracleGTID) || source.UsingMariaDBGTID
+	if !usingGTID && (sourceCoordinates == nil || sourceCoordinates.LogFile == "") {
+		return fmt.Errorf("%+v has no replication configuration, and cannot be set up via GTID", target.Key)
+	}
+	if usingGTID {
+		_, err = inst.ChangeMasterTo(&target.Key, masterKey, &inst.BinlogCoordinates{}, false, inst.GTIDHintForce)
+	} else {
+		_, err = inst.ChangeMasterTo(&target.Key, &source.Key, sourceCoordinates, false, inst.GTIDHintDeny)
+	}
+	if err != nil {
+		return err
+	}
+	if replicationUser, replicationPassword, err := inst.ReadReplicationCredentials(&source.Key); err == nil && replicationUser != "" {
+		if _, err := inst.ChangeMasterCredentials(&target.Key, replicationUser, replicationPassword); err != nil {
+			return err
+		}
+	}
+	if usingGTID {
+		return nil
+	}
+	if _, err := inst.StartSlave(&target.Key); err != nil {
+		return err
+	}
+	_, err = inst.RelocateBelow(&target.Key, masterKey)
+	return err
+}
+
+func completeReplicaRebuild(rebuild *ReplicaRebuild, err error) error {
+	if err != nil {
+		rebuild.Status = ReplicaRebuildFailed
+		rebuild.StatusMessage = err.Error()
+	} else {
+		rebuild.Status = ReplicaRebuildSucceeded
+		rebuild.StatusMessage = "done"
+	}
+	log.Errore(writeReplicaRebuild(rebuild))
+	inst.AuditOperation(fmt.Sprintf("rebuild-replica-%s", rebuild.Status), &rebuild.TargetKey, fmt.Sprintf("%s: %s", rebuild.UID, rebuild.StatusMessage))
+	return err
+}
+
+// RunReplicaRebuild runs a registered replica rebuild: the target is downtimed, gets the source's data copied over,
+// is set to replicate from the source's master, and is awaited to catch up and be re-discovered. The downtime ends
+// when the rebuild completes, whether it succeeds or fails.
+func RunReplicaRebuild(rebuild *ReplicaRebuild) error {
+	timeout := time.Duration(config.Config.ReplicaRebuildTimeoutSeconds) * time.Second
+	if err := beginDowntime(inst.NewDowntime(&rebuild.TargetKey, rebuild.Owner, fmt.Sprintf("rebuild-replica %s", rebuild.UID), 2*timeout)); err != nil {
+		return completeReplicaRebuild(rebuild, err)
+	}
+	defer func() { log.Errore(endDowntime(&rebuild.TargetKey)) }()
+
+	replicaRebuildStep(rebuild, fmt.Sprintf("copying data from %+v via %s", rebuild.SourceKey, rebuild.Method))
+	var err error
+	var sourceCoordinates *inst.BinlogCoordinates
+	switch rebuild.Method {
+	case config.ReplicaRebuildMethodAgentSeed:
+		err = copyReplicaRebuildDataViaAgentSeed(rebuild, timeout)
+	case config.ReplicaRebuildMethodClone:
+		sourceCoordinates, err = copyReplicaRebuildDataViaClone(rebuild, timeout)
+	default:
+		err = copyReplicaRebuildDataViaCommand(rebuild)
+	}
+	if err != nil {
+		return completeReplicaRebuild(rebuild, fmt.Errorf("Failed copying data: %+v", err))
+	}
+
+	replicaRebuildStep(rebuild, "data copied; waiting for MySQL on target")
+	target, err := awaitReachableInstance(&rebuild.TargetKey, timeout)
+	if err != nil {
+		return completeReplicaRebuild(rebuild, err)
+	}
+	replicaRebuildStep(rebuild, "configuring replication")
+	if err := configureRebuiltReplication(rebuild, target, sourceCoordinates); err != nil {
+		return completeReplicaRebuild(rebuild, fmt.Errorf("Failed configuring replication: %+v", err))
+	}
+	replicaRebuildStep(rebuild, "waiting for replication to catch up")
+	if err := awaitHealthyReplica(&rebuild.TargetKey, timeout); err != nil {
+		return completeReplicaRebuild(rebuild, err)
+	}
+	DiscoverInstance(rebuild.TargetKey)
+	return completeReplicaRebuild(rebuild, nil)
+}
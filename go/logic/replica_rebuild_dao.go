@@ -0,0 +1,158 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const (
+	ReplicaRebuildRunning   = "running"
+	ReplicaRebuildSucceeded = "succeeded"
+	ReplicaRebuildFailed    = "failed"
+)
+
+// ReplicaRebuild is the state of a replica being rebuilt from the data of a healthy source replica.
+// Like agent seeds, rebuilds are run and tracked by the orchestrator node which began them.
+type ReplicaRebuild struct {
+	UID             string
+	TargetKey       inst.InstanceKey
+	SourceKey       inst.InstanceKey
+	Method          string
+	SeedId          int64 // agent seed, when rebuilding via orchestrator-agent
+	Owner           string
+	StartTimestamp  string
+	Status          string
+	StatusMessage   string
+	StatusTimestamp string
+}
+
+// writeReplicaRebuild inserts a replica rebuild, or updates its progress
+func writeReplicaRebuild(rebuild *ReplicaRebuild) error {
+	_, err := db.ExecOrchestrator(`
+			insert into replica_rebuild (
+					rebuild_uid, target_hostname, target_port, source_hostname, source_port, rebuild_method,
+					seed_id, owner, start_timestamp, status, status_message, status_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?,
+					?, ?, now(), ?, ?, now()
+				)
+				on duplicate key update
+					seed_id=values(seed_id),
+					status=values(status),
+					status_message=values(status_message),
+					status_timestamp=now()
+			`, rebuild.UID, rebuild.TargetKey.Hostname, rebuild.TargetKey.Port, rebuild.SourceKey.Hostname, rebuild.SourceKey.Port, rebuild.Method,
+		rebuild.SeedId, rebuild.Owner, rebuild.Status, rebuild.StatusMessage,
+	)
+	return log.Errore(err)
+}
+
+func readReplicaRebuilds(whereCondition string, args []interface{}) ([]ReplicaRebuild, error) {
+	res := []ReplicaRebuild{}
+	query := fmt.Sprintf(`
+		select
+			rebuild_uid, target_hostname, target_port, source_hostname, source_port, rebuild_method,
+			seed_id, owner, start_timestamp, status, status_message, status_timestamp
+		from
+			replica_rebuild
+		%s
+		order by
+			start_timestamp desc
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		rebuild := ReplicaRebuild{}
+		rebuild.UID = m.GetString("rebuild_uid")
+		rebuild.TargetKey.Hostname = m.GetString("target_hostname")
+		rebuild.TargetKey.Port = m.GetInt("target_port")
+		rebuild.SourceKey.Hostname = m.GetString("source_hostname")
+		rebuild.SourceKey.Port = m.GetInt("source_port")
+		rebuild.Method = m.GetString("rebuild_method")
+		rebuild.SeedId = m.GetInt64("seed_id")
+		rebuild.Owner = m.GetString("owner")
+		rebuild.StartTimestamp = m.GetString("start_timestamp")
+		rebuild.Status = m.GetString("status")
+		rebuild.StatusMessage = m.GetString("status_message")
+		rebuild.StatusTimestamp = m.GetString("status_timestamp")
+
+		res = append(res, rebuild)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadReplicaRebuilds reads replica rebuilds, optionally filtered by target instance, latest first
+func ReadReplicaRebuilds(targetKey *inst.InstanceKey) ([]ReplicaRebuild, error) {
+	if targetKey == nil {
+		return readReplicaRebuilds(``, sqlutils.Args())
+	}
+	return readReplicaRebuilds(`where target_hostname = ? and target_port = ?`, sqlutils.Args(targetKey.Hostname, targetKey.Port))
+}
+
+// ReadReplicaRebuild reads a single replica rebuild
+func ReadReplicaRebuild(uid string) (*ReplicaRebuild, error) {
+	rebuilds, err := readReplicaRebuilds(`where rebuild_uid = ?`, sqlutils.Args(uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(rebuilds) == 0 {
+		return nil, fmt.Errorf("replica rebuild %s not found", uid)
+	}
+	return &rebuilds[0], nil
+}
+
+// readRunningReplicaRebuilds reads rebuilds in progress involving given instance, as either target or source
+func readRunningReplicaRebuilds(instanceKey *inst.InstanceKey) ([]ReplicaRebuild, error) {
+	return readReplicaRebuilds(`
+		where
+			status = ?
+			and (
+				(target_hostname = ? and target_port = ?)
+				or (source_hostname = ? and source_port = ?)
+			)
+		`, sqlutils.Args(ReplicaRebuildRunning, instanceKey.Hostname, instanceKey.Port, instanceKey.Hostname, instanceKey.Port))
+}
+
+// FailStaleReplicaRebuilds marks as failed rebuilds which made no progress for twice ReplicaRebuildTimeoutSeconds,
+// e.g. as the node running them went down
+func FailStaleReplicaRebuilds() error {
+	_, err := db.ExecOrchestrator(`
+			update
+				replica_rebuild
+			set
+				status = ?,
+				status_message = 'stale',
+				status_timestamp = now()
+			where
+				status = ?
+				and status_timestamp < now() - interval ? second
+			`, ReplicaRebuildFailed, ReplicaRebuildRunning, 2*config.Config.ReplicaRebuildTimeoutSeconds,
+	)
+	return log.Errore(err)
+}
+
+// ExpireReplicaRebuilds removes old rows from the replica_rebuild table
+func ExpireReplicaRebuilds() error {
+	return inst.ExpireTableData("replica_rebuild", "start_timestamp")
+}
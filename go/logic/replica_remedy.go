@@ -0,0 +1,198 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+// replicaRemedyMutex serializes the rate limit check and registration of replica remediations
+var replicaRemedyMutex sync.Mutex
+
+// replicaRemedyAction returns the remediation action the cluster's replica remedy configures for given
+// analysis, or an empty string if there is none
+func replicaRemedyAction(analysisEntry *inst.ReplicationAnalysis, remedy *config.ReplicaRemedy) string {
+	if remedy == nil {
+		return ""
+	}
+	switch analysisEntry.Analysis {
+	case inst.ReplicationStopped:
+		return remedy.SQLErrnoAction(analysisEntry.LastSQLErrno)
+	case inst.ErrantGTIDReplica:
+		return remedy.ErrantGTIDAction
+	}
+	return ""
+}
+
+// countRecentReplicaRemedies counts remediations of given replica within the last hour
+func countRecentReplicaRemedies(instanceKey *inst.InstanceKey) (count uint, err error) {
+	query := `
+		select
+			count(*) as count_remedies
+		from
+			replica_remedy
+		where
+			hostname = ?
+			and port = ?
+			and remedy_timestamp >= NOW() - INTERVAL 1 HOUR
+		`
+	err = db.QueryOrchestrator(query, sqlutils.Args(instanceKey.Hostname, instanceKey.Port), func(m sqlutils.RowMap) error {
+		count = m.GetUint("count_remedies")
+		return nil
+	})
+	return count, log.Errore(err)
+}
+
+// registerReplicaRemedy registers a remediation of the analyzed replica, returning false if the replica
+// has had maxPerHour remediations within the last hour. A forced remediation is not rate limited.
+func registerReplicaRemedy(analysisEntry *inst.ReplicationAnalysis, action string, maxPerHour uint, force bool) (bool, error) {
+	replicaRemedyMutex.Lock()
+	defer replicaRemedyMutex.Unlock()
+
+	if !force {
+		count, err := countRecentReplicaRemedies(&analysisEntry.AnalyzedInstanceKey)
+		if err != nil {
+			return false, err
+		}
+		if count >= maxPerHour {
+			return false, nil
+		}
+	}
+	_, err := db.ExecOrchestrator(`
+			insert into replica_remedy (
+				hostname, port, remedy_timestamp, analysis, action, sql_errno
+			) values (
+				?, ?, NOW(), ?, ?, ?
+			)
+		`, analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port, string(analysisEntry.Analysis), action, analysisEntry.LastSQLErrno,
+	)
+	if err != nil {
+		return false, log.Errore(err)
+	}
+	return true, nil
+}
+
+// skipReplicaStatements skips failing statements on a replica, one at a time, for as long as replication
+// keeps failing on errors the remedy skips, up to the remedy's SkipCount
+func skipReplicaStatements(instanceKey *inst.InstanceKey, remedy *config.ReplicaRemedy) (skipped uint, err error) {
+	for {
+		instance, err := inst.SkipQuery(instanceKey)
+		if err != inst.ReplicationNotRunningError {
+			if err == nil {
+				skipped++
+			}
+			return skipped, err
+		}
+		skipped++
+		if skipped >= remedy.StatementsToSkip() || instance == nil || remedy.SQLErrnoAction(instance.LastSQLErrno) != config.ReplicaRemedySkip {
+			return skipped, err
+		}
+	}
+}
+
+// runReplicaRemedy applies a remediation action on a replica
+func runReplicaRemedy(instanceKey *inst.InstanceKey, action string, remedy *config.ReplicaRemedy) (result string, err error) {
+	switch action {
+	case config.ReplicaRemedyRestart:
+		if _, err := inst.StartSlave(instanceKey); err != nil {
+			return "", err
+		}
+		return "replication restarted", nil
+	case config.ReplicaRemedySkip:
+		skipped, err := skipReplicaStatements(instanceKey, remedy)
+		if err != nil {
+			return "", fmt.Errorf("after skipping %d statements: %+v", skipped, err)
+		}
+		return fmt.Sprintf("skipped %d statements", skipped), nil
+	case config.ReplicaRemedyReclone:
+		rebuild, err := BeginReplicaRebuild(instanceKey, nil, "orchestrator")
+		if err != nil {
+			return "", err
+		}
+		go RunReplicaRebuild(rebuild)
+		return fmt.Sprintf("rebuild %s started from %+v", rebuild.UID, rebuild.SourceKey), nil
+	case config.ReplicaRemedyInjectEmpty:
+		_, clusterMaster, countInjectedTransactions, err := inst.ErrantGTIDInjectEmpty(instanceKey)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("injected %d empty transactions on %+v", countInjectedTransactions, clusterMaster.Key), nil
+	case config.ReplicaRemedyResetMaster:
+		if _, err := inst.ErrantGTIDResetMaster(instanceKey); err != nil {
+			return "", err
+		}
+		return "errant GTID reset", nil
+	}
+	return "", fmt.Errorf("Unknown replica remedy action: %s", action)
+}
+
+// checkAndRemedyReplica remediates a replica with stopped replication or errant GTID, as configured by the
+// cluster's replica remedy. Remediation is rate limited per replica, and audited. This is not a recovery:
+// nothing is registered in topology_recovery. Analysis hook sets run as for any analysis without a recovery.
+func checkAndRemedyReplica(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	remedy := analysisEntry.ClusterDetails.ReplicaRemedy()
+	action := replicaRemedyAction(&analysisEntry, remedy)
+	if action == "" {
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	instanceKey := &analysisEntry.AnalyzedInstanceKey
+	if err := inst.CheckNoTouch(instanceKey); err != nil {
+		if forceInstanceRecovery || util.ClearToLog("checkAndRemedyReplica: no-touch", instanceKey.StringCode()) {
+			inst.AuditOperation("refuse-no-touch-remedy", instanceKey, err.Error())
+		}
+		if forceInstanceRecovery {
+			return false, nil, err
+		}
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	registered, err := registerReplicaRemedy(&analysisEntry, action, remedy.ActionsPerHour(), forceInstanceRecovery)
+	if err != nil {
+		return false, nil, err
+	}
+	if !registered {
+		if util.ClearToLog("checkAndRemedyReplica: rate limit", instanceKey.StringCode()) {
+			log.Infof("checkAndRemedyReplica: not remediating %+v on %+v: %d remediations within the last hour", analysisEntry.Analysis, *instanceKey, remedy.ActionsPerHour())
+		}
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	inst.AuditOperation("remediate-replica", instanceKey, fmt.Sprintf("%s on %+v: %s", action, analysisEntry.Analysis, analysisEntry.Description))
+	result, err := runReplicaRemedy(instanceKey, action, remedy)
+	if err != nil {
+		inst.AuditOperation("remediate-replica-failed", instanceKey, fmt.Sprintf("%s on %+v: %+v", action, analysisEntry.Analysis, err))
+		log.Errorf("checkAndRemedyReplica: %s on %+v failed: %+v", action, *instanceKey, err)
+	} else {
+		inst.AuditOperation("remediate-replica-done", instanceKey, fmt.Sprintf("%s on %+v: %s", action, analysisEntry.Analysis, result))
+	}
+	if _, _, hooksErr := checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses); hooksErr != nil && err == nil {
+		err = hooksErr
+	}
+	return false, nil, err
+}
+
+// ExpireReplicaRemedies removes old rows from the replica_remedy table
+func ExpireReplicaRemedies() error {
+	return inst.ExpireTableData("replica_remedy", "remedy_timestamp")
+}
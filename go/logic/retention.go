@@ -0,0 +1,224 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	goos "os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+	"github.com/rcrowley/go-metrics"
+)
+
+var retentionArchivedRowsCounter = metrics.NewCounter()
+var retentionArchiveFailureCounter = metrics.NewCounter()
+var retentionArchiveLagGauge = metrics.NewGauge()
+
+func init() {
+	metrics.Register("retention.archive.rows", retentionArchivedRowsCounter)
+	metrics.Register("retention.archive.fail", retentionArchiveFailureCounter)
+	metrics.Register("retention.archive.lag_seconds", retentionArchiveLagGauge)
+}
+
+// retentionPolicy is how long rows of a history table are kept, by their timestamp column
+type retentionPolicy struct {
+	TableName       string
+	TimestampColumn string
+	RetentionHours  func() uint
+}
+
+var retentionPolicies = []retentionPolicy{
+	{"audit", "audit_timestamp", func() uint { return 24 * config.Config.AuditRetentionDays }},
+	{"topology_recovery", "start_active_period", func() uint { return 24 * config.Config.RecoveryRetentionDays }},
+	{"topology_recovery_steps", "audit_at", func() uint { return 24 * config.Config.RecoveryRetentionDays }},
+	{"topology_failure_detection", "start_active_period", func() uint { return 24 * config.Config.RecoveryRetentionDays }},
+	{"database_instance_analysis_changelog", "analysis_timestamp", func() uint {
+		if config.Config.AnalysisChangelogRetentionHours > 0 {
+			return config.Config.AnalysisChangelogRetentionHours
+		}
+		return config.Config.UnseenInstanceForgetHours
+	}},
+}
+
+func isRetentionArchiveEnabled() bool {
+	return config.Config.RetentionArchiveDirectory != ""
+}
+
+// readRetentionCutoff returns the backend's time, formatted 'YYYY-MM-DD hh:mm:ss', given hours ago. Rows older
+// than the cutoff are past retention.
+func readRetentionCutoff(hours uint) (cutoff string, err error) {
+	query := `select NOW() - INTERVAL ? HOUR as cutoff`
+	err = db.QueryOrchestrator(query, sqlutils.Args(hours), func(m sqlutils.RowMap) error {
+		cutoff = m.GetString("cutoff")
+		return nil
+	})
+	return cutoff, log.Errore(err)
+}
+
+// purgeRetainedRows removes rows older than given cutoff
+func purgeRetainedRows(policy *retentionPolicy, cutoff string) error {
+	query := fmt.Sprintf(`delete from %s where %s < ?`, policy.TableName, policy.TimestampColumn)
+	writeFunc := func() error {
+		_, err := db.ExecOrchestrator(query, cutoff)
+		return log.Errore(err)
+	}
+	return inst.ExecDBWriteFunc(writeFunc)
+}
+
+// ExpireRetainedTables purges history rows past retention. When archiving, rows are instead purged by
+// ArchiveRetainedTables, once archived.
+func ExpireRetainedTables() {
+	if isRetentionArchiveEnabled() {
+		return
+	}
+	for i := range retentionPolicies {
+		policy := &retentionPolicies[i]
+		cutoff, err := readRetentionCutoff(policy.RetentionHours())
+		if err != nil {
+			continue
+		}
+		purgeRetainedRows(policy, cutoff)
+	}
+}
+
+// writeRetentionArchive writes the rows of given table older than given cutoff as gzipped JSON lines, one row per
+// line. No file is left behind when there are no such rows.
+func writeRetentionArchive(policy *retentionPolicy, cutoff string, archiveFile string) (rows int64, err error) {
+	temporaryFile := archiveFile + ".tmp"
+	file, err := goos.Create(temporaryFile)
+	if err != nil {
+		return 0, err
+	}
+	defer goos.Remove(temporaryFile)
+	defer file.Close()
+
+	gzipWriter := gzip.NewWriter(file)
+	encoder := json.NewEncoder(gzipWriter)
+	query := fmt.Sprintf(`select * from %s where %s < ? order by %s`, policy.TableName, policy.TimestampColumn, policy.TimestampColumn)
+	err = db.QueryOrchestrator(query, sqlutils.Args(cutoff), func(m sqlutils.RowMap) error {
+		row := make(map[string]interface{})
+		for column, value := range m {
+			if value.Valid {
+				row[column] = value.String
+			} else {
+				row[column] = nil
+			}
+		}
+		rows++
+		return encoder.Encode(row)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return 0, err
+	}
+	if err := file.Close(); err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		return 0, nil
+	}
+	return rows, goos.Rename(temporaryFile, archiveFile)
+}
+
+// archiveRetainedTable archives, uploads and then purges the rows of a table which are past retention
+func archiveRetainedTable(policy *retentionPolicy) error {
+	cutoff, err := readRetentionCutoff(policy.RetentionHours())
+	if err != nil {
+		return err
+	}
+	archiveFile := filepath.Join(config.Config.RetentionArchiveDirectory,
+		fmt.Sprintf("%s-%s.json.gz", policy.TableName, time.Now().UTC().Format("20060102-150405")))
+	rows, err := writeRetentionArchive(policy, cutoff, archiveFile)
+	if err != nil {
+		return fmt.Errorf("Archiving %s: %+v", policy.TableName, err)
+	}
+	if rows == 0 {
+		return nil
+	}
+	if command := config.Config.RetentionArchiveUploadCommand; command != "" {
+		command = strings.Replace(command, "{archiveFile}", archiveFile, -1)
+		command = strings.Replace(command, "{tableName}", policy.TableName, -1)
+		env := []string{
+			fmt.Sprintf("ORC_ARCHIVE_FILE=%s", archiveFile),
+			fmt.Sprintf("ORC_TABLE_NAME=%s", policy.TableName),
+		}
+		if err := os.CommandRun(command, env); err != nil {
+			return fmt.Errorf("Uploading %s: %+v", archiveFile, err)
+		}
+	}
+	if err := purgeRetainedRows(policy, cutoff); err != nil {
+		return err
+	}
+	retentionArchivedRowsCounter.Inc(rows)
+	inst.AuditOperation("retention-archive", nil, fmt.Sprintf("%s: archived %d rows older than %s to %s", policy.TableName, rows, cutoff, archiveFile))
+	return nil
+}
+
+// readRetentionArchiveLag returns how long the oldest row of a table has been past retention; rows are expected
+// to have been archived and purged by then
+func readRetentionArchiveLag(policy *retentionPolicy) (lag time.Duration, err error) {
+	query := fmt.Sprintf(`
+		select
+			NOW() - INTERVAL ? HOUR as cutoff,
+			min(%s) as oldest
+		from
+			%s
+		`, policy.TimestampColumn, policy.TableName)
+	err = db.QueryOrchestrator(query, sqlutils.Args(policy.RetentionHours()), func(m sqlutils.RowMap) error {
+		cutoff := m.GetTime("cutoff")
+		oldest := m.GetTime("oldest")
+		if !oldest.IsZero() && oldest.Before(cutoff) {
+			lag = cutoff.Sub(oldest)
+		}
+		return nil
+	})
+	return lag, log.Errore(err)
+}
+
+// ArchiveRetainedTables archives history rows past retention into RetentionArchiveDirectory, optionally uploads
+// the archives, and purges the archived rows. Rows of a table failing archival are kept, to be archived next time.
+func ArchiveRetainedTables() {
+	if !isRetentionArchiveEnabled() {
+		return
+	}
+	var maxLag time.Duration
+	for i := range retentionPolicies {
+		policy := &retentionPolicies[i]
+		if err := archiveRetainedTable(policy); err != nil {
+			retentionArchiveFailureCounter.Inc(1)
+			log.Errore(err)
+			inst.AuditOperation("retention-archive-failed", nil, err.Error())
+		}
+		if lag, err := readRetentionArchiveLag(policy); err == nil && lag > maxLag {
+			maxLag = lag
+		}
+	}
+	retentionArchiveLagGauge.Update(int64(maxLag.Seconds()))
+}
@@ -0,0 +1,475 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/github/orchestrator/go/agent"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+)
+
+// Rolling restart statuses
+const (
+	RollingRestartRunning   = "running"
+	RollingRestartPaused    = "paused"
+	RollingRestartAborted   = "aborted"
+	RollingRestartCompleted = "completed"
+	RollingRestartFailed    = "failed"
+)
+
+// Rolling restart statuses of a single instance
+const (
+	RollingRestartInstancePending    = "pending"
+	RollingRestartInstanceRestarting = "restarting"
+	RollingRestartInstanceRestarted  = "restarted"
+	RollingRestartInstanceSkipped    = "skipped"
+	RollingRestartInstanceFailed     = "failed"
+)
+
+// RollingRestartInstance is the state of a single server in a rolling restart
+type RollingRestartInstance struct {
+	Key       inst.InstanceKey
+	MasterKey inst.InstanceKey
+	IsMaster  bool
+	Status    string
+	Message   string
+}
+
+// RollingRestart is a coordinated restart of all servers in a cluster: replicas first, deepest first, then
+// the master, following a graceful takeover
+type RollingRestart struct {
+	ClusterName   string
+	DesignatedKey inst.InstanceKey // empty when the master's successor is chosen by orchestrator
+	Owner         string
+	Status        string
+	Message       string
+	StartedAt     time.Time
+	CompletedAt   time.Time
+	Instances     []RollingRestartInstance
+}
+
+// IsActive returns true when the rolling restart is running or paused
+func (this *RollingRestart) IsActive() bool {
+	return this.Status == RollingRestartRunning || this.Status == RollingRestartPaused
+}
+
+// Rolling restarts are kept in memory by cluster name; only the latest one per cluster is kept.
+// Fields of a registered rolling restart are only accessed under rollingRestartsMutex.
+var rollingRestartsMutex sync.Mutex
+var rollingRestarts = make(map[string]*RollingRestart)
+
+const rollingRestartPollInterval = time.Second
+
+func setRollingRestartStatus(rollingRestart *RollingRestart, status string, message string) {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	rollingRestart.Status = status
+	rollingRestart.Message = message
+	if !rollingRestart.IsActive() {
+		rollingRestart.CompletedAt = time.Now()
+	}
+}
+
+func setRollingRestartInstanceStatus(rollingRestart *RollingRestart, i int, status string, message string) {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	rollingRestart.Instances[i].Status = status
+	rollingRestart.Instances[i].Message = message
+}
+
+func readRollingRestartStatus(rollingRestart *RollingRestart) string {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	return rollingRestart.Status
+}
+
+// ReadRollingRestarts returns the latest rolling restart of each cluster, optionally filtered by cluster
+func ReadRollingRestarts(clusterName string) []RollingRestart {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	res := []RollingRestart{}
+	for name, rollingRestart := range rollingRestarts {
+		if clusterName != "" && name != clusterName {
+			continue
+		}
+		rollingRestartCopy := *rollingRestart
+		rollingRestartCopy.Instances = append([]RollingRestartInstance{}, rollingRestart.Instances...)
+		res = append(res, rollingRestartCopy)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ClusterName < res[j].ClusterName })
+	return res
+}
+
+// BeginRollingRestart validates and registers a rolling restart of given cluster. The restart itself is
+// run by RunRollingRestart. The designated key may be nil, in which case orchestrator picks the new master.
+func BeginRollingRestart(clusterName string, designatedKey *inst.InstanceKey, owner string) (*RollingRestart, error) {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
+	}
+	if len(clusterMasters) != 1 {
+		return nil, fmt.Errorf("Cannot deduce cluster master for %+v. Found %+v potential masters", clusterName, len(clusterMasters))
+	}
+	clusterMaster := clusterMasters[0]
+	if !clusterMaster.IsLastCheckValid {
+		return nil, fmt.Errorf("Master %+v is not reachable", clusterMaster.Key)
+	}
+	clusterInstances, err := inst.ReadClusterInstances(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	replicas := [](*inst.Instance){}
+	for _, instance := range clusterInstances {
+		if !instance.Key.Equals(&clusterMaster.Key) {
+			replicas = append(replicas, instance)
+		}
+	}
+	if len(replicas) == 0 {
+		return nil, fmt.Errorf("Master %+v doesn't seem to have replicas; cannot restart it gracefully", clusterMaster.Key)
+	}
+	rollingRestart := &RollingRestart{
+		ClusterName: clusterName,
+		Owner:       owner,
+		Status:      RollingRestartRunning,
+		StartedAt:   time.Now(),
+	}
+	if designatedKey != nil && designatedKey.IsValid() {
+		designatedInstance, found, err := inst.ReadInstance(designatedKey)
+		if err != nil {
+			return nil, err
+		}
+		if !found || designatedInstance.ClusterName != clusterName || !designatedInstance.IsReplica() {
+			return nil, fmt.Errorf("Designated instance %+v must be a replica in cluster %+v", *designatedKey, clusterName)
+		}
+		rollingRestart.DesignatedKey = *designatedKey
+	}
+	// Deepest replicas go first, so that intermediate masters restart after their own replicas are done
+	sort.SliceStable(replicas, func(i, j int) bool { return replicas[i].ReplicationDepth > replicas[j].ReplicationDepth })
+	for _, replica := range replicas {
+		rollingRestart.Instances = append(rollingRestart.Instances, RollingRestartInstance{Key: replica.Key, MasterKey: replica.MasterKey, Status: RollingRestartInstancePending})
+	}
+	rollingRestart.Instances = append(rollingRestart.Instances, RollingRestartInstance{Key: clusterMaster.Key, IsMaster: true, Status: RollingRestartInstancePending})
+
+	if err := registerRollingRestart(rollingRestart); err != nil {
+		return nil, err
+	}
+	inst.AuditOperation("rolling-restart", &clusterMaster.Key, fmt.Sprintf("cluster %s: %d servers, by %s", clusterName, len(rollingRestart.Instances), owner))
+	return rollingRestart, nil
+}
+
+func registerRollingRestart(rollingRestart *RollingRestart) error {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	if existing, found := rollingRestarts[rollingRestart.ClusterName]; found && existing.IsActive() {
+		return fmt.Errorf("A rolling restart of %+v is already %s, started by %s", rollingRestart.ClusterName, existing.Status, existing.Owner)
+	}
+	rollingRestarts[rollingRestart.ClusterName] = rollingRestart
+	return nil
+}
+
+// controlRollingRestart changes the status of the active rolling restart of given cluster
+func controlRollingRestart(clusterName string, owner string, fromStatuses []string, toStatus string) error {
+	rollingRestartsMutex.Lock()
+	defer rollingRestartsMutex.Unlock()
+
+	rollingRestart, found := rollingRestarts[clusterName]
+	if !found {
+		return fmt.Errorf("No rolling restart found for %+v", clusterName)
+	}
+	for _, fromStatus := range fromStatuses {
+		if rollingRestart.Status == fromStatus {
+			rollingRestart.Status = toStatus
+			rollingRestart.Message = fmt.Sprintf("%s by %s", toStatus, owner)
+			inst.AuditOperation(fmt.Sprintf("rolling-restart-%s", toStatus), nil, fmt.Sprintf("cluster %s, by %s", clusterName, owner))
+			return nil
+		}
+	}
+	return fmt.Errorf("Cannot mark rolling restart of %+v as %s: status is %s", clusterName, toStatus, rollingRestart.Status)
+}
+
+// PauseRollingRestart pauses a running rolling restart. Servers being restarted complete their restart.
+func PauseRollingRestart(clusterName string, owner string) error {
+	return controlRollingRestart(clusterName, owner, []string{RollingRestartRunning}, RollingRestartPaused)
+}
+
+// ResumeRollingRestart resumes a paused rolling restart
+func ResumeRollingRestart(clusterName string, owner string) error {
+	return controlRollingRestart(clusterName, owner, []string{RollingRestartPaused}, RollingRestartRunning)
+}
+
+// AbortRollingRestart aborts a running or paused rolling restart. Servers being restarted complete their restart.
+func AbortRollingRestart(clusterName string, owner string) error {
+	return controlRollingRestart(clusterName, owner, []string{RollingRestartRunning, RollingRestartPaused}, RollingRestartAborted)
+}
+
+// awaitRollingRestartTurn blocks while the rolling restart is paused, and errors if it is aborted
+func awaitRollingRestartTurn(rollingRestart *RollingRestart) error {
+	for {
+		switch readRollingRestartStatus(rollingRestart) {
+		case RollingRestartRunning:
+			return nil
+		case RollingRestartPaused:
+			time.Sleep(rollingRestartPollInterval)
+		default:
+			return fmt.Errorf("rolling restart of %+v aborted", rollingRestart.ClusterName)
+		}
+	}
+}
+
+// awaitRollingRestartClusterLag waits until reachable, non-downtimed replicas in the cluster have reasonable lag
+func awaitRollingRestartClusterLag(rollingRestart *RollingRestart) error {
+	timeout := time.Duration(config.Config.RollingRestartInstanceTimeoutSeconds) * time.Second
+	for start := time.Now(); ; time.Sleep(rollingRestartPollInterval) {
+		if err := awaitRollingRestartTurn(rollingRestart); err != nil {
+			return err
+		}
+		clusterInstances, err := inst.ReadClusterInstances(rollingRestart.ClusterName)
+		if err != nil {
+			return err
+		}
+		laggingKeys := []string{}
+		for _, instance := range clusterInstances {
+			if instance.IsReplica() && instance.IsLastCheckValid && !instance.IsDowntimed && !instance.HasReasonableMaintenanceReplicationLag() {
+				laggingKeys = append(laggingKeys, instance.Key.DisplayString())
+			}
+		}
+		if len(laggingKeys) == 0 {
+			return nil
+		}
+		if time.Since(start) > timeout {
+			return fmt.Errorf("replicas lag too much after %+v: %s", timeout, strings.Join(laggingKeys, ", "))
+		}
+	}
+}
+
+// beginDowntime downtimes an instance undergoing maintenance, such as a restart, so that it is not
+// considered a failure
+func beginDowntime(downtime *inst.Downtime) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("begin-downtime", downtime)
+	} else {
+		err = inst.BeginDowntime(downtime)
+	}
+	return err
+}
+
+func endDowntime(instanceKey *inst.InstanceKey) (err error) {
+	if orcraft.IsRaftEnabled() {
+		_, err = orcraft.PublishCommand("end-downtime", *instanceKey)
+	} else {
+		_, err = inst.EndDowntime(instanceKey)
+	}
+	return err
+}
+
+// restartInstance restarts a single MySQL server, via RollingRestartCommand, or else via orchestrator-agent
+func restartInstance(clusterName string, instanceKey *inst.InstanceKey) error {
+	command := config.Config.RollingRestartCommand
+	if command == "" {
+		if _, err := agent.MySQLStop(instanceKey.Hostname); err != nil {
+			return err
+		}
+		_, err := agent.MySQLStart(instanceKey.Hostname)
+		return err
+	}
+	command = strings.Replace(command, "{host}", instanceKey.Hostname, -1)
+	command = strings.Replace(command, "{port}", fmt.Sprintf("%d", instanceKey.Port), -1)
+	command = strings.Replace(command, "{cluster}", clusterName, -1)
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_HOST=%s", instanceKey.Hostname))
+	env = append(env, fmt.Sprintf("ORC_PORT=%d", instanceKey.Port))
+	env = append(env, fmt.Sprintf("ORC_CLUSTER=%s", clusterName))
+	return os.CommandRun(command, env)
+}
+
+// awaitHealthyReplica waits for a restarted or rebuilt replica to come back replicating with reasonable lag.
+// Replication is started if found stopped.
+func awaitHealthyReplica(instanceKey *inst.InstanceKey, timeout time.Duration) error {
+	replicationStarted := false
+	var lastErr error
+	for start := time.Now(); time.Since(start) <= timeout; time.Sleep(rollingRestartPollInterval) {
+		instance, err := inst.ReadTopologyInstance(instanceKey)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if instance.ReplicationThreadsStopped() && !replicationStarted {
+			if _, err := inst.StartSlave(instanceKey); err != nil {
+				lastErr = err
+				continue
+			}
+			replicationStarted = true
+			continue
+		}
+		if instance.ReplicaRunning() && instance.HasReasonableMaintenanceReplicationLag() {
+			return nil
+		}
+		lastErr = fmt.Errorf("replication running: %t, lag: %+v", instance.ReplicaRunning(), instance.SlaveLagSeconds)
+	}
+	return fmt.Errorf("%+v not healthy after %+v: %+v", *instanceKey, timeout, lastErr)
+}
+
+// rollingRestartReplica restarts the i-th instance of a rolling restart, known to be a replica.
+// Unreachable or no-touch replicas are skipped.
+func rollingRestartReplica(rollingRestart *RollingRestart, i int) error {
+	instanceKey := rollingRestart.Instances[i].Key
+	if instance, err := inst.ReadTopologyInstance(&instanceKey); err != nil || !instance.IsLastCheckValid {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceSkipped, "unreachable")
+		inst.AuditOperation("rolling-restart-instance", &instanceKey, "skipped: unreachable")
+		return nil
+	}
+	if err := inst.CheckNoTouch(&instanceKey); err != nil {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceSkipped, err.Error())
+		inst.AuditOperation("rolling-restart-instance", &instanceKey, fmt.Sprintf("skipped: %+v", err))
+		return nil
+	}
+	downtimeDuration := 2 * time.Duration(config.Config.RollingRestartInstanceTimeoutSeconds) * time.Second
+	if err := beginDowntime(inst.NewDowntime(&instanceKey, rollingRestart.Owner, "rolling restart", downtimeDuration)); err != nil {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceFailed, err.Error())
+		return err
+	}
+	defer func() { log.Errore(endDowntime(&instanceKey)) }()
+
+	setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceRestarting, "")
+	inst.AuditOperation("rolling-restart-instance", &instanceKey, "restarting")
+	start := time.Now()
+	if err := restartInstance(rollingRestart.ClusterName, &instanceKey); err != nil {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceFailed, err.Error())
+		inst.AuditOperation("rolling-restart-instance", &instanceKey, fmt.Sprintf("restart failed: %+v", err))
+		return fmt.Errorf("Failed restarting %+v: %+v", instanceKey, err)
+	}
+	if err := awaitHealthyReplica(&instanceKey, time.Duration(config.Config.RollingRestartInstanceTimeoutSeconds)*time.Second); err != nil {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceFailed, err.Error())
+		inst.AuditOperation("rolling-restart-instance", &instanceKey, fmt.Sprintf("restarted yet unhealthy: %+v", err))
+		return err
+	}
+	setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceRestarted, fmt.Sprintf("restarted in %+v", time.Since(start)))
+	inst.AuditOperation("rolling-restart-instance", &instanceKey, fmt.Sprintf("restarted in %+v", time.Since(start)))
+	return nil
+}
+
+// rollingRestartMaster gracefully takes over the master, then restarts the demoted master as a replica
+func rollingRestartMaster(rollingRestart *RollingRestart, i int) error {
+	masterKey := rollingRestart.Instances[i].Key
+	var designatedKey *inst.InstanceKey
+	if rollingRestart.DesignatedKey.IsValid() {
+		designatedKey = &rollingRestart.DesignatedKey
+	} else {
+		replicas, err := inst.ReadReplicaInstances(&masterKey)
+		if err != nil {
+			return err
+		}
+		if len(replicas) > 1 {
+			candidateReplica, _, _, _, _, err := inst.GetCandidateReplica(&masterKey, false)
+			if err != nil {
+				return err
+			}
+			designatedKey = &candidateReplica.Key
+		}
+	}
+	setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceRestarting, "graceful master takeover")
+	inst.AuditOperation("rolling-restart-instance", &masterKey, "graceful master takeover")
+	topologyRecovery, _, err := GracefulMasterTakeover(rollingRestart.ClusterName, designatedKey)
+	if err == nil && (topologyRecovery == nil || topologyRecovery.SuccessorKey == nil) {
+		err = fmt.Errorf("no successor promoted")
+	}
+	if err != nil {
+		setRollingRestartInstanceStatus(rollingRestart, i, RollingRestartInstanceFailed, err.Error())
+		return fmt.Errorf("Graceful master takeover failed: %+v", err)
+	}
+	// The demoted master now replicates from the new master, and is restarted as any other replica
+	return rollingRestartReplica(rollingRestart, i)
+}
+
+// rollingRestartBatchEnd returns the end (exclusive) of the batch of replicas starting at batchStart: up to
+// concurrency replicas, cut short before a replica whose master, or one of whose replicas, is already in the batch
+func rollingRestartBatchEnd(rollingRestart *RollingRestart, batchStart int, concurrency int, masterIndex int) int {
+	batchEnd := batchStart
+	for ; batchEnd < masterIndex && batchEnd < batchStart+concurrency; batchEnd++ {
+		candidate := rollingRestart.Instances[batchEnd]
+		for _, batched := range rollingRestart.Instances[batchStart:batchEnd] {
+			if candidate.MasterKey.Equals(&batched.Key) || batched.MasterKey.Equals(&candidate.Key) {
+				return batchEnd
+			}
+		}
+	}
+	return batchEnd
+}
+
+// RunRollingRestart runs a registered rolling restart: replicas are restarted in batches of RollingRestartConcurrency,
+// each batch waiting for the cluster's replication lag to be reasonable. A replica is never restarted in the same batch
+// as its master. The master goes last, following a graceful master takeover. Honors pause and abort between batches.
+func RunRollingRestart(rollingRestart *RollingRestart) (err error) {
+	defer func() {
+		if err != nil {
+			status := RollingRestartFailed
+			if readRollingRestartStatus(rollingRestart) == RollingRestartAborted {
+				status = RollingRestartAborted
+			}
+			setRollingRestartStatus(rollingRestart, status, err.Error())
+			inst.AuditOperation("rolling-restart-failed", nil, fmt.Sprintf("cluster %s: %+v", rollingRestart.ClusterName, err))
+			return
+		}
+		setRollingRestartStatus(rollingRestart, RollingRestartCompleted, "")
+		inst.AuditOperation("rolling-restart-completed", nil, fmt.Sprintf("cluster %s", rollingRestart.ClusterName))
+	}()
+
+	concurrency := int(config.Config.RollingRestartConcurrency)
+	masterIndex := len(rollingRestart.Instances) - 1
+	for batchStart, batchEnd := 0, 0; batchStart < masterIndex; batchStart = batchEnd {
+		if err := awaitRollingRestartClusterLag(rollingRestart); err != nil {
+			return err
+		}
+		batchEnd = rollingRestartBatchEnd(rollingRestart, batchStart, concurrency, masterIndex)
+		errs := make([]error, batchEnd-batchStart)
+		var wg sync.WaitGroup
+		for i := batchStart; i < batchEnd; i++ {
+			i := i
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i-batchStart] = rollingRestartReplica(rollingRestart, i)
+			}()
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if err := awaitRollingRestartClusterLag(rollingRestart); err != nil {
+		return err
+	}
+	return rollingRestartMaster(rollingRestart, masterIndex)
+}
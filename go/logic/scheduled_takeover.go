@@ -0,0 +1,268 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+	goos "os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/os"
+	"github.com/github/orchestrator/go/process"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+// ScheduledTakeoverTimeFormat is the format of scheduled takeover windows, in orchestrator backend time
+const ScheduledTakeoverTimeFormat = "2006-01-02 15:04:05"
+
+// Scheduled takeover events, as passed to ScheduledTakeoverProcesses
+const (
+	ScheduledTakeoverEventStarting  = "starting"
+	ScheduledTakeoverEventSucceeded = "succeeded"
+	ScheduledTakeoverEventFailed    = "failed"
+	ScheduledTakeoverEventExpired   = "expired"
+)
+
+var runningScheduledMasterTakeovers int64
+
+// validateScheduledMasterTakeover checks the prerequisites of a graceful takeover on given cluster. These are
+// checked when scheduling, and again at execution time, since the topology may have changed meanwhile.
+func validateScheduledMasterTakeover(clusterName string, designatedKey *inst.InstanceKey) error {
+	clusterMasters, err := inst.ReadClusterMaster(clusterName)
+	if err != nil {
+		return fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
+	}
+	if len(clusterMasters) != 1 {
+		return fmt.Errorf("Cannot deduce cluster master for %+v. Found %+v potential masters", clusterName, len(clusterMasters))
+	}
+	clusterMaster := clusterMasters[0]
+	if !clusterMaster.IsLastCheckValid {
+		return fmt.Errorf("Master %+v is not reachable", clusterMaster.Key)
+	}
+	if designatedKey == nil || !designatedKey.IsValid() {
+		replicas, err := inst.ReadReplicaInstances(&clusterMaster.Key)
+		if err != nil {
+			return err
+		}
+		if len(replicas) != 1 {
+			return fmt.Errorf("When no target instance indicated, master %+v should only have one replica, but has %+v", clusterMaster.Key, len(replicas))
+		}
+		return nil
+	}
+	designatedInstance, found, err := inst.ReadInstance(designatedKey)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("Designated instance %+v not found", *designatedKey)
+	}
+	if designatedInstance.ClusterName != clusterName || !designatedInstance.IsReplica() {
+		return fmt.Errorf("Designated instance %+v must be a replica in cluster %+v", *designatedKey, clusterName)
+	}
+	if !designatedInstance.IsLastCheckValid {
+		return fmt.Errorf("Designated instance %+v is not reachable", *designatedKey)
+	}
+	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
+		return fmt.Errorf("Designated instance %+v cannot be promoted due to promotion rule or PromotionIgnoreHostnameFilters", *designatedKey)
+	}
+	if !designatedInstance.HasReasonableMaintenanceReplicationLag() {
+		return fmt.Errorf("Designated instance %+v lags too much", *designatedKey)
+	}
+	return nil
+}
+
+// ScheduleMasterTakeover schedules a graceful master takeover on given cluster, to execute within given window.
+// The designated key may be nil, in which case the master is expected to have a single replica.
+func ScheduleMasterTakeover(clusterName string, designatedKey *inst.InstanceKey, windowStart string, windowEnd string, owner string, comment string) (*ScheduledMasterTakeover, error) {
+	start, err := time.ParseInLocation(ScheduledTakeoverTimeFormat, windowStart, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse window start %q; expected format: %s", windowStart, ScheduledTakeoverTimeFormat)
+	}
+	end, err := time.ParseInLocation(ScheduledTakeoverTimeFormat, windowEnd, time.Local)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot parse window end %q; expected format: %s", windowEnd, ScheduledTakeoverTimeFormat)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("Window end %s must be after window start %s", windowEnd, windowStart)
+	}
+	if err := validateScheduledMasterTakeover(clusterName, designatedKey); err != nil {
+		return nil, err
+	}
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	takeover := &ScheduledMasterTakeover{
+		UID:              util.PrettyUniqueToken(),
+		ClusterName:      clusterName,
+		ClusterAlias:     clusterInfo.ClusterAlias,
+		WindowStart:      windowStart,
+		WindowEnd:        windowEnd,
+		ScheduledBy:      owner,
+		ScheduledComment: comment,
+		Status:           ScheduledTakeoverScheduled,
+	}
+	if designatedKey != nil && designatedKey.IsValid() {
+		takeover.DesignatedKey = *designatedKey
+	}
+	if err := writeScheduledMasterTakeoverStatus(takeover); err != nil {
+		return nil, err
+	}
+	inst.AuditOperation("schedule-master-takeover", designatedKey, fmt.Sprintf("%s: cluster %s, window %s to %s, by %s: %s", takeover.UID, clusterName, windowStart, windowEnd, owner, comment))
+	return takeover, nil
+}
+
+// CancelScheduledMasterTakeover cancels a scheduled takeover which has not started executing
+func CancelScheduledMasterTakeover(uid string, owner string) (*ScheduledMasterTakeover, error) {
+	takeover, err := ReadScheduledMasterTakeover(uid)
+	if err != nil {
+		return nil, err
+	}
+	if takeover.Status != ScheduledTakeoverScheduled {
+		return takeover, fmt.Errorf("Cannot cancel scheduled master takeover %s: status is %s", uid, takeover.Status)
+	}
+	takeover.Status = ScheduledTakeoverCancelled
+	takeover.StatusMessage = fmt.Sprintf("cancelled by %s", owner)
+	if err := writeScheduledMasterTakeoverStatus(takeover); err != nil {
+		return takeover, err
+	}
+	inst.AuditOperation("cancel-scheduled-master-takeover", nil, fmt.Sprintf("%s: cluster %s, cancelled by %s", uid, takeover.ClusterName, owner))
+	return takeover, nil
+}
+
+// replaceScheduledTakeoverPlaceholders replaces scheduled takeover placeholders in given command
+func replaceScheduledTakeoverPlaceholders(command string, event string, takeover *ScheduledMasterTakeover) string {
+	command = strings.Replace(command, "{takeoverEvent}", event, -1)
+	command = strings.Replace(command, "{takeoverUID}", takeover.UID, -1)
+	command = strings.Replace(command, "{takeoverCluster}", takeover.ClusterName, -1)
+	command = strings.Replace(command, "{designatedHost}", takeover.DesignatedKey.Hostname, -1)
+	command = strings.Replace(command, "{designatedPort}", fmt.Sprintf("%d", takeover.DesignatedKey.Port), -1)
+	command = strings.Replace(command, "{takeoverMessage}", takeover.StatusMessage, -1)
+	command = strings.Replace(command, "{orchestratorHost}", process.ThisHostname, -1)
+	return command
+}
+
+// applyScheduledTakeoverEnvironmentVariables sets the relevant environment variables for a scheduled takeover event
+func applyScheduledTakeoverEnvironmentVariables(event string, takeover *ScheduledMasterTakeover) []string {
+	env := goos.Environ()
+	env = append(env, fmt.Sprintf("ORC_TAKEOVER_EVENT=%s", event))
+	env = append(env, fmt.Sprintf("ORC_TAKEOVER_UID=%s", takeover.UID))
+	env = append(env, fmt.Sprintf("ORC_TAKEOVER_CLUSTER=%s", takeover.ClusterName))
+	env = append(env, fmt.Sprintf("ORC_DESIGNATED_HOST=%s", takeover.DesignatedKey.Hostname))
+	env = append(env, fmt.Sprintf("ORC_DESIGNATED_PORT=%d", takeover.DesignatedKey.Port))
+	env = append(env, fmt.Sprintf("ORC_TAKEOVER_MESSAGE=%s", takeover.StatusMessage))
+	env = append(env, fmt.Sprintf("ORC_ORCHESTRATOR_HOST=%s", process.ThisHostname))
+	return env
+}
+
+// executeScheduledTakeoverProcesses notifies ScheduledTakeoverProcesses of a scheduled takeover event.
+// Failures are audited, and do not affect the takeover.
+func executeScheduledTakeoverProcesses(event string, takeover *ScheduledMasterTakeover) {
+	processes := config.Config.ScheduledTakeoverProcesses
+	for i, command := range processes {
+		fullDescription := fmt.Sprintf("ScheduledTakeoverProcesses hook %d of %d", i+1, len(processes))
+		command := replaceScheduledTakeoverPlaceholders(command, event, takeover)
+		env := applyScheduledTakeoverEnvironmentVariables(event, takeover)
+
+		start := time.Now()
+		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+			inst.AuditOperation("scheduled-master-takeover-hook", nil, fmt.Sprintf("%s %s: completed %s in %v: %s", takeover.UID, event, fullDescription, time.Since(start), command))
+		} else {
+			inst.AuditOperation("scheduled-master-takeover-hook", nil, fmt.Sprintf("%s %s: %s failed in %v with error: %v: %s", takeover.UID, event, fullDescription, time.Since(start), cmdErr, command))
+			log.Errorf("Execution of %s failed: %+v", fullDescription, cmdErr)
+		}
+	}
+}
+
+// completeScheduledMasterTakeover persists the final status of a scheduled takeover, and notifies
+func completeScheduledMasterTakeover(takeover *ScheduledMasterTakeover, status string, event string, message string) {
+	takeover.Status = status
+	takeover.StatusMessage = message
+	log.Errore(writeScheduledMasterTakeoverStatus(takeover))
+	inst.AuditOperation(fmt.Sprintf("scheduled-master-takeover-%s", event), nil, fmt.Sprintf("%s: cluster %s: %s", takeover.UID, takeover.ClusterName, message))
+	executeScheduledTakeoverProcesses(event, takeover)
+}
+
+// executeScheduledMasterTakeover re-validates and runs a scheduled takeover whose window is open. The cluster
+// is looked up by alias, since its name may have changed, by a failover, since scheduling.
+func executeScheduledMasterTakeover(takeover *ScheduledMasterTakeover) {
+	var designatedKey *inst.InstanceKey
+	if takeover.DesignatedKey.IsValid() {
+		designatedKey = &takeover.DesignatedKey
+	}
+	clusterName, err := inst.DeduceClusterName(takeover.ClusterAlias)
+	if err != nil {
+		completeScheduledMasterTakeover(takeover, ScheduledTakeoverFailed, ScheduledTakeoverEventFailed, fmt.Sprintf("cannot resolve cluster %s: %+v", takeover.ClusterAlias, err))
+		return
+	}
+	takeover.ClusterName = clusterName
+	if err := validateScheduledMasterTakeover(takeover.ClusterName, designatedKey); err != nil {
+		completeScheduledMasterTakeover(takeover, ScheduledTakeoverFailed, ScheduledTakeoverEventFailed, fmt.Sprintf("pre-checks failed: %+v", err))
+		return
+	}
+	takeover.Status = ScheduledTakeoverExecuting
+	takeover.StatusMessage = ""
+	if err := writeScheduledMasterTakeoverStatus(takeover); err != nil {
+		return
+	}
+	inst.AuditOperation("scheduled-master-takeover-starting", designatedKey, fmt.Sprintf("%s: cluster %s", takeover.UID, takeover.ClusterName))
+	executeScheduledTakeoverProcesses(ScheduledTakeoverEventStarting, takeover)
+
+	topologyRecovery, _, err := GracefulMasterTakeover(takeover.ClusterName, designatedKey)
+	if err != nil {
+		completeScheduledMasterTakeover(takeover, ScheduledTakeoverFailed, ScheduledTakeoverEventFailed, err.Error())
+		return
+	}
+	if topologyRecovery == nil || topologyRecovery.SuccessorKey == nil {
+		completeScheduledMasterTakeover(takeover, ScheduledTakeoverFailed, ScheduledTakeoverEventFailed, "no successor promoted")
+		return
+	}
+	completeScheduledMasterTakeover(takeover, ScheduledTakeoverSucceeded, ScheduledTakeoverEventSucceeded, fmt.Sprintf("promoted %+v", *topologyRecovery.SuccessorKey))
+}
+
+// RunScheduledMasterTakeovers expires scheduled takeovers whose window passed, and executes those whose
+// window is open, one at a time. Only one invocation runs at any given time.
+func RunScheduledMasterTakeovers() error {
+	if !atomic.CompareAndSwapInt64(&runningScheduledMasterTakeovers, 0, 1) {
+		return nil
+	}
+	defer atomic.StoreInt64(&runningScheduledMasterTakeovers, 0)
+
+	missed, err := readMissedScheduledMasterTakeovers()
+	if err != nil {
+		return err
+	}
+	for _, takeover := range missed {
+		takeover := takeover
+		completeScheduledMasterTakeover(&takeover, ScheduledTakeoverExpired, ScheduledTakeoverEventExpired, fmt.Sprintf("window ended at %s without execution", takeover.WindowEnd))
+	}
+	due, err := readDueScheduledMasterTakeovers()
+	if err != nil {
+		return err
+	}
+	for _, takeover := range due {
+		takeover := takeover
+		executeScheduledMasterTakeover(&takeover)
+	}
+	return nil
+}
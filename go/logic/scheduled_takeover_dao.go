@@ -0,0 +1,160 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	orcraft "github.com/github/orchestrator/go/raft"
+
+	"github.com/openark/golib/log"
+	"github.com/openark/golib/sqlutils"
+)
+
+const (
+	ScheduledTakeoverScheduled = "scheduled"
+	ScheduledTakeoverExecuting = "executing"
+	ScheduledTakeoverSucceeded = "succeeded"
+	ScheduledTakeoverFailed    = "failed"
+	ScheduledTakeoverCancelled = "cancelled"
+	ScheduledTakeoverExpired   = "expired"
+)
+
+// ScheduledMasterTakeover is a graceful master takeover to be executed within a future time window
+type ScheduledMasterTakeover struct {
+	UID                string
+	ClusterName        string // as of scheduling; the cluster is identified by its alias, which survives failovers
+	ClusterAlias       string
+	DesignatedKey      inst.InstanceKey // empty when the master's single replica is to be promoted
+	WindowStart        string
+	WindowEnd          string
+	ScheduledBy        string
+	ScheduledComment   string
+	ScheduledTimestamp string
+	Status             string
+	StatusMessage      string
+	StatusTimestamp    string
+}
+
+func writeScheduledMasterTakeoverStatus(takeover *ScheduledMasterTakeover) error {
+	if orcraft.IsRaftEnabled() {
+		_, err := orcraft.PublishCommand("write-scheduled-master-takeover", takeover)
+		return log.Errore(err)
+	}
+	return writeScheduledMasterTakeover(takeover)
+}
+
+// writeScheduledMasterTakeover inserts a scheduled takeover, or updates its status
+func writeScheduledMasterTakeover(takeover *ScheduledMasterTakeover) error {
+	_, err := db.ExecOrchestrator(`
+			insert into scheduled_master_takeover (
+					takeover_uid, cluster_name, cluster_alias, designated_hostname, designated_port, window_start, window_end,
+					scheduled_by, scheduled_comment, scheduled_timestamp, status, status_message, status_timestamp
+				) values (
+					?, ?, ?, ?, ?, ?, ?,
+					?, ?, now(), ?, ?, now()
+				)
+				on duplicate key update
+					status=values(status),
+					status_message=values(status_message),
+					status_timestamp=now()
+			`, takeover.UID, takeover.ClusterName, takeover.ClusterAlias, takeover.DesignatedKey.Hostname, takeover.DesignatedKey.Port,
+		takeover.WindowStart, takeover.WindowEnd,
+		takeover.ScheduledBy, takeover.ScheduledComment, takeover.Status, takeover.StatusMessage,
+	)
+	return log.Errore(err)
+}
+
+func readScheduledMasterTakeovers(whereCondition string, args []interface{}) ([]ScheduledMasterTakeover, error) {
+	res := []ScheduledMasterTakeover{}
+	query := fmt.Sprintf(`
+		select
+			takeover_uid, cluster_name, cluster_alias, designated_hostname, designated_port, window_start, window_end,
+			scheduled_by, scheduled_comment, scheduled_timestamp, status, status_message, status_timestamp
+		from
+			scheduled_master_takeover
+		%s
+		order by
+			window_start asc
+		`, whereCondition)
+	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
+		takeover := ScheduledMasterTakeover{}
+		takeover.UID = m.GetString("takeover_uid")
+		takeover.ClusterName = m.GetString("cluster_name")
+		takeover.ClusterAlias = m.GetString("cluster_alias")
+		takeover.DesignatedKey.Hostname = m.GetString("designated_hostname")
+		takeover.DesignatedKey.Port = m.GetInt("designated_port")
+		takeover.WindowStart = m.GetString("window_start")
+		takeover.WindowEnd = m.GetString("window_end")
+		takeover.ScheduledBy = m.GetString("scheduled_by")
+		takeover.ScheduledComment = m.GetString("scheduled_comment")
+		takeover.ScheduledTimestamp = m.GetString("scheduled_timestamp")
+		takeover.Status = m.GetString("status")
+		takeover.StatusMessage = m.GetString("status_message")
+		takeover.StatusTimestamp = m.GetString("status_timestamp")
+
+		res = append(res, takeover)
+		return nil
+	})
+	return res, log.Errore(err)
+}
+
+// ReadScheduledMasterTakeovers reads scheduled takeovers, optionally filtered by cluster alias, in window order
+func ReadScheduledMasterTakeovers(clusterAlias string) ([]ScheduledMasterTakeover, error) {
+	if clusterAlias == "" {
+		return readScheduledMasterTakeovers(``, sqlutils.Args())
+	}
+	return readScheduledMasterTakeovers(`where cluster_alias = ?`, sqlutils.Args(clusterAlias))
+}
+
+// ReadScheduledMasterTakeover reads a single scheduled takeover
+func ReadScheduledMasterTakeover(uid string) (*ScheduledMasterTakeover, error) {
+	takeovers, err := readScheduledMasterTakeovers(`where takeover_uid = ?`, sqlutils.Args(uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(takeovers) == 0 {
+		return nil, fmt.Errorf("scheduled master takeover %s not found", uid)
+	}
+	return &takeovers[0], nil
+}
+
+// readDueScheduledMasterTakeovers reads scheduled takeovers whose window is open
+func readDueScheduledMasterTakeovers() ([]ScheduledMasterTakeover, error) {
+	return readScheduledMasterTakeovers(`
+		where
+			status = ?
+			and window_start <= now()
+			and window_end > now()
+		`, sqlutils.Args(ScheduledTakeoverScheduled))
+}
+
+// readMissedScheduledMasterTakeovers reads scheduled takeovers whose window passed without executing
+func readMissedScheduledMasterTakeovers() ([]ScheduledMasterTakeover, error) {
+	return readScheduledMasterTakeovers(`
+		where
+			status = ?
+			and window_end <= now()
+		`, sqlutils.Args(ScheduledTakeoverScheduled))
+}
+
+// ExpireScheduledMasterTakeovers removes old rows from the scheduled_master_takeover table
+func ExpireScheduledMasterTakeovers() error {
+	return inst.ExpireTableData("scheduled_master_takeover", "window_end")
+}
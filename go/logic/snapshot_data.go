@@ -45,12 +45,25 @@ type SnapshotData struct {
 	InjectedPseudoGTIDClusters,
 	HostnameResolves,
 	HostnameUnresolves,
+	ReportedAddresses,
 	DowntimedInstances,
+	NoTouchInstances,
 	Candidates,
 	Detections,
 	KVStore,
 	Recovery,
-	RecoverySteps sqlutils.NamedResultData
+	RecoverySteps,
+	RecoveryJournal,
+	RecoveryTopologies,
+	PostponedFunctions,
+	ClusterPriorityTiers,
+	MasterFlappingAcknowledgements,
+	ScheduledMasterTakeovers,
+	ReplicaProvisioningRequests,
+	DowntimeSchedules,
+	ClusterMaintenance,
+	MembershipViolations,
+	RecoveryEscalations sqlutils.NamedResultData
 
 	LeaderURI string
 }
@@ -59,6 +72,47 @@ func NewSnapshotData() *SnapshotData {
 	return &SnapshotData{}
 }
 
+// snapshotTable is a backend table carried by a snapshot, along with its data
+type snapshotTable struct {
+	TableName string
+	Data      *sqlutils.NamedResultData
+}
+
+// tables lists the backend tables carried by a snapshot
+func (this *SnapshotData) tables() []snapshotTable {
+	return []snapshotTable{
+		{"cluster_alias", &this.ClusterAlias},
+		{"cluster_alias_override", &this.ClusterAliasOverride},
+		{"cluster_domain_name", &this.ClusterDomainName},
+		{"access_token", &this.AccessToken},
+		{"host_attributes", &this.HostAttributes},
+		{"database_instance_tags", &this.InstanceTags},
+		{"database_instance_pool", &this.PoolInstances},
+		{"hostname_resolve", &this.HostnameResolves},
+		{"hostname_unresolve", &this.HostnameUnresolves},
+		{"instance_reported_address", &this.ReportedAddresses},
+		{"database_instance_downtime", &this.DowntimedInstances},
+		{"database_instance_no_touch", &this.NoTouchInstances},
+		{"candidate_database_instance", &this.Candidates},
+		{"topology_failure_detection", &this.Detections},
+		{"kv_store", &this.KVStore},
+		{"topology_recovery", &this.Recovery},
+		{"topology_recovery_steps", &this.RecoverySteps},
+		{"topology_recovery_journal", &this.RecoveryJournal},
+		{"topology_recovery_topology", &this.RecoveryTopologies},
+		{"postponed_function", &this.PostponedFunctions},
+		{"cluster_priority_tier", &this.ClusterPriorityTiers},
+		{"cluster_master_flapping_acknowledgement", &this.MasterFlappingAcknowledgements},
+		{"scheduled_master_takeover", &this.ScheduledMasterTakeovers},
+		{"replica_provisioning_request", &this.ReplicaProvisioningRequests},
+		{"downtime_schedule", &this.DowntimeSchedules},
+		{"cluster_maintenance", &this.ClusterMaintenance},
+		{"membership_violation", &this.MembershipViolations},
+		{"cluster_injected_pseudo_gtid", &this.InjectedPseudoGTIDClusters},
+		{"topology_recovery_escalation", &this.RecoveryEscalations},
+	}
+}
+
 func readTableData(tableName string, data *sqlutils.NamedResultData) error {
 	orcdb, err := db.OpenOrchestrator()
 	if err != nil {
@@ -86,22 +140,9 @@ func CreateSnapshotData() *SnapshotData {
 	snapshotData.MinimalInstances, _ = inst.ReadAllMinimalInstances()
 	snapshotData.RecoveryDisabled, _ = IsRecoveryDisabled()
 
-	readTableData("cluster_alias", &snapshotData.ClusterAlias)
-	readTableData("cluster_alias_override", &snapshotData.ClusterAliasOverride)
-	readTableData("cluster_domain_name", &snapshotData.ClusterDomainName)
-	readTableData("access_token", &snapshotData.AccessToken)
-	readTableData("host_attributes", &snapshotData.HostAttributes)
-	readTableData("database_instance_tags", &snapshotData.InstanceTags)
-	readTableData("database_instance_pool", &snapshotData.PoolInstances)
-	readTableData("hostname_resolve", &snapshotData.HostnameResolves)
-	readTableData("hostname_unresolve", &snapshotData.HostnameUnresolves)
-	readTableData("database_instance_downtime", &snapshotData.DowntimedInstances)
-	readTableData("candidate_database_instance", &snapshotData.Candidates)
-	readTableData("topology_failure_detection", &snapshotData.Detections)
-	readTableData("kv_store", &snapshotData.KVStore)
-	readTableData("topology_recovery", &snapshotData.Recovery)
-	readTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
-	readTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	for _, table := range snapshotData.tables() {
+		readTableData(table.TableName, table.Data)
+	}
 
 	log.Debugf("raft snapshot data created")
 	return snapshotData
@@ -141,8 +182,18 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 	if err := json.NewDecoder(zr).Decode(&snapshotData); err != nil {
 		return err
 	}
+	return restoreSnapshotData(snapshotData)
+}
 
+// restoreSnapshotData applies a raft snapshot onto this node: its leader URI and backend data. Instances
+// not in the snapshot are forgotten.
+func restoreSnapshotData(snapshotData *SnapshotData) error {
 	orcraft.LeaderURI.Set(snapshotData.LeaderURI)
+	return applySnapshotData(snapshotData, true)
+}
+
+// applySnapshotData applies snapshot data onto the backend, optionally forgetting instances not in the snapshot
+func applySnapshotData(snapshotData *SnapshotData, forgetMissingInstances bool) error {
 	// keys
 	{
 		snapshotInstanceKeyMap := inst.NewInstanceKeyMap()
@@ -152,12 +203,14 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 		}
 
 		discardedKeys := 0
-		// Forget instances that were not in snapshot
 		existingKeys, _ := inst.ReadAllInstanceKeys()
-		for _, existingKey := range existingKeys {
-			if !snapshotInstanceKeyMap.HasKey(existingKey) {
-				inst.ForgetInstance(&existingKey)
-				discardedKeys++
+		if forgetMissingInstances {
+			// Forget instances that were not in snapshot
+			for _, existingKey := range existingKeys {
+				if !snapshotInstanceKeyMap.HasKey(existingKey) {
+					inst.ForgetInstance(&existingKey)
+					discardedKeys++
+				}
 			}
 		}
 		log.Debugf("raft snapshot restore: discarded %+v keys", discardedKeys)
@@ -192,22 +245,11 @@ func (this *SnapshotDataCreatorApplier) Restore(rc io.ReadCloser) error {
 		}
 		log.Debugf("raft snapshot restore: discovered %+v keys", discoveredKeys)
 	}
-	writeTableData("cluster_alias", &snapshotData.ClusterAlias)
-	writeTableData("cluster_alias_override", &snapshotData.ClusterAliasOverride)
-	writeTableData("cluster_domain_name", &snapshotData.ClusterDomainName)
-	writeTableData("access_token", &snapshotData.AccessToken)
-	writeTableData("host_attributes", &snapshotData.HostAttributes)
-	writeTableData("database_instance_tags", &snapshotData.InstanceTags)
-	writeTableData("database_instance_pool", &snapshotData.PoolInstances)
-	writeTableData("hostname_resolve", &snapshotData.HostnameResolves)
-	writeTableData("hostname_unresolve", &snapshotData.HostnameUnresolves)
-	writeTableData("database_instance_downtime", &snapshotData.DowntimedInstances)
-	writeTableData("candidate_database_instance", &snapshotData.Candidates)
-	writeTableData("kv_store", &snapshotData.KVStore)
-	writeTableData("topology_recovery", &snapshotData.Recovery)
-	writeTableData("topology_failure_detection", &snapshotData.Detections)
-	writeTableData("topology_recovery_steps", &snapshotData.RecoverySteps)
-	writeTableData("cluster_injected_pseudo_gtid", &snapshotData.InjectedPseudoGTIDClusters)
+	for _, table := range snapshotData.tables() {
+		if err := writeTableData(table.TableName, table.Data); err != nil {
+			return log.Errorf("raft snapshot restore: cannot write %s: %+v", table.TableName, err)
+		}
+	}
 
 	// recovery disable
 	{
@@ -19,7 +19,6 @@ package logic
 import (
 	"encoding/json"
 	"fmt"
-	"math/rand"
 	goos "os"
 	"sort"
 	"strings"
@@ -28,6 +27,7 @@ import (
 
 	"github.com/github/orchestrator/go/attributes"
 	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/federation"
 	"github.com/github/orchestrator/go/inst"
 	"github.com/github/orchestrator/go/kv"
 	ometrics "github.com/github/orchestrator/go/metrics"
@@ -42,6 +42,9 @@ import (
 
 var countPendingRecoveries int64
 
+// countActiveMasterRecoveries counts (co-)master recoveries executing on this node
+var countActiveMasterRecoveries int64
+
 type RecoveryType string
 
 const (
@@ -60,6 +63,10 @@ type RecoveryAcknowledgement struct {
 	Id            int64
 	UID           string
 	AllRecoveries bool
+
+	Analysis      string // with ClusterName, FromTimestamp & ToTimestamp as optional filters
+	FromTimestamp string
+	ToTimestamp   string
 }
 
 func NewRecoveryAcknowledgement(owner string, comment string) *RecoveryAcknowledgement {
@@ -96,6 +103,7 @@ type TopologyRecovery struct {
 	AnalysisEntry             inst.ReplicationAnalysis
 	SuccessorKey              *inst.InstanceKey
 	SuccessorAlias            string
+	SuccessorPromotionRule    inst.CandidatePromotionRule
 	IsActive                  bool
 	IsSuccessful              bool
 	LostReplicas              inst.InstanceKeyMap
@@ -113,6 +121,9 @@ type TopologyRecovery struct {
 	RelatedRecoveryId         int64
 	Type                      RecoveryType
 	RecoveryType              MasterRecoveryType
+	FencingMethod             string
+	FencingStatus             string
+	DataLossEstimate          *DataLossEstimate
 }
 
 func NewTopologyRecovery(replicationAnalysis inst.ReplicationAnalysis) *TopologyRecovery {
@@ -161,12 +172,17 @@ const (
 	MasterRecoveryGTID                            = "MasterRecoveryGTID"
 	MasterRecoveryPseudoGTID                      = "MasterRecoveryPseudoGTID"
 	MasterRecoveryBinlogServer                    = "MasterRecoveryBinlogServer"
+	MasterRecoveryGTIDAndBLS                      = "MasterRecoveryGTIDAndBinlogServers"
+	MasterRecoveryProvider                        = "MasterRecoveryProvider"
 )
 
 var emergencyReadTopologyInstanceMap *cache.Cache
 var emergencyRestartReplicaTopologyInstanceMap *cache.Cache
 var emergencyOperationGracefulPeriodMap *cache.Cache
 
+// analysisHooksRunMap remembers analysis hooks recently run, per analysis and instance
+var analysisHooksRunMap = cache.New(time.Minute, time.Minute)
+
 // InstancesByCountReplicas sorts instances by umber of replicas, descending
 type InstancesByCountReplicas [](*inst.Instance)
 
@@ -189,7 +205,12 @@ var recoverDeadIntermediateMasterFailureCounter = metrics.NewCounter()
 var recoverDeadCoMasterCounter = metrics.NewCounter()
 var recoverDeadCoMasterSuccessCounter = metrics.NewCounter()
 var recoverDeadCoMasterFailureCounter = metrics.NewCounter()
+var recoverMasterStaleDataRefusalCounter = metrics.NewCounter()
+var recoverMasterFlappingRefusalCounter = metrics.NewCounter()
 var countPendingRecoveriesGauge = metrics.NewGauge()
+var countActiveMasterRecoveriesGauge = metrics.NewGauge()
+var promotionSQLThreadBytesRemainingGauge = metrics.NewGauge()
+var promotionSQLThreadApplyRateGauge = metrics.NewGauge()
 
 func init() {
 	metrics.Register("recover.dead_master.start", recoverDeadMasterCounter)
@@ -201,12 +222,19 @@ func init() {
 	metrics.Register("recover.dead_co_master.start", recoverDeadCoMasterCounter)
 	metrics.Register("recover.dead_co_master.success", recoverDeadCoMasterSuccessCounter)
 	metrics.Register("recover.dead_co_master.fail", recoverDeadCoMasterFailureCounter)
+	metrics.Register("recover.master.stale_data_refusal", recoverMasterStaleDataRefusalCounter)
+	metrics.Register("recover.master.flapping_refusal", recoverMasterFlappingRefusalCounter)
 	metrics.Register("recover.pending", countPendingRecoveriesGauge)
+	metrics.Register("recover.active_master", countActiveMasterRecoveriesGauge)
+	metrics.Register("recover.promotion_sql_thread.bytes_remaining", promotionSQLThreadBytesRemainingGauge)
+	metrics.Register("recover.promotion_sql_thread.bytes_per_second", promotionSQLThreadApplyRateGauge)
 
 	go initializeTopologyRecoveryPostConfiguration()
 
 	ometrics.OnMetricsTick(func() {
 		countPendingRecoveriesGauge.Update(getCountPendingRecoveries())
+		countActiveMasterRecoveriesGauge.Update(atomic.LoadInt64(&countActiveMasterRecoveries))
+		recoveryQueueDepthGauge.Update(getRecoveryQueueDepth())
 	})
 }
 
@@ -214,6 +242,11 @@ func getCountPendingRecoveries() int64 {
 	return atomic.LoadInt64(&countPendingRecoveries)
 }
 
+// IsMasterRecoveryInProgress checks whether this node is executing a master or co-master recovery
+func IsMasterRecoveryInProgress() bool {
+	return atomic.LoadInt64(&countActiveMasterRecoveries) > 0
+}
+
 func initializeTopologyRecoveryPostConfiguration() {
 	config.WaitForConfigurationToBeLoaded()
 
@@ -242,8 +275,19 @@ func resolveRecovery(topologyRecovery *TopologyRecovery, successorInstance *inst
 	if successorInstance != nil {
 		topologyRecovery.SuccessorKey = &successorInstance.Key
 		topologyRecovery.SuccessorAlias = successorInstance.InstanceAlias
+		topologyRecovery.SuccessorPromotionRule = successorInstance.PromotionRule
 		topologyRecovery.IsSuccessful = true
 	}
+	// Instances which took part in a recovery are polled aggressively for a while
+	recoveredInstanceKeys := inst.NewInstanceKeyMap()
+	recoveredInstanceKeys.AddKey(topologyRecovery.AnalysisEntry.AnalyzedInstanceKey)
+	recoveredInstanceKeys.AddKeys(topologyRecovery.ParticipatingInstanceKeys.GetInstanceKeys())
+	if topologyRecovery.SuccessorKey != nil {
+		recoveredInstanceKeys.AddKey(*topologyRecovery.SuccessorKey)
+	}
+	inst.MarkInstancesUnstable(recoveredInstanceKeys.GetInstanceKeys(), "recovery")
+	pagerDutyResolveRecovery(topologyRecovery)
+
 	if orcraft.IsRaftEnabled() {
 		_, err := orcraft.PublishCommand("resolve-recovery", topologyRecovery)
 		return err
@@ -285,6 +329,7 @@ func replaceCommandPlaceholders(command string, topologyRecovery *TopologyRecove
 	command = strings.Replace(command, "{countLostReplicas}", fmt.Sprintf("%d", len(topologyRecovery.LostReplicas)), -1)
 	command = strings.Replace(command, "{slaveHosts}", analysisEntry.SlaveHosts.ToCommaDelimitedList(), -1)
 	command = strings.Replace(command, "{replicaHosts}", analysisEntry.SlaveHosts.ToCommaDelimitedList(), -1)
+	command = strings.Replace(command, "{dataLossEstimate}", topologyRecovery.DataLossEstimate.String(), -1)
 
 	return command
 }
@@ -310,6 +355,7 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	env = append(env, fmt.Sprintf("ORC_LOST_REPLICAS=%s", topologyRecovery.LostReplicas.ToCommaDelimitedList()))
 	env = append(env, fmt.Sprintf("ORC_REPLICA_HOSTS=%s", analysisEntry.SlaveHosts.ToCommaDelimitedList()))
 	env = append(env, fmt.Sprintf("ORC_RECOVERY_UID=%s", topologyRecovery.UID))
+	env = append(env, fmt.Sprintf("ORC_DATA_LOSS_ESTIMATE=%s", topologyRecovery.DataLossEstimate.String()))
 
 	if topologyRecovery.SuccessorKey != nil {
 		env = append(env, fmt.Sprintf("ORC_SUCCESSOR_HOST=%s", topologyRecovery.SuccessorKey.Hostname))
@@ -322,6 +368,36 @@ func applyEnvironmentVariables(topologyRecovery *TopologyRecovery) []string {
 	return env
 }
 
+// preFailoverProcesses returns the global PreFailoverProcesses, followed by those of the cluster's template, if any
+func preFailoverProcesses(topologyRecovery *TopologyRecovery) (processes []string) {
+	processes = append(processes, config.Config.PreFailoverProcesses...)
+	if template := topologyRecovery.AnalysisEntry.ClusterDetails.GetClusterTemplate(); template != nil {
+		processes = append(processes, template.PreFailoverProcesses...)
+	}
+	return processes
+}
+
+// postFailoverProcesses returns the global PostFailoverProcesses, followed by those of the cluster's template, if any
+func postFailoverProcesses(topologyRecovery *TopologyRecovery) (processes []string) {
+	processes = append(processes, config.Config.PostFailoverProcesses...)
+	if template := topologyRecovery.AnalysisEntry.ClusterDetails.GetClusterTemplate(); template != nil {
+		processes = append(processes, template.PostFailoverProcesses...)
+	}
+	return processes
+}
+
+// auditHookOutput audits the output of a hook as a recovery step, truncated to maxHookOutputAuditLength
+func auditHookOutput(topologyRecovery *TopologyRecovery, fullDescription string, output string) {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return
+	}
+	if len(output) > maxHookOutputAuditLength {
+		output = output[:maxHookOutputAuditLength] + "..."
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("%s%s: %s", hookOutputAuditPrefix, fullDescription, output))
+}
+
 // executeProcesses executes a list of processes
 func executeProcesses(processes []string, description string, topologyRecovery *TopologyRecovery, failOnError bool) error {
 	if len(processes) == 0 {
@@ -340,7 +416,9 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 		// Log the command to be run and record how long it takes as this may be useful
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Running %s: %s", fullDescription, command))
 		start := time.Now()
-		if cmdErr := os.CommandRun(command, env); cmdErr == nil {
+		output, cmdErr := os.CommandRunWithOutput(command, env)
+		auditHookOutput(topologyRecovery, fullDescription, output)
+		if cmdErr == nil {
 			info := fmt.Sprintf("Completed %s in %v",
 				fullDescription, time.Since(start))
 			AuditTopologyRecovery(topologyRecovery, info)
@@ -349,7 +427,6 @@ func executeProcesses(processes []string, description string, topologyRecovery *
 				fullDescription, time.Since(start), cmdErr)
 			AuditTopologyRecovery(topologyRecovery, info)
 			log.Errorf(info)
-			// FIXME: It would be good to additionally include command execution output to the auditing
 
 			if err == nil {
 				// Note first error
@@ -378,6 +455,9 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	if err != nil {
 		return nil, log.Errore(err)
 	}
+	if promotedBinlogServer.IsRipple() {
+		return recoverDeadMasterInRippleTopology(topologyRecovery, promotedBinlogServer)
+	}
 	promotedBinlogServer, err = inst.StopSlave(&promotedBinlogServer.Key)
 	if err != nil {
 		return promotedReplica, log.Errore(err)
@@ -426,42 +506,151 @@ func recoverDeadMasterInBinlogServerTopology(topologyRecovery *TopologyRecovery)
 	if err != nil {
 		return nil, log.Errore(err)
 	}
+	postponeMovingBinlogServerReplicas(topologyRecovery, promotedBinlogServer, promotedReplica, inst.GTIDHintDeny)
 
-	func() {
-		// Move binlog server replicas up to replicate from master.
-		// This can only be done once a BLS has skipped to the next binlog
-		// We postpone this operation. The master is already promoted and we're happy.
-		binlogServerReplicas, err := inst.ReadBinlogServerReplicaInstances(&promotedBinlogServer.Key)
-		if err != nil {
+	return promotedReplica, err
+}
+
+// recoverDeadMasterInRippleTopology recovers a dead master whose replicas are Ripple binlog servers. Ripple replicates
+// via GTID and writes binary logs of its own, hence there is no need to align binary log names: the candidate replica
+// applies all the Ripple server has, is detached, and the Ripple server is then repointed below it via GTID.
+func recoverDeadMasterInRippleTopology(topologyRecovery *TopologyRecovery, promotedBinlogServer *inst.Instance) (promotedReplica *inst.Instance, err error) {
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %+v is a Ripple binlog server; recovering via GTID", promotedBinlogServer.Key))
+	promotedBinlogServer, err = inst.StopSlave(&promotedBinlogServer.Key)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	promotedReplica, err = inst.GetCandidateReplicaOfBinlogServerTopology(&promotedBinlogServer.Key)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	if promotedReplica == nil {
+		return promotedReplica, log.Errorf("recoverDeadMasterInRippleTopology: no candidate replica found below %+v", promotedBinlogServer.Key)
+	}
+	// Apply everything the Ripple server has
+	promotedReplica, err = inst.StopSlave(&promotedReplica.Key)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	promotedReplica, err = inst.StartSlaveUntilMasterCoordinates(&promotedReplica.Key, &promotedBinlogServer.SelfBinlogCoordinates)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	promotedReplica, err = inst.StopSlave(&promotedReplica.Key)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	promotedReplica, err = inst.ResetSlave(&promotedReplica.Key)
+	if err != nil {
+		return promotedReplica, log.Errore(err)
+	}
+	// Reconnect the Ripple server to the promoted replica (now master), via Ripple's CHANGE MASTER TO and START SLAVE
+	promotedBinlogServer, err = inst.Repoint(&promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintForce)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	postponeMovingBinlogServerReplicas(topologyRecovery, promotedBinlogServer, promotedReplica, inst.GTIDHintForce)
+
+	return promotedReplica, err
+}
+
+// postponeMovingBinlogServerReplicas moves the binlog servers replicating from an already repointed binlog
+// server to replicate directly from the promoted replica. The moves are postponed.
+func postponeMovingBinlogServerReplicas(topologyRecovery *TopologyRecovery, promotedBinlogServer *inst.Instance, promotedReplica *inst.Instance, gtidHint inst.OperationGTIDHint) {
+	// Move binlog server replicas up to replicate from master.
+	// This can only be done once a BLS has skipped to the next binlog
+	// We postpone this operation. The master is already promoted and we're happy.
+	binlogServerReplicas, err := inst.ReadBinlogServerReplicaInstances(&promotedBinlogServer.Key)
+	if err != nil {
+		return
+	}
+	maxBinlogServersToPromote := 3
+	for i, binlogServerReplica := range binlogServerReplicas {
+		binlogServerReplica := binlogServerReplica
+		if i >= maxBinlogServersToPromote {
 			return
 		}
-		maxBinlogServersToPromote := 3
-		for i, binlogServerReplica := range binlogServerReplicas {
-			binlogServerReplica := binlogServerReplica
-			if i >= maxBinlogServersToPromote {
-				return
+		postponedFunction := func() error {
+			binlogServerReplica, err := inst.StopSlave(&binlogServerReplica.Key)
+			if err != nil {
+				return err
 			}
-			postponedFunction := func() error {
-				binlogServerReplica, err := inst.StopSlave(&binlogServerReplica.Key)
+			// Make sure the BLS has the "next binlog" -- the one the master flushed & purged to. Otherwise the BLS
+			// will request a binlog the master does not have. Not applicable when moving via GTID (Ripple).
+			if gtidHint != inst.GTIDHintForce && binlogServerReplica.ExecBinlogCoordinates.SmallerThan(&promotedBinlogServer.ExecBinlogCoordinates) {
+				binlogServerReplica, err = inst.StartSlaveUntilMasterCoordinates(&binlogServerReplica.Key, &promotedBinlogServer.ExecBinlogCoordinates)
 				if err != nil {
 					return err
 				}
-				// Make sure the BLS has the "next binlog" -- the one the master flushed & purged to. Otherwise the BLS
-				// will request a binlog the master does not have
-				if binlogServerReplica.ExecBinlogCoordinates.SmallerThan(&promotedBinlogServer.ExecBinlogCoordinates) {
-					binlogServerReplica, err = inst.StartSlaveUntilMasterCoordinates(&binlogServerReplica.Key, &promotedBinlogServer.ExecBinlogCoordinates)
-					if err != nil {
-						return err
-					}
-				}
-				_, err = inst.Repoint(&binlogServerReplica.Key, &promotedReplica.Key, inst.GTIDHintDeny)
-				return err
 			}
-			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("recoverDeadMasterInBinlogServerTopology, moving binlog server %+v", binlogServerReplica.Key))
+			_, err = inst.Repoint(&binlogServerReplica.Key, &promotedReplica.Key, gtidHint)
+			return err
 		}
-	}()
+		topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("recoverDeadMasterInBinlogServerTopology, moving binlog server %+v", binlogServerReplica.Key))
+	}
+}
 
-	return promotedReplica, err
+// recoverDeadMasterInGTIDWithBinlogServersTopology recovers a dead master whose replicas are a mix of GTID replicas
+// and binlog servers. The two are handled independently within the same recovery: a replica is promoted via GTID,
+// among the GTID replicas only. Binlog servers are then regrouped and repointed below the promoted replica, bringing
+// their sub-replicas along. Should that fail, the GTID sub-replicas of the binlog servers are moved via GTID instead.
+func recoverDeadMasterInGTIDWithBinlogServersTopology(topologyRecovery *TopologyRecovery, promotedReplicaIsIdeal func(*inst.Instance) bool) (promotedReplica *inst.Instance, lostReplicas [](*inst.Instance), err error) {
+	failedMasterKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+
+	var cannotReplicateReplicas [](*inst.Instance)
+	AuditTopologyRecovery(topologyRecovery, "RecoverDeadMaster: regrouping non binlog server replicas via GTID")
+	lostReplicas, _, cannotReplicateReplicas, promotedReplica, err = inst.RegroupReplicasGTIDExcludingBinlogServers(failedMasterKey, true, nil, &topologyRecovery.PostponedFunctionsContainer, promotedReplicaIsIdeal)
+	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
+	if promotedReplica == nil {
+		return promotedReplica, lostReplicas, err
+	}
+	topologyRecovery.AddError(err)
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: promoted %+v via GTID; now handling binlog servers", promotedReplica.Key))
+
+	binlogServers, err := inst.ReadBinlogServerReplicaInstances(failedMasterKey)
+	if err != nil {
+		return promotedReplica, lostReplicas, log.Errore(err)
+	}
+	repointBinlogServers := func() error {
+		_, promotedBinlogServer, err := inst.RegroupReplicasBinlogServers(failedMasterKey, false)
+		if err != nil {
+			return err
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: regrouped binlog servers below %+v", promotedBinlogServer.Key))
+		if promotedBinlogServer, err = inst.StopSlave(&promotedBinlogServer.Key); err != nil {
+			return err
+		}
+		// The binlog servers will continue with the binary log following their last one; make the promoted replica serve it.
+		if _, err := inst.FlushBinaryLogsTo(&promotedReplica.Key, promotedBinlogServer.ExecBinlogCoordinates.LogFile); err != nil {
+			return err
+		}
+		if _, err := inst.FlushBinaryLogs(&promotedReplica.Key, 1); err != nil {
+			return err
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: flushed binary logs on %+v past %+v", promotedReplica.Key, promotedBinlogServer.ExecBinlogCoordinates.LogFile))
+		if promotedBinlogServer, err = inst.SkipToNextBinaryLog(&promotedBinlogServer.Key); err != nil {
+			return err
+		}
+		if promotedBinlogServer, err = inst.Repoint(&promotedBinlogServer.Key, &promotedReplica.Key, inst.GTIDHintDeny); err != nil {
+			return err
+		}
+		postponeMovingBinlogServerReplicas(topologyRecovery, promotedBinlogServer, promotedReplica, inst.GTIDHintDeny)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: repointed binlog server %+v below %+v", promotedBinlogServer.Key, promotedReplica.Key))
+		return nil
+	}
+	if err := repointBinlogServers(); err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: unable to repoint binlog servers below %+v: %+v; will move their sub-replicas via GTID", promotedReplica.Key, err))
+		for _, binlogServer := range binlogServers {
+			movedReplicas, unmovedReplicas, err, _ := inst.MoveReplicasGTID(&binlogServer.Key, &promotedReplica.Key, "")
+			if err != nil {
+				log.Errore(err)
+			}
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: moved %d sub-replicas of binlog server %+v via GTID; %d unmoved", len(movedReplicas), binlogServer.Key, len(unmovedReplicas)))
+			lostReplicas = append(lostReplicas, binlogServer)
+			lostReplicas = append(lostReplicas, unmovedReplicas...)
+		}
+	}
+	return promotedReplica, lostReplicas, nil
 }
 
 // recoverDeadMaster recovers a dead master, complete logic inside
@@ -474,7 +663,19 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 
 	inst.AuditOperation("recover-dead-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(preFailoverProcesses(topologyRecovery), "PreFailoverProcesses", topologyRecovery, true); err != nil {
+			return nil, lostReplicas, topologyRecovery.AddError(err)
+		}
+	}
+
+	if err := fenceDeadMaster(topologyRecovery); err != nil {
+		if config.Config.MasterFencingRequired {
+			return nil, lostReplicas, topologyRecovery.AddError(err)
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: %+v; proceeding since MasterFencingRequired is false", err))
+	}
+	if isLiveMasterAnalysis(analysisEntry.Analysis) {
+		if err := fenceLiveMaster(topologyRecovery); err != nil {
 			return nil, lostReplicas, topologyRecovery.AddError(err)
 		}
 	}
@@ -486,6 +687,12 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 		masterRecoveryType = MasterRecoveryGTID
 	} else if analysisEntry.BinlogServerImmediateTopology {
 		masterRecoveryType = MasterRecoveryBinlogServer
+	} else if analysisEntry.GTIDWithBinlogServersImmediateTopology {
+		masterRecoveryType = MasterRecoveryGTIDAndBLS
+	}
+	failoverProvider := getFailoverProvider(analysisEntry)
+	if failoverProvider != nil {
+		masterRecoveryType = MasterRecoveryProvider
 	}
 	topologyRecovery.RecoveryType = masterRecoveryType
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: masterRecoveryType=%+v", masterRecoveryType))
@@ -526,6 +733,17 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: recovering via binlog servers"))
 			promotedReplica, err = recoverDeadMasterInBinlogServerTopology(topologyRecovery)
 		}
+	case MasterRecoveryGTIDAndBLS:
+		{
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: recovering via GTID and binlog servers"))
+			promotedReplica, lostReplicas, err = recoverDeadMasterInGTIDWithBinlogServersTopology(topologyRecovery, promotedReplicaIsIdeal)
+		}
+	case MasterRecoveryProvider:
+		{
+			promotedReplica, lostReplicas, err = recoverDeadMasterViaProvider(topologyRecovery, failoverProvider, candidateInstanceKey)
+			// The provider has already honored the candidate, and cannot be second-guessed by SQL-level relocation
+			postponedAll = true
+		}
 	}
 	topologyRecovery.AddError(err)
 	lostReplicas = append(lostReplicas, cannotReplicateReplicas...)
@@ -575,12 +793,18 @@ func recoverDeadMaster(topologyRecovery *TopologyRecovery, candidateInstanceKey
 }
 
 func MasterFailoverGeographicConstraintSatisfied(analysisEntry *inst.ReplicationAnalysis, suggestedInstance *inst.Instance) (satisfied bool, dissatisfiedReason string) {
-	if config.Config.PreventCrossDataCenterMasterFailover {
+	preventCrossDataCenterMasterFailover := config.Config.PreventCrossDataCenterMasterFailover
+	preventCrossRegionMasterFailover := config.Config.PreventCrossRegionMasterFailover
+	if template := analysisEntry.ClusterDetails.GetClusterTemplate(); template != nil {
+		preventCrossDataCenterMasterFailover = preventCrossDataCenterMasterFailover || template.PreventCrossDataCenterMasterFailover
+		preventCrossRegionMasterFailover = preventCrossRegionMasterFailover || template.PreventCrossRegionMasterFailover
+	}
+	if preventCrossDataCenterMasterFailover {
 		if suggestedInstance.DataCenter != analysisEntry.AnalyzedInstanceDataCenter {
 			return false, fmt.Sprintf("PreventCrossDataCenterMasterFailover: will not promote server in %s when failed server in %s", suggestedInstance.DataCenter, analysisEntry.AnalyzedInstanceDataCenter)
 		}
 	}
-	if config.Config.PreventCrossRegionMasterFailover {
+	if preventCrossRegionMasterFailover {
 		if suggestedInstance.Region != analysisEntry.AnalyzedInstanceRegion {
 			return false, fmt.Sprintf("PreventCrossRegionMasterFailover: will not promote server in %s when failed server in %s", suggestedInstance.Region, analysisEntry.AnalyzedInstanceRegion)
 		}
@@ -797,12 +1021,187 @@ func replacePromotedReplicaWithCandidate(topologyRecovery *TopologyRecovery, dea
 	return promotedReplica, nil
 }
 
+// waitForPromotedReplicaSQLThread waits for the promoted replica to apply its relay logs, for up to
+// DelayMasterPromotionMaxWaitSeconds, auditing the progress and apply rate on the way.
+func waitForPromotedReplicaSQLThread(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (*inst.Instance, error) {
+	defer promotionSQLThreadBytesRemainingGauge.Update(0)
+	defer promotionSQLThreadApplyRateGauge.Update(0)
+
+	reportProgress := func(progress *inst.ReplicationCatchupProgress) {
+		promotionSQLThreadBytesRemainingGauge.Update(progress.BytesRemaining)
+		if progress.BytesPerSecond >= 0 {
+			promotionSQLThreadApplyRateGauge.Update(int64(progress.BytesPerSecond))
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: %s", progress.String()))
+	}
+	maxWait := time.Duration(config.Config.DelayMasterPromotionMaxWaitSeconds) * time.Second
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: waiting for SQL thread on %+v; max wait: %+v", promotedReplica.Key, maxWait))
+	return inst.WaitForSQLThreadUpToDateWithProgress(&promotedReplica.Key, maxWait, 0, reportProgress)
+}
+
+// delayPromotionUntilSQLThreadUpToDate delays the promotion of a replica which has yet to apply its relay logs. Should the
+// wait fail or time out, DelayMasterPromotionTimeoutAction decides whether to fail the promotion, promote another
+// candidate, or proceed with the promotion regardless.
+func delayPromotionUntilSQLThreadUpToDate(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance, lostReplicas [](*inst.Instance)) (*inst.Instance, error) {
+	caughtUpReplica, err := waitForPromotedReplicaSQLThread(topologyRecovery, promotedReplica)
+	if err == nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: SQL thread caught up on %+v", promotedReplica.Key))
+		return caughtUpReplica, nil
+	}
+	switch config.Config.DelayMasterPromotionTimeoutAction {
+	case config.DelayMasterPromotionTimeoutNextCandidate:
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: %+v; will promote next candidate", err))
+		nextCandidate, err := promoteNextCandidateWithSQLThreadUpToDate(topologyRecovery, promotedReplica)
+		if err != nil {
+			return nil, fmt.Errorf("DelayMasterPromotionIfSQLThreadNotUpToDate error: %+v", err)
+		}
+		return nextCandidate, nil
+	case config.DelayMasterPromotionTimeoutProceed:
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: %+v; proceeding with promotion of %+v, whose relay logs are not fully applied", err, promotedReplica.Key))
+		postponeReattachingLostReplicas(topologyRecovery, promotedReplica, lostReplicas)
+		return promotedReplica, nil
+	}
+	return nil, fmt.Errorf("DelayMasterPromotionIfSQLThreadNotUpToDate error: %+v", err)
+}
+
+// promoteNextCandidateWithSQLThreadUpToDate replaces a promoted replica whose SQL thread has not caught up with the
+// most up to date of its own replicas whose SQL thread has. Relay logs not applied by the promoted replica are given up.
+func promoteNextCandidateWithSQLThreadUpToDate(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (*inst.Instance, error) {
+	replicas, err := inst.ReadReplicaInstances(&promotedReplica.Key)
+	if err != nil {
+		return nil, err
+	}
+	var nextCandidate *inst.Instance
+	for _, replica := range replicas {
+		if !replica.IsLastCheckValid || !replica.SQLThreadUpToDate() {
+			continue
+		}
+		if replica.PromotionRule == inst.MustNotPromoteRule || inst.IsBannedFromBeingCandidateReplica(replica) {
+			continue
+		}
+		if nextCandidate == nil || nextCandidate.ExecBinlogCoordinates.SmallerThan(&replica.ExecBinlogCoordinates) {
+			nextCandidate = replica
+		}
+	}
+	if nextCandidate == nil {
+		return nil, fmt.Errorf("no replica of %+v qualifies as next candidate", promotedReplica.Key)
+	}
+	replacement, err := replacePromotedReplicaWithCandidate(topologyRecovery, &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey, promotedReplica, &nextCandidate.Key)
+	if err != nil {
+		return nil, err
+	}
+	if !replacement.Key.Equals(&nextCandidate.Key) {
+		return nil, fmt.Errorf("could not promote next candidate %+v", nextCandidate.Key)
+	}
+	return replacement, nil
+}
+
+// closePromotedReplicaMTSGaps closes gaps a multi-threaded promoted replica may have in its applied transactions, so
+// that the new master does not serve writes on top of an inconsistent data set. Failing to, the promotion fails.
+func closePromotedReplicaMTSGaps(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance) (*inst.Instance, error) {
+	timeout := time.Duration(config.Config.CloseMTSGapsTimeoutSeconds) * time.Second
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("CloseMTSGapsBeforeMasterPromotion: checking %+v for MTS gaps; timeout: %+v", promotedReplica.Key, timeout))
+	closedReplica, closed, err := inst.CloseMTSGaps(&promotedReplica.Key, timeout)
+	if err != nil {
+		inst.AuditOperation("close-mts-gaps-failed", &promotedReplica.Key, err.Error())
+		return nil, fmt.Errorf("RecoverDeadMaster: failed %+v promotion; could not close MTS gaps: %+v", promotedReplica.Key, err)
+	}
+	if !closed {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("CloseMTSGapsBeforeMasterPromotion: %+v is single threaded, or its sql thread is running; no gaps to close", promotedReplica.Key))
+		return promotedReplica, nil
+	}
+	inst.AuditOperation("close-mts-gaps", &promotedReplica.Key, fmt.Sprintf("closed MTS gaps before promotion; executed up to %+v", closedReplica.ExecBinlogCoordinates))
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("CloseMTSGapsBeforeMasterPromotion: closed MTS gaps on %+v; executed up to %+v", promotedReplica.Key, closedReplica.ExecBinlogCoordinates))
+	return closedReplica, nil
+}
+
+// postponeReattachingLostReplicas attempts, once the promotion is complete, to relocate replicas lost during the
+// recovery below the promoted replica. Each lost replica is reattached once, even if reported lost more than once.
+func postponeReattachingLostReplicas(topologyRecovery *TopologyRecovery, promotedReplica *inst.Instance, lostReplicas [](*inst.Instance)) {
+	lostReplicaKeys := inst.NewInstanceKeyMap()
+	lostReplicaKeys.AddKeys(topologyRecovery.LostReplicas.GetInstanceKeys())
+	lostReplicaKeys.AddInstances(lostReplicas)
+	for _, lostReplicaKey := range lostReplicaKeys.GetInstanceKeys() {
+		if lostReplicaKey.Equals(&promotedReplica.Key) {
+			continue
+		}
+		lostReplicaKey := lostReplicaKey
+		postponedFunction := func() error {
+			_, err := inst.RelocateBelow(&lostReplicaKey, &promotedReplica.Key)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: reattaching lost replica %+v below %+v: success=%t", lostReplicaKey, promotedReplica.Key, (err == nil)))
+			return err
+		}
+		topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate, reattaching lost replica %+v", lostReplicaKey))
+	}
+}
+
+// checkMasterRecoveryDataFreshness returns an error when the data on which the given analysis is based
+// cannot be trusted: either the backend data for the master is too old, or this node's raft log has
+// not yet been applied, as told by raftApplyLag. Acting upon stale data may mean failing over a healthy master.
+func checkMasterRecoveryDataFreshness(analysisEntry *inst.ReplicationAnalysis, raftApplyLag uint64) error {
+	if maxStaleness := config.Config.MasterRecoveryMaxDataStalenessSeconds; maxStaleness > 0 {
+		if analysisEntry.SecondsSinceLastChecked > int64(maxStaleness) {
+			return fmt.Errorf("backend data for %+v is %d seconds old; MasterRecoveryMaxDataStalenessSeconds is %d", analysisEntry.AnalyzedInstanceKey, analysisEntry.SecondsSinceLastChecked, maxStaleness)
+		}
+	}
+	if maxApplyLag := config.Config.MasterRecoveryMaxRaftApplyLag; maxApplyLag > 0 {
+		if raftApplyLag > maxApplyLag {
+			return fmt.Errorf("raft apply lag is %d entries; MasterRecoveryMaxRaftApplyLag is %d", raftApplyLag, maxApplyLag)
+		}
+	}
+	return nil
+}
+
+// refuseStaleMasterRecovery checks data freshness for an automated master recovery. It returns true
+// (and audits the refusal) when the recovery must not take place.
+func refuseStaleMasterRecovery(analysisEntry *inst.ReplicationAnalysis, forceInstanceRecovery bool) bool {
+	if forceInstanceRecovery {
+		// A human (or an explicit API call) takes responsibility
+		return false
+	}
+	var raftApplyLag uint64
+	if orcraft.IsRaftEnabled() {
+		raftApplyLag = orcraft.ApplyLag()
+	}
+	err := checkMasterRecoveryDataFreshness(analysisEntry, raftApplyLag)
+	if err == nil {
+		return false
+	}
+	recoverMasterStaleDataRefusalCounter.Inc(1)
+	log.Errorf("Refusing %+v recovery on %+v due to stale data: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, err)
+	inst.AuditOperation("refuse-stale-master-recovery", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%s: %+v", analysisEntry.Analysis, err))
+	return true
+}
+
+// refuseFlappingMasterRecovery checks whether an automated master recovery must be refused because the
+// cluster's master has been failing over too often. Returns true when refused.
+func refuseFlappingMasterRecovery(analysisEntry *inst.ReplicationAnalysis, forceInstanceRecovery bool) bool {
+	if forceInstanceRecovery || !analysisEntry.ClusterDetails.IsMasterFlapping {
+		return false
+	}
+	recoverMasterFlappingRefusalCounter.Inc(1)
+	if util.ClearToLog("refuseFlappingMasterRecovery", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+		log.Errorf("Refusing %+v recovery on %+v: master of cluster %s is flapping; acknowledge via ack-master-flapping to re-enable automated failover", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, analysisEntry.ClusterDetails.ClusterName)
+		inst.AuditOperation("refuse-flapping-master-recovery", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%s: master of cluster %s is flapping", analysisEntry.Analysis, analysisEntry.ClusterDetails.ClusterName))
+	}
+	return true
+}
+
 // checkAndRecoverDeadMaster checks a given analysis, decides whether to take action, and possibly takes action
 // Returns true when action was taken.
 func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
 		return false, nil, nil
 	}
+	if refuseStaleMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
+	if refuseFlappingMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
+	if refuseUnconfirmedMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadMaster.", analysisEntry.AnalyzedInstanceKey))
@@ -810,6 +1209,8 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 	}
 
 	// That's it! We must do recovery!
+	atomic.AddInt64(&countActiveMasterRecoveries, 1)
+	defer atomic.AddInt64(&countActiveMasterRecoveries, -1)
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("will handle DeadMaster event on %+v", analysisEntry.ClusterDetails.ClusterName))
 	recoverDeadMasterCounter.Inc(1)
 	promotedReplica, lostReplicas, err := recoverDeadMaster(topologyRecovery, candidateInstanceKey, skipProcesses)
@@ -824,15 +1225,39 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 		if satisfied, reason := MasterFailoverGeographicConstraintSatisfied(&analysisEntry, promotedReplica); !satisfied {
 			return nil, fmt.Errorf("RecoverDeadMaster: failed %+v promotion; %s", promotedReplica.Key, reason)
 		}
+		if config.Config.CloseMTSGapsBeforeMasterPromotion {
+			closedReplica, err := closePromotedReplicaMTSGaps(topologyRecovery, promotedReplica)
+			if err != nil {
+				return nil, err
+			}
+			promotedReplica = closedReplica
+		}
 		if config.Config.FailMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
 			return nil, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionIfSQLThreadNotUpToDate is set and promoted replica %+v 's sql thread is not up to date (relay logs still unapplied). Aborting promotion", promotedReplica.Key)
 		}
 		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate && !promotedReplica.SQLThreadUpToDate() {
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: waiting for SQL thread on %+v", promotedReplica.Key))
-			if _, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
-				return nil, fmt.Errorf("DelayMasterPromotionIfSQLThreadNotUpToDate error: %+v", err)
+			delayedReplica, err := delayPromotionUntilSQLThreadUpToDate(topologyRecovery, promotedReplica, lostReplicas)
+			if err != nil {
+				return delayedReplica, err
+			}
+			promotedReplica = delayedReplica
+		}
+		if config.Config.MasterFailoverBinlogArchiveFetchCommand != "" && topologyRecovery.RecoveryType != MasterRecoveryProvider {
+			appliedReplica, err := applyBinlogArchiveTail(topologyRecovery, promotedReplica)
+			if err != nil {
+				// Not applying the tail means data loss, yet not a broken promotion
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: could not apply binlog archive tail on %+v; proceeding with promotion: %+v", promotedReplica.Key, err))
+			} else {
+				promotedReplica = appliedReplica
+			}
+		}
+		if violations, err := evaluateClusterMembership(&analysisEntry.ClusterDetails, &promotedReplica.Key); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: could not evaluate membership rules: %+v", err))
+		} else {
+			// Membership rules only warn; they never cancel a promotion
+			for _, violation := range violations {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: warning: promoting %+v violates membership rule %s: data center %s: %s", promotedReplica.Key, violation.Rule, violation.DataCenter, violation.Description))
 			}
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("DelayMasterPromotionIfSQLThreadNotUpToDate: SQL thread caught up on %+v", promotedReplica.Key))
 		}
 		// All seems well. No override done.
 		return promotedReplica, err
@@ -841,41 +1266,231 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 	if err != nil {
 		AuditTopologyRecovery(topologyRecovery, err.Error())
 	}
+	var promotion *MasterPromotion
+	if promotedReplica != nil {
+		promotion = &MasterPromotion{
+			SuccessorKey:  promotedReplica.Key,
+			ClusterDomain: analysisEntry.ClusterDetails.ClusterDomain,
+			// on GracefulMasterTakeoverCommandHint it makes utter sense to RESET SLAVE ALL and read_only=0, and there is no sense in not doing so.
+			// A provider has already made the promoted server a standalone, writable master.
+			ApplyMySQLPromotion: (config.Config.ApplyMySQLPromotionAfterMasterFailover || analysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint) && topologyRecovery.RecoveryType != MasterRecoveryProvider,
+			SkipProcesses:       skipProcesses,
+		}
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepPromoted, promotion)
+	}
+	defer journalRecoveryStep(topologyRecovery, RecoveryJournalStepCompleted, nil)
+	if promotedReplica != nil && topologyRecovery.RecoveryType != MasterRecoveryProvider {
+		if estimate, err := estimateDataLoss(topologyRecovery, promotedReplica); err != nil {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: could not estimate data loss: %+v", err))
+		} else {
+			topologyRecovery.DataLossEstimate = estimate
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: estimated data loss: %s", estimate.String()))
+		}
+	}
 	// And this is the end; whether successful or not, we're done.
 	resolveRecovery(topologyRecovery, promotedReplica)
 	// Now, see whether we are successful or not. From this point there's no going back.
 	if promotedReplica != nil {
 		// Success!
-		recoverDeadMasterSuccessCounter.Inc(1)
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("RecoverDeadMaster: successfully promoted %+v", promotedReplica.Key))
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promoted server coordinates: %+v", promotedReplica.SelfBinlogCoordinates))
 
-		if config.Config.ApplyMySQLPromotionAfterMasterFailover || analysisEntry.CommandHint == inst.GracefulMasterTakeoverCommandHint {
-			// on GracefulMasterTakeoverCommandHint it makes utter sense to RESET SLAVE ALL and read_only=0, and there is no sense in not doing so.
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
-			{
-				_, err := inst.ResetSlaveOperation(&promotedReplica.Key)
-				if err != nil {
-					// Ugly, but this is important. Let's give it another try
-					_, err = inst.ResetSlaveOperation(&promotedReplica.Key)
-				}
-				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying RESET SLAVE ALL on promoted master: success=%t", (err == nil)))
-				if err != nil {
-					AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: NOTE that %+v is promoted even though SHOW SLAVE STATUS may still show it has a master", promotedReplica.Key))
-				}
+		if config.Config.MasterFailoverDetachReplicaMasterHost && topologyRecovery.RecoveryType != MasterRecoveryProvider {
+			postponedFunction := func() error {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: detaching master host on promoted master"))
+				inst.DetachReplicaMasterHost(&promotedReplica.Key)
+				return nil
 			}
-			{
-				_, err := inst.SetReadOnly(&promotedReplica.Key, false)
-				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=0 on promoted master: success=%t", (err == nil)))
+			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadMaster, detaching promoted master host %+v", promotedReplica.Key))
+		}
+		if err := finalizeMasterPromotion(topologyRecovery, promotion, map[string]bool{}); err != nil {
+			recoverDeadMasterFailureCounter.Inc(1)
+			return true, topologyRecovery, err
+		}
+		recoverDeadMasterSuccessCounter.Inc(1)
+	} else {
+		recoverDeadMasterFailureCounter.Inc(1)
+	}
+
+	return true, topologyRecovery, err
+}
+
+// setReadOnlyVerified sets read_only on a promoted or demoted master, retrying per ReadOnlyFlipRetries until
+// re-reading the variable shows the change took effect
+func setReadOnlyVerified(instanceKey *inst.InstanceKey, readOnly bool) (*inst.Instance, error) {
+	return inst.SetReadOnlyVerified(instanceKey, readOnly, config.Config.ReadOnlyFlipRetries, time.Duration(config.Config.ReadOnlyFlipRetryIntervalSeconds)*time.Second)
+}
+
+// failMasterPromotion marks an already resolved master recovery as failed, once its promoted master turns out unusable
+func failMasterPromotion(topologyRecovery *TopologyRecovery, err error) error {
+	topologyRecovery.SuccessorKey = nil
+	topologyRecovery.SuccessorAlias = ""
+	topologyRecovery.SuccessorPromotionRule = ""
+	return markMasterPromotionFailed(topologyRecovery, err)
+}
+
+// markMasterPromotionFailed marks an already resolved master recovery as failed, keeping its successor. The promotion
+// is not rolled back: the promoted master, already writable, is still recorded as the cluster's master.
+func markMasterPromotionFailed(topologyRecovery *TopologyRecovery, err error) error {
+	AuditTopologyRecovery(topologyRecovery, err.Error())
+	topologyRecovery.AddError(err)
+	topologyRecovery.IsSuccessful = false
+	resolveRecovery(topologyRecovery, nil)
+	return err
+}
+
+// runPromotionChecks runs the cluster's PromotionChecks on the promoted master, recording each outcome as a recovery
+// step. It returns an error when a failed check's policy is to abort the promotion.
+func runPromotionChecks(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) error {
+	for _, check := range topologyRecovery.AnalysisEntry.ClusterDetails.PromotionChecks() {
+		holds, result, err := inst.AssertInstanceQuery(promotedKey, check.Query)
+		if err != nil {
+			result = err.Error()
+		}
+		if holds {
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promotion check %s on %+v: passed (%s)", check.Description(), *promotedKey, result))
+			continue
+		}
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promotion check %s on %+v: failed (%s); policy: %s", check.Description(), *promotedKey, result, check.FailurePolicy()))
+		switch check.FailurePolicy() {
+		case config.PromotionCheckAbort:
+			return fmt.Errorf("RecoverDeadMaster: failed promotion. Promotion check %s failed on %+v: %s", check.Description(), *promotedKey, result)
+		case config.PromotionCheckWarn:
+			log.Warningf("Promotion check %s failed on %+v: %s", check.Description(), *promotedKey, result)
+			inst.AuditOperation("promotion-check-failed", promotedKey, fmt.Sprintf("recovery %s: %s: %s", topologyRecovery.UID, check.Description(), result))
+		}
+	}
+	return nil
+}
+
+// verifyGTIDConsistency audits replicas of the promoted master whose gtid_mode or enforce_gtid_consistency differ from
+// the promoted master's. Discrepancies are warnings; the promotion proceeds.
+func verifyGTIDConsistency(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) {
+	replicas, err := inst.ReadReplicaInstances(promotedKey)
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot verify GTID consistency: %+v", err))
+		return
+	}
+	replicaKeys := []inst.InstanceKey{}
+	for _, replica := range replicas {
+		replicaKeys = append(replicaKeys, replica.Key)
+	}
+	discrepancies, errs := inst.GTIDConsistencyDiscrepancies(promotedKey, replicaKeys)
+	for _, err := range errs {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot verify GTID consistency of %+v", err))
+	}
+	for i := range discrepancies {
+		discrepancy := &discrepancies[i]
+		log.Warningf("GTID consistency: %s", discrepancy.String())
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: GTID consistency warning: %s", discrepancy.String()))
+		inst.AuditOperation("gtid-consistency-warning", &discrepancy.Key, fmt.Sprintf("recovery %s: %s=%s, master %+v has %s", topologyRecovery.UID, discrepancy.Variable, discrepancy.Value, *promotedKey, discrepancy.MasterValue))
+	}
+	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: verified GTID consistency of %d replicas: %d discrepancies", len(replicaKeys)-len(errs), len(discrepancies)))
+}
+
+// handleDownstreamHeads repoints, or alerts on, instances which replicate from the failed master via a named
+// replication channel, per DownstreamHeadsPolicy. These are not replicas in the master's cluster, and are not
+// otherwise relocated by the recovery.
+func handleDownstreamHeads(topologyRecovery *TopologyRecovery, promotedKey *inst.InstanceKey) {
+	failedKey := &topologyRecovery.AnalysisEntry.AnalyzedInstanceKey
+	failedMaster, found, err := inst.ReadInstance(failedKey)
+	if err == nil && !found {
+		err = fmt.Errorf("instance not found")
+	}
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot read downstream heads of %+v: %+v", *failedKey, err))
+		return
+	}
+	heads, err := inst.ReadDownstreamHeads(failedMaster)
+	if err != nil {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot read downstream heads of %+v: %+v", *failedKey, err))
+		return
+	}
+	for i := range heads {
+		head := &heads[i]
+		if config.Config.DownstreamHeadsPolicy == config.DownstreamHeadsRepoint {
+			err := inst.RepointReplicationChannel(&head.Key, head.Channel, promotedKey)
+			if err == nil {
+				topologyRecovery.ParticipatingInstanceKeys.AddKey(head.Key)
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: repointed downstream head %+v (cluster %s) channel %s to %+v", head.Key, head.ClusterName, head.Channel, *promotedKey))
+				continue
 			}
-			// Let's attempt, though we won't necessarily succeed, to set old master as read-only
-			go func() {
-				_, err := inst.SetReadOnly(&analysisEntry.AnalyzedInstanceKey, true)
-				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=1 on demoted master: success=%t", (err == nil)))
-			}()
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: cannot repoint downstream head %+v channel %s: %+v", head.Key, head.Channel, err))
 		}
+		log.Warningf("Downstream head %+v (cluster %s) replicates from %+v via channel %s", head.Key, head.ClusterName, *failedKey, head.Channel)
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: downstream head %+v (cluster %s) still replicates from %+v via channel %s", head.Key, head.ClusterName, *failedKey, head.Channel))
+		inst.AuditOperation("downstream-head-lost", &head.Key, fmt.Sprintf("recovery %s: channel %s replicates from %+v, replaced by %+v", topologyRecovery.UID, head.Channel, *failedKey, *promotedKey))
+	}
+}
+
+// finalizeMasterPromotion applies the promotion of a new master onto MySQL, KV stores, cluster aliases and hooks.
+// Steps found in journaledSteps were already completed (by a since crashed orchestrator node) and are skipped;
+// each completed step is journaled.
+// With FailMasterPromotionIfNotWritable, a promoted master that cannot be made writable fails the recovery, and
+// the remaining steps are not applied. So does a failed PromotionCheck whose policy is to abort, though the
+// promotion is not rolled back and the promoted master is kept as the recovery's successor. The PromotionCanary
+// runs last, once KV stores and hooks have repointed the access path; a failed canary whose policy is to abort
+// likewise fails the recovery, though all steps have been applied.
+func finalizeMasterPromotion(topologyRecovery *TopologyRecovery, promotion *MasterPromotion, journaledSteps map[string]bool) error {
+	analysisEntry := &topologyRecovery.AnalysisEntry
+	promotedKey := &promotion.SuccessorKey
 
-		kvPairs := inst.GetClusterMasterKVPairs(analysisEntry.ClusterDetails.ClusterAlias, &promotedReplica.Key)
+	if promotion.ApplyMySQLPromotion && !journaledSteps[RecoveryJournalStepMySQLPromotion] {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
+		{
+			_, err := inst.ResetSlaveOperation(promotedKey)
+			if err != nil {
+				// Ugly, but this is important. Let's give it another try
+				_, err = inst.ResetSlaveOperation(promotedKey)
+			}
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying RESET SLAVE ALL on promoted master: success=%t", (err == nil)))
+			if err != nil {
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: NOTE that %+v is promoted even though SHOW SLAVE STATUS may still show it has a master", *promotedKey))
+			}
+		}
+		// Let's attempt, though we won't necessarily succeed, to set old master as read-only
+		go func() {
+			_, err := setReadOnlyVerified(&analysisEntry.AnalyzedInstanceKey, true)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=1 on demoted master: success=%t", (err == nil)))
+			// A graceful takeover has already applied DemotedMasterKillPolicies
+			if analysisEntry.CommandHint != inst.GracefulMasterTakeoverCommandHint {
+				killFailedOverMasterSessions(topologyRecovery, &analysisEntry.ClusterDetails, &analysisEntry.AnalyzedInstanceKey)
+			}
+			if config.Config.ApplyEventSchedulerAfterMasterFailover {
+				err := inst.SetEventScheduler(&analysisEntry.AnalyzedInstanceKey, false)
+				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying event_scheduler=OFF on demoted master: success=%t", (err == nil)))
+			}
+		}()
+		{
+			_, err := setReadOnlyVerified(promotedKey, false)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying read-only=0 on promoted master: success=%t", (err == nil)))
+			if err != nil && config.Config.FailMasterPromotionIfNotWritable {
+				return failMasterPromotion(topologyRecovery, fmt.Errorf("RecoverDeadMaster: failed promotion. FailMasterPromotionIfNotWritable is set and promoted master %+v could not be made writable: %+v", *promotedKey, err))
+			}
+		}
+		if config.Config.ApplyEventSchedulerAfterMasterFailover {
+			err := inst.SetEventScheduler(promotedKey, true)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: applying event_scheduler=ON on promoted master: success=%t", (err == nil)))
+		}
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepMySQLPromotion, nil)
+	} else if !promotion.ApplyMySQLPromotion && topologyRecovery.RecoveryType == MasterRecoveryProvider {
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: promoted via provider; skipping MySQL changes to promoted master"))
+	}
+
+	if !journaledSteps[RecoveryJournalStepPromotionChecks] {
+		if err := runPromotionChecks(topologyRecovery, promotedKey); err != nil {
+			return markMasterPromotionFailed(topologyRecovery, err)
+		}
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepPromotionChecks, nil)
+	}
+	if config.Config.VerifyGTIDConsistencyAfterMasterFailover {
+		verifyGTIDConsistency(topologyRecovery, promotedKey)
+	}
+	handleDownstreamHeads(topologyRecovery, promotedKey)
+	go checkPromotedMasterBinlogSpace(topologyRecovery, promotedKey)
+
+	if !journaledSteps[RecoveryJournalStepKV] {
+		kvPairs := analysisEntry.ClusterDetails.GetMasterKVPairs(promotedKey)
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Writing KV %+v", kvPairs))
 		if orcraft.IsRaftEnabled() {
 			for _, kvPair := range kvPairs {
@@ -896,38 +1511,38 @@ func checkAndRecoverDeadMaster(analysisEntry inst.ReplicationAnalysis, candidate
 			err := kv.DistributePairs(kvPairs)
 			log.Errore(err)
 		}
-		if config.Config.MasterFailoverDetachReplicaMasterHost {
-			postponedFunction := func() error {
-				AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: detaching master host on promoted master"))
-				inst.DetachReplicaMasterHost(&promotedReplica.Key)
-				return nil
-			}
-			topologyRecovery.AddPostponedFunction(postponedFunction, fmt.Sprintf("RecoverDeadMaster, detaching promoted master host %+v", promotedReplica.Key))
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepKV, nil)
+	}
+	go refreshReadPool(promotedKey)
+
+	if !journaledSteps[RecoveryJournalStepClusterAlias] {
+		before := analysisEntry.AnalyzedInstanceKey.StringCode()
+		after := promotedKey.StringCode()
+		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: updating cluster_alias: %v -> %v", before, after))
+		//~~~inst.ReplaceClusterName(before, after)
+		if alias := analysisEntry.ClusterDetails.ClusterAlias; alias != "" {
+			inst.SetClusterAlias(promotedKey.StringCode(), alias)
+		} else {
+			inst.ReplaceAliasClusterName(before, after)
 		}
-		func() error {
-			before := analysisEntry.AnalyzedInstanceKey.StringCode()
-			after := promotedReplica.Key.StringCode()
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: updating cluster_alias: %v -> %v", before, after))
-			//~~~inst.ReplaceClusterName(before, after)
-			if alias := analysisEntry.ClusterDetails.ClusterAlias; alias != "" {
-				inst.SetClusterAlias(promotedReplica.Key.StringCode(), alias)
-			} else {
-				inst.ReplaceAliasClusterName(before, after)
-			}
-			return nil
-		}()
+		attributes.SetGeneralAttribute(analysisEntry.ClusterDetails.ClusterDomain, promotedKey.StringCode())
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepClusterAlias, nil)
+	}
 
-		attributes.SetGeneralAttribute(analysisEntry.ClusterDetails.ClusterDomain, promotedReplica.Key.StringCode())
+	if !promotion.SkipProcesses && !journaledSteps[RecoveryJournalStepPostProcesses] {
+		// Execute post master-failover processes
+		executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepPostProcesses, nil)
+	}
 
-		if !skipProcesses {
-			// Execute post master-failover processes
-			executeProcesses(config.Config.PostMasterFailoverProcesses, "PostMasterFailoverProcesses", topologyRecovery, false)
+	if !journaledSteps[RecoveryJournalStepPromotionCanary] {
+		// By now KV stores and hooks have had the chance to repoint the production access path to the promoted master
+		if err := runPromotionCanary(topologyRecovery, promotedKey); err != nil {
+			return markMasterPromotionFailed(topologyRecovery, err)
 		}
-	} else {
-		recoverDeadMasterFailureCounter.Inc(1)
+		journalRecoveryStep(topologyRecovery, RecoveryJournalStepPromotionCanary, nil)
 	}
-
-	return true, topologyRecovery, err
+	return nil
 }
 
 // isGeneralyValidAsCandidateSiblingOfIntermediateMaster sees that basic server configuration and state are valid
@@ -1075,7 +1690,7 @@ func RecoverDeadIntermediateMaster(topologyRecovery *TopologyRecovery, skipProce
 
 	inst.AuditOperation("recover-dead-intermediate-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(preFailoverProcesses(topologyRecovery), "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return nil, topologyRecovery.AddError(err)
 		}
 	}
@@ -1213,7 +1828,7 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 	}
 	inst.AuditOperation("recover-dead-co-master", failedInstanceKey, "problem found; will recover")
 	if !skipProcesses {
-		if err := executeProcesses(config.Config.PreFailoverProcesses, "PreFailoverProcesses", topologyRecovery, true); err != nil {
+		if err := executeProcesses(preFailoverProcesses(topologyRecovery), "PreFailoverProcesses", topologyRecovery, true); err != nil {
 			return nil, lostReplicas, topologyRecovery.AddError(err)
 		}
 	}
@@ -1267,11 +1882,11 @@ func RecoverDeadCoMaster(topologyRecovery *TopologyRecovery, skipProcesses bool)
 	}
 	if promotedReplica != nil {
 		if config.Config.DelayMasterPromotionIfSQLThreadNotUpToDate {
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting to ensure the SQL thread catches up on %+v", promotedReplica.Key))
-			if _, err := inst.WaitForSQLThreadUpToDate(&promotedReplica.Key, 0, 0); err != nil {
+			delayedReplica, err := delayPromotionUntilSQLThreadUpToDate(topologyRecovery, promotedReplica, lostReplicas)
+			if err != nil {
 				return promotedReplica, lostReplicas, err
 			}
-			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("SQL thread caught up on %+v", promotedReplica.Key))
+			promotedReplica = delayedReplica
 		}
 		topologyRecovery.ParticipatingInstanceKeys.AddKey(promotedReplica.Key)
 	}
@@ -1327,6 +1942,15 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 	if !(forceInstanceRecovery || analysisEntry.ClusterDetails.HasAutomatedMasterRecovery) {
 		return false, nil, nil
 	}
+	if refuseStaleMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
+	if refuseFlappingMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
+	if refuseUnconfirmedMasterRecovery(&analysisEntry, forceInstanceRecovery) {
+		return false, nil, nil
+	}
 	topologyRecovery, err := AttemptRecoveryRegistration(&analysisEntry, !forceInstanceRecovery, !forceInstanceRecovery)
 	if topologyRecovery == nil {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("found an active or recent recovery on %+v. Will not issue another RecoverDeadCoMaster.", analysisEntry.AnalyzedInstanceKey))
@@ -1334,6 +1958,8 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 	}
 
 	// That's it! We must do recovery!
+	atomic.AddInt64(&countActiveMasterRecoveries, 1)
+	defer atomic.AddInt64(&countActiveMasterRecoveries, -1)
 	recoverDeadCoMasterCounter.Inc(1)
 	promotedReplica, lostReplicas, err := RecoverDeadCoMaster(topologyRecovery, skipProcesses)
 	resolveRecovery(topologyRecovery, promotedReplica)
@@ -1352,7 +1978,11 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 
 		if config.Config.ApplyMySQLPromotionAfterMasterFailover {
 			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadMaster: will apply MySQL changes to promoted master"))
-			inst.SetReadOnly(&promotedReplica.Key, false)
+			_, err := setReadOnlyVerified(&promotedReplica.Key, false)
+			AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("- RecoverDeadCoMaster: applying read-only=0 on promoted master: success=%t", (err == nil)))
+			if err != nil && config.Config.FailMasterPromotionIfNotWritable {
+				return true, topologyRecovery, failMasterPromotion(topologyRecovery, fmt.Errorf("RecoverDeadCoMaster: failed promotion. FailMasterPromotionIfNotWritable is set and promoted master %+v could not be made writable: %+v", promotedReplica.Key, err))
+			}
 		}
 		if !skipProcesses {
 			// Execute post intermediate-master-failover processes
@@ -1367,8 +1997,26 @@ func checkAndRecoverDeadCoMaster(analysisEntry inst.ReplicationAnalysis, candida
 }
 
 // checkAndRecoverGenericProblem is a general-purpose recovery function
+// checkAndRecoverGenericProblem handles problems that have no recovery of their own by running their
+// AnalysisHookSets, if any. Hooks run at most once per RecoveryPeriodBlockSeconds per analysis and instance,
+// unless forced. No topology recovery is registered, and so recoveries on the cluster are never blocked.
 func checkAndRecoverGenericProblem(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
-	return false, nil, nil
+	processes := analysisEntry.ClusterDetails.AnalysisHookProcesses(string(analysisEntry.Analysis))
+	if len(processes) == 0 || skipProcesses {
+		return false, nil, nil
+	}
+	runKey := fmt.Sprintf("%s/%s", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey.StringCode())
+	blockPeriod := time.Duration(config.Config.RecoveryPeriodBlockSeconds) * time.Second
+	if forceInstanceRecovery {
+		analysisHooksRunMap.Set(runKey, true, blockPeriod)
+	} else if err := analysisHooksRunMap.Add(runKey, true, blockPeriod); err != nil {
+		// Hooks recently run
+		return false, nil, nil
+	}
+	topologyRecovery := NewTopologyRecovery(analysisEntry)
+	inst.AuditOperation("analysis-hooks", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("running %d hooks on %+v; uid: %s", len(processes), analysisEntry.Analysis, topologyRecovery.UID))
+	err := executeProcesses(processes, "AnalysisHookSets", topologyRecovery, false)
+	return false, nil, err
 }
 
 // Force a re-read of a topology instance; this is done because we need to substantiate a suspicion
@@ -1378,6 +2026,9 @@ func emergentlyReadTopologyInstance(instanceKey *inst.InstanceKey, analysisCode
 		// Just recently attempted
 		return
 	}
+	if isNoTouchLocked(instanceKey) {
+		return
+	}
 	go inst.ExecuteOnTopology(func() {
 		inst.ReadTopologyInstance(instanceKey)
 		inst.AuditOperation("emergently-read-topology-instance", instanceKey, string(analysisCode))
@@ -1402,12 +2053,22 @@ func emergentlyRestartReplicationOnTopologyInstance(instanceKey *inst.InstanceKe
 		// Just recently attempted on this specific replica
 		return
 	}
+	if isNoTouchLocked(instanceKey) {
+		return
+	}
 	go inst.ExecuteOnTopology(func() {
 		inst.RestartIOThread(instanceKey)
 		inst.AuditOperation("emergently-restart-replication-topology-instance", instanceKey, string(analysisCode))
 	})
 }
 
+// isNoTouchLocked checks whether given instance is, or may be, no-touch locked; emergent operations are
+// skipped on such instances
+func isNoTouchLocked(instanceKey *inst.InstanceKey) bool {
+	lock, err := inst.ReadNoTouchLock(instanceKey)
+	return err != nil || lock != nil
+}
+
 func beginEmergencyOperationGracefulPeriod(instanceKey *inst.InstanceKey) {
 	emergencyOperationGracefulPeriodMap.Set(instanceKey.StringCode(), true, cache.DefaultExpiration)
 }
@@ -1453,11 +2114,16 @@ func checkAndExecuteFailureDetectionProcesses(analysisEntry inst.ReplicationAnal
 	if skipProcesses {
 		return true, false, nil
 	}
+	if reason := silenceDetectionReason(&analysisEntry); reason != "" {
+		inst.AuditOperation("silence-detection-notification", &analysisEntry.AnalyzedInstanceKey, reason)
+		return true, false, nil
+	}
+	pagerDutyTriggerDetection(&analysisEntry)
 	err = executeProcesses(config.Config.OnFailureDetectionProcesses, "OnFailureDetectionProcesses", NewTopologyRecovery(analysisEntry), true)
 	return true, true, err
 }
 
-func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstanceKey *inst.InstanceKey) (
+func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstanceKey *inst.InstanceKey, clusterInfo *inst.ClusterInfo) (
 	checkAndRecoverFunction func(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (recoveryAttempted bool, topologyRecovery *TopologyRecovery, err error),
 	isActionableRecovery bool,
 ) {
@@ -1469,6 +2135,11 @@ func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstance
 		} else {
 			return checkAndRecoverDeadMaster, true
 		}
+	case inst.DeadMasterWithoutSlaves:
+		// Aurora readers do not replicate via binlogs, and are invisible to orchestrator. The provider knows of them.
+		if clusterInfo.IsRDSFailoverCluster && !isInEmergencyOperationGracefulPeriod(analyzedInstanceKey) {
+			return checkAndRecoverDeadMaster, true
+		}
 	// intermediate master
 	case inst.DeadIntermediateMaster:
 		return checkAndRecoverDeadIntermediateMaster, true
@@ -1496,12 +2167,37 @@ func getCheckAndRecoverFunction(analysisCode inst.AnalysisCode, analyzedInstance
 		return checkAndRecoverGenericProblem, false
 	case inst.AllMasterSlavesNotReplicatingOrDead:
 		return checkAndRecoverGenericProblem, false
+	case inst.MasterFlapping:
+		return checkAndRecoverGenericProblem, false
+	case inst.LockedSemiSyncMaster:
+		if remedy := clusterInfo.SemiSyncRemedy(); remedy != nil {
+			if remedy.Action == config.SemiSyncRemedyFailover && !isInEmergencyOperationGracefulPeriod(analyzedInstanceKey) {
+				return checkAndRecoverDeadMaster, true
+			}
+			return checkAndRecoverLockedSemiSyncMaster, false
+		}
+	case inst.TooManyConnectionsMaster, inst.ReadOnlyFilesystemMaster, inst.DiskFullMaster:
+		if clusterInfo.UnusableMasterPolicy(string(analysisCode)) != nil && !isInEmergencyOperationGracefulPeriod(analyzedInstanceKey) {
+			return checkAndRecoverUnusableMaster, true
+		}
+	// replica, opt-in remediation
+	case inst.ReplicationStopped, inst.ErrantGTIDReplica:
+		if clusterInfo.ReplicaRemedy() != nil {
+			return checkAndRemedyReplica, false
+		}
 	}
 	// Right now this is mostly causing noise with no clear action.
 	// Will revisit this in the future.
 	// case inst.AllMasterSlavesStale:
 	//   return checkAndRecoverGenericProblem, false
 
+	if inst.IsCustomAnalysisCode(analysisCode) {
+		// operator defined analysis: hooks only
+		return checkAndRecoverGenericProblem, false
+	}
+	if len(clusterInfo.AnalysisHookProcesses(string(analysisCode))) > 0 {
+		return checkAndRecoverGenericProblem, false
+	}
 	return nil, false
 }
 
@@ -1529,7 +2225,8 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	atomic.AddInt64(&countPendingRecoveries, 1)
 	defer atomic.AddInt64(&countPendingRecoveries, -1)
 
-	checkAndRecoverFunction, isActionableRecovery := getCheckAndRecoverFunction(analysisEntry.Analysis, &analysisEntry.AnalyzedInstanceKey)
+	applyHealthProbes(&analysisEntry, forceInstanceRecovery)
+	checkAndRecoverFunction, isActionableRecovery := getCheckAndRecoverFunction(analysisEntry.Analysis, &analysisEntry.AnalyzedInstanceKey, &analysisEntry.ClusterDetails)
 	analysisEntry.IsActionableRecovery = isActionableRecovery
 	runEmergentOperations(&analysisEntry)
 
@@ -1562,6 +2259,35 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 		}
 	}
 
+	if !forceInstanceRecovery && isShadowEvaluated(&analysisEntry) {
+		// Canary deployment, and this is not a canary cluster: only record what we would have done
+		return false, nil, recordShadowDecision(&analysisEntry)
+	}
+	if isActionableRecovery {
+		if err := inst.CheckNoTouch(&analysisEntry.AnalyzedInstanceKey); err != nil {
+			// A recovery replaces the failed instance, and so must not run while it is locked
+			if forceInstanceRecovery || util.ClearToLog("executeCheckAndRecoverFunction: no-touch", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+				log.Warningf("executeCheckAndRecoverFunction: refusing %+v recovery on %+v: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, err)
+				inst.AuditOperation("refuse-no-touch-recovery", &analysisEntry.AnalyzedInstanceKey, err.Error())
+			}
+			if forceInstanceRecovery {
+				return false, nil, err
+			}
+			return false, nil, nil
+		}
+		if err := federation.MayRecoverCluster(analysisEntry.ClusterDetails.ClusterName, analysisEntry.ClusterDetails.ClusterAlias); err != nil {
+			// Another federation member is responsible for this cluster
+			if forceInstanceRecovery || util.ClearToLog("executeCheckAndRecoverFunction: federation", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+				log.Warningf("executeCheckAndRecoverFunction: refusing %+v recovery on %+v: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, err)
+				inst.AuditOperation("refuse-federated-recovery", &analysisEntry.AnalyzedInstanceKey, err.Error())
+			}
+			if forceInstanceRecovery {
+				return false, nil, err
+			}
+			return false, nil, nil
+		}
+	}
+
 	// Initiate detection:
 	registrationSuccess, _, err := checkAndExecuteFailureDetectionProcesses(analysisEntry, skipProcesses)
 	if registrationSuccess {
@@ -1595,11 +2321,40 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 			"skipProcesses: %v: recoveries disabled globally but forcing this recovery",
 			analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses)
 	}
+	// Check for the cluster being in maintenance mode
+	if analysisEntry.IsClusterInMaintenance {
+		if !forceInstanceRecovery {
+			log.Infof("CheckAndRecover: Analysis: %+v, InstanceKey: %+v, candidateInstanceKey: %+v, "+
+				"skipProcesses: %v: NOT Recovering host (cluster %s in maintenance)",
+				analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses, analysisEntry.ClusterDetails.ClusterAlias)
+			return false, nil, err
+		}
+		log.Infof("CheckAndRecover: Analysis: %+v, InstanceKey: %+v, candidateInstanceKey: %+v, "+
+			"skipProcesses: %v: cluster %s in maintenance but forcing this recovery",
+			analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, candidateInstanceKey, skipProcesses, analysisEntry.ClusterDetails.ClusterAlias)
+	}
 
 	// Actually attempt recovery:
 	if isActionableRecovery || util.ClearToLog("executeCheckAndRecoverFunction: recovery", analysisEntry.AnalyzedInstanceKey.StringCode()) {
 		log.Infof("executeCheckAndRecoverFunction: proceeding with %+v recovery on %+v; isRecoverable?: %+v; skipProcesses: %+v", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, isActionableRecovery, skipProcesses)
 	}
+	if isActionableRecovery {
+		// Concurrent recoveries are capped; excess recoveries wait here, masters first.
+		// Repeated analyses of an instance already queued or being recovered do not queue again.
+		wait, acquired := recoveryQueue.acquire(&analysisEntry, forceInstanceRecovery)
+		if !acquired {
+			return false, nil, nil
+		}
+		if wait > 0 {
+			log.Infof("executeCheckAndRecoverFunction: %+v recovery on %+v waited %+v for a recovery slot", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, wait)
+			if !isAnalysisStillValid(&analysisEntry) {
+				recoveryQueue.release(&analysisEntry)
+				inst.AuditOperation("recovery-dequeued-obsolete", &analysisEntry.AnalyzedInstanceKey, fmt.Sprintf("%+v no longer holds after waiting %+v for a recovery slot", analysisEntry.Analysis, wait))
+				return false, nil, nil
+			}
+		}
+		defer recoveryQueue.release(&analysisEntry)
+	}
 	recoveryAttempted, topologyRecovery, err = checkAndRecoverFunction(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
 	if !recoveryAttempted {
 		return recoveryAttempted, topologyRecovery, err
@@ -1619,7 +2374,7 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 		} else {
 			// Execute general post failover processes
 			inst.EndDowntime(topologyRecovery.SuccessorKey)
-			executeProcesses(config.Config.PostFailoverProcesses, "PostFailoverProcesses", topologyRecovery, false)
+			executeProcesses(postFailoverProcesses(topologyRecovery), "PostFailoverProcesses", topologyRecovery, false)
 		}
 	}
 	AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Waiting for %d postponed functions", topologyRecovery.PostponedFunctionsContainer.Len()))
@@ -1628,6 +2383,14 @@ func executeCheckAndRecoverFunction(analysisEntry inst.ReplicationAnalysis, cand
 	if topologyRecovery.PostponedFunctionsContainer.Len() > 0 {
 		AuditTopologyRecovery(topologyRecovery, fmt.Sprintf("Executed postponed functions: %+v", strings.Join(topologyRecovery.PostponedFunctionsContainer.Descriptions(), ", ")))
 	}
+	if !skipProcesses {
+		// Lost replicas are detached by now
+		requestReplicaProvisioning(topologyRecovery)
+	}
+	snapshotRecoveryTopologyAfter(topologyRecovery)
+	if !skipProcesses {
+		emailRecoverySummary(topologyRecovery)
+	}
 	return recoveryAttempted, topologyRecovery, err
 }
 
@@ -1642,9 +2405,17 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 		log.Infof("--noop provided; will not execute processes")
 		skipProcesses = true
 	}
-	// intentionally iterating entries in random order
-	for _, j := range rand.Perm(len(replicationAnalysis)) {
+	bulkRecoveredKeys := make(map[inst.InstanceKey]bool)
+	if specificInstance == nil {
+		// Many masters dead in one data center are recovered in bulk, not independently
+		bulkRecoveredKeys = checkAndRecoverDatacenterFailures(replicationAnalysis, skipProcesses)
+	}
+	// intentionally iterating entries in random order within each cluster priority tier
+	for _, j := range prioritizedAnalysisOrder(replicationAnalysis) {
 		analysisEntry := replicationAnalysis[j]
+		if bulkRecoveredKeys[analysisEntry.AnalyzedInstanceKey] {
+			continue
+		}
 		if specificInstance != nil {
 			// We are looking for a specific instance; if this is not the one, skip!
 			if !specificInstance.Equals(&analysisEntry.AnalyzedInstanceKey) {
@@ -1655,6 +2426,10 @@ func CheckAndRecover(specificInstance *inst.InstanceKey, candidateInstanceKey *i
 			// Only recover a downtimed server if explicitly requested
 			continue
 		}
+		if analysisEntry.ActivitySuppressionReason != "" && specificInstance == nil {
+			// Explained by a running backup or DDL; neither detection hooks nor recovery apply
+			continue
+		}
 
 		if specificInstance != nil {
 			// force mode. Keep it synchronuous
@@ -1773,13 +2548,51 @@ func ForceMasterTakeover(clusterName string, destination *inst.Instance) (topolo
 	return topologyRecovery, nil
 }
 
+// relocateDesignatedInstanceBelowMaster relocates a designated instance, which is a descendant of the master
+// yet not its direct replica, to replicate directly from the master, as a preliminary step to graceful takeover.
+func relocateDesignatedInstanceBelowMaster(clusterMaster *inst.Instance, designatedKey *inst.InstanceKey) (*inst.Instance, error) {
+	designatedInstance, found, err := inst.ReadInstance(designatedKey)
+	if err != nil {
+		return nil, log.Errore(err)
+	}
+	if !found {
+		return nil, fmt.Errorf("GracefulMasterTakeover: indicated designated instance %+v not found", *designatedKey)
+	}
+	if designatedInstance.ClusterName != clusterMaster.ClusterName || !designatedInstance.IsReplica() {
+		return nil, fmt.Errorf("GracefulMasterTakeover: indicated designated instance %+v must be a replica in the cluster of master %+v", *designatedKey, clusterMaster.Key)
+	}
+	if err := inst.CheckNoTouch(designatedKey); err != nil {
+		inst.AuditOperation("refuse-no-touch-recovery", designatedKey, err.Error())
+		return nil, fmt.Errorf("GracefulMasterTakeover: %+v", err)
+	}
+	if !designatedInstance.UsingGTID() && !designatedInstance.UsingPseudoGTID {
+		return nil, fmt.Errorf("GracefulMasterTakeover: indicated designated instance %+v is not a direct replica of the master %+v, and can only be relocated below it via GTID or Pseudo-GTID, neither of which it uses", *designatedKey, clusterMaster.Key)
+	}
+	log.Infof("GracefulMasterTakeover: designated instance %+v replicates from %+v; will relocate it below master %+v", *designatedKey, designatedInstance.MasterKey, clusterMaster.Key)
+	inst.AuditOperation("graceful-master-takeover-relocate", designatedKey, fmt.Sprintf("relocating below master %+v, from %+v", clusterMaster.Key, designatedInstance.MasterKey))
+	if designatedInstance, err = inst.RelocateBelow(designatedKey, &clusterMaster.Key); err != nil {
+		return nil, fmt.Errorf("GracefulMasterTakeover: cannot relocate designated instance %+v below master %+v: %+v", *designatedKey, clusterMaster.Key, err)
+	}
+	if !designatedInstance.MasterKey.Equals(&clusterMaster.Key) {
+		return nil, fmt.Errorf("GracefulMasterTakeover: relocated designated instance %+v, yet it replicates from %+v rather than master %+v", *designatedKey, designatedInstance.MasterKey, clusterMaster.Key)
+	}
+	return designatedInstance, nil
+}
+
 // GracefulMasterTakeover will demote master of existing topology and promote its
 // direct replica instead.
-// It expects that replica to have no siblings.
+// It expects that replica to have no siblings, unless designated. A designated deeper descendant is
+// first relocated to be a direct replica of the master.
 // This function is graceful in that it will first lock down the master, then wait
 // for the designated replica to catch up with last position.
 // It will point old master at the newly promoted master at the correct coordinates, but will not start replication.
 func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
+	return GracefulMasterTakeoverWithProgress(clusterName, designatedKey, nil)
+}
+
+// GracefulMasterTakeoverWithProgress is GracefulMasterTakeover, reporting the designated replica's progress
+// catching up with the demoted master to onProgress, if given.
+func GracefulMasterTakeoverWithProgress(clusterName string, designatedKey *inst.InstanceKey, onProgress func(*inst.ReplicationCatchupProgress)) (topologyRecovery *TopologyRecovery, promotedMasterCoordinates *inst.BinlogCoordinates, err error) {
 	clusterMasters, err := inst.ReadClusterMaster(clusterName)
 	if err != nil {
 		return nil, nil, fmt.Errorf("Cannot deduce cluster master for %+v; error: %+v", clusterName, err)
@@ -1789,6 +2602,16 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 	}
 	clusterMaster := clusterMasters[0]
 
+	clusterInfo, err := inst.ReadClusterInfo(clusterName)
+	if err != nil {
+		return nil, nil, log.Errore(err)
+	}
+	if err := federation.MayRecoverCluster(clusterName, clusterInfo.ClusterAlias); err != nil {
+		// Another federation member is responsible for this cluster
+		inst.AuditOperation("refuse-federated-recovery", &clusterMaster.Key, err.Error())
+		return nil, nil, fmt.Errorf("GracefulMasterTakeover: %+v", err)
+	}
+
 	clusterMasterDirectReplicas, err := inst.ReadReplicaInstances(&clusterMaster.Key)
 	if err != nil {
 		return nil, nil, log.Errore(err)
@@ -1818,11 +2641,23 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 			}
 		}
 		if designatedInstance == nil {
-			return nil, nil, fmt.Errorf("GracefulMasterTakeover: indicated designated instance %+v must be directly replicating from the master %+v", *designatedKey, clusterMaster.Key)
+			// A deeper descendant is first relocated to be a direct replica of the master
+			if designatedInstance, err = relocateDesignatedInstanceBelowMaster(clusterMaster, designatedKey); err != nil {
+				return nil, nil, err
+			}
+			if clusterMasterDirectReplicas, err = inst.ReadReplicaInstances(&clusterMaster.Key); err != nil {
+				return nil, nil, log.Errore(err)
+			}
 		}
 		log.Infof("GracefulMasterTakeover: designated master instructed to be %+v", designatedInstance.Key)
 	}
 
+	for _, instanceKey := range []*inst.InstanceKey{&clusterMaster.Key, &designatedInstance.Key} {
+		if err := inst.CheckNoTouch(instanceKey); err != nil {
+			inst.AuditOperation("refuse-no-touch-recovery", instanceKey, err.Error())
+			return nil, nil, fmt.Errorf("GracefulMasterTakeover: %+v", err)
+		}
+	}
 	if inst.IsBannedFromBeingCandidateReplica(designatedInstance) {
 		return nil, nil, fmt.Errorf("GracefulMasterTakeover: designated instance %+v cannot be promoted due to promotion rule or it is explicitly ignored in PromotionIgnoreHostnameFilters configuration", designatedInstance.Key)
 	}
@@ -1879,15 +2714,48 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		return nil, nil, fmt.Errorf("Failed running PreGracefulTakeoverProcesses: %+v", err)
 	}
 
+	drained, err := drainMaster(&analysisEntry.ClusterDetails, &clusterMaster.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if drained {
+		drainedKey := clusterMaster.Key
+		defer func() {
+			if topologyRecovery == nil || topologyRecovery.SuccessorKey == nil {
+				// Takeover fails; the master keeps serving
+				undrainMaster(&analysisEntry.ClusterDetails, &drainedKey)
+			}
+		}()
+	}
+	if _, err := killDemotedMasterSessions(nil, &analysisEntry.ClusterDetails, &clusterMaster.Key, true); err != nil {
+		log.Errorf("GracefulMasterTakeover: noting an error killing sessions on %+v, and proceeding: %+v", clusterMaster.Key, err)
+	}
 	log.Infof("GracefulMasterTakeover: Will set %+v as read_only", clusterMaster.Key)
-	if clusterMaster, err = inst.SetReadOnly(&clusterMaster.Key, true); err != nil {
+	if clusterMaster, err = setReadOnlyVerified(&clusterMaster.Key, true); err != nil {
 		return nil, nil, err
 	}
+	if _, err := killDemotedMasterSessions(nil, &analysisEntry.ClusterDetails, &clusterMaster.Key, false); err != nil {
+		log.Errorf("GracefulMasterTakeover: noting an error killing sessions on %+v, and proceeding: %+v", clusterMaster.Key, err)
+	}
 	demotedMasterSelfBinlogCoordinates := &clusterMaster.SelfBinlogCoordinates
 	log.Infof("GracefulMasterTakeover: Will wait for %+v to reach master coordinates %+v", designatedInstance.Key, *demotedMasterSelfBinlogCoordinates)
-	if designatedInstance, _, err = inst.WaitForExecBinlogCoordinatesToReach(&designatedInstance.Key, demotedMasterSelfBinlogCoordinates, time.Duration(config.Config.ReasonableMaintenanceReplicationLagSeconds)*time.Second); err != nil {
+	targetGtidSet := ""
+	if designatedInstance.UsingOracleGTID {
+		targetGtidSet = clusterMaster.ExecutedGtidSet
+	}
+	reportProgress := func(progress *inst.ReplicationCatchupProgress) {
+		registerGracefulTakeoverProgress(clusterName, progress)
+		log.Infof("GracefulMasterTakeover: %s", progress.String())
+		inst.AuditOperation("graceful-master-takeover-progress", &progress.InstanceKey, progress.String())
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	defer clearGracefulTakeoverProgress(clusterName)
+	if designatedInstance, _, err = inst.WaitForExecBinlogCoordinatesToReachWithProgress(&designatedInstance.Key, demotedMasterSelfBinlogCoordinates, targetGtidSet, time.Duration(config.Config.ReasonableMaintenanceReplicationLagSeconds)*time.Second, reportProgress); err != nil {
 		return nil, nil, err
 	}
+	clearGracefulTakeoverProgress(clusterName)
 	promotedMasterCoordinates = &designatedInstance.SelfBinlogCoordinates
 
 	log.Infof("GracefulMasterTakeover: attempting recovery")
@@ -1908,7 +2776,7 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		return nil, nil, fmt.Errorf("GracefulMasterTakeover: Recovery attempted yet no replica promoted; err=%+v", err)
 	}
 	var gtidHint inst.OperationGTIDHint = inst.GTIDHintNeutral
-	if topologyRecovery.RecoveryType == MasterRecoveryGTID {
+	if topologyRecovery.RecoveryType == MasterRecoveryGTID || topologyRecovery.RecoveryType == MasterRecoveryGTIDAndBLS {
 		gtidHint = inst.GTIDHintForce
 	}
 	clusterMaster, err = inst.ChangeMasterTo(&clusterMaster.Key, &designatedInstance.Key, promotedMasterCoordinates, false, gtidHint)
@@ -1922,8 +2790,9 @@ func GracefulMasterTakeover(clusterName string, designatedKey *inst.InstanceKey)
 		}
 	}
 
+	// Preserve the designated instance's TLS settings. Clusters requiring TLS replication get it enforced by ChangeMasterTo.
 	if designatedInstance.AllowTLS {
-		_, enableSSLErr := inst.EnableMasterSSL(&clusterMaster.Key)
+		_, enableSSLErr := inst.EnableReplicationTLS(&clusterMaster.Key, designatedInstance.TLSVerifyServerCert)
 		if err == nil {
 			err = enableSSLErr
 		}
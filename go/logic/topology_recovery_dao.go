@@ -17,6 +17,7 @@
 package logic
 
 import (
+	"encoding/json"
 	"fmt"
 	"strings"
 
@@ -43,6 +44,7 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 		analysisEntry.CountReplicas,
 		analysisEntry.SlaveHosts.ToCommaDelimitedList(),
 		analysisEntry.IsActionableRecovery,
+		healthProbeResultsJSON(analysisEntry.HealthProbeResults),
 	)
 	startActivePeriodHint := "now()"
 	if analysisEntry.StartActivePeriod != "" {
@@ -65,6 +67,7 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 					count_affected_slaves,
 					slave_hosts,
 					is_actionable,
+					health_probe_results,
 					start_active_period
 				) values (
 					?,
@@ -79,11 +82,12 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 					?,
 					?,
 					?,
+					?,
 					%s
 				)
 			`, startActivePeriodHint)
 
-	sqlResult, err := db.ExecOrchestrator(query, args...)
+	sqlResult, err := db.ExecOrchestratorRecovery(query, args...)
 	if err != nil {
 		return false, log.Errore(err)
 	}
@@ -94,6 +98,18 @@ func AttemptFailureDetectionRegistration(analysisEntry *inst.ReplicationAnalysis
 	return (rows > 0), nil
 }
 
+// healthProbeResultsJSON serializes probe results for the failure detection audit; empty when there are none
+func healthProbeResultsJSON(results []inst.HealthProbeResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	b, err := json.Marshal(results)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // ClearActiveFailureDetections clears the "in_active_period" flag for old-enough detections, thereby allowing for
 // further detections on cleared instances.
 func ClearActiveFailureDetections() error {
@@ -139,7 +155,7 @@ func acknowledgeInstanceFailureDetection(instanceKey *inst.InstanceKey) error {
 
 func writeTopologyRecovery(topologyRecovery *TopologyRecovery) (*TopologyRecovery, error) {
 	analysisEntry := topologyRecovery.AnalysisEntry
-	sqlResult, err := db.ExecOrchestrator(`
+	sqlResult, err := db.ExecOrchestratorRecovery(`
 			insert ignore
 				into topology_recovery (
 					recovery_id,
@@ -242,6 +258,11 @@ func AttemptRecoveryRegistration(analysisEntry *inst.ReplicationAnalysis, failIf
 	if err != nil {
 		return nil, log.Errore(err)
 	}
+	if topologyRecovery != nil {
+		topologyRecovery.SetPostponedFunctionListener(topologyRecovery)
+		snapshotRecoveryTopology(topologyRecovery, analysisEntry.ClusterDetails.ClusterName, RecoveryTopologyPhaseBefore)
+		pagerDutyTriggerRecovery(topologyRecovery)
+	}
 	if orcraft.IsRaftEnabled() {
 		if _, err := orcraft.PublishCommand("write-recovery", topologyRecovery); err != nil {
 			return nil, log.Errore(err)
@@ -270,7 +291,7 @@ func ClearActiveRecoveries() error {
 // Recoveries are blocked thru the in_active_period flag, which comes to avoid flapping.
 func RegisterBlockedRecoveries(analysisEntry *inst.ReplicationAnalysis, blockingRecoveries []TopologyRecovery) error {
 	for _, recovery := range blockingRecoveries {
-		_, err := db.ExecOrchestrator(`
+		_, err := db.ExecOrchestratorRecovery(`
 			insert
 				into blocked_topology_recovery (
 					hostname,
@@ -381,11 +402,17 @@ func acknowledgeRecoveries(owner string, comment string, markEndRecovery bool, w
 				and
 				%s
 		`, additionalSet, whereClause)
+	// On raft, acknowledgements apply on all members; only the leader notifies
+	var acknowledgedRecoveries []TopologyRecovery
+	if isPagerDutyEnabled() && (!orcraft.IsRaftEnabled() || orcraft.IsLeader()) {
+		acknowledgedRecoveries, _ = readRecoveries(fmt.Sprintf("where acknowledged = 0 and %s", whereClause), ``, args)
+	}
 	args = append(sqlutils.Args(owner, comment), args...)
 	sqlResult, err := db.ExecOrchestrator(query, args...)
 	if err != nil {
 		return 0, log.Errore(err)
 	}
+	pagerDutyAcknowledgeRecoveries(acknowledgedRecoveries)
 	rows, err := sqlResult.RowsAffected()
 	return rows, log.Errore(err)
 }
@@ -438,6 +465,63 @@ func AcknowledgeClusterRecoveries(clusterName string, owner string, comment stri
 	return countAcknowledgedEntries, nil
 }
 
+// AcknowledgeAnalysisRecoveries marks active recoveries of given analysis as acknowledged, optionally limited
+// to a cluster and to recoveries started within a time range (timestamps are inclusive, formatted 'YYYY-MM-DD hh:mm:ss').
+// This also implied clearing their active period, which in turn enables further recoveries on those topologies
+func AcknowledgeAnalysisRecoveries(analysis string, clusterName string, fromTimestamp string, toTimestamp string, owner string, comment string) (countAcknowledgedEntries int64, err error) {
+	filter := &inst.ListingFilter{FromTimestamp: fromTimestamp, ToTimestamp: toTimestamp, ClusterName: clusterName}
+	conditions, args := filter.Conditions("", "start_active_period", "cluster_name")
+	conditions = append(conditions, `analysis = ?`)
+	args = append(args, analysis)
+	whereClause := strings.Join(conditions, " and ")
+
+	clearAcknowledgedFailureDetections(whereClause, args)
+	return acknowledgeRecoveries(owner, comment, false, whereClause, args)
+}
+
+// AutoAcknowledgeRecoveries acknowledges completed recoveries matching an auto-acknowledge policy of their cluster.
+// The policy's own conditions (age, success, analysis) are applied in SQL; cluster filters are applied per recovery.
+func AutoAcknowledgeRecoveries() (countAcknowledgedEntries int64, err error) {
+	for i := range config.Config.AutoAcknowledgePolicies {
+		policy := &config.Config.AutoAcknowledgePolicies[i]
+		conditions := []string{
+			`acknowledged = 0`,
+			`end_recovery is not null`,
+			`end_recovery <= NOW() - INTERVAL ? MINUTE`,
+		}
+		args := sqlutils.Args(policy.AfterMinutes)
+		if policy.SuccessfulOnly {
+			conditions = append(conditions, `is_successful = 1`)
+		}
+		recoveries, err := readRecoveries(inst.WhereClause(conditions), ``, args)
+		if err != nil {
+			return countAcknowledgedEntries, log.Errore(err)
+		}
+		for j := range recoveries {
+			recovery := &recoveries[j]
+			if !policy.AppliesToAnalysis(string(recovery.AnalysisEntry.Analysis)) {
+				continue
+			}
+			if !recovery.AnalysisEntry.ClusterDetails.AutoAckPolicyApplies(policy) {
+				continue
+			}
+			ack := NewRecoveryAcknowledgement("orchestrator", fmt.Sprintf("auto-acknowledged by policy #%d", i))
+			ack.Id = recovery.Id
+			if orcraft.IsRaftEnabled() {
+				_, err = orcraft.PublishCommand("ack-recovery", ack)
+			} else {
+				_, err = AcknowledgeRecovery(ack.Id, ack.Owner, ack.Comment)
+			}
+			if err != nil {
+				return countAcknowledgedEntries, err
+			}
+			log.Infof("auto-acknowledged recovery %d of %s by policy #%d", recovery.Id, recovery.AnalysisEntry.ClusterDetails.ClusterName, i)
+			countAcknowledgedEntries++
+		}
+	}
+	return countAcknowledgedEntries, nil
+}
+
 // AcknowledgeInstanceRecoveries marks active recoveries for given instane as acknowledged.
 // This also implied clearing their active period, which in turn enables further recoveries on those topologies
 func AcknowledgeInstanceRecoveries(instanceKey *inst.InstanceKey, owner string, comment string) (countAcknowledgedEntries int64, err error) {
@@ -477,32 +561,51 @@ func AcknowledgeCrashedRecoveries() (countAcknowledgedEntries int64, err error)
 // It does not clear the "active period" as this still takes place in order to avoid flapping.
 func writeResolveRecovery(topologyRecovery *TopologyRecovery) error {
 	var successorKeyToWrite inst.InstanceKey
-	if topologyRecovery.IsSuccessful {
+	if topologyRecovery.SuccessorKey != nil {
+		// A failed recovery may still have a successor, when its promotion was applied yet failed verification
 		successorKeyToWrite = *topologyRecovery.SuccessorKey
 	}
-	_, err := db.ExecOrchestrator(`
+	dataLossEstimate := ""
+	if topologyRecovery.DataLossEstimate != nil {
+		if estimateJSON, err := json.Marshal(topologyRecovery.DataLossEstimate); err == nil {
+			dataLossEstimate = string(estimateJSON)
+		}
+	}
+	_, err := db.ExecOrchestratorRecovery(`
 			update topology_recovery set
 				is_successful = ?,
 				successor_hostname = ?,
 				successor_port = ?,
 				successor_alias = ?,
+				successor_promotion_rule = ?,
 				lost_slaves = ?,
 				participating_instances = ?,
 				all_errors = ?,
+				fencing_method = ?,
+				fencing_status = ?,
+				data_loss_estimate = ?,
 				end_recovery = NOW()
 			where
 				uid = ?
 			`, topologyRecovery.IsSuccessful, successorKeyToWrite.Hostname, successorKeyToWrite.Port,
-		topologyRecovery.SuccessorAlias, topologyRecovery.LostReplicas.ToCommaDelimitedList(),
+		topologyRecovery.SuccessorAlias, string(topologyRecovery.SuccessorPromotionRule),
+		topologyRecovery.LostReplicas.ToCommaDelimitedList(),
 		topologyRecovery.ParticipatingInstanceKeys.ToCommaDelimitedList(),
 		strings.Join(topologyRecovery.AllErrors, "\n"),
+		topologyRecovery.FencingMethod, topologyRecovery.FencingStatus,
+		dataLossEstimate,
 		topologyRecovery.UID,
 	)
 	return log.Errore(err)
 }
 
-// readRecoveries reads recovery entry/audit entries from topology_recovery
+// readRecoveries reads recovery entry/audit entries from topology_recovery, newest first
 func readRecoveries(whereCondition string, limit string, args []interface{}) ([]TopologyRecovery, error) {
+	return readOrderedRecoveries(whereCondition, fmt.Sprintf(`order by recovery_id desc %s`, limit), args)
+}
+
+// readOrderedRecoveries reads recovery entry/audit entries from topology_recovery, by given order & limit clause
+func readOrderedRecoveries(whereCondition string, orderLimit string, args []interface{}) ([]TopologyRecovery, error) {
 	res := []TopologyRecovery{}
 	query := fmt.Sprintf(`
 		select
@@ -520,6 +623,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
       ifnull(successor_hostname, '') as successor_hostname,
       ifnull(successor_port, 0) as successor_port,
       ifnull(successor_alias, '') as successor_alias,
+      successor_promotion_rule,
       analysis,
       cluster_name,
       cluster_alias,
@@ -532,14 +636,15 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
       acknowledged_at,
       acknowledged_by,
       acknowledge_comment,
-      last_detection_id
+      last_detection_id,
+      fencing_method,
+      fencing_status,
+      data_loss_estimate
 		from
 			topology_recovery
 		%s
-		order by
-			recovery_id desc
 		%s
-		`, whereCondition, limit)
+		`, whereCondition, orderLimit)
 	err := db.QueryOrchestrator(query, args, func(m sqlutils.RowMap) error {
 		topologyRecovery := *NewTopologyRecovery(inst.ReplicationAnalysis{})
 		topologyRecovery.Id = m.GetInt64("recovery_id")
@@ -564,6 +669,7 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 		topologyRecovery.SuccessorKey.Hostname = m.GetString("successor_hostname")
 		topologyRecovery.SuccessorKey.Port = m.GetInt("successor_port")
 		topologyRecovery.SuccessorAlias = m.GetString("successor_alias")
+		topologyRecovery.SuccessorPromotionRule = inst.CandidatePromotionRule(m.GetString("successor_promotion_rule"))
 
 		topologyRecovery.AnalysisEntry.ClusterDetails.ReadRecoveryInfo()
 
@@ -577,6 +683,15 @@ func readRecoveries(whereCondition string, limit string, args []interface{}) ([]
 		topologyRecovery.AcknowledgedComment = m.GetString("acknowledge_comment")
 
 		topologyRecovery.LastDetectionId = m.GetInt64("last_detection_id")
+		topologyRecovery.FencingMethod = m.GetString("fencing_method")
+		topologyRecovery.FencingStatus = m.GetString("fencing_status")
+		if dataLossEstimate := m.GetString("data_loss_estimate"); dataLossEstimate != "" {
+			topologyRecovery.DataLossEstimate = &DataLossEstimate{}
+			if err := json.Unmarshal([]byte(dataLossEstimate), topologyRecovery.DataLossEstimate); err != nil {
+				log.Errorf("readRecoveries: cannot parse data loss estimate of recovery %s: %+v", topologyRecovery.UID, err)
+				topologyRecovery.DataLossEstimate = nil
+			}
+		}
 
 		res = append(res, topologyRecovery)
 		return nil
@@ -694,6 +809,21 @@ func ReadRecentRecoveries(clusterName string, unacknowledgedOnly bool, page int)
 	return readRecoveries(whereClause, limit, args)
 }
 
+// ReadRecoveriesByFilter reads recoveries by given listing filter, optionally only those of given analysis codes
+func ReadRecoveriesByFilter(filter *inst.ListingFilter, analysisCodes []string, unacknowledgedOnly bool) ([]TopologyRecovery, error) {
+	conditions, args := filter.Conditions("recovery_id", "start_active_period", "cluster_name")
+	if len(analysisCodes) > 0 {
+		conditions = append(conditions, fmt.Sprintf(`analysis in (?%s)`, strings.Repeat(", ?", len(analysisCodes)-1)))
+		for _, analysisCode := range analysisCodes {
+			args = append(args, analysisCode)
+		}
+	}
+	if unacknowledgedOnly {
+		conditions = append(conditions, `acknowledged=0`)
+	}
+	return readOrderedRecoveries(inst.WhereClause(conditions), filter.OrderLimit("recovery_id"), args)
+}
+
 // readRecoveries reads recovery entry/audit entries from topology_recovery
 func readFailureDetections(whereCondition string, limit string, args []interface{}) ([]TopologyRecovery, error) {
 	res := []TopologyRecovery{}
@@ -712,6 +842,7 @@ func readFailureDetections(whereCondition string, limit string, args []interface
       cluster_alias,
       count_affected_slaves,
       slave_hosts,
+      health_probe_results,
       (select max(recovery_id) from topology_recovery where topology_recovery.last_detection_id = detection_id) as related_recovery_id
 		from
 			topology_failure_detection
@@ -737,6 +868,12 @@ func readFailureDetections(whereCondition string, limit string, args []interface
 		failureDetection.AnalysisEntry.CountReplicas = m.GetUint("count_affected_slaves")
 		failureDetection.AnalysisEntry.ReadReplicaHostsFromString(m.GetString("slave_hosts"))
 		failureDetection.AnalysisEntry.StartActivePeriod = m.GetString("start_active_period")
+		if healthProbeResults := m.GetString("health_probe_results"); healthProbeResults != "" {
+			if err := json.Unmarshal([]byte(healthProbeResults), &failureDetection.AnalysisEntry.HealthProbeResults); err != nil {
+				log.Errorf("readFailureDetections: cannot parse health probe results of detection %d: %+v", failureDetection.Id, err)
+				failureDetection.AnalysisEntry.HealthProbeResults = nil
+			}
+		}
 
 		failureDetection.RelatedRecoveryId = m.GetInt64("related_recovery_id")
 
@@ -810,7 +947,7 @@ func ReadBlockedRecoveries(clusterName string) ([]BlockedTopologyRecovery, error
 
 // writeTopologyRecoveryStep writes down a single step in a recovery process
 func writeTopologyRecoveryStep(topologyRecoveryStep *TopologyRecoveryStep) error {
-	sqlResult, err := db.ExecOrchestrator(`
+	sqlResult, err := db.ExecOrchestratorRecovery(`
 			insert ignore
 				into topology_recovery_steps (
 					recovery_step_id, recovery_uid, audit_at, message
@@ -849,18 +986,3 @@ func ReadTopologyRecoverySteps(recoveryUID string) ([]TopologyRecoveryStep, erro
 	})
 	return res, log.Errore(err)
 }
-
-// ExpireFailureDetectionHistory removes old rows from the topology_failure_detection table
-func ExpireFailureDetectionHistory() error {
-	return inst.ExpireTableData("topology_failure_detection", "start_active_period")
-}
-
-// ExpireTopologyRecoveryHistory removes old rows from the topology_failure_detection table
-func ExpireTopologyRecoveryHistory() error {
-	return inst.ExpireTableData("topology_recovery", "start_active_period")
-}
-
-// ExpireTopologyRecoveryStepsHistory removes old rows from the topology_failure_detection table
-func ExpireTopologyRecoveryStepsHistory() error {
-	return inst.ExpireTableData("topology_recovery_steps", "audit_at")
-}
@@ -0,0 +1,111 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"testing"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/openark/golib/log"
+)
+
+func init() {
+	config.Config.HostnameResolveMethod = "none"
+	config.MarkConfigurationLoaded()
+	log.SetLevel(log.ERROR)
+}
+
+// withSQLiteBackend runs given function against an in-memory SQLite backend
+func withSQLiteBackend(t *testing.T, f func()) {
+	backendDB, dataFile := config.Config.BackendDB, config.Config.SQLite3DataFile
+	defer func() {
+		config.Config.BackendDB, config.Config.SQLite3DataFile = backendDB, dataFile
+	}()
+	config.Config.BackendDB = "sqlite"
+	config.Config.SQLite3DataFile = ":memory:"
+	if _, err := db.OpenOrchestrator(); err != nil {
+		t.Fatal(err)
+	}
+	f()
+}
+
+func TestCheckMasterRecoveryDataFreshness(t *testing.T) {
+	maxStaleness, maxApplyLag := config.Config.MasterRecoveryMaxDataStalenessSeconds, config.Config.MasterRecoveryMaxRaftApplyLag
+	defer func() {
+		config.Config.MasterRecoveryMaxDataStalenessSeconds, config.Config.MasterRecoveryMaxRaftApplyLag = maxStaleness, maxApplyLag
+	}()
+
+	tests := []struct {
+		name                    string
+		maxStaleness            uint
+		maxApplyLag             uint64
+		secondsSinceLastChecked int64
+		raftApplyLag            uint64
+		expectFresh             bool
+	}{
+		{name: "no limits", secondsSinceLastChecked: 3600, raftApplyLag: 1000, expectFresh: true},
+		{name: "staleness below limit", maxStaleness: 30, secondsSinceLastChecked: 29, expectFresh: true},
+		{name: "staleness at limit", maxStaleness: 30, secondsSinceLastChecked: 30, expectFresh: true},
+		{name: "staleness above limit", maxStaleness: 30, secondsSinceLastChecked: 31, expectFresh: false},
+		{name: "apply lag below limit", maxApplyLag: 10, raftApplyLag: 9, expectFresh: true},
+		{name: "apply lag at limit", maxApplyLag: 10, raftApplyLag: 10, expectFresh: true},
+		{name: "apply lag above limit", maxApplyLag: 10, raftApplyLag: 11, expectFresh: false},
+		{name: "apply lag ignored without limit", maxStaleness: 30, raftApplyLag: 1000, expectFresh: true},
+		{name: "both within limits", maxStaleness: 30, maxApplyLag: 10, secondsSinceLastChecked: 30, raftApplyLag: 10, expectFresh: true},
+		{name: "fresh data, lagging raft", maxStaleness: 30, maxApplyLag: 10, secondsSinceLastChecked: 1, raftApplyLag: 11, expectFresh: false},
+	}
+	for _, tt := range tests {
+		config.Config.MasterRecoveryMaxDataStalenessSeconds = tt.maxStaleness
+		config.Config.MasterRecoveryMaxRaftApplyLag = tt.maxApplyLag
+		analysisEntry := &inst.ReplicationAnalysis{SecondsSinceLastChecked: tt.secondsSinceLastChecked}
+		err := checkMasterRecoveryDataFreshness(analysisEntry, tt.raftApplyLag)
+		if (err == nil) != tt.expectFresh {
+			t.Errorf("%s: expected fresh=%t, got error %+v", tt.name, tt.expectFresh, err)
+		}
+	}
+}
+
+func TestRefuseStaleMasterRecovery(t *testing.T) {
+	maxStaleness := config.Config.MasterRecoveryMaxDataStalenessSeconds
+	defer func() { config.Config.MasterRecoveryMaxDataStalenessSeconds = maxStaleness }()
+	config.Config.MasterRecoveryMaxDataStalenessSeconds = 30
+
+	withSQLiteBackend(t, func() {
+		tests := []struct {
+			name                    string
+			secondsSinceLastChecked int64
+			forceInstanceRecovery   bool
+			expectRefused           bool
+		}{
+			{name: "fresh", secondsSinceLastChecked: 5, expectRefused: false},
+			{name: "at limit", secondsSinceLastChecked: 30, expectRefused: false},
+			{name: "stale", secondsSinceLastChecked: 31, expectRefused: true},
+			{name: "stale, forced", secondsSinceLastChecked: 31, forceInstanceRecovery: true, expectRefused: false},
+		}
+		for _, tt := range tests {
+			analysisEntry := &inst.ReplicationAnalysis{
+				Analysis:                inst.DeadMaster,
+				SecondsSinceLastChecked: tt.secondsSinceLastChecked,
+			}
+			if refused := refuseStaleMasterRecovery(analysisEntry, tt.forceInstanceRecovery); refused != tt.expectRefused {
+				t.Errorf("%s: expected refused=%t, got %t", tt.name, tt.expectRefused, refused)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package logic
+
+import (
+	"fmt"
+
+	"github.com/github/orchestrator/go/agent"
+	"github.com/github/orchestrator/go/config"
+	"github.com/github/orchestrator/go/db"
+	"github.com/github/orchestrator/go/inst"
+	"github.com/github/orchestrator/go/util"
+
+	"github.com/openark/golib/log"
+)
+
+// probeMasterConnections connects to a master over a dedicated, unpooled connection. A master refusing the
+// connection for lack of connection slots, or found at max_connections, has its connections exhausted;
+// returned details then describe the condition.
+func probeMasterConnections(masterKey *inst.InstanceKey) (details string, err error) {
+	sqlDB, err := db.OpenTopologyProbe(masterKey.Hostname, masterKey.Port)
+	if err != nil {
+		return "", err
+	}
+	defer sqlDB.Close()
+
+	var maxConnections, threadsConnected uint
+	var variableName string
+	if err := sqlDB.QueryRow("select @@global.max_connections").Scan(&maxConnections); err != nil {
+		if inst.UnusableMasterCondition(err) == inst.UnusableMasterTooManyConnections {
+			return err.Error(), nil
+		}
+		return "", err
+	}
+	// A user with CONNECTION_ADMIN (or SUPER) may still connect via the extra, reserved connection
+	if err := sqlDB.QueryRow("show global status like 'Threads_connected'").Scan(&variableName, &threadsConnected); err != nil {
+		return "", err
+	}
+	if threadsConnected >= maxConnections {
+		return fmt.Sprintf("%d threads connected, max_connections is %d", threadsConnected, maxConnections), nil
+	}
+	return "", nil
+}
+
+// probeMasterDiskFree asks a master's orchestrator-agent for free space on the MySQL datadir. A master short
+// of UnusableMasterMinDiskFreeMB is out of disk; returned details then describe the condition.
+func probeMasterDiskFree(masterKey *inst.InstanceKey) (details string, err error) {
+	diskFree, err := agent.MySQLDatadirDiskFree(masterKey.Hostname)
+	if err != nil {
+		return "", err
+	}
+	if diskFree < int64(config.Config.UnusableMasterMinDiskFreeMB)*1024*1024 {
+		return fmt.Sprintf("%d bytes free on MySQL datadir", diskFree), nil
+	}
+	return "", nil
+}
+
+// probeUnusableMaster probes a single master, recording any condition found
+func probeUnusableMaster(masterKey *inst.InstanceKey, hasAgent bool) {
+	if details, err := probeMasterConnections(masterKey); err != nil {
+		if util.ClearToLog("probeUnusableMaster: connections", masterKey.StringCode()) {
+			log.Debugf("probeUnusableMaster: %+v: %+v", *masterKey, err)
+		}
+	} else if details != "" {
+		inst.RecordUnusableMasterSignal(masterKey, inst.UnusableMasterTooManyConnections, inst.UnusableMasterSourceProbe, details)
+	}
+	if !hasAgent {
+		return
+	}
+	if details, err := probeMasterDiskFree(masterKey); err != nil {
+		if util.ClearToLog("probeUnusableMaster: agent", masterKey.StringCode()) {
+			log.Debugf("probeUnusableMaster: %+v: agent: %+v", *masterKey, err)
+		}
+	} else if details != "" {
+		inst.RecordUnusableMasterSignal(masterKey, inst.UnusableMasterDiskFull, inst.UnusableMasterSourceAgent, details)
+	}
+}
+
+// ProbeUnusableMasters probes all writeable masters for conditions leaving them reachable but unusable:
+// exhausted connections, and, where orchestrator-agent runs, a full disk. Failed heartbeat writes are
+// another source of such signals.
+func ProbeUnusableMasters() error {
+	if !config.Config.DetectUnusableMasters {
+		return nil
+	}
+	masters, err := inst.ReadWriteableClustersMasters()
+	if err != nil {
+		return log.Errore(err)
+	}
+	agentHostnames := readAgentHostnames()
+	for _, master := range masters {
+		go probeUnusableMaster(&master.Key, agentHostnames[master.Key.Hostname])
+	}
+	return nil
+}
+
+// checkAndRecoverUnusableMaster fails over a master found reachable but unusable, as configured by the
+// cluster's unusable master policy, once the condition persists for the policy's minimum duration.
+// Until then, analysis hook sets run as for any analysis without a recovery. The master being alive, the
+// failover fences it before promotion, and aborts if it cannot.
+func checkAndRecoverUnusableMaster(analysisEntry inst.ReplicationAnalysis, candidateInstanceKey *inst.InstanceKey, forceInstanceRecovery bool, skipProcesses bool) (bool, *TopologyRecovery, error) {
+	policy := analysisEntry.ClusterDetails.UnusableMasterPolicy(string(analysisEntry.Analysis))
+	if policy == nil || analysisEntry.UnusableMasterSignal == nil {
+		return checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+	}
+	if !forceInstanceRecovery && analysisEntry.UnusableMasterSignal.SecondsPersisted < policy.MinDuration() {
+		if util.ClearToLog("checkAndRecoverUnusableMaster", analysisEntry.AnalyzedInstanceKey.StringCode()) {
+			log.Infof("checkAndRecoverUnusableMaster: %+v on %+v persists for %d seconds; failing over after %d seconds", analysisEntry.Analysis, analysisEntry.AnalyzedInstanceKey, analysisEntry.UnusableMasterSignal.SecondsPersisted, policy.MinDuration())
+		}
+		_, _, err := checkAndRecoverGenericProblem(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+		return false, nil, err
+	}
+	return checkAndRecoverDeadMaster(analysisEntry, candidateInstanceKey, forceInstanceRecovery, skipProcesses)
+}
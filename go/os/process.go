@@ -35,13 +35,20 @@ var EmptyEnv = []string{}
 // command to a temporary file and then ask the shell to execute
 // it, after which the temporary file is removed.
 func CommandRun(commandText string, env []string, arguments ...string) error {
+	_, err := CommandRunWithOutput(commandText, env, arguments...)
+	return err
+}
+
+// CommandRunWithOutput executes some text as a command, like CommandRun, and additionally
+// returns the combined stdout/stderr output of the command, whether it succeeded or failed.
+func CommandRunWithOutput(commandText string, env []string, arguments ...string) (string, error) {
 	// show the actual command we have been asked to run
 	log.Infof("CommandRun(%v,%+v)", commandText, arguments)
 
 	cmd, shellScript, err := generateShellScript(commandText, env, arguments...)
 	defer os.Remove(shellScript)
 	if err != nil {
-		return log.Errore(err)
+		return "", log.Errore(err)
 	}
 
 	var waitStatus syscall.WaitStatus
@@ -56,14 +63,14 @@ func CommandRun(commandText string, env []string, arguments ...string) error {
 			log.Errorf("CommandRun: failed. exit status %d", waitStatus.ExitStatus())
 		}
 
-		return log.Errore(fmt.Errorf("(%s) %s", err.Error(), cmdOutput))
+		return string(cmdOutput), log.Errore(fmt.Errorf("(%s) %s", err.Error(), cmdOutput))
 	}
 
 	// Command was successful
 	waitStatus = cmd.ProcessState.Sys().(syscall.WaitStatus)
 	log.Infof("CommandRun successful. exit status %d", waitStatus.ExitStatus())
 
-	return nil
+	return string(cmdOutput), nil
 }
 
 // generateShellScript generates a temporary shell script based on
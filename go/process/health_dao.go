@@ -24,6 +24,7 @@ import (
 	"github.com/github/orchestrator/go/db"
 	"github.com/openark/golib/log"
 	"github.com/openark/golib/sqlutils"
+	"net"
 )
 
 // RegisterNode writes down this node in the node_health table
@@ -79,9 +80,12 @@ func WriteRegisterNode(nodeHealth *NodeHealth) (healthy bool, err error) {
 		dbBackend := ""
 		if config.Config.IsSQLite() {
 			dbBackend = config.Config.SQLite3DataFile
+		} else if config.Config.IsPostgreSQL() {
+			dbBackend = net.JoinHostPort(config.Config.PostgreSQLOrchestratorHost,
+				fmt.Sprintf("%d", config.Config.PostgreSQLOrchestratorPort))
 		} else {
-			dbBackend = fmt.Sprintf("%s:%d", config.Config.MySQLOrchestratorHost,
-				config.Config.MySQLOrchestratorPort)
+			dbBackend = net.JoinHostPort(config.Config.MySQLOrchestratorHost,
+				fmt.Sprintf("%d", config.Config.MySQLOrchestratorPort))
 		}
 		sqlResult, err := db.ExecOrchestrator(`
 			insert ignore into node_health
@@ -0,0 +1,129 @@
+/*
+   Copyright 2017 GitHub Inc.
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+       http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package proxysql
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/github/orchestrator/go/config"
+	"github.com/openark/golib/sqlutils"
+)
+
+// Backend server statuses
+const (
+	ServerStatusOnline      = "ONLINE"
+	ServerStatusOfflineSoft = "OFFLINE_SOFT"
+)
+
+// Server is a backend server of a ProxySQL hostgroup
+type Server struct {
+	Hostname string
+	Port     int
+	Weight   uint
+}
+
+// IsEnabled tells whether a ProxySQL admin interface is configured
+func IsEnabled() bool {
+	return config.Config.ProxySQLAdminAddress != ""
+}
+
+// openAdmin returns a connection to the ProxySQL admin interface. The admin interface does not support
+// prepared statements, hence parameters are interpolated client side.
+func openAdmin() (*sql.DB, error) {
+	uri := fmt.Sprintf("%s:%s@tcp(%s)/?timeout=%ds&readTimeout=%ds&interpolateParams=true",
+		config.Config.ProxySQLAdminUser,
+		config.Config.ProxySQLAdminPassword,
+		config.Config.ProxySQLAdminAddress,
+		config.Config.MySQLConnectTimeoutSeconds,
+		config.Config.MySQLTopologyReadTimeoutSeconds,
+	)
+	db, _, err := sqlutils.GetDB(uri)
+	return db, err
+}
+
+// serverAddress identifies a backend server within a hostgroup
+type serverAddress struct {
+	hostname string
+	port     int
+}
+
+// ReplaceHostgroupServers replaces the servers of a hostgroup with given servers, then loads the change to
+// runtime and saves it to disk. Servers are added or updated before others are removed, so the hostgroup is
+// never empty; on error, the in-memory configuration is reverted to runtime, so that no partial change is
+// left to be loaded later.
+func ReplaceHostgroupServers(hostgroup uint, servers []Server) error {
+	db, err := openAdmin()
+	if err != nil {
+		return err
+	}
+	removedServers := make(map[serverAddress]bool)
+	err = sqlutils.QueryRowsMap(db, "select hostname, port from mysql_servers where hostgroup_id = ?", func(m sqlutils.RowMap) error {
+		removedServers[serverAddress{hostname: m.GetString("hostname"), port: m.GetInt("port")}] = true
+		return nil
+	}, hostgroup)
+	if err != nil {
+		return err
+	}
+	revert := func(err error) error {
+		if _, revertErr := db.Exec("load mysql servers from runtime"); revertErr != nil {
+			return fmt.Errorf("%+v; and failed reverting mysql_servers: %+v", err, revertErr)
+		}
+		return err
+	}
+	for _, server := range servers {
+		address := serverAddress{hostname: server.Hostname, port: server.Port}
+		if removedServers[address] {
+			delete(removedServers, address)
+			_, err = db.Exec("update mysql_servers set weight = ? where hostgroup_id = ? and hostname = ? and port = ?", server.Weight, hostgroup, server.Hostname, server.Port)
+		} else {
+			_, err = db.Exec("insert into mysql_servers (hostgroup_id, hostname, port, weight) values (?, ?, ?, ?)", hostgroup, server.Hostname, server.Port, server.Weight)
+		}
+		if err != nil {
+			return revert(err)
+		}
+	}
+	for address := range removedServers {
+		if _, err := db.Exec("delete from mysql_servers where hostgroup_id = ? and hostname = ? and port = ?", hostgroup, address.hostname, address.port); err != nil {
+			return revert(err)
+		}
+	}
+	if _, err := db.Exec("load mysql servers to runtime"); err != nil {
+		return revert(err)
+	}
+	_, err = db.Exec("save mysql servers to disk")
+	return err
+}
+
+// SetServerStatus sets the status of a backend server, in all hostgroups, then loads the change to runtime.
+// OFFLINE_SOFT drains a server: ProxySQL routes no new traffic to it, and lets existing connections complete.
+// The change is not saved to disk.
+func SetServerStatus(hostname string, port int, status string) error {
+	db, err := openAdmin()
+	if err != nil {
+		return err
+	}
+	result, err := db.Exec("update mysql_servers set status = ? where hostname = ? and port = ?", status, hostname, port)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, err := result.RowsAffected(); err == nil && rowsAffected == 0 {
+		return fmt.Errorf("ProxySQL has no server %s:%d", hostname, port)
+	}
+	_, err = db.Exec("load mysql servers to runtime")
+	return err
+}
@@ -53,6 +53,7 @@ var ThisHostname string
 var healthRequestAuthenticationTokenCache = cache.New(config.RaftHealthPollSeconds*2*time.Second, time.Second)
 var healthReportsCache = cache.New(config.RaftHealthPollSeconds*2*time.Second, time.Second)
 var healthRequestReportCache = cache.New(time.Second, time.Second)
+var peerHostAddressesCache = cache.New(time.Minute, time.Minute)
 
 var fatalRaftErrorChan = make(chan error)
 
@@ -99,15 +100,20 @@ func computeLeaderURI() (uri string, err error) {
 		return config.Config.HTTPAdvertise, nil
 	}
 	// Not explicitly given. Let's heuristically compute using RaftAdvertise
+	return computeMemberURI(config.Config.RaftAdvertise)
+}
+
+// computeMemberURI heuristically computes the HTTP URI of a raft member, assuming it listens
+// on same scheme and port as this node
+func computeMemberURI(hostname string) (uri string, err error) {
 	scheme := "http"
 	if config.Config.UseSSL {
 		scheme = "https"
 	}
 
-	hostname := config.Config.RaftAdvertise
 	listenTokens := strings.Split(config.Config.ListenAddress, ":")
 	if len(listenTokens) < 2 {
-		return uri, fmt.Errorf("computeLeaderURI: cannot determine listen port out of config.Config.ListenAddress: %+v", config.Config.ListenAddress)
+		return uri, fmt.Errorf("computeMemberURI: cannot determine listen port out of config.Config.ListenAddress: %+v", config.Config.ListenAddress)
 	}
 	port := listenTokens[1]
 
@@ -194,18 +200,22 @@ func normalizeRaftHostnameIP(host string) (string, error) {
 }
 
 // normalizeRaftNode attempts to make sure there's a port to the given node.
-// It consults the DefaultRaftPort when there isn't
+// It consults the DefaultRaftPort when there isn't. IPv6 nodes with a port are bracketed, as in [::1]:10008
 func normalizeRaftNode(node string) (string, error) {
-	hostPort := strings.Split(node, ":")
-	host, err := normalizeRaftHostnameIP(hostPort[0])
+	hostname, port, err := net.SplitHostPort(node)
+	if err != nil {
+		// No port specified
+		hostname, port = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]"), ""
+	}
+	host, err := normalizeRaftHostnameIP(hostname)
 	if err != nil {
 		return host, err
 	}
-	if len(hostPort) > 1 {
-		return fmt.Sprintf("%s:%s", host, hostPort[1]), nil
+	if port != "" {
+		return net.JoinHostPort(host, port), nil
 	} else if config.Config.DefaultRaftPort != 0 {
 		// No port specified, add one
-		return fmt.Sprintf("%s:%d", host, config.Config.DefaultRaftPort), nil
+		return net.JoinHostPort(host, fmt.Sprintf("%d", config.Config.DefaultRaftPort)), nil
 	} else {
 		return host, nil
 	}
@@ -257,6 +267,35 @@ func IsHealthy() bool {
 	return state == raft.Leader || state == raft.Follower
 }
 
+// ApplyLag returns the number of raft log entries known to this node, yet to be applied to its FSM.
+// A high number suggests this node's view of the backend is behind the quorum's.
+func ApplyLag() uint64 {
+	if !isRaftSetupComplete() {
+		return 0
+	}
+	lastIndex := getRaft().LastIndex()
+	appliedIndex := getRaft().AppliedIndex()
+	if appliedIndex >= lastIndex {
+		return 0
+	}
+	return lastIndex - appliedIndex
+}
+
+// LeaderContactAge returns the time since this node last heard from the leader. It is zero on the leader.
+func LeaderContactAge() (time.Duration, error) {
+	if !isRaftSetupComplete() {
+		return 0, fmt.Errorf("raft setup incomplete")
+	}
+	if IsLeader() {
+		return 0, nil
+	}
+	lastContact := getRaft().LastContact()
+	if lastContact.IsZero() {
+		return 0, fmt.Errorf("no contact with leader")
+	}
+	return time.Since(lastContact), nil
+}
+
 func Snapshot() error {
 	future := getRaft().Snapshot()
 	return future.Error()
@@ -295,6 +334,49 @@ func IsPeer(peer string) (bool, error) {
 	return (store.raftBind == peer), nil
 }
 
+// resolvePeerHostAddresses returns all addresses given raft peer host resolves to, or the host itself
+// when it is an IP address or cannot be resolved. Resolutions are cached briefly.
+func resolvePeerHostAddresses(host string) []string {
+	if net.ParseIP(host) != nil {
+		return []string{host}
+	}
+	if addresses, found := peerHostAddressesCache.Get(host); found {
+		return addresses.([]string)
+	}
+	addresses, err := net.LookupHost(host)
+	if err != nil {
+		log.Errore(err)
+		return []string{host}
+	}
+	peerHostAddressesCache.Set(host, addresses, cache.DefaultExpiration)
+	return addresses
+}
+
+// IsPeerAddress checks whether given IP address is that of a raft peer. Peers given by hostname
+// are resolved to all their addresses.
+func IsPeerAddress(address string) bool {
+	peers, err := GetPeers()
+	if err != nil {
+		return false
+	}
+	addressIP := net.ParseIP(address)
+	for _, peer := range peers {
+		host, _, err := net.SplitHostPort(peer)
+		if err != nil {
+			host = peer
+		}
+		for _, peerAddress := range resolvePeerHostAddresses(host) {
+			if peerAddress == address {
+				return true
+			}
+			if addressIP != nil && addressIP.Equal(net.ParseIP(peerAddress)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // PublishCommand will distribute a command across the group
 func PublishCommand(op string, value interface{}) (response interface{}, err error) {
 	if !IsRaftEnabled() {
@@ -347,6 +429,26 @@ func HealthyMembers() (advertised []string) {
 	return advertised
 }
 
+// HealthyPeerURIs returns the HTTP URIs of raft-healthy members other than this node.
+// Only meaningful on the leader, which is where health reports are collected.
+func HealthyPeerURIs() (uris []string, err error) {
+	for _, raftAdvertised := range HealthyMembers() {
+		if raftAdvertised == config.Config.RaftAdvertise {
+			continue
+		}
+		hostname := raftAdvertised
+		if host, _, err := net.SplitHostPort(raftAdvertised); err == nil {
+			hostname = host
+		}
+		uri, err := computeMemberURI(hostname)
+		if err != nil {
+			return uris, err
+		}
+		uris = append(uris, uri)
+	}
+	return uris, nil
+}
+
 // Monitor is a utility function to routinely observe leadership state.
 // It doesn't actually do much; merely takes notes.
 func Monitor() {
@@ -72,6 +72,12 @@ func NewTLSConfig(caFile string, verifyCert bool) (*tls.Config, error) {
 // Verify that the OU of the presented client certificate matches the list
 // of Valid OUs
 func Verify(r *nethttp.Request, validOUs []string) error {
+	return VerifyClient(r, validOUs, nil)
+}
+
+// VerifyClient verifies that either the OU or any of the SANs of the presented
+// client certificate matches the list of valid OUs or valid SANs, respectively
+func VerifyClient(r *nethttp.Request, validOUs []string, validSANs []string) error {
 	if strings.Contains(r.URL.String(), config.Config.StatusEndpoint) && !config.Config.StatusOUVerify {
 		return nil
 	}
@@ -88,21 +94,72 @@ func Verify(r *nethttp.Request, validOUs []string) error {
 				return nil
 			}
 		}
+		for _, san := range CertificateSANs(chain[0]) {
+			log.Debug("Client presented SAN:", san)
+			if HasString(san, validSANs) {
+				log.Debug("Found valid SAN:", san)
+				return nil
+			}
+		}
 	}
-	log.Error("No valid OUs found")
+	log.Error("No valid OUs or SANs found")
 	return errors.New("Invalid OU")
 }
 
+// GetClientCertificate returns the verified certificate presented by the client, or nil if there is none
+func GetClientCertificate(r *nethttp.Request) *x509.Certificate {
+	if r.TLS == nil {
+		return nil
+	}
+	for _, chain := range r.TLS.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[0]
+		}
+	}
+	return nil
+}
+
+// CertificateSANs returns the subject alternative names (DNS names, email addresses,
+// IP addresses and URIs) of the given certificate
+func CertificateSANs(cert *x509.Certificate) (sans []string) {
+	sans = append(sans, cert.DNSNames...)
+	sans = append(sans, cert.EmailAddresses...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
+
 // TODO: make this testable?
 func VerifyOUs(validOUs []string) martini.Handler {
+	return VerifyOUsOrSANs(validOUs, nil)
+}
+
+// VerifyOUsOrSANs is a martini middleware rejecting requests whose client certificate
+// has neither a valid OU nor a valid SAN
+func VerifyOUsOrSANs(validOUs []string, validSANs []string) martini.Handler {
 	return func(res nethttp.ResponseWriter, req *nethttp.Request, c martini.Context) {
-		log.Debug("Verifying client OU")
-		if err := Verify(req, validOUs); err != nil {
+		log.Debug("Verifying client OU/SAN")
+		if err := VerifyClient(req, validOUs, validSANs); err != nil {
 			nethttp.Error(res, err.Error(), nethttp.StatusUnauthorized)
 		}
 	}
 }
 
+// NewClientTLSConfig returns a TLS configuration by which this node can present its own
+// certificate to other nodes, trusting the same CA as it does for its clients.
+func NewClientTLSConfig(serverTLSConfig *tls.Config) *tls.Config {
+	return &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		Certificates:       serverTLSConfig.Certificates,
+		RootCAs:            serverTLSConfig.ClientCAs,
+		InsecureSkipVerify: serverTLSConfig.InsecureSkipVerify,
+	}
+}
+
 // AppendKeyPair loads the given TLS key pair and appends it to
 // tlsConfig.Certificates.
 func AppendKeyPair(tlsConfig *tls.Config, certFile string, keyFile string) error {
@@ -116,6 +116,39 @@ func TestVerify(t *testing.T) {
 	}
 }
 
+func TestVerifyClientSAN(t *testing.T) {
+	req, err := nethttp.NewRequest("GET", "http://example.com/foo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemBlock, _ := pem.Decode([]byte(pemCertificate))
+	cert, err := x509.ParseCertificate(pemBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert.Subject.OrganizationalUnit = []string{"testing"}
+	cert.DNSNames = []string{"automation.example.com"}
+	cert.EmailAddresses = []string{"dba@example.com"}
+
+	req.TLS = &tls.ConnectionState{}
+	req.TLS.PeerCertificates = []*x509.Certificate{cert}
+	req.TLS.VerifiedChains = [][]*x509.Certificate{req.TLS.PeerCertificates}
+
+	sans := ssl.CertificateSANs(cert)
+	if !reflect.DeepEqual(sans, []string{"automation.example.com", "dba@example.com"}) {
+		t.Errorf("Unexpected SANs: %+v", sans)
+	}
+	if ssl.GetClientCertificate(req) != cert {
+		t.Errorf("Did not get client certificate")
+	}
+	if err := ssl.VerifyClient(req, []string{"other"}, []string{"dba@example.com"}); err != nil {
+		t.Errorf("Failed to verify certificate SAN")
+	}
+	if err := ssl.VerifyClient(req, []string{"other"}, []string{"other.example.com"}); err == nil {
+		t.Errorf("Verified certificate without valid OU or SAN")
+	}
+}
+
 func TestReadPEMData(t *testing.T) {
 	pemCertFile := writeFakeFile(pemCertificate)
 	defer syscall.Unlink(pemCertFile)
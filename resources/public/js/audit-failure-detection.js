@@ -23,7 +23,7 @@ $(document).ready(function() {
 
     hideLoader();
     auditEntries.forEach(function(audit) {
-      var analyzedInstanceDisplay = audit.AnalysisEntry.AnalyzedInstanceKey.Hostname + ":" + audit.AnalysisEntry.AnalyzedInstanceKey.Port;
+      var analyzedInstanceDisplay = displayInstanceKey(audit.AnalysisEntry.AnalyzedInstanceKey);
       var row = $('<tr/>');
       var analysisElement = $('<a class="more-detection-info"/>').attr("data-detection-id", audit.Id).text(audit.AnalysisEntry.Analysis);
 
@@ -21,9 +21,9 @@ $(document).ready(function () {
       		jQuery('<td/>', { text: audit.AuditType }).appendTo(row);
       		if (audit.AuditInstanceKey.Hostname) {
       			var uri = appUrl("/web/audit/instance/"+audit.AuditInstanceKey.Hostname+"/"+audit.AuditInstanceKey.Port);
-      			$('<a/>',  { text: audit.AuditInstanceKey.Hostname+":"+audit.AuditInstanceKey.Port , href: uri}).wrap($("<td/>")).parent().appendTo(row);
+      			$('<a/>',  { text: displayInstanceKey(audit.AuditInstanceKey) , href: uri}).wrap($("<td/>")).parent().appendTo(row);
       		} else {
-      			jQuery('<td/>', { text: audit.AuditInstanceKey.Hostname+":"+audit.AuditInstanceKey.Port }).appendTo(row);
+      			jQuery('<td/>', { text: displayInstanceKey(audit.AuditInstanceKey) }).appendTo(row);
       		}
       		jQuery('<td/>', { text: audit.Message }).appendTo(row);
       		row.appendTo('#audit tbody');
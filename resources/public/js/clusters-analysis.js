@@ -79,7 +79,7 @@ $(document).ready(function() {
 
     function displayAnalysisEntry(analysisEntry, popoverElement) {
       var blockedKey = getBlockedRecoveryKey(analysisEntry.AnalyzedInstanceKey.Hostname, analysisEntry.AnalyzedInstanceKey.Port, analysisEntry.Analysis);
-      var displayText = '<hr/><span><strong>' + analysisEntry.Analysis + (analysisEntry.IsDowntimed ? '<br/>[<i>downtime till ' + analysisEntry.DowntimeEndTimestamp + '</i>]' : '') + (blockedrecoveriesMap[blockedKey] ? '<br/><span class="glyphicon glyphicon-exclamation-sign text-danger"></span> Blocked' : '') + "</strong></span>" + "<br/>" + "<span>" + analysisEntry.AnalyzedInstanceKey.Hostname + ":" + analysisEntry.AnalyzedInstanceKey.Port + "</span>";
+      var displayText = '<hr/><span><strong>' + analysisEntry.Analysis + (analysisEntry.IsDowntimed ? '<br/>[<i>downtime till ' + analysisEntry.DowntimeEndTimestamp + '</i>]' : '') + (analysisEntry.IsClusterInMaintenance ? '<br/>[<i>cluster maintenance till ' + analysisEntry.ClusterDetails.MaintenanceEndTimestamp + '</i>]' : '') + (blockedrecoveriesMap[blockedKey] ? '<br/><span class="glyphicon glyphicon-exclamation-sign text-danger"></span> Blocked' : '') + "</strong></span>" + "<br/>" + "<span>" + displayInstanceKey(analysisEntry.AnalyzedInstanceKey) + "</span>";
       if (analysisEntry.IsDowntimed) {
         displayText = '<div class="downtimed">' + displayText + '</div>';
       } else if (blockedrecoveriesMap[blockedKey]) {
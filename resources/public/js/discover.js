@@ -24,8 +24,8 @@ function discover(hostname, port) {
             addAlert(operationResult.Message)
         } else {
         	var instance = operationResult.Details;
-            addInfo('Discovered <a href="' + appUrl('/web/search?s='+instance.Key.Hostname+":"+instance.Key.Port) + '" class="alert-link">'
-            		+instance.Key.Hostname+":"+instance.Key.Port+'</a>'
+            addInfo('Discovered <a href="' + appUrl('/web/search?s='+displayInstanceKey(instance.Key)) + '" class="alert-link">'
+            		+displayInstanceKey(instance.Key)+'</a>'
             	);
         }   
     }, "json"); 
@@ -119,7 +119,19 @@ function toHumanFormat(bytes) {
 }
 
 function getInstanceId(host, port) {
-  return "instance__" + host.replace(/[.]/g, "_") + "__" + port
+  return "instance__" + host.replace(/[.:%]/g, "_") + "__" + port
+}
+
+// formatHostPort brackets IPv6 addresses, as in [2001:db8::1]:3306
+function formatHostPort(host, port) {
+  if (host.indexOf(":") >= 0) {
+    return "[" + host + "]:" + port;
+  }
+  return host + ":" + port;
+}
+
+function displayInstanceKey(instanceKey) {
+  return formatHostPort(instanceKey.Hostname, instanceKey.Port);
 }
 
 
@@ -134,7 +146,7 @@ function getInstanceTitle(host, port) {
   if (host == "") {
     return "";
   }
-  return canonizeInstanceTitle(host + ":" + port);
+  return canonizeInstanceTitle(formatHostPort(host, port));
 }
 
 
@@ -406,7 +418,7 @@ function openNodeModal(node) {
     apiCommand("/api/reattach-replica-master-host/" + node.Key.Hostname + "/" + node.Key.Port);
   });
   $('#node_modal button[data-btn=reset-slave]').click(function() {
-    var message = "<p>Are you sure you wish to reset <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to reset <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>This will stop and break the replication." +
       "<p>FYI, this is a destructive operation that cannot be easily reverted";
@@ -418,7 +430,7 @@ function openNodeModal(node) {
     return false;
   });
   $('#node_modal [data-btn=gtid-errant-reset-master]').click(function() {
-    var message = "<p>Are you sure you wish to reset master on <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to reset master on <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>This will purge binary logs on server.";
     bootbox.confirm(message, function(confirm) {
@@ -444,7 +456,7 @@ function openNodeModal(node) {
     apiCommand("/api/set-writeable/" + node.Key.Hostname + "/" + node.Key.Port);
   });
   $('#node_modal button[data-btn=enable-gtid]').click(function() {
-    var message = "<p>Are you sure you wish to enable GTID on <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to enable GTID on <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>Replication <i>might</i> break as consequence";
     bootbox.confirm(message, function(confirm) {
@@ -454,7 +466,7 @@ function openNodeModal(node) {
     });
   });
   $('#node_modal button[data-btn=disable-gtid]').click(function() {
-    var message = "<p>Are you sure you wish to disable GTID on <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to disable GTID on <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>Replication <i>might</i> break as consequence";
     bootbox.confirm(message, function(confirm) {
@@ -464,7 +476,7 @@ function openNodeModal(node) {
     });
   });
   $('#node_modal button[data-btn=forget-instance]').click(function() {
-    var message = "<p>Are you sure you wish to forget <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to forget <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>It may be re-discovered if accessible from an existing instance through replication topology.";
     bootbox.confirm(message, function(confirm) {
@@ -536,7 +548,7 @@ function openNodeModal(node) {
     $('#node_modal button[data-btn=regroup-replicas]').show();
   }
   $('#node_modal button[data-btn=regroup-replicas]').click(function() {
-    var message = "<p>Are you sure you wish to regroup replicas of <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+    var message = "<p>Are you sure you wish to regroup replicas of <code><strong>" + displayInstanceKey(node.Key) +
       "</strong></code>?" +
       "<p>This will attempt to promote one replica over its siblings";
     bootbox.confirm(message, function(confirm) {
@@ -555,7 +567,7 @@ function openNodeModal(node) {
     if (isSilentUI()) {
       apiCommand(apiUrl);
     } else {
-      var message = "<p>Are you sure you want <code><strong>" + node.Key.Hostname + ":" + node.Key.Port +
+      var message = "<p>Are you sure you want <code><strong>" + displayInstanceKey(node.Key) +
         "</strong></code> to take its siblings?";
       bootbox.confirm(message, function(confirm) {
         if (confirm) {
@@ -595,7 +607,7 @@ function normalizeInstance(instance) {
   instance.id = getInstanceId(instance.Key.Hostname, instance.Key.Port);
   instance.title = instance.Key.Hostname + ':' + instance.Key.Port;
   instance.canonicalTitle = instance.title;
-  instance.masterTitle = instance.MasterKey.Hostname + ":" + instance.MasterKey.Port;
+  instance.masterTitle = displayInstanceKey(instance.MasterKey);
   instance.masterId = getInstanceId(instance.MasterKey.Hostname,
     instance.MasterKey.Port);
 